@@ -0,0 +1,83 @@
+package paperless
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// authCheckOperation names the request made by WithAuthCheck.
+const authCheckOperation = "AuthCheck"
+
+// WithAuthCheck verifies the API token before the first request. The token
+// is checked for obvious mistakes (empty, or containing whitespace such as a
+// trailing newline) and a single lightweight authenticated request is made.
+// If the server rejects the token with 401, that request and every later one
+// fail with an error matching ErrUnauthorized without contacting the server
+// again.
+//
+// Transport errors during the check are returned but not remembered, so the
+// check is retried on the next request.
+func WithAuthCheck() Option {
+	return func(client *Client) {
+		client.authCheck = true
+	}
+}
+
+// checkAuth runs the WithAuthCheck verification once per client.
+func (c *Client) checkAuth(ctx context.Context) error {
+	if !c.authCheck || operationFromContext(ctx) == authCheckOperation {
+		return nil
+	}
+
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	if c.authDone {
+		if c.authErr != nil {
+			// Return a copy: callers set Op on the error they receive.
+			err := *c.authErr
+			return &err
+		}
+		return nil
+	}
+
+	if err := validateToken(c.token); err != nil {
+		return err
+	}
+
+	fullURL, err := c.buildURL(tagsAPIPath, &ListOptions{PageSize: 1})
+	if err != nil {
+		return fmt.Errorf("build URL: %w", err)
+	}
+	ctx = context.WithValue(ctx, requestInfoKey{}, RequestInfo{
+		Operation: authCheckOperation,
+		Resource:  ResourceTags,
+	})
+	err = c.doRequestWithURL(ctx, "GET", fullURL, nil, nil)
+	if apiErr, ok := err.(*Error); ok {
+		// Any response other than 401 means the token was accepted; a 403
+		// only says the token cannot read tags.
+		if IsUnauthorized(apiErr) {
+			apiErr.Op = authCheckOperation
+			c.authDone, c.authErr = true, apiErr
+			copied := *apiErr
+			return &copied
+		}
+	} else if err != nil {
+		return wrapError(err, authCheckOperation)
+	}
+
+	c.authDone = true
+	return nil
+}
+
+// validateToken rejects tokens that cannot be valid without asking the server.
+func validateToken(token string) error {
+	if token == "" {
+		return fmt.Errorf("%s: %w: API token is empty", authCheckOperation, ErrUnauthorized)
+	}
+	if strings.ContainsAny(token, " \t\r\n") {
+		return fmt.Errorf("%s: %w: API token contains whitespace", authCheckOperation, ErrUnauthorized)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package paperless
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithAuthCheck_Unauthorized(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/api/tags/" || r.URL.Query().Get("page_size") != "1" {
+			t.Errorf("unexpected check request %s", r.URL)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"detail":"Invalid token."}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "bad-token", WithAuthCheck())
+	for i := 0; i < 3; i++ {
+		_, err := c.GetDocument(context.Background(), 1)
+		if !IsUnauthorized(err) {
+			t.Fatalf("call %d: expected unauthorized error, got %v", i, err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("server received %d requests, want 1", got)
+	}
+}
+
+func TestWithAuthCheck_Success(t *testing.T) {
+	var checks, gets atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags/":
+			checks.Add(1)
+			_ = json.NewEncoder(w).Encode(TagList{})
+		case "/api/documents/1/":
+			gets.Add(1)
+			_ = json.NewEncoder(w).Encode(Document{ID: 1})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token", WithAuthCheck())
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetDocument(context.Background(), 1); err != nil {
+			t.Fatalf("GetDocument failed: %v", err)
+		}
+	}
+	if checks.Load() != 1 || gets.Load() != 2 {
+		t.Errorf("checks = %d, gets = %d; want 1 and 2", checks.Load(), gets.Load())
+	}
+}
+
+func TestWithAuthCheck_ForbiddenAcceptsToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags/" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Document{ID: 1})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token", WithAuthCheck())
+	if _, err := c.GetDocument(context.Background(), 1); err != nil {
+		t.Fatalf("GetDocument failed: %v", err)
+	}
+}
+
+func TestWithAuthCheck_InvalidTokenFormat(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	for _, token := range []string{"", "abc123\n", "abc 123"} {
+		c := NewClient(server.URL, token, WithAuthCheck())
+		if _, err := c.ListTags(context.Background(), nil); !IsUnauthorized(err) {
+			t.Errorf("token %q: expected unauthorized error, got %v", token, err)
+		}
+	}
+	if called {
+		t.Error("request was sent despite invalid token")
+	}
+}
+
+func TestWithAuthCheck_Disabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/documents/1/" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Document{ID: 1})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "")
+	if _, err := c.GetDocument(context.Background(), 1); err != nil {
+		t.Fatalf("GetDocument failed: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package paperless
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultMaxResponseSize is the default limit on the size of a response body.
+const DefaultMaxResponseSize = 64 << 20
+
+// WithMaxResponseSize limits how many bytes of a response body the client
+// reads. Larger responses fail with an error matching ErrResponseTooLarge.
+// A value of 0 or less removes the limit.
+func WithMaxResponseSize(n int64) Option {
+	return func(client *Client) {
+		client.maxResponseSize = n
+	}
+}
+
+// limitedBody wraps r so reads fail once more than limit bytes arrive.
+func limitedBody(r io.Reader, limit int64) io.Reader {
+	if limit <= 0 {
+		return r
+	}
+	return &maxBytesReader{r: r, limit: limit, remaining: limit}
+}
+
+// maxBytesReader is a client-side counterpart of http.MaxBytesReader.
+type maxBytesReader struct {
+	r         io.Reader
+	limit     int64
+	remaining int64
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.remaining <= 0 {
+		// Only fail if the body really has more data.
+		var probe [1]byte
+		n, err := m.r.Read(probe[:])
+		if n > 0 {
+			return 0, fmt.Errorf("%w: exceeds %d bytes", ErrResponseTooLarge, m.limit)
+		}
+		return 0, err
+	}
+	if int64(len(p)) > m.remaining {
+		p = p[:m.remaining]
+	}
+	n, err := m.r.Read(p)
+	m.remaining -= int64(n)
+	return n, err
+}
+
+// shouldBuffer reports whether a successful response must be held in memory
+// so it can be stored in the response cache.
+func (c *Client) shouldBuffer(req *http.Request, resp *http.Response) bool {
+	if c.cache == nil || req.Method != http.MethodGet {
+		return false
+	}
+	return resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+// decodeBody stream-decodes a JSON body into result. With a nil result the
+// body is drained so the connection can be reused.
+func decodeBody(r io.Reader, result interface{}) error {
+	if result == nil {
+		_, _ = io.Copy(io.Discard, r)
+		return nil
+	}
+	if err := json.NewDecoder(r).Decode(result); err != nil {
+		if errors.Is(err, ErrResponseTooLarge) {
+			return fmt.Errorf("read response: %w", err)
+		}
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// decodeBytes decodes an already buffered JSON body into result.
+func decodeBytes(body []byte, result interface{}) error {
+	if result == nil {
+		return nil
+	}
+	return decodeBody(bytes.NewReader(body), result)
+}
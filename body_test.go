@@ -0,0 +1,87 @@
+package paperless
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithMaxResponseSize(t *testing.T) {
+	const body = `{"id":1,"name":"limited"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		limit   int64
+		wantErr bool
+	}{
+		{name: "under limit", limit: 1024},
+		{name: "exact limit", limit: int64(len(body))},
+		{name: "over limit", limit: 8, wantErr: true},
+		{name: "unlimited", limit: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(server.URL, "test-token", WithMaxResponseSize(tt.limit))
+			tag, err := c.GetTag(context.Background(), 1)
+			if tt.wantErr {
+				if !errors.Is(err, ErrResponseTooLarge) {
+					t.Fatalf("GetTag() error = %v, want ErrResponseTooLarge", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetTag() error = %v", err)
+			}
+			if tag.Name != "limited" {
+				t.Errorf("tag.Name = %q, want %q", tag.Name, "limited")
+			}
+		})
+	}
+}
+
+func TestWithMaxResponseSize_ErrorBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token", WithMaxResponseSize(10))
+	_, err := c.GetTag(context.Background(), 1)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("GetTag() error = %v, want ErrResponseTooLarge", err)
+	}
+}
+
+func TestWithMaxResponseSize_Cached(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"id":1,"name":"too long for the limit"}`))
+	}))
+	defer server.Close()
+
+	cache := NewMemoryCache()
+	c := NewClient(server.URL, "test-token", WithCache(cache), WithMaxResponseSize(8))
+	if _, err := c.GetTag(context.Background(), 1); !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("GetTag() error = %v, want ErrResponseTooLarge", err)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("cache has %d entries, want 0", cache.Len())
+	}
+}
+
+func TestNewClient_DefaultMaxResponseSize(t *testing.T) {
+	c := NewClient("http://localhost", "test-token")
+	if c.maxResponseSize != DefaultMaxResponseSize {
+		t.Errorf("maxResponseSize = %d, want %d", c.maxResponseSize, DefaultMaxResponseSize)
+	}
+}
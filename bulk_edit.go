@@ -0,0 +1,74 @@
+package paperless
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	bulkEditAPIPath      = documentsAPIPath + "bulk_edit/"
+	selectionDataAPIPath = documentsAPIPath + "selection_data/"
+)
+
+// BulkEditMethod is an operation of the documents bulk_edit endpoint.
+type BulkEditMethod string
+
+// Bulk edit methods. The parameters each method expects are described in
+// the Paperless-ngx API documentation; the common ones are noted here.
+const (
+	BulkSetCorrespondent BulkEditMethod = "set_correspondent" // {"correspondent": id or nil}
+	BulkSetDocumentType  BulkEditMethod = "set_document_type" // {"document_type": id or nil}
+	BulkSetStoragePath   BulkEditMethod = "set_storage_path"  // {"storage_path": id or nil}
+	BulkAddTag           BulkEditMethod = "add_tag"           // {"tag": id}
+	BulkRemoveTag        BulkEditMethod = "remove_tag"        // {"tag": id}
+	BulkModifyTags       BulkEditMethod = "modify_tags"       // {"add_tags": [ids], "remove_tags": [ids]}
+	BulkDelete           BulkEditMethod = "delete"            // no parameters
+	BulkReprocess        BulkEditMethod = "reprocess"         // no parameters
+)
+
+type bulkEditRequest struct {
+	Documents  []int                  `json:"documents"`
+	Method     BulkEditMethod         `json:"method"`
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
+// BulkEditDocuments applies method to the documents docIDs in a single
+// request. Paperless runs bulk edits as a background task, so changes may
+// not be visible immediately after BulkEditDocuments returns.
+func (c *Client) BulkEditDocuments(ctx context.Context, docIDs []int, method BulkEditMethod, params map[string]interface{}) error {
+	ctx = withOperation(ctx, "BulkEditDocuments", ResourceDocuments)
+	if len(docIDs) == 0 {
+		return fmt.Errorf("BulkEditDocuments: no documents given")
+	}
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+
+	req := &bulkEditRequest{Documents: docIDs, Method: method, Parameters: params}
+	if err := c.doRequest(ctx, "POST", bulkEditAPIPath, req, nil); err != nil {
+		return wrapError(err, "BulkEditDocuments")
+	}
+
+	return nil
+}
+
+type selectionDataRequest struct {
+	Documents []int `json:"documents"`
+}
+
+// GetSelectionData returns how many of the documents docIDs each
+// correspondent, tag, document type, storage path and custom field is
+// applied to, e.g. to show what a bulk edit would change.
+func (c *Client) GetSelectionData(ctx context.Context, docIDs []int) (*SelectionData, error) {
+	ctx = withOperation(ctx, "GetSelectionData", ResourceDocuments)
+	if len(docIDs) == 0 {
+		return nil, fmt.Errorf("GetSelectionData: no documents given")
+	}
+
+	var data SelectionData
+	if err := c.doRequest(ctx, "POST", selectionDataAPIPath, &selectionDataRequest{Documents: docIDs}, &data); err != nil {
+		return nil, wrapError(err, "GetSelectionData")
+	}
+
+	return &data, nil
+}
@@ -0,0 +1,151 @@
+package paperless
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestClient_BulkEditDocuments(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "POST" {
+				t.Errorf("method = %v, want POST", r.Method)
+			}
+			if r.URL.Path != "/api/documents/bulk_edit/" {
+				t.Errorf("path = %v, want /api/documents/bulk_edit/", r.URL.Path)
+			}
+			var body map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			want := map[string]interface{}{
+				"documents":  []interface{}{float64(3), float64(4)},
+				"method":     "set_correspondent",
+				"parameters": map[string]interface{}{"correspondent": float64(9)},
+			}
+			if !reflect.DeepEqual(body, want) {
+				t.Errorf("body = %v, want %v", body, want)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"result": "OK"}`))
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		err := c.BulkEditDocuments(context.Background(), []int{3, 4}, BulkSetCorrespondent, map[string]interface{}{"correspondent": 9})
+		if err != nil {
+			t.Fatalf("BulkEditDocuments failed: %v", err)
+		}
+	})
+
+	t.Run("no parameters", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]json.RawMessage
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if string(body["parameters"]) != "{}" {
+				t.Errorf("parameters = %s, want {}", body["parameters"])
+			}
+			_, _ = w.Write([]byte(`{"result": "OK"}`))
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		if err := c.BulkEditDocuments(context.Background(), []int{3}, BulkReprocess, nil); err != nil {
+			t.Fatalf("BulkEditDocuments failed: %v", err)
+		}
+	})
+
+	t.Run("no documents", func(t *testing.T) {
+		c := NewClient("http://paperless.invalid", "test-token")
+		if err := c.BulkEditDocuments(context.Background(), nil, BulkDelete, nil); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"method":["Unsupported"]}`))
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		err := c.BulkEditDocuments(context.Background(), []int{1}, "bogus", nil)
+		apiErr, ok := err.(*Error)
+		if !ok || apiErr.Op != "BulkEditDocuments" || apiErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected BulkEditDocuments 400, got %v", err)
+		}
+	})
+}
+
+func TestClient_GetSelectionData(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "POST" {
+				t.Errorf("method = %v, want POST", r.Method)
+			}
+			if r.URL.Path != "/api/documents/selection_data/" {
+				t.Errorf("path = %v, want /api/documents/selection_data/", r.URL.Path)
+			}
+			var body map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			want := map[string]interface{}{"documents": []interface{}{float64(3), float64(4)}}
+			if !reflect.DeepEqual(body, want) {
+				t.Errorf("body = %v, want %v", body, want)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{
+				"selected_correspondents": [{"id": 9, "document_count": 2}],
+				"selected_tags": [{"id": 1, "document_count": 2}, {"id": 5, "document_count": 1}],
+				"selected_document_types": [],
+				"selected_storage_paths": [{"id": 2, "document_count": 0}],
+				"selected_custom_fields": []
+			}`))
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		data, err := c.GetSelectionData(context.Background(), []int{3, 4})
+		if err != nil {
+			t.Fatalf("GetSelectionData failed: %v", err)
+		}
+		want := &SelectionData{
+			Correspondents: []SelectionCount{{ID: 9, DocumentCount: 2}},
+			Tags:           []SelectionCount{{ID: 1, DocumentCount: 2}, {ID: 5, DocumentCount: 1}},
+			DocumentTypes:  []SelectionCount{},
+			StoragePaths:   []SelectionCount{{ID: 2, DocumentCount: 0}},
+			CustomFields:   []SelectionCount{},
+		}
+		if !reflect.DeepEqual(data, want) {
+			t.Errorf("GetSelectionData = %+v, want %+v", data, want)
+		}
+	})
+
+	t.Run("no documents", func(t *testing.T) {
+		c := NewClient("http://paperless.invalid", "test-token")
+		if _, err := c.GetSelectionData(context.Background(), nil); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"documents":["Some documents don't exist"]}`))
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		_, err := c.GetSelectionData(context.Background(), []int{999})
+		apiErr, ok := err.(*Error)
+		if !ok || apiErr.Op != "GetSelectionData" || apiErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected GetSelectionData 400, got %v", err)
+		}
+	})
+}
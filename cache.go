@@ -0,0 +1,101 @@
+package paperless
+
+import (
+	"net/http"
+	"sync"
+)
+
+// CachedResponse is a response body stored with its validators.
+type CachedResponse struct {
+	ETag         string
+	LastModified string
+	Body         []byte
+}
+
+// ResponseCache stores GET response bodies keyed by request URL so the
+// Client can issue conditional requests. Implementations must be safe for
+// concurrent use. Because keys do not include the API token, a cache should
+// not be shared between clients that authenticate as different users.
+type ResponseCache interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse)
+}
+
+// WithCache enables conditional request caching. For GET requests the Client
+// sends If-None-Match / If-Modified-Since using the validators of a cached
+// response, and returns the cached body when the server replies 304 Not
+// Modified. Only responses carrying an ETag or Last-Modified header are stored.
+func WithCache(cache ResponseCache) Option {
+	return func(client *Client) {
+		client.cache = cache
+	}
+}
+
+// MemoryCache is an in-memory ResponseCache.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]*CachedResponse
+}
+
+// NewMemoryCache returns an empty in-memory cache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]*CachedResponse)}
+}
+
+// Get returns the cached response for key.
+func (m *MemoryCache) Get(key string) (*CachedResponse, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	resp, ok := m.entries[key]
+	return resp, ok
+}
+
+// Set stores resp under key.
+func (m *MemoryCache) Set(key string, resp *CachedResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = resp
+}
+
+// Len returns the number of cached responses.
+func (m *MemoryCache) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.entries)
+}
+
+// cachedResponseFor returns the cached entry to revalidate req with, and sets
+// the conditional request headers from it.
+func (c *Client) cachedResponseFor(req *http.Request) *CachedResponse {
+	if c.cache == nil || req.Method != http.MethodGet {
+		return nil
+	}
+	cached, ok := c.cache.Get(req.URL.String())
+	if !ok || cached == nil {
+		return nil
+	}
+	if cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	if cached.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.LastModified)
+	}
+	return cached
+}
+
+// storeResponse caches body if the response carries validators.
+func (c *Client) storeResponse(req *http.Request, resp *http.Response, body []byte) {
+	if c.cache == nil || req.Method != http.MethodGet {
+		return
+	}
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return
+	}
+	c.cache.Set(req.URL.String(), &CachedResponse{
+		ETag:         etag,
+		LastModified: lastModified,
+		Body:         body,
+	})
+}
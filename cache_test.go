@@ -0,0 +1,91 @@
+package paperless
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithCache_ETag(t *testing.T) {
+	var fullResponses int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddInt32(&fullResponses, 1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(TagList{Count: 1, Results: []Tag{{ID: 1, Name: "cached"}}})
+	}))
+	defer server.Close()
+
+	cache := NewMemoryCache()
+	c := NewClient(server.URL, "test-token", WithCache(cache))
+
+	for i := 0; i < 3; i++ {
+		tags, err := c.ListTags(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("ListTags #%d failed: %v", i, err)
+		}
+		if len(tags.Results) != 1 || tags.Results[0].Name != "cached" {
+			t.Fatalf("ListTags #%d returned %+v", i, tags)
+		}
+	}
+
+	if got := atomic.LoadInt32(&fullResponses); got != 1 {
+		t.Errorf("server sent %d full responses, want 1", got)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("cache has %d entries, want 1", cache.Len())
+	}
+}
+
+func TestWithCache_LastModified(t *testing.T) {
+	const lastModified = "Mon, 01 Jan 2024 00:00:00 GMT"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		_ = json.NewEncoder(w).Encode(Tag{ID: 2, Name: "dated"})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token", WithCache(NewMemoryCache()))
+	for i := 0; i < 2; i++ {
+		tag, err := c.GetTag(context.Background(), 2)
+		if err != nil {
+			t.Fatalf("GetTag #%d failed: %v", i, err)
+		}
+		if tag.Name != "dated" {
+			t.Fatalf("GetTag #%d name = %q, want dated", i, tag.Name)
+		}
+	}
+}
+
+func TestWithCache_SkipsUncacheable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			t.Errorf("unexpected conditional header on %s", r.Method)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_ = json.NewEncoder(w).Encode(Tag{ID: 3})
+	}))
+	defer server.Close()
+
+	cache := NewMemoryCache()
+	c := NewClient(server.URL, "test-token", WithCache(cache))
+	for i := 0; i < 2; i++ {
+		if _, err := c.CreateTag(context.Background(), &TagCreate{Name: "new"}); err != nil {
+			t.Fatalf("CreateTag failed: %v", err)
+		}
+	}
+	if cache.Len() != 0 {
+		t.Errorf("cache has %d entries after POST, want 0", cache.Len())
+	}
+}
@@ -0,0 +1,70 @@
+package paperless
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Capabilities describes what the Paperless server supports.
+type Capabilities struct {
+	Version    string // Paperless version from X-Version, e.g. "2.3.3"; empty if not sent
+	APIVersion int    // Highest API version from X-Api-Version; 0 if not sent
+	// Endpoints are the names listed by the API root, e.g. "custom_fields".
+	Endpoints map[string]bool
+}
+
+// CustomFields reports whether the server has custom fields (Paperless-ngx
+// 2.0 and later).
+func (c *Capabilities) CustomFields() bool {
+	return c.Endpoints["custom_fields"]
+}
+
+// Trash reports whether deleted documents are moved to a trash.
+func (c *Capabilities) Trash() bool {
+	return c.Endpoints["trash"]
+}
+
+// Capabilities probes the server's version and endpoints. The first
+// successful probe is cached on the client for its lifetime, so feature
+// checks in long-running programs cost one request in total; concurrent
+// callers wait for the same probe. Failed probes are not cached and are
+// retried by the next call.
+func (c *Client) Capabilities(ctx context.Context) (*Capabilities, error) {
+	ctx = withOperation(ctx, "Capabilities", "")
+
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+	if c.caps != nil {
+		return c.caps, nil
+	}
+
+	fullURL, err := c.buildURL("/api/", nil)
+	if err != nil {
+		return nil, fmt.Errorf("build URL: %w", err)
+	}
+	var root map[string]interface{}
+	capture := &headerCapture{result: &root}
+	if err := c.doRequestWithURL(ctx, "GET", fullURL, nil, capture); err != nil {
+		return nil, wrapError(err, "Capabilities")
+	}
+
+	caps := &Capabilities{
+		Version:   capture.header.Get("X-Version"),
+		Endpoints: make(map[string]bool, len(root)),
+	}
+	caps.APIVersion, _ = strconv.Atoi(capture.header.Get("X-Api-Version"))
+	for name := range root {
+		caps.Endpoints[name] = true
+	}
+	c.caps = caps
+	return caps, nil
+}
+
+// headerCapture is a request result that records the response headers and
+// decodes the body into result.
+type headerCapture struct {
+	header http.Header
+	result interface{}
+}
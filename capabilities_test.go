@@ -0,0 +1,67 @@
+package paperless
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_Capabilities(t *testing.T) {
+	var probes atomic.Int32
+	var fail atomic.Bool
+	fail.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/" {
+			t.Errorf("path = %v, want /api/", r.URL.Path)
+		}
+		probes.Add(1)
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Version", "2.3.3")
+		w.Header().Set("X-Api-Version", "5")
+		w.Write([]byte(`{"documents": "http://x/api/documents/", "custom_fields": "http://x/api/custom_fields/"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	if _, err := c.Capabilities(context.Background()); err == nil {
+		t.Fatal("expected the failed probe to return an error")
+	}
+
+	// A failed probe is not cached
+	fail.Store(false)
+	probes.Store(0)
+	var wg sync.WaitGroup
+	results := make([]*Capabilities, 20)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			caps, err := c.Capabilities(context.Background())
+			if err != nil {
+				t.Errorf("Capabilities failed: %v", err)
+			}
+			results[i] = caps
+		}(i)
+	}
+	wg.Wait()
+
+	if n := probes.Load(); n != 1 {
+		t.Errorf("server probed %d times, want 1", n)
+	}
+	caps := results[0]
+	if caps.Version != "2.3.3" || caps.APIVersion != 5 || !caps.CustomFields() || caps.Trash() {
+		t.Errorf("caps = %+v", caps)
+	}
+	for _, other := range results[1:] {
+		if other != caps {
+			t.Fatal("concurrent callers got different capabilities")
+		}
+	}
+}
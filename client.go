@@ -6,9 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -17,6 +20,37 @@ type Client struct {
 	baseURL    string
 	token      string
 	httpClient *http.Client
+	transport  *http.Transport // default transport, tuned by transport options
+	logger     *slog.Logger
+	metrics    Recorder
+	cache      ResponseCache
+
+	maxResponseSize int64
+
+	requestHooks  []RequestHook
+	responseHooks []ResponseHook
+
+	maxRetries        int
+	idempotencyKeys   bool
+	backoffMultiplier float64
+
+	requestInterval time.Duration
+	paceMu          sync.Mutex
+	nextRequest     time.Time
+
+	sharedRatePath string // State file of WithSharedRateLimit
+	sharedInterval time.Duration
+
+	authCheck bool
+	authMu    sync.Mutex
+	authDone  bool
+	authErr   *Error
+
+	capsMu sync.Mutex
+	caps   *Capabilities
+
+	pins   [][]byte // SHA-256 fingerprints of pinned certificates
+	pinErr error    // set by a malformed pin
 }
 
 // Option configures a Client.
@@ -36,16 +70,31 @@ func WithTimeout(d time.Duration) Option {
 	}
 }
 
+// WithLogger enables request logging. Each request is logged at debug level
+// with its method, path, status and latency. The API token is never logged,
+// and query parameter values other than pagination and ordering are redacted.
+func WithLogger(l *slog.Logger) Option {
+	return func(client *Client) {
+		client.logger = l
+	}
+}
+
 // NewClient creates a new Paperless-ngx API client.
 // baseURL is the Paperless instance URL (e.g., "http://localhost:8000").
 // token is the API authentication token.
+// Unless WithHTTPClient is given, requests use a pooled transport with a 30
+// second timeout; see WithMaxIdleConnsPerHost and related options.
 func NewClient(baseURL, token string, opts ...Option) *Client {
+	transport := newTransport()
 	c := &Client{
 		baseURL: baseURL,
 		token:   token,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: transport,
 		},
+		transport:       transport,
+		maxResponseSize: DefaultMaxResponseSize,
 	}
 
 	for _, opt := range opts {
@@ -90,20 +139,23 @@ func (c *Client) buildURL(path string, opts *ListOptions) (string, error) {
 	if opts != nil {
 		q := u.Query()
 		if opts.Page > 0 {
-			q.Set("page", strconv.Itoa(opts.Page))
+			q.Set(ParamPage, strconv.Itoa(opts.Page))
 		}
 		if opts.PageSize > 0 {
-			q.Set("page_size", strconv.Itoa(opts.PageSize))
+			q.Set(ParamPageSize, strconv.Itoa(opts.PageSize))
 		}
 		if opts.Query != "" {
 			if opts.TitleOnly && path == documentsAPIPath {
-				q.Set("title__icontains", opts.Query)
+				q.Set(ParamTitleContains, opts.Query)
 			} else {
-				q.Set("query", opts.Query)
+				q.Set(ParamQuery, opts.Query)
 			}
 		}
 		if opts.Ordering != "" {
-			q.Set("ordering", opts.Ordering)
+			q.Set(ParamOrdering, opts.Ordering)
+		}
+		if path == documentsAPIPath {
+			setDocumentFilters(q, opts)
 		}
 		u.RawQuery = q.Encode()
 	}
@@ -111,16 +163,90 @@ func (c *Client) buildURL(path string, opts *ListOptions) (string, error) {
 	return u.String(), nil
 }
 
+// setDocumentFilters adds the document-only filters from opts to q.
+func setDocumentFilters(q url.Values, opts *ListOptions) {
+	if opts.TagName != "" {
+		q.Set(ParamTagNameIs, opts.TagName)
+	}
+	if len(opts.TagIDs) > 0 {
+		q.Set(ParamTagIDsAll, joinInts(opts.TagIDs))
+	}
+	if len(opts.CorrespondentIDs) > 0 {
+		q.Set(ParamCorrespondentIDsIn, joinInts(opts.CorrespondentIDs))
+	}
+	if len(opts.DocumentTypeIDs) > 0 {
+		q.Set(ParamDocumentTypeIDsIn, joinInts(opts.DocumentTypeIDs))
+	}
+	if !opts.CreatedAfter.IsZero() {
+		q.Set(ParamCreatedAfter, opts.CreatedAfter.Format("2006-01-02"))
+	}
+	if !opts.CreatedBefore.IsZero() {
+		q.Set(ParamCreatedBefore, opts.CreatedBefore.Format("2006-01-02"))
+	}
+	if !opts.CreatedSince.IsZero() {
+		q.Set(ParamCreatedSince, opts.CreatedSince.Format("2006-01-02"))
+	}
+	if !opts.ModifiedSince.IsZero() {
+		q.Set(ParamModifiedSince, opts.ModifiedSince.Format(time.RFC3339))
+	}
+	if opts.ArchiveSerialNumber != 0 {
+		q.Set(ParamArchiveSerialNumber, strconv.FormatInt(opts.ArchiveSerialNumber, 10))
+	}
+	if opts.AfterID > 0 {
+		q.Set(ParamIDGreaterThan, strconv.Itoa(opts.AfterID))
+	}
+	if len(opts.IDs) > 0 {
+		q.Set(ParamIDsIn, joinInts(opts.IDs))
+	}
+}
+
+// joinInts formats IDs as a comma-separated list.
+func joinInts(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+// rawBody is a pre-encoded request body, such as a multipart form, that
+// doRequestWithURL sends as-is instead of encoding it as JSON.
+type rawBody struct {
+	contentType string
+	data        []byte
+}
+
 // doRequestWithURL performs an HTTP request using a full URL and decodes the JSON response.
 // This is the common helper function used by both doRequest and direct calls.
-func (c *Client) doRequestWithURL(ctx context.Context, method, fullURL string, body interface{}, result interface{}) error {
-	var bodyReader io.Reader
-	if body != nil {
+func (c *Client) doRequestWithURL(ctx context.Context, method, fullURL string, body interface{}, result interface{}) (err error) {
+	if err := c.checkAuth(ctx); err != nil {
+		return err
+	}
+
+	var (
+		start  = time.Now()
+		status int
+	)
+	defer func() {
+		c.recordMetrics(ctx, method, status, time.Since(start), err)
+	}()
+
+	var (
+		bodyReader  io.Reader
+		contentType string
+	)
+	switch b := body.(type) {
+	case nil:
+	case *rawBody:
+		bodyReader = bytes.NewReader(b.data)
+		contentType = b.contentType
+	default:
 		jsonBody, err := json.Marshal(body)
 		if err != nil {
 			return fmt.Errorf("marshal request body: %w", err)
 		}
 		bodyReader = bytes.NewBuffer(jsonBody)
+		contentType = "application/json"
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, fullURL, bodyReader)
@@ -128,37 +254,117 @@ func (c *Client) doRequestWithURL(ctx context.Context, method, fullURL string, b
 		return fmt.Errorf("create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Token "+c.token)
-	req.Header.Set("Accept", "application/json")
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+	// Without a token, as for RequestToken, the request is anonymous: an
+	// empty "Token" header would be rejected
+	if c.token != "" {
+		req.Header.Set("Authorization", "Token "+c.token)
+	}
+	if isFile(result) {
+		req.Header.Set("Accept", "*/*")
+	} else {
+		req.Header.Set("Accept", "application/json")
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if err := c.setIdempotencyKey(req); err != nil {
+		return fmt.Errorf("create idempotency key: %w", err)
+	}
+	cached := c.cachedResponseFor(req)
+	if err := c.runRequestHooks(req); err != nil {
+		return err
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
+		c.logRequest(ctx, req, 0, time.Since(start), err)
 		return fmt.Errorf("do request: %w", err)
 	}
+	status = resp.StatusCode
+	c.logRequest(ctx, req, resp.StatusCode, time.Since(start), nil)
+	origBody := resp.Body
 	defer func() {
-		_ = resp.Body.Close()
+		// Response hooks may replace the body; close both readers.
+		_ = origBody.Close()
+		if resp.Body != origBody {
+			_ = resp.Body.Close()
+		}
 	}()
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("read response: %w", err)
+	if err := c.runResponseHooks(resp); err != nil {
+		return err
 	}
+	if capture, ok := result.(*headerCapture); ok {
+		capture.header = resp.Header.Clone()
+		result = capture.result
+	}
+
+	reader := limitedBody(resp.Body, c.maxResponseSize)
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return decodeBytes(cached.Body, result)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		respBody, err := io.ReadAll(reader)
+		if err != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
 		return &Error{
 			StatusCode: resp.StatusCode,
 			Message:    string(respBody),
 		}
+	case isFile(result):
+		return readFile(resp, reader, result.(*File))
+	case c.shouldBuffer(req, resp):
+		respBody, err := io.ReadAll(reader)
+		if err != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
+		c.storeResponse(req, resp, respBody)
+		return decodeBytes(respBody, result)
+	default:
+		return decodeBody(reader, result)
 	}
+}
 
-	if result != nil {
-		if err := json.Unmarshal(respBody, result); err != nil {
-			return fmt.Errorf("decode response: %w", err)
-		}
+// safeQueryParams lists query parameters whose values are logged verbatim.
+// All other values may contain user data and are redacted.
+var safeQueryParams = map[string]bool{
+	"page":      true,
+	"page_size": true,
+	"ordering":  true,
+}
+
+// logRequest logs a completed request if a logger is configured.
+func (c *Client) logRequest(ctx context.Context, req *http.Request, status int, latency time.Duration, err error) {
+	if c.logger == nil {
+		return
 	}
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.Duration("latency", latency),
+	}
+	if req.URL.RawQuery != "" {
+		attrs = append(attrs, slog.String("query", redactQuery(req.URL.Query())))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	} else {
+		attrs = append(attrs, slog.Int("status", status))
+	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "paperless request", attrs...)
+}
 
-	return nil
+// redactQuery encodes query parameters with non-allowlisted values redacted.
+func redactQuery(q url.Values) string {
+	redacted := make(url.Values, len(q))
+	for key, values := range q {
+		if safeQueryParams[key] {
+			redacted[key] = values
+			continue
+		}
+		redacted[key] = []string{"REDACTED"}
+	}
+	return redacted.Encode()
 }
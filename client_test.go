@@ -1,10 +1,13 @@
 package paperless
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -192,6 +195,35 @@ func TestClient_buildURL(t *testing.T) {
 			},
 			want: "http://localhost:8000/api/documents/?ordering=-created&page=2&page_size=50&query=test",
 		},
+		{
+			name: "document filters",
+			path: "/api/documents/",
+			opts: &ListOptions{
+				TagName:             "tax",
+				TagIDs:              []int{1, 2},
+				CorrespondentIDs:    []int{7, 9},
+				DocumentTypeIDs:     []int{3},
+				CreatedAfter:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				CreatedBefore:       time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+				ArchiveSerialNumber: 42,
+			},
+			want: "http://localhost:8000/api/documents/?archive_serial_number=42&correspondent__id__in=7%2C9&created__date__gt=2024-01-01&created__date__lt=2024-06-30&document_type__id__in=3&tags__id__all=1%2C2&tags__name__iexact=tax",
+		},
+		{
+			name: "since filters",
+			path: "/api/documents/",
+			opts: &ListOptions{
+				CreatedSince:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				ModifiedSince: time.Date(2024, 3, 5, 22, 30, 0, 0, time.FixedZone("", 2*60*60)),
+			},
+			want: "http://localhost:8000/api/documents/?created__date__gte=2024-01-01&modified__gte=2024-03-05T22%3A30%3A00%2B02%3A00",
+		},
+		{
+			name: "document filters ignored for tags",
+			path: "/api/tags/",
+			opts: &ListOptions{TagName: "tax", TagIDs: []int{1}},
+			want: "http://localhost:8000/api/tags/",
+		},
 	}
 
 	for _, tt := range tests {
@@ -207,3 +239,45 @@ func TestClient_buildURL(t *testing.T) {
 		})
 	}
 }
+
+func TestWithLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(DocumentList{})
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c := NewClient(server.URL, "secret-token", WithLogger(logger))
+	_, err := c.ListDocuments(context.Background(), &ListOptions{Query: "tax return", Page: 2})
+	if err != nil {
+		t.Fatalf("ListDocuments failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"method=GET", "path=/api/documents/", "status=200", "latency=", "page=2", "query=REDACTED"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log output missing %q: %s", want, out)
+		}
+	}
+	for _, secret := range []string{"secret-token", "tax"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log output leaked %q: %s", secret, out)
+		}
+	}
+}
+
+func TestWithLogger_TransportError(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c := NewClient("http://127.0.0.1:1", "secret-token", WithLogger(logger))
+	if _, err := c.GetDocument(context.Background(), 1); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(buf.String(), "error=") {
+		t.Errorf("expected error attribute in log output: %s", buf.String())
+	}
+}
@@ -0,0 +1,164 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/embedding"
+)
+
+// Client is an HTTP client for an OpenAI-compatible chat completions API or
+// Ollama's native chat API.
+type Client struct {
+	apiKey  string
+	model   string
+	baseURL string
+	ollama  bool
+	client  *http.Client
+}
+
+// API is the chat API a Client speaks.
+type API string
+
+// Chat APIs. APIAuto picks one from the base URL, see NewClient.
+const (
+	APIAuto   API = "auto"
+	APIOpenAI API = "openai" // OpenAI-compatible /chat/completions
+	APIOllama API = "ollama" // Ollama's native /api/chat
+)
+
+// ParseAPI parses a chat API name; an empty name is APIAuto.
+func ParseAPI(name string) (API, error) {
+	switch api := API(strings.ToLower(strings.TrimSpace(name))); api {
+	case "", APIAuto:
+		return APIAuto, nil
+	case APIOpenAI, APIOllama:
+		return api, nil
+	}
+	return "", fmt.Errorf("unknown chat API %q (want auto, openai or ollama)", name)
+}
+
+// NewClient creates a new chat client with the provided base URL. An Ollama
+// base URL without the OpenAI-compatible /v1 suffix (for example
+// http://localhost:11434 or http://localhost:11434/api) uses Ollama's native
+// /api/chat endpoint; any other URL is treated as OpenAI-compatible. Use
+// NewClientWithAPI where the URL is misleading, e.g. for an
+// OpenAI-compatible gateway mounted at /api.
+func NewClient(baseURL, apiKey, model string) *Client {
+	return NewClientWithAPI(baseURL, apiKey, model, APIAuto)
+}
+
+// NewClientWithAPI creates a new chat client that speaks api, or detects it
+// from the base URL like NewClient with APIAuto.
+func NewClientWithAPI(baseURL, apiKey, model string, api API) *Client {
+	baseURL = strings.TrimRight(baseURL, "/")
+	ollama := api == APIOllama
+	if api == APIAuto || api == "" {
+		ollama = isOllamaNative(baseURL)
+	}
+	return &Client{
+		apiKey:  apiKey,
+		model:   model,
+		baseURL: baseURL,
+		ollama:  ollama,
+		client:  &http.Client{Timeout: 120 * time.Second},
+	}
+}
+
+// isOllamaNative reports whether baseURL looks like Ollama's native API.
+func isOllamaNative(baseURL string) bool {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	if strings.HasSuffix(u.Path, "/api") {
+		return true
+	}
+	return embedding.DetectProvider(baseURL) == embedding.ProviderOllama && !strings.HasSuffix(u.Path, "/v1")
+}
+
+// endpoint returns the chat URL and request body for messages.
+func (c *Client) endpoint(messages []Message) (string, interface{}) {
+	if !c.ollama {
+		return c.baseURL + "/chat/completions", CompletionRequest{Model: c.model, Messages: messages}
+	}
+	chatURL := c.baseURL + "/api/chat"
+	if strings.HasSuffix(c.baseURL, "/api") {
+		chatURL = c.baseURL + "/chat"
+	}
+	return chatURL, OllamaChatRequest{Model: c.model, Messages: messages}
+}
+
+// Complete sends the conversation and returns the assistant's reply. The API
+// key is optional so local servers can be used without one.
+func (c *Client) Complete(ctx context.Context, messages []Message) (string, error) {
+	if strings.TrimSpace(c.baseURL) == "" {
+		return "", fmt.Errorf("base URL is required")
+	}
+	if strings.TrimSpace(c.model) == "" {
+		return "", fmt.Errorf("model is required")
+	}
+
+	chatURL, payload := c.endpoint(messages)
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", chatURL, bytes.NewReader(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+			return "", fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Error.Message)
+		}
+		var ollamaErr OllamaErrorResponse
+		if err := json.Unmarshal(body, &ollamaErr); err == nil && ollamaErr.Error != "" {
+			return "", fmt.Errorf("API error (%d): %s", resp.StatusCode, ollamaErr.Error)
+		}
+		return "", fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if c.ollama {
+		var ollamaResp OllamaChatResponse
+		if err := json.Unmarshal(body, &ollamaResp); err != nil {
+			return "", fmt.Errorf("failed to decode response: %w", err)
+		}
+		return ollamaResp.Message.Content, nil
+	}
+
+	var completion CompletionResponse
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return "", fmt.Errorf("no choices in response")
+	}
+
+	return completion.Choices[0].Message.Content, nil
+}
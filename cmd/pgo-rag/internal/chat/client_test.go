@@ -0,0 +1,185 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompleteSuccess(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("Expected path /chat/completions, got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Expected bearer auth, got %q", r.Header.Get("Authorization"))
+		}
+
+		var req CompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Model != "test-model" || len(req.Messages) != 2 {
+			t.Errorf("Unexpected request: %+v", req)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"The answer [1]"}}]}`))
+	}))
+	defer server.Close()
+
+	var client = NewClient(server.URL+"/", "test-key", "test-model")
+	var answer, err = client.Complete(context.Background(), []Message{
+		{Role: "system", Content: "be brief"},
+		{Role: "user", Content: "question"},
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if answer != "The answer [1]" {
+		t.Errorf("Expected answer 'The answer [1]', got '%s'", answer)
+	}
+}
+
+func TestCompleteWithoutKey(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("Expected no Authorization header, got %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	var client = NewClient(server.URL, "", "local")
+	if _, err := client.Complete(context.Background(), []Message{{Role: "user", Content: "hi"}}); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+}
+
+func TestCompleteAPIError(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"unknown model","type":"invalid_request_error"}}`))
+	}))
+	defer server.Close()
+
+	var client = NewClient(server.URL, "key", "missing")
+	var _, err = client.Complete(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	if err == nil || !strings.Contains(err.Error(), "unknown model") {
+		t.Fatalf("Expected API error message, got %v", err)
+	}
+}
+
+func TestCompleteRequiresModel(t *testing.T) {
+	var client = NewClient("http://localhost:9999", "key", "")
+	if _, err := client.Complete(context.Background(), nil); err == nil {
+		t.Fatal("Expected error for missing model")
+	}
+}
+
+func TestCompleteOllamaNative(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("Expected path /api/chat, got %s", r.URL.Path)
+		}
+
+		var req OllamaChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Stream {
+			t.Error("Expected non-streaming request")
+		}
+		if req.Model != "llama3.2" {
+			t.Errorf("Expected model llama3.2, got %s", req.Model)
+		}
+
+		w.Write([]byte(`{"model":"llama3.2","message":{"role":"assistant","content":"local answer"},"done":true}`))
+	}))
+	defer server.Close()
+
+	var client = NewClient(server.URL+"/api", "", "llama3.2")
+	var answer, err = client.Complete(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if answer != "local answer" {
+		t.Errorf("Expected 'local answer', got '%s'", answer)
+	}
+}
+
+func TestCompleteOllamaError(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model \"missing\" not found"}`))
+	}))
+	defer server.Close()
+
+	var client = NewClient(server.URL+"/api", "", "missing")
+	var _, err = client.Complete(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("Expected Ollama error message, got %v", err)
+	}
+}
+
+func TestIsOllamaNative(t *testing.T) {
+	var tests = []struct {
+		url  string
+		want bool
+	}{
+		{url: "http://localhost:11434", want: true},
+		{url: "http://localhost:11434/api", want: true},
+		{url: "http://ollama.lan:8080", want: true},
+		{url: "http://localhost:11434/v1", want: false},
+		{url: "https://api.openai.com/v1", want: false},
+		{url: "https://openrouter.ai/api/v1", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isOllamaNative(tt.url); got != tt.want {
+			t.Errorf("isOllamaNative(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestNewClientWithAPI(t *testing.T) {
+	var paths []string
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/chat/completions") {
+			w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"ok"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"ok"},"done":true}`))
+	}))
+	defer server.Close()
+
+	// An OpenAI-compatible gateway mounted at /api, such as Open WebUI, and
+	// Ollama on a URL that doesn't look like it
+	for _, client := range []*Client{
+		NewClientWithAPI(server.URL+"/api", "key", "model", APIOpenAI),
+		NewClientWithAPI(server.URL, "", "model", APIOllama),
+		NewClientWithAPI(server.URL+"/api", "", "model", APIAuto),
+	} {
+		if _, err := client.Complete(context.Background(), []Message{{Role: "user", Content: "hi"}}); err != nil {
+			t.Fatalf("Complete failed: %v", err)
+		}
+	}
+	if want := "/api/chat/completions /api/chat /api/chat"; strings.Join(paths, " ") != want {
+		t.Errorf("paths = %v, want %s", paths, want)
+	}
+}
+
+func TestParseAPI(t *testing.T) {
+	for name, want := range map[string]API{"": APIAuto, "auto": APIAuto, "OpenAI": APIOpenAI, "ollama": APIOllama} {
+		if got, err := ParseAPI(name); err != nil || got != want {
+			t.Errorf("ParseAPI(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseAPI("anthropic"); err == nil {
+		t.Error("Expected an error for an unknown API")
+	}
+}
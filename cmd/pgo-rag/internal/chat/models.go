@@ -0,0 +1,50 @@
+package chat
+
+// Message is a single chat message.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// CompletionRequest represents a request to an OpenAI-compatible chat completions API
+type CompletionRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature,omitempty"`
+}
+
+// CompletionResponse represents a response from an OpenAI-compatible chat completions API
+type CompletionResponse struct {
+	Choices []struct {
+		Index   int     `json:"index"`
+		Message Message `json:"message"`
+	} `json:"choices"`
+	Model string `json:"model"`
+}
+
+// ErrorResponse represents an error response from the API
+type ErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// OllamaChatRequest represents a request to Ollama's native /api/chat endpoint
+type OllamaChatRequest struct {
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	Stream   bool      `json:"stream"`
+}
+
+// OllamaChatResponse represents a non-streaming response from Ollama's /api/chat endpoint
+type OllamaChatResponse struct {
+	Model   string  `json:"model"`
+	Message Message `json:"message"`
+	Done    bool    `json:"done"`
+}
+
+// OllamaErrorResponse represents an error response from the Ollama API
+type OllamaErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,93 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Tokenizer counts tokens with a model's own tokenizer through a tokenize
+// endpoint in llama.cpp's format: it posts {"content": text} and reads the
+// length of the "tokens" array, or "count" where a server returns one.
+type Tokenizer struct {
+	url    string
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+// TokenizeRequest is the body of a tokenize request.
+type TokenizeRequest struct {
+	Model   string `json:"model,omitempty"`
+	Content string `json:"content"`
+}
+
+// TokenizeResponse is the body of a tokenize response.
+type TokenizeResponse struct {
+	Tokens []json.RawMessage `json:"tokens"`
+	Count  *int              `json:"count"`
+}
+
+// NewTokenizer creates a tokenizer for the endpoint at url, such as
+// http://localhost:8080/tokenize for llama.cpp's server. The API key and
+// model are optional and only sent when set.
+func NewTokenizer(url, apiKey, model string) *Tokenizer {
+	return &Tokenizer{
+		url:    strings.TrimSpace(url),
+		apiKey: apiKey,
+		model:  model,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// CountTokens returns the number of tokens the model uses for text.
+func (t *Tokenizer) CountTokens(ctx context.Context, text string) (int, error) {
+	if t.url == "" {
+		return 0, fmt.Errorf("tokenize URL is required")
+	}
+
+	jsonData, err := json.Marshal(TokenizeRequest{Model: t.model, Content: text})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", t.url, bytes.NewReader(jsonData))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tokens TokenizeResponse
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if tokens.Count != nil {
+		return *tokens.Count, nil
+	}
+	if tokens.Tokens == nil {
+		return 0, fmt.Errorf("no tokens in response")
+	}
+	return len(tokens.Tokens), nil
+}
@@ -0,0 +1,48 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTokenizerCountTokens(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req TokenizeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		switch r.URL.Path {
+		case "/tokenize":
+			if req.Content != "hello world" || req.Model != "llama3.2" {
+				t.Errorf("Unexpected request: %+v", req)
+			}
+			w.Write([]byte(`{"tokens":[15339,1917]}`))
+		case "/count":
+			w.Write([]byte(`{"count":7,"tokens":[]}`))
+		case "/empty":
+			w.Write([]byte(`{}`))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var n, err = NewTokenizer(server.URL+"/tokenize", "", "llama3.2").CountTokens(context.Background(), "hello world")
+	if err != nil || n != 2 {
+		t.Fatalf("CountTokens = %d, %v; want 2", n, err)
+	}
+	if n, err := NewTokenizer(server.URL+"/count", "", "").CountTokens(context.Background(), "x"); err != nil || n != 7 {
+		t.Fatalf("CountTokens with count = %d, %v; want 7", n, err)
+	}
+	for _, path := range []string{"/empty", "/missing"} {
+		if _, err := NewTokenizer(server.URL+path, "", "").CountTokens(context.Background(), "x"); err == nil {
+			t.Errorf("expected error for %s", path)
+		}
+	}
+	if _, err := NewTokenizer("", "", "").CountTokens(context.Background(), "x"); err == nil {
+		t.Error("expected error without URL")
+	}
+}
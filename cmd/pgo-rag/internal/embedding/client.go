@@ -28,9 +28,23 @@ func NewClient(baseURL, apiKey, model string) *Client {
 	}
 }
 
-// GenerateEmbedding generates an embedding vector for the given text
+// Model returns the embeddings model the client requests.
+func (c *Client) Model() string {
+	return c.model
+}
+
+// EmbedWithModel generates an embedding for text with model instead of the
+// client's own, e.g. to embed a query for chunks indexed by an older model.
+func (c *Client) EmbedWithModel(model, text string) ([]float32, error) {
+	other := *c
+	other.model = model
+	return other.GenerateEmbedding(text)
+}
+
+// GenerateEmbedding generates an embedding vector for the given text.
+// The API key may be omitted for a local Ollama server.
 func (c *Client) GenerateEmbedding(text string) ([]float32, error) {
-	if strings.TrimSpace(c.apiKey) == "" {
+	if strings.TrimSpace(c.apiKey) == "" && DetectProvider(c.baseURL) != ProviderOllama {
 		return nil, fmt.Errorf("api key is required")
 	}
 	if strings.TrimSpace(c.baseURL) == "" {
@@ -64,7 +78,9 @@ func (c *Client) GenerateEmbedding(text string) ([]float32, error) {
 			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
 
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		if c.apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		}
 		req.Header.Set("Content-Type", "application/json")
 
 		resp, lastErr = c.client.Do(req)
@@ -117,3 +133,91 @@ func (c *Client) GenerateEmbedding(text string) ([]float32, error) {
 
 	return embeddingResp.Data[0].Embedding, nil
 }
+
+// ListModels returns the embedding-capable models offered by the API.
+// Models are recognized by their declared output modality when the provider
+// reports one, and otherwise by name. The API key is optional so local
+// servers such as Ollama can be queried without one.
+func (c *Client) ListModels() ([]ModelInfo, error) {
+	if strings.TrimSpace(c.baseURL) == "" {
+		return nil, fmt.Errorf("base URL is required")
+	}
+
+	req, err := http.NewRequest("GET", c.baseURL+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var modelsResp ModelsResponse
+	if err := json.Unmarshal(body, &modelsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	models := make([]ModelInfo, 0, len(modelsResp.Data))
+	for _, m := range modelsResp.Data {
+		embeds := strings.Contains(strings.ToLower(m.ID), "embed") || KnownDimensions(m.ID) > 0
+		if m.Architecture != nil && len(m.Architecture.OutputModalities) > 0 {
+			embeds = false
+			for _, modality := range m.Architecture.OutputModalities {
+				if strings.HasPrefix(modality, "embedding") {
+					embeds = true
+				}
+			}
+		}
+		if !embeds {
+			continue
+		}
+		models = append(models, ModelInfo{
+			ID:         m.ID,
+			Name:       m.Name,
+			Dimensions: KnownDimensions(m.ID),
+		})
+	}
+	return models, nil
+}
+
+// Ping checks that the API is reachable and accepts the API key. It requests
+// the model list, which unlike an embedding costs nothing. Servers without a
+// model list still count as reachable.
+func (c *Client) Ping() error {
+	if strings.TrimSpace(c.baseURL) == "" {
+		return fmt.Errorf("base URL is required")
+	}
+
+	req, err := http.NewRequest("GET", c.baseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode >= 500 {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -100,6 +100,33 @@ func TestGenerateEmbeddingSuccess(t *testing.T) {
 	}
 }
 
+func TestEmbedWithModel(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req EmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Model != "old-model" {
+			t.Errorf("Expected model 'old-model', got '%s'", req.Model)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": [{"embedding": [0.5], "index": 0}]}`))
+	}))
+	defer server.Close()
+
+	var client = NewClient(server.URL, "test-key", "new-model")
+	var vector, err = client.EmbedWithModel("old-model", "query")
+	if err != nil {
+		t.Fatalf("EmbedWithModel failed: %v", err)
+	}
+	if len(vector) != 1 || vector[0] != 0.5 {
+		t.Errorf("Unexpected vector %v", vector)
+	}
+	if client.Model() != "new-model" {
+		t.Errorf("Expected the client to keep model 'new-model', got '%s'", client.Model())
+	}
+}
+
 func TestGenerateEmbeddingRetriesWithBody(t *testing.T) {
 	var requests int32
 	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -281,3 +308,85 @@ func TestGenerateEmbeddingInvalidJSON(t *testing.T) {
 		t.Error("Expected error for invalid JSON, got nil")
 	}
 }
+
+func TestListModels(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" {
+			t.Errorf("Expected path /models, got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("Expected no Authorization header without key")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"data": [
+			{"id": "text-embedding-3-small"},
+			{"id": "gpt-4o"},
+			{"id": "custom-embedder"},
+			{"id": "router/embeds", "architecture": {"output_modalities": ["embeddings"]}},
+			{"id": "router/embedding-named-chat", "architecture": {"output_modalities": ["text"]}}
+		]}`)
+	}))
+	defer server.Close()
+
+	var client = NewClient(server.URL, "", "")
+	var models, err = client.ListModels()
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+
+	var ids []string
+	for _, m := range models {
+		ids = append(ids, m.ID)
+	}
+	var want = []string{"text-embedding-3-small", "custom-embedder", "router/embeds"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Fatalf("Expected models %v, got %v", want, ids)
+	}
+	if models[0].Dimensions != 1536 {
+		t.Errorf("Expected 1536 dimensions, got %d", models[0].Dimensions)
+	}
+}
+
+func TestListModelsError(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	if _, err := NewClient(server.URL, "bad", "").ListModels(); err == nil {
+		t.Fatal("Expected error for 401 response")
+	}
+}
+
+func TestPing(t *testing.T) {
+	var status = http.StatusOK
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" {
+			t.Errorf("Expected /models, got %s", r.URL.Path)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	var client = NewClient(server.URL, "test-key", "test-model")
+	var tests = []struct {
+		status  int
+		wantErr bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusNotFound, false},
+		{http.StatusUnauthorized, true},
+		{http.StatusBadGateway, true},
+	}
+	for _, tt := range tests {
+		status = tt.status
+		if err := client.Ping(); (err != nil) != tt.wantErr {
+			t.Errorf("Ping with status %d: expected error %v, got %v", tt.status, tt.wantErr, err)
+		}
+	}
+
+	server.Close()
+	if err := client.Ping(); err == nil {
+		t.Error("Expected error for unreachable server, got nil")
+	}
+}
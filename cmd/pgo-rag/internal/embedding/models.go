@@ -27,3 +27,22 @@ type ErrorResponse struct {
 		Code    string `json:"code"`
 	} `json:"error"`
 }
+
+// ModelsResponse represents a response from an OpenAI-compatible /models endpoint.
+// Architecture is only returned by OpenRouter.
+type ModelsResponse struct {
+	Data []struct {
+		ID           string `json:"id"`
+		Name         string `json:"name"`
+		Architecture *struct {
+			OutputModalities []string `json:"output_modalities"`
+		} `json:"architecture"`
+	} `json:"data"`
+}
+
+// ModelInfo describes an embedding-capable model.
+type ModelInfo struct {
+	ID         string `json:"id"`
+	Name       string `json:"name,omitempty"`
+	Dimensions int    `json:"dimensions,omitempty"`
+}
@@ -0,0 +1,82 @@
+package embedding
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Provider identifies a known embeddings API provider.
+type Provider string
+
+// Known providers. ProviderUnknown is any other OpenAI-compatible endpoint.
+const (
+	ProviderUnknown    Provider = "unknown"
+	ProviderOpenAI     Provider = "openai"
+	ProviderOpenRouter Provider = "openrouter"
+	ProviderOllama     Provider = "ollama"
+)
+
+// defaultModels maps providers to the model used when none is configured.
+var defaultModels = map[Provider]string{
+	ProviderOpenAI:     "text-embedding-3-small",
+	ProviderOpenRouter: "google/gemini-embedding-001",
+	ProviderOllama:     "nomic-embed-text",
+}
+
+// knownDimensions lists output dimensions for common embedding models.
+// Model listing endpoints rarely report dimensions, so this fills the gap.
+var knownDimensions = map[string]int{
+	"text-embedding-3-small":        1536,
+	"text-embedding-3-large":        3072,
+	"text-embedding-ada-002":        1536,
+	"openai/text-embedding-3-small": 1536,
+	"openai/text-embedding-3-large": 3072,
+	"openai/text-embedding-ada-002": 1536,
+	"google/gemini-embedding-001":   3072,
+	"nomic-embed-text":              768,
+	"mxbai-embed-large":             1024,
+	"all-minilm":                    384,
+	"snowflake-arctic-embed":        1024,
+	"bge-m3":                        1024,
+}
+
+// DetectProvider guesses the provider from an embeddings base URL.
+func DetectProvider(baseURL string) Provider {
+	u, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil || u.Host == "" {
+		return ProviderUnknown
+	}
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case inDomain(host, "openai.com"):
+		return ProviderOpenAI
+	case inDomain(host, "openrouter.ai"):
+		return ProviderOpenRouter
+	case u.Port() == "11434" || strings.Contains(host, "ollama"):
+		return ProviderOllama
+	}
+	return ProviderUnknown
+}
+
+// inDomain reports whether host is domain or one of its subdomains
+func inDomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// DefaultModel returns the default embedding model for the provider behind
+// baseURL, or an empty string if the provider is unknown.
+func DefaultModel(baseURL string) string {
+	return defaultModels[DetectProvider(baseURL)]
+}
+
+// KnownDimensions returns the output dimensions for a model, or 0 if unknown.
+// Ollama-style tags such as "nomic-embed-text:latest" are matched by name.
+func KnownDimensions(model string) int {
+	if dims, ok := knownDimensions[model]; ok {
+		return dims
+	}
+	if name, _, ok := strings.Cut(model, ":"); ok {
+		return knownDimensions[name]
+	}
+	return 0
+}
@@ -0,0 +1,47 @@
+package embedding
+
+import "testing"
+
+func TestDetectProvider(t *testing.T) {
+	var tests = []struct {
+		url  string
+		want Provider
+	}{
+		{url: "https://api.openai.com/v1", want: ProviderOpenAI},
+		{url: "https://openai.com/v1", want: ProviderOpenAI},
+		{url: "https://notopenai.com/v1", want: ProviderUnknown},
+		{url: "https://openrouter.ai/api/v1", want: ProviderOpenRouter},
+		{url: "https://evilopenrouter.ai/api/v1", want: ProviderUnknown},
+		{url: "http://localhost:11434/v1", want: ProviderOllama},
+		{url: "http://ollama.lan/v1", want: ProviderOllama},
+		{url: "http://localhost:8080/v1", want: ProviderUnknown},
+		{url: "", want: ProviderUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := DetectProvider(tt.url); got != tt.want {
+			t.Errorf("DetectProvider(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultModel(t *testing.T) {
+	if got := DefaultModel("http://localhost:11434/v1"); got != "nomic-embed-text" {
+		t.Errorf("Expected Ollama default, got %q", got)
+	}
+	if got := DefaultModel("http://localhost:8080/v1"); got != "" {
+		t.Errorf("Expected no default for unknown provider, got %q", got)
+	}
+}
+
+func TestKnownDimensions(t *testing.T) {
+	if got := KnownDimensions("text-embedding-3-large"); got != 3072 {
+		t.Errorf("Expected 3072, got %d", got)
+	}
+	if got := KnownDimensions("nomic-embed-text:latest"); got != 768 {
+		t.Errorf("Expected 768 for tagged Ollama model, got %d", got)
+	}
+	if got := KnownDimensions("mystery-model"); got != 0 {
+		t.Errorf("Expected 0 for unknown model, got %d", got)
+	}
+}
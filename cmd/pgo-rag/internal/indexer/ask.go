@@ -0,0 +1,455 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/chat"
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/embedding"
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/storage"
+)
+
+// DefaultAskSources is the number of chunks given to the model by default.
+const DefaultAskSources = 5
+
+// maxQuoteLen bounds the length, in characters, of a citation's quote.
+const maxQuoteLen = 300
+
+// noSourcesAnswer is returned when no chunk matches the question.
+const noSourcesAnswer = "No indexed documents matched the question."
+
+const askSystemPrompt = `You answer questions about the user's documents using only the numbered sources provided.
+Cite every claim with the source number in square brackets, for example [1] or [2, 3].
+If the sources do not contain the answer, say that you do not know.`
+
+// Chatter generates a reply to a conversation.
+type Chatter interface {
+	Complete(ctx context.Context, messages []chat.Message) (string, error)
+}
+
+// AskOptions controls retrieval for Ask.
+type AskOptions struct {
+	// Sources is the maximum number of chunks considered for the prompt.
+	Sources  int
+	MinScore float64
+	Ranking  storage.RankOptions
+	// TokenBudget caps the prompt size in tokens. Chunks are packed most
+	// relevant first; the least relevant are truncated or dropped to fit.
+	// TokenBudgetMargin percent of it is kept free. Zero or less disables
+	// the budget.
+	TokenBudget int
+	// Tokens counts prompt tokens. Nil uses Tokenize if set, and
+	// ApproxTokenCounter otherwise.
+	Tokens TokenCounter
+	// Tokenize counts tokens with the chat model's tokenizer, using Ask's
+	// context. ApproxTokenCounter takes over for the rest of the question
+	// once it fails.
+	Tokenize Tokenizer
+	// Instructions are appended to the system prompt, e.g. to ask for a
+	// short answer.
+	Instructions string
+	// Report adds a PackingReport to the summary, listing the chunks that
+	// were and were not given to the model.
+	Report bool
+}
+
+// Citation is a chunk offered to the model as evidence for an answer.
+type Citation struct {
+	Source       int     `json:"source"`
+	PaperlessID  int     `json:"paperless_id"`
+	Title        string  `json:"title"`
+	PaperlessURL string  `json:"paperless_url"`
+	ChunkIndex   int     `json:"chunk_index"`
+	Page         int     `json:"page,omitempty"`
+	Score        float64 `json:"score"`
+	Quote        string  `json:"quote"`
+	// Truncated is set when only part of the chunk fit the token budget.
+	Truncated bool `json:"truncated,omitempty"`
+	// Cited is set when the answer references this source.
+	Cited bool `json:"cited"`
+}
+
+// AskSummary is the answer to a question and the evidence behind it.
+type AskSummary struct {
+	Question    string         `json:"question"`
+	Answer      string         `json:"answer"`
+	Citations   []Citation     `json:"citations"`
+	Packing     *PackingReport `json:"packing,omitempty"`
+	QueryTimeMs int64          `json:"query_time_ms"`
+}
+
+// reportNearMisses is the number of chunks beyond the retrieved ones that a
+// packing report lists, to show what just missed the prompt.
+const reportNearMisses = 5
+
+// Statuses of a chunk in a packing report.
+const (
+	ChunkIncluded  = "included"
+	ChunkTruncated = "truncated"
+	ChunkExcluded  = "excluded"
+)
+
+// Reasons a chunk was excluded from the prompt.
+const (
+	ReasonMinScore    = "min_score"    // Similarity below AskOptions.MinScore
+	ReasonSourceLimit = "source_limit" // Ranked below the AskOptions.Sources best
+	ReasonTokenBudget = "token_budget" // Did not fit in the token budget
+)
+
+// PackingReport explains which chunks Ask put in the prompt and why others
+// were left out.
+type PackingReport struct {
+	Sources     int     `json:"sources"`
+	MinScore    float64 `json:"min_score"`
+	TokenBudget int     `json:"token_budget"` // 0 means unlimited
+	// OverheadTokens are used by the instructions and the question,
+	// MarginTokens are kept free (see TokenBudgetMargin), and SourceTokens
+	// are used by the chunks in the prompt.
+	OverheadTokens int `json:"overhead_tokens"`
+	MarginTokens   int `json:"margin_tokens"`
+	SourceTokens   int `json:"source_tokens"`
+	// Chunks are the retrieved chunks in ranking order, followed by the
+	// best chunks that were not retrieved.
+	Chunks []PackedChunk `json:"chunks"`
+}
+
+// PackedChunk is a chunk of a packing report.
+type PackedChunk struct {
+	// Source is the chunk's number in the prompt, or 0 if it was excluded.
+	Source      int     `json:"source,omitempty"`
+	PaperlessID int     `json:"paperless_id"`
+	Title       string  `json:"title"`
+	ChunkIndex  int     `json:"chunk_index"`
+	Page        int     `json:"page,omitempty"`
+	Score       float64 `json:"score"`
+	Similarity  float64 `json:"similarity"`
+	// Tokens is the size of the chunk's source block, and PromptTokens
+	// the part of it in the prompt.
+	Tokens       int    `json:"tokens"`
+	PromptTokens int    `json:"prompt_tokens"`
+	Status       string `json:"status"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// Ask retrieves the chunks most relevant to question and asks the chat model
+// to answer from them. Chunks are packed into the prompt until the token
+// budget is reached. Every chunk given to the model is returned as a citation
+// with a quoted span; citations referenced in the answer are marked as cited.
+func Ask(ctx context.Context, db storage.Store, embedder Embedder, chatter Chatter, question string, opts AskOptions) (AskSummary, error) {
+	summary := AskSummary{Question: question, Citations: []Citation{}}
+	if db == nil {
+		return summary, errors.New("database is required")
+	}
+	if embedder == nil {
+		return summary, errors.New("embedder is required")
+	}
+	if chatter == nil {
+		return summary, errors.New("chat client is required")
+	}
+	if strings.TrimSpace(question) == "" {
+		return summary, errors.New("question is required")
+	}
+	if err := ValidateMinScore(opts.MinScore); err != nil {
+		return summary, err
+	}
+	sources := opts.Sources
+	if sources <= 0 {
+		sources = DefaultAskSources
+	}
+
+	start := time.Now()
+	vector, err := embedder.GenerateEmbedding(question)
+	if err != nil {
+		return summary, fmt.Errorf("embed question: %w", err)
+	}
+
+	ranking, err := withModelVectors(db, embedder, question, opts.Ranking)
+	if err != nil {
+		return summary, err
+	}
+	chunks, err := db.SearchChunks(vector, question, sources, opts.MinScore, ranking)
+	if err != nil {
+		return summary, err
+	}
+	recordSearchHits(db, documentHits(chunks))
+
+	counter := opts.Tokens
+	if counter == nil && opts.Tokenize != nil {
+		counter = tokenizerCounter(ctx, opts.Tokenize, ApproxTokenCounter)
+	}
+	if counter == nil {
+		counter = ApproxTokenCounter
+	}
+	systemPrompt := askSystemPrompt
+	if opts.Instructions != "" {
+		systemPrompt += "\n" + opts.Instructions
+	}
+	questionLine := fmt.Sprintf("Question: %s", question)
+	overhead := counter.CountTokens(systemPrompt) + counter.CountTokens("Sources:\n\n") + counter.CountTokens(questionLine)
+
+	if opts.Report {
+		// Search again without the score threshold for the chunks that
+		// just missed it or the source limit.
+		nearMisses, err := db.SearchChunks(vector, question, sources+reportNearMisses, MinScoreLowerBound, ranking)
+		if err != nil {
+			return summary, err
+		}
+		summary.Packing = &PackingReport{
+			Sources:        sources,
+			MinScore:       opts.MinScore,
+			TokenBudget:    max(opts.TokenBudget, 0),
+			OverheadTokens: overhead,
+			MarginTokens:   budgetMargin(opts.TokenBudget),
+			Chunks:         []PackedChunk{},
+		}
+		defer func() {
+			summary.Packing.addNearMisses(chunks, nearMisses, opts.MinScore, counter)
+		}()
+	}
+
+	if len(chunks) == 0 {
+		summary.Answer = noSourcesAnswer
+		summary.QueryTimeMs = time.Since(start).Milliseconds()
+		return summary, nil
+	}
+
+	budget := opts.TokenBudget
+	if budget > 0 {
+		budget -= overhead + budgetMargin(budget)
+		if budget <= 0 {
+			return summary, fmt.Errorf("token budget of %d is too small for the question", opts.TokenBudget)
+		}
+	}
+
+	bodies := make([]string, len(chunks))
+	blocks := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		bodies[i] = chunkBody(chunk)
+		blocks[i] = sourceBlock(i+1, chunk, bodies[i])
+	}
+	packed := packSources(blocks, budget, counter)
+	if summary.Packing != nil {
+		summary.Packing.addRetrieved(chunks, blocks, packed, counter)
+	}
+	if len(packed) == 0 {
+		return summary, fmt.Errorf("token budget of %d leaves no room for sources", opts.TokenBudget)
+	}
+
+	terms := questionTerms(question)
+	var prompt strings.Builder
+	prompt.WriteString("Sources:\n\n")
+	for _, p := range packed {
+		chunk := chunks[p.index]
+		body := bodies[p.index]
+		if p.truncated {
+			// Quote only the part the model actually saw.
+			_, body, _ = strings.Cut(p.block, "\n")
+		}
+		citation := Citation{
+			Source:       p.index + 1,
+			PaperlessID:  chunk.PaperlessID,
+			Title:        chunk.Title,
+			PaperlessURL: chunk.PaperlessURL,
+			ChunkIndex:   chunk.ChunkIndex,
+			Page:         chunk.Page,
+			Score:        chunk.SimilarityScore,
+			Quote:        bestQuote(body, terms),
+			Truncated:    p.truncated,
+		}
+		if chunk.Explanation != nil {
+			citation.Score = chunk.Explanation.FinalScore
+		}
+		summary.Citations = append(summary.Citations, citation)
+		prompt.WriteString(p.block)
+	}
+	prompt.WriteString(questionLine)
+
+	answer, err := chatter.Complete(ctx, []chat.Message{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: prompt.String()},
+	})
+	if err != nil {
+		return summary, fmt.Errorf("generate answer: %w", err)
+	}
+
+	summary.Answer = strings.TrimSpace(answer)
+	cited := citedSources(summary.Answer)
+	for i := range summary.Citations {
+		summary.Citations[i].Cited = cited[summary.Citations[i].Source]
+	}
+	summary.QueryTimeMs = time.Since(start).Milliseconds()
+	return summary, nil
+}
+
+// addRetrieved reports the retrieved chunks as included in the prompt,
+// truncated, or excluded by the token budget.
+func (r *PackingReport) addRetrieved(chunks []storage.ChunkResult, blocks []string, packed []packedSource, counter TokenCounter) {
+	for i, chunk := range chunks {
+		entry := packedChunk(chunk, counter.CountTokens(blocks[i]))
+		entry.Status, entry.Reason = ChunkExcluded, ReasonTokenBudget
+		for _, p := range packed {
+			if p.index != i {
+				continue
+			}
+			entry.Source = i + 1
+			entry.PromptTokens = counter.CountTokens(p.block)
+			entry.Status, entry.Reason = ChunkIncluded, ""
+			if p.truncated {
+				entry.Status = ChunkTruncated
+				entry.Reason = ReasonTokenBudget
+			}
+			r.SourceTokens += entry.PromptTokens
+		}
+		r.Chunks = append(r.Chunks, entry)
+	}
+}
+
+// addNearMisses reports the chunks of nearMisses that were not retrieved,
+// either for scoring below minScore or for ranking below the source limit.
+func (r *PackingReport) addNearMisses(retrieved, nearMisses []storage.ChunkResult, minScore float64, counter TokenCounter) {
+	for _, chunk := range nearMisses {
+		if retrievedChunk(retrieved, chunk) {
+			continue
+		}
+		entry := packedChunk(chunk, counter.CountTokens(sourceBlock(0, chunk, chunkBody(chunk))))
+		entry.Status, entry.Reason = ChunkExcluded, ReasonSourceLimit
+		if chunk.SimilarityScore < minScore {
+			entry.Reason = ReasonMinScore
+		}
+		r.Chunks = append(r.Chunks, entry)
+	}
+}
+
+func retrievedChunk(retrieved []storage.ChunkResult, chunk storage.ChunkResult) bool {
+	for _, c := range retrieved {
+		if c.DocumentID == chunk.DocumentID && c.ChunkIndex == chunk.ChunkIndex {
+			return true
+		}
+	}
+	return false
+}
+
+func packedChunk(chunk storage.ChunkResult, tokens int) PackedChunk {
+	entry := PackedChunk{
+		PaperlessID: chunk.PaperlessID,
+		Title:       chunk.Title,
+		ChunkIndex:  chunk.ChunkIndex,
+		Page:        chunk.Page,
+		Score:       chunk.SimilarityScore,
+		Similarity:  chunk.SimilarityScore,
+		Tokens:      tokens,
+	}
+	if chunk.Explanation != nil {
+		entry.Score = chunk.Explanation.FinalScore
+	}
+	return entry
+}
+
+// sourceBlock formats a numbered chunk for the prompt.
+func sourceBlock(n int, chunk storage.ChunkResult, body string) string {
+	header := fmt.Sprintf("[%d] %s", n, chunk.Title)
+	if chunk.Page > 0 {
+		header += fmt.Sprintf(" (page %d)", chunk.Page)
+	}
+	return header + "\n" + body + "\n\n"
+}
+
+// chunkBody strips the title and tags header that prefixes embedded chunks.
+func chunkBody(chunk storage.ChunkResult) string {
+	header := embedding.FormatDocumentText(chunk.Title, chunk.Tags)
+	return strings.TrimSpace(strings.TrimPrefix(chunk.Content, header))
+}
+
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// citedSources returns the source numbers referenced as [n] or [n, m] in text.
+func citedSources(text string) map[int]bool {
+	cited := make(map[int]bool)
+	for _, match := range citationPattern.FindAllStringSubmatch(text, -1) {
+		for _, part := range strings.Split(match[1], ",") {
+			if n, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+				cited[n] = true
+			}
+		}
+	}
+	return cited
+}
+
+// questionTerms returns the distinct lowercase words of a question.
+func questionTerms(question string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, word := range splitWords(question) {
+		terms[word] = true
+	}
+	return terms
+}
+
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// bestQuote returns the sentence of body sharing the most words with the
+// question, trimmed to maxQuoteLen characters. It falls back to the start of
+// body when no sentence matches.
+func bestQuote(body string, terms map[string]bool) string {
+	best, bestHits := "", 0
+	for _, sentence := range splitSentences(body) {
+		hits := 0
+		seen := make(map[string]bool)
+		for _, word := range splitWords(sentence) {
+			if terms[word] && !seen[word] {
+				seen[word] = true
+				hits++
+			}
+		}
+		if hits > bestHits {
+			best, bestHits = sentence, hits
+		}
+	}
+	if best == "" {
+		best = body
+	}
+	return truncateQuote(strings.Join(strings.Fields(best), " "))
+}
+
+// splitSentences splits text after sentence punctuation and at line breaks.
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	runes := []rune(text)
+	for i, r := range runes {
+		end := false
+		switch r {
+		case '\n', '\f':
+			end = true
+		case '.', '!', '?':
+			end = i+1 == len(runes) || unicode.IsSpace(runes[i+1])
+		}
+		if end {
+			if s := strings.TrimSpace(string(runes[start : i+1])); s != "" {
+				sentences = append(sentences, s)
+			}
+			start = i + 1
+		}
+	}
+	if s := strings.TrimSpace(string(runes[start:])); s != "" {
+		sentences = append(sentences, s)
+	}
+	return sentences
+}
+
+func truncateQuote(s string) string {
+	runes := []rune(s)
+	if len(runes) <= maxQuoteLen {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:maxQuoteLen-1])) + "…"
+}
@@ -0,0 +1,329 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/chat"
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/storage"
+)
+
+type fakeChatter struct {
+	answer   string
+	err      error
+	messages []chat.Message
+}
+
+func (f *fakeChatter) Complete(_ context.Context, messages []chat.Message) (string, error) {
+	f.messages = messages
+	return f.answer, f.err
+}
+
+func TestAskReturnsCitations(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	lease := storage.Document{PaperlessID: 10, PaperlessURL: "/documents/10/", Title: "Lease", Tags: "home"}
+	if err := db.UpsertDocumentWithChunks(lease, []storage.Chunk{
+		{Index: 0, Page: 1, Content: "Lease. Tags: home\n\nThe tenant pays rent monthly.", Vector: []float32{1, 0, 0}},
+		{Index: 1, Page: 2, Content: "Lease. Tags: home\n\nParking is included. The deposit is 500 euros.", Vector: []float32{0.9, 0.1, 0}},
+	}); err != nil {
+		t.Fatalf("failed to upsert lease: %v", err)
+	}
+	other := storage.Document{PaperlessID: 11, PaperlessURL: "/documents/11/", Title: "Recipe"}
+	if err := db.UpsertDocumentWithEmbedding(other, "Recipe\n\nBake for an hour.", []float32{0, 1, 0}); err != nil {
+		t.Fatalf("failed to upsert recipe: %v", err)
+	}
+
+	question := "How much is the deposit?"
+	embedder := fakeEmbedder{vectors: map[string][]float32{question: {1, 0, 0}}}
+	chatter := &fakeChatter{answer: "The deposit is 500 euros [2]."}
+
+	summary, err := Ask(ctx, db, embedder, chatter, question, AskOptions{MinScore: 0.5})
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if summary.Answer != chatter.answer {
+		t.Fatalf("expected answer %q, got %q", chatter.answer, summary.Answer)
+	}
+	if len(summary.Citations) != 2 {
+		t.Fatalf("expected 2 citations, got %+v", summary.Citations)
+	}
+
+	first, second := summary.Citations[0], summary.Citations[1]
+	if first.Source != 1 || first.ChunkIndex != 0 || first.Cited {
+		t.Fatalf("unexpected first citation: %+v", first)
+	}
+	if second.Source != 2 || second.PaperlessID != 10 || second.ChunkIndex != 1 || second.Page != 2 || !second.Cited {
+		t.Fatalf("unexpected second citation: %+v", second)
+	}
+	if second.Quote != "The deposit is 500 euros." {
+		t.Fatalf("expected quote of matching sentence, got %q", second.Quote)
+	}
+
+	prompt := chatter.messages[len(chatter.messages)-1].Content
+	if !strings.Contains(prompt, "[2] Lease (page 2)") || strings.Contains(prompt, "Tags: home") {
+		t.Fatalf("unexpected prompt: %s", prompt)
+	}
+}
+
+func TestAskNoMatches(t *testing.T) {
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	chatter := &fakeChatter{}
+	summary, err := Ask(context.Background(), db, fakeEmbedder{}, chatter, "anything", AskOptions{})
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if summary.Answer != noSourcesAnswer || len(summary.Citations) != 0 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if chatter.messages != nil {
+		t.Fatalf("expected chat model not to be called")
+	}
+}
+
+func TestAskChatError(t *testing.T) {
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.UpsertDocumentWithEmbedding(storage.Document{PaperlessID: 1, Title: "Doc"}, "Doc", []float32{0, 0, 1}); err != nil {
+		t.Fatalf("failed to upsert: %v", err)
+	}
+
+	chatter := &fakeChatter{err: errors.New("boom")}
+	if _, err := Ask(context.Background(), db, fakeEmbedder{}, chatter, "doc", AskOptions{}); err == nil {
+		t.Fatal("expected chat error")
+	}
+}
+
+func TestCitedSources(t *testing.T) {
+	cited := citedSources("A [1], B [2, 4] and [x].")
+	for _, n := range []int{1, 2, 4} {
+		if !cited[n] {
+			t.Fatalf("expected source %d to be cited", n)
+		}
+	}
+	if cited[3] {
+		t.Fatal("expected source 3 not to be cited")
+	}
+}
+
+func TestBestQuoteTruncates(t *testing.T) {
+	quote := bestQuote(strings.Repeat("word ", 200), nil)
+	if len([]rune(quote)) != maxQuoteLen {
+		t.Fatalf("expected quote of %d characters, got %d", maxQuoteLen, len([]rune(quote)))
+	}
+}
+
+func TestAskTokenBudget(t *testing.T) {
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	long := strings.Repeat("filler words here. ", 40)
+	for i, vector := range [][]float32{{1, 0, 0}, {0.9, 0.1, 0}, {0.8, 0.2, 0}} {
+		doc := storage.Document{PaperlessID: i + 1, Title: "Doc"}
+		if err := db.UpsertDocumentWithEmbedding(doc, "Doc\n\n"+long, vector); err != nil {
+			t.Fatalf("failed to upsert: %v", err)
+		}
+	}
+
+	question := "filler?"
+	embedder := fakeEmbedder{vectors: map[string][]float32{question: {1, 0, 0}}}
+
+	// One word per token makes the budget easy to reason about.
+	words := TokenCounterFunc(func(text string) int { return len(strings.Fields(text)) })
+	fixed := words.CountTokens(askSystemPrompt) + words.CountTokens("Question: "+question)
+	block := words.CountTokens(sourceBlock(1, storage.ChunkResult{SearchResult: storage.SearchResult{Title: "Doc"}}, strings.TrimSpace(long)))
+	// Leave about 50 tokens for the second source after the margin.
+	budget := (fixed + block + 50) * 100 / (100 - TokenBudgetMargin)
+
+	chatter := &fakeChatter{answer: "ok [1]"}
+	summary, err := Ask(context.Background(), db, embedder, chatter, question, AskOptions{
+		MinScore:    0.5,
+		TokenBudget: budget,
+		Tokens:      words,
+	})
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if len(summary.Citations) != 2 {
+		t.Fatalf("expected 2 packed sources, got %+v", summary.Citations)
+	}
+	if summary.Citations[0].Truncated || !summary.Citations[1].Truncated {
+		t.Fatalf("expected only the second source to be truncated, got %+v", summary.Citations)
+	}
+	if summary.Citations[0].PaperlessID != 1 || summary.Citations[1].PaperlessID != 2 {
+		t.Fatalf("expected most relevant sources to be kept, got %+v", summary.Citations)
+	}
+
+	prompt := chatter.messages[len(chatter.messages)-1].Content
+	if strings.Contains(prompt, "[3]") {
+		t.Fatalf("expected least relevant source to be dropped: %s", prompt)
+	}
+	if used := words.CountTokens(askSystemPrompt) + words.CountTokens(prompt); used > budget-budgetMargin(budget) {
+		t.Fatalf("prompt uses %d tokens, over budget %d less the margin", used, budget)
+	}
+
+	if _, err := Ask(context.Background(), db, embedder, chatter, question, AskOptions{TokenBudget: 5, Tokens: words}); err == nil {
+		t.Fatal("expected error for budget smaller than the prompt")
+	}
+}
+
+func TestPackSourcesUnlimited(t *testing.T) {
+	packed := packSources([]string{"a", "b", "c"}, 0, ApproxTokenCounter)
+	if len(packed) != 3 {
+		t.Fatalf("expected all sources without a budget, got %d", len(packed))
+	}
+}
+
+func TestTruncateToTokens(t *testing.T) {
+	cut := truncateToTokens(strings.Repeat("abcd", 100), 10, ApproxTokenCounter)
+	if n := ApproxTokenCounter.CountTokens(cut); n > 10 {
+		t.Fatalf("expected at most 10 tokens, got %d", n)
+	}
+	if !strings.HasSuffix(cut, "…") {
+		t.Fatalf("expected ellipsis, got %q", cut)
+	}
+}
+
+func TestApproxTokenCounter(t *testing.T) {
+	tests := map[string]int{
+		"":            0,
+		"abcd":        1,
+		"abcde":       2,
+		"東京都":         3,
+		"Rechnung 東京": 3 + 2,
+		"안녕하세요 world": 5 + 2,
+	}
+	for text, want := range tests {
+		if got := ApproxTokenCounter.CountTokens(text); got != want {
+			t.Errorf("ApproxTokenCounter(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestTokenizerCounter(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "ask")
+	calls := 0
+	fail := false
+	counter := tokenizerCounter(ctx, func(got context.Context, text string) (int, error) {
+		calls++
+		if got.Value(ctxKey{}) != "ask" {
+			t.Errorf("expected Ask's context to be passed to the tokenizer")
+		}
+		if fail {
+			return 0, errors.New("tokenizer down")
+		}
+		return len(strings.Fields(text)), nil
+	}, ApproxTokenCounter)
+
+	if n := counter.CountTokens("one two three"); n != 3 {
+		t.Fatalf("expected tokenizer count 3, got %d", n)
+	}
+	if n := counter.CountTokens("one two three"); n != 3 || calls != 1 {
+		t.Fatalf("expected a memoized count, got %d after %d calls", n, calls)
+	}
+
+	fail = true
+	if n := counter.CountTokens(strings.Repeat("abcd", 10)); n != 10 {
+		t.Fatalf("expected the estimate when the tokenizer fails, got %d", n)
+	}
+	fail = false
+	if n := counter.CountTokens("four five"); n != 3 || calls != 2 {
+		t.Fatalf("expected the tokenizer not to be called after a failure, got %d after %d calls", n, calls)
+	}
+}
+
+func TestAskPackingReport(t *testing.T) {
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	long := strings.Repeat("filler words here. ", 40)
+	for i, vector := range [][]float32{{1, 0, 0}, {0.9, 0.1, 0}, {0.8, 0.2, 0}, {0.7, 0.3, 0}, {0, 1, 0}} {
+		doc := storage.Document{PaperlessID: i + 1, Title: "Doc"}
+		if err := db.UpsertDocumentWithEmbedding(doc, "Doc\n\n"+long, vector); err != nil {
+			t.Fatalf("failed to upsert: %v", err)
+		}
+	}
+
+	question := "filler?"
+	embedder := fakeEmbedder{vectors: map[string][]float32{question: {1, 0, 0}}}
+	words := TokenCounterFunc(func(text string) int { return len(strings.Fields(text)) })
+	fixed := words.CountTokens(askSystemPrompt) + words.CountTokens("Question: "+question)
+	block := words.CountTokens(sourceBlock(1, storage.ChunkResult{SearchResult: storage.SearchResult{Title: "Doc"}}, strings.TrimSpace(long)))
+	budget := (fixed + block + 50) * 100 / (100 - TokenBudgetMargin)
+
+	summary, err := Ask(context.Background(), db, embedder, &fakeChatter{answer: "ok [1]"}, question, AskOptions{
+		Sources:     3,
+		MinScore:    0.5,
+		TokenBudget: budget,
+		Tokens:      words,
+		Report:      true,
+	})
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	report := summary.Packing
+	if report == nil {
+		t.Fatal("expected a packing report")
+	}
+	if report.Sources != 3 || report.TokenBudget != budget || report.OverheadTokens != fixed+words.CountTokens("Sources:") ||
+		report.MarginTokens != budgetMargin(budget) {
+		t.Fatalf("unexpected report settings: %+v", report)
+	}
+
+	type row struct {
+		id     int
+		source int
+		status string
+		reason string
+	}
+	want := []row{
+		{1, 1, ChunkIncluded, ""},
+		{2, 2, ChunkTruncated, ReasonTokenBudget},
+		{3, 0, ChunkExcluded, ReasonTokenBudget},
+		{4, 0, ChunkExcluded, ReasonSourceLimit},
+		{5, 0, ChunkExcluded, ReasonMinScore},
+	}
+	if len(report.Chunks) != len(want) {
+		t.Fatalf("expected %d chunks in report, got %+v", len(want), report.Chunks)
+	}
+	for i, w := range want {
+		c := report.Chunks[i]
+		if c.PaperlessID != w.id || c.Source != w.source || c.Status != w.status || c.Reason != w.reason {
+			t.Errorf("chunk %d = %+v, want %+v", i, c, w)
+		}
+	}
+	first, second := report.Chunks[0], report.Chunks[1]
+	if first.PromptTokens != first.Tokens || second.PromptTokens >= second.Tokens || report.SourceTokens != first.PromptTokens+second.PromptTokens {
+		t.Fatalf("unexpected token accounting: %+v", report)
+	}
+
+	summary, err = Ask(context.Background(), db, embedder, &fakeChatter{}, question, AskOptions{MinScore: 0.5})
+	if err != nil || summary.Packing != nil {
+		t.Fatalf("expected no report without Report, got %+v, %v", summary.Packing, err)
+	}
+}
@@ -0,0 +1,146 @@
+package indexer
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"unicode"
+)
+
+// DefaultTokenBudget is the default prompt size, in tokens, for ask.
+const DefaultTokenBudget = 3000
+
+// TokenBudgetMargin is the share of the token budget, in percent, that Ask
+// keeps free for the chat template's special tokens and for counting errors.
+const TokenBudgetMargin = 10
+
+// minTruncatedTokens is the smallest excerpt worth keeping when a chunk has
+// to be truncated to fit the budget.
+const minTruncatedTokens = 32
+
+// TokenCounter counts the tokens a model uses for text.
+type TokenCounter interface {
+	CountTokens(text string) int
+}
+
+// TokenCounterFunc adapts a function to a TokenCounter.
+type TokenCounterFunc func(text string) int
+
+// CountTokens implements TokenCounter.
+func (f TokenCounterFunc) CountTokens(text string) int {
+	return f(text)
+}
+
+// ApproxTokenCounter estimates tokens as one per four characters, which is
+// close to BPE tokenizers for English text, and one per Chinese, Japanese or
+// Korean character, which tokenizers rarely merge. It is only a fallback for
+// when the model's tokenizer is not available.
+var ApproxTokenCounter TokenCounter = TokenCounterFunc(approxTokens)
+
+func approxTokens(text string) int {
+	tokens, run := 0, 0
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			tokens++
+			continue
+		}
+		run++
+	}
+	return tokens + (run+3)/4
+}
+
+// Tokenizer counts tokens with the chat model's own tokenizer, typically
+// behind an API, so it can fail.
+type Tokenizer func(ctx context.Context, text string) (int, error)
+
+// tokenizerCounter counts tokens with tokenize until it fails, and with
+// fallback from then on, so a broken or hung tokenizer costs one timeout per
+// question rather than one per chunk. The failure is logged. Counts are
+// memoized because packing asks for the same text more than once.
+func tokenizerCounter(ctx context.Context, tokenize Tokenizer, fallback TokenCounter) TokenCounter {
+	var (
+		mu     sync.Mutex
+		counts = make(map[string]int)
+		failed bool
+	)
+	return TokenCounterFunc(func(text string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		if n, ok := counts[text]; ok {
+			return n
+		}
+		if failed {
+			return fallback.CountTokens(text)
+		}
+		n, err := tokenize(ctx, text)
+		if err != nil {
+			slog.Warn("Failed to count tokens with the tokenizer, estimating them instead", "error", err)
+			failed = true
+			return fallback.CountTokens(text)
+		}
+		counts[text] = n
+		return n
+	})
+}
+
+// budgetMargin returns the tokens of budget kept free by TokenBudgetMargin.
+func budgetMargin(budget int) int {
+	if budget <= 0 {
+		return 0
+	}
+	return (budget*TokenBudgetMargin + 99) / 100
+}
+
+// packedSource is a source block selected for the prompt.
+type packedSource struct {
+	index     int
+	block     string
+	truncated bool
+}
+
+// packSources selects source blocks in order, most relevant first, until the
+// budget is used up. The first block that does not fit is truncated if a
+// useful excerpt remains, and everything after it is dropped. A budget of 0
+// or less keeps every block.
+func packSources(blocks []string, budget int, counter TokenCounter) []packedSource {
+	var packed []packedSource
+	remaining := budget
+	for i, block := range blocks {
+		if budget <= 0 {
+			packed = append(packed, packedSource{index: i, block: block})
+			continue
+		}
+		cost := counter.CountTokens(block)
+		if cost <= remaining {
+			packed = append(packed, packedSource{index: i, block: block})
+			remaining -= cost
+			continue
+		}
+		if remaining >= minTruncatedTokens {
+			if cut := truncateToTokens(block, remaining, counter); cut != "" {
+				packed = append(packed, packedSource{index: i, block: cut, truncated: true})
+			}
+		}
+		break
+	}
+	return packed
+}
+
+// truncateToTokens returns the longest prefix of text, cut at a rune
+// boundary, that counter reports as fitting in limit tokens.
+func truncateToTokens(text string, limit int, counter TokenCounter) string {
+	runes := []rune(text)
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if counter.CountTokens(string(runes[:mid])+"…") <= limit {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	if lo == 0 {
+		return ""
+	}
+	return string(runes[:lo]) + "…"
+}
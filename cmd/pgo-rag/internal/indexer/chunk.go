@@ -0,0 +1,103 @@
+package indexer
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/storage"
+)
+
+// Default chunking parameters, measured in characters of document content.
+const (
+	DefaultChunkSize    = 2000
+	DefaultChunkOverlap = 200
+)
+
+// buildChunks splits a document into chunks for embedding. Every chunk's text
+// starts with the title and tags so it can be matched on its own. A document
+// whose content fits in one chunk yields exactly buildEmbeddingText's output.
+// Pages are derived from form feeds in the OCR content and left at 0 when the
+// content has none.
+func buildChunks(title, tags, content string, size, overlap int) []storage.Chunk {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		text := buildEmbeddingText(title, tags, "")
+		if text == "" {
+			return nil
+		}
+		return []storage.Chunk{{Content: text}}
+	}
+
+	paged := strings.ContainsRune(content, '\f')
+	var chunks []storage.Chunk
+	for i, span := range splitContent([]rune(content), size, overlap) {
+		chunk := storage.Chunk{
+			Index:   i,
+			Content: buildEmbeddingText(title, tags, span.text),
+		}
+		if paged {
+			chunk.Page = span.page
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// contentSpan is one window of document content.
+type contentSpan struct {
+	text string
+	page int
+}
+
+// splitContent cuts content into windows of at most size runes that overlap
+// by up to overlap runes, preferring to break on whitespace. A size of 0 or
+// less disables splitting.
+func splitContent(content []rune, size, overlap int) []contentSpan {
+	if size <= 0 || len(content) <= size {
+		return []contentSpan{{text: string(content), page: 1}}
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	var spans []contentSpan
+	page := 1
+	pageAt := 0
+	for start := 0; start < len(content); {
+		end := start + size
+		if end >= len(content) {
+			end = len(content)
+		} else if cut := lastSpace(content[start:end]); cut > size/2 {
+			end = start + cut
+		}
+
+		for ; pageAt < start; pageAt++ {
+			if content[pageAt] == '\f' {
+				page++
+			}
+		}
+		if text := strings.TrimSpace(string(content[start:end])); text != "" {
+			spans = append(spans, contentSpan{text: text, page: page})
+		}
+
+		if end == len(content) {
+			break
+		}
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return spans
+}
+
+// lastSpace returns the index of the last whitespace rune in s, or -1.
+func lastSpace(s []rune) int {
+	for i := len(s) - 1; i >= 0; i-- {
+		if unicode.IsSpace(s[i]) {
+			return i
+		}
+	}
+	return -1
+}
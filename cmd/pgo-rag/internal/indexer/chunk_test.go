@@ -0,0 +1,73 @@
+package indexer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildChunksSingle(t *testing.T) {
+	chunks := buildChunks("Invoice", "bills", " short body ", DefaultChunkSize, DefaultChunkOverlap)
+	if len(chunks) != 1 {
+		t.Fatalf("expected 1 chunk, got %d", len(chunks))
+	}
+	if want := buildEmbeddingText("Invoice", "bills", "short body"); chunks[0].Content != want {
+		t.Fatalf("expected %q, got %q", want, chunks[0].Content)
+	}
+	if chunks[0].Page != 0 {
+		t.Fatalf("expected page 0 without form feeds, got %d", chunks[0].Page)
+	}
+}
+
+func TestBuildChunksEmpty(t *testing.T) {
+	if chunks := buildChunks("", "", "   ", DefaultChunkSize, DefaultChunkOverlap); len(chunks) != 0 {
+		t.Fatalf("expected no chunks, got %d", len(chunks))
+	}
+	chunks := buildChunks("Title only", "", "", DefaultChunkSize, DefaultChunkOverlap)
+	if len(chunks) != 1 || chunks[0].Content != "Title only" {
+		t.Fatalf("expected title-only chunk, got %+v", chunks)
+	}
+}
+
+func TestBuildChunksSplitsWithOverlapAndPages(t *testing.T) {
+	page1 := strings.Repeat("alpha ", 10)
+	page2 := strings.Repeat("beta ", 10)
+	content := page1 + "\f" + page2
+
+	chunks := buildChunks("Doc", "", content, 30, 10)
+	if len(chunks) < 3 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if chunk.Index != i {
+			t.Fatalf("expected chunk index %d, got %d", i, chunk.Index)
+		}
+		if !strings.HasPrefix(chunk.Content, "Doc\n\n") {
+			t.Fatalf("expected header on chunk %d, got %q", i, chunk.Content)
+		}
+		if len(chunk.Content) > len("Doc\n\n")+30 {
+			t.Fatalf("chunk %d exceeds size: %q", i, chunk.Content)
+		}
+	}
+	if chunks[0].Page != 1 {
+		t.Fatalf("expected first chunk on page 1, got %d", chunks[0].Page)
+	}
+	last := chunks[len(chunks)-1]
+	if last.Page != 2 || !strings.Contains(last.Content, "beta") {
+		t.Fatalf("expected last chunk on page 2, got %+v", last)
+	}
+
+	// Consecutive chunks share text.
+	first := strings.TrimPrefix(chunks[0].Content, "Doc\n\n")
+	second := strings.TrimPrefix(chunks[1].Content, "Doc\n\n")
+	tail := first[len(first)-5:]
+	if !strings.Contains(second, tail) {
+		t.Fatalf("expected overlap %q in %q", tail, second)
+	}
+}
+
+func TestSplitContentDisabled(t *testing.T) {
+	spans := splitContent([]rune(strings.Repeat("x", 50)), -1, 0)
+	if len(spans) != 1 {
+		t.Fatalf("expected chunking to be disabled, got %d spans", len(spans))
+	}
+}
@@ -0,0 +1,156 @@
+package indexer
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/storage"
+)
+
+// DocumentChange describes a document that differs between two index states.
+type DocumentChange struct {
+	PaperlessID int    `json:"paperless_id"`
+	Title       string `json:"title"`
+	// Fields lists what changed for updated documents: title, tags,
+	// paperless_url, last_modified or embedded_at (re-embedded).
+	Fields []string `json:"fields,omitempty"`
+}
+
+// FailureChange describes an indexing failure that appeared or was resolved.
+type FailureChange struct {
+	PaperlessID int    `json:"paperless_id"`
+	Error       string `json:"error"`
+}
+
+// StateDiff summarizes what changed between two index databases.
+type StateDiff struct {
+	Added     []DocumentChange `json:"added"`
+	Updated   []DocumentChange `json:"updated"`
+	Removed   []DocumentChange `json:"removed"`
+	Failed    []FailureChange  `json:"failed"`
+	Recovered []FailureChange  `json:"recovered"`
+	Unchanged int              `json:"unchanged"`
+}
+
+// DiffState compares the documents and failures of two index databases, such
+// as a backup taken before a build and the index after it. Failed lists
+// failures that are new or whose error changed; Recovered lists failures that
+// no longer occur.
+func DiffState(before, after storage.Store) (StateDiff, error) {
+	diff := StateDiff{
+		Added:     []DocumentChange{},
+		Updated:   []DocumentChange{},
+		Removed:   []DocumentChange{},
+		Failed:    []FailureChange{},
+		Recovered: []FailureChange{},
+	}
+	if before == nil || after == nil {
+		return diff, errors.New("both databases are required")
+	}
+
+	beforeDocs, err := documentsByID(before)
+	if err != nil {
+		return diff, err
+	}
+	afterDocs, err := documentsByID(after)
+	if err != nil {
+		return diff, err
+	}
+
+	for _, id := range sortedKeys(afterDocs) {
+		doc := afterDocs[id]
+		old, ok := beforeDocs[id]
+		if !ok {
+			diff.Added = append(diff.Added, DocumentChange{PaperlessID: id, Title: doc.Title})
+			continue
+		}
+		fields := changedFields(old, doc)
+		if len(fields) == 0 {
+			diff.Unchanged++
+			continue
+		}
+		diff.Updated = append(diff.Updated, DocumentChange{PaperlessID: id, Title: doc.Title, Fields: fields})
+	}
+	for _, id := range sortedKeys(beforeDocs) {
+		if _, ok := afterDocs[id]; !ok {
+			diff.Removed = append(diff.Removed, DocumentChange{PaperlessID: id, Title: beforeDocs[id].Title})
+		}
+	}
+
+	beforeFailures, err := failuresByID(before)
+	if err != nil {
+		return diff, err
+	}
+	afterFailures, err := failuresByID(after)
+	if err != nil {
+		return diff, err
+	}
+	for _, id := range sortedKeys(afterFailures) {
+		if old, ok := beforeFailures[id]; !ok || old.Error != afterFailures[id].Error {
+			diff.Failed = append(diff.Failed, FailureChange{PaperlessID: id, Error: afterFailures[id].Error})
+		}
+	}
+	for _, id := range sortedKeys(beforeFailures) {
+		if _, ok := afterFailures[id]; !ok {
+			diff.Recovered = append(diff.Recovered, FailureChange{PaperlessID: id, Error: beforeFailures[id].Error})
+		}
+	}
+
+	return diff, nil
+}
+
+func changedFields(before, after storage.Document) []string {
+	var fields []string
+	if before.Title != after.Title {
+		fields = append(fields, "title")
+	}
+	if before.Tags != after.Tags {
+		fields = append(fields, "tags")
+	}
+	if before.Correspondent != after.Correspondent {
+		fields = append(fields, "correspondent")
+	}
+	if before.PaperlessURL != after.PaperlessURL {
+		fields = append(fields, "paperless_url")
+	}
+	if !before.LastModified.Equal(after.LastModified) {
+		fields = append(fields, "last_modified")
+	}
+	if !before.EmbeddedAt.Equal(after.EmbeddedAt) {
+		fields = append(fields, "embedded_at")
+	}
+	return fields
+}
+
+func documentsByID(db storage.Store) (map[int]storage.Document, error) {
+	docs, err := db.ListDocuments()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]storage.Document, len(docs))
+	for _, doc := range docs {
+		byID[doc.PaperlessID] = doc
+	}
+	return byID, nil
+}
+
+func failuresByID(db storage.Store) (map[int]storage.IndexFailure, error) {
+	failures, err := db.ListIndexFailures()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]storage.IndexFailure, len(failures))
+	for _, failure := range failures {
+		byID[failure.PaperlessID] = failure
+	}
+	return byID, nil
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
@@ -0,0 +1,91 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/storage"
+)
+
+func TestDiffState(t *testing.T) {
+	dir := t.TempDir()
+	before, err := storage.NewDB(filepath.Join(dir, "before.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer before.Close()
+
+	modified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, doc := range []storage.Document{
+		{PaperlessID: 1, PaperlessURL: "/1", Title: "Kept", LastModified: modified},
+		{PaperlessID: 2, PaperlessURL: "/2", Title: "Retitled", LastModified: modified},
+		{PaperlessID: 3, PaperlessURL: "/3", Title: "Removed", LastModified: modified},
+	} {
+		if err := before.UpsertDocumentWithEmbedding(doc, doc.Title, []float32{1, 0}); err != nil {
+			t.Fatalf("failed to upsert: %v", err)
+		}
+	}
+	if err := before.RecordIndexFailure(8, errors.New("timeout")); err != nil {
+		t.Fatalf("failed to record failure: %v", err)
+	}
+
+	afterPath := filepath.Join(dir, "after.db")
+	if err := before.Backup(context.Background(), afterPath); err != nil {
+		t.Fatalf("failed to copy db: %v", err)
+	}
+	after, err := storage.NewDB(afterPath)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer after.Close()
+
+	if err := after.UpdateDocument(storage.Document{PaperlessID: 2, PaperlessURL: "/2", Title: "New title", LastModified: modified}); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	if err := after.DeleteDocument(3); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if err := after.UpsertDocumentWithEmbedding(storage.Document{PaperlessID: 4, PaperlessURL: "/4", Title: "Added"}, "Added", []float32{0, 1}); err != nil {
+		t.Fatalf("failed to upsert: %v", err)
+	}
+	if err := after.ClearIndexFailure(8); err != nil {
+		t.Fatalf("failed to clear failure: %v", err)
+	}
+	if err := after.RecordIndexFailure(9, errors.New("embed failed")); err != nil {
+		t.Fatalf("failed to record failure: %v", err)
+	}
+
+	diff, err := DiffState(before, after)
+	if err != nil {
+		t.Fatalf("DiffState failed: %v", err)
+	}
+
+	if len(diff.Added) != 1 || diff.Added[0].PaperlessID != 4 {
+		t.Fatalf("unexpected added: %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].PaperlessID != 3 {
+		t.Fatalf("unexpected removed: %+v", diff.Removed)
+	}
+	if len(diff.Updated) != 1 || diff.Updated[0].PaperlessID != 2 || diff.Updated[0].Fields[0] != "title" {
+		t.Fatalf("unexpected updated: %+v", diff.Updated)
+	}
+	if diff.Unchanged != 1 {
+		t.Fatalf("expected 1 unchanged document, got %d", diff.Unchanged)
+	}
+	if !reflect.DeepEqual(diff.Failed, []FailureChange{{PaperlessID: 9, Error: "embed failed"}}) {
+		t.Fatalf("unexpected failed: %+v", diff.Failed)
+	}
+	if !reflect.DeepEqual(diff.Recovered, []FailureChange{{PaperlessID: 8, Error: "timeout"}}) {
+		t.Fatalf("unexpected recovered: %+v", diff.Recovered)
+	}
+}
+
+func TestDiffStateRequiresDatabases(t *testing.T) {
+	if _, err := DiffState(nil, nil); err == nil {
+		t.Fatal("expected error for missing databases")
+	}
+}
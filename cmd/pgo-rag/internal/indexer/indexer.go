@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"sort"
 	"strings"
+	"text/template"
 	"time"
 
 	paperless "github.com/jason-riddle/paperless-go"
@@ -23,6 +25,7 @@ type Embedder interface {
 type PaperlessClient interface {
 	ListDocuments(ctx context.Context, opts *paperless.ListOptions) (*paperless.DocumentList, error)
 	ListTags(ctx context.Context, opts *paperless.ListOptions) (*paperless.TagList, error)
+	ListCorrespondents(ctx context.Context, opts *paperless.ListOptions) (*paperless.CorrespondentList, error)
 }
 
 // BuildOptions configures the indexing process.
@@ -30,6 +33,15 @@ type BuildOptions struct {
 	PageSize int
 	MaxDocs  int
 	TagName  string
+	// ChunkSize is the maximum number of content characters embedded per
+	// chunk. Zero uses DefaultChunkSize; a negative value disables chunking.
+	ChunkSize int
+	// ChunkOverlap is the number of characters shared by consecutive chunks.
+	ChunkOverlap int
+	// DocURLTemplate renders the URL stored for each document. It is executed
+	// with the paperless.Document, e.g. "https://host/documents/{{.ID}}/details".
+	// Nil stores the API path.
+	DocURLTemplate *template.Template
 }
 
 // BuildSummary describes the result of an index build.
@@ -39,18 +51,51 @@ type BuildSummary struct {
 	DocumentsSkipped    int `json:"documents_skipped"`
 	DocumentsFailed     int `json:"documents_failed"`
 	EmbeddingsGenerated int `json:"embeddings_generated"`
+	// ChunksFailed counts chunks whose embedding failed. Their documents are
+	// counted in DocumentsFailed and retried by the next build.
+	ChunksFailed int `json:"chunks_failed"`
+	// ChunksResumed counts chunks whose embedding was kept from an earlier
+	// failed build instead of being generated again.
+	ChunksResumed int `json:"chunks_resumed"`
+	// MaxDocs is the document limit the build ran with; 0 means no limit.
+	MaxDocs int `json:"max_docs"`
+	// Interrupted is set when the build stopped early because its context
+	// was canceled. Progress up to the last completed document is persisted.
+	Interrupted bool `json:"interrupted"`
+	// Dimension is the length of the vectors the build stored, taken from
+	// the index or else from the first embedding.
+	Dimension int `json:"dimension"`
 }
 
+// ErrDimensionMismatch is returned when the embedder returns a vector whose
+// dimension differs from the rest of the build, e.g. because the provider
+// changed the model behind an alias. The build stops rather than store
+// vectors no query can be compared with.
+var ErrDimensionMismatch = errors.New("embedding dimension changed")
+
 // SearchSummary includes the results and timing for a search.
 type SearchSummary struct {
 	Results      []storage.SearchResult `json:"results"`
 	QueryTimeMs  int64                  `json:"query_time_ms"`
 	TotalResults int                    `json:"total_results"`
+	// Ranking echoes the ranking weights when explanations are requested.
+	Ranking *storage.RankOptions `json:"ranking,omitempty"`
 }
 
 // BuildIndex fetches documents from Paperless and updates the local SQLite index.
-func BuildIndex(ctx context.Context, client PaperlessClient, db *storage.DB, embedder Embedder, opts BuildOptions) (BuildSummary, error) {
-	var summary BuildSummary
+// If ctx is canceled, the document currently being embedded is finished and
+// persisted before BuildIndex returns the partial summary with Interrupted set
+// along with the context error.
+func BuildIndex(ctx context.Context, client PaperlessClient, db storage.Store, embedder Embedder, opts BuildOptions) (BuildSummary, error) {
+	summary, err := buildIndex(ctx, client, db, embedder, opts)
+	if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		summary.Interrupted = true
+	}
+	return summary, err
+}
+
+func buildIndex(ctx context.Context, client PaperlessClient, db storage.Store, embedder Embedder, opts BuildOptions) (BuildSummary, error) {
+	summary := BuildSummary{MaxDocs: opts.MaxDocs}
 
 	if client == nil {
 		return summary, errors.New("paperless client is required")
@@ -71,6 +116,15 @@ func BuildIndex(ctx context.Context, client PaperlessClient, db *storage.DB, emb
 	if err != nil {
 		return summary, err
 	}
+	correspondentsByID, err := listAllCorrespondents(ctx, client, pageSize)
+	if err != nil {
+		return summary, err
+	}
+
+	summary.Dimension, err = db.VectorDimension(embedderModel(embedder))
+	if err != nil {
+		return summary, err
+	}
 
 	state, err := db.GetIndexState()
 	if err != nil {
@@ -83,67 +137,36 @@ func BuildIndex(ctx context.Context, client PaperlessClient, db *storage.DB, emb
 		)
 	}
 
-	page := 1
-	for {
-		if opts.MaxDocs > 0 && summary.DocumentsFetched >= opts.MaxDocs {
+	// ID cursoring keeps documents uploaded or deleted during the build from
+	// shifting pages, which would skip or repeat documents.
+	listOpts := &paperless.ListOptions{PageSize: pageSize}
+	if opts.MaxDocs > 0 && opts.MaxDocs < pageSize {
+		listOpts.PageSize = opts.MaxDocs
+	}
+	it := paperless.NewDocumentIterator(client, listOpts, paperless.WithIDCursor())
+	for opts.MaxDocs <= 0 || summary.DocumentsFetched < opts.MaxDocs {
+		if !it.Next(ctx) {
 			break
 		}
+		doc := it.Document()
+		summary.DocumentsFetched++
 
-		select {
-		case <-ctx.Done():
-			return summary, ctx.Err()
-		default:
-		}
-
-		effectivePageSize := pageSize
-		if opts.MaxDocs > 0 {
-			remaining := opts.MaxDocs - summary.DocumentsFetched
-			if remaining <= 0 {
-				break
-			}
-			if remaining < effectivePageSize {
-				effectivePageSize = remaining
-			}
-		}
-
-		list, err := client.ListDocuments(ctx, &paperless.ListOptions{
-			Page:     page,
-			PageSize: effectivePageSize,
-			Ordering: "id",
-		})
-		if err != nil {
+		if err := processDocument(ctx, db, embedder, tagsByID, correspondentsByID, opts, doc, &summary); err != nil {
 			return summary, err
 		}
-		if len(list.Results) == 0 {
-			break
-		}
-
-		for _, doc := range list.Results {
-			if opts.MaxDocs > 0 && summary.DocumentsFetched >= opts.MaxDocs {
-				break
-			}
-
-			summary.DocumentsFetched++
 
-			if err := processDocument(ctx, db, embedder, tagsByID, opts, doc, &summary); err != nil {
-				return summary, err
-			}
-
-			if err := db.UpdateIndexState(doc.ID); err != nil {
-				return summary, err
-			}
-		}
-
-		if list.Next == nil {
-			break
+		if err := db.UpdateIndexState(doc.ID); err != nil {
+			return summary, err
 		}
-		page++
+	}
+	if err := it.Err(); err != nil {
+		return summary, err
 	}
 
 	return summary, nil
 }
 
-func processDocument(ctx context.Context, db *storage.DB, embedder Embedder, tagsByID map[int]string, opts BuildOptions, doc paperless.Document, summary *BuildSummary) error {
+func processDocument(ctx context.Context, db storage.Store, embedder Embedder, tagsByID, correspondentsByID map[int]string, opts BuildOptions, doc paperless.Document, summary *BuildSummary) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
@@ -160,8 +183,13 @@ func processDocument(ctx context.Context, db *storage.DB, embedder Embedder, tag
 	}
 
 	tags := formatTags(doc.Tags, tagsByID)
-	text := buildEmbeddingText(doc.Title, tags, doc.Content)
-	if text == "" {
+	correspondent := formatCorrespondent(doc.Correspondent, correspondentsByID)
+	chunkSize := opts.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = DefaultChunkSize
+	}
+	chunks := buildChunks(doc.Title, tags, doc.Content, chunkSize, opts.ChunkOverlap)
+	if len(chunks) == 0 {
 		slog.Info("Skipping document with empty embedding text",
 			"paperless_id", doc.ID,
 			"tags", tags,
@@ -170,12 +198,29 @@ func processDocument(ctx context.Context, db *storage.DB, embedder Embedder, tag
 		return nil
 	}
 
+	paperlessURL, err := docURL(doc, opts.DocURLTemplate)
+	if err != nil {
+		return err
+	}
+
 	modified := doc.Modified.Time()
 	existing, err := db.GetDocumentByPaperlessID(doc.ID)
 	if err != nil {
 		return err
 	}
 	if existing != nil && existing.LastModified.Equal(modified) && !existing.EmbeddedAt.IsZero() {
+		if existing.PaperlessURL != paperlessURL {
+			// The URL template changed; no need to re-embed.
+			if err := db.SetDocumentURL(doc.ID, paperlessURL); err != nil {
+				return err
+			}
+		}
+		if existing.Correspondent != correspondent {
+			// Indexed before correspondents were stored
+			if err := db.SetDocumentCorrespondent(doc.ID, correspondent); err != nil {
+				return err
+			}
+		}
 		slog.Info("Skipping unchanged document",
 			"paperless_id", doc.ID,
 			"last_modified", modified,
@@ -184,24 +229,87 @@ func processDocument(ctx context.Context, db *storage.DB, embedder Embedder, tag
 		return nil
 	}
 
-	vector, err := embedder.GenerateEmbedding(text)
+	// Chunks embedded by an earlier, failed build are reused if their text
+	// and dimension are unchanged, so a retry only embeds the chunks that
+	// failed.
+	pending, err := db.GetPendingChunks(doc.ID)
 	if err != nil {
-		return recordDocumentFailure(db, summary, doc.ID, fmt.Errorf("generate embedding for document %d: %w", doc.ID, err))
+		return err
+	}
+	reusable := make(map[int]storage.PendingChunk, len(pending))
+	for _, p := range pending {
+		if p.Vector != nil {
+			reusable[p.Index] = p
+		}
+	}
+
+	textLen := 0
+	generated := 0
+	failed := make(map[int]error)
+	var firstErr error
+	for i := range chunks {
+		if p, ok := reusable[chunks[i].Index]; ok && p.Content == chunks[i].Content && (summary.Dimension == 0 || len(p.Vector) == summary.Dimension) {
+			chunks[i].Vector = p.Vector
+			summary.ChunksResumed++
+			continue
+		}
+		vector, err := embedder.GenerateEmbedding(chunks[i].Content)
+		if err != nil {
+			slog.Warn("Failed to embed chunk",
+				"paperless_id", doc.ID,
+				"chunk_index", chunks[i].Index,
+				"error", err,
+			)
+			failed[i] = err
+			if firstErr == nil {
+				firstErr = fmt.Errorf("chunk %d: %w", chunks[i].Index, err)
+			}
+			continue
+		}
+		if err := checkDimension(summary, vector); err != nil {
+			err = fmt.Errorf("generate embedding for document %d: chunk %d: %w", doc.ID, chunks[i].Index, err)
+			if recordErr := recordDocumentFailure(db, summary, doc.ID, err); recordErr != nil {
+				return recordErr
+			}
+			return err
+		}
+		chunks[i].Vector = vector
+		textLen += len(chunks[i].Content)
+		generated++
+	}
+	summary.EmbeddingsGenerated += generated
+	model := embedderModel(embedder)
+	for i := range chunks {
+		chunks[i].Model = model
+	}
+
+	if len(failed) > 0 {
+		summary.ChunksFailed += len(failed)
+		if err := savePendingChunks(db, doc.ID, chunks, failed); err != nil {
+			return err
+		}
+		return recordDocumentFailure(db, summary, doc.ID, fmt.Errorf("generate embedding for document %d: %d of %d chunks failed, first %w", doc.ID, len(failed), len(chunks), firstErr))
 	}
 
 	slog.Info("Embedded document",
 		"paperless_id", doc.ID,
 		"tags", tags,
-		"embedding_text_len", len(text),
+		"chunks", len(chunks),
+		"embedding_text_len", textLen,
 	)
 
-	if err := db.UpsertDocumentWithEmbedding(storage.Document{
-		PaperlessID:  doc.ID,
-		PaperlessURL: docURL(doc),
-		Title:        doc.Title,
-		Tags:         tags,
-		LastModified: modified,
-	}, text, vector); err != nil {
+	if err := db.UpsertDocumentWithChunks(storage.Document{
+		PaperlessID:   doc.ID,
+		PaperlessURL:  paperlessURL,
+		Title:         doc.Title,
+		Tags:          tags,
+		Correspondent: correspondent,
+		LastModified:  modified,
+	}, chunks); err != nil {
+		// Keep the vectors so the retry does not embed the document again
+		if saveErr := savePendingChunks(db, doc.ID, chunks, nil); saveErr != nil {
+			return saveErr
+		}
 		return recordDocumentFailure(db, summary, doc.ID, fmt.Errorf("update index for document %d: %w", doc.ID, err))
 	}
 
@@ -210,11 +318,36 @@ func processDocument(ctx context.Context, db *storage.DB, embedder Embedder, tag
 	}
 
 	summary.DocumentsIndexed++
-	summary.EmbeddingsGenerated++
 	return nil
 }
 
-func recordDocumentFailure(db *storage.DB, summary *BuildSummary, paperlessID int, err error) error {
+// savePendingChunks stores the chunks of a document that failed to index.
+// failed maps positions in chunks to the error embedding them returned.
+func savePendingChunks(db storage.Store, paperlessID int, chunks []storage.Chunk, failed map[int]error) error {
+	pending := make([]storage.PendingChunk, len(chunks))
+	for i, chunk := range chunks {
+		pending[i] = storage.PendingChunk{Index: chunk.Index, Content: chunk.Content, Vector: chunk.Vector}
+		if err := failed[i]; err != nil {
+			pending[i].Error = err.Error()
+		}
+	}
+	return db.SavePendingChunks(paperlessID, pending)
+}
+
+// checkDimension takes the dimension of the build from vector if none is
+// known yet, and otherwise reports ErrDimensionMismatch if vector differs.
+func checkDimension(summary *BuildSummary, vector []float32) error {
+	if summary.Dimension == 0 {
+		summary.Dimension = len(vector)
+		return nil
+	}
+	if len(vector) != summary.Dimension {
+		return fmt.Errorf("%w: expected %d dimensions, got %d; check whether the embeddings model changed and migrate with reembed", ErrDimensionMismatch, summary.Dimension, len(vector))
+	}
+	return nil
+}
+
+func recordDocumentFailure(db storage.Store, summary *BuildSummary, paperlessID int, err error) error {
 	slog.Error("Failed to index document",
 		"paperless_id", paperlessID,
 		"error", err,
@@ -226,10 +359,52 @@ func recordDocumentFailure(db *storage.DB, summary *BuildSummary, paperlessID in
 	return nil
 }
 
+// MinScoreLowerBound and MinScoreUpperBound bound SearchOptions.MinScore.
+// Cosine similarity ranges from -1 (opposite) to 1 (identical).
+const (
+	MinScoreLowerBound = -1.0
+	MinScoreUpperBound = 1.0
+)
+
+// DefaultMinScore is the minimum similarity used by the CLI when none is given.
+const DefaultMinScore = 0.7
+
+// SearchOptions configures SearchIndexWithOptions.
+type SearchOptions struct {
+	Limit int
+	// MinScore is the minimum cosine similarity for a result, in [-1, 1].
+	// Zero and negative values are honored as given.
+	MinScore float64
+	Ranking  storage.RankOptions
+	// Explain keeps per-result score explanations in the output.
+	Explain bool
+	// Snippets sets each result's snippet to the sentence of its best chunk
+	// that best matches the query.
+	Snippets bool
+}
+
 // SearchIndex runs a similarity search against the local index.
-func SearchIndex(ctx context.Context, db *storage.DB, embedder Embedder, query string, limit int, threshold float64) (SearchSummary, error) {
-	var summary SearchSummary
+// threshold is the minimum cosine similarity, see SearchOptions.MinScore.
+//
+// Deprecated: use SearchIndexWithOptions.
+func SearchIndex(ctx context.Context, db storage.Store, embedder Embedder, query string, limit int, threshold float64) (SearchSummary, error) {
+	return SearchIndexWithOptions(ctx, db, embedder, query, SearchOptions{
+		Limit:    limit,
+		MinScore: threshold,
+	})
+}
+
+// ValidateMinScore reports an error if score is outside [-1, 1].
+func ValidateMinScore(score float64) error {
+	if math.IsNaN(score) || score < MinScoreLowerBound || score > MinScoreUpperBound {
+		return fmt.Errorf("min score must be between %g and %g, got %g", MinScoreLowerBound, MinScoreUpperBound, score)
+	}
+	return nil
+}
 
+// SearchIndexWithOptions runs a hybrid search against the local index.
+func SearchIndexWithOptions(ctx context.Context, db storage.Store, embedder Embedder, query string, opts SearchOptions) (SearchSummary, error) {
+	var summary SearchSummary
 	if db == nil {
 		return summary, errors.New("storage database is required")
 	}
@@ -239,11 +414,12 @@ func SearchIndex(ctx context.Context, db *storage.DB, embedder Embedder, query s
 	if strings.TrimSpace(query) == "" {
 		return summary, errors.New("query is required")
 	}
+	limit := opts.Limit
 	if limit <= 0 {
 		limit = 10
 	}
-	if threshold <= 0 {
-		threshold = 0.7
+	if err := ValidateMinScore(opts.MinScore); err != nil {
+		return summary, err
 	}
 
 	select {
@@ -258,15 +434,35 @@ func SearchIndex(ctx context.Context, db *storage.DB, embedder Embedder, query s
 		return summary, fmt.Errorf("generate embedding for query: %w", err)
 	}
 
-	results, err := db.SearchSimilar(vector, limit, threshold)
+	ranking, err := withModelVectors(db, embedder, query, opts.Ranking)
+	if err != nil {
+		return summary, err
+	}
+	results, err := db.SearchHybrid(vector, query, limit, opts.MinScore, ranking)
 	if err != nil {
 		return summary, err
 	}
+	recordSearchHits(db, results)
+	if opts.Explain {
+		ranking := opts.Ranking
+		summary.Ranking = &ranking
+	} else {
+		for i := range results {
+			results[i].Explanation = nil
+		}
+	}
+	terms := questionTerms(query)
+	for i := range results {
+		if opts.Snippets {
+			results[i].Snippet = bestQuote(chunkBody(storage.ChunkResult{SearchResult: results[i], Content: results[i].Snippet}), terms)
+		} else {
+			results[i].Snippet = ""
+		}
+	}
 
 	summary.Results = results
 	summary.TotalResults = len(results)
 	summary.QueryTimeMs = time.Since(start).Milliseconds()
-
 	return summary, nil
 }
 
@@ -299,6 +495,45 @@ func listAllTags(ctx context.Context, client PaperlessClient, pageSize int) (map
 	return tagsByID, nil
 }
 
+func listAllCorrespondents(ctx context.Context, client PaperlessClient, pageSize int) (map[int]string, error) {
+	page := 1
+	correspondentsByID := make(map[int]string)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		list, err := client.ListCorrespondents(ctx, &paperless.ListOptions{Page: page, PageSize: pageSize})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, correspondent := range list.Results {
+			correspondentsByID[correspondent.ID] = correspondent.Name
+		}
+
+		if list.Next == nil || len(list.Results) == 0 {
+			break
+		}
+		page++
+	}
+
+	return correspondentsByID, nil
+}
+
+func formatCorrespondent(id *int, correspondentsByID map[int]string) string {
+	if id == nil {
+		return ""
+	}
+	if name := correspondentsByID[*id]; name != "" {
+		return name
+	}
+	return fmt.Sprintf("correspondent-%d", *id)
+}
+
 func formatTags(tagIDs []int, tagsByID map[int]string) string {
 	if len(tagIDs) == 0 {
 		return ""
@@ -331,8 +566,28 @@ func buildEmbeddingText(title string, tags string, content string) string {
 	return base + "\n\n" + content
 }
 
-func docURL(doc paperless.Document) string {
-	return fmt.Sprintf("/api/documents/%d/", doc.ID)
+// ParseDocURLTemplate parses a document URL template and checks that it
+// renders for a sample document, so mistakes surface before indexing starts.
+func ParseDocURLTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("doc-url").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse document URL template: %w", err)
+	}
+	if _, err := docURL(paperless.Document{ID: 1}, tmpl); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+func docURL(doc paperless.Document, tmpl *template.Template) (string, error) {
+	if tmpl == nil {
+		return fmt.Sprintf("/api/documents/%d/", doc.ID), nil
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, doc); err != nil {
+		return "", fmt.Errorf("render document URL: %w", err)
+	}
+	return b.String(), nil
 }
 
 func documentHasTag(doc paperless.Document, tagsByID map[int]string, tagName string) bool {
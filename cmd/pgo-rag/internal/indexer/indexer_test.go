@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -35,30 +36,45 @@ func (f failingEmbedder) GenerateEmbedding(text string) ([]float32, error) {
 }
 
 type fakePaperless struct {
-	documents []paperless.Document
-	tags      []paperless.Tag
+	documents      []paperless.Document
+	tags           []paperless.Tag
+	correspondents []paperless.Correspondent
 }
 
 func (f fakePaperless) ListDocuments(_ context.Context, opts *paperless.ListOptions) (*paperless.DocumentList, error) {
-	page, pageSize := normalizePage(opts, len(f.documents))
+	docs := f.documents
+	if opts != nil && opts.AfterID > 0 {
+		docs = nil
+		for _, doc := range f.documents {
+			if doc.ID > opts.AfterID {
+				docs = append(docs, doc)
+			}
+		}
+	}
+
+	page, pageSize := normalizePage(opts, len(docs))
 	start := (page - 1) * pageSize
-	if start >= len(f.documents) {
-		return &paperless.DocumentList{Count: len(f.documents)}, nil
+	if start >= len(docs) {
+		return &paperless.DocumentList{Count: len(docs)}, nil
 	}
 
 	end := start + pageSize
-	if end > len(f.documents) {
-		end = len(f.documents)
+	if end > len(docs) {
+		end = len(docs)
 	}
 
-	list := &paperless.DocumentList{Count: len(f.documents), Results: f.documents[start:end]}
-	if end < len(f.documents) {
+	list := &paperless.DocumentList{Count: len(docs), Results: docs[start:end]}
+	if end < len(docs) {
 		next := "next"
 		list.Next = &next
 	}
 	return list, nil
 }
 
+func (f fakePaperless) ListCorrespondents(_ context.Context, _ *paperless.ListOptions) (*paperless.CorrespondentList, error) {
+	return &paperless.CorrespondentList{Count: len(f.correspondents), Results: f.correspondents}, nil
+}
+
 func (f fakePaperless) ListTags(_ context.Context, opts *paperless.ListOptions) (*paperless.TagList, error) {
 	page, pageSize := normalizePage(opts, len(f.tags))
 	start := (page - 1) * pageSize
@@ -258,6 +274,9 @@ func TestBuildIndexMaxDocs(t *testing.T) {
 	if summary.DocumentsFetched != 2 {
 		t.Fatalf("expected 2 documents fetched, got %d", summary.DocumentsFetched)
 	}
+	if summary.MaxDocs != 2 {
+		t.Fatalf("expected max_docs 2 in summary, got %d", summary.MaxDocs)
+	}
 }
 
 func TestBuildIndexTagFilter(t *testing.T) {
@@ -332,6 +351,73 @@ func TestBuildIndexFailureIsRecorded(t *testing.T) {
 	}
 }
 
+type countingEmbedder struct {
+	failOn string
+	calls  *int
+}
+
+func (c countingEmbedder) GenerateEmbedding(text string) ([]float32, error) {
+	*c.calls++
+	if text == c.failOn {
+		return nil, errors.New("embed failed")
+	}
+	return []float32{1, 0, 0}, nil
+}
+
+func TestBuildIndexRetriesFailedChunksOnly(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	modified := time.Now().UTC().Truncate(time.Second)
+	content := strings.Repeat("alpha ", 10) + strings.Repeat("beta ", 10) + strings.Repeat("gamma ", 10)
+	client := fakePaperless{
+		documents: []paperless.Document{
+			{ID: 1, Title: "Doc1", Content: content, Modified: paperless.Date(modified)},
+		},
+	}
+	opts := BuildOptions{ChunkSize: 60, ChunkOverlap: 10}
+	chunks := buildChunks("Doc1", "", content, opts.ChunkSize, opts.ChunkOverlap)
+	if len(chunks) < 3 {
+		t.Fatalf("expected at least 3 chunks, got %d", len(chunks))
+	}
+
+	calls := 0
+	summary, err := BuildIndex(ctx, client, db, countingEmbedder{failOn: chunks[1].Content, calls: &calls}, opts)
+	if err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	if summary.DocumentsFailed != 1 || summary.ChunksFailed != 1 || calls != len(chunks) {
+		t.Fatalf("expected one failed chunk after embedding all %d chunks, got %+v after %d calls", len(chunks), summary, calls)
+	}
+	pending, err := db.GetPendingChunks(1)
+	if err != nil {
+		t.Fatalf("GetPendingChunks failed: %v", err)
+	}
+	if len(pending) != len(chunks) || pending[1].Vector != nil || pending[1].Error == "" || pending[0].Vector == nil {
+		t.Fatalf("unexpected pending chunks: %+v", pending)
+	}
+
+	calls = 0
+	summary, err = BuildIndex(ctx, client, db, countingEmbedder{calls: &calls}, opts)
+	if err != nil {
+		t.Fatalf("BuildIndex retry failed: %v", err)
+	}
+	if calls != 1 || summary.ChunksResumed != len(chunks)-1 || summary.EmbeddingsGenerated != 1 || summary.DocumentsIndexed != 1 {
+		t.Fatalf("expected only the failed chunk to be embedded, got %+v after %d calls", summary, calls)
+	}
+	if pending, err := db.GetPendingChunks(1); err != nil || len(pending) != 0 {
+		t.Fatalf("expected pending chunks to be cleared, got %+v, %v", pending, err)
+	}
+	if failure, err := db.GetIndexFailure(1); err != nil || failure != nil {
+		t.Fatalf("expected failure to be cleared, got %+v, %v", failure, err)
+	}
+}
+
 func TestHelpers(t *testing.T) {
 	if result := formatTags([]int{2, 1}, map[int]string{1: "alpha", 2: "beta"}); result != "alpha, beta" {
 		t.Fatalf("unexpected tags: %s", result)
@@ -345,8 +431,66 @@ func TestHelpers(t *testing.T) {
 		t.Fatalf("unexpected embedding text: %s", text)
 	}
 
-	if docURL(paperless.Document{ID: 42}) != "/api/documents/42/" {
-		t.Fatalf("unexpected doc URL")
+	if url, err := docURL(paperless.Document{ID: 42}, nil); err != nil || url != "/api/documents/42/" {
+		t.Fatalf("unexpected doc URL: %s, %v", url, err)
+	}
+}
+
+func TestDocURLTemplate(t *testing.T) {
+	tmpl, err := ParseDocURLTemplate("https://paperless.example.com/documents/{{.ID}}/details")
+	if err != nil {
+		t.Fatalf("ParseDocURLTemplate failed: %v", err)
+	}
+	url, err := docURL(paperless.Document{ID: 42}, tmpl)
+	if err != nil {
+		t.Fatalf("docURL failed: %v", err)
+	}
+	if url != "https://paperless.example.com/documents/42/details" {
+		t.Fatalf("unexpected doc URL: %s", url)
+	}
+
+	if _, err := ParseDocURLTemplate("{{.ID"); err == nil {
+		t.Fatal("expected parse error")
+	}
+	if _, err := ParseDocURLTemplate("/documents/{{.Missing}}"); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
+
+func TestBuildIndexUpdatesURLWithoutReembedding(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	client := fakePaperless{documents: []paperless.Document{
+		{ID: 7, Title: "Lease", Content: "rent", Modified: paperless.Date(time.Now().UTC().Truncate(time.Second))},
+	}}
+	if _, err := BuildIndex(ctx, client, db, fakeEmbedder{}, BuildOptions{}); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+
+	tmpl, err := ParseDocURLTemplate("https://paperless.example.com/documents/{{.ID}}/details")
+	if err != nil {
+		t.Fatalf("ParseDocURLTemplate failed: %v", err)
+	}
+	summary, err := BuildIndex(ctx, client, db, fakeEmbedder{}, BuildOptions{DocURLTemplate: tmpl})
+	if err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	if summary.EmbeddingsGenerated != 0 || summary.DocumentsSkipped != 1 {
+		t.Fatalf("expected unchanged document to be skipped, got %+v", summary)
+	}
+
+	doc, err := db.GetDocumentByPaperlessID(7)
+	if err != nil {
+		t.Fatalf("GetDocumentByPaperlessID failed: %v", err)
+	}
+	if doc.PaperlessURL != "https://paperless.example.com/documents/7/details" {
+		t.Fatalf("expected URL to be updated, got %s", doc.PaperlessURL)
 	}
 }
 
@@ -389,3 +533,173 @@ func TestListAllTags(t *testing.T) {
 		t.Fatalf("expected tag 2 name 'two', got %s", tags[2])
 	}
 }
+
+type cancelingEmbedder struct {
+	cancel context.CancelFunc
+}
+
+func (c cancelingEmbedder) GenerateEmbedding(text string) ([]float32, error) {
+	// Simulate a signal arriving while the document is being embedded.
+	c.cancel()
+	return []float32{1, 0, 0}, nil
+}
+
+func TestBuildIndexInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	modified := time.Now().UTC().Truncate(time.Second)
+	client := fakePaperless{
+		documents: []paperless.Document{
+			{ID: 1, Title: "Doc1", Content: "content1", Modified: paperless.Date(modified)},
+			{ID: 2, Title: "Doc2", Content: "content2", Modified: paperless.Date(modified)},
+		},
+	}
+
+	summary, err := BuildIndex(ctx, client, db, cancelingEmbedder{cancel: cancel}, BuildOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if !summary.Interrupted {
+		t.Fatal("expected summary to be marked interrupted")
+	}
+	if summary.DocumentsIndexed != 1 {
+		t.Fatalf("expected in-flight document to be indexed, got %d", summary.DocumentsIndexed)
+	}
+
+	state, err := db.GetIndexState()
+	if err != nil {
+		t.Fatalf("GetIndexState failed: %v", err)
+	}
+	if state.LastPaperlessID != 1 {
+		t.Fatalf("expected index state to record document 1, got %d", state.LastPaperlessID)
+	}
+}
+
+func TestBuildIndexStopsOnDimensionDrift(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	modified := time.Now().UTC().Truncate(time.Second)
+	client := fakePaperless{
+		documents: []paperless.Document{
+			{ID: 1, Title: "Doc1", Content: "content1", Modified: paperless.Date(modified)},
+			{ID: 2, Title: "Doc2", Content: "drifted", Modified: paperless.Date(modified)},
+			{ID: 3, Title: "Doc3", Content: "content3", Modified: paperless.Date(modified)},
+		},
+	}
+	embedder := fakeEmbedder{vectors: map[string][]float32{"Doc2\n\ndrifted": {1, 0}}}
+
+	summary, err := BuildIndex(ctx, client, db, embedder, BuildOptions{})
+	if !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected ErrDimensionMismatch, got %v", err)
+	}
+	if summary.DocumentsIndexed != 1 || summary.DocumentsFailed != 1 || summary.Dimension != 3 {
+		t.Fatalf("expected document 1 indexed and 2 failed, got %+v", summary)
+	}
+	failure, err := db.GetIndexFailure(2)
+	if err != nil || failure == nil || !strings.Contains(failure.Error, "expected 3 dimensions, got 2") {
+		t.Fatalf("expected a dimension failure for document 2, got %+v (%v)", failure, err)
+	}
+	if doc, err := db.GetDocumentByPaperlessID(3); err != nil || doc != nil {
+		t.Fatalf("expected the build to stop before document 3, got %+v (%v)", doc, err)
+	}
+
+	// The dimension of an existing index applies from the first document.
+	client.documents = client.documents[2:]
+	embedder.vectors["Doc3\n\ncontent3"] = []float32{1, 0}
+	if _, err := BuildIndex(ctx, client, db, embedder, BuildOptions{}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected ErrDimensionMismatch against the index, got %v", err)
+	}
+}
+
+func TestSearchIndexExplain(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.UpsertDocumentWithEmbedding(storage.Document{
+		PaperlessID:  1,
+		PaperlessURL: "/api/documents/1/",
+		Title:        "Doc",
+	}, "content", []float32{1, 0, 0}); err != nil {
+		t.Fatalf("failed to upsert: %v", err)
+	}
+
+	embedder := fakeEmbedder{vectors: map[string][]float32{"query": {1, 0, 0}}}
+
+	plain, err := SearchIndexWithOptions(ctx, db, embedder, "query", SearchOptions{Limit: 5, MinScore: 0.5})
+	if err != nil {
+		t.Fatalf("SearchIndexWithOptions failed: %v", err)
+	}
+	if plain.Results[0].Explanation != nil || plain.Ranking != nil {
+		t.Fatalf("expected no explanation without Explain")
+	}
+
+	explained, err := SearchIndexWithOptions(ctx, db, embedder, "query", SearchOptions{Limit: 5, MinScore: 0.5, Explain: true})
+	if err != nil {
+		t.Fatalf("SearchIndexWithOptions failed: %v", err)
+	}
+	if explained.Results[0].Explanation == nil || explained.Ranking == nil {
+		t.Fatalf("expected explanation with Explain")
+	}
+}
+
+func TestSearchIndexMinScore(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.UpsertDocumentWithEmbedding(storage.Document{
+		PaperlessID:  1,
+		PaperlessURL: "/api/documents/1/",
+		Title:        "Opposite",
+	}, "content", []float32{-1, 0, 0}); err != nil {
+		t.Fatalf("failed to upsert: %v", err)
+	}
+
+	embedder := fakeEmbedder{vectors: map[string][]float32{"query": {1, 0, 0}}}
+
+	// A zero min score is honored rather than remapped to a default.
+	summary, err := SearchIndexWithOptions(ctx, db, embedder, "query", SearchOptions{MinScore: 0})
+	if err != nil {
+		t.Fatalf("SearchIndexWithOptions failed: %v", err)
+	}
+	if summary.TotalResults != 0 {
+		t.Fatalf("expected no results at min score 0, got %d", summary.TotalResults)
+	}
+
+	// Negative similarities are reachable with a negative min score.
+	summary, err = SearchIndexWithOptions(ctx, db, embedder, "query", SearchOptions{MinScore: -1})
+	if err != nil {
+		t.Fatalf("SearchIndexWithOptions failed: %v", err)
+	}
+	if summary.TotalResults != 1 {
+		t.Fatalf("expected 1 result at min score -1, got %d", summary.TotalResults)
+	}
+
+	for _, invalid := range []float64{-1.5, 1.01} {
+		if _, err := SearchIndexWithOptions(ctx, db, embedder, "query", SearchOptions{MinScore: invalid}); err == nil {
+			t.Errorf("expected error for min score %g", invalid)
+		}
+	}
+}
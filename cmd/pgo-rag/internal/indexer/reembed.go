@@ -0,0 +1,166 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/storage"
+)
+
+// DefaultReembedBatch is the number of chunks re-embedded per batch.
+const DefaultReembedBatch = 500
+
+// ModelEmbedder is an Embedder that knows its model and can embed with
+// another one. Chunks are recorded with the model that embedded them, and
+// searches embed the query once per model still present in the index.
+type ModelEmbedder interface {
+	Embedder
+	Model() string
+	EmbedWithModel(model, text string) ([]float32, error)
+}
+
+// ReembedOptions configures a migration to another embeddings model.
+type ReembedOptions struct {
+	// Model is the model the embedder uses; migrated chunks record it.
+	Model string
+	// Batch is the number of chunks embedded and stored per transaction.
+	// Zero uses DefaultReembedBatch.
+	Batch int
+	// Resume continues a migration to Model that was stopped part way.
+	// Without it, Reembed refuses to touch a partly migrated index.
+	Resume bool
+}
+
+// ReembedSummary describes the result of a re-embed.
+type ReembedSummary struct {
+	Model string `json:"model"`
+	// Total is the number of chunks in the index.
+	Total int `json:"total"`
+	// Migrated counts the chunks re-embedded by this run.
+	Migrated int `json:"migrated"`
+	// Remaining counts the chunks still embedded by another model.
+	Remaining int `json:"remaining"`
+	Batches   int `json:"batches"`
+	// Interrupted is set when the context was canceled. Completed batches
+	// are persisted; rerun with Resume to finish.
+	Interrupted bool `json:"interrupted"`
+}
+
+// Reembed re-embeds every chunk not embedded by opts.Model, one batch at a
+// time. Each batch replaces its vectors in one transaction, and searches
+// compare unmigrated chunks with the query embedded by their old model, so
+// the index stays searchable throughout.
+func Reembed(ctx context.Context, db storage.Store, embedder Embedder, opts ReembedOptions) (ReembedSummary, error) {
+	summary, err := reembed(ctx, db, embedder, opts)
+	if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		summary.Interrupted = true
+	}
+	return summary, err
+}
+
+func reembed(ctx context.Context, db storage.Store, embedder Embedder, opts ReembedOptions) (ReembedSummary, error) {
+	summary := ReembedSummary{Model: opts.Model}
+	if db == nil {
+		return summary, errors.New("storage database is required")
+	}
+	if embedder == nil {
+		return summary, errors.New("embedder is required")
+	}
+	if opts.Model == "" {
+		return summary, errors.New("model is required")
+	}
+	batch := opts.Batch
+	if batch <= 0 {
+		batch = DefaultReembedBatch
+	}
+
+	counts, err := db.CountChunksByModel()
+	if err != nil {
+		return summary, err
+	}
+	for _, n := range counts {
+		summary.Total += n
+	}
+	done := counts[opts.Model]
+	summary.Remaining = summary.Total - done
+	if done > 0 && summary.Remaining > 0 && !opts.Resume {
+		return summary, fmt.Errorf("%d of %d chunks are already embedded by %s; pass -resume to continue the interrupted re-embed", done, summary.Total, opts.Model)
+	}
+
+	for summary.Remaining > 0 {
+		chunks, err := db.ChunksToReembed(opts.Model, batch)
+		if err != nil {
+			return summary, err
+		}
+		if len(chunks) == 0 {
+			break
+		}
+		for i := range chunks {
+			select {
+			case <-ctx.Done():
+				return summary, ctx.Err()
+			default:
+			}
+			vector, err := embedder.GenerateEmbedding(chunks[i].Content)
+			if err != nil {
+				return summary, fmt.Errorf("generate embedding for chunk %d: %w", chunks[i].ID, err)
+			}
+			chunks[i].Vector = vector
+		}
+		if err := db.UpdateChunkVectors(opts.Model, chunks); err != nil {
+			return summary, err
+		}
+		summary.Batches++
+		summary.Migrated += len(chunks)
+		summary.Remaining -= len(chunks)
+		slog.Info("Re-embedded batch",
+			"model", opts.Model,
+			"chunks", len(chunks),
+			"migrated", summary.Total-summary.Remaining,
+			"total", summary.Total,
+		)
+	}
+	summary.Remaining = max(summary.Remaining, 0)
+
+	return summary, nil
+}
+
+// embedderModel returns the model of embedder, or "" if it does not say.
+func embedderModel(embedder Embedder) string {
+	if me, ok := embedder.(ModelEmbedder); ok {
+		return me.Model()
+	}
+	return ""
+}
+
+// withModelVectors returns ranking with the query embedded by every other
+// model that still has chunks in the index, so a search during a re-embed
+// finds chunks on both sides of the migration. Chunks without a recorded
+// model are assumed to be embedded by embedder's model.
+func withModelVectors(db storage.Store, embedder Embedder, query string, ranking storage.RankOptions) (storage.RankOptions, error) {
+	me, ok := embedder.(ModelEmbedder)
+	if !ok {
+		return ranking, nil
+	}
+	counts, err := db.CountChunksByModel()
+	if err != nil {
+		return ranking, err
+	}
+	vectors := make(map[string][]float32)
+	for model := range counts {
+		if model == "" || model == me.Model() {
+			continue
+		}
+		vector, err := me.EmbedWithModel(model, query)
+		if err != nil {
+			return ranking, fmt.Errorf("generate embedding for query with %s: %w", model, err)
+		}
+		vectors[model] = vector
+	}
+	if len(vectors) > 0 {
+		ranking.ModelVectors = vectors
+	}
+	return ranking, nil
+}
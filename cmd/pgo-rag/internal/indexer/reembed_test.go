@@ -0,0 +1,126 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	paperless "github.com/jason-riddle/paperless-go"
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/storage"
+)
+
+// modelEmbedder embeds "old" text in 3 dimensions and "new" text in 2,
+// pointing each text containing "rent" the same way.
+type modelEmbedder struct {
+	model  string
+	cancel context.CancelFunc
+	calls  *int
+}
+
+func (m modelEmbedder) Model() string { return m.model }
+
+func (m modelEmbedder) GenerateEmbedding(text string) ([]float32, error) {
+	return m.EmbedWithModel(m.model, text)
+}
+
+func (m modelEmbedder) EmbedWithModel(model, text string) ([]float32, error) {
+	if m.calls != nil {
+		*m.calls++
+		if m.cancel != nil && *m.calls == 2 {
+			m.cancel()
+		}
+	}
+	rent := strings.Contains(text, "rent")
+	switch {
+	case model == "new" && rent:
+		return []float32{1, 0}, nil
+	case model == "new":
+		return []float32{0, 1}, nil
+	case rent:
+		return []float32{1, 0, 0}, nil
+	default:
+		return []float32{0, 0, 1}, nil
+	}
+}
+
+func TestReembedStaged(t *testing.T) {
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	for id, content := range map[int]string{1: "rent is due", 2: "rent receipt", 3: "bake bread"} {
+		vector, _ := modelEmbedder{model: "old"}.GenerateEmbedding(content)
+		if err := db.UpsertDocumentWithChunks(storage.Document{PaperlessID: id, PaperlessURL: "/doc"},
+			[]storage.Chunk{{Content: content, Vector: vector, Model: "old"}}); err != nil {
+			t.Fatalf("failed to upsert: %v", err)
+		}
+	}
+
+	// Cancel while the second batch of one chunk is embedded; like a build,
+	// the chunk in flight is still stored.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	newEmbedder := modelEmbedder{model: "new", cancel: cancel, calls: &calls}
+	summary, err := Reembed(ctx, db, newEmbedder, ReembedOptions{Model: "new", Batch: 1})
+	if !errors.Is(err, context.Canceled) || !summary.Interrupted {
+		t.Fatalf("expected an interrupted re-embed, got %+v, %v", summary, err)
+	}
+	if summary.Migrated != 2 || summary.Remaining != 1 || summary.Total != 3 {
+		t.Fatalf("expected 2 of 3 chunks migrated, got %+v", summary)
+	}
+
+	// Both migrated and old chunks are found, whichever model searches.
+	for _, searcher := range []modelEmbedder{{model: "old"}, {model: "new"}} {
+		results, err := SearchIndexWithOptions(context.Background(), db, searcher, "rent", SearchOptions{Limit: 10, MinScore: 0.5})
+		if err != nil {
+			t.Fatalf("search with %s failed: %v", searcher.model, err)
+		}
+		if len(results.Results) != 2 {
+			t.Errorf("search with %s: expected both rent documents, got %+v", searcher.model, results.Results)
+		}
+	}
+
+	if _, err := Reembed(context.Background(), db, modelEmbedder{model: "new"}, ReembedOptions{Model: "new"}); err == nil || !strings.Contains(err.Error(), "-resume") {
+		t.Fatalf("expected a partly migrated index to require resume, got %v", err)
+	}
+
+	summary, err = Reembed(context.Background(), db, modelEmbedder{model: "new"}, ReembedOptions{Model: "new", Resume: true})
+	if err != nil {
+		t.Fatalf("resumed re-embed failed: %v", err)
+	}
+	if summary.Migrated != 1 || summary.Remaining != 0 || summary.Batches != 1 {
+		t.Fatalf("expected the remaining chunk in one batch, got %+v", summary)
+	}
+	counts, err := db.CountChunksByModel()
+	if err != nil {
+		t.Fatalf("CountChunksByModel failed: %v", err)
+	}
+	if len(counts) != 1 || counts["new"] != 3 {
+		t.Errorf("expected every chunk on the new model, got %v", counts)
+	}
+}
+
+func TestBuildIndexRecordsModel(t *testing.T) {
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	client := fakePaperless{documents: []paperless.Document{{ID: 1, Title: "Lease", Content: "rent is due"}}}
+	if _, err := BuildIndex(context.Background(), client, db, modelEmbedder{model: "old"}, BuildOptions{}); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	counts, err := db.CountChunksByModel()
+	if err != nil {
+		t.Fatalf("CountChunksByModel failed: %v", err)
+	}
+	if len(counts) != 1 || counts["old"] == 0 {
+		t.Errorf("expected chunks recorded with model old, got %v", counts)
+	}
+}
@@ -0,0 +1,130 @@
+package indexer
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/storage"
+)
+
+// Groupings accepted by Stats.
+const (
+	GroupByTag           = "tag"
+	GroupByCorrespondent = "correspondent"
+)
+
+// NoGroup is the group of documents without tags or correspondent.
+const NoGroup = "(none)"
+
+// GroupStats summarizes the indexed documents of one tag or correspondent.
+type GroupStats struct {
+	Group      string `json:"group"`
+	Documents  int    `json:"documents"`
+	Chunks     int    `json:"chunks"`
+	SearchHits int    `json:"search_hits"`
+	// HitRate is the number of search hits per document of the group.
+	HitRate float64 `json:"hit_rate"`
+	// AvgSimilarity is the mean similarity score of the group's hits, 0
+	// without hits.
+	AvgSimilarity float64 `json:"avg_similarity"`
+}
+
+// StatsSummary is the result of Stats. A document with several tags counts
+// in the group of each, so the groups may add up to more than the totals.
+type StatsSummary struct {
+	GroupBy    string       `json:"group_by"`
+	Documents  int          `json:"documents"`
+	Chunks     int          `json:"chunks"`
+	SearchHits int          `json:"search_hits"`
+	Groups     []GroupStats `json:"groups"`
+}
+
+// Stats summarizes the index per tag or correspondent, most documents first.
+// Search hits are counted by searches and questions since the documents
+// were first indexed.
+func Stats(db storage.Store, groupBy string) (StatsSummary, error) {
+	summary := StatsSummary{GroupBy: groupBy, Groups: []GroupStats{}}
+	if groupBy != GroupByTag && groupBy != GroupByCorrespondent {
+		return summary, fmt.Errorf("unknown grouping %q: use %s or %s", groupBy, GroupByTag, GroupByCorrespondent)
+	}
+	docs, err := db.DocumentStats()
+	if err != nil {
+		return summary, err
+	}
+
+	groups := make(map[string]*GroupStats)
+	scores := make(map[string]float64)
+	for _, doc := range docs {
+		summary.Documents++
+		summary.Chunks += doc.Chunks
+		summary.SearchHits += doc.SearchHits
+		for _, name := range documentGroups(doc, groupBy) {
+			g := groups[name]
+			if g == nil {
+				g = &GroupStats{Group: name}
+				groups[name] = g
+			}
+			g.Documents++
+			g.Chunks += doc.Chunks
+			g.SearchHits += doc.SearchHits
+			scores[name] += doc.ScoreSum
+		}
+	}
+
+	for name, g := range groups {
+		g.HitRate = float64(g.SearchHits) / float64(g.Documents)
+		if g.SearchHits > 0 {
+			g.AvgSimilarity = scores[name] / float64(g.SearchHits)
+		}
+		summary.Groups = append(summary.Groups, *g)
+	}
+	sort.Slice(summary.Groups, func(i, j int) bool {
+		a, b := summary.Groups[i], summary.Groups[j]
+		if a.Documents != b.Documents {
+			return a.Documents > b.Documents
+		}
+		return a.Group < b.Group
+	})
+	return summary, nil
+}
+
+// documentGroups returns the groups doc belongs to. Tags are stored as
+// written by formatTags.
+func documentGroups(doc storage.DocumentStats, groupBy string) []string {
+	if groupBy == GroupByCorrespondent {
+		if doc.Correspondent == "" {
+			return []string{NoGroup}
+		}
+		return []string{doc.Correspondent}
+	}
+	if strings.TrimSpace(doc.Tags) == "" {
+		return []string{NoGroup}
+	}
+	return strings.Split(doc.Tags, ", ")
+}
+
+// recordSearchHits counts results for Stats. Failing to count them does
+// not fail the search.
+func recordSearchHits(db storage.Store, results []storage.SearchResult) {
+	if err := db.RecordSearchHits(results); err != nil {
+		slog.Warn("Failed to record search hits", "error", err)
+	}
+}
+
+// documentHits returns one hit per document of chunks, with the score of
+// its best chunk.
+func documentHits(chunks []storage.ChunkResult) []storage.SearchResult {
+	var hits []storage.SearchResult
+	seen := make(map[int]int)
+	for _, chunk := range chunks {
+		if i, ok := seen[chunk.PaperlessID]; ok {
+			hits[i].SimilarityScore = max(hits[i].SimilarityScore, chunk.SimilarityScore)
+			continue
+		}
+		seen[chunk.PaperlessID] = len(hits)
+		hits = append(hits, chunk.SearchResult)
+	}
+	return hits
+}
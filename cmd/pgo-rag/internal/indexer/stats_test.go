@@ -0,0 +1,80 @@
+package indexer
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+
+	paperless "github.com/jason-riddle/paperless-go"
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/storage"
+)
+
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	acme := 7
+	client := fakePaperless{
+		documents: []paperless.Document{
+			{ID: 1, Title: "Invoice", Content: "invoice total", Tags: []int{1, 2}, Correspondent: &acme},
+			{ID: 2, Title: "Receipt", Content: "receipt total", Tags: []int{1}},
+			{ID: 3, Title: "Letter", Content: "dear sir"},
+		},
+		tags:           []paperless.Tag{{ID: 1, Name: "finance"}, {ID: 2, Name: "inbox"}},
+		correspondents: []paperless.Correspondent{{ID: 7, Name: "ACME"}},
+	}
+	embedder := fakeEmbedder{vectors: map[string][]float32{
+		buildEmbeddingText("Invoice", "finance, inbox", "invoice total"): {1, 0, 0},
+		buildEmbeddingText("Receipt", "finance", "receipt total"):        {0.6, 0.8, 0},
+		buildEmbeddingText("Letter", "", "dear sir"):                     {0, 0, 1},
+		"total": {1, 0, 0},
+	}}
+	if _, err := BuildIndex(ctx, client, db, embedder, BuildOptions{}); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	if _, err := SearchIndex(ctx, db, embedder, "total", 5, 0.5); err != nil {
+		t.Fatalf("SearchIndex failed: %v", err)
+	}
+
+	byTag, err := Stats(db, GroupByTag)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if byTag.Documents != 3 || byTag.Chunks != 3 || byTag.SearchHits != 2 {
+		t.Errorf("totals = %+v", byTag)
+	}
+	want := []GroupStats{
+		{Group: "finance", Documents: 2, Chunks: 2, SearchHits: 2, HitRate: 1, AvgSimilarity: 0.8},
+		{Group: NoGroup, Documents: 1, Chunks: 1},
+		{Group: "inbox", Documents: 1, Chunks: 1, SearchHits: 1, HitRate: 1, AvgSimilarity: 1},
+	}
+	if len(byTag.Groups) != len(want) {
+		t.Fatalf("groups = %+v, want %+v", byTag.Groups, want)
+	}
+	for i, g := range byTag.Groups {
+		w := want[i]
+		if g.Group != w.Group || g.Documents != w.Documents || g.Chunks != w.Chunks || g.SearchHits != w.SearchHits ||
+			g.HitRate != w.HitRate || math.Abs(g.AvgSimilarity-w.AvgSimilarity) > 1e-6 {
+			t.Errorf("group %d = %+v, want %+v", i, g, w)
+		}
+	}
+
+	byCorrespondent, err := Stats(db, GroupByCorrespondent)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if len(byCorrespondent.Groups) != 2 || byCorrespondent.Groups[0].Group != NoGroup ||
+		byCorrespondent.Groups[1].Group != "ACME" || byCorrespondent.Groups[1].SearchHits != 1 {
+		t.Errorf("groups = %+v", byCorrespondent.Groups)
+	}
+
+	if _, err := Stats(db, "year"); err == nil {
+		t.Error("Stats accepted an unknown grouping")
+	}
+}
@@ -0,0 +1,52 @@
+package profile
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringService is the service pgo login stores tokens under in the OS
+// keyring, with the Paperless URL as the account.
+const keyringService = "pgo"
+
+// KeyringToken returns the token pgo login stored for the instance at
+// baseURL, or "" if there is none. Like pgo, it asks security on macOS and
+// secret-tool (libsecret) elsewhere; a missing tool means there is no token,
+// and other failures are logged.
+func KeyringToken(baseURL string) string {
+	account := strings.TrimRight(baseURL, "/")
+	var (
+		name string
+		args []string
+	)
+	switch runtime.GOOS {
+	case "darwin":
+		name, args = "security", []string{"find-generic-password", "-s", keyringService, "-a", account, "-w"}
+	case "windows":
+		return ""
+	default:
+		name, args = "secret-tool", []string{"lookup", "service", keyringService, "account", account}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		switch {
+		case errors.Is(err, exec.ErrNotFound):
+		// security exits with 44, and secret-tool with 1 and no message,
+		// when there is no such token.
+		case errors.As(err, &exitErr) && name == "security" && exitErr.ExitCode() == 44:
+		case errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && stderr.Len() == 0:
+		default:
+			slog.Warn("Could not read the token from the OS keyring", "tool", name, "error", err, "stderr", strings.TrimSpace(stderr.String()))
+		}
+		return ""
+	}
+	return strings.TrimSpace(stdout.String())
+}
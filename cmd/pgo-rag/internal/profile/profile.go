@@ -0,0 +1,225 @@
+// Package profile reads Paperless credentials from the pgo config file, so
+// pgo and pgo-rag share one set of named profiles.
+package profile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Profile is the part of a pgo profile pgo-rag uses.
+type Profile struct {
+	Name  string
+	URL   string
+	Token string
+	// MaxRate is the most requests per second to send to the instance,
+	// shared with pgo; 0 for no limit.
+	MaxRate float64
+}
+
+// Config is the pgo config file:
+//
+//	default_profile = "home"
+//
+//	[profiles.home]
+//	url = "https://paperless.home.example"
+//	token = "..."
+//
+// Keys only pgo uses, such as tags or notify, are ignored; pgo validates
+// them.
+type Config struct {
+	DefaultProfile string
+	Profiles       map[string]*Profile
+}
+
+// Settings are the Paperless connection settings from the environment and
+// the config.
+type Settings struct {
+	URL     string
+	Token   string
+	MaxRate float64
+}
+
+// DefaultPath returns the pgo config file path, preferring XDG_CONFIG_HOME.
+func DefaultPath() (string, error) {
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		return filepath.Join(configHome, "paperless-go", "config.toml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "paperless-go", "config.toml"), nil
+}
+
+// Load reads the config file at path. A missing file is an empty config.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return &Config{Profiles: map[string]*Profile{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open config: %w", err)
+	}
+	defer f.Close()
+
+	cfg, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Resolve picks the settings the same way pgo does, before flags are
+// applied: a profile selected explicitly (-profile or PAPERLESS_PROFILE)
+// overrides PAPERLESS_URL, PAPERLESS_TOKEN and PAPERLESS_MAX_RATE, while the
+// config's default_profile only fills in what the environment leaves unset.
+func Resolve(cfg *Config, profileName string, getenv func(string) string) (Settings, error) {
+	explicit := profileName != ""
+	if !explicit {
+		profileName = cfg.DefaultProfile
+	}
+
+	var p Profile
+	if profileName != "" {
+		found, ok := cfg.Profiles[profileName]
+		if !ok {
+			return Settings{}, fmt.Errorf("profile %q not found in config", profileName)
+		}
+		p = *found
+	}
+
+	pick := func(profileValue, envValue string) string {
+		if envValue == "" || (explicit && profileValue != "") {
+			return profileValue
+		}
+		return envValue
+	}
+	s := Settings{
+		URL:     pick(p.URL, getenv("PAPERLESS_URL")),
+		Token:   pick(p.Token, getenv("PAPERLESS_TOKEN")),
+		MaxRate: p.MaxRate,
+	}
+	if env := strings.TrimSpace(getenv("PAPERLESS_MAX_RATE")); env != "" && (s.MaxRate == 0 || !explicit) {
+		rate, err := strconv.ParseFloat(env, 64)
+		if err != nil || rate < 0 {
+			return Settings{}, fmt.Errorf("invalid PAPERLESS_MAX_RATE %q (requests per second)", env)
+		}
+		s.MaxRate = rate
+	}
+	return s, nil
+}
+
+// Parse parses the keys pgo-rag needs from a pgo config file: the root
+// default_profile and each [profiles.<name>] table's url, token and
+// max_rate.
+func Parse(r io.Reader) (*Config, error) {
+	cfg := &Config{Profiles: map[string]*Profile{}}
+	var current *Profile
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			end := strings.LastIndex(line, "]")
+			if strings.HasPrefix(line, "[[") || end < 0 {
+				return nil, fmt.Errorf("line %d: unsupported table header %s", n, line)
+			}
+			name, ok := strings.CutPrefix(strings.TrimSpace(line[1:end]), "profiles.")
+			if !ok || name == "" {
+				return nil, fmt.Errorf("line %d: unknown table %s (expected [profiles.<name>])", n, line[:end+1])
+			}
+			if cfg.Profiles[name] == nil {
+				cfg.Profiles[name] = &Profile{Name: name}
+			}
+			current = cfg.Profiles[name]
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		var dst *string
+		switch {
+		case current == nil && key == "default_profile":
+			dst = &cfg.DefaultProfile
+		case current != nil && key == "url":
+			dst = &current.URL
+		case current != nil && key == "token":
+			dst = &current.Token
+		case current != nil && key == "max_rate":
+			word, _, _ := strings.Cut(value, "#")
+			rate, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(word), "_", ""), 64)
+			if err != nil || rate < 0 {
+				return nil, fmt.Errorf("line %d: max_rate must be a number of requests per second", n)
+			}
+			current.MaxRate = rate
+			continue
+		default:
+			continue
+		}
+		s, err := parseString(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", n, key, err)
+		}
+		*dst = s
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if cfg.DefaultProfile != "" && cfg.Profiles[cfg.DefaultProfile] == nil {
+		return nil, fmt.Errorf("default_profile %q is not defined", cfg.DefaultProfile)
+	}
+	return cfg, nil
+}
+
+// parseString parses a basic "..." or literal '...' string, followed by
+// nothing but an optional comment.
+func parseString(s string) (string, error) {
+	var value, rest string
+	switch {
+	case strings.HasPrefix(s, "'"):
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string")
+		}
+		value, rest = s[1:end+1], s[end+2:]
+	case strings.HasPrefix(s, `"`):
+		end := -1
+		for i := 1; i < len(s) && end < 0; i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				end = i
+			}
+		}
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string")
+		}
+		v, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", s[:end+1])
+		}
+		value, rest = v, s[end+1:]
+	default:
+		return "", fmt.Errorf("must be a string")
+	}
+	if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected %q after value", rest)
+	}
+	return value, nil
+}
@@ -0,0 +1,122 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `
+# Shared with pgo
+default_profile = "home"
+
+[profiles.home]
+url = "https://paperless.home.example"
+token = 'home-token' # literal string
+tags = [1, 5]
+
+[profiles.work]
+url = "https://paperless.example.com"
+token = "work\"token"
+notify = "desktop"
+production = true
+max_rate = 5
+`
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if cfg.DefaultProfile != "home" || len(cfg.Profiles) != 2 {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if p := cfg.Profiles["home"]; p.URL != "https://paperless.home.example" || p.Token != "home-token" || p.MaxRate != 0 {
+		t.Errorf("home = %+v", p)
+	}
+	if p := cfg.Profiles["work"]; p.Name != "work" || p.Token != `work"token` || p.MaxRate != 5 {
+		t.Errorf("work = %+v", p)
+	}
+
+	for _, bad := range []string{
+		"default_profile = \"missing\"",
+		"[settings]\nurl = \"x\"",
+		"[profiles.a]\nurl = https://x",
+		"[profiles.a]\ntoken = \"open",
+		"[profiles.a]\nmax_rate = -1",
+		"[profiles.a]\nurl",
+	} {
+		if _, err := Parse(strings.NewReader(bad)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(filepath.Join(dir, "missing.toml"))
+	if err != nil || len(cfg.Profiles) != 0 {
+		t.Fatalf("expected an empty config for a missing file, got %+v, %v", cfg, err)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	path, err := DefaultPath()
+	if err != nil || path != filepath.Join(dir, "paperless-go", "config.toml") {
+		t.Fatalf("DefaultPath = %q, %v", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(testConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := Load(path); err != nil || cfg.Profiles["work"] == nil {
+		t.Fatalf("Load = %+v, %v", cfg, err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+	envURL := map[string]string{"PAPERLESS_URL": "https://env.example", "PAPERLESS_MAX_RATE": "2"}
+
+	tests := []struct {
+		name    string
+		profile string
+		env     map[string]string
+		want    Settings
+	}{
+		{"default profile", "", nil, Settings{URL: "https://paperless.home.example", Token: "home-token"}},
+		{"environment over default profile", "", envURL, Settings{URL: "https://env.example", Token: "home-token", MaxRate: 2}},
+		{"explicit profile over environment", "work", envURL, Settings{URL: "https://paperless.example.com", Token: `work"token`, MaxRate: 5}},
+		{"environment fills in explicit profile", "home", envURL, Settings{URL: "https://paperless.home.example", Token: "home-token", MaxRate: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(cfg, tt.profile, env(tt.env))
+			if err != nil {
+				t.Fatalf("Resolve failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Resolve(cfg, "missing", env(nil)); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+	if _, err := Resolve(cfg, "", env(map[string]string{"PAPERLESS_MAX_RATE": "fast"})); err == nil {
+		t.Error("expected error for invalid PAPERLESS_MAX_RATE")
+	}
+	empty := &Config{Profiles: map[string]*Profile{}}
+	if got, err := Resolve(empty, "", env(envURL)); err != nil || got.URL != "https://env.example" || got.Token != "" {
+		t.Errorf("Resolve without config = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// AccessConfig controls who may call the search API. Health endpoints are
+// always open so orchestrator probes need no credentials.
+type AccessConfig struct {
+	// BearerToken, if set, is accepted as "Authorization: Bearer <token>".
+	BearerToken string
+	// BasicUser and BasicPassword, if set, are accepted as basic auth.
+	// Either credential is enough when both kinds are configured.
+	BasicUser     string
+	BasicPassword string
+	// AllowedOrigins are the browser origins allowed to call the API
+	// cross-origin, or "*" for any origin.
+	AllowedOrigins []string
+}
+
+// RequiresAuth reports whether any credential is configured.
+func (c AccessConfig) RequiresAuth() bool {
+	return c.BearerToken != "" || c.BasicUser != ""
+}
+
+// WithAccess wraps h with CORS handling and, if credentials are configured,
+// authentication of everything but /livez and /readyz.
+func WithAccess(h http.Handler, cfg AccessConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && cfg.allowsOrigin(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+			// Preflights carry no credentials, so they are answered
+			// before authentication.
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+				w.Header().Set("Access-Control-Max-Age", "600")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+
+		if cfg.RequiresAuth() && r.URL.Path != "/livez" && r.URL.Path != "/readyz" && !cfg.authorized(r) {
+			if cfg.BasicUser != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="pgo-rag"`)
+			} else {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			}
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (c AccessConfig) allowsOrigin(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c AccessConfig) authorized(r *http.Request) bool {
+	if c.BearerToken != "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && secureEqual(token, c.BearerToken) {
+			return true
+		}
+	}
+	if c.BasicUser != "" {
+		if user, password, ok := r.BasicAuth(); ok && secureEqual(user, c.BasicUser) && secureEqual(password, c.BasicPassword) {
+			return true
+		}
+	}
+	return false
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// ClientCATLSConfig returns a TLS config that requires clients to present a
+// certificate signed by a CA in the PEM file at caFile.
+func ClientCATLSConfig(caFile string) (*tls.Config, error) {
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithAccess(t *testing.T) {
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := WithAccess(inner, AccessConfig{
+		BearerToken:    "s3cret",
+		BasicUser:      "dash",
+		BasicPassword:  "board",
+		AllowedOrigins: []string{"http://homeassistant.local:8123"},
+	})
+
+	request := func(method, target string, setup func(r *http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if setup != nil {
+			setup(req)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name  string
+		setup func(r *http.Request)
+		want  int
+	}{
+		{"no credentials", nil, http.StatusUnauthorized},
+		{"bearer token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cret") }, http.StatusOK},
+		{"wrong bearer token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") }, http.StatusUnauthorized},
+		{"basic auth", func(r *http.Request) { r.SetBasicAuth("dash", "board") }, http.StatusOK},
+		{"wrong password", func(r *http.Request) { r.SetBasicAuth("dash", "s3cret") }, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := request("GET", "/search?q=x", tt.setup); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	if rec := request("GET", "/search?q=x", nil); rec.Header().Get("WWW-Authenticate") == "" {
+		t.Error("401 response is missing WWW-Authenticate")
+	}
+	for _, path := range []string{"/livez", "/readyz"} {
+		if rec := request("GET", path, nil); rec.Code != http.StatusOK {
+			t.Errorf("%s without credentials returned %d, want it open for probes", path, rec.Code)
+		}
+	}
+
+	preflight := request("OPTIONS", "/search", func(r *http.Request) {
+		r.Header.Set("Origin", "http://homeassistant.local:8123")
+		r.Header.Set("Access-Control-Request-Method", "GET")
+	})
+	if preflight.Code != http.StatusNoContent || preflight.Header().Get("Access-Control-Allow-Origin") != "http://homeassistant.local:8123" {
+		t.Errorf("preflight returned %d with headers %v", preflight.Code, preflight.Header())
+	}
+
+	other := request("GET", "/search?q=x", func(r *http.Request) {
+		r.Header.Set("Origin", "http://evil.example")
+		r.Header.Set("Authorization", "Bearer s3cret")
+	})
+	if got := other.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("disallowed origin got Access-Control-Allow-Origin %q", got)
+	}
+}
+
+func TestWithAccessOpenByDefault(t *testing.T) {
+	handler := WithAccess(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), AccessConfig{AllowedOrigins: []string{"*"}})
+	req := httptest.NewRequest("GET", "/search?q=x", nil)
+	req.Header.Set("Origin", "http://dashboard.lan")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "http://dashboard.lan" {
+		t.Errorf("got %d with headers %v", rec.Code, rec.Header())
+	}
+}
+
+func TestClientCATLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.pem")
+	if err := os.WriteFile(bad, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ClientCATLSConfig(bad); err == nil {
+		t.Error("expected an error for a file without certificates")
+	}
+	if _, err := ClientCATLSConfig(filepath.Join(dir, "missing.pem")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/indexer"
+)
+
+// DefaultConverseMaxLength is the default maximum length, in characters, of
+// a /converse answer: about 20 seconds of speech.
+const DefaultConverseMaxLength = 300
+
+// converseInstructions ask the model for an answer that works when read
+// aloud by a voice assistant.
+const converseInstructions = `Answer in one or two short sentences of plain text that can be read aloud: no lists, headings or formatting.`
+
+// ConverseConfig enables /converse.
+type ConverseConfig struct {
+	Chatter indexer.Chatter
+	Ask     indexer.AskOptions
+	// MaxLength caps the answer length in characters. Zero uses
+	// DefaultConverseMaxLength.
+	MaxLength int
+}
+
+// EnableConverse serves /converse, answering questions with cfg.Chatter.
+// Without it /converse responds 404.
+func (s *Server) EnableConverse(cfg ConverseConfig) {
+	if cfg.MaxLength <= 0 {
+		cfg.MaxLength = DefaultConverseMaxLength
+	}
+	cfg.Ask.Instructions = converseInstructions
+	s.converse = &cfg
+}
+
+// converseRequest is the POST body of /converse. "text" matches the field
+// Home Assistant sends for conversation input.
+type converseRequest struct {
+	Text      string `json:"text"`
+	MaxLength int    `json:"max_length"`
+}
+
+func (s *Server) handleConverse(w http.ResponseWriter, r *http.Request) {
+	if s.converse == nil {
+		writeError(w, http.StatusNotFound, "converse is not enabled; start serve with -chat-model")
+		return
+	}
+
+	var req converseRequest
+	switch r.Method {
+	case http.MethodGet:
+		req.Text = r.URL.Query().Get("q")
+		if v := r.URL.Query().Get("max_length"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "max_length must be a positive integer")
+				return
+			}
+			req.MaxLength = n
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(io.LimitReader(r.Body, 64<<10)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		writeError(w, http.StatusBadRequest, "q or text is required")
+		return
+	}
+	if req.MaxLength < 0 {
+		writeError(w, http.StatusBadRequest, "max_length must be a positive integer")
+		return
+	}
+	maxLength := s.converse.MaxLength
+	if req.MaxLength > 0 && req.MaxLength < maxLength {
+		maxLength = req.MaxLength
+	}
+
+	summary, err := indexer.Ask(r.Context(), s.db, s.embedder, s.converse.Chatter, req.Text, s.converse.Ask)
+	if err != nil {
+		slog.Error("Converse failed", "error", err)
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, SpokenAnswer(summary.Answer, maxLength))
+}
+
+var (
+	citationMarks = regexp.MustCompile(`\s*\[\d+(?:\s*,\s*\d+)*\]`)
+	markdownMarks = regexp.MustCompile("(?m)^\\s*(?:#+|[-*]|\\d+\\.)\\s+|[*_`]+")
+)
+
+// SpokenAnswer turns an answer into plain text for a voice assistant: it
+// drops citation markers and markdown and shortens the text to maxLength
+// characters, preferably at the end of a sentence.
+func SpokenAnswer(answer string, maxLength int) string {
+	text := citationMarks.ReplaceAllString(answer, "")
+	text = markdownMarks.ReplaceAllString(text, "")
+	text = strings.Join(strings.Fields(text), " ")
+
+	runes := []rune(text)
+	if maxLength <= 0 || len(runes) <= maxLength {
+		return text
+	}
+	cut := string(runes[:maxLength])
+	if i := strings.LastIndexAny(cut, ".!?"); i > len(cut)/2 {
+		return cut[:i+1]
+	}
+	cut = string(runes[:maxLength-1])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:") + "…"
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/chat"
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/storage"
+)
+
+type fakeChatter struct {
+	answer   string
+	messages []chat.Message
+}
+
+func (f *fakeChatter) Complete(_ context.Context, messages []chat.Message) (string, error) {
+	f.messages = messages
+	return f.answer, nil
+}
+
+func TestConverseEndpoint(t *testing.T) {
+	srv, _, db := newTestServer(t)
+	handler := srv.Handler()
+
+	if code := get(t, handler, "/converse?q=deposit", &map[string]string{}); code != http.StatusNotFound {
+		t.Errorf("/converse without a chat model returned %d, want 404", code)
+	}
+
+	doc := storage.Document{PaperlessID: 4, PaperlessURL: "/api/documents/4/", Title: "Lease"}
+	if err := db.UpsertDocumentWithChunks(doc, []storage.Chunk{{Content: "The deposit is 500 euros.", Vector: []float32{1, 0, 0}}}); err != nil {
+		t.Fatalf("failed to index document: %v", err)
+	}
+	chatter := &fakeChatter{answer: "**The deposit** is 500 euros [1]. It is refundable at the end of the lease [1, 2]."}
+	srv.EnableConverse(ConverseConfig{Chatter: chatter, MaxLength: 100})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/converse", strings.NewReader(`{"text": "How much is the deposit?"}`)))
+	want := "The deposit is 500 euros. It is refundable at the end of the lease."
+	if rec.Code != http.StatusOK || rec.Body.String() != want {
+		t.Errorf("POST /converse = %d %q, want %q", rec.Code, rec.Body.String(), want)
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(chatter.messages[0].Content, converseInstructions) {
+		t.Errorf("system prompt does not ask for a short answer: %q", chatter.messages[0].Content)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/converse?q=deposit&max_length=30", nil))
+	if rec.Body.String() != "The deposit is 500 euros." {
+		t.Errorf("GET /converse with max_length = %q", rec.Body.String())
+	}
+
+	for _, target := range []string{"/converse", "/converse?q=deposit&max_length=x"} {
+		if code := get(t, handler, target, &map[string]string{}); code != http.StatusBadRequest {
+			t.Errorf("%s returned %d, want 400", target, code)
+		}
+	}
+}
+
+func TestSpokenAnswer(t *testing.T) {
+	tests := []struct {
+		answer    string
+		maxLength int
+		want      string
+	}{
+		{"Rent is due monthly [2].", 100, "Rent is due monthly."},
+		{"- First item\n- Second `item`", 100, "First item Second item"},
+		{"The lease runs until 2026. It renews automatically every year after that.", 40, "The lease runs until 2026."},
+		{"The insurance covers water damage and theft in the apartment", 30, "The insurance covers water…"},
+	}
+	for _, tt := range tests {
+		if got := SpokenAnswer(tt.answer, tt.maxLength); got != tt.want {
+			t.Errorf("SpokenAnswer(%q, %d) = %q, want %q", tt.answer, tt.maxLength, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package server exposes the index over HTTP for pgo-rag serve.
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/indexer"
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/storage"
+)
+
+// Pinger is implemented by embedders that can check their API is reachable
+// without generating an embedding.
+type Pinger interface {
+	Ping() error
+}
+
+// Health is the result of a readiness check.
+type Health struct {
+	Ready    bool   `json:"ready"`
+	Index    string `json:"index"`
+	Embedder string `json:"embedder"`
+	// ConsecutiveFailures counts the failed checks since the last
+	// successful one.
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	CheckedAt           time.Time `json:"checked_at"`
+}
+
+// Server serves search requests and health endpoints for orchestrators.
+type Server struct {
+	db       storage.Store
+	embedder indexer.Embedder
+	converse *ConverseConfig
+	ui       bool
+
+	mu     sync.RWMutex
+	health Health
+}
+
+// New returns a server for db. It reports not ready until CheckHealth has
+// run.
+func New(db storage.Store, embedder indexer.Embedder) *Server {
+	return &Server{
+		db:       db,
+		embedder: embedder,
+		health:   Health{Index: "not checked", Embedder: "not checked"},
+	}
+}
+
+// CheckHealth checks that the index can be queried and the embedder is
+// reachable, and records the result for /readyz. Checks are run by the
+// caller, typically on a timer, so probes do not hit the embeddings API.
+func (s *Server) CheckHealth() Health {
+	health := Health{Ready: true, Index: "ok", Embedder: "ok", CheckedAt: time.Now().UTC()}
+	if _, err := s.db.CountDocuments(); err != nil {
+		health.Ready = false
+		health.Index = err.Error()
+	}
+	if pinger, ok := s.embedder.(Pinger); ok {
+		if err := pinger.Ping(); err != nil {
+			health.Ready = false
+			health.Embedder = err.Error()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !health.Ready {
+		health.ConsecutiveFailures = s.health.ConsecutiveFailures + 1
+		slog.Warn("Health check failed",
+			"index", health.Index,
+			"embedder", health.Embedder,
+			"consecutive_failures", health.ConsecutiveFailures,
+		)
+	}
+	s.health = health
+	return health
+}
+
+// Handler returns the HTTP handler:
+//
+//	GET /  the search page, if enabled
+//	GET /search?q=<text>[&limit=10][&min_score=0.7][&snippets=true]  search
+//	    results as JSON
+//	GET /converse?q=<text>[&max_length=300]  a short plain-text answer, if
+//	    enabled; POST accepts {"text": ..., "max_length": ...}
+//	GET /livez   200 while the process is serving
+//	GET /readyz  200 if the last health check passed, 503 otherwise
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", s.handleUI)
+	mux.HandleFunc("/search", s.handleSearch)
+	mux.HandleFunc("/converse", s.handleConverse)
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/readyz", s.handleReady)
+	return mux
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	health := s.health
+	s.mu.RUnlock()
+
+	status := http.StatusOK
+	if !health.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, health)
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	opts := indexer.SearchOptions{Limit: 10, MinScore: indexer.DefaultMinScore}
+	if query.Get("q") == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		opts.Limit = limit
+	}
+	if v := query.Get("min_score"); v != "" {
+		score, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "min_score must be a number")
+			return
+		}
+		if err := indexer.ValidateMinScore(score); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts.MinScore = score
+	}
+	if v := query.Get("snippets"); v != "" {
+		snippets, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "snippets must be true or false")
+			return
+		}
+		opts.Snippets = snippets
+	}
+
+	summary, err := indexer.SearchIndexWithOptions(r.Context(), s.db, s.embedder, query.Get("q"), opts)
+	if err != nil {
+		slog.Error("Search failed", "error", err)
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, summary)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		slog.Error("Failed to write response", "error", err)
+	}
+}
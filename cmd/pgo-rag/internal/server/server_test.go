@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/indexer"
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/storage"
+)
+
+type fakeEmbedder struct {
+	pingErr *error
+}
+
+func (f fakeEmbedder) GenerateEmbedding(text string) ([]float32, error) {
+	return []float32{1, 0, 0}, nil
+}
+
+func (f fakeEmbedder) Ping() error {
+	return *f.pingErr
+}
+
+func newTestServer(t *testing.T) (*Server, *error, *storage.DB) {
+	t.Helper()
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	var pingErr error
+	return New(db, fakeEmbedder{pingErr: &pingErr}), &pingErr, db
+}
+
+func get(t *testing.T, handler http.Handler, target string, v interface{}) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("invalid JSON from %s: %v\n%s", target, err, rec.Body.String())
+		}
+	}
+	return rec.Code
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	srv, pingErr, db := newTestServer(t)
+	handler := srv.Handler()
+
+	if code := get(t, handler, "/livez", nil); code != http.StatusOK {
+		t.Errorf("/livez returned %d", code)
+	}
+
+	var health Health
+	if code := get(t, handler, "/readyz", &health); code != http.StatusServiceUnavailable || health.Ready {
+		t.Errorf("/readyz before the first check returned %d, %+v", code, health)
+	}
+
+	srv.CheckHealth()
+	if code := get(t, handler, "/readyz", &health); code != http.StatusOK || !health.Ready {
+		t.Errorf("/readyz after a passing check returned %d, %+v", code, health)
+	}
+
+	*pingErr = errors.New("connection refused")
+	srv.CheckHealth()
+	if got := srv.CheckHealth(); got.ConsecutiveFailures != 2 || got.Embedder != "connection refused" || got.Index != "ok" {
+		t.Errorf("unexpected health after two failed checks: %+v", got)
+	}
+	if code := get(t, handler, "/readyz", &health); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz with an unreachable embedder returned %d", code)
+	}
+
+	*pingErr = nil
+	db.Close()
+	if got := srv.CheckHealth(); got.Ready || got.Index == "ok" || got.ConsecutiveFailures != 3 {
+		t.Errorf("expected a closed index to fail the check, got %+v", got)
+	}
+	if code := get(t, handler, "/livez", nil); code != http.StatusOK {
+		t.Errorf("/livez should not depend on dependencies, returned %d", code)
+	}
+}
+
+func TestSearchEndpoint(t *testing.T) {
+	srv, _, db := newTestServer(t)
+	handler := srv.Handler()
+
+	doc := storage.Document{PaperlessID: 4, PaperlessURL: "/api/documents/4/", Title: "Lease"}
+	if err := db.UpsertDocumentWithChunks(doc, []storage.Chunk{{Content: "Lease\n\nThe lease runs a year. Rent is due monthly.", Vector: []float32{1, 0, 0}}}); err != nil {
+		t.Fatalf("failed to index document: %v", err)
+	}
+
+	var summary indexer.SearchSummary
+	if code := get(t, handler, "/search?q=lease&limit=5", &summary); code != http.StatusOK {
+		t.Fatalf("/search returned %d", code)
+	}
+	if len(summary.Results) != 1 || summary.Results[0].PaperlessID != 4 || summary.Results[0].Snippet != "" {
+		t.Errorf("unexpected results: %+v", summary.Results)
+	}
+
+	if code := get(t, handler, "/search?q=lease&snippets=true", &summary); code != http.StatusOK {
+		t.Fatalf("/search with snippets returned %d", code)
+	}
+	if len(summary.Results) != 1 || summary.Results[0].Snippet != "The lease runs a year." {
+		t.Errorf("expected the matching sentence as snippet, got %+v", summary.Results)
+	}
+
+	var errResp map[string]string
+	for _, target := range []string{"/search", "/search?q=lease&limit=0", "/search?q=lease&min_score=2", "/search?q=lease&snippets=maybe"} {
+		if code := get(t, handler, target, &errResp); code != http.StatusBadRequest || errResp["error"] == "" {
+			t.Errorf("%s returned %d, %v; want 400 with an error", target, code, errResp)
+		}
+	}
+}
+
+func TestUI(t *testing.T) {
+	srv, _, _ := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("/ without -ui returned %d, want 404", rec.Code)
+	}
+
+	srv.EnableUI()
+	handler := srv.Handler()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") || !strings.Contains(rec.Body.String(), `fetch("search?"`) {
+		t.Errorf("/ returned %d %q, want the search page", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("/missing returned %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed ui.html
+var uiPage []byte
+
+// EnableUI serves a search page at / that calls /search from the browser.
+// Without it / responds 404.
+func (s *Server) EnableUI() {
+	s.ui = true
+}
+
+func (s *Server) handleUI(w http.ResponseWriter, r *http.Request) {
+	if !s.ui {
+		writeError(w, http.StatusNotFound, "the web UI is not enabled; start serve with -ui")
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'")
+	w.Write(uiPage)
+}
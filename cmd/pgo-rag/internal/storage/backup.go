@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// backupPagesPerStep controls how many pages are copied per backup step.
+// Copying in small steps releases the source lock between steps so
+// concurrent writers are not blocked for the whole backup.
+const backupPagesPerStep = 256
+
+// backuper is implemented by modernc.org/sqlite driver connections.
+type backuper interface {
+	NewBackup(dstURI string) (*sqlite.Backup, error)
+}
+
+// Backup writes a consistent snapshot of the database to dstPath using
+// SQLite's online backup API. Writes to the source database may continue
+// while the backup runs. The snapshot is written to a temporary file and
+// renamed into place once complete.
+func (db *DB) Backup(ctx context.Context, dstPath string) error {
+	dir := filepath.Dir(dstPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(dstPath)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	conn, err := db.conn.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn any) error {
+		src, ok := driverConn.(backuper)
+		if !ok {
+			return fmt.Errorf("driver connection does not support online backup")
+		}
+		backup, err := src.NewBackup(tmpPath)
+		if err != nil {
+			return fmt.Errorf("failed to start backup: %w", err)
+		}
+		for {
+			more, err := backup.Step(backupPagesPerStep)
+			if err != nil {
+				_ = backup.Finish()
+				return fmt.Errorf("failed to copy pages: %w", err)
+			}
+			if !more {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				_ = backup.Finish()
+				return ctx.Err()
+			case <-time.After(time.Millisecond):
+			}
+		}
+		if err := backup.Finish(); err != nil {
+			return fmt.Errorf("failed to finish backup: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		return fmt.Errorf("failed to move backup into place: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackup(t *testing.T) {
+	var db = setupTestDB(t)
+	defer db.Close()
+
+	var err = db.UpsertDocumentWithEmbedding(Document{
+		PaperlessID:  42,
+		PaperlessURL: "/api/documents/42/",
+		Title:        "Snapshot me",
+	}, "content", []float32{1, 0, 0})
+	if err != nil {
+		t.Fatalf("Failed to upsert document: %v", err)
+	}
+
+	var dstPath = filepath.Join(t.TempDir(), "nested", "snapshot.db")
+	if err := db.Backup(context.Background(), dstPath); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	// Writes to the source after the backup must not affect the snapshot.
+	if err := db.DeleteDocument(42); err != nil {
+		t.Fatalf("Failed to delete document: %v", err)
+	}
+
+	snapshot, err := NewDB(dstPath)
+	if err != nil {
+		t.Fatalf("Failed to open snapshot: %v", err)
+	}
+	defer snapshot.Close()
+
+	doc, err := snapshot.GetDocumentByPaperlessID(42)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	if doc == nil || doc.Title != "Snapshot me" {
+		t.Fatalf("Expected document in snapshot, got %+v", doc)
+	}
+}
+
+func TestBackupCanceled(t *testing.T) {
+	var db = setupTestDB(t)
+	defer db.Close()
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	if err := db.Backup(ctx, filepath.Join(t.TempDir(), "snapshot.db")); err == nil {
+		t.Fatal("Expected error for canceled context")
+	}
+}
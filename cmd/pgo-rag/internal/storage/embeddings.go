@@ -8,9 +8,9 @@ import (
 // InsertDocument inserts a new document into the database
 func (db *DB) InsertDocument(doc Document) (int64, error) {
 	result, err := db.conn.Exec(`
-		INSERT INTO documents (paperless_id, paperless_url, title, tags, last_modified)
-		VALUES (?, ?, ?, ?, ?)
-	`, doc.PaperlessID, doc.PaperlessURL, doc.Title, doc.Tags, doc.LastModified)
+		INSERT INTO documents (paperless_id, paperless_url, title, tags, correspondent, last_modified)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, doc.PaperlessID, doc.PaperlessURL, doc.Title, doc.Tags, doc.Correspondent, doc.LastModified)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert document: %w", err)
 	}
@@ -25,21 +25,28 @@ func (db *DB) InsertDocument(doc Document) (int64, error) {
 
 // UpsertDocumentWithEmbedding inserts or updates a document and replaces its embeddings.
 func (db *DB) UpsertDocumentWithEmbedding(doc Document, content string, vector []float32) error {
+	return db.UpsertDocumentWithChunks(doc, []Chunk{{Content: content, Vector: vector}})
+}
+
+// UpsertDocumentWithChunks inserts or updates a document and replaces its
+// embeddings with one row per chunk.
+func (db *DB) UpsertDocumentWithChunks(doc Document, chunks []Chunk) error {
 	tx, err := db.conn.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
 	if _, err := tx.Exec(`
-		INSERT INTO documents (paperless_id, paperless_url, title, tags, last_modified, embedded_at)
-		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		INSERT INTO documents (paperless_id, paperless_url, title, tags, correspondent, last_modified, embedded_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 		ON CONFLICT(paperless_id) DO UPDATE SET
 			paperless_url = excluded.paperless_url,
 			title = excluded.title,
 			tags = excluded.tags,
+			correspondent = excluded.correspondent,
 			last_modified = excluded.last_modified,
 			embedded_at = CURRENT_TIMESTAMP
-	`, doc.PaperlessID, doc.PaperlessURL, doc.Title, doc.Tags, doc.LastModified); err != nil {
+	`, doc.PaperlessID, doc.PaperlessURL, doc.Title, doc.Tags, doc.Correspondent, doc.LastModified); err != nil {
 		if rollbackErr := tx.Rollback(); rollbackErr != nil {
 			return fmt.Errorf("failed to upsert document: %v (rollback error: %w)", err, rollbackErr)
 		}
@@ -61,15 +68,23 @@ func (db *DB) UpsertDocumentWithEmbedding(doc Document, content string, vector [
 		return fmt.Errorf("failed to delete embeddings: %w", err)
 	}
 
-	vectorBytes := serializeVector(vector)
-	if _, err := tx.Exec(`
-		INSERT INTO embeddings (document_id, content, vector)
-		VALUES (?, ?, ?)
-	`, docID, content, vectorBytes); err != nil {
+	for _, chunk := range chunks {
+		if _, err := tx.Exec(`
+			INSERT INTO embeddings (document_id, chunk_index, page, content, vector, model)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, docID, chunk.Index, chunk.Page, chunk.Content, serializeVector(chunk.Vector), chunk.Model); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return fmt.Errorf("failed to insert embedding: %v (rollback error: %w)", err, rollbackErr)
+			}
+			return fmt.Errorf("failed to insert embedding: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM pending_chunks WHERE paperless_id = ?`, doc.PaperlessID); err != nil {
 		if rollbackErr := tx.Rollback(); rollbackErr != nil {
-			return fmt.Errorf("failed to insert embedding: %v (rollback error: %w)", err, rollbackErr)
+			return fmt.Errorf("failed to delete pending chunks: %v (rollback error: %w)", err, rollbackErr)
 		}
-		return fmt.Errorf("failed to insert embedding: %w", err)
+		return fmt.Errorf("failed to delete pending chunks: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -92,6 +107,26 @@ func (db *DB) UpdateDocument(doc Document) error {
 	return nil
 }
 
+// SetDocumentURL updates the stored URL of a document without touching its
+// embeddings.
+func (db *DB) SetDocumentURL(paperlessID int, paperlessURL string) error {
+	_, err := db.conn.Exec(`UPDATE documents SET paperless_url = ? WHERE paperless_id = ?`, paperlessURL, paperlessID)
+	if err != nil {
+		return fmt.Errorf("failed to update document url: %w", err)
+	}
+	return nil
+}
+
+// SetDocumentCorrespondent updates the stored correspondent of a document
+// without touching its embeddings.
+func (db *DB) SetDocumentCorrespondent(paperlessID int, correspondent string) error {
+	_, err := db.conn.Exec(`UPDATE documents SET correspondent = ? WHERE paperless_id = ?`, correspondent, paperlessID)
+	if err != nil {
+		return fmt.Errorf("failed to update document correspondent: %w", err)
+	}
+	return nil
+}
+
 // InsertEmbedding inserts a new embedding into the database
 func (db *DB) InsertEmbedding(docID int, content string, vector []float32) error {
 	vectorBytes := serializeVector(vector)
@@ -111,7 +146,7 @@ func (db *DB) GetDocumentByPaperlessID(paperlessID int) (*Document, error) {
 	var embeddedAt sql.NullString
 	var lastModified sql.NullString
 	err := db.conn.QueryRow(`
-		SELECT id, paperless_id, paperless_url, title, tags, embedded_at, last_modified
+		SELECT id, paperless_id, paperless_url, title, tags, correspondent, embedded_at, last_modified
 		FROM documents
 		WHERE paperless_id = ?
 	`, paperlessID).Scan(
@@ -120,6 +155,7 @@ func (db *DB) GetDocumentByPaperlessID(paperlessID int) (*Document, error) {
 		&doc.PaperlessURL,
 		&doc.Title,
 		&doc.Tags,
+		&doc.Correspondent,
 		&embeddedAt,
 		&lastModified,
 	)
@@ -168,7 +204,7 @@ func (db *DB) DeleteEmbeddingsByDocumentID(documentID int) error {
 // ListDocuments returns all documents in the database
 func (db *DB) ListDocuments() ([]Document, error) {
 	rows, err := db.conn.Query(`
-		SELECT id, paperless_id, paperless_url, title, tags, embedded_at, last_modified
+		SELECT id, paperless_id, paperless_url, title, tags, correspondent, embedded_at, last_modified
 		FROM documents
 		ORDER BY paperless_id
 	`)
@@ -188,6 +224,7 @@ func (db *DB) ListDocuments() ([]Document, error) {
 			&doc.PaperlessURL,
 			&doc.Title,
 			&doc.Tags,
+			&doc.Correspondent,
 			&embeddedAt,
 			&lastModified,
 		)
@@ -227,3 +264,17 @@ func (db *DB) CountDocuments() (int, error) {
 	}
 	return count, nil
 }
+
+// VectorDimension returns the dimension of the vectors embedded by model,
+// or 0 if the index has none.
+func (db *DB) VectorDimension(model string) (int, error) {
+	var size int
+	err := db.conn.QueryRow(`SELECT length(vector) FROM embeddings WHERE model = ? LIMIT 1`, model).Scan(&size)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get vector dimension: %w", err)
+	}
+	return size / 4, nil
+}
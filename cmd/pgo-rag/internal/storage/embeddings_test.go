@@ -343,3 +343,27 @@ func TestCountDocuments(t *testing.T) {
 		t.Errorf("Expected 1 document, got %d", count)
 	}
 }
+
+func TestVectorDimension(t *testing.T) {
+	var db = setupTestDB(t)
+	defer db.Close()
+
+	var dims, err = db.VectorDimension("m")
+	if err != nil {
+		t.Fatalf("Failed to get vector dimension: %v", err)
+	}
+	if dims != 0 {
+		t.Errorf("Expected 0 dimensions for an empty index, got %d", dims)
+	}
+
+	var chunks = []Chunk{{Content: "a", Vector: []float32{1, 0, 0}, Model: "m"}}
+	if err := db.UpsertDocumentWithChunks(Document{PaperlessID: 1, PaperlessURL: "/1"}, chunks); err != nil {
+		t.Fatalf("Failed to upsert chunks: %v", err)
+	}
+	if dims, err = db.VectorDimension("m"); err != nil || dims != 3 {
+		t.Errorf("Expected 3 dimensions, got %d (%v)", dims, err)
+	}
+	if dims, err = db.VectorDimension("other"); err != nil || dims != 0 {
+		t.Errorf("Expected 0 dimensions for another model, got %d (%v)", dims, err)
+	}
+}
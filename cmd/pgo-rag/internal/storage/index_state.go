@@ -59,7 +59,8 @@ func (db *DB) ResetIndexState() error {
 	return db.UpdateIndexState(0)
 }
 
-// ClearIndexData removes documents, embeddings, failures, and resets state.
+// ClearIndexData removes documents, embeddings, failures, pending chunks,
+// and resets state.
 func (db *DB) ClearIndexData() error {
 	tx, err := db.conn.Begin()
 	if err != nil {
@@ -84,6 +85,12 @@ func (db *DB) ClearIndexData() error {
 		}
 		return fmt.Errorf("failed to clear failures: %w", err)
 	}
+	if _, err := tx.Exec(`DELETE FROM pending_chunks`); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("failed to clear pending chunks: %v (rollback error: %w)", err, rollbackErr)
+		}
+		return fmt.Errorf("failed to clear pending chunks: %w", err)
+	}
 	if _, err := tx.Exec(`UPDATE index_state SET last_paperless_id = 0, updated_at = CURRENT_TIMESTAMP WHERE id = 1`); err != nil {
 		if rollbackErr := tx.Rollback(); rollbackErr != nil {
 			return fmt.Errorf("failed to reset index state: %v (rollback error: %w)", err, rollbackErr)
@@ -149,3 +156,37 @@ func (db *DB) GetIndexFailure(paperlessID int) (*IndexFailure, error) {
 	}
 	return &failure, nil
 }
+
+// ListIndexFailures returns all recorded failures ordered by Paperless ID.
+func (db *DB) ListIndexFailures() ([]IndexFailure, error) {
+	rows, err := db.conn.Query(`
+		SELECT paperless_id, error, failed_at
+		FROM index_failures
+		ORDER BY paperless_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list index failures: %w", err)
+	}
+	defer rows.Close()
+
+	var failures []IndexFailure
+	for rows.Next() {
+		var failure IndexFailure
+		var failedAt sql.NullString
+		if err := rows.Scan(&failure.PaperlessID, &failure.Error, &failedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan index failure: %w", err)
+		}
+		if failedAt.Valid {
+			parsed, err := parseTimestamp(failedAt.String)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse index_failures.failed_at: %w", err)
+			}
+			failure.FailedAt = parsed
+		}
+		failures = append(failures, failure)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating index failures: %w", err)
+	}
+	return failures, nil
+}
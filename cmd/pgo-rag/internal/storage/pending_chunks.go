@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetPendingChunks returns the pending chunks of a document ordered by
+// chunk index, or none if the document has no failed build.
+func (db *DB) GetPendingChunks(paperlessID int) ([]PendingChunk, error) {
+	rows, err := db.conn.Query(`
+		SELECT chunk_index, content, vector, error
+		FROM pending_chunks
+		WHERE paperless_id = ?
+		ORDER BY chunk_index
+	`, paperlessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending chunks: %w", err)
+	}
+	defer rows.Close()
+	return scanPendingChunks(rows)
+}
+
+// SavePendingChunks replaces the pending chunks of a document. They are
+// removed when the document is indexed by UpsertDocumentWithChunks.
+func (db *DB) SavePendingChunks(paperlessID int, chunks []PendingChunk) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM pending_chunks WHERE paperless_id = ?`, paperlessID); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("failed to delete pending chunks: %v (rollback error: %w)", err, rollbackErr)
+		}
+		return fmt.Errorf("failed to delete pending chunks: %w", err)
+	}
+
+	for _, chunk := range chunks {
+		vector, chunkErr := pendingChunkValues(chunk)
+		if _, err := tx.Exec(`
+			INSERT INTO pending_chunks (paperless_id, chunk_index, content, vector, error)
+			VALUES (?, ?, ?, ?, ?)
+		`, paperlessID, chunk.Index, chunk.Content, vector, chunkErr); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return fmt.Errorf("failed to insert pending chunk: %v (rollback error: %w)", err, rollbackErr)
+			}
+			return fmt.Errorf("failed to insert pending chunk: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit pending chunks: %w", err)
+	}
+
+	return nil
+}
+
+// pendingChunkValues returns the vector and error columns of a pending
+// chunk, NULL where unset.
+func pendingChunkValues(chunk PendingChunk) ([]byte, sql.NullString) {
+	var vector []byte
+	if chunk.Vector != nil {
+		vector = serializeVector(chunk.Vector)
+	}
+	return vector, sql.NullString{String: chunk.Error, Valid: chunk.Error != ""}
+}
+
+// scanPendingChunks reads rows of chunk_index, content, vector and error.
+func scanPendingChunks(rows *sql.Rows) ([]PendingChunk, error) {
+	var chunks []PendingChunk
+	for rows.Next() {
+		var (
+			chunk    PendingChunk
+			vector   []byte
+			chunkErr sql.NullString
+		)
+		if err := rows.Scan(&chunk.Index, &chunk.Content, &vector, &chunkErr); err != nil {
+			return nil, fmt.Errorf("failed to scan pending chunk: %w", err)
+		}
+		if vector != nil {
+			chunk.Vector = deserializeVector(vector)
+		}
+		chunk.Error = chunkErr.String
+		chunks = append(chunks, chunk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pending chunks: %w", err)
+	}
+	return chunks, nil
+}
@@ -0,0 +1,633 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// postgresSchema mirrors initialSchema for Postgres. Vectors use the pgvector
+// type without a fixed dimension so any embeddings model can be stored.
+const postgresSchema = `CREATE EXTENSION IF NOT EXISTS vector;
+
+CREATE TABLE IF NOT EXISTS documents (
+    id BIGSERIAL PRIMARY KEY,
+    paperless_id INTEGER UNIQUE NOT NULL,
+    paperless_url TEXT NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    tags TEXT NOT NULL DEFAULT '',
+    correspondent TEXT NOT NULL DEFAULT '',
+    search_hits INTEGER NOT NULL DEFAULT 0,
+    search_score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    embedded_at TIMESTAMPTZ DEFAULT now(),
+    last_modified TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS embeddings (
+    id BIGSERIAL PRIMARY KEY,
+    document_id BIGINT NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    chunk_index INTEGER NOT NULL DEFAULT 0,
+    page INTEGER NOT NULL DEFAULT 0,
+    content TEXT NOT NULL,
+    vector vector NOT NULL,
+    model TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+ALTER TABLE embeddings ADD COLUMN IF NOT EXISTS model TEXT NOT NULL DEFAULT '';
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS correspondent TEXT NOT NULL DEFAULT '';
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS search_hits INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS search_score DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS index_state (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    last_paperless_id INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+INSERT INTO index_state (id, last_paperless_id) VALUES (1, 0) ON CONFLICT (id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS index_failures (
+    paperless_id INTEGER PRIMARY KEY,
+    error TEXT NOT NULL,
+    failed_at TIMESTAMPTZ DEFAULT now()
+);
+
+-- Pending vectors are only read back, never searched, so they are stored
+-- in the SQLite encoding rather than as pgvector values.
+CREATE TABLE IF NOT EXISTS pending_chunks (
+    paperless_id INTEGER NOT NULL,
+    chunk_index INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    vector BYTEA,
+    error TEXT,
+    PRIMARY KEY (paperless_id, chunk_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_embeddings_document_id ON embeddings(document_id);
+`
+
+// PostgresDB stores the index in Postgres using the pgvector extension.
+type PostgresDB struct {
+	conn *sql.DB
+}
+
+// NewPostgresDB connects to Postgres and creates the schema if needed. The
+// pgvector extension must be installable by the connecting role.
+func NewPostgresDB(dsn string) (*PostgresDB, error) {
+	conn, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if _, err := conn.Exec(postgresSchema); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return &PostgresDB{conn: conn}, nil
+}
+
+// Close closes the database connection.
+func (db *PostgresDB) Close() error {
+	return db.conn.Close()
+}
+
+// formatVector renders a vector in pgvector's text format.
+func formatVector(vector []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, v := range vector {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// parseVector parses a vector in pgvector's text format.
+func parseVector(text string) ([]float32, error) {
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "["), "]")
+	if text == "" {
+		return []float32{}, nil
+	}
+	fields := strings.Split(text, ",")
+	vector := make([]float32, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse vector: %w", err)
+		}
+		vector[i] = float32(v)
+	}
+	return vector, nil
+}
+
+// GetDocumentByPaperlessID retrieves a document by its Paperless ID.
+func (db *PostgresDB) GetDocumentByPaperlessID(paperlessID int) (*Document, error) {
+	var (
+		doc          Document
+		embeddedAt   sql.NullTime
+		lastModified sql.NullTime
+	)
+	err := db.conn.QueryRow(`
+		SELECT id, paperless_id, paperless_url, title, tags, correspondent, embedded_at, last_modified
+		FROM documents
+		WHERE paperless_id = $1
+	`, paperlessID).Scan(&doc.ID, &doc.PaperlessID, &doc.PaperlessURL, &doc.Title, &doc.Tags, &doc.Correspondent, &embeddedAt, &lastModified)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+	doc.EmbeddedAt = embeddedAt.Time
+	doc.LastModified = lastModified.Time
+	return &doc, nil
+}
+
+// UpsertDocumentWithChunks inserts or updates a document and replaces its
+// embeddings with one row per chunk.
+func (db *PostgresDB) UpsertDocumentWithChunks(doc Document, chunks []Chunk) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var docID int
+	if err := tx.QueryRow(`
+		INSERT INTO documents (paperless_id, paperless_url, title, tags, correspondent, last_modified, embedded_at)
+		VALUES ($1, $2, $3, $4, $5, $6, now())
+		ON CONFLICT (paperless_id) DO UPDATE SET
+			paperless_url = excluded.paperless_url,
+			title = excluded.title,
+			tags = excluded.tags,
+			correspondent = excluded.correspondent,
+			last_modified = excluded.last_modified,
+			embedded_at = now()
+		RETURNING id
+	`, doc.PaperlessID, doc.PaperlessURL, doc.Title, doc.Tags, doc.Correspondent, doc.LastModified).Scan(&docID); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("failed to upsert document: %v (rollback error: %w)", err, rollbackErr)
+		}
+		return fmt.Errorf("failed to upsert document: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM embeddings WHERE document_id = $1`, docID); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("failed to delete embeddings: %v (rollback error: %w)", err, rollbackErr)
+		}
+		return fmt.Errorf("failed to delete embeddings: %w", err)
+	}
+
+	for _, chunk := range chunks {
+		if _, err := tx.Exec(`
+			INSERT INTO embeddings (document_id, chunk_index, page, content, vector, model)
+			VALUES ($1, $2, $3, $4, $5::vector, $6)
+		`, docID, chunk.Index, chunk.Page, chunk.Content, formatVector(chunk.Vector), chunk.Model); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return fmt.Errorf("failed to insert embedding: %v (rollback error: %w)", err, rollbackErr)
+			}
+			return fmt.Errorf("failed to insert embedding: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM pending_chunks WHERE paperless_id = $1`, doc.PaperlessID); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("failed to delete pending chunks: %v (rollback error: %w)", err, rollbackErr)
+		}
+		return fmt.Errorf("failed to delete pending chunks: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit embedding update: %w", err)
+	}
+
+	return nil
+}
+
+// SetDocumentURL updates the stored URL of a document without touching its
+// embeddings.
+func (db *PostgresDB) SetDocumentURL(paperlessID int, paperlessURL string) error {
+	_, err := db.conn.Exec(`UPDATE documents SET paperless_url = $1 WHERE paperless_id = $2`, paperlessURL, paperlessID)
+	if err != nil {
+		return fmt.Errorf("failed to update document url: %w", err)
+	}
+	return nil
+}
+
+// SetDocumentCorrespondent updates the stored correspondent of a document
+// without touching its embeddings.
+func (db *PostgresDB) SetDocumentCorrespondent(paperlessID int, correspondent string) error {
+	_, err := db.conn.Exec(`UPDATE documents SET correspondent = $1 WHERE paperless_id = $2`, correspondent, paperlessID)
+	if err != nil {
+		return fmt.Errorf("failed to update document correspondent: %w", err)
+	}
+	return nil
+}
+
+// DeleteDocument deletes a document and, by cascade, its embeddings.
+func (db *PostgresDB) DeleteDocument(paperlessID int) error {
+	_, err := db.conn.Exec(`DELETE FROM documents WHERE paperless_id = $1`, paperlessID)
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	return nil
+}
+
+// ListDocuments returns all documents ordered by Paperless ID.
+func (db *PostgresDB) ListDocuments() ([]Document, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, paperless_id, paperless_url, title, tags, correspondent, embedded_at, last_modified
+		FROM documents
+		ORDER BY paperless_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	defer rows.Close()
+
+	var documents []Document
+	for rows.Next() {
+		var (
+			doc          Document
+			embeddedAt   sql.NullTime
+			lastModified sql.NullTime
+		)
+		if err := rows.Scan(&doc.ID, &doc.PaperlessID, &doc.PaperlessURL, &doc.Title, &doc.Tags, &doc.Correspondent, &embeddedAt, &lastModified); err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		doc.EmbeddedAt = embeddedAt.Time
+		doc.LastModified = lastModified.Time
+		documents = append(documents, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating documents: %w", err)
+	}
+
+	return documents, nil
+}
+
+// CountDocuments returns the number of indexed documents.
+func (db *PostgresDB) CountDocuments() (int, error) {
+	var count int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM documents`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+	return count, nil
+}
+
+// VectorDimension returns the dimension of the vectors embedded by model,
+// or 0 if the index has none.
+func (db *PostgresDB) VectorDimension(model string) (int, error) {
+	var dims int
+	err := db.conn.QueryRow(`SELECT vector_dims(vector) FROM embeddings WHERE model = $1 LIMIT 1`, model).Scan(&dims)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get vector dimension: %w", err)
+	}
+	return dims, nil
+}
+
+// SearchHybrid is the Postgres counterpart of DB.SearchHybrid. Cosine
+// similarity is computed by pgvector; ranking is shared with SQLite.
+func (db *PostgresDB) SearchHybrid(queryVector []float32, query string, limit int, threshold float64, opts RankOptions) ([]SearchResult, error) {
+	candidates, err := db.loadChunkCandidates(queryVector, opts)
+	if err != nil {
+		return nil, err
+	}
+	return bestPerDocument(rankCandidates(candidates, query, threshold, opts), limit), nil
+}
+
+// SearchChunks is the Postgres counterpart of DB.SearchChunks.
+func (db *PostgresDB) SearchChunks(queryVector []float32, query string, limit int, threshold float64, opts RankOptions) ([]ChunkResult, error) {
+	candidates, err := db.loadChunkCandidates(queryVector, opts)
+	if err != nil {
+		return nil, err
+	}
+	return limitChunks(rankCandidates(candidates, query, threshold, opts), limit), nil
+}
+
+// loadChunkCandidates lets pgvector compare chunks with queryVector. Chunks
+// embedded by a model in opts.ModelVectors may have another dimension, which
+// pgvector rejects, so their vectors are returned and compared in Go.
+func (db *PostgresDB) loadChunkCandidates(queryVector []float32, opts RankOptions) ([]ChunkResult, error) {
+	otherModels := make([]string, 0, len(opts.ModelVectors))
+	for model := range opts.ModelVectors {
+		otherModels = append(otherModels, model)
+	}
+	rows, err := db.conn.Query(`
+		SELECT
+			e.document_id,
+			e.chunk_index,
+			e.page,
+			e.content,
+			CASE WHEN e.model = ANY($2) THEN NULL ELSE 1 - (e.vector <=> $1::vector) END,
+			CASE WHEN e.model = ANY($2) THEN e.vector::text END,
+			e.model,
+			d.paperless_id,
+			d.paperless_url,
+			d.title,
+			d.tags,
+			d.last_modified
+		FROM embeddings e
+		JOIN documents d ON e.document_id = d.id
+		ORDER BY e.document_id, e.chunk_index
+	`, formatVector(queryVector), otherModels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []ChunkResult
+	for rows.Next() {
+		var (
+			result       ChunkResult
+			similarity   sql.NullFloat64
+			vector       sql.NullString
+			model        string
+			lastModified sql.NullTime
+		)
+		if err := rows.Scan(&result.DocumentID, &result.ChunkIndex, &result.Page, &result.Content, &similarity, &vector, &model,
+			&result.PaperlessID, &result.PaperlessURL, &result.Title, &result.Tags, &lastModified); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if vector.Valid {
+			parsed, err := parseVector(vector.String)
+			if err != nil {
+				return nil, err
+			}
+			result.SimilarityScore = cosineSimilarity(opts.queryVectorFor(queryVector, model), parsed)
+		} else if similarity.Valid && !math.IsNaN(similarity.Float64) {
+			// A zero vector has no defined cosine distance; treat it as
+			// unrelated like cosineSimilarity does.
+			result.SimilarityScore = similarity.Float64
+		}
+		result.LastModified = lastModified.Time
+		candidates = append(candidates, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return candidates, nil
+}
+
+// GetIndexState returns the current index state.
+func (db *PostgresDB) GetIndexState() (IndexState, error) {
+	var (
+		state     IndexState
+		updatedAt sql.NullTime
+	)
+	err := db.conn.QueryRow(`
+		SELECT last_paperless_id, updated_at
+		FROM index_state
+		WHERE id = 1
+	`).Scan(&state.LastPaperlessID, &updatedAt)
+	if err != nil {
+		return state, fmt.Errorf("failed to get index state: %w", err)
+	}
+	state.UpdatedAt = updatedAt.Time
+	return state, nil
+}
+
+// UpdateIndexState sets the last processed Paperless ID.
+func (db *PostgresDB) UpdateIndexState(lastPaperlessID int) error {
+	_, err := db.conn.Exec(`
+		UPDATE index_state
+		SET last_paperless_id = $1, updated_at = now()
+		WHERE id = 1
+	`, lastPaperlessID)
+	if err != nil {
+		return fmt.Errorf("failed to update index state: %w", err)
+	}
+	return nil
+}
+
+// ClearIndexData removes documents, embeddings, failures, pending chunks,
+// and resets state.
+func (db *PostgresDB) ClearIndexData() error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin clear transaction: %w", err)
+	}
+
+	statements := []struct {
+		query string
+		what  string
+	}{
+		{query: `DELETE FROM embeddings`, what: "clear embeddings"},
+		{query: `DELETE FROM documents`, what: "clear documents"},
+		{query: `DELETE FROM index_failures`, what: "clear failures"},
+		{query: `DELETE FROM pending_chunks`, what: "clear pending chunks"},
+		{query: `UPDATE index_state SET last_paperless_id = 0, updated_at = now() WHERE id = 1`, what: "reset index state"},
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt.query); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return fmt.Errorf("failed to %s: %v (rollback error: %w)", stmt.what, err, rollbackErr)
+			}
+			return fmt.Errorf("failed to %s: %w", stmt.what, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit clear transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RecordIndexFailure stores the latest error for a Paperless document.
+func (db *PostgresDB) RecordIndexFailure(paperlessID int, err error) error {
+	if err == nil {
+		return nil
+	}
+	_, execErr := db.conn.Exec(`
+		INSERT INTO index_failures (paperless_id, error)
+		VALUES ($1, $2)
+		ON CONFLICT (paperless_id) DO UPDATE SET
+			error = excluded.error,
+			failed_at = now()
+	`, paperlessID, err.Error())
+	if execErr != nil {
+		return fmt.Errorf("failed to record index failure: %w", execErr)
+	}
+	return nil
+}
+
+// ClearIndexFailure removes any recorded failure for a document.
+func (db *PostgresDB) ClearIndexFailure(paperlessID int) error {
+	_, err := db.conn.Exec(`DELETE FROM index_failures WHERE paperless_id = $1`, paperlessID)
+	if err != nil {
+		return fmt.Errorf("failed to clear index failure: %w", err)
+	}
+	return nil
+}
+
+// ListIndexFailures returns all recorded failures ordered by Paperless ID.
+func (db *PostgresDB) ListIndexFailures() ([]IndexFailure, error) {
+	rows, err := db.conn.Query(`
+		SELECT paperless_id, error, failed_at
+		FROM index_failures
+		ORDER BY paperless_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list index failures: %w", err)
+	}
+	defer rows.Close()
+
+	var failures []IndexFailure
+	for rows.Next() {
+		var (
+			failure  IndexFailure
+			failedAt sql.NullTime
+		)
+		if err := rows.Scan(&failure.PaperlessID, &failure.Error, &failedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan index failure: %w", err)
+		}
+		failure.FailedAt = failedAt.Time
+		failures = append(failures, failure)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating index failures: %w", err)
+	}
+	return failures, nil
+}
+
+// GetPendingChunks returns the pending chunks of a document ordered by
+// chunk index, or none if the document has no failed build.
+func (db *PostgresDB) GetPendingChunks(paperlessID int) ([]PendingChunk, error) {
+	rows, err := db.conn.Query(`
+		SELECT chunk_index, content, vector, error
+		FROM pending_chunks
+		WHERE paperless_id = $1
+		ORDER BY chunk_index
+	`, paperlessID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending chunks: %w", err)
+	}
+	defer rows.Close()
+	return scanPendingChunks(rows)
+}
+
+// SavePendingChunks replaces the pending chunks of a document. They are
+// removed when the document is indexed by UpsertDocumentWithChunks.
+func (db *PostgresDB) SavePendingChunks(paperlessID int, chunks []PendingChunk) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM pending_chunks WHERE paperless_id = $1`, paperlessID); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("failed to delete pending chunks: %v (rollback error: %w)", err, rollbackErr)
+		}
+		return fmt.Errorf("failed to delete pending chunks: %w", err)
+	}
+
+	for _, chunk := range chunks {
+		vector, chunkErr := pendingChunkValues(chunk)
+		if _, err := tx.Exec(`
+			INSERT INTO pending_chunks (paperless_id, chunk_index, content, vector, error)
+			VALUES ($1, $2, $3, $4, $5)
+		`, paperlessID, chunk.Index, chunk.Content, vector, chunkErr); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return fmt.Errorf("failed to insert pending chunk: %v (rollback error: %w)", err, rollbackErr)
+			}
+			return fmt.Errorf("failed to insert pending chunk: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit pending chunks: %w", err)
+	}
+
+	return nil
+}
+
+// CountChunksByModel returns the number of indexed chunks per embeddings
+// model. Chunks indexed before models were recorded count under "".
+func (db *PostgresDB) CountChunksByModel() (map[string]int, error) {
+	rows, err := db.conn.Query(`SELECT model, COUNT(*) FROM embeddings GROUP BY model`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count chunks by model: %w", err)
+	}
+	defer rows.Close()
+	return scanModelCounts(rows)
+}
+
+// ChunksToReembed returns up to limit chunks not embedded by model, in the
+// order they were indexed.
+func (db *PostgresDB) ChunksToReembed(model string, limit int) ([]StoredChunk, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, content
+		FROM embeddings
+		WHERE model != $1
+		ORDER BY id
+		LIMIT $2
+	`, model, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chunks to re-embed: %w", err)
+	}
+	defer rows.Close()
+	return scanStoredChunks(rows)
+}
+
+// UpdateChunkVectors replaces the vectors of chunks with ones embedded by
+// model in one transaction.
+func (db *PostgresDB) UpdateChunkVectors(model string, chunks []StoredChunk) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	for _, chunk := range chunks {
+		if _, err := tx.Exec(`UPDATE embeddings SET vector = $1::vector, model = $2 WHERE id = $3`,
+			formatVector(chunk.Vector), model, chunk.ID); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return fmt.Errorf("failed to update chunk vector: %v (rollback error: %w)", err, rollbackErr)
+			}
+			return fmt.Errorf("failed to update chunk vector: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit chunk vectors: %w", err)
+	}
+
+	return nil
+}
+
+// RecordSearchHits counts each result as a hit of its document and adds its
+// similarity score to the document's total.
+func (db *PostgresDB) RecordSearchHits(results []SearchResult) error {
+	return recordSearchHits(db.conn, `
+		UPDATE documents
+		SET search_hits = search_hits + 1, search_score = search_score + $1
+		WHERE paperless_id = $2
+	`, results)
+}
+
+// DocumentStats returns the chunk count and search hits of every indexed
+// document, ordered by Paperless ID.
+func (db *PostgresDB) DocumentStats() ([]DocumentStats, error) {
+	rows, err := db.conn.Query(documentStatsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document stats: %w", err)
+	}
+	defer rows.Close()
+	return scanDocumentStats(rows)
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsPostgresDSN(t *testing.T) {
+	var tests = []struct {
+		dsn  string
+		want bool
+	}{
+		{"postgres://user@localhost/pgo", true},
+		{"postgresql://user@localhost/pgo", true},
+		{"index.db", false},
+		{"/var/lib/pgo/postgres.db", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPostgresDSN(tt.dsn); got != tt.want {
+			t.Errorf("IsPostgresDSN(%q): expected %v, got %v", tt.dsn, tt.want, got)
+		}
+	}
+}
+
+func TestFormatVector(t *testing.T) {
+	var got = formatVector([]float32{1, 0.5, -0.25})
+	if got != "[1,0.5,-0.25]" {
+		t.Errorf("Expected [1,0.5,-0.25], got %s", got)
+	}
+	if got := formatVector(nil); got != "[]" {
+		t.Errorf("Expected [], got %s", got)
+	}
+}
+
+func TestOpenSQLite(t *testing.T) {
+	var store, err = Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	if _, ok := store.(*DB); !ok {
+		t.Errorf("Expected *DB, got %T", store)
+	}
+}
+
+// TestPostgresDB runs against a real Postgres with pgvector when
+// PGO_RAG_POSTGRES_DSN is set. The database's index tables are cleared.
+func TestPostgresDB(t *testing.T) {
+	var dsn = os.Getenv("PGO_RAG_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("PGO_RAG_POSTGRES_DSN not set")
+	}
+
+	var store, err = Open(dsn)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.ClearIndexData(); err != nil {
+		t.Fatalf("Failed to clear index: %v", err)
+	}
+
+	var docs = []struct {
+		doc    Document
+		chunks []Chunk
+	}{
+		{
+			doc: Document{PaperlessID: 1, PaperlessURL: "http://example.com/doc/1", Title: "Invoice", Tags: "finance"},
+			chunks: []Chunk{
+				{Index: 0, Page: 1, Content: "Invoice total due", Vector: []float32{1, 0, 0}},
+				{Index: 1, Page: 2, Content: "Payment terms", Vector: []float32{0.8, 0.2, 0}},
+			},
+		},
+		{
+			doc:    Document{PaperlessID: 2, PaperlessURL: "http://example.com/doc/2", Title: "Recipe", Tags: "cooking"},
+			chunks: []Chunk{{Index: 0, Content: "Bake the bread", Vector: []float32{0, 0, 1}}},
+		},
+	}
+	for _, d := range docs {
+		if err := store.UpsertDocumentWithChunks(d.doc, d.chunks); err != nil {
+			t.Fatalf("Failed to upsert document %d: %v", d.doc.PaperlessID, err)
+		}
+	}
+
+	count, err := store.CountDocuments()
+	if err != nil {
+		t.Fatalf("Failed to count documents: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 documents, got %d", count)
+	}
+
+	if dims, err := store.VectorDimension(""); err != nil || dims != 3 {
+		t.Errorf("Expected 3 dimensions, got %d (%v)", dims, err)
+	}
+
+	results, err := store.SearchHybrid([]float32{1, 0, 0}, "invoice", 10, 0.5, RankOptions{})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 1 || results[0].PaperlessID != 1 {
+		t.Fatalf("Expected only document 1, got %+v", results)
+	}
+
+	chunks, err := store.SearchChunks([]float32{1, 0, 0}, "invoice", 10, 0.5, RankOptions{})
+	if err != nil {
+		t.Fatalf("Failed to search chunks: %v", err)
+	}
+	if len(chunks) != 2 || chunks[0].ChunkIndex != 0 || chunks[1].Page != 2 {
+		t.Errorf("Expected both invoice chunks in order, got %+v", chunks)
+	}
+
+	// Migrate one chunk to a model of another dimension; pgvector must not
+	// compare it with the old query vector.
+	toMigrate, err := store.ChunksToReembed("new", 1)
+	if err != nil || len(toMigrate) != 1 {
+		t.Fatalf("Failed to get chunks to re-embed: %v, %+v", err, toMigrate)
+	}
+	toMigrate[0].Vector = []float32{1, 0}
+	if err := store.UpdateChunkVectors("new", toMigrate); err != nil {
+		t.Fatalf("Failed to update chunk vectors: %v", err)
+	}
+	chunks, err = store.SearchChunks([]float32{0, 1}, "", 10, 0.5, RankOptions{
+		ModelVectors: map[string][]float32{"": {1, 0, 0}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to search mixed models: %v", err)
+	}
+	if len(chunks) != 1 || chunks[0].Content != "Payment terms" {
+		t.Errorf("Expected only the unmigrated invoice chunk, got %+v", chunks)
+	}
+
+	if err := store.SetDocumentURL(1, "http://example.com/new/1"); err != nil {
+		t.Fatalf("Failed to set document url: %v", err)
+	}
+	doc, err := store.GetDocumentByPaperlessID(1)
+	if err != nil || doc == nil {
+		t.Fatalf("Failed to get document: %v", err)
+	}
+	if doc.PaperlessURL != "http://example.com/new/1" {
+		t.Errorf("Expected updated url, got %s", doc.PaperlessURL)
+	}
+
+	if err := store.UpdateIndexState(2); err != nil {
+		t.Fatalf("Failed to update index state: %v", err)
+	}
+	state, err := store.GetIndexState()
+	if err != nil {
+		t.Fatalf("Failed to get index state: %v", err)
+	}
+	if state.LastPaperlessID != 2 {
+		t.Errorf("Expected last paperless id 2, got %d", state.LastPaperlessID)
+	}
+
+	if err := store.RecordIndexFailure(3, errors.New("boom")); err != nil {
+		t.Fatalf("Failed to record failure: %v", err)
+	}
+	failures, err := store.ListIndexFailures()
+	if err != nil {
+		t.Fatalf("Failed to list failures: %v", err)
+	}
+	if len(failures) != 1 || failures[0].Error != "boom" {
+		t.Errorf("Expected one failure, got %+v", failures)
+	}
+
+	var pending = []PendingChunk{{Index: 0, Content: "first", Vector: []float32{1, 0.5}}, {Index: 1, Content: "second", Error: "boom"}}
+	if err := store.SavePendingChunks(3, pending); err != nil {
+		t.Fatalf("Failed to save pending chunks: %v", err)
+	}
+	gotPending, err := store.GetPendingChunks(3)
+	if err != nil {
+		t.Fatalf("Failed to get pending chunks: %v", err)
+	}
+	if len(gotPending) != 2 || gotPending[0].Vector[1] != 0.5 || gotPending[1].Vector != nil || gotPending[1].Error != "boom" {
+		t.Errorf("Expected saved pending chunks, got %+v", gotPending)
+	}
+
+	if err := store.DeleteDocument(2); err != nil {
+		t.Fatalf("Failed to delete document: %v", err)
+	}
+	if doc, _ := store.GetDocumentByPaperlessID(2); doc != nil {
+		t.Errorf("Expected document 2 to be deleted")
+	}
+}
@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// QueryResult holds the outcome of an ad-hoc SQL statement.
+type QueryResult struct {
+	Columns []string        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+	// RowsAffected is set for statements executed without a result set.
+	RowsAffected *int64 `json:"rows_affected,omitempty"`
+}
+
+// Query runs an ad-hoc SQL statement against the index. Unless write is set
+// the query must be a single SELECT, WITH, VALUES, EXPLAIN or reading PRAGMA
+// statement, and it runs on a connection with PRAGMA query_only enabled, so
+// any attempt to modify the database fails. Use OpenReadOnly for a database
+// that can't be written at all. With write set, statements that do not
+// return rows are executed and report the number of rows affected. BLOB
+// values, such as embedding vectors, are summarized rather than returned.
+func (db *DB) Query(ctx context.Context, query string, write bool) (*QueryResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if write && db.readOnly {
+		return nil, fmt.Errorf("database is opened read-only")
+	}
+	if !write {
+		if err := checkReadOnlyQuery(query); err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := db.conn.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if !write {
+		if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+			return nil, fmt.Errorf("failed to enable read-only mode: %w", err)
+		}
+		defer func() {
+			// The connection returns to the pool, so restore write access.
+			_, _ = conn.ExecContext(context.Background(), "PRAGMA query_only = OFF")
+		}()
+	} else if !returnsRows(query) {
+		res, err := conn.ExecContext(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute statement: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rows affected: %w", err)
+		}
+		return &QueryResult{Columns: []string{}, Rows: [][]interface{}{}, RowsAffected: &affected}, nil
+	}
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	return scanQueryRows(rows)
+}
+
+// returnsRows reports whether a statement is expected to produce a result set.
+func returnsRows(query string) bool {
+	fields := strings.Fields(skipComments(query))
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT", "WITH", "PRAGMA", "EXPLAIN", "VALUES":
+		return true
+	}
+	return false
+}
+
+// readPragmas are the pragmas that take an argument but only read.
+var readPragmas = map[string]bool{
+	"foreign_key_check": true,
+	"foreign_key_list":  true,
+	"index_info":        true,
+	"index_list":        true,
+	"index_xinfo":       true,
+	"integrity_check":   true,
+	"quick_check":       true,
+	"table_info":        true,
+	"table_xinfo":       true,
+}
+
+// checkReadOnlyQuery rejects queries that query_only alone does not stop:
+// several statements, where a later one could turn query_only off, and
+// pragmas that set a value. Anything but a single statement that returns
+// rows is rejected.
+func checkReadOnlyQuery(query string) error {
+	if n := countStatements(query); n != 1 {
+		return fmt.Errorf("expected a single statement, got %d", n)
+	}
+	if !returnsRows(query) {
+		return fmt.Errorf("statement modifies the database and requires write access")
+	}
+
+	fields := strings.Fields(skipComments(query))
+	if !strings.EqualFold(fields[0], "PRAGMA") || len(fields) == 1 {
+		return nil
+	}
+	rest := strings.Join(fields[1:], " ")
+	end := strings.IndexAny(rest, "=(;")
+	if end < 0 || rest[end] == ';' {
+		return nil
+	}
+	name := strings.ToLower(strings.TrimSpace(rest[:end]))
+	if _, after, ok := strings.Cut(name, "."); ok {
+		name = strings.TrimSpace(after) // schema.pragma
+	}
+	if rest[end] == '(' && readPragmas[name] {
+		return nil
+	}
+	return fmt.Errorf("PRAGMA %s sets a value and requires write access", name)
+}
+
+// countStatements counts the non-empty statements in query, ignoring
+// semicolons in string literals, quoted identifiers and comments.
+func countStatements(query string) int {
+	count, pending := 0, false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			// A doubled quote closes and reopens, which counts the same.
+			end := strings.IndexByte(query[i+1:], closing)
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 1
+			}
+			pending = true
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 3
+			}
+		case c == ';':
+			if pending {
+				count++
+			}
+			pending = false
+		case !unicode.IsSpace(rune(c)):
+			pending = true
+		}
+	}
+	if pending {
+		count++
+	}
+	return count
+}
+
+// skipComments returns query without its leading whitespace and comments.
+func skipComments(query string) string {
+	for {
+		query = strings.TrimLeftFunc(query, unicode.IsSpace)
+		switch {
+		case strings.HasPrefix(query, "--"):
+			_, rest, ok := strings.Cut(query, "\n")
+			if !ok {
+				return ""
+			}
+			query = rest
+		case strings.HasPrefix(query, "/*"):
+			_, rest, ok := strings.Cut(query[2:], "*/")
+			if !ok {
+				return ""
+			}
+			query = rest
+		default:
+			return query
+		}
+	}
+}
+
+func scanQueryRows(rows *sql.Rows) (*QueryResult, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	result := &QueryResult{Columns: columns, Rows: [][]interface{}{}}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = fmt.Sprintf("<blob %d bytes>", len(b))
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQueryReadOnly(t *testing.T) {
+	var db = setupTestDB(t)
+	defer db.Close()
+
+	var ctx = context.Background()
+	if err := db.UpsertDocumentWithEmbedding(Document{PaperlessID: 1, PaperlessURL: "/1", Title: "Invoice"}, "invoice", []float32{1, 0}); err != nil {
+		t.Fatalf("Failed to upsert document: %v", err)
+	}
+
+	var result, err = db.Query(ctx, "SELECT d.paperless_id, d.title, e.vector FROM documents d JOIN embeddings e ON e.document_id = d.id", false)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(result.Columns) != 3 || result.Columns[1] != "title" {
+		t.Errorf("Unexpected columns: %v", result.Columns)
+	}
+	if len(result.Rows) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(result.Rows))
+	}
+	if result.Rows[0][1] != "Invoice" {
+		t.Errorf("Expected title Invoice, got %v", result.Rows[0][1])
+	}
+	if result.Rows[0][2] != "<blob 8 bytes>" {
+		t.Errorf("Expected blob summary, got %v", result.Rows[0][2])
+	}
+
+	if _, err := db.Query(ctx, "DELETE FROM documents", false); err == nil {
+		t.Fatal("Expected write to fail in read-only mode")
+	}
+	var count, _ = db.CountDocuments()
+	if count != 1 {
+		t.Errorf("Expected document to survive, got count %d", count)
+	}
+
+	// The pooled connection must allow writes again afterwards.
+	if err := db.UpsertDocumentWithEmbedding(Document{PaperlessID: 2, PaperlessURL: "/2", Title: "Receipt"}, "receipt", []float32{0, 1}); err != nil {
+		t.Fatalf("Expected writes after read-only query, got %v", err)
+	}
+}
+
+func TestQueryWrite(t *testing.T) {
+	var db = setupTestDB(t)
+	defer db.Close()
+
+	var ctx = context.Background()
+	for _, id := range []int{1, 2} {
+		if _, err := db.InsertDocument(Document{PaperlessID: id, PaperlessURL: "/", Title: "Doc"}); err != nil {
+			t.Fatalf("Failed to insert document: %v", err)
+		}
+	}
+
+	var result, err = db.Query(ctx, "DELETE FROM documents WHERE paperless_id = 1", true)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.RowsAffected == nil || *result.RowsAffected != 1 {
+		t.Errorf("Expected 1 row affected, got %v", result.RowsAffected)
+	}
+
+	result, err = db.Query(ctx, "SELECT COUNT(*) AS n FROM documents", true)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Rows[0][0] != int64(1) {
+		t.Errorf("Expected 1 remaining document, got %v", result.Rows[0][0])
+	}
+}
+
+func TestQueryEmpty(t *testing.T) {
+	var db = setupTestDB(t)
+	defer db.Close()
+
+	if _, err := db.Query(context.Background(), "  ", false); err == nil {
+		t.Fatal("Expected error for empty query")
+	}
+}
+
+func TestQueryRejectsBypass(t *testing.T) {
+	var db = setupTestDB(t)
+	defer db.Close()
+
+	var ctx = context.Background()
+	for _, query := range []string{
+		"PRAGMA query_only=OFF; INSERT INTO index_failures(paperless_id, error) VALUES (1, 'x')",
+		"SELECT 1; DELETE FROM documents",
+		"SELECT 1 /* ; */; -- trailing\nDELETE FROM documents",
+		"PRAGMA query_only = OFF",
+		"PRAGMA main.journal_mode(DELETE)",
+		"INSERT INTO index_failures(paperless_id, error) VALUES (1, 'x')",
+		"ATTACH DATABASE 'other.db' AS other",
+	} {
+		if _, err := db.Query(ctx, query, false); err == nil {
+			t.Errorf("Expected read-only query %q to fail", query)
+		}
+	}
+	var result, err = db.Query(ctx, "SELECT COUNT(*) FROM index_failures", false)
+	if err != nil || result.Rows[0][0] != int64(0) {
+		t.Fatalf("Expected no rows written, got %v, %v", result, err)
+	}
+
+	for _, query := range []string{
+		"SELECT ';' AS semicolon;",
+		"-- comment\nSELECT 1",
+		"PRAGMA table_info(documents)",
+		"PRAGMA query_only",
+	} {
+		if _, err := db.Query(ctx, query, false); err != nil {
+			t.Errorf("Query %q failed: %v", query, err)
+		}
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	var dir = t.TempDir()
+	var missing = filepath.Join(dir, "missing.db")
+	if _, err := OpenReadOnly(missing); err == nil {
+		t.Fatal("Expected error for missing database")
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Fatalf("Expected missing database not to be created, got %v", err)
+	}
+
+	var path = filepath.Join(dir, "index.db")
+	var rw, err = NewDB(path)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	rw.Close()
+
+	db, err := OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly failed: %v", err)
+	}
+	defer db.Close()
+
+	var ctx = context.Background()
+	if _, err := db.Query(ctx, "SELECT COUNT(*) FROM documents", false); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if _, err := db.Query(ctx, "DELETE FROM documents", true); err == nil {
+		t.Fatal("Expected write query to fail on a read-only database")
+	}
+	// Even with query_only turned off, the file itself is read-only.
+	if _, err := db.conn.ExecContext(ctx, "PRAGMA query_only = OFF; INSERT INTO index_failures(paperless_id, error) VALUES (1, 'x')"); err == nil {
+		t.Fatal("Expected insert to fail on a mode=ro connection")
+	}
+}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// BM25 parameters (standard Okapi defaults).
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// RankOptions configures hybrid ranking on top of vector similarity.
+// With all weights zero, results are ranked by vector similarity alone.
+type RankOptions struct {
+	// BM25Weight scales the BM25 keyword score (normalized to 0-1).
+	BM25Weight float64 `json:"bm25_weight"`
+	// RecencyWeight is the boost given to a document modified just now,
+	// decaying by half every RecencyHalfLife. In JSON the half-life is a
+	// duration string such as "720h".
+	RecencyWeight   float64       `json:"recency_weight"`
+	RecencyHalfLife time.Duration `json:"-"`
+	// TagWeight scales the fraction of query terms that match a tag name.
+	TagWeight float64 `json:"tag_weight"`
+	// Now is the reference time for recency; zero means time.Now().
+	Now time.Time `json:"-"`
+	// ModelVectors holds the query embedded by other models than the one
+	// that produced queryVector, keyed by model name. Chunks embedded by one
+	// of these models are compared with its vector instead, so an index that
+	// is partly migrated to a new model stays searchable.
+	ModelVectors map[string][]float32 `json:"-"`
+}
+
+// rankOptionsJSON is RankOptions with the half-life as a duration string
+type rankOptionsJSON struct {
+	rankOptionsFields
+	RecencyHalfLife string `json:"recency_half_life,omitempty"`
+}
+
+// rankOptionsFields has the fields of RankOptions without its methods, so
+// they are encoded as usual
+type rankOptionsFields RankOptions
+
+// MarshalJSON encodes the options with the half-life as a duration string.
+func (opts RankOptions) MarshalJSON() ([]byte, error) {
+	out := rankOptionsJSON{rankOptionsFields: rankOptionsFields(opts)}
+	if opts.RecencyHalfLife != 0 {
+		out.RecencyHalfLife = opts.RecencyHalfLife.String()
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes options with the half-life given as a duration
+// string, e.g. "720h" or "90m".
+func (opts *RankOptions) UnmarshalJSON(data []byte) error {
+	var in rankOptionsJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*opts = RankOptions(in.rankOptionsFields)
+	if in.RecencyHalfLife != "" {
+		d, err := time.ParseDuration(in.RecencyHalfLife)
+		if err != nil {
+			return fmt.Errorf("invalid recency_half_life: %w", err)
+		}
+		opts.RecencyHalfLife = d
+	}
+	return nil
+}
+
+// queryVectorFor returns the query vector to compare with a chunk embedded
+// by model.
+func (opts RankOptions) queryVectorFor(queryVector []float32, model string) []float32 {
+	if vector, ok := opts.ModelVectors[model]; ok {
+		return vector
+	}
+	return queryVector
+}
+
+// ScoreExplanation breaks a result's final score into its components.
+type ScoreExplanation struct {
+	VectorScore  float64 `json:"vector_score"`
+	BM25Score    float64 `json:"bm25_score"`
+	BM25Boost    float64 `json:"bm25_boost"`
+	RecencyBoost float64 `json:"recency_boost"`
+	TagBoost     float64 `json:"tag_boost"`
+	FinalScore   float64 `json:"final_score"`
+}
+
+// tokenize lowercases text and splits it into letter/digit runs.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// bm25Scorer computes BM25 scores for a fixed corpus.
+type bm25Scorer struct {
+	docFreq   map[string]int
+	docTerms  []map[string]int
+	docLens   []int
+	avgDocLen float64
+}
+
+func newBM25Scorer(corpus []string) *bm25Scorer {
+	s := &bm25Scorer{
+		docFreq:  make(map[string]int),
+		docTerms: make([]map[string]int, len(corpus)),
+		docLens:  make([]int, len(corpus)),
+	}
+	var total int
+	for i, text := range corpus {
+		terms := make(map[string]int)
+		tokens := tokenize(text)
+		for _, tok := range tokens {
+			terms[tok]++
+		}
+		for term := range terms {
+			s.docFreq[term]++
+		}
+		s.docTerms[i] = terms
+		s.docLens[i] = len(tokens)
+		total += len(tokens)
+	}
+	if len(corpus) > 0 {
+		s.avgDocLen = float64(total) / float64(len(corpus))
+	}
+	return s
+}
+
+// score returns the BM25 score of document i for the query terms.
+func (s *bm25Scorer) score(i int, queryTerms []string) float64 {
+	if s.avgDocLen == 0 {
+		return 0
+	}
+	n := float64(len(s.docTerms))
+	var total float64
+	for _, term := range queryTerms {
+		tf := float64(s.docTerms[i][term])
+		if tf == 0 {
+			continue
+		}
+		df := float64(s.docFreq[term])
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		norm := tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(s.docLens[i])/s.avgDocLen))
+		total += idf * norm
+	}
+	return total
+}
+
+// recencyBoost decays weight by half every halfLife since modified.
+func recencyBoost(weight float64, halfLife time.Duration, modified, now time.Time) float64 {
+	if weight == 0 || halfLife <= 0 || modified.IsZero() {
+		return 0
+	}
+	age := now.Sub(modified)
+	if age < 0 {
+		age = 0
+	}
+	return weight * math.Exp(-math.Ln2*float64(age)/float64(halfLife))
+}
+
+// tagMatchFraction returns the fraction of query terms found in the
+// comma-separated tag list.
+func tagMatchFraction(queryTerms []string, tags string) float64 {
+	if len(queryTerms) == 0 || tags == "" {
+		return 0
+	}
+	tagTerms := make(map[string]bool)
+	for _, tok := range tokenize(tags) {
+		tagTerms[tok] = true
+	}
+	var matched int
+	for _, term := range queryTerms {
+		if tagTerms[term] {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(queryTerms))
+}
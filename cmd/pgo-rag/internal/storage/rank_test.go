@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	var tokens = tokenize("Tax-Return 2024, FINAL!")
+	var want = []string{"tax", "return", "2024", "final"}
+	if len(tokens) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, tokens)
+	}
+	for i := range want {
+		if tokens[i] != want[i] {
+			t.Errorf("Expected token %d to be %q, got %q", i, want[i], tokens[i])
+		}
+	}
+}
+
+func TestBM25Scorer(t *testing.T) {
+	var scorer = newBM25Scorer([]string{
+		"invoice invoice payment",
+		"recipe for bread",
+		"payment reminder",
+	})
+
+	var invoice = scorer.score(0, []string{"invoice"})
+	if invoice <= 0 {
+		t.Fatalf("Expected positive score for matching doc, got %f", invoice)
+	}
+	if scorer.score(1, []string{"invoice"}) != 0 {
+		t.Error("Expected zero score for non-matching doc")
+	}
+	// The rarer term should weigh more than the common one.
+	if scorer.score(0, []string{"invoice"}) <= scorer.score(0, []string{"payment"}) {
+		t.Error("Expected rare term to score higher than common term")
+	}
+}
+
+func TestRecencyBoost(t *testing.T) {
+	var now = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var halfLife = 24 * time.Hour
+
+	if got := recencyBoost(1, halfLife, now, now); math.Abs(got-1) > 1e-9 {
+		t.Errorf("Expected full boost for fresh doc, got %f", got)
+	}
+	if got := recencyBoost(1, halfLife, now.Add(-halfLife), now); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("Expected half boost after one half-life, got %f", got)
+	}
+	if got := recencyBoost(0, halfLife, now, now); got != 0 {
+		t.Errorf("Expected zero boost with zero weight, got %f", got)
+	}
+	if got := recencyBoost(1, halfLife, time.Time{}, now); got != 0 {
+		t.Errorf("Expected zero boost for unknown modification time, got %f", got)
+	}
+}
+
+func TestTagMatchFraction(t *testing.T) {
+	if got := tagMatchFraction([]string{"tax", "2024"}, "finance, tax"); got != 0.5 {
+		t.Errorf("Expected 0.5, got %f", got)
+	}
+	if got := tagMatchFraction([]string{"tax"}, ""); got != 0 {
+		t.Errorf("Expected 0 for no tags, got %f", got)
+	}
+}
+
+func TestSearchHybrid(t *testing.T) {
+	var db = setupTestDB(t)
+	defer db.Close()
+
+	var now = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var items = []struct {
+		doc     Document
+		content string
+		vector  []float32
+	}{
+		{
+			doc:     Document{PaperlessID: 1, PaperlessURL: "/1", Title: "Old invoice", Tags: "archive", LastModified: now.AddDate(-5, 0, 0)},
+			content: "invoice from the plumber",
+			vector:  []float32{1, 0, 0},
+		},
+		{
+			doc:     Document{PaperlessID: 2, PaperlessURL: "/2", Title: "Tax notice", Tags: "tax", LastModified: now},
+			content: "tax notice tax",
+			vector:  []float32{0.9, 0.1, 0},
+		},
+	}
+	for _, item := range items {
+		if err := db.UpsertDocumentWithEmbedding(item.doc, item.content, item.vector); err != nil {
+			t.Fatalf("Failed to upsert document: %v", err)
+		}
+	}
+
+	var query = []float32{1, 0, 0}
+
+	// Without boosts the closest vector wins.
+	var results, err = db.SearchHybrid(query, "tax", 10, 0.5, RankOptions{Now: now})
+	if err != nil {
+		t.Fatalf("SearchHybrid failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Title != "Old invoice" {
+		t.Fatalf("Expected vector-only ranking, got %+v", results)
+	}
+	if results[0].Explanation == nil {
+		t.Fatal("Expected explanation on results")
+	}
+	if results[0].Explanation.FinalScore != results[0].SimilarityScore {
+		t.Errorf("Expected final score to equal vector score without boosts")
+	}
+
+	// Boosts promote the keyword, tag and recency match.
+	results, err = db.SearchHybrid(query, "tax", 10, 0.5, RankOptions{
+		BM25Weight:      0.5,
+		RecencyWeight:   0.2,
+		RecencyHalfLife: 24 * time.Hour,
+		TagWeight:       0.3,
+		Now:             now,
+	})
+	if err != nil {
+		t.Fatalf("SearchHybrid failed: %v", err)
+	}
+	if results[0].Title != "Tax notice" {
+		t.Fatalf("Expected boosted document first, got %s", results[0].Title)
+	}
+	var exp = results[0].Explanation
+	if exp.BM25Score != 1 || exp.BM25Boost != 0.5 {
+		t.Errorf("Expected normalized BM25 1 and boost 0.5, got %f and %f", exp.BM25Score, exp.BM25Boost)
+	}
+	if math.Abs(exp.RecencyBoost-0.2) > 1e-9 {
+		t.Errorf("Expected recency boost 0.2, got %f", exp.RecencyBoost)
+	}
+	if exp.TagBoost != 0.3 {
+		t.Errorf("Expected tag boost 0.3, got %f", exp.TagBoost)
+	}
+	var sum = exp.VectorScore + exp.BM25Boost + exp.RecencyBoost + exp.TagBoost
+	if math.Abs(exp.FinalScore-sum) > 1e-9 {
+		t.Errorf("Expected final score %f, got %f", sum, exp.FinalScore)
+	}
+}
+
+func TestRankOptionsJSON(t *testing.T) {
+	opts := RankOptions{BM25Weight: 0.5, RecencyWeight: 0.2, RecencyHalfLife: 720 * time.Hour}
+	data, err := json.Marshal(opts)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if want := `{"bm25_weight":0.5,"recency_weight":0.2,"tag_weight":0,"recency_half_life":"720h0m0s"}`; string(data) != want {
+		t.Errorf("JSON = %s, want %s", data, want)
+	}
+
+	var decoded RankOptions
+	if err := json.Unmarshal([]byte(`{"recency_weight": 0.3, "recency_half_life": "90m"}`), &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.RecencyWeight != 0.3 || decoded.RecencyHalfLife != 90*time.Minute {
+		t.Errorf("decoded = %+v", decoded)
+	}
+	if err := json.Unmarshal([]byte(`{"recency_half_life": "a month"}`), &decoded); err == nil {
+		t.Error("Expected an error for an invalid duration")
+	}
+}
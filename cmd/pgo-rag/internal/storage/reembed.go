@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// CountChunksByModel returns the number of indexed chunks per embeddings
+// model. Chunks indexed before models were recorded count under "".
+func (db *DB) CountChunksByModel() (map[string]int, error) {
+	rows, err := db.conn.Query(`SELECT model, COUNT(*) FROM embeddings GROUP BY model`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count chunks by model: %w", err)
+	}
+	defer rows.Close()
+	return scanModelCounts(rows)
+}
+
+// ChunksToReembed returns up to limit chunks not embedded by model, in the
+// order they were indexed.
+func (db *DB) ChunksToReembed(model string, limit int) ([]StoredChunk, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, content
+		FROM embeddings
+		WHERE model != ?
+		ORDER BY id
+		LIMIT ?
+	`, model, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chunks to re-embed: %w", err)
+	}
+	defer rows.Close()
+	return scanStoredChunks(rows)
+}
+
+// UpdateChunkVectors replaces the vectors of chunks with ones embedded by
+// model. The chunks are updated in one transaction, so a batch is either
+// migrated completely or not at all.
+func (db *DB) UpdateChunkVectors(model string, chunks []StoredChunk) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	for _, chunk := range chunks {
+		if _, err := tx.Exec(`UPDATE embeddings SET vector = ?, model = ? WHERE id = ?`,
+			serializeVector(chunk.Vector), model, chunk.ID); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return fmt.Errorf("failed to update chunk vector: %v (rollback error: %w)", err, rollbackErr)
+			}
+			return fmt.Errorf("failed to update chunk vector: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit chunk vectors: %w", err)
+	}
+
+	return nil
+}
+
+// scanModelCounts reads rows of model and count.
+func scanModelCounts(rows *sql.Rows) (map[string]int, error) {
+	counts := make(map[string]int)
+	for rows.Next() {
+		var (
+			model string
+			count int
+		)
+		if err := rows.Scan(&model, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan model count: %w", err)
+		}
+		counts[model] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating model counts: %w", err)
+	}
+	return counts, nil
+}
+
+// scanStoredChunks reads rows of id and content.
+func scanStoredChunks(rows *sql.Rows) ([]StoredChunk, error) {
+	var chunks []StoredChunk
+	for rows.Next() {
+		var chunk StoredChunk
+		if err := rows.Scan(&chunk.ID, &chunk.Content); err != nil {
+			return nil, fmt.Errorf("failed to scan chunk: %w", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chunks: %w", err)
+	}
+	return chunks, nil
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReembedChunks(t *testing.T) {
+	var db = setupTestDB(t)
+	defer db.Close()
+
+	var doc = Document{PaperlessID: 7, PaperlessURL: "/7", Title: "Lease"}
+	var chunks = []Chunk{
+		{Index: 0, Content: "rent is due monthly", Vector: []float32{1, 0, 0}, Model: "old"},
+		{Index: 1, Content: "deposit is refundable", Vector: []float32{0, 1, 0}, Model: "old"},
+		{Index: 2, Content: "appendix", Vector: []float32{0, 0, 1}, Model: "old"},
+	}
+	if err := db.UpsertDocumentWithChunks(doc, chunks); err != nil {
+		t.Fatalf("Failed to upsert chunks: %v", err)
+	}
+
+	pending, err := db.ChunksToReembed("new", 2)
+	if err != nil {
+		t.Fatalf("ChunksToReembed failed: %v", err)
+	}
+	if len(pending) != 2 || pending[0].Content != "rent is due monthly" || pending[1].Content != "deposit is refundable" {
+		t.Fatalf("Expected the first two chunks, got %+v", pending)
+	}
+
+	// The new model has another dimension; migrated chunks are compared
+	// with the query embedded by it.
+	pending[0].Vector = []float32{1, 0}
+	pending[1].Vector = []float32{0, 1}
+	if err := db.UpdateChunkVectors("new", pending); err != nil {
+		t.Fatalf("UpdateChunkVectors failed: %v", err)
+	}
+
+	counts, err := db.CountChunksByModel()
+	if err != nil {
+		t.Fatalf("CountChunksByModel failed: %v", err)
+	}
+	if !reflect.DeepEqual(counts, map[string]int{"new": 2, "old": 1}) {
+		t.Errorf("Expected 2 new and 1 old chunk, got %v", counts)
+	}
+
+	results, err := db.SearchChunks([]float32{0, 1}, "", 10, 0.5, RankOptions{
+		ModelVectors: map[string][]float32{"old": {0, 0, 1}},
+	})
+	if err != nil {
+		t.Fatalf("SearchChunks failed: %v", err)
+	}
+	if len(results) != 2 || results[0].ChunkIndex != 1 || results[1].ChunkIndex != 2 {
+		t.Errorf("Expected chunk 1 by the new model and chunk 2 by the old, got %+v", results)
+	}
+
+	pending, err = db.ChunksToReembed("new", 10)
+	if err != nil {
+		t.Fatalf("ChunksToReembed failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Content != "appendix" {
+		t.Errorf("Expected only the appendix left, got %+v", pending)
+	}
+}
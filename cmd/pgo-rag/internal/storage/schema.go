@@ -4,30 +4,87 @@ import "time"
 
 // Document represents a Paperless document in the database
 type Document struct {
-	ID           int       `json:"id"`
-	PaperlessID  int       `json:"paperless_id"`
-	PaperlessURL string    `json:"paperless_url"`
-	Title        string    `json:"title"`
-	Tags         string    `json:"tags"`
-	EmbeddedAt   time.Time `json:"embedded_at"`
-	LastModified time.Time `json:"last_modified"`
+	ID           int    `json:"id"`
+	PaperlessID  int    `json:"paperless_id"`
+	PaperlessURL string `json:"paperless_url"`
+	Title        string `json:"title"`
+	Tags         string `json:"tags"`
+	// Correspondent is the name of the document's correspondent, "" if
+	// it has none.
+	Correspondent string    `json:"correspondent"`
+	EmbeddedAt    time.Time `json:"embedded_at"`
+	LastModified  time.Time `json:"last_modified"`
+}
+
+// DocumentStats is the share of the index a document takes and how often
+// searches returned it.
+type DocumentStats struct {
+	PaperlessID   int
+	Tags          string
+	Correspondent string
+	Chunks        int
+	SearchHits    int     // Searches and questions the document was a result of
+	ScoreSum      float64 // Sum of its similarity scores in those results
 }
 
 // Embedding represents a vector embedding for a document
 type Embedding struct {
 	ID         int       `json:"id"`
 	DocumentID int       `json:"document_id"`
+	ChunkIndex int       `json:"chunk_index"`
+	Page       int       `json:"page"`
 	Content    string    `json:"content"`
 	Vector     []float32 `json:"vector"`
 	CreatedAt  time.Time `json:"created_at"`
 }
 
+// Chunk is one embedded piece of a document's text.
+type Chunk struct {
+	Index   int       // Position of the chunk within the document, from 0
+	Page    int       // 1-based page the chunk starts on, 0 if unknown
+	Content string    // Text that was embedded
+	Vector  []float32 // Embedding of Content
+	Model   string    // Embeddings model that produced Vector, "" if unknown
+}
+
+// StoredChunk is an indexed chunk being re-embedded. Vector is the new
+// embedding; ChunksToReembed leaves it nil.
+type StoredChunk struct {
+	ID      int64
+	Content string
+	Vector  []float32
+}
+
+// PendingChunk is a chunk of a document that could not be indexed
+// completely. Vector is set if the chunk was embedded, Error if embedding
+// it failed.
+type PendingChunk struct {
+	Index   int
+	Content string
+	Vector  []float32
+	Error   string
+}
+
 // SearchResult represents a search result with similarity score
 type SearchResult struct {
 	DocumentID      int       `json:"document_id"`
+	PaperlessID     int       `json:"paperless_id"`
+	ChunkIndex      int       `json:"chunk_index"`
+	Page            int       `json:"page,omitempty"`
 	PaperlessURL    string    `json:"paperless_url"`
 	Title           string    `json:"title"`
 	Tags            string    `json:"tags"`
 	SimilarityScore float64   `json:"similarity_score"`
 	LastModified    time.Time `json:"last_modified"`
+	// Explanation is populated by SearchHybrid.
+	Explanation *ScoreExplanation `json:"explanation,omitempty"`
+	// Snippet is set by SearchHybrid to the text of the document's best
+	// chunk, for callers to quote from.
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// ChunkResult is a chunk-level search result including the chunk text.
+type ChunkResult struct {
+	SearchResult
+	Content string `json:"content"`
 }
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"database/sql"
 	"fmt"
 	"sort"
 	"time"
@@ -85,3 +86,158 @@ func (db *DB) SearchSimilar(queryVector []float32, limit int, threshold float64)
 
 	return results, nil
 }
+
+// SearchHybrid performs a vector similarity search and re-ranks matches using
+// the BM25 keyword score, recency and tag matches configured in opts. The
+// threshold applies to the vector similarity. Each document appears once,
+// represented by its best-scoring chunk, and every result carries a score
+// explanation.
+func (db *DB) SearchHybrid(queryVector []float32, query string, limit int, threshold float64, opts RankOptions) ([]SearchResult, error) {
+	candidates, err := db.loadChunkCandidates(queryVector, opts)
+	if err != nil {
+		return nil, err
+	}
+	return bestPerDocument(rankCandidates(candidates, query, threshold, opts), limit), nil
+}
+
+// SearchChunks ranks individual chunks like SearchHybrid but returns every
+// matching chunk, including its text, so callers can quote evidence.
+func (db *DB) SearchChunks(queryVector []float32, query string, limit int, threshold float64, opts RankOptions) ([]ChunkResult, error) {
+	candidates, err := db.loadChunkCandidates(queryVector, opts)
+	if err != nil {
+		return nil, err
+	}
+	return limitChunks(rankCandidates(candidates, query, threshold, opts), limit), nil
+}
+
+// loadChunkCandidates reads every embedded chunk with its similarity to
+// queryVector, or to the vector opts.ModelVectors holds for its model.
+func (db *DB) loadChunkCandidates(queryVector []float32, opts RankOptions) ([]ChunkResult, error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			e.document_id,
+			e.chunk_index,
+			e.page,
+			e.content,
+			e.vector,
+			e.model,
+			d.paperless_id,
+			d.paperless_url,
+			d.title,
+			d.tags,
+			d.last_modified
+		FROM embeddings e
+		JOIN documents d ON e.document_id = d.id
+		ORDER BY e.document_id, e.chunk_index
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []ChunkResult
+	for rows.Next() {
+		var (
+			result       ChunkResult
+			vectorBytes  []byte
+			model        string
+			lastModified sql.NullString
+		)
+		if err := rows.Scan(&result.DocumentID, &result.ChunkIndex, &result.Page, &result.Content, &vectorBytes, &model,
+			&result.PaperlessID, &result.PaperlessURL, &result.Title, &result.Tags, &lastModified); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result.SimilarityScore = cosineSimilarity(opts.queryVectorFor(queryVector, model), deserializeVector(vectorBytes))
+		if lastModified.Valid {
+			if parsed, err := parseTimestamp(lastModified.String); err == nil {
+				result.LastModified = parsed
+			}
+		}
+		candidates = append(candidates, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return candidates, nil
+}
+
+// rankCandidates scores chunks whose similarity is already set and returns
+// the ones above threshold, best first. BM25 statistics cover every
+// candidate, not only matches, so all chunks must be passed in.
+func rankCandidates(candidates []ChunkResult, query string, threshold float64, opts RankOptions) []ChunkResult {
+	corpus := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		corpus[i] = candidate.Content
+	}
+
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	queryTerms := tokenize(query)
+	scorer := newBM25Scorer(corpus)
+
+	var (
+		results []ChunkResult
+		maxBM25 float64
+	)
+	for i, result := range candidates {
+		if result.SimilarityScore < threshold {
+			continue
+		}
+		bm25 := scorer.score(i, queryTerms)
+		if bm25 > maxBM25 {
+			maxBM25 = bm25
+		}
+		result.Explanation = &ScoreExplanation{
+			VectorScore:  result.SimilarityScore,
+			BM25Score:    bm25,
+			RecencyBoost: recencyBoost(opts.RecencyWeight, opts.RecencyHalfLife, result.LastModified, now),
+			TagBoost:     opts.TagWeight * tagMatchFraction(queryTerms, result.Tags),
+		}
+		results = append(results, result)
+	}
+
+	for i := range results {
+		exp := results[i].Explanation
+		if maxBM25 > 0 {
+			exp.BM25Score /= maxBM25
+		}
+		exp.BM25Boost = opts.BM25Weight * exp.BM25Score
+		exp.FinalScore = exp.VectorScore + exp.BM25Boost + exp.RecencyBoost + exp.TagBoost
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Explanation.FinalScore > results[j].Explanation.FinalScore
+	})
+
+	return results
+}
+
+// bestPerDocument keeps the first, best ranked, chunk of each document,
+// with its text as the snippet.
+func bestPerDocument(chunks []ChunkResult, limit int) []SearchResult {
+	var results []SearchResult
+	seen := make(map[int]bool)
+	for _, chunk := range chunks {
+		if seen[chunk.DocumentID] {
+			continue
+		}
+		seen[chunk.DocumentID] = true
+		result := chunk.SearchResult
+		result.Snippet = chunk.Content
+		results = append(results, result)
+		if limit > 0 && len(results) == limit {
+			break
+		}
+	}
+	return results
+}
+
+func limitChunks(chunks []ChunkResult, limit int) []ChunkResult {
+	if limit > 0 && limit < len(chunks) {
+		return chunks[:limit]
+	}
+	return chunks
+}
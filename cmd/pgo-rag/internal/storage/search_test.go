@@ -295,3 +295,53 @@ func TestParseTimestamp(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchChunks(t *testing.T) {
+	var db = setupTestDB(t)
+	defer db.Close()
+
+	var doc = Document{PaperlessID: 7, PaperlessURL: "/7", Title: "Lease", Tags: "home"}
+	var chunks = []Chunk{
+		{Index: 0, Page: 1, Content: "rent is due monthly", Vector: []float32{1, 0, 0}},
+		{Index: 1, Page: 2, Content: "deposit is refundable", Vector: []float32{0.8, 0.2, 0}},
+		{Index: 2, Page: 3, Content: "unrelated appendix", Vector: []float32{0, 1, 0}},
+	}
+	if err := db.UpsertDocumentWithChunks(doc, chunks); err != nil {
+		t.Fatalf("Failed to upsert chunks: %v", err)
+	}
+
+	var results, err = db.SearchChunks([]float32{1, 0, 0}, "deposit", 10, 0.5, RankOptions{})
+	if err != nil {
+		t.Fatalf("SearchChunks failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 chunk results, got %d", len(results))
+	}
+	if results[0].ChunkIndex != 0 || results[0].Page != 1 || results[0].Content != "rent is due monthly" {
+		t.Errorf("Unexpected first chunk: %+v", results[0])
+	}
+	if results[1].ChunkIndex != 1 || results[1].PaperlessID != 7 {
+		t.Errorf("Unexpected second chunk: %+v", results[1])
+	}
+
+	// Document-level search reports each document once, via its best chunk.
+	docs, err := db.SearchHybrid([]float32{1, 0, 0}, "deposit", 10, 0.5, RankOptions{})
+	if err != nil {
+		t.Fatalf("SearchHybrid failed: %v", err)
+	}
+	if len(docs) != 1 || docs[0].ChunkIndex != 0 {
+		t.Fatalf("Expected one result from chunk 0, got %+v", docs)
+	}
+
+	// Re-upserting replaces all previous chunks.
+	if err := db.UpsertDocumentWithEmbedding(doc, "single", []float32{1, 0, 0}); err != nil {
+		t.Fatalf("Failed to upsert document: %v", err)
+	}
+	results, err = db.SearchChunks([]float32{1, 0, 0}, "", 10, 0, RankOptions{})
+	if err != nil {
+		t.Fatalf("SearchChunks failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Content != "single" {
+		t.Errorf("Expected chunks to be replaced, got %+v", results)
+	}
+}
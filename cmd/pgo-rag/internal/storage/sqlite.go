@@ -5,8 +5,10 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -20,6 +22,9 @@ CREATE TABLE IF NOT EXISTS documents (
     paperless_url TEXT NOT NULL,
     title TEXT,
     tags TEXT,
+    correspondent TEXT NOT NULL DEFAULT '',
+    search_hits INTEGER NOT NULL DEFAULT 0,
+    search_score REAL NOT NULL DEFAULT 0,
     embedded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     last_modified TIMESTAMP
 );
@@ -28,8 +33,11 @@ CREATE TABLE IF NOT EXISTS documents (
 CREATE TABLE IF NOT EXISTS embeddings (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     document_id INTEGER NOT NULL,
+    chunk_index INTEGER NOT NULL DEFAULT 0,
+    page INTEGER NOT NULL DEFAULT 0,
     content TEXT NOT NULL,
     vector BLOB NOT NULL,
+    model TEXT NOT NULL DEFAULT '',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (document_id) REFERENCES documents(id) ON DELETE CASCADE
 );
@@ -50,6 +58,17 @@ CREATE TABLE IF NOT EXISTS index_failures (
     failed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Chunks of documents that failed to index. Embedded chunks keep their
+-- vector and failed ones their error, so a retry only embeds the failures.
+CREATE TABLE IF NOT EXISTS pending_chunks (
+    paperless_id INTEGER NOT NULL,
+    chunk_index INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    vector BLOB,
+    error TEXT,
+    PRIMARY KEY (paperless_id, chunk_index)
+);
+
 -- Index for faster lookups
 CREATE INDEX IF NOT EXISTS idx_paperless_id ON documents(paperless_id);
 CREATE INDEX IF NOT EXISTS idx_document_id ON embeddings(document_id);
@@ -57,7 +76,8 @@ CREATE INDEX IF NOT EXISTS idx_document_id ON embeddings(document_id);
 
 // DB wraps the SQLite database connection
 type DB struct {
-	conn *sql.DB
+	conn     *sql.DB
+	readOnly bool
 }
 
 // NewDB creates a new database connection and runs migrations
@@ -91,6 +111,54 @@ func NewDB(dbPath string) (*DB, error) {
 	return db, nil
 }
 
+// OpenReadOnly opens an existing database without write access: the file is
+// opened with mode=ro and every connection runs with PRAGMA query_only, so
+// nothing, including migrations, can change it. A missing file is an error
+// rather than a new, empty index.
+func OpenReadOnly(dbPath string) (*DB, error) {
+	abs, err := filepath.Abs(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve database path: %w", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("failed to open database: %s is a directory", dbPath)
+	}
+
+	path := filepath.ToSlash(abs)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // Windows drive letters
+	}
+	dsn := url.URL{Scheme: "file", Path: path, RawQuery: "mode=ro&_pragma=query_only(1)"}
+	conn, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return &DB{conn: conn, readOnly: true}, nil
+}
+
+// columnMigrations adds columns introduced after the initial schema to
+// databases created by older versions.
+var columnMigrations = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{table: "embeddings", column: "chunk_index", definition: "INTEGER NOT NULL DEFAULT 0"},
+	{table: "embeddings", column: "page", definition: "INTEGER NOT NULL DEFAULT 0"},
+	{table: "embeddings", column: "model", definition: "TEXT NOT NULL DEFAULT ''"},
+	{table: "documents", column: "correspondent", definition: "TEXT NOT NULL DEFAULT ''"},
+	{table: "documents", column: "search_hits", definition: "INTEGER NOT NULL DEFAULT 0"},
+	{table: "documents", column: "search_score", definition: "REAL NOT NULL DEFAULT 0"},
+}
+
 // runMigrations executes the SQL schema
 func (db *DB) runMigrations() error {
 	// Execute schema
@@ -98,9 +166,32 @@ func (db *DB) runMigrations() error {
 		return fmt.Errorf("failed to execute migration: %w", err)
 	}
 
+	for _, m := range columnMigrations {
+		exists, err := db.columnExists(m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := db.conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)); err != nil {
+			return fmt.Errorf("failed to add %s.%s: %w", m.table, m.column, err)
+		}
+	}
+
 	return nil
 }
 
+// columnExists reports whether table has the named column.
+func (db *DB) columnExists(table, column string) (bool, error) {
+	var count int
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect %s columns: %w", table, err)
+	}
+	return count > 0, nil
+}
+
 // Close closes the database connection
 func (db *DB) Close() error {
 	return db.conn.Close()
@@ -1,10 +1,12 @@
 package storage
 
 import (
+	"database/sql"
 	"fmt"
 	"math"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -240,6 +242,14 @@ func TestIndexFailuresLifecycle(t *testing.T) {
 		t.Fatal("Expected failure error to be set")
 	}
 
+	failures, err := db.ListIndexFailures()
+	if err != nil {
+		t.Fatalf("Failed to list index failures: %v", err)
+	}
+	if len(failures) != 1 || failures[0].PaperlessID != 99 {
+		t.Fatalf("Expected one listed failure for 99, got %+v", failures)
+	}
+
 	if err := db.ClearIndexFailure(99); err != nil {
 		t.Fatalf("Failed to clear index failure: %v", err)
 	}
@@ -252,3 +262,79 @@ func TestIndexFailuresLifecycle(t *testing.T) {
 		t.Fatal("Expected failure record to be cleared")
 	}
 }
+
+func TestPendingChunksLifecycle(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	var chunks = []PendingChunk{
+		{Index: 0, Content: "first", Vector: []float32{1, 0.5}},
+		{Index: 1, Content: "second", Error: "rate limited"},
+	}
+	if err := db.SavePendingChunks(7, chunks); err != nil {
+		t.Fatalf("Failed to save pending chunks: %v", err)
+	}
+	// Saving again replaces the earlier chunks
+	if err := db.SavePendingChunks(7, chunks); err != nil {
+		t.Fatalf("Failed to replace pending chunks: %v", err)
+	}
+
+	got, err := db.GetPendingChunks(7)
+	if err != nil {
+		t.Fatalf("Failed to get pending chunks: %v", err)
+	}
+	if !reflect.DeepEqual(got, chunks) {
+		t.Fatalf("Expected %+v, got %+v", chunks, got)
+	}
+
+	var chunk = Chunk{Index: 0, Content: "first", Vector: []float32{1, 0.5}}
+	if err := db.UpsertDocumentWithChunks(Document{PaperlessID: 7, PaperlessURL: "/api/documents/7/"}, []Chunk{chunk}); err != nil {
+		t.Fatalf("Failed to upsert document: %v", err)
+	}
+	got, err = db.GetPendingChunks(7)
+	if err != nil {
+		t.Fatalf("Failed to get pending chunks after upsert: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Expected pending chunks to be removed by the upsert, got %+v", got)
+	}
+}
+
+func TestMigrationAddsChunkColumns(t *testing.T) {
+	var dbPath = filepath.Join(t.TempDir(), "old.db")
+
+	// Create an embeddings table as written by older versions.
+	var conn, err = sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if _, err := conn.Exec(`CREATE TABLE embeddings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		document_id INTEGER NOT NULL,
+		content TEXT NOT NULL,
+		vector BLOB NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		t.Fatalf("Failed to create legacy table: %v", err)
+	}
+	conn.Close()
+
+	db, err := NewDB(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	defer db.Close()
+
+	for _, column := range []string{"chunk_index", "page", "model"} {
+		var exists, err = db.columnExists("embeddings", column)
+		if err != nil {
+			t.Fatalf("columnExists failed: %v", err)
+		}
+		if !exists {
+			t.Errorf("Expected column %s to be added", column)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// documentStatsQuery works on both SQLite and Postgres.
+const documentStatsQuery = `
+	SELECT d.paperless_id, COALESCE(d.tags, ''), d.correspondent, COUNT(e.id), d.search_hits, d.search_score
+	FROM documents d
+	LEFT JOIN embeddings e ON e.document_id = d.id
+	GROUP BY d.id, d.paperless_id, d.tags, d.correspondent, d.search_hits, d.search_score
+	ORDER BY d.paperless_id
+`
+
+// RecordSearchHits counts each result as a hit of its document and adds its
+// similarity score to the document's total.
+func (db *DB) RecordSearchHits(results []SearchResult) error {
+	return recordSearchHits(db.conn, `
+		UPDATE documents
+		SET search_hits = search_hits + 1, search_score = search_score + ?
+		WHERE paperless_id = ?
+	`, results)
+}
+
+// DocumentStats returns the chunk count and search hits of every indexed
+// document, ordered by Paperless ID.
+func (db *DB) DocumentStats() ([]DocumentStats, error) {
+	rows, err := db.conn.Query(documentStatsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document stats: %w", err)
+	}
+	defer rows.Close()
+	return scanDocumentStats(rows)
+}
+
+// recordSearchHits runs update, which takes the score and the Paperless ID,
+// for every result in one transaction.
+func recordSearchHits(conn *sql.DB, update string, results []SearchResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	tx, err := conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	for _, result := range results {
+		if _, err := tx.Exec(update, result.SimilarityScore, result.PaperlessID); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return fmt.Errorf("failed to record search hit: %v (rollback error: %w)", err, rollbackErr)
+			}
+			return fmt.Errorf("failed to record search hit: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit search hits: %w", err)
+	}
+	return nil
+}
+
+func scanDocumentStats(rows *sql.Rows) ([]DocumentStats, error) {
+	var stats []DocumentStats
+	for rows.Next() {
+		var s DocumentStats
+		if err := rows.Scan(&s.PaperlessID, &s.Tags, &s.Correspondent, &s.Chunks, &s.SearchHits, &s.ScoreSum); err != nil {
+			return nil, fmt.Errorf("failed to scan document stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating document stats: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"strings"
+)
+
+// Store is the index storage used by the indexer. DB (SQLite) and
+// PostgresDB (Postgres with pgvector) implement it.
+type Store interface {
+	GetDocumentByPaperlessID(paperlessID int) (*Document, error)
+	UpsertDocumentWithChunks(doc Document, chunks []Chunk) error
+	SetDocumentURL(paperlessID int, paperlessURL string) error
+	SetDocumentCorrespondent(paperlessID int, correspondent string) error
+	DeleteDocument(paperlessID int) error
+	ListDocuments() ([]Document, error)
+	CountDocuments() (int, error)
+	VectorDimension(model string) (int, error)
+
+	SearchHybrid(queryVector []float32, query string, limit int, threshold float64, opts RankOptions) ([]SearchResult, error)
+	SearchChunks(queryVector []float32, query string, limit int, threshold float64, opts RankOptions) ([]ChunkResult, error)
+
+	GetIndexState() (IndexState, error)
+	UpdateIndexState(lastPaperlessID int) error
+	ClearIndexData() error
+	RecordIndexFailure(paperlessID int, err error) error
+	ClearIndexFailure(paperlessID int) error
+	ListIndexFailures() ([]IndexFailure, error)
+	GetPendingChunks(paperlessID int) ([]PendingChunk, error)
+	SavePendingChunks(paperlessID int, chunks []PendingChunk) error
+
+	CountChunksByModel() (map[string]int, error)
+	ChunksToReembed(model string, limit int) ([]StoredChunk, error)
+	UpdateChunkVectors(model string, chunks []StoredChunk) error
+
+	RecordSearchHits(results []SearchResult) error
+	DocumentStats() ([]DocumentStats, error)
+
+	Close() error
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*PostgresDB)(nil)
+)
+
+// IsPostgresDSN reports whether dsn selects the Postgres backend.
+func IsPostgresDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// Open opens the store selected by dsn: a postgres:// or postgresql:// URL
+// opens Postgres, anything else is treated as a SQLite database path.
+func Open(dsn string) (Store, error) {
+	if IsPostgresDSN(dsn) {
+		return NewPostgresDB(dsn)
+	}
+	return NewDB(dsn)
+}
@@ -3,40 +3,114 @@ package main
 import (
 	"bufio"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
+	"text/template"
 	"time"
 
 	paperless "github.com/jason-riddle/paperless-go"
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/chat"
 	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/embedding"
 	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/indexer"
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/profile"
+	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/server"
 	"github.com/jason-riddle/paperless-go/cmd/pgo-rag/internal/storage"
 )
 
 const usage = `pgo-rag: local RAG indexing and search for Paperless
 
 Usage:
-  pgo-rag build   -db <path> -url <paperless-url> -token <api-token>
-  pgo-rag search  -db <path> -query <text> [-limit 10] [-threshold 0.7]
+  pgo-rag build   -db <path> (-url <paperless-url> -token <api-token> | -profile <name>) (-all | -max-docs <n>)
+  pgo-rag search  -db <path> -query <text> [-limit 10] [-min-score 0.7] [-explain]
+  pgo-rag ask     -db <path> -question <text> -chat-model <model> [-sources 5] [-token-budget 3000] [-verbose]
+  pgo-rag serve   -db <path> [-addr :8080] [-ui] [-health-interval 30s] [-exit-on-unhealthy]
+                  [-auth-token <token>] [-basic-auth user:pass] [-cors-origins <origins>]
+                  [-tls-cert <file> -tls-key <file> [-tls-client-ca <file>]]
+                  [-chat-model <model> [-converse-max-length 300]]
+  pgo-rag reembed -db <path> -model <new-model> [-batch 500] [-resume]
+  pgo-rag backup  -db <path> -out <snapshot-path>
+  pgo-rag diff-state -before <snapshot-path> -after <db-path>
+  pgo-rag stats   -db <path> [-group-by tag|correspondent]
+  pgo-rag sql     -db <path> [-format json|csv] [-write] "<statement>"
+  pgo-rag models  [-embeddings-url <url>]
 
 Global flags:
   -url             Paperless instance URL (or PAPERLESS_URL)
   -token           Paperless API token (or PAPERLESS_TOKEN)
+  -profile         pgo config profile (or PAPERLESS_PROFILE) with the url, token
+                   and max_rate; see pgo's config file. Flags win, and an
+                   explicit profile wins over PAPERLESS_URL and PAPERLESS_TOKEN
   -log-level       Log level (debug, info, warn, error) (or LOG_LEVEL)
   -embeddings-url  Embeddings API base URL (or PGO_RAG_EMBEDDINGS_URL)
   -embeddings-key  Embeddings API key (or PGO_RAG_EMBEDDINGS_KEY)
-  -embeddings-model Embeddings model name (or PGO_RAG_EMBEDDINGS_MODEL)
-  -max-docs        Maximum documents to index (or PGO_RAG_MAX_DOCS)
+  -embeddings-model Embeddings model name (or PGO_RAG_EMBEDDINGS_MODEL);
+                   defaults per provider (OpenAI, OpenRouter, Ollama)
+  -chat-url        Chat API base URL for ask (or PGO_RAG_CHAT_URL); defaults
+                   to -embeddings-url. Ollama URLs use the native /api/chat
+  -chat-key        Chat API key (or PGO_RAG_CHAT_KEY); optional for local servers
+  -chat-api        Chat API of -chat-url: auto, openai or ollama (or
+                   PGO_RAG_CHAT_API, default auto, which detects it from the URL)
+  -chat-model      Chat model used by ask and serve's /converse (or PGO_RAG_CHAT_MODEL)
+  -tokenize-url    Tokenize endpoint of the chat model, e.g. llama.cpp's
+                   http://localhost:8080/tokenize (or PGO_RAG_TOKENIZE_URL);
+                   counts prompt tokens for the budget instead of estimating
+  -tokenize-key    API key sent to -tokenize-url (or PGO_RAG_TOKENIZE_KEY);
+                   no key is sent by default
+  -converse-max-length Maximum /converse answer length in characters
+                   (or PGO_RAG_CONVERSE_MAX_LENGTH, default 300)
+  -all             Index all documents
+  -max-docs        Maximum documents to index (or PGO_RAG_MAX_DOCS); build
+                   requires -all or a limit
+  -chunk-size      Characters of content per embedded chunk (default 2000)
+  -chunk-overlap   Characters shared by consecutive chunks (default 200)
+  -doc-url-template Template for stored document URLs (or PGO_RAG_DOC_URL_TEMPLATE),
+                   e.g. "https://paperless.example.com/documents/{{.ID}}/details"
   -fresh           Clear existing index before building
   -tag             Tag name filter (or PGO_RAG_TAG)
+  -max-rate        Maximum Paperless requests per second of build (or
+                   PAPERLESS_MAX_RATE, or the profile's max_rate), shared with
+                   pgo and other builds
+  -auth-token      Bearer token required by serve's search API (or PGO_RAG_AUTH_TOKEN)
+  -basic-auth      user:password accepted by serve's search API (or PGO_RAG_BASIC_AUTH)
+  -cors-origins    Comma-separated origins allowed to call serve from a browser,
+                   or "*" (or PGO_RAG_CORS_ORIGINS)
+  -tls-cert, -tls-key  Serve HTTPS with this certificate and key
+  -tls-client-ca   Require client certificates signed by this CA (mTLS)
+
+Exit codes:
+  0  success
+  1  error
+  2  usage error
+  3  build or reembed interrupted (SIGINT/SIGTERM); rerun build, or
+     reembed with -resume, to continue
+  4  serve stopped by -exit-on-unhealthy
 `
 
+// exitInterrupted is returned when a build is stopped by a signal after
+// persisting its progress, so wrappers know to resume later.
+const exitInterrupted = 3
+
+// errInterrupted reports that a build stopped early after a signal.
+var errInterrupted = errors.New("interrupted")
+
+// exitUnhealthy is returned when serve stops after failing its health
+// checks, so a supervisor restarts it.
+const exitUnhealthy = 4
+
+// errUnhealthy reports that serve stopped because of -exit-on-unhealthy.
+var errUnhealthy = errors.New("unhealthy")
+
 func main() {
 	loaded, err := loadDotEnv(".env")
 	if err != nil {
@@ -52,21 +126,80 @@ func main() {
 		os.Exit(2)
 	}
 
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		// Restore default signal handling so a second signal exits immediately.
+		stop()
+	}()
+
 	cmd := os.Args[1]
 	args := os.Args[2:]
 
 	switch cmd {
 	case "build":
 		if err := runBuild(ctx, args); err != nil {
+			if errors.Is(err, errInterrupted) {
+				fmt.Fprintln(os.Stderr, "build interrupted; progress saved, rerun to resume")
+				os.Exit(exitInterrupted)
+			}
 			fmt.Fprintln(os.Stderr, "build error:", err)
 			os.Exit(1)
 		}
+	case "reembed":
+		if err := runReembed(ctx, args); err != nil {
+			if errors.Is(err, errInterrupted) {
+				fmt.Fprintln(os.Stderr, "reembed interrupted; progress saved, rerun with -resume to continue")
+				os.Exit(exitInterrupted)
+			}
+			fmt.Fprintln(os.Stderr, "reembed error:", err)
+			os.Exit(1)
+		}
 	case "search":
 		if err := runSearch(ctx, args); err != nil {
 			fmt.Fprintln(os.Stderr, "search error:", err)
 			os.Exit(1)
 		}
+	case "serve":
+		if err := runServe(ctx, args); err != nil {
+			if errors.Is(err, errUnhealthy) {
+				fmt.Fprintln(os.Stderr, "serve stopped: health checks failing")
+				os.Exit(exitUnhealthy)
+			}
+			fmt.Fprintln(os.Stderr, "serve error:", err)
+			os.Exit(1)
+		}
+	case "ask":
+		if err := runAsk(ctx, args); err != nil {
+			fmt.Fprintln(os.Stderr, "ask error:", err)
+			os.Exit(1)
+		}
+	case "diff-state":
+		if err := runDiffState(args); err != nil {
+			fmt.Fprintln(os.Stderr, "diff-state error:", err)
+			os.Exit(1)
+		}
+	case "stats":
+		if err := runStats(args); err != nil {
+			fmt.Fprintln(os.Stderr, "stats error:", err)
+			os.Exit(1)
+		}
+	case "sql":
+		if err := runSQL(ctx, args); err != nil {
+			fmt.Fprintln(os.Stderr, "sql error:", err)
+			os.Exit(1)
+		}
+	case "backup":
+		if err := runBackup(ctx, args); err != nil {
+			fmt.Fprintln(os.Stderr, "backup error:", err)
+			os.Exit(1)
+		}
+	case "models":
+		if err := runModels(args); err != nil {
+			fmt.Fprintln(os.Stderr, "models error:", err)
+			os.Exit(1)
+		}
 	case "help", "-h", "--help":
 		fmt.Fprint(os.Stdout, usage)
 	default:
@@ -80,14 +213,20 @@ func runBuild(ctx context.Context, args []string) error {
 	flags := flag.NewFlagSet("build", flag.ContinueOnError)
 	flags.SetOutput(os.Stderr)
 
-	dbPath := flags.String("db", "", "SQLite database path")
-	url := flags.String("url", os.Getenv("PAPERLESS_URL"), "Paperless URL")
-	token := flags.String("token", os.Getenv("PAPERLESS_TOKEN"), "Paperless token")
+	dbPath := flags.String("db", "", "SQLite database path or postgres:// DSN")
+	url := flags.String("url", "", "Paperless URL (default PAPERLESS_URL or the profile's url)")
+	token := flags.String("token", "", "Paperless token (default PAPERLESS_TOKEN or the profile's token)")
+	profileName := flags.String("profile", os.Getenv("PAPERLESS_PROFILE"), "pgo config profile to read the Paperless URL and token from")
 	logLevel := flags.String("log-level", os.Getenv("LOG_LEVEL"), "Log level (debug, info, warn, error)")
 	pageSize := flags.Int("page-size", 100, "Paperless page size")
-	maxDocs := flags.Int("max-docs", getenvIntDefault("PGO_RAG_MAX_DOCS", 5), "Maximum documents to index (0 = no limit)")
+	maxRate := flags.Float64("max-rate", 0, "Maximum Paperless requests per second, shared with pgo (default PAPERLESS_MAX_RATE or the profile's max_rate; 0: no limit)")
+	all := flags.Bool("all", false, "Index all documents")
+	maxDocs := flags.Int("max-docs", getenvIntDefault("PGO_RAG_MAX_DOCS", 0), "Maximum documents to index (required unless -all)")
 	tagName := flags.String("tag", strings.TrimSpace(os.Getenv("PGO_RAG_TAG")), "Tag name filter (exact match)")
 	fresh := flags.Bool("fresh", false, "Clear existing index before building")
+	chunkSize := flags.Int("chunk-size", indexer.DefaultChunkSize, "Characters of document content per embedded chunk (-1 = no chunking)")
+	chunkOverlap := flags.Int("chunk-overlap", indexer.DefaultChunkOverlap, "Characters shared by consecutive chunks")
+	docURLTemplate := flags.String("doc-url-template", os.Getenv("PGO_RAG_DOC_URL_TEMPLATE"), "Template for stored document URLs, e.g. https://host/documents/{{.ID}}/details")
 	embeddingsURL := flags.String("embeddings-url", os.Getenv("PGO_RAG_EMBEDDINGS_URL"), "Embeddings API base URL")
 	embeddingsKey := flags.String("embeddings-key", os.Getenv("PGO_RAG_EMBEDDINGS_KEY"), "Embeddings API key")
 	embeddingsModel := flags.String("embeddings-model", os.Getenv("PGO_RAG_EMBEDDINGS_MODEL"), "Embeddings model")
@@ -103,23 +242,42 @@ func runBuild(ctx context.Context, args []string) error {
 	if *dbPath == "" {
 		return fmt.Errorf("-db is required")
 	}
+	if err := resolvePaperless(flags, *profileName, url, token, maxRate); err != nil {
+		return err
+	}
 	if *url == "" {
 		return fmt.Errorf("-url is required")
 	}
 	if *token == "" {
 		return fmt.Errorf("-token is required")
 	}
+	limit, err := resolveMaxDocs(flags, *all, *maxDocs)
+	if err != nil {
+		return err
+	}
+	if *chunkOverlap < 0 || (*chunkSize > 0 && *chunkOverlap >= *chunkSize) {
+		return fmt.Errorf("-chunk-overlap must be >= 0 and smaller than -chunk-size")
+	}
+	var urlTemplate *template.Template
+	if *docURLTemplate != "" {
+		tmpl, err := indexer.ParseDocURLTemplate(*docURLTemplate)
+		if err != nil {
+			return err
+		}
+		urlTemplate = tmpl
+	}
 	if *embeddingsURL == "" {
 		return fmt.Errorf("-embeddings-url is required")
 	}
-	if *embeddingsKey == "" {
+	if *embeddingsKey == "" && embedding.DetectProvider(*embeddingsURL) != embedding.ProviderOllama {
 		return fmt.Errorf("-embeddings-key is required")
 	}
-	if *embeddingsModel == "" {
-		return fmt.Errorf("-embeddings-model is required")
+	model, err := resolveEmbeddingsModel(*embeddingsURL, *embeddingsModel)
+	if err != nil {
+		return err
 	}
 
-	db, err := storage.NewDB(*dbPath)
+	db, err := storage.Open(*dbPath)
 	if err != nil {
 		return err
 	}
@@ -130,16 +288,27 @@ func runBuild(ctx context.Context, args []string) error {
 		}
 	}
 
-	client := paperless.NewClient(*url, *token)
-	embedder := embedding.NewClient(*embeddingsURL, *embeddingsKey, *embeddingsModel)
+	clientOpts := []paperless.Option{paperless.WithAuthCheck(), paperless.WithRetries(3), paperless.WithLogger(slog.Default())}
+	if *maxRate > 0 {
+		ratePath, err := paperless.DefaultRateLimitPath(*url)
+		if err != nil {
+			return fmt.Errorf("-max-rate: %w", err)
+		}
+		clientOpts = append(clientOpts, paperless.WithSharedRateLimit(ratePath, *maxRate))
+	}
+	client := paperless.NewClient(*url, *token, clientOpts...)
+	embedder := embedding.NewClient(*embeddingsURL, *embeddingsKey, model)
 
 	start := time.Now()
 	summary, err := indexer.BuildIndex(ctx, client, db, embedder, indexer.BuildOptions{
-		PageSize: *pageSize,
-		MaxDocs:  *maxDocs,
-		TagName:  *tagName,
+		PageSize:       *pageSize,
+		MaxDocs:        limit,
+		TagName:        *tagName,
+		ChunkSize:      *chunkSize,
+		ChunkOverlap:   *chunkOverlap,
+		DocURLTemplate: urlTemplate,
 	})
-	if err != nil {
+	if err != nil && !summary.Interrupted {
 		return err
 	}
 
@@ -151,17 +320,103 @@ func runBuild(ctx context.Context, args []string) error {
 		DurationMs:   time.Since(start).Milliseconds(),
 	}
 
-	return writeJSON(resp)
+	if err := writeJSON(resp); err != nil {
+		return err
+	}
+	if summary.Interrupted {
+		return errInterrupted
+	}
+	return nil
+}
+
+// runReembed migrates the index to another embeddings model in batches.
+// Searches keep finding chunks that are not migrated yet, so the search
+// configuration can switch to the new model at any point.
+func runReembed(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("reembed", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+
+	dbPath := flags.String("db", "", "SQLite database path or postgres:// DSN")
+	model := flags.String("model", "", "Embeddings model to migrate the index to")
+	batch := flags.Int("batch", indexer.DefaultReembedBatch, "Chunks re-embedded per transaction")
+	resume := flags.Bool("resume", false, "Continue an interrupted re-embed to -model")
+	logLevel := flags.String("log-level", os.Getenv("LOG_LEVEL"), "Log level (debug, info, warn, error)")
+	embeddingsURL := flags.String("embeddings-url", os.Getenv("PGO_RAG_EMBEDDINGS_URL"), "Embeddings API base URL")
+	embeddingsKey := flags.String("embeddings-key", os.Getenv("PGO_RAG_EMBEDDINGS_KEY"), "Embeddings API key")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := configureLogging(*logLevel); err != nil {
+		return err
+	}
+
+	if *dbPath == "" {
+		return fmt.Errorf("-db is required")
+	}
+	if *model == "" {
+		return fmt.Errorf("-model is required")
+	}
+	if *batch <= 0 {
+		return fmt.Errorf("-batch must be > 0")
+	}
+	if *embeddingsURL == "" {
+		return fmt.Errorf("-embeddings-url is required")
+	}
+	if *embeddingsKey == "" && embedding.DetectProvider(*embeddingsURL) != embedding.ProviderOllama {
+		return fmt.Errorf("-embeddings-key is required")
+	}
+
+	db, err := storage.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	embedder := embedding.NewClient(*embeddingsURL, *embeddingsKey, *model)
+
+	start := time.Now()
+	summary, err := indexer.Reembed(ctx, db, embedder, indexer.ReembedOptions{
+		Model:  *model,
+		Batch:  *batch,
+		Resume: *resume,
+	})
+	if err != nil && !summary.Interrupted {
+		return err
+	}
+
+	resp := struct {
+		indexer.ReembedSummary
+		DurationMs int64 `json:"duration_ms"`
+	}{
+		ReembedSummary: summary,
+		DurationMs:     time.Since(start).Milliseconds(),
+	}
+
+	if err := writeJSON(resp); err != nil {
+		return err
+	}
+	if summary.Interrupted {
+		return errInterrupted
+	}
+	return nil
 }
 
 func runSearch(ctx context.Context, args []string) error {
 	flags := flag.NewFlagSet("search", flag.ContinueOnError)
 	flags.SetOutput(os.Stderr)
 
-	dbPath := flags.String("db", "", "SQLite database path")
+	dbPath := flags.String("db", "", "SQLite database path or postgres:// DSN")
 	query := flags.String("query", "", "Search query")
 	limit := flags.Int("limit", 10, "Max results")
-	threshold := flags.Float64("threshold", 0.7, "Similarity threshold (0-1, higher = stricter)")
+	minScore := flags.Float64("min-score", indexer.DefaultMinScore, "Minimum cosine similarity, from -1 to 1 (higher = stricter)")
+	threshold := flags.Float64("threshold", indexer.DefaultMinScore, "Deprecated: use -min-score")
+	explain := flags.Bool("explain", false, "Report per-result score components")
+	bm25Weight := flags.Float64("bm25-weight", 0, "Weight of the BM25 keyword score (0 = vector only)")
+	recencyWeight := flags.Float64("recency-weight", 0, "Boost for recently modified documents")
+	recencyHalfLife := flags.Duration("recency-half-life", 365*24*time.Hour, "Age at which the recency boost halves")
+	tagWeight := flags.Float64("tag-weight", 0, "Boost for query terms matching tag names")
 	logLevel := flags.String("log-level", os.Getenv("LOG_LEVEL"), "Log level (debug, info, warn, error)")
 	embeddingsURL := flags.String("embeddings-url", os.Getenv("PGO_RAG_EMBEDDINGS_URL"), "Embeddings API base URL")
 	embeddingsKey := flags.String("embeddings-key", os.Getenv("PGO_RAG_EMBEDDINGS_KEY"), "Embeddings API key")
@@ -184,28 +439,40 @@ func runSearch(ctx context.Context, args []string) error {
 	if *limit <= 0 {
 		return fmt.Errorf("-limit must be > 0")
 	}
-	if *threshold < 0 || *threshold > 1 {
-		return fmt.Errorf("-threshold must be between 0 and 1")
+	score, err := resolveMinScore(flags, *minScore, *threshold)
+	if err != nil {
+		return err
 	}
 	if *embeddingsURL == "" {
 		return fmt.Errorf("-embeddings-url is required")
 	}
-	if *embeddingsKey == "" {
+	if *embeddingsKey == "" && embedding.DetectProvider(*embeddingsURL) != embedding.ProviderOllama {
 		return fmt.Errorf("-embeddings-key is required")
 	}
-	if *embeddingsModel == "" {
-		return fmt.Errorf("-embeddings-model is required")
+	model, err := resolveEmbeddingsModel(*embeddingsURL, *embeddingsModel)
+	if err != nil {
+		return err
 	}
 
-	db, err := storage.NewDB(*dbPath)
+	db, err := storage.Open(*dbPath)
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	embedder := embedding.NewClient(*embeddingsURL, *embeddingsKey, *embeddingsModel)
+	embedder := embedding.NewClient(*embeddingsURL, *embeddingsKey, model)
 
-	summary, err := indexer.SearchIndex(ctx, db, embedder, *query, *limit, *threshold)
+	summary, err := indexer.SearchIndexWithOptions(ctx, db, embedder, *query, indexer.SearchOptions{
+		Limit:    *limit,
+		MinScore: score,
+		Explain:  *explain,
+		Ranking: storage.RankOptions{
+			BM25Weight:      *bm25Weight,
+			RecencyWeight:   *recencyWeight,
+			RecencyHalfLife: *recencyHalfLife,
+			TagWeight:       *tagWeight,
+		},
+	})
 	if err != nil {
 		return err
 	}
@@ -213,12 +480,659 @@ func runSearch(ctx context.Context, args []string) error {
 	return writeJSON(summary)
 }
 
+func runServe(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+
+	dbPath := flags.String("db", "", "SQLite database path or postgres:// DSN")
+	addr := flags.String("addr", getenvDefault("PGO_RAG_ADDR", ":8080"), "Listen address")
+	ui := flags.Bool("ui", false, "Serve a search page at / for use from a browser")
+	healthInterval := flags.Duration("health-interval", 30*time.Second, "Time between health checks of the index and embedder")
+	exitOnUnhealthy := flags.Bool("exit-on-unhealthy", false, "Exit with status 4 after -unhealthy-threshold failed health checks in a row")
+	unhealthyThreshold := flags.Int("unhealthy-threshold", 3, "Failed health checks in a row before -exit-on-unhealthy exits")
+	logLevel := flags.String("log-level", os.Getenv("LOG_LEVEL"), "Log level (debug, info, warn, error)")
+	embeddingsURL := flags.String("embeddings-url", os.Getenv("PGO_RAG_EMBEDDINGS_URL"), "Embeddings API base URL")
+	embeddingsKey := flags.String("embeddings-key", os.Getenv("PGO_RAG_EMBEDDINGS_KEY"), "Embeddings API key")
+	embeddingsModel := flags.String("embeddings-model", os.Getenv("PGO_RAG_EMBEDDINGS_MODEL"), "Embeddings model")
+	authToken := flags.String("auth-token", os.Getenv("PGO_RAG_AUTH_TOKEN"), "Bearer token required by the search API")
+	basicAuth := flags.String("basic-auth", os.Getenv("PGO_RAG_BASIC_AUTH"), "user:password accepted by the search API")
+	corsOrigins := flags.String("cors-origins", os.Getenv("PGO_RAG_CORS_ORIGINS"), "Comma-separated origins allowed to call the API from a browser, or *")
+	tlsCert := flags.String("tls-cert", os.Getenv("PGO_RAG_TLS_CERT"), "TLS certificate file")
+	tlsKey := flags.String("tls-key", os.Getenv("PGO_RAG_TLS_KEY"), "TLS key file")
+	tlsClientCA := flags.String("tls-client-ca", os.Getenv("PGO_RAG_TLS_CLIENT_CA"), "CA file clients must present certificates from (mTLS)")
+	chatURL := flags.String("chat-url", os.Getenv("PGO_RAG_CHAT_URL"), "Chat API base URL for /converse (defaults to -embeddings-url)")
+	chatKey := flags.String("chat-key", os.Getenv("PGO_RAG_CHAT_KEY"), "Chat API key (optional for local providers)")
+	chatAPI := flags.String("chat-api", os.Getenv("PGO_RAG_CHAT_API"), "Chat API: auto, openai or ollama (auto detects it from -chat-url)")
+	chatModel := flags.String("chat-model", os.Getenv("PGO_RAG_CHAT_MODEL"), "Chat model; enables /converse")
+	tokenizeURL := flags.String("tokenize-url", os.Getenv("PGO_RAG_TOKENIZE_URL"), "Tokenize endpoint of the chat model for the prompt token budget, e.g. llama.cpp's /tokenize")
+	tokenizeKey := flags.String("tokenize-key", os.Getenv("PGO_RAG_TOKENIZE_KEY"), "API key for -tokenize-url (optional)")
+	converseMaxLength := flags.Int("converse-max-length", getenvIntDefault("PGO_RAG_CONVERSE_MAX_LENGTH", server.DefaultConverseMaxLength), "Maximum length of /converse answers in characters")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := configureLogging(*logLevel); err != nil {
+		return err
+	}
+
+	if *dbPath == "" {
+		return fmt.Errorf("-db is required")
+	}
+	access, err := parseAccessConfig(*authToken, *basicAuth, *corsOrigins)
+	if err != nil {
+		return err
+	}
+	if (*tlsCert == "") != (*tlsKey == "") {
+		return fmt.Errorf("-tls-cert and -tls-key must be set together")
+	}
+	if *tlsClientCA != "" && *tlsCert == "" {
+		return fmt.Errorf("-tls-client-ca requires -tls-cert and -tls-key")
+	}
+	if *converseMaxLength <= 0 {
+		return fmt.Errorf("-converse-max-length must be > 0")
+	}
+	api, err := chat.ParseAPI(*chatAPI)
+	if err != nil {
+		return err
+	}
+	if *healthInterval <= 0 {
+		return fmt.Errorf("-health-interval must be > 0")
+	}
+	if *unhealthyThreshold <= 0 {
+		return fmt.Errorf("-unhealthy-threshold must be > 0")
+	}
+	if *embeddingsURL == "" {
+		return fmt.Errorf("-embeddings-url is required")
+	}
+	if *embeddingsKey == "" && embedding.DetectProvider(*embeddingsURL) != embedding.ProviderOllama {
+		return fmt.Errorf("-embeddings-key is required")
+	}
+	model, err := resolveEmbeddingsModel(*embeddingsURL, *embeddingsModel)
+	if err != nil {
+		return err
+	}
+
+	db, err := storage.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	srv := server.New(db, embedding.NewClient(*embeddingsURL, *embeddingsKey, model))
+	if *chatModel != "" {
+		if *chatURL == "" {
+			*chatURL = *embeddingsURL
+			if *chatKey == "" {
+				*chatKey = *embeddingsKey
+			}
+		}
+		srv.EnableConverse(server.ConverseConfig{
+			Chatter: chat.NewClientWithAPI(*chatURL, *chatKey, *chatModel, api),
+			Ask: indexer.AskOptions{
+				MinScore:    indexer.DefaultMinScore,
+				TokenBudget: indexer.DefaultTokenBudget,
+				Tokenize:    tokenizer(*tokenizeURL, *tokenizeKey, *chatModel),
+			},
+			MaxLength: *converseMaxLength,
+		})
+	}
+	if *ui {
+		srv.EnableUI()
+	}
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           server.WithAccess(srv.Handler(), access),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if *tlsClientCA != "" {
+		if httpServer.TLSConfig, err = server.ClientCATLSConfig(*tlsClientCA); err != nil {
+			return err
+		}
+	}
+	if !access.RequiresAuth() && *tlsClientCA == "" {
+		slog.Warn("The search API is unauthenticated; set -auth-token, -basic-auth or -tls-client-ca before exposing it")
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if *tlsCert != "" {
+			serveErr <- httpServer.ListenAndServeTLS(*tlsCert, *tlsKey)
+			return
+		}
+		serveErr <- httpServer.ListenAndServe()
+	}()
+	slog.Info("Serving", "addr", *addr, "ui", *ui, "tls", *tlsCert != "", "mtls", *tlsClientCA != "", "auth", access.RequiresAuth())
+
+	// Health is checked on a timer rather than per probe, so frequent
+	// orchestrator probes do not hit the embeddings API.
+	ticker := time.NewTicker(*healthInterval)
+	defer ticker.Stop()
+	for {
+		health := srv.CheckHealth()
+		if *exitOnUnhealthy && health.ConsecutiveFailures >= *unhealthyThreshold {
+			return shutdownServer(httpServer, errUnhealthy)
+		}
+		select {
+		case <-ctx.Done():
+			return shutdownServer(httpServer, nil)
+		case err := <-serveErr:
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+// parseAccessConfig builds the serve access settings from the -auth-token,
+// -basic-auth and -cors-origins values.
+func parseAccessConfig(token, basicAuth, origins string) (server.AccessConfig, error) {
+	access := server.AccessConfig{BearerToken: token}
+	if basicAuth != "" {
+		user, password, ok := strings.Cut(basicAuth, ":")
+		if !ok || user == "" || password == "" {
+			return access, fmt.Errorf("-basic-auth must be user:password")
+		}
+		access.BasicUser, access.BasicPassword = user, password
+	}
+	for _, origin := range strings.Split(origins, ",") {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin != "" {
+			access.AllowedOrigins = append(access.AllowedOrigins, origin)
+		}
+	}
+	return access, nil
+}
+
+// shutdownServer stops srv, letting in-flight requests finish, and returns
+// err unless the shutdown fails.
+func shutdownServer(srv *http.Server, err error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if shutdownErr := srv.Shutdown(ctx); shutdownErr != nil {
+		return shutdownErr
+	}
+	return err
+}
+
+// resolveMinScore reconciles -min-score with the deprecated -threshold flag.
+func resolveMinScore(flags *flag.FlagSet, minScore, threshold float64) (float64, error) {
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	score := minScore
+	if set["threshold"] {
+		fmt.Fprintln(os.Stderr, "warning: -threshold is deprecated; use -min-score")
+		if set["min-score"] && minScore != threshold {
+			return 0, fmt.Errorf("-threshold and -min-score disagree (%g vs %g); use -min-score only", threshold, minScore)
+		}
+		score = threshold
+	}
+	if err := indexer.ValidateMinScore(score); err != nil {
+		return 0, fmt.Errorf("-min-score: %w", err)
+	}
+	return score, nil
+}
+
+// resolvePaperless fills in the Paperless URL, token and rate limit that
+// were not given as flags from the environment, the pgo config file and the
+// token pgo login stored, the same way pgo does.
+func resolvePaperless(flags *flag.FlagSet, profileName string, url, token *string, maxRate *float64) error {
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	path, err := profile.DefaultPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := profile.Load(path)
+	if err != nil {
+		return err
+	}
+	s, err := profile.Resolve(cfg, profileName, os.Getenv)
+	if err != nil {
+		return err
+	}
+	if !set["url"] {
+		*url = s.URL
+	}
+	if !set["token"] {
+		*token = s.Token
+		// A token stored by pgo login is used if nothing else gives one
+		if *token == "" && *url != "" {
+			*token = profile.KeyringToken(*url)
+		}
+	}
+	if !set["max-rate"] {
+		*maxRate = s.MaxRate
+	}
+	return nil
+}
+
+// resolveMaxDocs returns the document limit for build, 0 meaning all
+// documents. A limit must be chosen explicitly: the former default of 5
+// silently indexed a fraction of most libraries. PGO_RAG_MAX_DOCS counts as
+// explicit but, unlike -max-docs, is overridden by -all.
+func resolveMaxDocs(flags *flag.FlagSet, all bool, maxDocs int) (int, error) {
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	if all {
+		if set["max-docs"] {
+			return 0, fmt.Errorf("-all and -max-docs cannot be combined")
+		}
+		fmt.Fprintln(os.Stderr, "indexing all documents (-all)")
+		return 0, nil
+	}
+	if maxDocs <= 0 {
+		if set["max-docs"] {
+			return 0, fmt.Errorf("-max-docs must be > 0; use -all to index every document")
+		}
+		return 0, fmt.Errorf("pass -all to index every document, or -max-docs <n> (or PGO_RAG_MAX_DOCS) to index the first n")
+	}
+	source := "-max-docs"
+	if !set["max-docs"] {
+		source = "PGO_RAG_MAX_DOCS"
+	}
+	fmt.Fprintf(os.Stderr, "indexing at most %d documents (%s)\n", maxDocs, source)
+	return maxDocs, nil
+}
+
+// resolveEmbeddingsModel returns model, or the provider default when empty.
+func resolveEmbeddingsModel(embeddingsURL, model string) (string, error) {
+	if model != "" {
+		return model, nil
+	}
+	if def := embedding.DefaultModel(embeddingsURL); def != "" {
+		slog.Info("Using provider default embeddings model",
+			"provider", embedding.DetectProvider(embeddingsURL),
+			"model", def,
+		)
+		return def, nil
+	}
+	return "", fmt.Errorf("-embeddings-model is required for this provider")
+}
+
+func runModels(args []string) error {
+	flags := flag.NewFlagSet("models", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+
+	logLevel := flags.String("log-level", os.Getenv("LOG_LEVEL"), "Log level (debug, info, warn, error)")
+	embeddingsURL := flags.String("embeddings-url", os.Getenv("PGO_RAG_EMBEDDINGS_URL"), "Embeddings API base URL")
+	embeddingsKey := flags.String("embeddings-key", os.Getenv("PGO_RAG_EMBEDDINGS_KEY"), "Embeddings API key (optional for local providers)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := configureLogging(*logLevel); err != nil {
+		return err
+	}
+
+	if *embeddingsURL == "" {
+		return fmt.Errorf("-embeddings-url is required")
+	}
+
+	models, err := embedding.NewClient(*embeddingsURL, *embeddingsKey, "").ListModels()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(struct {
+		Provider     embedding.Provider    `json:"provider"`
+		DefaultModel string                `json:"default_model,omitempty"`
+		Models       []embedding.ModelInfo `json:"models"`
+	}{
+		Provider:     embedding.DetectProvider(*embeddingsURL),
+		DefaultModel: embedding.DefaultModel(*embeddingsURL),
+		Models:       models,
+	})
+}
+
+func runAsk(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("ask", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+
+	dbPath := flags.String("db", "", "SQLite database path or postgres:// DSN")
+	question := flags.String("question", "", "Question to answer")
+	sources := flags.Int("sources", indexer.DefaultAskSources, "Maximum number of chunks given to the chat model")
+	tokenBudget := flags.Int("token-budget", getenvIntDefault("PGO_RAG_TOKEN_BUDGET", indexer.DefaultTokenBudget), "Prompt token budget (0 = no limit)")
+	minScore := flags.Float64("min-score", indexer.DefaultMinScore, "Minimum cosine similarity, from -1 to 1 (higher = stricter)")
+	bm25Weight := flags.Float64("bm25-weight", 0, "Weight of the BM25 keyword score (0 = vector only)")
+	logLevel := flags.String("log-level", os.Getenv("LOG_LEVEL"), "Log level (debug, info, warn, error)")
+	embeddingsURL := flags.String("embeddings-url", os.Getenv("PGO_RAG_EMBEDDINGS_URL"), "Embeddings API base URL")
+	embeddingsKey := flags.String("embeddings-key", os.Getenv("PGO_RAG_EMBEDDINGS_KEY"), "Embeddings API key")
+	embeddingsModel := flags.String("embeddings-model", os.Getenv("PGO_RAG_EMBEDDINGS_MODEL"), "Embeddings model")
+	chatURL := flags.String("chat-url", os.Getenv("PGO_RAG_CHAT_URL"), "Chat API base URL (defaults to -embeddings-url)")
+	chatKey := flags.String("chat-key", os.Getenv("PGO_RAG_CHAT_KEY"), "Chat API key (optional for local providers)")
+	chatAPI := flags.String("chat-api", os.Getenv("PGO_RAG_CHAT_API"), "Chat API: auto, openai or ollama (auto detects it from -chat-url)")
+	chatModel := flags.String("chat-model", os.Getenv("PGO_RAG_CHAT_MODEL"), "Chat model")
+	tokenizeURL := flags.String("tokenize-url", os.Getenv("PGO_RAG_TOKENIZE_URL"), "Tokenize endpoint of the chat model for -token-budget, e.g. llama.cpp's /tokenize")
+	tokenizeKey := flags.String("tokenize-key", os.Getenv("PGO_RAG_TOKENIZE_KEY"), "API key for -tokenize-url (optional)")
+	verbose := flags.Bool("verbose", false, "Report which chunks were included in or excluded from the prompt, and why")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := configureLogging(*logLevel); err != nil {
+		return err
+	}
+
+	if *dbPath == "" {
+		return fmt.Errorf("-db is required")
+	}
+	if strings.TrimSpace(*question) == "" {
+		return fmt.Errorf("-question is required")
+	}
+	if *sources <= 0 {
+		return fmt.Errorf("-sources must be > 0")
+	}
+	if *embeddingsURL == "" {
+		return fmt.Errorf("-embeddings-url is required")
+	}
+	if *embeddingsKey == "" && embedding.DetectProvider(*embeddingsURL) != embedding.ProviderOllama {
+		return fmt.Errorf("-embeddings-key is required")
+	}
+	if *chatModel == "" {
+		return fmt.Errorf("-chat-model is required")
+	}
+	api, err := chat.ParseAPI(*chatAPI)
+	if err != nil {
+		return err
+	}
+	model, err := resolveEmbeddingsModel(*embeddingsURL, *embeddingsModel)
+	if err != nil {
+		return err
+	}
+
+	db, err := storage.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	embedder := embedding.NewClient(*embeddingsURL, *embeddingsKey, model)
+	if *chatURL == "" {
+		// Reuse the embeddings provider for generation unless told otherwise.
+		*chatURL = *embeddingsURL
+		if *chatKey == "" {
+			*chatKey = *embeddingsKey
+		}
+	}
+	chatter := chat.NewClientWithAPI(*chatURL, *chatKey, *chatModel, api)
+
+	summary, err := indexer.Ask(ctx, db, embedder, chatter, *question, indexer.AskOptions{
+		Sources:     *sources,
+		MinScore:    *minScore,
+		Ranking:     storage.RankOptions{BM25Weight: *bm25Weight},
+		TokenBudget: *tokenBudget,
+		Tokenize:    tokenizer(*tokenizeURL, *tokenizeKey, *chatModel),
+		Report:      *verbose,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(summary)
+}
+
+// tokenizer counts prompt tokens with the chat model's tokenize endpoint.
+// Without an endpoint, Ask estimates them. The key is the endpoint's own:
+// the chat or embeddings key may belong to a different host.
+func tokenizer(tokenizeURL, apiKey, model string) indexer.Tokenizer {
+	if tokenizeURL == "" {
+		return nil
+	}
+	return chat.NewTokenizer(tokenizeURL, apiKey, model).CountTokens
+}
+
+func runSQL(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("sql", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+
+	dbPath := flags.String("db", "", "SQLite database path")
+	format := flags.String("format", "json", "Output format (json, csv)")
+	write := flags.Bool("write", false, "Allow statements that modify the index")
+	logLevel := flags.String("log-level", os.Getenv("LOG_LEVEL"), "Log level (debug, info, warn, error)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := configureLogging(*logLevel); err != nil {
+		return err
+	}
+
+	if *dbPath == "" {
+		return fmt.Errorf("-db is required")
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected exactly one SQL statement argument")
+	}
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("-format must be json or csv")
+	}
+	if storage.IsPostgresDSN(*dbPath) {
+		return fmt.Errorf("sql supports SQLite databases only; use psql for Postgres")
+	}
+
+	// Read-only queries never create or migrate the database.
+	open := storage.OpenReadOnly
+	if *write {
+		open = storage.NewDB
+	}
+	db, err := open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	result, err := db.Query(ctx, flags.Arg(0), *write)
+	if err != nil {
+		return err
+	}
+
+	if *format == "csv" {
+		return writeCSV(result)
+	}
+	return writeJSON(result)
+}
+
+func writeCSV(result *storage.QueryResult) error {
+	w := csv.NewWriter(os.Stdout)
+	if result.RowsAffected != nil {
+		// Statements without a result set report the rows they changed.
+		if err := w.Write([]string{"rows_affected"}); err != nil {
+			return err
+		}
+		if err := w.Write([]string{strconv.FormatInt(*result.RowsAffected, 10)}); err != nil {
+			return err
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	if err := w.Write(result.Columns); err != nil {
+		return err
+	}
+	for _, row := range result.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			if value != nil {
+				record[i] = fmt.Sprint(value)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func runDiffState(args []string) error {
+	flags := flag.NewFlagSet("diff-state", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+
+	beforePath := flags.String("before", "", "Index database before the change (e.g. a backup)")
+	afterPath := flags.String("after", "", "Index database after the change")
+	logLevel := flags.String("log-level", os.Getenv("LOG_LEVEL"), "Log level (debug, info, warn, error)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := configureLogging(*logLevel); err != nil {
+		return err
+	}
+
+	if *beforePath == "" {
+		return fmt.Errorf("-before is required")
+	}
+	if *afterPath == "" {
+		return fmt.Errorf("-after is required")
+	}
+	// NewDB creates missing files; a typo should not produce an empty index.
+	for _, path := range []string{*beforePath, *afterPath} {
+		if storage.IsPostgresDSN(path) {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("open index: %w", err)
+		}
+	}
+
+	before, err := storage.Open(*beforePath)
+	if err != nil {
+		return err
+	}
+	defer before.Close()
+
+	after, err := storage.Open(*afterPath)
+	if err != nil {
+		return err
+	}
+	defer after.Close()
+
+	diff, err := indexer.DiffState(before, after)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(diff)
+}
+
+func runStats(args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+
+	dbPath := flags.String("db", "", "SQLite database path")
+	groupBy := flags.String("group-by", indexer.GroupByTag, "Group documents by tag or correspondent")
+	logLevel := flags.String("log-level", os.Getenv("LOG_LEVEL"), "Log level (debug, info, warn, error)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := configureLogging(*logLevel); err != nil {
+		return err
+	}
+
+	if *dbPath == "" {
+		return fmt.Errorf("-db is required")
+	}
+	if *groupBy != indexer.GroupByTag && *groupBy != indexer.GroupByCorrespondent {
+		return fmt.Errorf("-group-by must be %s or %s", indexer.GroupByTag, indexer.GroupByCorrespondent)
+	}
+	if !storage.IsPostgresDSN(*dbPath) {
+		if _, err := os.Stat(*dbPath); err != nil {
+			return fmt.Errorf("open index: %w", err)
+		}
+	}
+
+	db, err := storage.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	stats, err := indexer.Stats(db, *groupBy)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(stats)
+}
+
+func runBackup(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+
+	dbPath := flags.String("db", "", "SQLite database path")
+	outPath := flags.String("out", "", "Snapshot output path")
+	logLevel := flags.String("log-level", os.Getenv("LOG_LEVEL"), "Log level (debug, info, warn, error)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := configureLogging(*logLevel); err != nil {
+		return err
+	}
+
+	if *dbPath == "" {
+		return fmt.Errorf("-db is required")
+	}
+	if *outPath == "" {
+		return fmt.Errorf("-out is required")
+	}
+	if *outPath == *dbPath {
+		return fmt.Errorf("-out must differ from -db")
+	}
+	if storage.IsPostgresDSN(*dbPath) {
+		return fmt.Errorf("backup supports SQLite databases only; use pg_dump for Postgres")
+	}
+
+	db, err := storage.NewDB(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	start := time.Now()
+	if err := db.Backup(ctx, *outPath); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(*outPath)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(struct {
+		Path       string `json:"path"`
+		Bytes      int64  `json:"bytes"`
+		DurationMs int64  `json:"duration_ms"`
+	}{
+		Path:       *outPath,
+		Bytes:      info.Size(),
+		DurationMs: time.Since(start).Milliseconds(),
+	})
+}
+
 func writeJSON(value interface{}) error {
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
 	return enc.Encode(value)
 }
 
+func getenvDefault(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
+
 func getenvIntDefault(key string, fallback int) int {
 	value := strings.TrimSpace(os.Getenv(key))
 	if value == "" {
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// matchingAlgorithms maps the --algorithm names to Paperless algorithms
+var matchingAlgorithms = map[string]paperless.MatchingAlgorithm{
+	"none":    paperless.MatchNone,
+	"any":     paperless.MatchAny,
+	"all":     paperless.MatchAll,
+	"literal": paperless.MatchLiteral,
+	"regex":   paperless.MatchRegex,
+	"fuzzy":   paperless.MatchFuzzy,
+	"auto":    paperless.MatchAuto,
+}
+
+// matchFlags are the automatic matching flags of add correspondent and
+// add doctype
+type matchFlags struct {
+	match         *string
+	algorithm     *string
+	caseSensitive *bool
+}
+
+func addMatchFlags(fs *flag.FlagSet) *matchFlags {
+	return &matchFlags{
+		match:         fs.String("match", "", "Text matched against new documents to assign this automatically"),
+		algorithm:     fs.String("algorithm", "", "How -match is matched: none, any, all, literal, regex, fuzzy or auto (default: the server's)"),
+		caseSensitive: fs.Bool("case-sensitive", false, "Match case-sensitively"),
+	}
+}
+
+// options returns the flags as fields of a create request, leaving unset
+// ones nil so the server's defaults apply
+func (m *matchFlags) options() (*paperless.MatchingAlgorithm, *bool, error) {
+	var algorithm *paperless.MatchingAlgorithm
+	if *m.algorithm != "" {
+		a, ok := matchingAlgorithms[strings.ToLower(*m.algorithm)]
+		if !ok {
+			return nil, nil, usagef("unknown matching algorithm: %s (want none, any, all, literal, regex, fuzzy or auto)", *m.algorithm)
+		}
+		algorithm = &a
+	}
+	var insensitive *bool
+	if *m.caseSensitive {
+		insensitive = paperless.Ptr(false)
+	}
+	return algorithm, insensitive, nil
+}
+
+func runAdd(client *paperless.Client, args []string) error {
+	if len(args) < 1 {
+		return commandUsage("add")
+	}
+	resource := args[0]
+	if resource != "tag" && resource != "correspondent" && resource != "doctype" {
+		return usagef("unknown resource for add: %s", resource)
+	}
+	if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+		return commandUsage("add " + resource)
+	}
+	name := args[1]
+
+	fs := flag.NewFlagSet("add "+resource, flag.ContinueOnError)
+	var match *matchFlags
+	if resource != "tag" {
+		match = addMatchFlags(fs)
+	}
+	if err := fs.Parse(args[2:]); err != nil {
+		return usagef("parse add %s flags: %w", resource, err)
+	}
+	if fs.NArg() != 0 {
+		return usagef("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var created interface{}
+	var err error
+	switch resource {
+	case "tag":
+		created, err = client.CreateTag(ctx, &paperless.TagCreate{Name: name})
+	case "correspondent", "doctype":
+		algorithm, insensitive, optErr := match.options()
+		if optErr != nil {
+			return optErr
+		}
+		if resource == "correspondent" {
+			created, err = client.CreateCorrespondent(ctx, &paperless.CorrespondentCreate{
+				Name:              name,
+				Match:             *match.match,
+				MatchingAlgorithm: algorithm,
+				IsInsensitive:     insensitive,
+			})
+		} else {
+			created, err = client.CreateDocumentType(ctx, &paperless.DocumentTypeCreate{
+				Name:              name,
+				Match:             *match.match,
+				MatchingAlgorithm: algorithm,
+				IsInsensitive:     insensitive,
+			})
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", resource, err)
+	}
+
+	if err := writeOutput(created); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCLI_AddCorrespondentAndDoctype(t *testing.T) {
+	var mu sync.Mutex
+	bodies := map[string]map[string]interface{}{}
+	received := func(path string) map[string]interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		return bodies[path]
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		bodies[r.URL.Path] = body
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 7, "name": "` + body["name"].(string) + `"}`))
+	}))
+	defer server.Close()
+
+	run := func(args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"add"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	stdout, stderr, err := run("correspondent", "ACME Corp", "--match", "acme", "--algorithm", "literal", "--case-sensitive")
+	if err != nil {
+		t.Fatalf("add correspondent failed: %v\nStderr: %s", err, stderr)
+	}
+	var created struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &created); err != nil || created.ID != 7 || created.Name != "ACME Corp" {
+		t.Errorf("output = %s (%v), want correspondent 7", stdout, err)
+	}
+	want := map[string]interface{}{"name": "ACME Corp", "match": "acme", "matching_algorithm": float64(3), "is_insensitive": false}
+	if got := received("/api/correspondents/"); !reflect.DeepEqual(got, want) {
+		t.Errorf("correspondent request = %v, want %v", got, want)
+	}
+
+	if _, stderr, err := run("doctype", "Invoice"); err != nil {
+		t.Fatalf("add doctype failed: %v\nStderr: %s", err, stderr)
+	}
+	if got := received("/api/document_types/"); !reflect.DeepEqual(got, map[string]interface{}{"name": "Invoice"}) {
+		t.Errorf("doctype request = %v, want only the name", got)
+	}
+
+	if _, stderr, err := run("doctype", "Invoice", "--algorithm", "exact"); err == nil || !strings.Contains(stderr, "unknown matching algorithm: exact") {
+		t.Errorf("expected unknown algorithm error, got %v, stderr: %s", err, stderr)
+	}
+	if _, stderr, err := run("tag", "x", "--match", "y"); err == nil || !strings.Contains(stderr, "flag provided but not defined") {
+		t.Errorf("expected add tag to reject --match, got %v, stderr: %s", err, stderr)
+	}
+}
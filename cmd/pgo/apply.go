@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// BulkApplyResult is the outcome for one document of a bulk apply
+type BulkApplyResult struct {
+	ID    int    `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// BulkApplyOutput is the result of apply docs with --from-file or stdin,
+// or with --remove-tags
+type BulkApplyOutput struct {
+	Tags        []int             `json:"tags"`
+	RemovedTags []int             `json:"removed_tags,omitempty"`
+	Results     []BulkApplyResult `json:"results"`
+	Succeeded   int               `json:"succeeded"`
+	Failed      int               `json:"failed"`
+}
+
+// tagEdit returns the bulk edit that adds the tags add and removes the tags
+// remove. Removing a single tag uses remove_tag; anything else modify_tags.
+func tagEdit(add, remove []int) (paperless.BulkEditMethod, map[string]interface{}) {
+	if len(add) == 0 && len(remove) == 1 {
+		return paperless.BulkRemoveTag, map[string]interface{}{"tag": remove[0]}
+	}
+	if remove == nil {
+		remove = []int{}
+	}
+	return paperless.BulkModifyTags, map[string]interface{}{"add_tags": add, "remove_tags": remove}
+}
+
+// tagEditFunc applies a tag change to a batch of documents
+type tagEditFunc func(ctx context.Context, docIDs []int) error
+
+// readDocumentIDs reads document IDs separated by whitespace or commas.
+// Text after # on a line is a comment. Duplicates are dropped, since
+// Paperless rejects bulk edits that list a document twice.
+func readDocumentIDs(r io.Reader) ([]int, error) {
+	var ids []int
+	seen := make(map[int]bool)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		for _, field := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			id, err := strconv.Atoi(field)
+			if err != nil || id <= 0 {
+				return nil, fmt.Errorf("line %d: invalid document ID %q", n, field)
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// stdinIDArg in place of a document ID makes a command read the IDs from
+// stdin, e.g. from pgo search docs piped through jq
+const stdinIDArg = "-"
+
+// readStdinDocumentIDs reads the document IDs given with stdinIDArg
+func readStdinDocumentIDs() ([]int, error) {
+	ids, err := readDocumentIDs(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document IDs: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no document IDs given")
+	}
+	return ids, nil
+}
+
+// warnMissingDocuments warns about the IDs that are not among docs
+func warnMissingDocuments(ids []int, docs []paperless.Document) {
+	found := make(map[int]bool, len(docs))
+	for _, doc := range docs {
+		found[doc.ID] = true
+	}
+	var missing []int
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		slog.Warn("documents not found or not matching the filters: " + joinIDs(missing))
+	}
+}
+
+// resolveNamedRefs resolves names (case-insensitive) or IDs of tags,
+// correspondents or document types. kind names the resource in errors.
+func resolveNamedRefs(refs []string, names map[int]string, kind string) ([]int, error) {
+	var ids []int
+	for _, ref := range refs {
+		id, err := resolveNamedRef(ref, names, kind)
+		if err != nil {
+			return nil, err
+		}
+		if !containsInt(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func resolveNamedRef(ref string, names map[int]string, kind string) (int, error) {
+	for id, name := range names {
+		if strings.EqualFold(name, ref) {
+			return id, nil
+		}
+	}
+	// Numbers are IDs unless something is named like one, as tags for years are
+	if id, err := strconv.Atoi(ref); err == nil && id > 0 {
+		return id, nil
+	}
+	return 0, fmt.Errorf("unknown %s: %s", kind, ref)
+}
+
+// resolveTagNames returns the IDs of the tags named names (case-insensitive),
+// along with all tag names. Names missing from the tag cache are looked up
+// again on the server in case the cache is stale; with createMissing, tags
+// that still don't exist are created.
+func resolveTagNames(ctx context.Context, client *paperless.Client, names []string, forceRefresh, createMissing bool) ([]int, map[int]string, error) {
+	tagNames, err := getTagNamesWithCache(ctx, client, forceRefresh, DefaultCacheTTL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+	missing := missingTagNames(names, tagNames)
+	if len(missing) > 0 && !forceRefresh {
+		if tagNames, err = getTagNamesWithCache(ctx, client, true, DefaultCacheTTL); err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch tags: %w", err)
+		}
+		missing = missingTagNames(names, tagNames)
+	}
+	if len(missing) > 0 {
+		if !createMissing {
+			return nil, nil, fmt.Errorf("unknown tag: %s (use --create-missing to create it)", strings.Join(missing, ", "))
+		}
+		for _, name := range missing {
+			tag, err := client.CreateTag(ctx, &paperless.TagCreate{Name: name})
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to create tag %s: %w", name, err)
+			}
+			fmt.Fprintf(os.Stderr, "Created tag %q (ID %d)\n", tag.Name, tag.ID)
+			tagNames[tag.ID] = name
+		}
+		tagCache().Save(tagNames)
+	}
+
+	var ids []int
+	for _, name := range names {
+		if id, ok := tagIDByName(tagNames, name); ok && !containsInt(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, tagNames, nil
+}
+
+// missingTagNames returns the names without a tag, without duplicates
+func missingTagNames(names []string, tagNames map[int]string) []string {
+	var missing []string
+	for _, name := range names {
+		if _, ok := tagIDByName(tagNames, name); !ok && !containsString(missing, name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+func tagIDByName(tagNames map[int]string, name string) (int, bool) {
+	for id, tagName := range tagNames {
+		if strings.EqualFold(tagName, name) {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// bulkApply applies edit to ids in batches, run by pool. A failed batch is
+// retried one document at a time, so one missing document does not fail
+// the others. Results are in the order of ids.
+func bulkApply(ctx context.Context, pool workerPool, ids []int, batchSize int, edit tagEditFunc) []BulkApplyResult {
+	var batches [][]int
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batches = append(batches, ids[start:end])
+	}
+
+	batchResults := make([][]BulkApplyResult, len(batches))
+	errs := pool.run(ctx, len(batches), func(ctx context.Context, i int) error {
+		batchResults[i] = applyBatch(ctx, batches[i], edit)
+		return nil
+	})
+
+	results := make([]BulkApplyResult, 0, len(ids))
+	for i, batch := range batches {
+		if errs[i] != nil {
+			// Not started before ctx was done
+			for _, id := range batch {
+				results = append(results, BulkApplyResult{ID: id, Error: errs[i].Error()})
+			}
+			continue
+		}
+		results = append(results, batchResults[i]...)
+	}
+	return results
+}
+
+// applyBatch applies edit to one batch, retrying its documents one at a
+// time if it fails
+func applyBatch(ctx context.Context, batch []int, edit tagEditFunc) []BulkApplyResult {
+	results := make([]BulkApplyResult, 0, len(batch))
+	err := edit(ctx, batch)
+	if err == nil {
+		for _, id := range batch {
+			results = append(results, BulkApplyResult{ID: id, OK: true})
+		}
+		return results
+	}
+	if len(batch) == 1 || ctx.Err() != nil {
+		for _, id := range batch {
+			results = append(results, BulkApplyResult{ID: id, Error: err.Error()})
+		}
+		return results
+	}
+	for _, id := range batch {
+		result := BulkApplyResult{ID: id, OK: true}
+		if err := edit(ctx, []int{id}); err != nil {
+			result = BulkApplyResult{ID: id, Error: err.Error()}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// applyDocFlags are the flags of apply docs <id>
+type applyDocFlags struct {
+	tags          *string
+	tagNames      *string
+	createMissing *bool
+	removeTags    *string
+}
+
+func addApplyDocFlags(fs *flag.FlagSet) *applyDocFlags {
+	return &applyDocFlags{
+		tags:          fs.String("tags", "", "Comma-separated tag IDs to set, replacing the document's tags"),
+		tagNames:      fs.String("tag-names", "", "Comma-separated tag names to set, combined with -tags"),
+		createMissing: fs.Bool("create-missing", false, "Create the tags of -tag-names that don't exist"),
+		removeTags:    fs.String("remove-tags", "", "Comma-separated tag names or IDs to remove, keeping the others"),
+	}
+}
+
+// bulkApplyFlags are the flags of apply docs without an ID
+type bulkApplyFlags struct {
+	fromFile   *string
+	tags       *string
+	removeTags *string
+	batchSize  *int
+}
+
+func addBulkApplyFlags(fs *flag.FlagSet) *bulkApplyFlags {
+	return &bulkApplyFlags{
+		fromFile:   fs.String("from-file", "", "File with document IDs, one per line (default: stdin)"),
+		tags:       fs.String("tags", "", "Comma-separated tag names or IDs to add"),
+		removeTags: fs.String("remove-tags", "", "Comma-separated tag names or IDs to remove"),
+		batchSize:  fs.Int("batch-size", 100, "Documents per bulk edit request"),
+	}
+}
+
+// runBulkApply adds and removes tags of several documents with bulk edits.
+// The documents are ids, or if ids is nil, those listed in --from-file or
+// on stdin.
+func runBulkApply(client *paperless.Client, args []string, ids []int, forceRefresh bool, pool workerPool) error {
+	applyFlags := flag.NewFlagSet("apply docs", flag.ContinueOnError)
+	bulk := addBulkApplyFlags(applyFlags)
+	positional, err := parseInterspersed(applyFlags, args)
+	if err != nil {
+		return usagef("parse apply flags: %w", err)
+	}
+	fromFile, tags, removeTags, batchSize := bulk.fromFile, bulk.tags, bulk.removeTags, bulk.batchSize
+	// "-" reads the IDs from stdin, which is also the default
+	if len(positional) == 1 && positional[0] == stdinIDArg && ids == nil && *fromFile == "" {
+		positional = nil
+	}
+	if len(positional) != 0 || *batchSize <= 0 {
+		return commandUsage("apply docs")
+	}
+	tagRefs := splitList(*tags)
+	removeRefs := splitList(*removeTags)
+	if len(tagRefs) == 0 && len(removeRefs) == 0 {
+		return usagef("missing required flag: --tags or --remove-tags")
+	}
+
+	if ids == nil {
+		var in io.Reader = os.Stdin
+		if *fromFile != "" && *fromFile != "-" {
+			f, err := os.Open(*fromFile)
+			if err != nil {
+				return fmt.Errorf("failed to open ID file: %w", err)
+			}
+			defer f.Close()
+			in = f
+		}
+		if ids, err = readDocumentIDs(in); err != nil {
+			return fmt.Errorf("failed to read document IDs: %w", err)
+		}
+		if len(ids) == 0 {
+			return fmt.Errorf("no document IDs given")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	tagNames, err := getTagNamesWithCache(ctx, client, forceRefresh, DefaultCacheTTL)
+	if err != nil {
+		slog.Warn("Could not fetch tags for name resolution", "error", err)
+		tagNames = make(map[int]string)
+	}
+	tagIDs, err := resolveNamedRefs(tagRefs, tagNames, "tag")
+	if err != nil {
+		return err
+	}
+	removeIDs, err := resolveNamedRefs(removeRefs, tagNames, "tag")
+	if err != nil {
+		return err
+	}
+	if tagIDs == nil {
+		tagIDs = []int{}
+	}
+
+	method, params := tagEdit(tagIDs, removeIDs)
+	results := bulkApply(ctx, pool, ids, *batchSize, func(ctx context.Context, docIDs []int) error {
+		return client.BulkEditDocuments(ctx, docIDs, method, params)
+	})
+
+	output := BulkApplyOutput{Tags: tagIDs, RemovedTags: removeIDs, Results: results}
+	for _, r := range results {
+		if r.OK {
+			output.Succeeded++
+		} else {
+			output.Failed++
+		}
+	}
+	if err := writeOutput(output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	if output.Failed > 0 {
+		return fmt.Errorf("failed to update tags of %d of %d documents", output.Failed, len(ids))
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestReadDocumentIDs(t *testing.T) {
+	ids, err := readDocumentIDs(strings.NewReader("# invoices\n12\n13, 14 15\n\n12  # again\n"))
+	if err != nil {
+		t.Fatalf("readDocumentIDs failed: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int{12, 13, 14, 15}) {
+		t.Errorf("ids = %v, want [12 13 14 15]", ids)
+	}
+
+	if _, err := readDocumentIDs(strings.NewReader("1\nabc\n")); err == nil || !strings.Contains(err.Error(), `line 2: invalid document ID "abc"`) {
+		t.Errorf("expected invalid ID error, got %v", err)
+	}
+}
+
+func TestResolveNamedRefs(t *testing.T) {
+	tagNames := map[int]string{1: "Invoice", 7: "2024"}
+	ids, err := resolveNamedRefs([]string{"invoice", "2024", "3", "Invoice"}, tagNames, "tag")
+	if err != nil {
+		t.Fatalf("resolveNamedRefs failed: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int{1, 7, 3}) {
+		t.Errorf("ids = %v, want [1 7 3] (a tag named 2024 wins over ID 2024)", ids)
+	}
+	if _, err := resolveNamedRefs([]string{"receipts"}, tagNames, "tag"); err == nil || err.Error() != "unknown tag: receipts" {
+		t.Errorf("expected unknown tag error, got %v", err)
+	}
+}
+
+func TestBulkApply(t *testing.T) {
+	var batches [][]int
+	edit := func(ctx context.Context, ids []int) error {
+		batches = append(batches, ids)
+		for _, id := range ids {
+			if id == 4 {
+				return fmt.Errorf("document 4 does not exist")
+			}
+		}
+		return nil
+	}
+
+	results := bulkApply(context.Background(), workerPool{concurrency: 1}, []int{1, 2, 3, 4, 5}, 2, edit)
+	wantBatches := [][]int{{1, 2}, {3, 4}, {3}, {4}, {5}}
+	if !reflect.DeepEqual(batches, wantBatches) {
+		t.Errorf("batches = %v, want %v", batches, wantBatches)
+	}
+	want := []BulkApplyResult{
+		{ID: 1, OK: true},
+		{ID: 2, OK: true},
+		{ID: 3, OK: true},
+		{ID: 4, Error: "document 4 does not exist"},
+		{ID: 5, OK: true},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results = %+v, want %+v", results, want)
+	}
+}
+
+func TestCLI_ApplyDocs_Bulk(t *testing.T) {
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags/":
+			w.Write([]byte(`{"count": 2, "results": [{"id": 1, "name": "invoice"}, {"id": 9, "name": "2024"}]}`))
+		case "/api/documents/bulk_edit/":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			requests = append(requests, body)
+			w.Write([]byte(`{"result": "OK"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(stdin string, args ...string) (BulkApplyOutput, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory", "apply", "docs"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token")
+		cmd.Stdin = strings.NewReader(stdin)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		var out BulkApplyOutput
+		if stdout.Len() > 0 {
+			if jsonErr := json.Unmarshal(stdout.Bytes(), &out); jsonErr != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", jsonErr, stdout.String())
+			}
+		}
+		return out, stderr.String(), err
+	}
+
+	out, stderr, err := run("3\n4\n5\n", "--tags", "invoice,2024", "--batch-size", "2")
+	if err != nil {
+		t.Fatalf("Command failed: %v\nStderr: %s", err, stderr)
+	}
+	if out.Succeeded != 3 || out.Failed != 0 || !reflect.DeepEqual(out.Tags, []int{1, 9}) {
+		t.Errorf("output = %+v", out)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("got %d bulk edit requests, want 2", len(requests))
+	}
+	first := requests[0]
+	if first["method"] != "modify_tags" || !reflect.DeepEqual(first["documents"], []interface{}{float64(3), float64(4)}) {
+		t.Errorf("first request = %v", first)
+	}
+	params := first["parameters"].(map[string]interface{})
+	if !reflect.DeepEqual(params["add_tags"], []interface{}{float64(1), float64(9)}) {
+		t.Errorf("add_tags = %v, want [1 9]", params["add_tags"])
+	}
+
+	idFile := filepath.Join(t.TempDir(), "ids.txt")
+	if err := os.WriteFile(idFile, []byte("8\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, stderr, err = run("", "--from-file="+idFile, "--tags=invoice")
+	if err != nil || out.Succeeded != 1 || out.Results[0].ID != 8 {
+		t.Errorf("--from-file output = %+v, %v, stderr: %s", out, err, stderr)
+	}
+
+	// "-" reads stdin, with flags after it
+	out, stderr, err = run("6 7\n", "-", "--tags", "invoice")
+	if err != nil || out.Succeeded != 2 || out.Results[1].ID != 7 {
+		t.Errorf("- output = %+v, %v, stderr: %s", out, err, stderr)
+	}
+
+	if _, stderr, err = run("1\n", "--tags", "unknown"); err == nil || !strings.Contains(stderr, "unknown tag: unknown") {
+		t.Errorf("expected unknown tag error, got %v, stderr: %s", err, stderr)
+	}
+}
+
+func TestCLI_ApplyDocs_TagNames(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		created []string
+		patched []interface{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/tags/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 1, "name": "Taxes"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/tags/":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			created = append(created, body["name"].(string))
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"id": 9, "name": %q}`, body["name"])
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/documents/5/":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			patched = body["tags"].([]interface{})
+			b, _ := json.Marshal(map[string]interface{}{"id": 5, "tags": patched})
+			w.Write(b)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(args ...string) (DocumentWithTagNames, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory", "apply", "docs", "5"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		var out DocumentWithTagNames
+		if stdout.Len() > 0 {
+			if jsonErr := json.Unmarshal(stdout.Bytes(), &out); jsonErr != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", jsonErr, stdout.String())
+			}
+		}
+		return out, stderr.String(), err
+	}
+
+	if _, stderr, err := run("--tag-names", "taxes,2024"); err == nil || !strings.Contains(stderr, "unknown tag: 2024 (use --create-missing") {
+		t.Errorf("expected unknown tag error, got %v, stderr: %s", err, stderr)
+	}
+	mu.Lock()
+	if patched != nil || created != nil {
+		t.Fatalf("document or tags changed without --create-missing: %v, %v", patched, created)
+	}
+	mu.Unlock()
+
+	out, stderr, err := run("--tag-names=taxes,2024", "--create-missing")
+	if err != nil {
+		t.Fatalf("Command failed: %v\nStderr: %s", err, stderr)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(created, []string{"2024"}) {
+		t.Errorf("created tags = %v, want [2024]", created)
+	}
+	if !reflect.DeepEqual(patched, []interface{}{float64(1), float64(9)}) {
+		t.Errorf("patched tags = %v, want [1 9]", patched)
+	}
+	if !reflect.DeepEqual(out.TagNames, []string{"Taxes", "2024"}) {
+		t.Errorf("tag names = %v, want [Taxes 2024]", out.TagNames)
+	}
+}
+
+func TestCLI_ApplyDocs_RemoveTags(t *testing.T) {
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags/":
+			w.Write([]byte(`{"count": 2, "results": [{"id": 1, "name": "inbox"}, {"id": 9, "name": "2024"}]}`))
+		case "/api/documents/bulk_edit/":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			requests = append(requests, body)
+			w.Write([]byte(`{"result": "OK"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(stdin string, args ...string) (BulkApplyOutput, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory", "apply", "docs"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		cmd.Stdin = strings.NewReader(stdin)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		var out BulkApplyOutput
+		if stdout.Len() > 0 {
+			if jsonErr := json.Unmarshal(stdout.Bytes(), &out); jsonErr != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", jsonErr, stdout.String())
+			}
+		}
+		return out, stderr.String(), err
+	}
+
+	out, stderr, err := run("", "12", "--remove-tags=inbox")
+	if err != nil {
+		t.Fatalf("Command failed: %v\nStderr: %s", err, stderr)
+	}
+	if out.Succeeded != 1 || !reflect.DeepEqual(out.RemovedTags, []int{1}) || len(out.Tags) != 0 {
+		t.Errorf("output = %+v", out)
+	}
+	want := map[string]interface{}{
+		"documents":  []interface{}{float64(12)},
+		"method":     "remove_tag",
+		"parameters": map[string]interface{}{"tag": float64(1)},
+	}
+	if len(requests) != 1 || !reflect.DeepEqual(requests[0], want) {
+		t.Fatalf("requests = %v, want %v", requests, want)
+	}
+
+	requests = nil
+	if _, stderr, err = run("3\n4\n", "--tags", "2024", "--remove-tags", "inbox"); err != nil {
+		t.Fatalf("Command failed: %v\nStderr: %s", err, stderr)
+	}
+	params := requests[0]["parameters"].(map[string]interface{})
+	if requests[0]["method"] != "modify_tags" || !reflect.DeepEqual(params["add_tags"], []interface{}{float64(9)}) || !reflect.DeepEqual(params["remove_tags"], []interface{}{float64(1)}) {
+		t.Errorf("request = %v, want modify_tags adding 9 and removing 1", requests[0])
+	}
+
+	if _, stderr, err = run("", "12", "--tags=1", "--remove-tags=inbox"); err == nil || !strings.Contains(stderr, "cannot be combined") {
+		t.Errorf("expected --tags with --remove-tags to fail, got %v, stderr: %s", err, stderr)
+	}
+}
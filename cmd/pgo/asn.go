@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+	"github.com/jason-riddle/paperless-go/cmd/pgo/internal/cache"
+)
+
+// ASNOutput is the result of asn next
+type ASNOutput struct {
+	ASN      int64 `json:"asn"`
+	Reserved bool  `json:"reserved"` // false with --peek
+}
+
+// asnReservations records the highest archive serial number handed out by
+// asn next or asn assign. The server only knows ASNs assigned to documents,
+// so without it two labels printed before either document is scanned would
+// get the same number.
+type asnReservations struct {
+	Last       int64     `json:"last"`
+	ReservedAt time.Time `json:"reserved_at"`
+}
+
+// getASNFilePath returns the path of the ASN reservations of the instance.
+// It lives next to the caches but is not one: cache clear keeps it.
+func getASNFilePath() (string, error) {
+	dir, err := caches.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "asn.json"), nil
+}
+
+// loadASNReservations returns the reservations, or none if there are none yet
+func loadASNReservations() (asnReservations, error) {
+	var r asnReservations
+	path, err := getASNFilePath()
+	if err != nil {
+		return r, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return r, fmt.Errorf("read ASN reservations: %w", err)
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, fmt.Errorf("parse ASN reservations %s: %w", path, err)
+	}
+	return r, nil
+}
+
+func saveASNReservations(r asnReservations) error {
+	path, err := getASNFilePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create cache directory: %w", err)
+	}
+	if err := cache.WriteFileAtomic(path, data); err != nil {
+		return fmt.Errorf("save ASN reservations: %w", err)
+	}
+	return nil
+}
+
+// nextASN returns the next free archive serial number: the server's next one,
+// or the one after the last reserved, whichever is higher. With reserve, it
+// is recorded as reserved.
+func nextASN(ctx context.Context, client *paperless.Client, reserve bool) (int64, error) {
+	next, err := client.NextArchiveSerialNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get next archive serial number: %w", err)
+	}
+	if reserve {
+		// Concurrent runs read and update the reservations one after the
+		// other, so they never reserve the same number
+		path, err := getASNFilePath()
+		if err != nil {
+			return 0, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return 0, fmt.Errorf("create cache directory: %w", err)
+		}
+		unlock, err := cache.LockFile(path + ".lock")
+		if err != nil {
+			return 0, fmt.Errorf("lock ASN reservations: %w", err)
+		}
+		defer unlock()
+	}
+	reservations, err := loadASNReservations()
+	if err != nil {
+		return 0, err
+	}
+	if reservations.Last >= next {
+		next = reservations.Last + 1
+	}
+	if reserve {
+		if err := saveASNReservations(asnReservations{Last: next, ReservedAt: time.Now()}); err != nil {
+			return 0, err
+		}
+	}
+	return next, nil
+}
+
+// asnNextFlags are the flags of asn next
+type asnNextFlags struct {
+	peek *bool
+}
+
+func addASNNextFlags(fs *flag.FlagSet) *asnNextFlags {
+	return &asnNextFlags{
+		peek: fs.Bool("peek", false, "Show the next archive serial number without reserving it"),
+	}
+}
+
+// asnAssignFlags are the flags of asn assign
+type asnAssignFlags struct {
+	asn   *int64
+	force *bool
+}
+
+func addASNAssignFlags(fs *flag.FlagSet) *asnAssignFlags {
+	return &asnAssignFlags{
+		asn:   fs.Int64("asn", 0, "Archive serial number to assign, e.g. one reserved with asn next (default: the next free one)"),
+		force: fs.Bool("force", false, "Replace an archive serial number the document already has"),
+	}
+}
+
+// runASN reserves archive serial numbers and assigns them to documents
+func runASN(client *paperless.Client, args []string, forceRefresh bool) error {
+	if len(args) == 0 || (args[0] != "next" && args[0] != "assign") {
+		return commandUsage("asn")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if args[0] == "next" {
+		fs := flag.NewFlagSet("asn next", flag.ContinueOnError)
+		flags := addASNNextFlags(fs)
+		if err := fs.Parse(args[1:]); err != nil {
+			return usagef("parse asn next flags: %w", err)
+		}
+		if fs.NArg() != 0 {
+			return commandUsage("asn next")
+		}
+		next, err := nextASN(ctx, client, !*flags.peek)
+		if err != nil {
+			return err
+		}
+		if err := writeOutput(ASNOutput{ASN: next, Reserved: !*flags.peek}); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		return nil
+	}
+
+	fs := flag.NewFlagSet("asn assign", flag.ContinueOnError)
+	flags := addASNAssignFlags(fs)
+	positional, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		return usagef("parse asn assign flags: %w", err)
+	}
+	if len(positional) != 1 {
+		return commandUsage("asn assign")
+	}
+	id, err := strconv.Atoi(positional[0])
+	if err != nil || id <= 0 {
+		return usagef("invalid ID format: %s", positional[0])
+	}
+	if *flags.asn < 0 {
+		return usagef("invalid archive serial number: %d", *flags.asn)
+	}
+
+	doc, err := client.GetDocument(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get document %d: %w", id, err)
+	}
+	if doc.ArchiveSerialNumber != nil && !*flags.force {
+		return fmt.Errorf("document %d already has archive serial number %d (use --force to replace it)", id, *doc.ArchiveSerialNumber)
+	}
+
+	if asn := *flags.asn; asn > 0 {
+		// An explicit number must not be taken by another document
+		taken, err := client.ListDocuments(ctx, &paperless.ListOptions{ArchiveSerialNumber: asn})
+		if err != nil {
+			return fmt.Errorf("failed to check archive serial number %d: %w", asn, err)
+		}
+		for _, other := range taken.Results {
+			if other.ID != id {
+				return fmt.Errorf("archive serial number %d is already assigned to document %d", asn, other.ID)
+			}
+		}
+		if doc, err = client.UpdateDocument(ctx, id, &paperless.DocumentUpdate{ArchiveSerialNumber: &asn}); err != nil {
+			return fmt.Errorf("failed to assign archive serial number %d: %w", asn, err)
+		}
+	} else {
+		// Another client may assign the same number first; Paperless then
+		// rejects it as not unique and the next one is tried
+		const attempts = 3
+		for attempt := 1; ; attempt++ {
+			asn, err := nextASN(ctx, client, true)
+			if err != nil {
+				return err
+			}
+			doc, err = client.UpdateDocument(ctx, id, &paperless.DocumentUpdate{ArchiveSerialNumber: &asn})
+			if err == nil {
+				break
+			}
+			if !paperless.IsValidation(err) || attempt == attempts {
+				return fmt.Errorf("failed to assign archive serial number %d: %w", asn, err)
+			}
+		}
+	}
+
+	tagNames, err := getTagNamesWithCache(ctx, client, forceRefresh, DefaultCacheTTL)
+	if err != nil {
+		slog.Warn("Could not fetch tags for name resolution", "error", err)
+		tagNames = make(map[int]string)
+	}
+	if err := writeOutput(convertDocToOutput(doc, tagNames)); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCLI_ASN(t *testing.T) {
+	// Document 5 has ASN 10, document 6 has none. The server rejects ASN 13
+	// as if another client had just assigned it.
+	var (
+		mu      sync.Mutex
+		patched []map[string]interface{}
+	)
+	patches := func() []map[string]interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]map[string]interface{}(nil), patched...)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/tags/":
+			w.Write([]byte(`{"count": 0, "results": []}`))
+		case r.URL.Path == "/api/documents/next_asn/":
+			w.Write([]byte(`11`))
+		case r.URL.Path == "/api/documents/" && r.URL.Query().Get("archive_serial_number") == "10":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 5, "archive_serial_number": 10}]}`))
+		case r.URL.Path == "/api/documents/":
+			w.Write([]byte(`{"count": 0, "results": []}`))
+		case r.URL.Path == "/api/documents/5/" && r.Method == "GET":
+			w.Write([]byte(`{"id": 5, "title": "Invoice", "archive_serial_number": 10}`))
+		case r.URL.Path == "/api/documents/6/" && r.Method == "GET":
+			w.Write([]byte(`{"id": 6, "title": "Receipt"}`))
+		case r.URL.Path == "/api/documents/6/" && r.Method == "PATCH":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			patched = append(patched, body)
+			mu.Unlock()
+			if body["archive_serial_number"] == float64(13) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"archive_serial_number": ["Document with this Archive Serial Number already exists."]}`))
+				return
+			}
+			w.Write([]byte(`{"id": 6, "title": "Receipt", "archive_serial_number": ` + fmt.Sprint(body["archive_serial_number"]) + `}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cacheHome := t.TempDir()
+	run := func(args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+cacheHome)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+	next := func(args ...string) ASNOutput {
+		t.Helper()
+		stdout, stderr, err := run(append([]string{"asn", "next"}, args...)...)
+		if err != nil {
+			t.Fatalf("asn next failed: %v\nStderr: %s", err, stderr)
+		}
+		var out ASNOutput
+		if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+			t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, stdout)
+		}
+		return out
+	}
+
+	// Reserved numbers are not handed out twice, though the server still
+	// suggests 11
+	if out := next("-peek"); out != (ASNOutput{ASN: 11}) {
+		t.Errorf("asn next -peek = %+v, want 11 unreserved", out)
+	}
+	if out := next(); out != (ASNOutput{ASN: 11, Reserved: true}) {
+		t.Errorf("asn next = %+v, want 11 reserved", out)
+	}
+	if out := next(); out.ASN != 12 {
+		t.Errorf("second asn next = %+v, want 12", out)
+	}
+
+	// 13 is rejected as taken, so 14 is assigned
+	stdout, stderr, err := run("asn", "assign", "6")
+	if err != nil {
+		t.Fatalf("asn assign failed: %v\nStderr: %s", err, stderr)
+	}
+	if got := patches(); len(got) != 2 || got[1]["archive_serial_number"] != float64(14) {
+		t.Errorf("patches = %v, want 13 then 14", got)
+	}
+	if !strings.Contains(stdout, `"archive_serial_number": 14`) {
+		t.Errorf("expected the updated document, got %s", stdout)
+	}
+
+	if _, stderr, err := run("asn", "assign", "5"); err == nil || !strings.Contains(stderr, "already has archive serial number 10") {
+		t.Errorf("expected already assigned error, got %v, stderr: %s", err, stderr)
+	}
+	if _, stderr, err := run("asn", "assign", "6", "--asn", "10"); err == nil || !strings.Contains(stderr, "already assigned to document 5") {
+		t.Errorf("expected collision error, got %v, stderr: %s", err, stderr)
+	}
+	if _, stderr, err := run("asn", "assign"); err == nil || !strings.Contains(stderr, "usage: pgo asn assign") {
+		t.Errorf("expected usage error, got %v, stderr: %s", err, stderr)
+	}
+}
+
+func TestCLI_ASNConcurrent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/documents/next_asn/":
+			w.Write([]byte(`1`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// Every run sees the same server suggestion, so only the reservations
+	// keep them apart
+	const runs = 8
+	cacheHome := t.TempDir()
+	asns := make([]int64, runs)
+	var wg sync.WaitGroup
+	for i := range asns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd := exec.Command("./pgo", "-memory", "asn", "next")
+			cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+cacheHome)
+			var stdout, stderr bytes.Buffer
+			cmd.Stdout, cmd.Stderr = &stdout, &stderr
+			if err := cmd.Run(); err != nil {
+				t.Errorf("asn next failed: %v\nStderr: %s", err, stderr.String())
+				return
+			}
+			var out ASNOutput
+			if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+				t.Errorf("Failed to parse JSON output: %v\nOutput: %s", err, stdout.String())
+				return
+			}
+			asns[i] = out.ASN
+		}()
+	}
+	wg.Wait()
+
+	seen := map[int64]bool{}
+	for _, asn := range asns {
+		if seen[asn] {
+			t.Errorf("ASN %d reserved twice: %v", asn, asns)
+		}
+		seen[asn] = true
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// auditChecks are the problems audit looks for, in the order they are
+// reported
+var auditChecks = []string{"no-tags", "no-correspondent", "no-doctype", "no-content"}
+
+// auditProblems returns the problems of doc among checks
+func auditProblems(doc *paperless.Document, checks map[string]bool) []string {
+	var problems []string
+	for _, check := range auditChecks {
+		if !checks[check] {
+			continue
+		}
+		var failed bool
+		switch check {
+		case "no-tags":
+			failed = len(doc.Tags) == 0
+		case "no-correspondent":
+			failed = doc.Correspondent == nil
+		case "no-doctype":
+			failed = doc.DocumentType == nil
+		case "no-content":
+			failed = strings.TrimSpace(doc.Content) == ""
+		}
+		if failed {
+			problems = append(problems, check)
+		}
+	}
+	return problems
+}
+
+// AuditReport is the result of audit: the number of documents with each
+// problem, and one result per document and problem, grouped by problem, so
+// the csv and table formats list the cleanup queue as is. A document with
+// several problems is listed once per problem.
+type AuditReport struct {
+	Documents int            `json:"documents"`
+	Problems  []AuditProblem `json:"problems"`
+	Results   []AuditIssue   `json:"results"`
+}
+
+// AuditProblem is the number of documents with a problem
+type AuditProblem struct {
+	Problem string `json:"problem"`
+	Count   int    `json:"count"`
+}
+
+// AuditIssue is a document with a problem
+type AuditIssue struct {
+	Problem string `json:"problem"`
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	Created string `json:"created"`
+}
+
+// auditCounts collects the documents with each problem
+type auditCounts struct {
+	checks    map[string]bool
+	documents int
+	issues    map[string][]AuditIssue
+}
+
+func newAuditCounts(checks map[string]bool) *auditCounts {
+	return &auditCounts{checks: checks, issues: map[string][]AuditIssue{}}
+}
+
+func (c *auditCounts) add(doc *paperless.Document) {
+	c.documents++
+	for _, problem := range auditProblems(doc, c.checks) {
+		c.issues[problem] = append(c.issues[problem], AuditIssue{
+			Problem: problem,
+			ID:      doc.ID,
+			Title:   doc.Title,
+			Created: doc.CreatedDay().String(),
+		})
+	}
+}
+
+// report lists the problems that were checked in auditChecks order
+func (c *auditCounts) report() AuditReport {
+	r := AuditReport{Documents: c.documents, Problems: []AuditProblem{}, Results: []AuditIssue{}}
+	for _, check := range auditChecks {
+		if !c.checks[check] {
+			continue
+		}
+		r.Problems = append(r.Problems, AuditProblem{Problem: check, Count: len(c.issues[check])})
+		r.Results = append(r.Results, c.issues[check]...)
+	}
+	return r
+}
+
+// parseAuditChecks parses the -check list; an empty list selects all checks
+func parseAuditChecks(list string) (map[string]bool, error) {
+	checks := map[string]bool{}
+	names := splitList(list)
+	if len(names) == 0 {
+		names = auditChecks
+	}
+	for _, name := range names {
+		name = strings.ToLower(name)
+		known := false
+		for _, check := range auditChecks {
+			known = known || name == check
+		}
+		if !known {
+			return nil, usagef("unknown audit check: %s (want %s)", name, strings.Join(auditChecks, ", "))
+		}
+		checks[name] = true
+	}
+	return checks, nil
+}
+
+// auditFlags are the flags of audit
+type auditFlags struct {
+	checks  *string
+	format  *string
+	filters *docFilters
+}
+
+func addAuditFlags(fs *flag.FlagSet) *auditFlags {
+	return &auditFlags{
+		checks:  fs.String("check", "", "Problems to look for, comma-separated: "+strings.Join(auditChecks, ", ")+" (default: all)"),
+		format:  fs.String("format", "", "Output format: pretty, json, table, csv or yaml (default: -output-format)"),
+		filters: addDocFilterFlags(fs),
+	}
+}
+
+func runAudit(client *paperless.Client, args []string, forceRefresh bool) error {
+	auditFlagSet := flag.NewFlagSet("audit", flag.ContinueOnError)
+	audit := addAuditFlags(auditFlagSet)
+	if err := auditFlagSet.Parse(args); err != nil {
+		return usagef("parse audit flags: %w", err)
+	}
+	if auditFlagSet.NArg() != 0 {
+		return commandUsage("audit")
+	}
+	checks, err := parseAuditChecks(*audit.checks)
+	if err != nil {
+		return err
+	}
+	if *audit.format != "" {
+		if err := configureOutput(*audit.format, "", plainOutput); err != nil {
+			return usagef("%w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	opts := &paperless.ListOptions{PageSize: 100}
+	if err := audit.filters.apply(ctx, client, forceRefresh, opts); err != nil {
+		return err
+	}
+
+	counts := newAuditCounts(checks)
+	prog := newProgress("Fetching documents", 0)
+	it := client.IterDocuments(opts, paperless.WithIDCursor())
+	for it.Next(ctx) {
+		prog.setTotal(it.Count())
+		doc := it.Document()
+		counts.add(&doc)
+		prog.add(1, 0)
+	}
+	prog.finish()
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	if err := writeOutput(counts.report()); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
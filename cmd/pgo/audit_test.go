@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+func TestAuditCounts(t *testing.T) {
+	one := 1
+	checks, err := parseAuditChecks("no-tags,No-Content")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := newAuditCounts(checks)
+	for _, doc := range []paperless.Document{
+		{ID: 1, Title: "complete", Tags: []int{1}, Correspondent: &one, DocumentType: &one, Content: "text"},
+		{ID: 2, Title: "untagged", Content: "text"},
+		{ID: 3, Title: "blank", Content: " \n"},
+	} {
+		counts.add(&doc)
+	}
+	r := counts.report()
+	if r.Documents != 3 {
+		t.Errorf("Documents = %d, want 3", r.Documents)
+	}
+	wantProblems := []AuditProblem{{"no-tags", 2}, {"no-content", 1}}
+	if !reflect.DeepEqual(r.Problems, wantProblems) {
+		t.Errorf("Problems = %+v, want %+v", r.Problems, wantProblems)
+	}
+	var got []string
+	for _, issue := range r.Results {
+		got = append(got, issue.Problem+":"+issue.Title)
+	}
+	if want := "no-tags:untagged,no-tags:blank,no-content:blank"; strings.Join(got, ",") != want {
+		t.Errorf("Results = %s, want %s", strings.Join(got, ","), want)
+	}
+
+	if _, err := parseAuditChecks("no-owner"); err == nil {
+		t.Error("expected error for unknown check")
+	}
+}
+
+func TestCLI_Audit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/documents/":
+			if r.URL.Query().Get("id__gt") != "" {
+				w.Write([]byte(`{"count": 3, "results": []}`))
+				return
+			}
+			w.Write([]byte(`{"count": 3, "results": [
+				{"id": 1, "title": "Invoice", "tags": [1], "correspondent": 1, "document_type": 1, "content": "text", "created": "2024-01-02"},
+				{"id": 2, "title": "Scan", "tags": [], "correspondent": null, "document_type": 1, "content": "", "created": "2024-02-03"},
+				{"id": 3, "title": "Letter", "tags": [2], "correspondent": null, "document_type": null, "content": "text", "created": "2024-03-04"}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory", "audit"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	stdout, stderr, err := run("--format", "csv")
+	if err != nil {
+		t.Fatalf("audit failed: %v\nStderr: %s", err, stderr)
+	}
+	want := "problem,id,title,created\n" +
+		"no-tags,2,Scan,2024-02-03\n" +
+		"no-correspondent,2,Scan,2024-02-03\n" +
+		"no-correspondent,3,Letter,2024-03-04\n" +
+		"no-doctype,3,Letter,2024-03-04\n" +
+		"no-content,2,Scan,2024-02-03\n"
+	if stdout != want {
+		t.Errorf("csv output =\n%s\nwant\n%s", stdout, want)
+	}
+
+	stdout, stderr, err = run("-check", "no-doctype")
+	if err != nil {
+		t.Fatalf("audit failed: %v\nStderr: %s", err, stderr)
+	}
+	var r AuditReport
+	if err := json.Unmarshal([]byte(stdout), &r); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, stdout)
+	}
+	if r.Documents != 3 || len(r.Problems) != 1 || r.Problems[0].Count != 1 || len(r.Results) != 1 || r.Results[0].ID != 3 {
+		t.Errorf("json output = %+v", r)
+	}
+
+	if _, stderr, err := run("-check", "no-owner"); err == nil || !strings.Contains(stderr, "unknown audit check: no-owner") {
+		t.Errorf("expected unknown check error, got %v, stderr: %s", err, stderr)
+	}
+}
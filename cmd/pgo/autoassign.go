@@ -0,0 +1,401 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// AutoassignChange is what the rules change on a document
+type AutoassignChange struct {
+	ID            int      `json:"id"`
+	Title         string   `json:"title"`
+	Rules         []string `json:"rules"` // Names of the matching rules
+	Correspondent string   `json:"correspondent,omitempty"`
+	DocumentType  string   `json:"document_type,omitempty"`
+	AddTags       []string `json:"add_tags,omitempty"`
+	Errors        []string `json:"errors,omitempty"`
+}
+
+// AutoassignOutput is the result of autoassign
+type AutoassignOutput struct {
+	DryRun    bool               `json:"dry_run"`
+	Rules     int                `json:"rules"`
+	Documents int                `json:"documents"` // Documents checked
+	Changed   int                `json:"changed"`
+	Failed    int                `json:"failed"`
+	Results   []AutoassignChange `json:"results"`
+}
+
+// autoassignRule assigns a correspondent, document type and tags to the
+// documents matching all of its patterns
+type autoassignRule struct {
+	name          string
+	title         *regexp.Regexp
+	content       *regexp.Regexp
+	filename      *regexp.Regexp
+	correspondent string
+	doctype       string
+	tags          []string
+
+	// Resolved IDs
+	correspondentID int
+	doctypeID       int
+	tagIDs          []int
+}
+
+// parseAutoassignRules parses a rules file, a list of rules:
+//
+//	# Telekom bills
+//	- name: Telekom invoices
+//	  title: '(?i)telekom'
+//	  content: 'Rechnungsnummer \d+'
+//	  correspondent: Deutsche Telekom
+//	  doctype: Invoice
+//	  tags: [bills, telecom]
+//
+// title, content and filename (the original file name) are regular
+// expressions, and a rule needs at least one. Single quotes keep
+// backslashes as they are.
+func parseAutoassignRules(data []byte) ([]*autoassignRule, error) {
+	value, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list of rules")
+	}
+
+	var rules []*autoassignRule
+	for i, item := range items {
+		obj, ok := item.(object)
+		if !ok {
+			return nil, fmt.Errorf("rule %d: expected a mapping", i+1)
+		}
+		rule := &autoassignRule{name: fmt.Sprintf("rule %d", i+1)}
+		if name, ok := obj.lookup("name").(string); ok && name != "" {
+			rule.name = name
+		}
+		for _, f := range obj {
+			var err error
+			switch f.key {
+			case "name":
+			case "title":
+				rule.title, err = compileRulePattern(f.value)
+			case "content":
+				rule.content, err = compileRulePattern(f.value)
+			case "filename":
+				rule.filename, err = compileRulePattern(f.value)
+			case "correspondent":
+				rule.correspondent, _ = f.value.(string)
+			case "doctype":
+				rule.doctype, _ = f.value.(string)
+			case "tags":
+				rule.tags, err = ruleTags(f.value)
+			default:
+				err = fmt.Errorf("unknown key %q", f.key)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", rule.name, f.key, err)
+			}
+		}
+		if rule.title == nil && rule.content == nil && rule.filename == nil {
+			return nil, fmt.Errorf("%s: needs a title, content or filename pattern", rule.name)
+		}
+		if rule.correspondent == "" && rule.doctype == "" && len(rule.tags) == 0 {
+			return nil, fmt.Errorf("%s: needs a correspondent, doctype or tags to assign", rule.name)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func compileRulePattern(value interface{}) (*regexp.Regexp, error) {
+	pattern, ok := value.(string)
+	if !ok || pattern == "" {
+		return nil, fmt.Errorf("expected a regular expression")
+	}
+	return regexp.Compile(pattern)
+}
+
+// ruleTags returns the tag names of a rule, a list or a single name
+func ruleTags(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		tags := make([]string, 0, len(v))
+		for _, item := range v {
+			tag, ok := item.(string)
+			if !ok || tag == "" {
+				return nil, fmt.Errorf("expected tag names")
+			}
+			tags = append(tags, tag)
+		}
+		return tags, nil
+	}
+	return nil, fmt.Errorf("expected a list of tag names")
+}
+
+// matches reports whether all patterns of the rule match doc
+func (r *autoassignRule) matches(doc paperless.Document) bool {
+	return (r.title == nil || r.title.MatchString(doc.Title)) &&
+		(r.content == nil || r.content.MatchString(doc.Content)) &&
+		(r.filename == nil || r.filename.MatchString(doc.OriginalFileName))
+}
+
+// autoassignPlan is what the rules assign to a document, as IDs. Zero means
+// nothing to assign.
+type autoassignPlan struct {
+	rules           []string
+	correspondentID int
+	doctypeID       int
+	tagIDs          []int
+}
+
+// planAutoassign applies the rules to doc. The first matching rule with a
+// correspondent or document type sets it, and the tags of all matching
+// rules are added. Without overwrite, a correspondent or document type the
+// document already has is kept. Tags the document has are left out.
+func planAutoassign(rules []*autoassignRule, doc paperless.Document, overwrite bool) autoassignPlan {
+	var plan autoassignPlan
+	for _, r := range rules {
+		if !r.matches(doc) {
+			continue
+		}
+		plan.rules = append(plan.rules, r.name)
+		if plan.correspondentID == 0 {
+			plan.correspondentID = r.correspondentID
+		}
+		if plan.doctypeID == 0 {
+			plan.doctypeID = r.doctypeID
+		}
+		for _, id := range r.tagIDs {
+			if !containsInt(doc.Tags, id) && !containsInt(plan.tagIDs, id) {
+				plan.tagIDs = append(plan.tagIDs, id)
+			}
+		}
+	}
+	if doc.Correspondent != nil && (!overwrite || *doc.Correspondent == plan.correspondentID) {
+		plan.correspondentID = 0
+	}
+	if doc.DocumentType != nil && (!overwrite || *doc.DocumentType == plan.doctypeID) {
+		plan.doctypeID = 0
+	}
+	return plan
+}
+
+func (p autoassignPlan) empty() bool {
+	return p.correspondentID == 0 && p.doctypeID == 0 && len(p.tagIDs) == 0
+}
+
+// resolveAutoassignRules resolves the names in the rules to IDs. Unknown
+// names fail before anything changes.
+func resolveAutoassignRules(ctx context.Context, client *paperless.Client, rules []*autoassignRule, forceRefresh bool) (correspondents, doctypes, tags map[int]string, err error) {
+	var tagRefs []string
+	needCorrespondents, needDoctypes := false, false
+	for _, r := range rules {
+		tagRefs = append(tagRefs, r.tags...)
+		needCorrespondents = needCorrespondents || r.correspondent != ""
+		needDoctypes = needDoctypes || r.doctype != ""
+	}
+
+	correspondents, doctypes, tags = map[int]string{}, map[int]string{}, map[int]string{}
+	if needCorrespondents {
+		if correspondents, err = getCorrespondentNamesWithCache(ctx, client, forceRefresh, DefaultCacheTTL); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to fetch correspondents: %w", err)
+		}
+	}
+	if needDoctypes {
+		list, err := listAll(ctx, func(ctx context.Context, opts *paperless.ListOptions) (*paperless.List[paperless.DocumentType], error) {
+			list, err := client.ListDocumentTypes(ctx, opts)
+			return (*paperless.List[paperless.DocumentType])(list), err
+		})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to fetch document types: %w", err)
+		}
+		for _, dt := range list {
+			doctypes[dt.ID] = dt.Name
+		}
+	}
+	if len(tagRefs) > 0 {
+		if _, tags, err = resolveTagNames(ctx, client, tagRefs, forceRefresh, false); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	for _, r := range rules {
+		if r.correspondent != "" {
+			if r.correspondentID, err = resolveNamedRef(r.correspondent, correspondents, "correspondent"); err != nil {
+				return nil, nil, nil, fmt.Errorf("%s: %w", r.name, err)
+			}
+		}
+		if r.doctype != "" {
+			if r.doctypeID, err = resolveNamedRef(r.doctype, doctypes, "document type"); err != nil {
+				return nil, nil, nil, fmt.Errorf("%s: %w", r.name, err)
+			}
+		}
+		if r.tagIDs, err = resolveNamedRefs(r.tags, tags, "tag"); err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: %w", r.name, err)
+		}
+	}
+	return correspondents, doctypes, tags, nil
+}
+
+// autoassignEdit is one bulk edit: a method and its parameters for the
+// documents it applies to
+type autoassignEdit struct {
+	method paperless.BulkEditMethod
+	params map[string]interface{}
+	label  string
+	ids    []int
+}
+
+// autoassignFlags are the flags of autoassign
+type autoassignFlags struct {
+	rules     *string
+	planOnly  *bool
+	overwrite *bool
+	batchSize *int
+	filters   *docFilters
+}
+
+func addAutoassignFlags(fs *flag.FlagSet) *autoassignFlags {
+	return &autoassignFlags{
+		rules:     fs.String("rules", "", "YAML file of rules matching title, content or filename patterns"),
+		planOnly:  fs.Bool("dry-run", false, "Show the changes without making them"),
+		overwrite: fs.Bool("overwrite", false, "Replace correspondents and document types documents already have"),
+		batchSize: fs.Int("batch-size", 100, "Documents per bulk edit request"),
+		filters:   addDocFilterFlags(fs),
+	}
+}
+
+// runAutoassign applies the rules of --rules to the documents matching the
+// filters, with one bulk edit per correspondent, document type and tag
+func runAutoassign(client *paperless.Client, args []string, forceRefresh bool, pool workerPool) error {
+	fs := flag.NewFlagSet("autoassign", flag.ContinueOnError)
+	flags := addAutoassignFlags(fs)
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return usagef("parse autoassign flags: %w", err)
+	}
+	if *flags.rules == "" || len(positional) != 0 || *flags.batchSize <= 0 {
+		return commandUsage("autoassign")
+	}
+
+	data, err := os.ReadFile(*flags.rules)
+	if err != nil {
+		return fmt.Errorf("failed to read rules: %w", err)
+	}
+	rules, err := parseAutoassignRules(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *flags.rules, err)
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("%s: no rules", *flags.rules)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	correspondents, doctypes, tags, err := resolveAutoassignRules(ctx, client, rules, forceRefresh)
+	if err != nil {
+		return err
+	}
+	opts := &paperless.ListOptions{PageSize: 100}
+	if err := flags.filters.apply(ctx, client, forceRefresh, opts); err != nil {
+		return err
+	}
+
+	output := AutoassignOutput{DryRun: *flags.planOnly, Rules: len(rules), Results: []AutoassignChange{}}
+	edits := map[string]*autoassignEdit{}
+	addEdit := func(key, label string, method paperless.BulkEditMethod, params map[string]interface{}, id int) {
+		if edits[key] == nil {
+			edits[key] = &autoassignEdit{method: method, params: params, label: label}
+		}
+		edits[key].ids = append(edits[key].ids, id)
+	}
+
+	prog := newProgress("Matching documents", 0)
+	it := client.IterDocuments(opts, paperless.WithIDCursor())
+	for it.Next(ctx) {
+		prog.setTotal(it.Count())
+		doc := it.Document()
+		output.Documents++
+		prog.add(1, 0)
+
+		plan := planAutoassign(rules, doc, *flags.overwrite)
+		if plan.empty() {
+			continue
+		}
+		change := AutoassignChange{ID: doc.ID, Title: doc.Title, Rules: plan.rules}
+		if id := plan.correspondentID; id != 0 {
+			change.Correspondent = correspondents[id]
+			addEdit(fmt.Sprintf("correspondent/%d", id), "correspondent "+correspondents[id],
+				paperless.BulkSetCorrespondent, map[string]interface{}{"correspondent": id}, doc.ID)
+		}
+		if id := plan.doctypeID; id != 0 {
+			change.DocumentType = doctypes[id]
+			addEdit(fmt.Sprintf("doctype/%d", id), "document type "+doctypes[id],
+				paperless.BulkSetDocumentType, map[string]interface{}{"document_type": id}, doc.ID)
+		}
+		for _, id := range plan.tagIDs {
+			change.AddTags = append(change.AddTags, tags[id])
+			addEdit(fmt.Sprintf("tag/%d", id), "tag "+tags[id],
+				paperless.BulkAddTag, map[string]interface{}{"tag": id}, doc.ID)
+		}
+		output.Results = append(output.Results, change)
+	}
+	prog.finish()
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("failed to list documents: %w", err)
+	}
+	output.Changed = len(output.Results)
+
+	if !*flags.planOnly {
+		// Edits run in a fixed order, so the requests are the same from
+		// run to run
+		keys := make([]string, 0, len(edits))
+		for key := range edits {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		failed := map[int][]string{}
+		for _, key := range keys {
+			edit := edits[key]
+			results := bulkApply(ctx, pool, edit.ids, *flags.batchSize, func(ctx context.Context, docIDs []int) error {
+				return client.BulkEditDocuments(ctx, docIDs, edit.method, edit.params)
+			})
+			for _, r := range results {
+				if !r.OK {
+					failed[r.ID] = append(failed[r.ID], fmt.Sprintf("%s: %s", edit.label, r.Error))
+				}
+			}
+		}
+		for i := range output.Results {
+			if errs := failed[output.Results[i].ID]; len(errs) > 0 {
+				output.Results[i].Errors = errs
+				output.Failed++
+			}
+		}
+	}
+
+	if err := writeOutput(output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	if output.Failed > 0 {
+		return fmt.Errorf("failed to update %d of %d documents", output.Failed, output.Changed)
+	}
+	return nil
+}
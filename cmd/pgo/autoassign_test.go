@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+func TestParseAutoassignRules(t *testing.T) {
+	rules, err := parseAutoassignRules([]byte(`
+- name: Telekom invoices
+  title: '(?i)telekom'
+  content: 'Rechnungsnummer \d+'
+  correspondent: Deutsche Telekom
+  doctype: Invoice
+  tags: [bills, telecom]
+- filename: '\.jpg$'
+  tags: photos
+`))
+	if err != nil {
+		t.Fatalf("parseAutoassignRules failed: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("got %d rules, want 2", len(rules))
+	}
+	r := rules[0]
+	if r.name != "Telekom invoices" || r.title.String() != "(?i)telekom" || r.content.String() != `Rechnungsnummer \d+` ||
+		r.filename != nil || r.correspondent != "Deutsche Telekom" || r.doctype != "Invoice" || strings.Join(r.tags, ",") != "bills,telecom" {
+		t.Errorf("rule 1 = %+v", r)
+	}
+	if r := rules[1]; r.name != "rule 2" || r.filename.String() != `\.jpg$` || strings.Join(r.tags, ",") != "photos" {
+		t.Errorf("rule 2 = %+v", r)
+	}
+
+	for _, bad := range []string{
+		"title: x",
+		"- title: x\n  color: red\n  tags: a",
+		"- title: '('\n  tags: a",
+		"- correspondent: Telekom",
+		"- title: x",
+	} {
+		if _, err := parseAutoassignRules([]byte(bad)); err == nil {
+			t.Errorf("parseAutoassignRules(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestPlanAutoassign(t *testing.T) {
+	rules, err := parseAutoassignRules([]byte(`
+- name: telekom
+  title: Telekom
+  correspondent: Telekom
+  tags: [bills]
+- name: invoices
+  content: Invoice
+  correspondent: Other
+  doctype: Invoice
+  tags: [bills, finance]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules[0].correspondentID, rules[0].tagIDs = 1, []int{10}
+	rules[1].correspondentID, rules[1].doctypeID, rules[1].tagIDs = 2, 5, []int{10, 11}
+
+	three := 3
+	doc := paperless.Document{ID: 1, Title: "Telekom 2024", Content: "Invoice", Tags: []int{11}}
+	plan := planAutoassign(rules, doc, false)
+	if strings.Join(plan.rules, ",") != "telekom,invoices" || plan.correspondentID != 1 || plan.doctypeID != 5 || len(plan.tagIDs) != 1 || plan.tagIDs[0] != 10 {
+		t.Errorf("plan = %+v", plan)
+	}
+
+	// Assigned correspondents are kept unless overwritten
+	doc.Correspondent = &three
+	if plan := planAutoassign(rules, doc, false); plan.correspondentID != 0 {
+		t.Errorf("correspondent changed without overwrite: %+v", plan)
+	}
+	if plan := planAutoassign(rules, doc, true); plan.correspondentID != 1 {
+		t.Errorf("correspondent not overwritten: %+v", plan)
+	}
+
+	if plan := planAutoassign(rules, paperless.Document{Title: "Letter"}, true); !plan.empty() || plan.rules != nil {
+		t.Errorf("plan for unmatched document = %+v", plan)
+	}
+}
+
+func TestCLI_Autoassign(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		bulkEdits []string
+	)
+	edits := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), bulkEdits...)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags/":
+			w.Write([]byte(`{"count": 2, "results": [{"id": 10, "name": "bills"}, {"id": 11, "name": "telecom"}]}`))
+		case "/api/correspondents/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 1, "name": "Deutsche Telekom"}]}`))
+		case "/api/document_types/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 5, "name": "Invoice"}]}`))
+		case "/api/documents/":
+			if r.URL.Query().Get("id__gt") != "" {
+				w.Write([]byte(`{"count": 3, "results": []}`))
+				return
+			}
+			w.Write([]byte(`{"count": 3, "results": [
+				{"id": 1, "title": "Telekom March", "content": "Rechnungsnummer 123", "tags": [10]},
+				{"id": 2, "title": "Telekom April", "content": "Rechnungsnummer 456", "correspondent": 1, "tags": [10, 11]},
+				{"id": 3, "title": "Shopping list", "content": "milk", "tags": []}]}`))
+		case "/api/documents/bulk_edit/":
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			bulkEdits = append(bulkEdits, string(body))
+			mu.Unlock()
+			w.Write([]byte(`{"result": "OK"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	rulesPath := filepath.Join(t.TempDir(), "rules.yaml")
+	rules := `- name: Telekom invoices
+  title: '(?i)telekom'
+  content: 'Rechnungsnummer \d+'
+  correspondent: Deutsche Telekom
+  doctype: Invoice
+  tags: [bills, telecom]
+`
+	if err := os.WriteFile(rulesPath, []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) AutoassignOutput {
+		cmd := exec.Command("./pgo", append([]string{"-memory", "autoassign", "--rules", rulesPath}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("autoassign failed: %v\nStderr: %s", err, stderr.String())
+		}
+		var out AutoassignOutput
+		if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+			t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, stdout.String())
+		}
+		return out
+	}
+
+	out := run("--dry-run")
+	if !out.DryRun || out.Documents != 3 || out.Changed != 2 || len(edits()) != 0 {
+		t.Fatalf("dry run = %+v, bulk edits %v", out, edits())
+	}
+	if c := out.Results[0]; c.ID != 1 || c.Correspondent != "Deutsche Telekom" || c.DocumentType != "Invoice" || strings.Join(c.AddTags, ",") != "telecom" {
+		t.Errorf("change 1 = %+v", c)
+	}
+	// Document 2 keeps its correspondent and has the tags
+	if c := out.Results[1]; c.ID != 2 || c.Correspondent != "" || c.DocumentType != "Invoice" || c.AddTags != nil {
+		t.Errorf("change 2 = %+v", c)
+	}
+
+	if out := run(); out.DryRun || out.Changed != 2 || out.Failed != 0 {
+		t.Errorf("output = %+v", out)
+	}
+	got := edits()
+	want := []string{
+		`"documents":[1],"method":"set_correspondent","parameters":{"correspondent":1}`,
+		`"documents":[1,2],"method":"set_document_type","parameters":{"document_type":5}`,
+		`"documents":[1],"method":"add_tag","parameters":{"tag":11}`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("bulk edits = %v", got)
+	}
+	for i, w := range want {
+		if !strings.Contains(got[i], w) {
+			t.Errorf("bulk edit %d = %s, want %s", i, got[i], w)
+		}
+	}
+}
@@ -0,0 +1,682 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// browseMode is what keys typed in the browser go to
+type browseMode int
+
+const (
+	browseList      browseMode = iota // navigation and actions
+	browseSearch                      // incremental search
+	browseTagFilter                   // tag filter prompt
+	browseAddTag                      // add tag prompt
+	browseRemoveTag                   // remove tag prompt
+)
+
+const browseHelp = "↑↓ move  / search  t filter tag  a/r add/remove tag  o open  d download  esc clear  q quit"
+
+// browser is the state of pgo browse. It is kept apart from the terminal so
+// it can be driven by tests.
+type browser struct {
+	client             *paperless.Client
+	docs               []paperless.Document // All loaded documents, newest first
+	tagNames           map[int]string
+	correspondentNames map[int]string
+
+	visible   []int  // Indexes into docs that match the filters
+	search    string // Incremental search words
+	tagFilter int    // Tag ID documents must have, 0 for none
+	cursor    int    // Index into visible
+	offset    int    // First visible row shown
+
+	mode   browseMode
+	input  string // Text typed at a prompt
+	status string // Message shown until the next key
+
+	downloadDir string
+	openURL     func(url string) error
+}
+
+func newBrowser(client *paperless.Client, docs []paperless.Document, tagNames, correspondentNames map[int]string) *browser {
+	b := &browser{
+		client:             client,
+		docs:               docs,
+		tagNames:           tagNames,
+		correspondentNames: correspondentNames,
+		downloadDir:        ".",
+		openURL:            openInBrowser,
+	}
+	b.filter()
+	return b
+}
+
+// filter recomputes the visible documents, keeping the selected document
+// selected if it still matches
+func (b *browser) filter() {
+	selected := -1
+	if b.cursor < len(b.visible) {
+		selected = b.visible[b.cursor]
+	}
+
+	words := strings.Fields(strings.ToLower(b.search))
+	b.visible = b.visible[:0]
+	for i := range b.docs {
+		if b.matches(&b.docs[i], words) {
+			b.visible = append(b.visible, i)
+		}
+	}
+
+	b.cursor, b.offset = 0, 0
+	for i, idx := range b.visible {
+		if idx == selected {
+			b.cursor = i
+		}
+	}
+}
+
+// matches reports whether doc has the filtered tag and contains all words
+// in its title, content, file name, correspondent or tag names
+func (b *browser) matches(doc *paperless.Document, words []string) bool {
+	if b.tagFilter != 0 && !containsInt(doc.Tags, b.tagFilter) {
+		return false
+	}
+	if len(words) == 0 {
+		return true
+	}
+	text := []string{doc.Title, doc.OriginalFileName, doc.Content}
+	if doc.Correspondent != nil {
+		text = append(text, b.correspondentNames[*doc.Correspondent])
+	}
+	for _, id := range doc.Tags {
+		text = append(text, b.tagNames[id])
+	}
+	haystack := strings.ToLower(strings.Join(text, "\n"))
+	for _, word := range words {
+		if !strings.Contains(haystack, word) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsInt(ids []int, id int) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// selected returns the selected document, or nil if none match
+func (b *browser) selected() *paperless.Document {
+	if b.cursor >= len(b.visible) {
+		return nil
+	}
+	return &b.docs[b.visible[b.cursor]]
+}
+
+// findTag resolves a tag name: an exact case-insensitive match, or else the
+// only tag starting with name
+func (b *browser) findTag(name string) (int, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	var prefixed []int
+	for id, tagName := range b.tagNames {
+		lower := strings.ToLower(tagName)
+		if lower == name {
+			return id, nil
+		}
+		if strings.HasPrefix(lower, name) {
+			prefixed = append(prefixed, id)
+		}
+	}
+	switch len(prefixed) {
+	case 0:
+		return 0, fmt.Errorf("no tag named %q", name)
+	case 1:
+		return prefixed[0], nil
+	}
+	return 0, fmt.Errorf("%q matches %d tags", name, len(prefixed))
+}
+
+// handleKey applies a key and reports whether the browser should exit
+func (b *browser) handleKey(ctx context.Context, key string) bool {
+	b.status = ""
+	if key == "ctrl-c" {
+		return true
+	}
+	if b.mode != browseList {
+		b.handlePromptKey(ctx, key)
+		return false
+	}
+
+	switch key {
+	case "q":
+		return true
+	case "up", "k":
+		b.move(-1)
+	case "down", "j":
+		b.move(1)
+	case "pgup":
+		b.move(-10)
+	case "pgdown":
+		b.move(10)
+	case "home", "g":
+		b.move(-len(b.visible))
+	case "end", "G":
+		b.move(len(b.visible))
+	case "/":
+		b.mode, b.input = browseSearch, b.search
+	case "t":
+		b.mode, b.input = browseTagFilter, b.tagNames[b.tagFilter]
+	case "a", "r":
+		if b.selected() == nil {
+			b.status = "No document selected"
+			break
+		}
+		b.mode, b.input = browseAddTag, ""
+		if key == "r" {
+			b.mode = browseRemoveTag
+		}
+	case "o":
+		if doc := b.selected(); doc != nil {
+			if err := b.openURL(b.client.DocumentURLs(doc.ID).Web); err != nil {
+				b.status = fmt.Sprintf("Error: %v", err)
+			}
+		}
+	case "d":
+		if doc := b.selected(); doc != nil {
+			b.download(ctx, doc)
+		}
+	case "esc":
+		b.search, b.tagFilter = "", 0
+		b.filter()
+	}
+	return false
+}
+
+// handlePromptKey edits the prompt input. The search is applied as it is
+// typed; the other prompts on enter.
+func (b *browser) handlePromptKey(ctx context.Context, key string) {
+	switch key {
+	case "esc":
+		if b.mode == browseSearch {
+			b.search = ""
+			b.filter()
+		}
+		b.mode = browseList
+		return
+	case "enter":
+		mode := b.mode
+		b.mode = browseList
+		switch mode {
+		case browseTagFilter:
+			b.applyTagFilter(b.input)
+		case browseAddTag, browseRemoveTag:
+			b.editTag(ctx, b.input, mode == browseAddTag)
+		}
+		return
+	case "backspace":
+		if r := []rune(b.input); len(r) > 0 {
+			b.input = string(r[:len(r)-1])
+		}
+	default:
+		if len([]rune(key)) != 1 {
+			return // ignore navigation keys at a prompt
+		}
+		b.input += key
+	}
+
+	if b.mode == browseSearch {
+		b.search = b.input
+		b.filter()
+	}
+}
+
+func (b *browser) applyTagFilter(name string) {
+	if strings.TrimSpace(name) == "" {
+		b.tagFilter = 0
+		b.filter()
+		return
+	}
+	id, err := b.findTag(name)
+	if err != nil {
+		b.status = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	b.tagFilter = id
+	b.filter()
+}
+
+// editTag adds a tag to or removes it from the selected document
+func (b *browser) editTag(ctx context.Context, name string, add bool) {
+	doc := b.selected()
+	if doc == nil || strings.TrimSpace(name) == "" {
+		return
+	}
+	id, err := b.findTag(name)
+	if err != nil {
+		b.status = fmt.Sprintf("Error: %v", err)
+		return
+	}
+
+	tags := []int{}
+	for _, t := range doc.Tags {
+		if t != id {
+			tags = append(tags, t)
+		}
+	}
+	if add {
+		tags = append(tags, id)
+	}
+	if len(tags) == len(doc.Tags) && containsInt(doc.Tags, id) == add {
+		return // nothing to change
+	}
+
+	updated, err := b.client.UpdateDocument(ctx, doc.ID, &paperless.DocumentUpdate{Tags: &tags})
+	if err != nil {
+		b.status = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	*doc = *updated
+	verb := "Removed"
+	if add {
+		verb = "Added"
+	}
+	b.status = fmt.Sprintf("%s tag %s on #%d", verb, b.tagNames[id], doc.ID)
+	b.filter()
+}
+
+// download saves the original file of doc in the download directory
+func (b *browser) download(ctx context.Context, doc *paperless.Document) {
+	f, err := b.client.DownloadDocument(ctx, doc.ID, true)
+	if err != nil {
+		b.status = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	name := filepath.Base(f.Name)
+	if f.Name == "" || name == "." || name == string(filepath.Separator) {
+		name = fmt.Sprintf("document-%d", doc.ID)
+	}
+	path := filepath.Join(b.downloadDir, name)
+
+	// Never overwrite an existing file
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		b.status = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	_, err = out.Write(f.Data)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		b.status = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	b.status = "Saved " + path
+}
+
+func (b *browser) move(delta int) {
+	b.cursor += delta
+	if b.cursor >= len(b.visible) {
+		b.cursor = len(b.visible) - 1
+	}
+	if b.cursor < 0 {
+		b.cursor = 0
+	}
+}
+
+// render draws the screen: a header, the document list, a preview of the
+// selected document and a status line
+func (b *browser) render(width, height int) string {
+	if height < 8 {
+		height = 8
+	}
+	listHeight := (height - 3) / 2
+	previewHeight := height - 3 - listHeight
+
+	// Keep the cursor in view
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+listHeight {
+		b.offset = b.cursor - listHeight + 1
+	}
+
+	var lines []string
+	header := fmt.Sprintf(" pgo browse  %d/%d documents", len(b.visible), len(b.docs))
+	if b.search != "" {
+		header += fmt.Sprintf("  search: %s", b.search)
+	}
+	if b.tagFilter != 0 {
+		header += fmt.Sprintf("  tag: %s", b.tagNames[b.tagFilter])
+	}
+	lines = append(lines, "\x1b[7m"+pad(header, width)+"\x1b[0m")
+
+	for row := 0; row < listHeight; row++ {
+		i := b.offset + row
+		if i >= len(b.visible) {
+			lines = append(lines, "")
+			continue
+		}
+		doc := &b.docs[b.visible[i]]
+		line := fmt.Sprintf(" %6d  %s  %s", doc.ID, doc.Created.Time().Format("2006-01-02"), doc.Title)
+		if names := b.docTagNames(doc); len(names) > 0 {
+			line += "  [" + strings.Join(names, ", ") + "]"
+		}
+		if i == b.cursor {
+			line = "\x1b[7m" + pad(line, width) + "\x1b[0m"
+		} else {
+			line = truncate(line, width)
+		}
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, strings.Repeat("─", width))
+	preview := b.previewLines(width)
+	for row := 0; row < previewHeight; row++ {
+		if row < len(preview) {
+			lines = append(lines, preview[row])
+		} else {
+			lines = append(lines, "")
+		}
+	}
+
+	footer := browseHelp
+	switch {
+	case b.mode == browseSearch:
+		footer = "/" + b.input
+	case b.mode == browseTagFilter:
+		footer = "Filter by tag (empty for all): " + b.input
+	case b.mode == browseAddTag:
+		footer = "Add tag: " + b.input
+	case b.mode == browseRemoveTag:
+		footer = "Remove tag: " + b.input
+	case b.status != "":
+		footer = b.status
+	}
+	lines = append(lines, truncate(footer, width))
+
+	// Raw mode turns off output processing, so lines end in \r\n
+	return strings.Join(lines, "\r\n")
+}
+
+// previewLines describes the selected document
+func (b *browser) previewLines(width int) []string {
+	doc := b.selected()
+	if doc == nil {
+		return []string{" No matching documents"}
+	}
+
+	lines := []string{truncate(fmt.Sprintf(" #%d  %s", doc.ID, doc.Title), width)}
+	if doc.Correspondent != nil {
+		lines = append(lines, truncate(" From  "+nameOrUnknown(b.correspondentNames, *doc.Correspondent), width))
+	}
+	if names := b.docTagNames(doc); len(names) > 0 {
+		lines = append(lines, truncate(" Tags  "+strings.Join(names, ", "), width))
+	}
+	if doc.OriginalFileName != "" {
+		lines = append(lines, truncate(" File  "+doc.OriginalFileName, width))
+	}
+	if text := contentExcerpt(doc.Content, 2000); text != "" {
+		lines = append(lines, "")
+		for _, line := range wrapText(text, width-2) {
+			lines = append(lines, " "+line)
+		}
+	}
+	return lines
+}
+
+func (b *browser) docTagNames(doc *paperless.Document) []string {
+	names := make([]string, 0, len(doc.Tags))
+	for _, id := range doc.Tags {
+		if name, ok := b.tagNames[id]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, fmt.Sprintf("unknown(%d)", id))
+		}
+	}
+	return names
+}
+
+// truncate cuts s to width characters, dropping control characters that
+// would corrupt the screen
+func truncate(s string, width int) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		if r == '\x1b' || (unicode.IsControl(r) && r != '\t') {
+			continue
+		}
+		if r == '\t' {
+			r = ' '
+		}
+		if n >= width {
+			break
+		}
+		b.WriteRune(r)
+		n++
+	}
+	return b.String()
+}
+
+// pad truncates s and fills it with spaces to width characters
+func pad(s string, width int) string {
+	s = truncate(s, width)
+	if n := len([]rune(s)); n < width {
+		s += strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// readKey reads a key press from a terminal in raw mode. Special keys are
+// returned by name (up, enter, ctrl-c, ...), others as the character.
+func readKey(r *bufio.Reader) (string, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	switch c {
+	case 3:
+		return "ctrl-c", nil
+	case '\r', '\n':
+		return "enter", nil
+	case 127, 8:
+		return "backspace", nil
+	case '\x1b':
+		// A lone escape has nothing buffered after it
+		if r.Buffered() == 0 {
+			return "esc", nil
+		}
+		next, _ := r.ReadByte()
+		if next != '[' && next != 'O' {
+			return "esc", nil
+		}
+		seq := ""
+		for r.Buffered() > 0 {
+			b, _ := r.ReadByte()
+			seq += string(b)
+			if b >= '@' && b <= '~' {
+				break
+			}
+		}
+		switch seq {
+		case "A":
+			return "up", nil
+		case "B":
+			return "down", nil
+		case "H", "1~", "7~":
+			return "home", nil
+		case "F", "4~", "8~":
+			return "end", nil
+		case "5~":
+			return "pgup", nil
+		case "6~":
+			return "pgdown", nil
+		}
+		return "", nil
+	}
+	return string(c), nil
+}
+
+// openInBrowser opens url with the desktop's default handler
+func openInBrowser(url string) error {
+	name := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		name = "open"
+	}
+	cmd := exec.Command(name, url)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// stty runs stty on the terminal tty
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("stty %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// terminalSize returns the width and height of tty, or 80x24 if unknown
+func terminalSize(tty *os.File) (int, int) {
+	out, err := stty(tty, "size")
+	if err == nil {
+		if rows, cols, ok := strings.Cut(out, " "); ok {
+			h, errH := strconv.Atoi(rows)
+			w, errW := strconv.Atoi(cols)
+			if errH == nil && errW == nil && w > 0 && h > 0 {
+				return w, h
+			}
+		}
+	}
+	return 80, 24
+}
+
+// browseFlags are the flags of browse
+type browseFlags struct {
+	limit *int
+	query *string
+}
+
+func addBrowseFlags(fs *flag.FlagSet) *browseFlags {
+	return &browseFlags{
+		limit: fs.Int("limit", 1000, "Maximum number of documents to load, newest first"),
+		query: fs.String("query", "", "Only load documents matching this full-text query"),
+	}
+}
+
+func runBrowse(client *paperless.Client, args []string, forceRefresh bool) error {
+	fs := flag.NewFlagSet("browse", flag.ContinueOnError)
+	flags := addBrowseFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return usagef("parse browse flags: %w", err)
+	}
+	limit, query := flags.limit, flags.query
+	if fs.NArg() != 0 || *limit <= 0 {
+		return commandUsage("browse")
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("browse needs a terminal: %w", err)
+	}
+	defer tty.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	fmt.Fprintln(os.Stderr, "Loading documents...")
+	docs, err := loadBrowseDocuments(ctx, client, *limit, *query)
+	if err != nil {
+		return err
+	}
+	tagNames, err := getTagNamesWithCache(ctx, client, forceRefresh, DefaultCacheTTL)
+	if err != nil {
+		slog.Warn("Could not fetch tags for name resolution", "error", err)
+		tagNames = make(map[int]string)
+	}
+	correspondentNames, err := getCorrespondentNamesWithCache(ctx, client, forceRefresh, DefaultCacheTTL)
+	if err != nil {
+		slog.Warn("Could not fetch correspondents for name resolution", "error", err)
+		correspondentNames = make(map[int]string)
+	}
+	b := newBrowser(client, docs, tagNames, correspondentNames)
+
+	saved, err := stty(tty, "-g")
+	if err != nil {
+		return err
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		return err
+	}
+	defer stty(tty, saved)
+
+	// Use the alternate screen so the shell's scrollback is left alone
+	io.WriteString(tty, "\x1b[?1049h\x1b[?25l")
+	defer io.WriteString(tty, "\x1b[?25h\x1b[?1049l")
+
+	keys := bufio.NewReader(tty)
+	for {
+		width, height := terminalSize(tty)
+		if _, err := io.WriteString(tty, "\x1b[H\x1b[2J"+b.render(width, height)); err != nil {
+			return err
+		}
+		key, err := readKey(keys)
+		if err != nil {
+			return err
+		}
+
+		actionCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		quit := b.handleKey(actionCtx, key)
+		cancel()
+		if quit {
+			return nil
+		}
+	}
+}
+
+// loadBrowseDocuments fetches up to limit documents, newest first
+func loadBrowseDocuments(ctx context.Context, client *paperless.Client, limit int, query string) ([]paperless.Document, error) {
+	var docs []paperless.Document
+	opts := &paperless.ListOptions{Page: 1, PageSize: 100, Ordering: "-created", Query: query}
+	for len(docs) < limit {
+		list, err := client.ListDocuments(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list documents: %w", err)
+		}
+		docs = append(docs, list.Results...)
+		if list.Next == nil || *list.Next == "" {
+			break
+		}
+		opts.Page++
+	}
+	if len(docs) > limit {
+		docs = docs[:limit]
+	}
+	return docs, nil
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+func newTestBrowser(t *testing.T, handler http.HandlerFunc) *browser {
+	t.Helper()
+	if handler == nil {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	amazon := 5
+	docs := []paperless.Document{
+		{ID: 3, Title: "Electricity bill", Content: "Stadtwerke March invoice", Tags: []int{1}},
+		{ID: 2, Title: "Amazon order", Content: "Headphones", Tags: []int{1, 2}, OriginalFileName: "order.pdf", Correspondent: &amazon},
+		{ID: 1, Title: "Lease", Content: "Apartment rental agreement"},
+	}
+	b := newBrowser(paperless.NewClient(server.URL, "test-token"), docs,
+		map[int]string{1: "invoice", 2: "shopping", 3: "insurance"}, map[int]string{5: "Amazon EU"})
+	b.downloadDir = t.TempDir()
+	return b
+}
+
+func visibleIDs(b *browser) []int {
+	ids := []int{}
+	for _, i := range b.visible {
+		ids = append(ids, b.docs[i].ID)
+	}
+	return ids
+}
+
+func pressKeys(b *browser, keys ...string) {
+	for _, key := range keys {
+		b.handleKey(context.Background(), key)
+	}
+}
+
+func TestBrowser_Navigation(t *testing.T) {
+	b := newTestBrowser(t, nil)
+
+	pressKeys(b, "down", "j")
+	if got := b.selected().ID; got != 1 {
+		t.Errorf("selected %d after two downs, want 1", got)
+	}
+	pressKeys(b, "down")
+	if got := b.selected().ID; got != 1 {
+		t.Errorf("cursor moved past the end: selected %d", got)
+	}
+	pressKeys(b, "g")
+	if got := b.selected().ID; got != 3 {
+		t.Errorf("selected %d after home, want 3", got)
+	}
+	if !b.handleKey(context.Background(), "q") {
+		t.Error("q did not quit")
+	}
+}
+
+func TestBrowser_IncrementalSearch(t *testing.T) {
+	b := newTestBrowser(t, nil)
+
+	pressKeys(b, "/", "i", "n", "v")
+	if got := visibleIDs(b); !reflect.DeepEqual(got, []int{3, 2}) {
+		t.Errorf("visible after 'inv' = %v, want [3 2] (content and tag matches)", got)
+	}
+	pressKeys(b, "o", "i", "c", "e", " ", "m", "a", "r")
+	if got := visibleIDs(b); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("visible after 'invoice mar' = %v, want [3]", got)
+	}
+	pressKeys(b, "enter")
+	if b.mode != browseList || b.search != "invoice mar" {
+		t.Errorf("enter should keep the search: mode %d, search %q", b.mode, b.search)
+	}
+
+	pressKeys(b, "/", "backspace", "backspace", "backspace", "backspace", "esc")
+	if b.search != "" || len(b.visible) != 3 {
+		t.Errorf("esc should clear the search: search %q, visible %v", b.search, visibleIDs(b))
+	}
+}
+
+func TestBrowser_TagFilter(t *testing.T) {
+	b := newTestBrowser(t, nil)
+
+	pressKeys(b, "t", "S", "h", "o", "enter")
+	if got := visibleIDs(b); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("visible with tag filter 'Sho' = %v, want [2]", got)
+	}
+
+	pressKeys(b, "t", "backspace", "backspace", "backspace", "backspace", "backspace", "backspace", "backspace", "backspace", "i", "n", "enter")
+	if b.status == "" || b.tagFilter != 2 {
+		t.Errorf("ambiguous tag should keep the filter and report an error: status %q, filter %d", b.status, b.tagFilter)
+	}
+
+	pressKeys(b, "esc")
+	if b.tagFilter != 0 || len(b.visible) != 3 {
+		t.Errorf("esc should clear the tag filter: filter %d, visible %v", b.tagFilter, visibleIDs(b))
+	}
+}
+
+func TestBrowser_AddAndRemoveTag(t *testing.T) {
+	var patched []int
+	b := newTestBrowser(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" || r.URL.Path != "/api/documents/3/" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		var update struct{ Tags []int }
+		json.NewDecoder(r.Body).Decode(&update)
+		patched = update.Tags
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(paperless.Document{ID: 3, Title: "Electricity bill", Tags: update.Tags})
+	})
+
+	pressKeys(b, "a", "i", "n", "s", "enter")
+	if !reflect.DeepEqual(patched, []int{1, 3}) {
+		t.Errorf("tags sent = %v, want [1 3]", patched)
+	}
+	if got := b.selected().Tags; !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Errorf("document tags = %v, want [1 3]", got)
+	}
+	if b.status != "Added tag insurance on #3" {
+		t.Errorf("status = %q", b.status)
+	}
+
+	pressKeys(b, "r", "i", "n", "v", "o", "enter")
+	if !reflect.DeepEqual(patched, []int{3}) {
+		t.Errorf("tags sent = %v, want [3]", patched)
+	}
+
+	// Removing a tag the document does not have makes no request
+	patched = nil
+	pressKeys(b, "r", "s", "h", "enter")
+	if patched != nil {
+		t.Errorf("unexpected update with tags %v", patched)
+	}
+}
+
+func TestBrowser_OpenAndDownload(t *testing.T) {
+	b := newTestBrowser(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/documents/3/download/" || r.URL.Query().Get("original") != "true" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `attachment; filename="../bill.pdf"`)
+		w.Write([]byte("%PDF-1.7"))
+	})
+	var opened string
+	b.openURL = func(url string) error {
+		opened = url
+		return nil
+	}
+
+	pressKeys(b, "o")
+	if !strings.HasSuffix(opened, "/documents/3/details") {
+		t.Errorf("opened %q, want the document details page", opened)
+	}
+
+	pressKeys(b, "d")
+	path := filepath.Join(b.downloadDir, "bill.pdf")
+	if data, err := os.ReadFile(path); err != nil || string(data) != "%PDF-1.7" {
+		t.Errorf("downloaded file = %q, %v", data, err)
+	}
+	if b.status != "Saved "+path {
+		t.Errorf("status = %q", b.status)
+	}
+
+	pressKeys(b, "d")
+	if !strings.HasPrefix(b.status, "Error:") {
+		t.Errorf("second download should not overwrite the file, status %q", b.status)
+	}
+}
+
+func TestBrowser_Render(t *testing.T) {
+	b := newTestBrowser(t, nil)
+	pressKeys(b, "down")
+
+	lines := strings.Split(b.render(60, 12), "\r\n")
+	if len(lines) != 12 {
+		t.Fatalf("render returned %d lines, want 12", len(lines))
+	}
+	if !strings.Contains(lines[0], "3/3 documents") {
+		t.Errorf("header = %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[2], "\x1b[7m") || !strings.Contains(lines[2], "Amazon order  [invoice, shopping]") {
+		t.Errorf("selected row = %q", lines[2])
+	}
+	for _, line := range lines[1:] {
+		if n := len([]rune(strings.NewReplacer("\x1b[7m", "", "\x1b[0m", "").Replace(line))); n > 60 {
+			t.Errorf("line wider than the screen (%d): %q", n, line)
+		}
+	}
+	if screen := b.render(60, 12); !strings.Contains(screen, "File  order.pdf") || !strings.Contains(screen, "From  Amazon EU") {
+		t.Error("preview does not show the selected document")
+	}
+
+	pressKeys(b, "/", "x", "y", "z")
+	screen := b.render(60, 12)
+	if !strings.Contains(screen, "No matching documents") || !strings.HasSuffix(screen, "/xyz") {
+		t.Errorf("unexpected screen for empty search:\n%s", screen)
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	input := "a\x1b[A\x1b[B\x1b[5~\x1bOH\r\x7f\x03é"
+	r := bufio.NewReader(strings.NewReader(input))
+	want := []string{"a", "up", "down", "pgup", "home", "enter", "backspace", "ctrl-c", "é"}
+	for _, w := range want {
+		got, err := readKey(r)
+		if err != nil || got != w {
+			t.Fatalf("readKey = %q, %v; want %q", got, err, w)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("ab\x1b[31mcdé\tf", 6); got != "ab[31m" {
+		t.Errorf("truncate = %q", got)
+	}
+	if got := pad("äb", 4); got != "äb  " {
+		t.Errorf("pad = %q", got)
+	}
+}
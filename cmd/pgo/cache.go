@@ -1,27 +1,185 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"os"
-	"path/filepath"
 	"time"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo/internal/cache"
 )
 
 // DefaultCacheTTL is the default time-to-live for cached data (12 hours)
 const DefaultCacheTTL = 12 * time.Hour
 
-// getCacheDir returns the cache directory path, preferring XDG_CACHE_HOME
-func getCacheDir() (string, error) {
-	// Try XDG_CACHE_HOME first
-	if cacheHome := os.Getenv("XDG_CACHE_HOME"); cacheHome != "" {
-		return filepath.Join(cacheHome, "paperless-go"), nil
+// caches is the cache store of the Paperless instance in use, opened by run
+// once the instance is known. Each instance gets its own cache directory so
+// tag and document names of different instances are not mixed.
+var caches = cache.Open("", false)
+
+// nameCache is a cache of object names by ID
+type nameCache = cache.Cache[map[int]string]
+
+// cacheFile names one of the name caches for the cache command
+type cacheFile struct {
+	name  string
+	cache func() *nameCache
+}
+
+var cacheFiles = []cacheFile{
+	{"tags", tagCache},
+	{"docs", docCache},
+	{"correspondents", correspondentCache},
+}
+
+// CacheStatus describes one cache file
+type CacheStatus struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Exists    bool   `json:"exists"`
+	Entries   int    `json:"entries"`
+	FetchedAt string `json:"fetched_at,omitempty"`
+	Age       string `json:"age,omitempty"`
+	Stale     bool   `json:"stale"`
+}
+
+// CacheStatusOutput is the output of cache status
+type CacheStatusOutput struct {
+	Dir    string        `json:"dir"`
+	TTL    string        `json:"ttl"`
+	Caches []CacheStatus `json:"caches"`
+}
+
+// CacheClearOutput is the output of cache clear
+type CacheClearOutput struct {
+	Removed []string `json:"removed"`
+}
+
+// cacheFlags select the caches of cache status, clear and path
+type cacheFlags struct {
+	tags           *bool
+	docs           *bool
+	correspondents *bool
+}
+
+func addCacheFlags(fs *flag.FlagSet) *cacheFlags {
+	return &cacheFlags{
+		tags:           fs.Bool("tags", false, "Only the tag cache"),
+		docs:           fs.Bool("docs", false, "Only the doc cache"),
+		correspondents: fs.Bool("correspondents", false, "Only the correspondent cache"),
 	}
+}
 
-	// Fall back to ~/.cache
-	home, err := os.UserHomeDir()
+// runCache runs the cache subcommands. Only warm talks to Paperless; the
+// others work without a URL or token.
+func runCache(conn settings, args []string) error {
+	if len(args) == 0 {
+		return commandUsage("cache")
+	}
+	if args[0] == "warm" {
+		if conn.URL == "" {
+			return errNoURL
+		}
+		if conn.Token == "" {
+			return errNoToken
+		}
+		return runCacheWarm(newClient(conn), args[1:])
+	}
+
+	fs := flag.NewFlagSet("cache "+args[0], flag.ContinueOnError)
+	flags := addCacheFlags(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return usagef("parse cache flags: %w", err)
+	}
+	tags, docs, correspondents := flags.tags, flags.docs, flags.correspondents
+	if fs.NArg() != 0 {
+		return commandUsage("cache")
+	}
+	if caches.Memory() {
+		return fmt.Errorf("cache %s works on the disk caches and cannot be used with -memory", args[0])
+	}
+
+	all := !*tags && !*docs && !*correspondents
+	selected := map[string]bool{"tags": all || *tags, "docs": all || *docs, "correspondents": all || *correspondents}
+	var files []cacheFile
+	for _, f := range cacheFiles {
+		if selected[f.name] {
+			files = append(files, f)
+		}
+	}
+
+	switch args[0] {
+	case "path":
+		// Without a selection, print the directory of the instance
+		if all {
+			dir, err := caches.Dir()
+			if err != nil {
+				return fmt.Errorf("failed to get cache directory: %w", err)
+			}
+			fmt.Println(dir)
+			return nil
+		}
+		for _, f := range files {
+			path, err := f.cache().Path()
+			if err != nil {
+				return fmt.Errorf("failed to get %s cache path: %w", f.name, err)
+			}
+			fmt.Println(path)
+		}
+		return nil
+	case "status":
+		return cacheStatus(files)
+	case "clear":
+		return cacheClear(files)
+	default:
+		return commandUsage("cache")
+	}
+}
+
+func cacheStatus(files []cacheFile) error {
+	dir, err := caches.Dir()
 	if err != nil {
-		return "", fmt.Errorf("get home directory: %w", err)
+		return fmt.Errorf("failed to get cache directory: %w", err)
+	}
+	output := CacheStatusOutput{Dir: dir, TTL: DefaultCacheTTL.String(), Caches: []CacheStatus{}}
+	for _, f := range files {
+		path, err := f.cache().Path()
+		if err != nil {
+			return fmt.Errorf("failed to get %s cache path: %w", f.name, err)
+		}
+		status := CacheStatus{Name: f.name, Path: path, Stale: true}
+		entry, err := f.cache().Load()
+		if err != nil {
+			return fmt.Errorf("failed to read %s cache: %w", f.name, err)
+		}
+		if entry != nil {
+			status.Exists = true
+			status.Entries = len(entry.Data)
+			status.FetchedAt = entry.FetchedAt.Format(time.RFC3339)
+			status.Age = time.Since(entry.FetchedAt).Truncate(time.Second).String()
+			status.Stale = entry.Stale(DefaultCacheTTL)
+		}
+		output.Caches = append(output.Caches, status)
 	}
+	if err := writeOutput(output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
 
-	return filepath.Join(home, ".cache", "paperless-go"), nil
+// cacheClear removes the cache files, their locks and temporary files left
+// by interrupted writes. Only the known file names are removed, never the
+// directory, so a misconfigured XDG_CACHE_HOME cannot lose other files.
+func cacheClear(files []cacheFile) error {
+	output := CacheClearOutput{Removed: []string{}}
+	for _, f := range files {
+		removed, err := f.cache().Remove()
+		output.Removed = append(output.Removed, removed...)
+		if err != nil {
+			return err
+		}
+	}
+	if err := writeOutput(output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
 }
@@ -1,67 +1,90 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo/internal/cache"
 )
 
-func TestGetCacheDir_Shared(t *testing.T) {
-	t.Run("uses XDG_CACHE_HOME when set", func(t *testing.T) {
-		// Save original env
-		orig := os.Getenv("XDG_CACHE_HOME")
-		defer func() {
-			if orig != "" {
-				_ = os.Setenv("XDG_CACHE_HOME", orig)
-			} else {
-				_ = os.Unsetenv("XDG_CACHE_HOME")
-			}
-		}()
+func TestDefaultCacheTTL_Shared(t *testing.T) {
+	// Verify default TTL is 12 hours
+	if DefaultCacheTTL != 12*time.Hour {
+		t.Errorf("DefaultCacheTTL = %v, want %v", DefaultCacheTTL, 12*time.Hour)
+	}
+}
 
-		testPath := "/tmp/test-cache-shared"
-		_ = os.Setenv("XDG_CACHE_HOME", testPath)
+func TestCLI_Cache(t *testing.T) {
+	cacheHome := t.TempDir()
+	run := func(url string, args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", args...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+url, "PAPERLESS_TOKEN=", "XDG_CACHE_HOME="+cacheHome)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return strings.TrimSpace(stdout.String()), stderr.String(), err
+	}
 
-		cacheDir, err := getCacheDir()
-		if err != nil {
-			t.Fatalf("getCacheDir failed: %v", err)
-		}
+	home := filepath.Join(cacheHome, "paperless-go", "instances", cache.Namespace("https://home.example"))
+	out, stderr, err := run("https://home.example", "cache", "path")
+	if err != nil || out != home {
+		t.Errorf("cache path = %q, %v, stderr: %s", out, err, stderr)
+	}
+	out, _, _ = run("https://home.example", "cache", "path", "--tags", "--docs")
+	if out != filepath.Join(home, "tags.json")+"\n"+filepath.Join(home, "docs.json") {
+		t.Errorf("cache path --tags --docs = %q", out)
+	}
 
-		expected := filepath.Join(testPath, "paperless-go")
-		if cacheDir != expected {
-			t.Errorf("cacheDir = %v, want %v", cacheDir, expected)
+	// Caches of another instance are left alone
+	work := filepath.Join(cacheHome, "paperless-go", "instances", cache.Namespace("https://work.example"))
+	fetched := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	for dir, tags := range map[string]string{home: `{"1": "tax"}`, work: `{"1": "payroll", "2": "hr"}`} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
 		}
-	})
-
-	t.Run("falls back to ~/.cache when XDG_CACHE_HOME not set", func(t *testing.T) {
-		// Save original env
-		orig := os.Getenv("XDG_CACHE_HOME")
-		defer func() {
-			if orig != "" {
-				_ = os.Setenv("XDG_CACHE_HOME", orig)
-			} else {
-				_ = os.Unsetenv("XDG_CACHE_HOME")
-			}
-		}()
-
-		_ = os.Unsetenv("XDG_CACHE_HOME")
-
-		cacheDir, err := getCacheDir()
-		if err != nil {
-			t.Fatalf("getCacheDir failed: %v", err)
+		data := `{"tags": ` + tags + `, "fetched_at": "` + fetched + `"}`
+		if err := os.WriteFile(filepath.Join(dir, "tags.json"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
 		}
+	}
+	if err := os.WriteFile(filepath.Join(home, ".tags.json.123"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
 
-		home, _ := os.UserHomeDir()
-		expected := filepath.Join(home, ".cache", "paperless-go")
-		if cacheDir != expected {
-			t.Errorf("cacheDir = %v, want %v", cacheDir, expected)
-		}
-	})
-}
+	out, stderr, err = run("https://work.example", "cache", "status")
+	var status CacheStatusOutput
+	if err != nil || json.Unmarshal([]byte(out), &status) != nil {
+		t.Fatalf("cache status failed: %v, output: %s, stderr: %s", err, out, stderr)
+	}
+	if status.Dir != work || status.TTL != DefaultCacheTTL.String() || len(status.Caches) != 3 {
+		t.Fatalf("status = %+v", status)
+	}
+	if tags := status.Caches[0]; tags.Name != "tags" || !tags.Exists || tags.Entries != 2 || tags.Stale || tags.Age == "" {
+		t.Errorf("tags status = %+v", tags)
+	}
+	if docs := status.Caches[1]; docs.Exists || !docs.Stale {
+		t.Errorf("docs status = %+v, want missing and stale", docs)
+	}
 
-func TestDefaultCacheTTL_Shared(t *testing.T) {
-	// Verify default TTL is 12 hours
-	if DefaultCacheTTL != 12*time.Hour {
-		t.Errorf("DefaultCacheTTL = %v, want %v", DefaultCacheTTL, 12*time.Hour)
+	out, stderr, err = run("https://home.example", "cache", "clear", "-tags")
+	var cleared CacheClearOutput
+	if err != nil || json.Unmarshal([]byte(out), &cleared) != nil || len(cleared.Removed) != 2 {
+		t.Errorf("cache clear = %s, %v, stderr: %s", out, err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(home, "tags.json")); !os.IsNotExist(err) {
+		t.Errorf("home tags cache not removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(work, "tags.json")); err != nil {
+		t.Errorf("work tags cache removed by clearing home: %v", err)
+	}
+
+	if _, stderr, err = run("https://home.example", "cache", "warm"); err == nil || !strings.Contains(stderr, "API token is required") {
+		t.Errorf("expected cache warm to require a token, got %v, stderr: %s", err, stderr)
 	}
 }
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// reprocessBatchSize is the number of documents per reprocess request.
+// Paperless queues one task per document, so batches only keep the
+// requests small.
+const reprocessBatchSize = 100
+
+// OCRCheckOutput is the result of check-ocr: the documents whose content is
+// empty or shorter than MinChars characters, which usually means OCR failed
+type OCRCheckOutput struct {
+	Documents   int        `json:"documents"` // Documents checked
+	MinChars    int        `json:"min_chars"`
+	Count       int        `json:"count"`
+	Reprocessed []int      `json:"reprocessed,omitempty"`
+	Results     []OCRIssue `json:"results"`
+}
+
+// OCRIssue is a document with empty or short content
+type OCRIssue struct {
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	Created string `json:"created"`
+	Problem string `json:"problem"` // "empty" or "short"
+	Chars   int    `json:"chars"`
+}
+
+// contentChars counts the characters of content, with runs of whitespace
+// counted as one
+func contentChars(content string) int {
+	return utf8.RuneCountInString(strings.Join(strings.Fields(content), " "))
+}
+
+// checkOCRFlags are the flags of check-ocr
+type checkOCRFlags struct {
+	minChars  *int
+	reprocess *bool
+	yes       *bool
+	filters   *docFilters
+}
+
+func addCheckOCRFlags(fs *flag.FlagSet) *checkOCRFlags {
+	return &checkOCRFlags{
+		minChars:  fs.Int("min-chars", 50, "Content shorter than this many characters is suspicious"),
+		reprocess: fs.Bool("reprocess", false, "Have Paperless redo OCR for the documents found, after confirmation"),
+		yes:       fs.Bool("yes", false, "Reprocess without asking for confirmation"),
+		filters:   addDocFilterFlags(fs),
+	}
+}
+
+func runCheckOCR(client *paperless.Client, args []string, forceRefresh bool) error {
+	fs := flag.NewFlagSet("check-ocr", flag.ContinueOnError)
+	check := addCheckOCRFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return usagef("parse check-ocr flags: %w", err)
+	}
+	if fs.NArg() != 0 {
+		return commandUsage("check-ocr")
+	}
+	if *check.minChars < 0 {
+		return usagef("invalid -min-chars %d", *check.minChars)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	opts := &paperless.ListOptions{PageSize: 100}
+	if err := check.filters.apply(ctx, client, forceRefresh, opts); err != nil {
+		return err
+	}
+
+	output := OCRCheckOutput{MinChars: *check.minChars, Results: []OCRIssue{}}
+	prog := newProgress("Checking documents", 0)
+	it := client.IterDocuments(opts, paperless.WithIDCursor())
+	for it.Next(ctx) {
+		prog.setTotal(it.Count())
+		doc := it.Document()
+		output.Documents++
+		prog.add(1, 0)
+
+		chars := contentChars(doc.Content)
+		if chars >= *check.minChars && chars > 0 {
+			continue
+		}
+		problem := "short"
+		if chars == 0 {
+			problem = "empty"
+		}
+		output.Results = append(output.Results, OCRIssue{
+			ID:      doc.ID,
+			Title:   doc.Title,
+			Created: doc.CreatedDay().Time().Format("2006-01-02"),
+			Problem: problem,
+			Chars:   chars,
+		})
+	}
+	prog.finish()
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("failed to list documents: %w", err)
+	}
+	output.Count = len(output.Results)
+
+	if *check.reprocess && output.Count > 0 {
+		if !*check.yes && dryRun == nil {
+			prompt := fmt.Sprintf("Reprocess %d documents? This replaces their content and archive versions [y/N]: ", output.Count)
+			ok, err := confirm(os.Stdin, os.Stderr, prompt)
+			if err != nil {
+				return fmt.Errorf("failed to read confirmation: %w", err)
+			}
+			if !ok {
+				return fmt.Errorf("aborted; pass --yes to reprocess without confirmation")
+			}
+		}
+		ids := make([]int, len(output.Results))
+		for i, issue := range output.Results {
+			ids[i] = issue.ID
+		}
+		for start := 0; start < len(ids); start += reprocessBatchSize {
+			batch := ids[start:min(start+reprocessBatchSize, len(ids))]
+			if err := client.BulkEditDocuments(ctx, batch, paperless.BulkReprocess, nil); err != nil {
+				if len(output.Reprocessed) > 0 {
+					err = fmt.Errorf("%w (queued for %s)", err, joinIDs(output.Reprocessed))
+				}
+				return fmt.Errorf("failed to reprocess documents: %w", err)
+			}
+			output.Reprocessed = append(output.Reprocessed, batch...)
+		}
+	}
+
+	if err := writeOutput(output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestContentChars(t *testing.T) {
+	tests := map[string]int{
+		"":               0,
+		" \n\t":          0,
+		"Invoice  \n 42": 10,
+		"Überweisung":    11,
+		"  a  ":          1,
+	}
+	for content, want := range tests {
+		if got := contentChars(content); got != want {
+			t.Errorf("contentChars(%q) = %d, want %d", content, got, want)
+		}
+	}
+}
+
+func TestCLI_CheckOCR(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		bulkEdits []string
+	)
+	edits := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), bulkEdits...)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/documents/":
+			if r.URL.Query().Get("id__gt") != "" {
+				w.Write([]byte(`{"count": 3, "results": []}`))
+				return
+			}
+			w.Write([]byte(`{"count": 3, "results": [
+				{"id": 1, "title": "Letter", "created": "2024-01-02", "content": "Dear customer, thank you for your order of the 1st of January."},
+				{"id": 2, "title": "Scan", "created": "2024-01-03", "content": "  \n"},
+				{"id": 3, "title": "Photo", "created": "2024-01-04", "content": "lorem ipsum"}]}`))
+		case "/api/documents/bulk_edit/":
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			bulkEdits = append(bulkEdits, string(body))
+			mu.Unlock()
+			w.Write([]byte(`{"result": "OK"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(stdin string, args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		cmd.Stdin = strings.NewReader(stdin)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	stdout, stderr, err := run("", "check-ocr")
+	if err != nil {
+		t.Fatalf("check-ocr failed: %v\nStderr: %s", err, stderr)
+	}
+	var out OCRCheckOutput
+	if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, stdout)
+	}
+	if out.Documents != 3 || out.Count != 2 || out.Results[0].ID != 2 || out.Results[0].Problem != "empty" ||
+		out.Results[1].ID != 3 || out.Results[1].Problem != "short" || out.Results[1].Chars != 11 {
+		t.Errorf("output = %+v", out)
+	}
+	if len(edits()) != 0 {
+		t.Errorf("documents reprocessed without -reprocess: %v", edits())
+	}
+
+	if _, stderr, err := run("n\n", "check-ocr", "-reprocess"); err == nil || !strings.Contains(stderr, "aborted") || len(edits()) != 0 {
+		t.Errorf("expected reprocessing to be aborted, got %v, stderr: %s, requests: %v", err, stderr, edits())
+	}
+
+	stdout, stderr, err = run("", "check-ocr", "-min-chars", "5", "-reprocess", "-yes")
+	if err != nil {
+		t.Fatalf("check-ocr -reprocess failed: %v\nStderr: %s", err, stderr)
+	}
+	if err := json.Unmarshal([]byte(stdout), &out); err != nil || len(out.Reprocessed) != 1 || out.Reprocessed[0] != 2 {
+		t.Errorf("reprocess output = %s, %v", stdout, err)
+	}
+	if got := edits(); len(got) != 1 || !strings.Contains(got[0], `"method":"reprocess"`) || !strings.Contains(got[0], `"documents":[2]`) {
+		t.Errorf("bulk edits = %v", got)
+	}
+}
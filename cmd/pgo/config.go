@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Profile is a named Paperless instance in the config file
+type Profile struct {
+	Name   string
+	URL    string
+	Token  string
+	Tags   []int  // Default tags for uploads
+	Notify string // Default -notify target
+	// ReadOnly and Production label instances that must not be changed by
+	// accident; changes need -yes-i-mean-it
+	ReadOnly   bool
+	Production bool
+	// MaxRate is the most requests per second to send to the instance,
+	// together with pgo-rag and other pgo processes; 0 for no limit
+	MaxRate float64
+}
+
+// Config is the pgo config file:
+//
+//	default_profile = "home"
+//
+//	[profiles.home]
+//	url = "https://paperless.home.example"
+//	token = "..."
+//	tags = [1, 5]
+//
+//	[profiles.work]
+//	url = "https://paperless.example.com"
+//	token = "..."
+//	notify = "desktop"
+//	production = true
+//	max_rate = 5
+type Config struct {
+	DefaultProfile string
+	Profiles       map[string]*Profile
+}
+
+// getConfigFilePath returns the config file path, preferring XDG_CONFIG_HOME
+func getConfigFilePath() (string, error) {
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		return filepath.Join(configHome, "paperless-go", "config.toml"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".config", "paperless-go", "config.toml"), nil
+}
+
+// loadConfig reads the config file at path. A missing file is an empty
+// config.
+func loadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return &Config{Profiles: map[string]*Profile{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open config: %w", err)
+	}
+	defer f.Close()
+
+	cfg, err := parseConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// settings are the connection settings after combining flags, environment
+// and config
+type settings struct {
+	URL     string
+	Token   string
+	Profile Profile
+	MaxRate float64 // See Profile.MaxRate
+}
+
+// resolveSettings picks the URL and token. Flags always win. A profile
+// selected explicitly (-profile or PAPERLESS_PROFILE) overrides
+// PAPERLESS_URL and PAPERLESS_TOKEN; the config's default_profile only fills
+// in what the environment leaves unset.
+func resolveSettings(cfg *Config, flagURL, flagToken, profileName string, getenv func(string) string) (settings, error) {
+	explicit := profileName != ""
+	if !explicit {
+		profileName = cfg.DefaultProfile
+	}
+
+	var s settings
+	if profileName != "" {
+		p, ok := cfg.Profiles[profileName]
+		if !ok {
+			return settings{}, fmt.Errorf("profile %q not found in config", profileName)
+		}
+		s.Profile = *p
+	}
+
+	pick := func(flagValue, profileValue, envValue string) string {
+		candidates := []string{flagValue, envValue, profileValue}
+		if explicit {
+			candidates = []string{flagValue, profileValue, envValue}
+		}
+		for _, v := range candidates {
+			if v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	s.URL = pick(flagURL, s.Profile.URL, getenv("PAPERLESS_URL"))
+	s.Token = pick(flagToken, s.Profile.Token, getenv("PAPERLESS_TOKEN"))
+	s.MaxRate = s.Profile.MaxRate
+	if env := strings.TrimSpace(getenv("PAPERLESS_MAX_RATE")); env != "" && (s.MaxRate == 0 || !explicit) {
+		rate, err := strconv.ParseFloat(env, 64)
+		if err != nil || rate < 0 {
+			return settings{}, fmt.Errorf("invalid PAPERLESS_MAX_RATE %q (requests per second)", env)
+		}
+		s.MaxRate = rate
+	}
+	return s, nil
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// parseConfig parses the subset of TOML used by the config file: tables
+// named [profiles.<name>], and string, integer, boolean and single-line
+// array values. Unknown keys are errors so typos are not silently ignored.
+func parseConfig(r io.Reader) (*Config, error) {
+	cfg := &Config{Profiles: map[string]*Profile{}}
+	var current *Profile
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			name, err := parseTableHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			if cfg.Profiles[name] == nil {
+				cfg.Profiles[name] = &Profile{Name: name}
+			}
+			current = cfg.Profiles[name]
+			continue
+		}
+
+		key, rest, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		key = strings.TrimSpace(key)
+		value, rest, err := parseValue(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", n, key, err)
+		}
+		if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+			return nil, fmt.Errorf("line %d: unexpected %q after value", n, rest)
+		}
+
+		if err := setConfigKey(cfg, current, key, value); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if cfg.DefaultProfile != "" && cfg.Profiles[cfg.DefaultProfile] == nil {
+		return nil, fmt.Errorf("default_profile %q is not defined", cfg.DefaultProfile)
+	}
+	return cfg, nil
+}
+
+// parseTableHeader returns the profile name of a [profiles.<name>] header
+func parseTableHeader(line string) (string, error) {
+	end := strings.LastIndex(line, "]")
+	if strings.HasPrefix(line, "[[") || end < 0 {
+		return "", fmt.Errorf("unsupported table header %s", line)
+	}
+	if rest := strings.TrimSpace(line[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected %q after table header", rest)
+	}
+	inner := strings.TrimSpace(line[1:end])
+	name, ok := strings.CutPrefix(inner, "profiles.")
+	if !ok {
+		return "", fmt.Errorf("unknown table [%s] (expected [profiles.<name>])", inner)
+	}
+	// Names are used as cache directory names, so they are restricted to
+	// bare keys
+	if !bareKey.MatchString(name) {
+		return "", fmt.Errorf("invalid profile name %s (use letters, digits, _ and -)", name)
+	}
+	return name, nil
+}
+
+// setConfigKey stores a value in the root table (profile nil) or a profile
+func setConfigKey(cfg *Config, profile *Profile, key string, value interface{}) error {
+	if profile == nil {
+		if key != "default_profile" {
+			return fmt.Errorf("unknown key %q (profiles go in [profiles.<name>] tables)", key)
+		}
+		return assignString(key, value, &cfg.DefaultProfile)
+	}
+
+	switch key {
+	case "url":
+		return assignString(key, value, &profile.URL)
+	case "token":
+		return assignString(key, value, &profile.Token)
+	case "notify":
+		return assignString(key, value, &profile.Notify)
+	case "readonly":
+		return assignBool(key, value, &profile.ReadOnly)
+	case "production":
+		return assignBool(key, value, &profile.Production)
+	case "max_rate":
+		n, ok := value.(int64)
+		if !ok || n < 0 {
+			return fmt.Errorf("max_rate must be a number of requests per second")
+		}
+		profile.MaxRate = float64(n)
+		return nil
+	case "tags":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("tags must be an array of tag IDs")
+		}
+		profile.Tags = nil
+		for _, item := range items {
+			id, ok := item.(int64)
+			if !ok || id <= 0 {
+				return fmt.Errorf("tags must be an array of tag IDs")
+			}
+			profile.Tags = append(profile.Tags, int(id))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown key %q", key)
+}
+
+func assignString(key string, value interface{}, dst *string) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("%s must be a string", key)
+	}
+	*dst = s
+	return nil
+}
+
+func assignBool(key string, value interface{}, dst *bool) error {
+	b, ok := value.(bool)
+	if !ok {
+		return fmt.Errorf("%s must be true or false", key)
+	}
+	*dst = b
+	return nil
+}
+
+// parseValue parses the value at the start of s and returns the rest
+func parseValue(s string) (interface{}, string, error) {
+	switch {
+	case s == "":
+		return nil, "", fmt.Errorf("missing value")
+	case s[0] == '"' || s[0] == '\'':
+		str, rest, err := parseString(s)
+		return str, rest, err
+	case s[0] == '[':
+		return parseArray(s)
+	}
+
+	end := strings.IndexAny(s, " \t,]#")
+	if end < 0 {
+		end = len(s)
+	}
+	word, rest := s[:end], s[end:]
+	switch word {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(word, "_", ""), 10, 64)
+	if err != nil {
+		return nil, "", fmt.Errorf("unsupported value %q", word)
+	}
+	return n, rest, nil
+}
+
+// parseString parses a basic "..." or literal '...' string
+func parseString(s string) (string, string, error) {
+	if s[0] == '\'' {
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			v, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", fmt.Errorf("invalid string %s", s[:i+1])
+			}
+			return v, s[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string")
+}
+
+// parseArray parses a single-line array
+func parseArray(s string) (interface{}, string, error) {
+	items := []interface{}{}
+	rest := strings.TrimSpace(s[1:])
+	for {
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			return nil, "", fmt.Errorf("unterminated array (arrays must be on one line)")
+		}
+		if strings.HasPrefix(rest, "]") {
+			return items, rest[1:], nil
+		}
+		item, after, err := parseValue(rest)
+		if err != nil {
+			return nil, "", err
+		}
+		items = append(items, item)
+
+		rest = strings.TrimSpace(after)
+		switch {
+		case strings.HasPrefix(rest, ","):
+			rest = strings.TrimSpace(rest[1:])
+		case strings.HasPrefix(rest, "]"):
+		default:
+			return nil, "", fmt.Errorf("unterminated array (arrays must be on one line)")
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo/internal/cache"
+)
+
+const testConfig = `# pgo profiles
+default_profile = "home"
+
+[profiles.home]
+url = "https://paperless.home.example"
+token = 'home-token' # literal string
+tags = [1, 5]
+
+[profiles.work-2]
+url = "https://paperless.example.com"
+token = "work\ttoken"
+notify = "desktop"
+tags = []
+production = true
+max_rate = 2
+`
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := parseConfig(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatalf("parseConfig failed: %v", err)
+	}
+	want := &Config{
+		DefaultProfile: "home",
+		Profiles: map[string]*Profile{
+			"home":   {Name: "home", URL: "https://paperless.home.example", Token: "home-token", Tags: []int{1, 5}},
+			"work-2": {Name: "work-2", URL: "https://paperless.example.com", Token: "work\ttoken", Notify: "desktop", Production: true, MaxRate: 2},
+		},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("parseConfig =\n%+v %+v\nwant\n%+v %+v", cfg, cfg.Profiles["work-2"], want, want.Profiles["work-2"])
+	}
+}
+
+func TestParseConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{"unknown root key", `url = "x"`, `line 1: unknown key "url"`},
+		{"unknown profile key", "[profiles.a]\ntokn = \"x\"", `line 2: unknown key "tokn"`},
+		{"unknown table", "[servers.a]", "unknown table [servers.a]"},
+		{"invalid profile name", `[profiles."a/b"]`, "invalid profile name"},
+		{"wrong type", "[profiles.a]\nurl = 1", "url must be a string"},
+		{"bad label", "[profiles.a]\nreadonly = \"yes\"", "readonly must be true or false"},
+		{"bad max_rate", "[profiles.a]\nmax_rate = \"fast\"", "max_rate must be a number"},
+		{"bad tags", "[profiles.a]\ntags = [\"x\"]", "tags must be an array of tag IDs"},
+		{"multi-line array", "[profiles.a]\ntags = [1,\n2]", "arrays must be on one line"},
+		{"unterminated string", "[profiles.a]\nurl = \"x", "unterminated string"},
+		{"trailing garbage", "[profiles.a]\nurl = \"x\" y", `unexpected "y"`},
+		{"undefined default", `default_profile = "nope"`, `default_profile "nope" is not defined`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseConfig(strings.NewReader(tt.config))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseConfig error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveSettings(t *testing.T) {
+	cfg, err := parseConfig(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatalf("parseConfig failed: %v", err)
+	}
+	env := map[string]string{"PAPERLESS_URL": "https://env.example", "PAPERLESS_TOKEN": "env-token"}
+	noEnv := map[string]string{}
+
+	tests := []struct {
+		name      string
+		flagURL   string
+		profile   string
+		env       map[string]string
+		wantURL   string
+		wantToken string
+	}{
+		{"default profile", "", "", noEnv, "https://paperless.home.example", "home-token"},
+		{"env beats default profile", "", "", env, "https://env.example", "env-token"},
+		{"explicit profile beats env", "", "work-2", env, "https://paperless.example.com", "work\ttoken"},
+		{"flag beats explicit profile", "https://flag.example", "work-2", env, "https://flag.example", "work\ttoken"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := resolveSettings(cfg, tt.flagURL, "", tt.profile, func(k string) string { return tt.env[k] })
+			if err != nil {
+				t.Fatalf("resolveSettings failed: %v", err)
+			}
+			if s.URL != tt.wantURL || s.Token != tt.wantToken {
+				t.Errorf("settings = %q %q, want %q %q", s.URL, s.Token, tt.wantURL, tt.wantToken)
+			}
+		})
+	}
+
+	rateEnv := map[string]string{"PAPERLESS_MAX_RATE": "0.5"}
+	for profile, want := range map[string]float64{"": 0.5, "home": 0.5, "work-2": 2} {
+		s, err := resolveSettings(cfg, "", "", profile, func(k string) string { return rateEnv[k] })
+		if err != nil || s.MaxRate != want {
+			t.Errorf("profile %q: max rate %v, %v; want %v", profile, s.MaxRate, err, want)
+		}
+	}
+	if _, err := resolveSettings(cfg, "", "", "", func(string) string { return "fast" }); err == nil {
+		t.Error("expected error for invalid PAPERLESS_MAX_RATE")
+	}
+
+	if _, err := resolveSettings(cfg, "", "", "missing", func(string) string { return "" }); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+	empty := &Config{Profiles: map[string]*Profile{}}
+	if s, err := resolveSettings(empty, "", "", "", func(k string) string { return env[k] }); err != nil || s.URL != "https://env.example" {
+		t.Errorf("without config: %+v, %v", s, err)
+	}
+}
+
+func TestCLI_Profile(t *testing.T) {
+	configHome := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(configHome, "paperless-go"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configHome, "paperless-go", "config.toml"), []byte(testConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", args...)
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+configHome, "XDG_CACHE_HOME=/tmp/test-cache", "PAPERLESS_PROFILE=")
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return strings.TrimSpace(stdout.String()), stderr.String(), err
+	}
+
+	out, stderr, err := run("config", "path")
+	if err != nil || out != filepath.Join(configHome, "paperless-go", "config.toml") {
+		t.Errorf("config path = %q, %v, stderr: %s", out, err, stderr)
+	}
+
+	out, stderr, err = run("-profile", "work-2", "cache", "path", "-tags")
+	if err != nil || out != "/tmp/test-cache/paperless-go/instances/"+cache.Namespace("https://paperless.example.com")+"/tags.json" {
+		t.Errorf("cache path = %q, %v, stderr: %s", out, err, stderr)
+	}
+
+	_, stderr, err = run("-profile", "nope", "get", "tags")
+	if err == nil || !strings.Contains(stderr, `profile "nope" not found`) {
+		t.Errorf("expected unknown profile error, got %v, stderr: %s", err, stderr)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+	"github.com/jason-riddle/paperless-go/cmd/pgo/internal/cache"
+)
+
+// correspondentCache returns the cache of correspondent ID to name mappings
+// of the instance in use, for correspondent name resolution
+func correspondentCache() *nameCache {
+	return cache.New[map[int]string](caches, "correspondents.json", "correspondents")
+}
+
+// getCorrespondentNamesWithCache fetches correspondent names with caching support
+func getCorrespondentNamesWithCache(ctx context.Context, client *paperless.Client, forceRefresh bool, ttl time.Duration) (map[int]string, error) {
+	return correspondentCache().Get(ttl, forceRefresh, func() (map[int]string, error) {
+		names := make(map[int]string)
+
+		// Fetch all pages of correspondents
+		opts := &paperless.ListOptions{PageSize: 100} // Large page size to minimize requests
+		for {
+			correspondents, err := client.ListCorrespondents(ctx, opts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch correspondents: %w", err)
+			}
+
+			// Add correspondents from this page
+			for _, c := range correspondents.Results {
+				names[c.ID] = c.Name
+			}
+
+			// Check if there are more pages
+			if correspondents.Next == nil || *correspondents.Next == "" {
+				break
+			}
+
+			// For simplicity, just increase page number (this assumes consistent ordering)
+			if opts.Page == 0 {
+				opts.Page = 1
+			}
+			opts.Page++
+		}
+		return names, nil
+	})
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+func TestGetCorrespondentNamesWithCache(t *testing.T) {
+	useTestCaches(t, false)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`{"count": 2, "next": null, "results": [{"id": 2, "name": "Telekom"}]}`))
+			return
+		}
+		w.Write([]byte(`{"count": 2, "next": "page2", "results": [{"id": 1, "name": "Amazon"}]}`))
+	}))
+	defer server.Close()
+	client := paperless.NewClient(server.URL, "test-token")
+
+	names, err := getCorrespondentNamesWithCache(context.Background(), client, false, DefaultCacheTTL)
+	if err != nil {
+		t.Fatalf("getCorrespondentNamesWithCache failed: %v", err)
+	}
+	if len(names) != 2 || names[1] != "Amazon" || names[2] != "Telekom" || requests != 2 {
+		t.Errorf("names = %v after %d requests, want both pages", names, requests)
+	}
+
+	path, err := correspondentCache().Path()
+	if err != nil || filepath.Base(path) != "correspondents.json" {
+		t.Errorf("cache path = %q, %v", path, err)
+	}
+	entry, err := correspondentCache().Load()
+	if err != nil || entry == nil || entry.Data[2] != "Telekom" {
+		t.Fatalf("Load = %+v, %v", entry, err)
+	}
+
+	// A fresh cache is used without requests
+	if _, err := getCorrespondentNamesWithCache(context.Background(), client, false, DefaultCacheTTL); err != nil || requests != 2 {
+		t.Errorf("expected cached names without requests, got %d requests, %v", requests, err)
+	}
+}
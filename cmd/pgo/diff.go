@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// DocDiffOutput is the result of diff docs
+type DocDiffOutput struct {
+	A int `json:"a"`
+	B int `json:"b"`
+	// SameFile is true if the original files have the same checksum
+	SameFile    bool            `json:"same_file"`
+	SameContent bool            `json:"same_content"`
+	Changes     []DocDiffChange `json:"changes"`
+}
+
+// DocDiffChange is a field that differs between the documents. Tags,
+// correspondents, document types and storage paths are shown by name.
+type DocDiffChange struct {
+	Field string      `json:"field"`
+	A     interface{} `json:"a"`
+	B     interface{} `json:"b"`
+	OnlyA []string    `json:"only_a,omitempty"` // Tags or note IDs only a has
+	OnlyB []string    `json:"only_b,omitempty"`
+}
+
+// docDiffNames resolves the IDs in a diff to names
+type docDiffNames struct {
+	ctx            context.Context
+	client         *paperless.Client
+	tags           map[int]string
+	correspondents map[int]string
+}
+
+// name returns the name of the object id of kind points to, or nil
+func (n *docDiffNames) name(kind string, id *int) interface{} {
+	if id == nil {
+		return nil
+	}
+	switch kind {
+	case "correspondent":
+		if name, ok := n.correspondents[*id]; ok {
+			return name
+		}
+	case "document_type":
+		if dt, err := n.client.GetDocumentType(n.ctx, *id); err == nil {
+			return dt.Name
+		}
+	case "storage_path":
+		if sp, err := n.client.GetStoragePath(n.ctx, *id); err == nil {
+			return sp.Name
+		}
+	}
+	return fmt.Sprintf("unknown(%d)", *id)
+}
+
+func (n *docDiffNames) tagNames(ids []int) []string {
+	names := []string{}
+	for _, id := range ids {
+		if name, ok := n.tags[id]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, fmt.Sprintf("unknown(%d)", id))
+		}
+	}
+	return names
+}
+
+// diffDocs compares documents a and b and their stored files
+func diffDocs(a, b *paperless.Document, metaA, metaB *paperless.DocumentMetadata, names *docDiffNames) DocDiffOutput {
+	out := DocDiffOutput{
+		A:           a.ID,
+		B:           b.ID,
+		SameFile:    metaA.OriginalChecksum != "" && metaA.OriginalChecksum == metaB.OriginalChecksum,
+		SameContent: a.Content == b.Content,
+		Changes:     []DocDiffChange{},
+	}
+	for _, c := range paperless.DiffDocuments(*a, *b) {
+		change := DocDiffChange{Field: c.Field, A: c.Old, B: c.New}
+		switch c.Field {
+		case "id", "content":
+			continue // always different, or summarized by same_content
+		case "tags":
+			change.A, change.B = names.tagNames(a.Tags), names.tagNames(b.Tags)
+			change.OnlyA, change.OnlyB = names.tagNames(c.Removed), names.tagNames(c.Added)
+		case "correspondent", "document_type", "storage_path":
+			change.A, change.B = names.name(c.Field, c.Old.(*int)), names.name(c.Field, c.New.(*int))
+		case "notes":
+			change.OnlyA = paperless.Map(c.Removed, strconv.Itoa)
+			change.OnlyB = paperless.Map(c.Added, strconv.Itoa)
+		}
+		out.Changes = append(out.Changes, change)
+	}
+
+	files := []struct {
+		field string
+		a, b  interface{}
+	}{
+		{"original_checksum", metaA.OriginalChecksum, metaB.OriginalChecksum},
+		{"original_size", metaA.OriginalSize, metaB.OriginalSize},
+		{"original_mime_type", metaA.OriginalMimeType, metaB.OriginalMimeType},
+		{"archive_checksum", metaA.ArchiveChecksum, metaB.ArchiveChecksum},
+		{"archive_size", metaA.ArchiveSize, metaB.ArchiveSize},
+	}
+	for _, f := range files {
+		if f.a != f.b {
+			out.Changes = append(out.Changes, DocDiffChange{Field: f.field, A: f.a, B: f.b})
+		}
+	}
+	return out
+}
+
+// runDiff compares two documents, e.g. to decide which duplicate to keep
+func runDiff(client *paperless.Client, args []string, forceRefresh bool) error {
+	if len(args) != 3 || args[0] != "docs" {
+		return commandUsage("diff docs")
+	}
+	var ids [2]int
+	for i, arg := range args[1:] {
+		id, err := strconv.Atoi(arg)
+		if err != nil || id <= 0 {
+			return usagef("invalid ID format: %s", arg)
+		}
+		ids[i] = id
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var docs [2]*paperless.Document
+	var metas [2]*paperless.DocumentMetadata
+	for i, id := range ids {
+		doc, err := client.GetDocument(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get document %d: %w", id, err)
+		}
+		meta, err := client.GetDocumentMetadata(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get metadata of document %d: %w", id, err)
+		}
+		docs[i], metas[i] = doc, meta
+	}
+
+	names := &docDiffNames{ctx: ctx, client: client}
+	var err error
+	if names.tags, err = getTagNamesWithCache(ctx, client, forceRefresh, DefaultCacheTTL); err != nil {
+		slog.Warn("Could not fetch tags for name resolution", "error", err)
+		names.tags = make(map[int]string)
+	}
+	if names.correspondents, err = getCorrespondentNamesWithCache(ctx, client, forceRefresh, DefaultCacheTTL); err != nil {
+		slog.Warn("Could not fetch correspondents for name resolution", "error", err)
+		names.correspondents = make(map[int]string)
+	}
+
+	if err := writeOutput(diffDocs(docs[0], docs[1], metas[0], metas[1], names)); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCLI_DiffDocs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags/":
+			w.Write([]byte(`{"count": 3, "results": [{"id": 1, "name": "tax"}, {"id": 2, "name": "inbox"}, {"id": 3, "name": "paid"}]}`))
+		case "/api/correspondents/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 4, "name": "ACME"}]}`))
+		case "/api/documents/10/":
+			w.Write([]byte(`{"id": 10, "title": "Invoice", "content": "total 12", "created": "2024-03-01",
+				"tags": [1, 2], "correspondent": 4}`))
+		case "/api/documents/11/":
+			w.Write([]byte(`{"id": 11, "title": "Invoice (1)", "content": "total 12", "created": "2024-03-01",
+				"tags": [1, 3], "correspondent": null}`))
+		case "/api/documents/10/metadata/":
+			w.Write([]byte(`{"original_checksum": "aaa", "original_size": 100, "original_mime_type": "application/pdf"}`))
+		case "/api/documents/11/metadata/":
+			w.Write([]byte(`{"original_checksum": "aaa", "original_size": 100, "original_mime_type": "application/pdf"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	stdout, stderr, err := run("diff", "docs", "10", "11")
+	if err != nil {
+		t.Fatalf("diff docs failed: %v\nStderr: %s", err, stderr)
+	}
+	var out DocDiffOutput
+	if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, stdout)
+	}
+	want := DocDiffOutput{
+		A: 10, B: 11, SameFile: true, SameContent: true,
+		Changes: []DocDiffChange{
+			{Field: "title", A: "Invoice", B: "Invoice (1)"},
+			{Field: "tags", A: []interface{}{"tax", "inbox"}, B: []interface{}{"tax", "paid"}, OnlyA: []string{"inbox"}, OnlyB: []string{"paid"}},
+			{Field: "correspondent", A: "ACME", B: nil},
+		},
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("diff =\n%+v\nwant\n%+v", out, want)
+	}
+
+	if _, stderr, err := run("diff", "docs", "10"); err == nil || !strings.Contains(stderr, "usage: pgo diff docs") {
+		t.Errorf("expected usage error, got %v, stderr: %s", err, stderr)
+	}
+}
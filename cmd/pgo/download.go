@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/jason-riddle/paperless-go"
+	"github.com/jason-riddle/paperless-go/cmd/pgo/internal/cache"
+)
+
+// downloadManifestFile lists the downloaded files in the destination
+const downloadManifestFile = "manifest.json"
+
+// DownloadedFile is a downloaded document in the manifest. Path is relative
+// to the destination directory.
+type DownloadedFile struct {
+	ID       int            `json:"id"`
+	Title    string         `json:"title"`
+	Created  string         `json:"created"`
+	Modified paperless.Date `json:"modified"`
+	Path     string         `json:"path"`
+	Original bool           `json:"original"` // The file as uploaded rather than the archived PDF
+	Size     int            `json:"size"`
+	SHA256   string         `json:"sha256"`
+}
+
+// DownloadManifest is written to manifest.json at the end of a download
+type DownloadManifest struct {
+	DownloadedAt string           `json:"downloaded_at"`
+	Source       string           `json:"source"`
+	Documents    []DownloadedFile `json:"documents"`
+}
+
+// DownloadOutput is the summary of a download run
+type DownloadOutput struct {
+	Dest       string   `json:"dest"`
+	Documents  int      `json:"documents"`
+	Downloaded int      `json:"downloaded"`
+	Skipped    int      `json:"skipped"`
+	Failed     int      `json:"failed"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// downloadFlags are the flags of download docs
+type downloadFlags struct {
+	filters  *docFilters
+	dest     *string
+	original *bool
+}
+
+func addDownloadFlags(fs *flag.FlagSet) *downloadFlags {
+	return &downloadFlags{
+		filters:  addDocFilterFlags(fs),
+		dest:     fs.String("dest", "", "Directory to download to; files unchanged since an earlier download there are skipped"),
+		original: fs.Bool("original", false, "Download the files as uploaded instead of the archived PDFs"),
+	}
+}
+
+// downloadFileName returns the file name of a downloaded document: its ID
+// and the name the server sends, made safe to use as a file name
+func downloadFileName(doc paperless.Document, serverName string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(serverName))
+	if name == "" || name == "." || name == ".." {
+		name = "document" + strings.ToLower(filepath.Ext(doc.OriginalFileName))
+	}
+	return strconv.Itoa(doc.ID) + "-" + name
+}
+
+// loadDownloadManifest returns the files of an earlier download to dest by
+// document ID, leaving out those missing on disk
+func loadDownloadManifest(dest string) map[int]DownloadedFile {
+	files := map[int]DownloadedFile{}
+	data, err := os.ReadFile(filepath.Join(dest, downloadManifestFile))
+	if err != nil {
+		return files
+	}
+	var manifest DownloadManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return files
+	}
+	for _, f := range manifest.Documents {
+		if info, err := os.Stat(filepath.Join(dest, f.Path)); err == nil && info.Size() == int64(f.Size) {
+			files[f.ID] = f
+		}
+	}
+	return files
+}
+
+// downloadDocument downloads doc into dest, the archived PDF unless original
+// is set or there is none. Downloaded bytes are added to prog.
+func downloadDocument(ctx context.Context, client *paperless.Client, dest string, doc paperless.Document, original bool, prog *progress) (DownloadedFile, error) {
+	original = original || doc.ArchivedFileName == ""
+	file, err := client.DownloadDocument(ctx, doc.ID, original)
+	if err != nil {
+		return DownloadedFile{}, fmt.Errorf("failed to download: %w", err)
+	}
+	prog.add(0, int64(len(file.Data)))
+
+	downloaded := DownloadedFile{
+		ID:       doc.ID,
+		Title:    doc.Title,
+		Created:  doc.CreatedDay().Time().Format("2006-01-02"),
+		Modified: doc.Modified,
+		Path:     downloadFileName(doc, file.Name),
+		Original: original,
+		Size:     len(file.Data),
+	}
+	sum := sha256.Sum256(file.Data)
+	downloaded.SHA256 = hex.EncodeToString(sum[:])
+	if err := cache.WriteFileAtomic(filepath.Join(dest, downloaded.Path), file.Data); err != nil {
+		return DownloadedFile{}, err
+	}
+	return downloaded, nil
+}
+
+// runDownload runs download docs: it lists the documents matching the
+// filters, which Paperless resolves, and downloads them with the pool
+func runDownload(client *paperless.Client, args []string, source string, forceRefresh bool, pool workerPool) error {
+	if len(args) == 0 || args[0] != "docs" {
+		return commandUsage("download")
+	}
+	fs := flag.NewFlagSet("download docs", flag.ContinueOnError)
+	download := addDownloadFlags(fs)
+	positional, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		return usagef("parse download flags: %w", err)
+	}
+	if len(positional) != 0 || *download.dest == "" {
+		return commandUsage("download docs")
+	}
+	dest := *download.dest
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return fmt.Errorf("failed to create download directory: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := &paperless.ListOptions{PageSize: 100}
+	if err := download.filters.apply(ctx, client, forceRefresh, opts); err != nil {
+		return err
+	}
+	docs := []paperless.Document{}
+	listing := newProgress("Listing documents", 0)
+	it := client.IterDocuments(opts, paperless.WithIDCursor())
+	for it.Next(ctx) {
+		listing.setTotal(it.Count())
+		docs = append(docs, it.Document())
+		listing.add(1, 0)
+	}
+	listing.finish()
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	// Each document gets its own slot, so the manifest keeps the list order
+	// however the downloads finish
+	previous := loadDownloadManifest(dest)
+	files := make([]DownloadedFile, len(docs))
+	skipped := make([]bool, len(docs))
+	prog := newProgress("Downloading", len(docs))
+	errs := pool.run(ctx, len(docs), func(ctx context.Context, i int) error {
+		doc := docs[i]
+		label := fmt.Sprintf("[%d/%d] #%d %s", i+1, len(docs), doc.ID, doc.Title)
+		defer prog.add(1, 0)
+
+		// A document is downloaded again only if it changed since
+		if prev, ok := previous[doc.ID]; ok && prev.Original == (*download.original || doc.ArchivedFileName == "") &&
+			prev.Modified.Time().Equal(doc.Modified.Time()) {
+			files[i], skipped[i] = prev, true
+			prog.step("%s: unchanged", label)
+			return nil
+		}
+
+		var err error
+		files[i], err = downloadDocument(ctx, client, dest, doc, *download.original, prog)
+		if err != nil {
+			if ctx.Err() == nil {
+				prog.logf("%s: %v", label, err)
+			}
+			return err
+		}
+		prog.step("%s: downloaded", label)
+		return nil
+	})
+	prog.finish()
+	if ctx.Err() != nil {
+		return errors.New("download interrupted; run it again to resume")
+	}
+
+	manifest := DownloadManifest{DownloadedAt: time.Now().Format(time.RFC3339), Source: source, Documents: []DownloadedFile{}}
+	output := DownloadOutput{Dest: dest, Documents: len(docs)}
+	for i, doc := range docs {
+		switch {
+		case errs[i] != nil:
+			output.Failed++
+			output.Errors = append(output.Errors, fmt.Sprintf("document %d: %v", doc.ID, errs[i]))
+			continue
+		case skipped[i]:
+			output.Skipped++
+		default:
+			output.Downloaded++
+		}
+		manifest.Documents = append(manifest.Documents, files[i])
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := cache.WriteFileAtomic(filepath.Join(dest, downloadManifestFile), data); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	if err := writeOutput(output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	if output.Failed > 0 {
+		return fmt.Errorf("failed to download %d of %d documents; run again to retry them", output.Failed, len(docs))
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+func TestDownloadFileName(t *testing.T) {
+	doc := paperless.Document{ID: 7, OriginalFileName: "scan.JPG"}
+	tests := map[string]string{
+		"2024 Invoice.pdf": "7-2024 Invoice.pdf",
+		"../../etc/passwd": "7-.._.._etc_passwd",
+		"":                 "7-document.jpg",
+		"..":               "7-document.jpg",
+	}
+	for name, want := range tests {
+		if got := downloadFileName(doc, name); got != want {
+			t.Errorf("downloadFileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCLI_DownloadDocs(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		query     string
+		downloads int
+	)
+	// state returns the last documents query and the number of downloads
+	state := func() (string, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		return query, downloads
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 3, "name": "taxes"}]}`))
+		case "/api/documents/":
+			mu.Lock()
+			query = r.URL.RawQuery
+			mu.Unlock()
+			w.Write([]byte(`{"count": 2, "results": [
+				{"id": 7, "title": "Invoice", "created": "2024-02-01", "modified": "2024-03-01T10:00:00Z", "original_file_name": "invoice.pdf", "archived_file_name": "2024 Invoice.pdf"},
+				{"id": 8, "title": "Scan", "created": "2024-02-03", "modified": "2024-03-01T10:00:00Z", "original_file_name": "scan.jpg"}]}`))
+		case "/api/documents/7/download/", "/api/documents/8/download/":
+			mu.Lock()
+			downloads++
+			mu.Unlock()
+			name := map[string]string{"/api/documents/7/download/": "2024 Invoice.pdf", "/api/documents/8/download/": "scan.jpg"}[r.URL.Path]
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+			fmt.Fprintf(w, "original=%s", r.URL.Query().Get("original"))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "out")
+	run := func() DownloadOutput {
+		cmd := exec.Command("./pgo", "-memory", "-concurrency", "2", "download", "docs", "--tag", "taxes", "--created-after", "2024-01-01", "--dest", dest)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("download docs failed: %v\nStderr: %s", err, stderr.String())
+		}
+		var out DownloadOutput
+		if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+			t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, stdout.String())
+		}
+		return out
+	}
+
+	out := run()
+	sent, downloaded := state()
+	if out.Documents != 2 || out.Downloaded != 2 || downloaded != 2 {
+		t.Errorf("output = %+v after %d downloads", out, downloaded)
+	}
+	if !strings.Contains(sent, "created__date__gt=2024-01-01") || !strings.Contains(sent, "tags__id__all=3") {
+		t.Errorf("filters not sent to the server: %s", sent)
+	}
+	// The archived PDF, and the original of the document without one
+	for path, want := range map[string]string{"7-2024 Invoice.pdf": "original=", "8-scan.jpg": "original=true"} {
+		if data, err := os.ReadFile(filepath.Join(dest, path)); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v, want %q", path, data, err, want)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dest, "manifest.json"))
+	var manifest DownloadManifest
+	if err != nil || json.Unmarshal(data, &manifest) != nil || len(manifest.Documents) != 2 {
+		t.Fatalf("manifest = %s, %v", data, err)
+	}
+	if f := manifest.Documents[0]; f.ID != 7 || f.Path != "7-2024 Invoice.pdf" || f.Original || f.Size != 9 || f.Created != "2024-02-01" || len(f.SHA256) != 64 {
+		t.Errorf("manifest entry = %+v", f)
+	}
+
+	// Unchanged documents are not downloaded again
+	out = run()
+	if _, downloaded = state(); out.Skipped != 2 || out.Downloaded != 0 || downloaded != 2 {
+		t.Errorf("second run = %+v after %d downloads, want both skipped", out, downloaded)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// dryRun is set by -dry-run. Commands then make their reads as usual but
+// only record their changes, and print the recorded requests instead of
+// their output.
+var dryRun *dryRunTransport
+
+// dryRunCommands are the commands that change data and support -dry-run
+var dryRunCommands = map[string]bool{
+	"apply":          true,
+	"add":            true,
+	"delete":         true,
+	"perms":          true,
+	"tag":            true,
+	"correspondents": true,
+	"watch":          true,
+	"check-ocr":      true,
+	"autoassign":     true,
+}
+
+// PlannedRequest is a request a command would have made without -dry-run
+type PlannedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Body   interface{} `json:"body,omitempty"`
+}
+
+// DryRunOutput is printed instead of a command's output with -dry-run
+type DryRunOutput struct {
+	DryRun   bool             `json:"dry_run"`
+	Requests []PlannedRequest `json:"requests"`
+}
+
+// dryRunTransport sends reads to next and answers everything else itself,
+// recording the request
+type dryRunTransport struct {
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	requests []PlannedRequest
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return t.next.RoundTrip(req)
+	}
+
+	planned := PlannedRequest{Method: req.Method, Path: req.URL.Path}
+	if req.URL.RawQuery != "" {
+		planned.Path += "?" + req.URL.RawQuery
+	}
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		planned.Body = plannedBody(req.Header.Get("Content-Type"), body)
+	}
+	t.mu.Lock()
+	t.requests = append(t.requests, planned)
+	t.mu.Unlock()
+
+	return dryRunResponse(req, body), nil
+}
+
+// plannedBody returns a request body for the dry run output: JSON as is,
+// and the fields and file names of a multipart upload
+func plannedBody(contentType string, body []byte) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if mediaType != "multipart/form-data" {
+		if json.Valid(body) {
+			return json.RawMessage(body)
+		}
+		return string(body)
+	}
+
+	fields := map[string]interface{}{}
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		value := part.FileName()
+		if value == "" {
+			data, _ := io.ReadAll(part)
+			value = string(data)
+		}
+		// Fields such as tags are repeated once per value
+		if prev, ok := fields[part.FormName()]; ok {
+			if list, ok := prev.([]string); ok {
+				fields[part.FormName()] = append(list, value)
+			} else {
+				fields[part.FormName()] = []string{prev.(string), value}
+			}
+		} else {
+			fields[part.FormName()] = value
+		}
+	}
+	return fields
+}
+
+// dryRunResponse is a plausible answer to a change, so commands carry on
+// as if it had been made: deletions succeed, uploads return a task ID and
+// updates return the object as sent, with the ID from the path.
+func dryRunResponse(req *http.Request, body []byte) *http.Response {
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Request:    req,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+	}
+
+	var data []byte
+	switch {
+	case req.Method == http.MethodDelete:
+		resp.StatusCode = http.StatusNoContent
+	case strings.HasSuffix(req.URL.Path, "/post_document/"):
+		data = []byte(`"dry-run"`)
+	default:
+		obj := map[string]interface{}{}
+		_ = json.Unmarshal(body, &obj)
+		if id, err := strconv.Atoi(path.Base(req.URL.Path)); err == nil {
+			obj["id"] = id
+		}
+		data, _ = json.Marshal(obj)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	resp.ContentLength = int64(len(data))
+	return resp
+}
+
+// writeDryRunOutput prints the requests recorded with -dry-run
+func writeDryRunOutput() error {
+	output := DryRunOutput{DryRun: true, Requests: dryRun.requests}
+	if output.Requests == nil {
+		output.Requests = []PlannedRequest{}
+	}
+	if err := render(os.Stdout, output, outputFormat, outputTemplate, plainOutput); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newDryRunServer serves tags and fails the test on any change
+func newDryRunServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method != http.MethodGet {
+			t.Errorf("dry run sent %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 1, "name": "invoice"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func runDryRun(t *testing.T, serverURL string, args ...string) (DryRunOutput, string, error) {
+	t.Helper()
+	cmd := exec.Command("./pgo", append([]string{"-dry-run"}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"PAPERLESS_URL="+serverURL,
+		"PAPERLESS_TOKEN=test-token",
+		"XDG_CACHE_HOME="+t.TempDir(),
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	var output DryRunOutput
+	if stdout.Len() > 0 {
+		if jsonErr := json.Unmarshal(stdout.Bytes(), &output); jsonErr != nil {
+			t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", jsonErr, stdout.String())
+		}
+	}
+	return output, stderr.String(), err
+}
+
+func TestCLI_DryRun(t *testing.T) {
+	server := newDryRunServer(t)
+
+	t.Run("delete", func(t *testing.T) {
+		// No --yes: a dry run does not ask
+		output, stderr, err := runDryRun(t, server.URL, "delete", "docs", "1", "2")
+		if err != nil {
+			t.Fatalf("Command failed: %v\nStderr: %s", err, stderr)
+		}
+		if strings.Contains(stderr, "[y/N]") {
+			t.Errorf("dry run asked for confirmation: %s", stderr)
+		}
+		want := []PlannedRequest{
+			{Method: "DELETE", Path: "/api/documents/1/"},
+			{Method: "DELETE", Path: "/api/documents/2/"},
+		}
+		if !output.DryRun || len(output.Requests) != 2 || output.Requests[0] != want[0] || output.Requests[1] != want[1] {
+			t.Errorf("output = %+v, want %+v", output, want)
+		}
+	})
+
+	t.Run("bulk apply", func(t *testing.T) {
+		ids := filepath.Join(t.TempDir(), "ids.txt")
+		os.WriteFile(ids, []byte("3\n4\n"), 0644)
+		output, stderr, err := runDryRun(t, server.URL, "apply", "docs", "--from-file", ids, "--tags", "invoice")
+		if err != nil {
+			t.Fatalf("Command failed: %v\nStderr: %s", err, stderr)
+		}
+		if len(output.Requests) != 1 {
+			t.Fatalf("requests = %+v, want one bulk edit", output.Requests)
+		}
+		req := output.Requests[0]
+		body, _ := json.Marshal(req.Body)
+		if req.Method != "POST" || req.Path != "/api/documents/bulk_edit/" || !strings.Contains(string(body), `"documents":[3,4]`) || !strings.Contains(string(body), `"add_tags":[1]`) {
+			t.Errorf("request = %s %s %s", req.Method, req.Path, body)
+		}
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "scan.pdf"), []byte("%PDF-1.4"), 0644)
+		output, stderr, err := runDryRun(t, server.URL, "watch", "-once", "-tags", "1", dir)
+		if err != nil {
+			t.Fatalf("Command failed: %v\nStderr: %s", err, stderr)
+		}
+		if len(output.Requests) != 1 || output.Requests[0].Path != "/api/documents/post_document/" {
+			t.Fatalf("requests = %+v, want one upload", output.Requests)
+		}
+		body := output.Requests[0].Body.(map[string]interface{})
+		if body["document"] != "scan.pdf" || body["tags"] != "1" {
+			t.Errorf("upload body = %v", body)
+		}
+		if _, err := os.Stat(filepath.Join(dir, defaultJournalName)); !os.IsNotExist(err) {
+			t.Errorf("dry run wrote the journal: %v", err)
+		}
+
+		if _, stderr, err := runDryRun(t, server.URL, "watch", dir); err == nil || !strings.Contains(stderr, "-dry-run needs -once") {
+			t.Errorf("expected -once error, got %v: %s", err, stderr)
+		}
+	})
+
+	t.Run("unsupported command", func(t *testing.T) {
+		_, stderr, err := runDryRun(t, server.URL, "export", "-dest", t.TempDir())
+		if err == nil || !strings.Contains(stderr, "-dry-run is not supported by pgo export") {
+			t.Errorf("expected unsupported error, got %v: %s", err, stderr)
+		}
+	})
+}
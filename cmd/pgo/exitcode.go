@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// Exit codes, so scripts can tell failures apart without parsing messages
+const (
+	exitFailure  = 1 // Any other error
+	exitUsage    = 2 // Invalid command, arguments, flags or configuration
+	exitAuth     = 3 // Missing or rejected token, or permission denied
+	exitNotFound = 4 // The requested object does not exist
+	exitServer   = 5 // The server failed (5xx)
+)
+
+// errorTypes name the exit codes in -json-errors output
+var errorTypes = map[int]string{
+	exitFailure:  "error",
+	exitUsage:    "usage",
+	exitAuth:     "auth",
+	exitNotFound: "not_found",
+	exitServer:   "server",
+}
+
+// exitCodeDescriptions describe the exit codes in the man page
+var exitCodeDescriptions = map[int]string{
+	exitFailure:  "Any other error",
+	exitUsage:    "Invalid command, arguments, flags or configuration",
+	exitAuth:     "Missing or rejected token, or permission denied",
+	exitNotFound: "The requested object does not exist",
+	exitServer:   "The server failed (5xx)",
+}
+
+var (
+	errNoURL   = usagef("paperless URL is required (use -url flag, PAPERLESS_URL env var or a config profile)")
+	errNoToken = errors.New("API token is required (use -token flag, PAPERLESS_TOKEN env var, a config profile or pgo login)")
+)
+
+// usageError is an error in how pgo was invoked
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string { return e.err.Error() }
+func (e *usageError) Unwrap() error { return e.err }
+
+// usagef formats a usageError
+func usagef(format string, args ...interface{}) error {
+	return &usageError{err: fmt.Errorf(format, args...)}
+}
+
+// exitCode returns the exit code for err
+func exitCode(err error) int {
+	var usageErr *usageError
+	var apiErr *paperless.Error
+	switch {
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.Is(err, errNoToken), paperless.IsUnauthorized(err), paperless.IsForbidden(err):
+		return exitAuth
+	case paperless.IsNotFound(err):
+		return exitNotFound
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= 500:
+		return exitServer
+	}
+	return exitFailure
+}
+
+// ErrorOutput is written to stderr for a failure with -json-errors
+type ErrorOutput struct {
+	Error    string `json:"error"`
+	Type     string `json:"type"` // usage, auth, not_found, server or error
+	ExitCode int    `json:"exit_code"`
+	// Status and Op describe the failed API request, if any
+	Status int    `json:"status,omitempty"`
+	Op     string `json:"op,omitempty"`
+}
+
+// reportError writes err to w, as a JSON line with jsonErrors, and returns
+// the exit code
+func reportError(w io.Writer, err error, jsonErrors bool) int {
+	code := exitCode(err)
+	if !jsonErrors {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		return code
+	}
+
+	output := ErrorOutput{Error: err.Error(), Type: errorTypes[code], ExitCode: code}
+	var apiErr *paperless.Error
+	if errors.As(err, &apiErr) {
+		output.Status = apiErr.StatusCode
+		output.Op = apiErr.Op
+	}
+	line, _ := json.Marshal(output)
+	fmt.Fprintf(w, "%s\n", line)
+	return code
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"usage", usagef("usage: pgo get <resource>"), exitUsage},
+		{"wrapped usage", fmt.Errorf("run: %w", usagef("parse flags: %w", errors.New("bad flag"))), exitUsage},
+		{"no token", errNoToken, exitAuth},
+		{"unauthorized", fmt.Errorf("failed: %w", &paperless.Error{StatusCode: 401}), exitAuth},
+		{"forbidden", &paperless.Error{StatusCode: 403}, exitAuth},
+		{"not found", fmt.Errorf("failed to get document: %w", &paperless.Error{StatusCode: 404}), exitNotFound},
+		{"server", &paperless.Error{StatusCode: 502}, exitServer},
+		{"validation", &paperless.Error{StatusCode: 400}, exitFailure},
+		{"other", errors.New("disk full"), exitFailure},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("%s: exitCode = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReportError(t *testing.T) {
+	err := fmt.Errorf("failed to get document: %w", &paperless.Error{StatusCode: 404, Message: "Not Found", Op: "GetDocument"})
+
+	var buf bytes.Buffer
+	if code := reportError(&buf, err, false); code != exitNotFound {
+		t.Errorf("code = %d, want %d", code, exitNotFound)
+	}
+	if buf.String() != "Error: failed to get document: GetDocument: 404 Not Found\n" {
+		t.Errorf("plain error = %q", buf.String())
+	}
+
+	buf.Reset()
+	reportError(&buf, err, true)
+	var output ErrorOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("invalid JSON error %q: %v", buf.String(), err)
+	}
+	want := ErrorOutput{Error: err.Error(), Type: "not_found", ExitCode: exitNotFound, Status: 404, Op: "GetDocument"}
+	if output != want {
+		t.Errorf("output = %+v, want %+v", output, want)
+	}
+}
+
+func TestCLI_ExitCodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/documents/1/":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/api/documents/2/":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"get"}, exitUsage},
+		{[]string{"frobnicate"}, exitUsage},
+		{[]string{"get", "docs", "abc"}, exitUsage},
+		{[]string{"get", "docs", "1"}, exitServer},
+		{[]string{"get", "docs", "2"}, exitAuth},
+		{[]string{"get", "docs", "99"}, exitNotFound},
+	}
+	for _, tt := range tests {
+		cmd := exec.Command("./pgo", append([]string{"-json-errors"}, tt.args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != tt.want {
+			t.Errorf("pgo %s: %v, want exit code %d", strings.Join(tt.args, " "), err, tt.want)
+			continue
+		}
+		var output ErrorOutput
+		if err := json.Unmarshal(stderr.Bytes(), &output); err != nil || output.ExitCode != tt.want {
+			t.Errorf("pgo %s: stderr %q is not a JSON error with exit code %d", strings.Join(tt.args, " "), stderr.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+	"github.com/jason-riddle/paperless-go/cmd/pgo/internal/cache"
+)
+
+// exportManifestVersion is bumped when the export layout changes
+const exportManifestVersion = 1
+
+// ExportedDocument is a document's metadata and the paths of its files,
+// relative to the export directory. It is written to the document's
+// metadata.json and listed in manifest.json.
+type ExportedDocument struct {
+	paperless.Document
+	OriginalPath string `json:"original_path"`
+	ArchivePath  string `json:"archive_path,omitempty"`
+}
+
+// ExportManifest is written to manifest.json at the end of an export
+type ExportManifest struct {
+	Version        int                       `json:"version"`
+	ExportedAt     string                    `json:"exported_at"`
+	Source         string                    `json:"source"`
+	Tags           []paperless.Tag           `json:"tags"`
+	Correspondents []paperless.Correspondent `json:"correspondents"`
+	DocumentTypes  []paperless.DocumentType  `json:"document_types"`
+	StoragePaths   []paperless.StoragePath   `json:"storage_paths"`
+	CustomFields   []paperless.CustomField   `json:"custom_fields"`
+	Documents      []ExportedDocument        `json:"documents"`
+}
+
+// ExportOutput is the summary of an export run
+type ExportOutput struct {
+	Dest       string   `json:"dest"`
+	Documents  int      `json:"documents"`
+	Downloaded int      `json:"downloaded"`
+	Skipped    int      `json:"skipped"`
+	Failed     int      `json:"failed"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// listAll fetches every page of a list endpoint
+func listAll[T any](ctx context.Context, list func(context.Context, *paperless.ListOptions) (*paperless.List[T], error)) ([]T, error) {
+	all := []T{}
+	opts := &paperless.ListOptions{PageSize: 100, Page: 1, Ordering: "id"}
+	for {
+		page, err := list(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page.Results...)
+		if page.Next == nil || *page.Next == "" {
+			return all, nil
+		}
+		opts.Page++
+	}
+}
+
+// exportDocumentDir returns the directory of a document, relative to the
+// export directory
+func exportDocumentDir(id int) string {
+	return filepath.Join("documents", strconv.Itoa(id))
+}
+
+// loadExportedDocument reads the metadata.json of a previous export. It
+// returns nil if the document was not exported completely.
+func loadExportedDocument(dest string, id int) *ExportedDocument {
+	data, err := os.ReadFile(filepath.Join(dest, exportDocumentDir(id), "metadata.json"))
+	if err != nil {
+		return nil
+	}
+	var exported ExportedDocument
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return nil
+	}
+	for _, path := range []string{exported.OriginalPath, exported.ArchivePath} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dest, path)); err != nil {
+			return nil
+		}
+	}
+	return &exported
+}
+
+// exportDocument downloads the original and archive files of doc and then
+// writes its metadata.json. metadata.json is written last, so a document
+// interrupted halfway is downloaded again by the next run. Downloaded bytes
+// are added to prog.
+func exportDocument(ctx context.Context, client *paperless.Client, dest string, doc paperless.Document, prog *progress) (*ExportedDocument, error) {
+	dir := exportDocumentDir(doc.ID)
+	if err := os.MkdirAll(filepath.Join(dest, dir), 0755); err != nil {
+		return nil, err
+	}
+
+	exported := &ExportedDocument{Document: doc}
+	exported.OriginalPath = filepath.Join(dir, "original"+strings.ToLower(filepath.Ext(doc.OriginalFileName)))
+	original, err := client.DownloadDocument(ctx, doc.ID, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download original: %w", err)
+	}
+	prog.add(0, int64(len(original.Data)))
+	if err := cache.WriteFileAtomic(filepath.Join(dest, exported.OriginalPath), original.Data); err != nil {
+		return nil, err
+	}
+
+	if doc.ArchivedFileName != "" {
+		exported.ArchivePath = filepath.Join(dir, "archive.pdf")
+		archive, err := client.DownloadDocument(ctx, doc.ID, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download archive: %w", err)
+		}
+		prog.add(0, int64(len(archive.Data)))
+		if err := cache.WriteFileAtomic(filepath.Join(dest, exported.ArchivePath), archive.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.WriteFileAtomic(filepath.Join(dest, dir, "metadata.json"), data); err != nil {
+		return nil, err
+	}
+	return exported, nil
+}
+
+// fetchExportMetadata fills in everything but the documents of a manifest
+func fetchExportMetadata(ctx context.Context, client *paperless.Client, manifest *ExportManifest) error {
+	var err error
+	if manifest.Tags, err = listAll(ctx, func(ctx context.Context, opts *paperless.ListOptions) (*paperless.List[paperless.Tag], error) {
+		list, err := client.ListTags(ctx, opts)
+		return (*paperless.List[paperless.Tag])(list), err
+	}); err != nil {
+		return fmt.Errorf("failed to fetch tags: %w", err)
+	}
+	if manifest.Correspondents, err = listAll(ctx, func(ctx context.Context, opts *paperless.ListOptions) (*paperless.List[paperless.Correspondent], error) {
+		list, err := client.ListCorrespondents(ctx, opts)
+		return (*paperless.List[paperless.Correspondent])(list), err
+	}); err != nil {
+		return fmt.Errorf("failed to fetch correspondents: %w", err)
+	}
+	if manifest.DocumentTypes, err = listAll(ctx, func(ctx context.Context, opts *paperless.ListOptions) (*paperless.List[paperless.DocumentType], error) {
+		list, err := client.ListDocumentTypes(ctx, opts)
+		return (*paperless.List[paperless.DocumentType])(list), err
+	}); err != nil {
+		return fmt.Errorf("failed to fetch document types: %w", err)
+	}
+	if manifest.StoragePaths, err = listAll(ctx, func(ctx context.Context, opts *paperless.ListOptions) (*paperless.List[paperless.StoragePath], error) {
+		list, err := client.ListStoragePaths(ctx, opts)
+		return (*paperless.List[paperless.StoragePath])(list), err
+	}); err != nil {
+		return fmt.Errorf("failed to fetch storage paths: %w", err)
+	}
+	if manifest.CustomFields, err = listAll(ctx, func(ctx context.Context, opts *paperless.ListOptions) (*paperless.List[paperless.CustomField], error) {
+		list, err := client.ListCustomFields(ctx, opts)
+		return (*paperless.List[paperless.CustomField])(list), err
+	}); err != nil {
+		// Custom fields were added in Paperless-ngx 2.0
+		if !paperless.IsNotFound(err) {
+			return fmt.Errorf("failed to fetch custom fields: %w", err)
+		}
+		manifest.CustomFields = []paperless.CustomField{}
+	}
+	return nil
+}
+
+func addExportFlags(fs *flag.FlagSet) (dest *string) {
+	return fs.String("dest", "", "Directory to export to; an earlier export there is resumed")
+}
+
+func runExport(client *paperless.Client, args []string, source string, pool workerPool) error {
+	exportFlags := flag.NewFlagSet("export", flag.ContinueOnError)
+	dest := addExportFlags(exportFlags)
+	if err := exportFlags.Parse(args); err != nil {
+		return usagef("parse export flags: %w", err)
+	}
+	if exportFlags.NArg() != 0 || *dest == "" {
+		return commandUsage("export")
+	}
+	if err := os.MkdirAll(filepath.Join(*dest, "documents"), 0755); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	manifest := ExportManifest{
+		Version:    exportManifestVersion,
+		ExportedAt: time.Now().Format(time.RFC3339),
+		Source:     source,
+		Documents:  []ExportedDocument{},
+	}
+	if err := fetchExportMetadata(ctx, client, &manifest); err != nil {
+		return err
+	}
+	// Cursoring by ID keeps documents uploaded or deleted meanwhile from
+	// shifting pages, which would skip or repeat documents
+	docs := []paperless.Document{}
+	listing := newProgress("Listing documents", 0)
+	it := client.IterDocuments(&paperless.ListOptions{PageSize: 100}, paperless.WithIDCursor())
+	for it.Next(ctx) {
+		listing.setTotal(it.Count())
+		docs = append(docs, it.Document())
+		listing.add(1, 0)
+	}
+	listing.finish()
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("failed to fetch documents: %w", err)
+	}
+
+	// Each document gets its own slot, so the manifest keeps the list order
+	// however the downloads finish
+	exported := make([]*ExportedDocument, len(docs))
+	skipped := make([]bool, len(docs))
+	prog := newProgress("Exporting", len(docs))
+	errs := pool.run(ctx, len(docs), func(ctx context.Context, i int) error {
+		doc := docs[i]
+		label := fmt.Sprintf("[%d/%d] #%d %s", i+1, len(docs), doc.ID, doc.Title)
+		defer prog.add(1, 0)
+
+		// A document is exported again only if it changed since
+		if prev := loadExportedDocument(*dest, doc.ID); prev != nil && prev.Modified.Time().Equal(doc.Modified.Time()) {
+			exported[i], skipped[i] = prev, true
+			prog.step("%s: unchanged", label)
+			return nil
+		}
+
+		var err error
+		exported[i], err = exportDocument(ctx, client, *dest, doc, prog)
+		if err != nil {
+			if ctx.Err() == nil {
+				prog.logf("%s: %v", label, err)
+			}
+			return err
+		}
+		prog.step("%s: downloaded", label)
+		return nil
+	})
+	prog.finish()
+	if ctx.Err() != nil {
+		return errors.New("export interrupted; run it again to resume")
+	}
+
+	output := ExportOutput{Dest: *dest, Documents: len(docs)}
+	for i, doc := range docs {
+		switch {
+		case errs[i] != nil:
+			output.Failed++
+			output.Errors = append(output.Errors, fmt.Sprintf("document %d: %v", doc.ID, errs[i]))
+		case skipped[i]:
+			manifest.Documents = append(manifest.Documents, *exported[i])
+			output.Skipped++
+		default:
+			manifest.Documents = append(manifest.Documents, *exported[i])
+			output.Downloaded++
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := cache.WriteFileAtomic(filepath.Join(*dest, "manifest.json"), data); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	if err := writeOutput(output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	if output.Failed > 0 {
+		return fmt.Errorf("failed to export %d of %d documents; run again to retry them", output.Failed, len(docs))
+	}
+	return nil
+}
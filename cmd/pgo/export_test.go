@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCLI_Export(t *testing.T) {
+	var mu sync.Mutex
+	modified := "2024-03-01T10:00:00Z"
+	failDownloads := false
+	downloads := 0
+	downloadCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return downloads
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 1, "name": "invoice"}]}`))
+		case "/api/correspondents/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 4, "name": "ACME"}]}`))
+		case "/api/document_types/", "/api/storage_paths/":
+			w.Write([]byte(`{"count": 0, "results": []}`))
+		case "/api/custom_fields/":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail": "Not found."}`))
+		case "/api/documents/":
+			if r.URL.Query().Get("page") == "2" {
+				fmt.Fprintf(w, `{"count": 2, "results": [{"id": 8, "title": "Scan", "modified": %q, "original_file_name": "scan.JPG"}]}`, modified)
+				return
+			}
+			fmt.Fprintf(w, `{"count": 2, "next": "http://example/api/documents/?page=2", "results": [{"id": 7, "title": "Invoice", "modified": %q, "tags": [1], "correspondent": 4, "original_file_name": "invoice.pdf", "archived_file_name": "2024 Invoice.pdf", "custom_fields": [{"field": 1, "value": "12.50"}]}]}`, modified)
+		case "/api/documents/7/download/", "/api/documents/8/download/":
+			downloads++
+			if failDownloads && r.URL.Path == "/api/documents/8/download/" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/octet-stream")
+			fmt.Fprintf(w, "%s original=%s", r.URL.Path, r.URL.Query().Get("original"))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "backup")
+	run := func(flags ...string) (ExportOutput, string, error) {
+		cmd := exec.Command("./pgo", append(flags, "export", "-dest", dest)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token")
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		var out ExportOutput
+		if stdout.Len() > 0 {
+			if jsonErr := json.Unmarshal(stdout.Bytes(), &out); jsonErr != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", jsonErr, stdout.String())
+			}
+		}
+		return out, stderr.String(), err
+	}
+
+	out, stderr, err := run()
+	if err != nil {
+		t.Fatalf("Command failed: %v\nStderr: %s", err, stderr)
+	}
+	if n := downloadCount(); out.Documents != 2 || out.Downloaded != 2 || out.Skipped != 0 || n != 3 {
+		t.Errorf("output = %+v after %d downloads, want 2 documents with 3 files", out, n)
+	}
+	for path, want := range map[string]string{
+		"documents/7/original.pdf": "/api/documents/7/download/ original=true",
+		"documents/7/archive.pdf":  "/api/documents/7/download/ original=",
+		"documents/8/original.jpg": "/api/documents/8/download/ original=true",
+	} {
+		if data, err := os.ReadFile(filepath.Join(dest, path)); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", path, data, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dest, "documents/8/archive.pdf")); !os.IsNotExist(err) {
+		t.Errorf("document without an archive version got archive.pdf: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dest, "manifest.json"))
+	if err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+	var manifest ExportManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	if manifest.Source != server.URL || len(manifest.Tags) != 1 || len(manifest.Correspondents) != 1 || manifest.CustomFields == nil {
+		t.Errorf("manifest = %+v", manifest)
+	}
+	if len(manifest.Documents) != 2 {
+		t.Fatalf("manifest lists %d documents, want 2", len(manifest.Documents))
+	}
+	doc := manifest.Documents[0]
+	if doc.ID != 7 || doc.OriginalPath != filepath.Join("documents", "7", "original.pdf") || doc.ArchivePath == "" || len(doc.CustomFields) != 1 {
+		t.Errorf("manifest document = %+v", doc)
+	}
+
+	if !strings.Contains(stderr, "[1/2] #7 Invoice: downloaded") {
+		t.Errorf("expected progress lines on stderr, got: %s", stderr)
+	}
+
+	// A second run skips the unchanged documents
+	before := downloadCount()
+	out, stderr, err = run("-quiet")
+	if n := downloadCount() - before; err != nil || out.Skipped != 2 || out.Downloaded != 0 || n != 0 {
+		t.Errorf("resumed export = %+v after %d downloads, %v, stderr: %s", out, n, err, stderr)
+	}
+	if stderr != "" {
+		t.Errorf("expected no progress with -quiet, got: %s", stderr)
+	}
+
+	// Changed documents are exported again; a failed one is reported
+	mu.Lock()
+	modified = "2024-03-02T10:00:00Z"
+	failDownloads = true
+	mu.Unlock()
+	out, stderr, err = run()
+	if err == nil || out.Downloaded != 1 || out.Failed != 1 || !strings.Contains(stderr, "failed to export 1 of 2 documents") {
+		t.Errorf("expected one failed document, got %+v, %v, stderr: %s", out, err, stderr)
+	}
+	data, _ = os.ReadFile(filepath.Join(dest, "manifest.json"))
+	manifest = ExportManifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil || len(manifest.Documents) != 1 {
+		t.Errorf("manifest after partial failure lists %d documents, %v", len(manifest.Documents), err)
+	}
+}
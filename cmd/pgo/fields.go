@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// docFieldNames are the fields of DocumentWithTagNames in output order
+var docFieldNames = []string{"id", "title", "content", "created", "modified", "added", "archive_serial_number", "original_file_name", "tags", "tag_names"}
+
+// docFields holds the field selection flags of get docs and search docs
+type docFields struct {
+	fields    *string
+	noContent *bool
+}
+
+func addDocFieldFlags(fs *flag.FlagSet) *docFields {
+	return &docFields{
+		fields:    fs.String("fields", "", "Comma-separated fields to output, e.g. id,title,tags (default: all but content)"),
+		noContent: fs.Bool("no-content", true, "Leave out the OCR content unless -fields lists it; -no-content=false includes it"),
+	}
+}
+
+// selected returns the fields to output, or nil for every field
+func (f *docFields) selected() ([]string, error) {
+	if *f.fields == "" {
+		if !*f.noContent {
+			return nil, nil
+		}
+		var fields []string
+		for _, name := range docFieldNames {
+			if name != "content" {
+				fields = append(fields, name)
+			}
+		}
+		return fields, nil
+	}
+	fields := splitList(*f.fields)
+	for _, name := range fields {
+		if !containsString(docFieldNames, name) {
+			return nil, usagef("unknown document field: %s (want %s)", name, strings.Join(docFieldNames, ", "))
+		}
+	}
+	return fields, nil
+}
+
+// DocumentFieldsOutput is DocumentListOutput with each result reduced to the
+// selected fields, in the order they were selected
+type DocumentFieldsOutput struct {
+	Count   int               `json:"count"`
+	Total   int               `json:"total"`
+	Results []json.RawMessage `json:"results"`
+}
+
+// selectDocFields reduces output to fields. A nil fields keeps it as is.
+func selectDocFields(output DocumentListOutput, fields []string) (interface{}, error) {
+	if fields == nil {
+		return output, nil
+	}
+	selected := DocumentFieldsOutput{Count: output.Count, Total: output.Total, Results: make([]json.RawMessage, len(output.Results))}
+	for i, doc := range output.Results {
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+		var b bytes.Buffer
+		b.WriteByte('{')
+		for j, name := range fields {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			key, _ := json.Marshal(name)
+			fmt.Fprintf(&b, "%s:%s", key, values[name])
+		}
+		b.WriteByte('}')
+		selected.Results[i] = b.Bytes()
+	}
+	return selected, nil
+}
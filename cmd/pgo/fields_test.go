@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCLI_GetDocsFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 1, "name": "tax"}]}`))
+		case "/api/documents/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 5, "title": "Receipt", "content": "lots of OCR text", "tags": [1]}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	stdout, stderr, err := run("get", "docs")
+	if err != nil {
+		t.Fatalf("get docs failed: %v\nStderr: %s", err, stderr)
+	}
+	if strings.Contains(stdout, "OCR") || !strings.Contains(stdout, `"tag_names"`) {
+		t.Errorf("expected every field but content by default, got %s", stdout)
+	}
+
+	stdout, stderr, err = run("get", "docs", "-no-content=false")
+	if err != nil {
+		t.Fatalf("get docs -no-content=false failed: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"content": "lots of OCR text"`) {
+		t.Errorf("expected content with -no-content=false, got %s", stdout)
+	}
+
+	stdout, stderr, err = run("-output-format", "csv", "get", "docs", "--fields", "id,title,tag_names")
+	if err != nil {
+		t.Fatalf("get docs --fields failed: %v\nStderr: %s", err, stderr)
+	}
+	if want := "id,title,tag_names\n5,Receipt,tax\n"; stdout != want {
+		t.Errorf("csv output = %q, want %q", stdout, want)
+	}
+
+	if _, stderr, err := run("get", "docs", "--fields", "id,owner"); err == nil || !strings.Contains(stderr, "unknown document field: owner") {
+		t.Errorf("expected unknown field error, got %v, stderr: %s", err, stderr)
+	}
+}
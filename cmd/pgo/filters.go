@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// docListFlags are the flags of get docs
+type docListFlags struct {
+	filters *docFilters
+	paging  *docPaging
+	fields  *docFields
+}
+
+func addDocListFlags(fs *flag.FlagSet) *docListFlags {
+	return &docListFlags{
+		filters: addDocFilterFlags(fs),
+		paging:  addDocPagingFlags(fs),
+		fields:  addDocFieldFlags(fs),
+	}
+}
+
+// searchDocsFlags are the flags of search docs
+type searchDocsFlags struct {
+	*docListFlags
+	titleOnly *bool
+}
+
+func addSearchDocsFlags(fs *flag.FlagSet) *searchDocsFlags {
+	return &searchDocsFlags{
+		docListFlags: addDocListFlags(fs),
+		titleOnly:    fs.Bool("title-only", false, "Search only document titles"),
+	}
+}
+
+// docFilters holds the document filter flags of get docs and search docs.
+// Filters are sent to Paperless, so they apply before pagination.
+type docFilters struct {
+	tags           *string
+	correspondents *string
+	doctypes       *string
+	createdAfter   *string
+	createdBefore  *string
+	since          *string
+	modifiedSince  *string
+	asn            *int64
+}
+
+func addDocFilterFlags(fs *flag.FlagSet) *docFilters {
+	return &docFilters{
+		tags:           fs.String("tag", "", "Only documents with all of these tags (comma-separated names or IDs)"),
+		correspondents: fs.String("correspondent", "", "Only documents from any of these correspondents (comma-separated names or IDs)"),
+		doctypes:       fs.String("doctype", "", "Only documents of any of these document types (comma-separated names or IDs)"),
+		createdAfter:   fs.String("created-after", "", "Only documents created after this date (YYYY-MM-DD)"),
+		createdBefore:  fs.String("created-before", "", "Only documents created before this date (YYYY-MM-DD)"),
+		since:          fs.String("since", "", "Only documents created on or after this date (YYYY-MM-DD)"),
+		modifiedSince:  fs.String("modified-since", "", "Only documents modified at or after this date (YYYY-MM-DD) or time (RFC 3339)"),
+		asn:            fs.Int64("asn", 0, "Only the document with this archive serial number"),
+	}
+}
+
+// apply resolves names to IDs and sets the filters on opts. Names are only
+// looked up for the filters that are set.
+func (f *docFilters) apply(ctx context.Context, client *paperless.Client, forceRefresh bool, opts *paperless.ListOptions) error {
+	if refs := splitList(*f.tags); len(refs) > 0 {
+		tagNames, err := getTagNamesWithCache(ctx, client, forceRefresh, DefaultCacheTTL)
+		if err != nil {
+			slog.Warn("Could not fetch tags for name resolution", "error", err)
+			tagNames = make(map[int]string)
+		}
+		if opts.TagIDs, err = resolveNamedRefs(refs, tagNames, "tag"); err != nil {
+			return err
+		}
+	}
+
+	if refs := splitList(*f.correspondents); len(refs) > 0 {
+		names, err := getCorrespondentNamesWithCache(ctx, client, forceRefresh, DefaultCacheTTL)
+		if err != nil {
+			slog.Warn("Could not fetch correspondents for name resolution", "error", err)
+			names = make(map[int]string)
+		}
+		if opts.CorrespondentIDs, err = resolveNamedRefs(refs, names, "correspondent"); err != nil {
+			return err
+		}
+	}
+
+	if refs := splitList(*f.doctypes); len(refs) > 0 {
+		names := make(map[int]string)
+		doctypes, err := listAll(ctx, func(ctx context.Context, opts *paperless.ListOptions) (*paperless.List[paperless.DocumentType], error) {
+			list, err := client.ListDocumentTypes(ctx, opts)
+			return (*paperless.List[paperless.DocumentType])(list), err
+		})
+		if err != nil {
+			slog.Warn("Could not fetch document types for name resolution", "error", err)
+		}
+		for _, dt := range doctypes {
+			names[dt.ID] = dt.Name
+		}
+		if opts.DocumentTypeIDs, err = resolveNamedRefs(refs, names, "document type"); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if opts.CreatedAfter, err = parseFilterDate("created-after", *f.createdAfter); err != nil {
+		return err
+	}
+	if opts.CreatedBefore, err = parseFilterDate("created-before", *f.createdBefore); err != nil {
+		return err
+	}
+	if opts.CreatedSince, err = parseFilterDate("since", *f.since); err != nil {
+		return err
+	}
+	if opts.ModifiedSince, err = parseFilterTime("modified-since", *f.modifiedSince); err != nil {
+		return err
+	}
+	if *f.asn < 0 {
+		return fmt.Errorf("invalid -asn %d", *f.asn)
+	}
+	opts.ArchiveSerialNumber = *f.asn
+	return nil
+}
+
+// parseFilterDate parses a YYYY-MM-DD date flag. An empty value is the zero
+// time, meaning no filter.
+func parseFilterDate(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -%s date %q, want YYYY-MM-DD", name, value)
+	}
+	return t, nil
+}
+
+// parseFilterTime parses a flag that takes a YYYY-MM-DD date, meaning its
+// start in local time, or an RFC 3339 time such as the one a sync script
+// saved on its last run. An empty value is the zero time, meaning no filter.
+func parseFilterTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -%s time %q, want YYYY-MM-DD or RFC 3339", name, value)
+	}
+	return t, nil
+}
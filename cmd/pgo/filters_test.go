@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCLI_DocFilters(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		query url.Values
+	)
+	// sentQuery returns the query of the last documents request and resets it
+	sentQuery := func() url.Values {
+		mu.Lock()
+		defer mu.Unlock()
+		q := query
+		query = nil
+		return q
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags/":
+			w.Write([]byte(`{"count": 2, "results": [{"id": 1, "name": "invoice"}, {"id": 2, "name": "paid"}]}`))
+		case "/api/correspondents/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 4, "name": "ACME"}]}`))
+		case "/api/document_types/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 9, "name": "Receipt"}]}`))
+		case "/api/documents/":
+			mu.Lock()
+			query = r.URL.Query()
+			mu.Unlock()
+			w.Write([]byte(`{"count": 0, "results": []}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command("./pgo", args...)
+		cmd.Env = append(os.Environ(),
+			"PAPERLESS_URL="+server.URL,
+			"PAPERLESS_TOKEN=test-token",
+			"XDG_CACHE_HOME="+t.TempDir(),
+		)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		return stderr.String(), err
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want map[string]string
+	}{
+		{
+			name: "get docs",
+			args: []string{"get", "docs", "-tag", "invoice,2", "-correspondent", "acme", "-doctype", "Receipt", "-created-after", "2024-01-01", "-created-before", "2024-12-31", "-asn", "42"},
+			want: map[string]string{
+				"tags__id__all":         "1,2",
+				"correspondent__id__in": "4",
+				"document_type__id__in": "9",
+				"created__date__gt":     "2024-01-01",
+				"created__date__lt":     "2024-12-31",
+				"archive_serial_number": "42",
+			},
+		},
+		{
+			name: "search docs",
+			args: []string{"search", "docs", "-title-only", "-doctype", "9", "-created-after", "2023-06-01", "lease"},
+			want: map[string]string{
+				"title__icontains":      "lease",
+				"document_type__id__in": "9",
+				"created__date__gt":     "2023-06-01",
+				"tags__id__all":         "",
+			},
+		},
+		{
+			name: "since",
+			args: []string{"get", "docs", "--since", "2024-01-01", "--modified-since", "2024-03-05T22:30:00Z"},
+			want: map[string]string{
+				"created__date__gte": "2024-01-01",
+				"modified__gte":      "2024-03-05T22:30:00Z",
+				"created__date__gt":  "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sentQuery()
+			if stderr, err := run(tt.args...); err != nil {
+				t.Fatalf("Command failed: %v\nStderr: %s", err, stderr)
+			}
+			query := sentQuery()
+			for param, want := range tt.want {
+				if got := query.Get(param); got != want {
+					t.Errorf("%s = %q, want %q", param, got, want)
+				}
+			}
+		})
+	}
+
+	for _, args := range [][]string{
+		{"get", "docs", "-tag", "unknown"},
+		{"get", "docs", "-created-after", "01/02/2024"},
+		{"get", "docs", "-modified-since", "yesterday"},
+		{"search", "docs", "-asn", "-1", "lease"},
+	} {
+		sentQuery()
+		if stderr, err := run(args...); err == nil || sentQuery() != nil {
+			t.Errorf("%s: expected an error before listing documents, got %v, stderr: %s", strings.Join(args, " "), err, stderr)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// allowProtectedWrites is set by -yes-i-mean-it
+var allowProtectedWrites bool
+
+// writeCommands are the commands that only change data. They are refused
+// on a protected profile before they start, instead of at their first
+// change, e.g. after delete asked for confirmation.
+var writeCommands = map[string]bool{
+	"add":    true,
+	"apply":  true,
+	"delete": true,
+	"watch":  true,
+}
+
+// checkProtectedProfile returns an error if profile p is labeled readonly
+// or production and -yes-i-mean-it was not given
+func checkProtectedProfile(p Profile) error {
+	if allowProtectedWrites || (!p.ReadOnly && !p.Production) {
+		return nil
+	}
+	label := "production"
+	if p.ReadOnly {
+		label = "readonly"
+	}
+	return usagef("profile %q is labeled %s: pass -yes-i-mean-it before the command to change its data, or -dry-run to see what would change", p.Name, label)
+}
+
+// protectedWriteGuard returns a request hook that refuses the requests that
+// change data if checkProtectedProfile fails for p, or nil if changes are
+// allowed. It covers the commands that change data only with some flags or
+// answers, such as tag tree or perms, which can still read.
+func protectedWriteGuard(p Profile) paperless.RequestHook {
+	if err := checkProtectedProfile(p); err == nil {
+		return nil
+	}
+	return func(req *http.Request) error {
+		switch req.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return nil
+		}
+		return checkProtectedProfile(p)
+	}
+}
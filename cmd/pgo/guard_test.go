@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCLI_ProtectedProfile(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		deletes int
+	)
+	deleted := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return deletes
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/tags/":
+			w.Write([]byte(`{"count": 0, "results": []}`))
+		case r.URL.Path == "/api/documents/5/" && r.Method == "GET":
+			w.Write([]byte(`{"id": 5, "title": "Invoice"}`))
+		case r.URL.Path == "/api/documents/next_asn/":
+			w.Write([]byte(`7`))
+		case r.URL.Path == "/api/documents/5/" && r.Method == "DELETE":
+			mu.Lock()
+			deletes++
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	configHome := t.TempDir()
+	config := "[profiles.prod]\nurl = \"" + server.URL + "\"\ntoken = \"t\"\nproduction = true\n\n" +
+		"[profiles.archive]\nurl = \"" + server.URL + "\"\ntoken = \"t\"\nreadonly = true\n"
+	if err := os.MkdirAll(filepath.Join(configHome, "paperless-go"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configHome, "paperless-go", "config.toml"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (string, string, int) {
+		cmd := exec.Command("./pgo", append([]string{"-memory"}, args...)...)
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+configHome, "XDG_CACHE_HOME="+t.TempDir(),
+			"PAPERLESS_URL=", "PAPERLESS_TOKEN=", "PAPERLESS_PROFILE=")
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		cmd.Run()
+		return stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()
+	}
+
+	// Reads are allowed
+	if stdout, stderr, code := run("-profile", "prod", "get", "docs", "5"); code != 0 || !strings.Contains(stdout, "Invoice") {
+		t.Errorf("get docs on production profile: exit %d, stdout %s, stderr %s", code, stdout, stderr)
+	}
+
+	for _, profile := range []string{"prod", "archive"} {
+		_, stderr, code := run("-profile", profile, "delete", "docs", "5", "--yes")
+		if code == 0 || !strings.Contains(stderr, "pass -yes-i-mean-it") {
+			t.Errorf("delete on %s profile: exit %d, stderr %s", profile, code, stderr)
+		}
+	}
+	if _, stderr, _ := run("-profile", "archive", "delete", "docs", "5", "--yes"); !strings.Contains(stderr, "labeled readonly") {
+		t.Errorf("expected readonly label in error, stderr: %s", stderr)
+	}
+	// Commands that may only read are refused at their first change
+	if _, stderr, code := run("-profile", "prod", "asn", "assign", "5"); code != exitUsage || !strings.Contains(stderr, "labeled production") {
+		t.Errorf("asn assign on production profile: exit %d, stderr %s", code, stderr)
+	}
+	if n := deleted(); n != 0 {
+		t.Fatalf("documents deleted on a protected profile: %d", n)
+	}
+
+	if _, stderr, code := run("-profile", "prod", "-yes-i-mean-it", "delete", "docs", "5", "--yes"); code != 0 || deleted() != 1 {
+		t.Errorf("delete with -yes-i-mean-it: exit %d, deletes %d, stderr %s", code, deleted(), stderr)
+	}
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// InitOutput is the result of init
+type InitOutput struct {
+	Config     string `json:"config"`
+	Profile    string `json:"profile"`
+	URL        string `json:"url"`
+	Default    bool   `json:"default"`
+	Version    string `json:"version,omitempty"`    // Paperless version, if the server sends it
+	Completion string `json:"completion,omitempty"` // Installed completion script
+}
+
+// prompter asks questions on out and reads the answers from in
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	tty *os.File // in, if it is a terminal, to hide secrets
+}
+
+// ask returns the answer to question, or def if the answer is empty
+func (p *prompter) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			fmt.Fprintln(p.out)
+			if def != "" {
+				return def, nil
+			}
+			return "", fmt.Errorf("no answer to %q", question)
+		}
+		return "", err
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// askSecret is ask without echoing the answer on a terminal
+func (p *prompter) askSecret(question string) (string, error) {
+	if p.tty != nil {
+		if saved, err := stty(p.tty, "-g"); err == nil {
+			if _, err := stty(p.tty, "-echo"); err == nil {
+				defer func() {
+					stty(p.tty, saved)
+					fmt.Fprintln(p.out)
+				}()
+			}
+		}
+	}
+	return p.ask(question, "")
+}
+
+// confirm asks a yes/no question. No answer, even at the end of the input,
+// is def.
+func (p *prompter) confirm(question string, def bool) bool {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	answer, err := p.ask(question+" ("+hint+")", "")
+	if err != nil || answer == "" {
+		return def
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// normalizeURL adds https:// to a URL without a scheme and drops a
+// trailing slash
+func normalizeURL(raw string) (string, error) {
+	raw = strings.TrimRight(strings.TrimSpace(raw), "/")
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("invalid Paperless URL %q (e.g. https://paperless.example.com)", raw)
+	}
+	return raw, nil
+}
+
+// writeProfile adds profile p to the config file at path, creating it, and
+// with makeDefault sets default_profile to it. The file is edited as text,
+// so comments and other profiles are kept. A profile without a token gets
+// no token line, so the token stored by pgo login is used.
+func writeProfile(path string, p Profile, makeDefault bool) error {
+	lines, err := readConfigLines(path)
+	if err != nil {
+		return err
+	}
+
+	if makeDefault {
+		defaultLine := "default_profile = " + strconv.Quote(p.Name)
+		replaced := false
+		for i, line := range lines {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "[") {
+				break // default_profile belongs to the root table
+			}
+			if key, _, ok := strings.Cut(trimmed, "="); ok && strings.TrimSpace(key) == "default_profile" {
+				lines[i], replaced = defaultLine, true
+				break
+			}
+		}
+		if !replaced {
+			lines = append([]string{defaultLine, ""}, lines...)
+		}
+	}
+
+	if len(lines) > 0 && lines[len(lines)-1] != "" {
+		lines = append(lines, "")
+	}
+	lines = append(lines,
+		"[profiles."+p.Name+"]",
+		"url = "+strconv.Quote(p.URL),
+	)
+	if p.Token != "" {
+		lines = append(lines, "token = "+strconv.Quote(p.Token))
+	}
+	return writeConfigLines(path, lines)
+}
+
+// readConfigLines returns the lines of the config file at path, or none if
+// it does not exist
+func readConfigLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n"), nil
+}
+
+// writeConfigLines replaces the config file at path with lines. It holds
+// tokens, so it is made readable by its owner only.
+func writeConfigLines(path string, lines []string) error {
+	content := strings.Join(lines, "\n") + "\n"
+
+	// Never write a config that pgo can't read back
+	if _, err := parseConfig(strings.NewReader(content)); err != nil {
+		return fmt.Errorf("update config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(path, 0600); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return nil
+}
+
+// completionPath returns where shell's completion script is installed:
+// for bash, the directory bash-completion loads scripts from on demand
+func completionPath(shell string) (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("get home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	if shell == "bash" {
+		return filepath.Join(dataHome, "bash-completion", "completions", "pgo"), nil
+	}
+	return filepath.Join(dataHome, "paperless-go", "completion.zsh"), nil
+}
+
+// installCompletion writes the completion script for shell
+func installCompletion(shell string) (string, error) {
+	path, err := completionPath(shell)
+	if err != nil {
+		return "", err
+	}
+	var script strings.Builder
+	if err := runCompletion(&script, []string{shell}); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("create completion directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(script.String()), 0644); err != nil {
+		return "", fmt.Errorf("write completion: %w", err)
+	}
+	return path, nil
+}
+
+// askToken asks for an API token, or for a username and password to log in
+// to the instance at baseURL and get one
+func askToken(ctx context.Context, p *prompter, baseURL string) (string, error) {
+	token, err := p.askSecret("API token (empty to log in with your username and password)")
+	if err != nil || token != "" {
+		return token, err
+	}
+	username, err := p.ask("Username", "")
+	if err != nil {
+		return "", err
+	}
+	password, err := p.askSecret("Password")
+	if err != nil {
+		return "", err
+	}
+	token, err = paperless.NewClient(baseURL, "", paperless.WithTimeout(30*time.Second)).RequestToken(ctx, username, password)
+	if paperless.IsValidation(err) {
+		return "", errors.New("login failed: wrong username or password")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to log in: %w", err)
+	}
+	return token, nil
+}
+
+// checkToken connects to the instance at baseURL with token and reports the
+// server version on p
+func checkToken(ctx context.Context, p *prompter, baseURL, token string) (*paperless.Capabilities, error) {
+	client := paperless.NewClient(baseURL, token, paperless.WithTimeout(30*time.Second), paperless.WithAuthCheck())
+	caps, err := client.Capabilities(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", baseURL, err)
+	}
+	if caps.Version != "" {
+		fmt.Fprintf(p.out, "Connected to Paperless-ngx %s\n", caps.Version)
+	} else {
+		fmt.Fprintln(p.out, "Connected")
+	}
+	return caps, nil
+}
+
+// newPrompter returns a prompter on stdin and stderr
+func newPrompter() *prompter {
+	p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stderr}
+	if isTerminal(os.Stdin) {
+		p.tty = os.Stdin
+	}
+	return p
+}
+
+// runInit sets up a profile interactively: it asks for the URL and a token,
+// or logs in to get one, checks that they work and writes the config file.
+// defaults are the URL, token and profile given by flags or environment.
+func runInit(configPath string, args []string, defaults settings) error {
+	if len(args) != 0 {
+		return commandUsage("init")
+	}
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	p := newPrompter()
+	fmt.Fprintf(p.out, "Setting up pgo in %s\n", configPath)
+
+	var baseURL string
+	for baseURL == "" {
+		answer, err := p.ask("Paperless URL", defaults.URL)
+		if err != nil {
+			return err
+		}
+		if baseURL, err = normalizeURL(answer); err != nil {
+			fmt.Fprintln(p.out, err)
+		}
+	}
+
+	// No overall timeout: answering the questions may take a while
+	ctx := context.Background()
+
+	token := defaults.Token
+	if token != "" {
+		fmt.Fprintln(p.out, "Using the token from -token or PAPERLESS_TOKEN")
+	} else if token, err = askToken(ctx, p, baseURL); err != nil {
+		return err
+	}
+	caps, err := checkToken(ctx, p, baseURL, token)
+	if err != nil {
+		return err
+	}
+
+	name := defaults.Profile.Name
+	if name == "" {
+		name = "default"
+	}
+	for {
+		answer, err := p.ask("Profile name", name)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !bareKey.MatchString(answer):
+			fmt.Fprintln(p.out, "Use letters, digits, _ and - only")
+			continue
+		case cfg.Profiles[answer] != nil:
+			fmt.Fprintf(p.out, "Profile %q already exists; choose another name or edit the config file\n", answer)
+			continue
+		}
+		name = answer
+		break
+	}
+
+	makeDefault := len(cfg.Profiles) == 0 || p.confirm(fmt.Sprintf("Use %s by default", name), false)
+	if err := writeProfile(configPath, Profile{Name: name, URL: baseURL, Token: token}, makeDefault); err != nil {
+		return err
+	}
+	fmt.Fprintf(p.out, "Saved profile %s\n", name)
+
+	output := InitOutput{Config: configPath, Profile: name, URL: baseURL, Default: makeDefault, Version: caps.Version}
+	if shell := filepath.Base(os.Getenv("SHELL")); shell == "bash" || shell == "zsh" {
+		if p.confirm("Install "+shell+" completion", true) {
+			path, err := installCompletion(shell)
+			if err != nil {
+				slog.Warn("Could not install completion", "error", err)
+			} else {
+				output.Completion = path
+				if shell == "zsh" {
+					fmt.Fprintf(p.out, "Add this line to ~/.zshrc to load it:\n  source %s\n", path)
+				} else {
+					fmt.Fprintln(p.out, "Completion is loaded by bash-completion in new shells")
+				}
+			}
+		}
+	}
+
+	if err := writeOutput(output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "paperless-go", "config.toml")
+
+	if err := writeProfile(path, Profile{Name: "home", URL: "https://home.example", Token: "t1"}, true); err != nil {
+		t.Fatalf("writeProfile failed: %v", err)
+	}
+	existing := "# my profiles\n" + mustRead(t, path)
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeProfile(path, Profile{Name: "work", URL: "https://work.example", Token: `a"b`}, true); err != nil {
+		t.Fatalf("writeProfile failed: %v", err)
+	}
+
+	content := mustRead(t, path)
+	if !strings.HasPrefix(content, "# my profiles\ndefault_profile = \"work\"\n") {
+		t.Errorf("comment not kept or default not replaced:\n%s", content)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if cfg.DefaultProfile != "work" || cfg.Profiles["home"].Token != "t1" || cfg.Profiles["work"].Token != `a"b` {
+		t.Errorf("config = %+v, home %+v, work %+v", cfg, cfg.Profiles["home"], cfg.Profiles["work"])
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("config mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+}
+
+func mustRead(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestNormalizeURL(t *testing.T) {
+	for in, want := range map[string]string{
+		"paperless.local:8000":          "https://paperless.local:8000",
+		" http://192.168.1.5:8000/ ":    "http://192.168.1.5:8000",
+		"https://example.com/paperless": "https://example.com/paperless",
+	} {
+		if got, err := normalizeURL(in); err != nil || got != want {
+			t.Errorf("normalizeURL(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := normalizeURL("ftp://example.com"); err == nil {
+		t.Error("normalizeURL accepted ftp")
+	}
+}
+
+func TestCLI_Init(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/token/":
+			w.Write([]byte(`{"token": "from-login"}`))
+		case "/api/tags/":
+			if r.Header.Get("Authorization") != "Token from-login" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"detail": "Invalid token."}`))
+				return
+			}
+			w.Write([]byte(`{"count": 0, "results": []}`))
+		case "/api/":
+			w.Header().Set("X-Version", "2.3.3")
+			w.Write([]byte(`{"documents": "x"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	configHome, dataHome := t.TempDir(), t.TempDir()
+	run := func(stdin string) (string, string, error) {
+		cmd := exec.Command("./pgo", "init")
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+configHome, "XDG_DATA_HOME="+dataHome, "SHELL=/bin/bash",
+			"PAPERLESS_URL=", "PAPERLESS_TOKEN=", "PAPERLESS_PROFILE=")
+		cmd.Stdin = strings.NewReader(stdin)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	// URL, empty token, username, password, profile name, completion
+	stdout, stderr, err := run(server.URL + "\n\nalice\nsecret\nhome\ny\n")
+	if err != nil {
+		t.Fatalf("init failed: %v\nStderr: %s", err, stderr)
+	}
+	var out InitOutput
+	if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, stdout)
+	}
+	configPath := filepath.Join(configHome, "paperless-go", "config.toml")
+	if out.Profile != "home" || !out.Default || out.Version != "2.3.3" || out.Config != configPath {
+		t.Errorf("output = %+v", out)
+	}
+	cfg, err := loadConfig(configPath)
+	if err != nil || cfg.DefaultProfile != "home" || cfg.Profiles["home"].Token != "from-login" {
+		t.Errorf("config = %+v, %v", cfg, err)
+	}
+	if out.Completion == "" || !strings.Contains(mustRead(t, out.Completion), "complete") {
+		t.Errorf("completion not installed: %+v", out)
+	}
+
+	// A wrong token is not saved; the name of an existing profile is asked again
+	if _, stderr, err := run(server.URL + "\nwrong\n"); err == nil || !strings.Contains(stderr, "failed to connect") {
+		t.Errorf("expected connection error, got %v, stderr: %s", err, stderr)
+	}
+	_, stderr, err = run(server.URL + "\nfrom-login\nhome\nwork\n\nn\n")
+	if err != nil {
+		t.Fatalf("second init failed: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, `Profile "home" already exists`) {
+		t.Errorf("expected existing profile to be refused, stderr: %s", stderr)
+	}
+	if cfg, err := loadConfig(configPath); err != nil || cfg.DefaultProfile != "home" || cfg.Profiles["work"] == nil {
+		t.Errorf("config = %+v, %v", cfg, err)
+	}
+}
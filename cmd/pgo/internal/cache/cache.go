@@ -0,0 +1,263 @@
+// Package cache keeps data fetched from a Paperless instance on disk, so that
+// pgo commands need not fetch it again until it is stale. Each instance has
+// its own directory, and a Store can keep its caches in memory only.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BaseDir returns the directory of all caches, preferring XDG_CACHE_HOME
+func BaseDir() (string, error) {
+	if cacheHome := os.Getenv("XDG_CACHE_HOME"); cacheHome != "" {
+		return filepath.Join(cacheHome, "paperless-go"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home directory: %w", err)
+	}
+	return filepath.Join(home, ".cache", "paperless-go"), nil
+}
+
+// Namespace returns the cache directory name of the instance at rawURL: its
+// host and path, readable, plus a hash of the URL so that instances whose
+// names sanitize alike still get their own directory
+func Namespace(rawURL string) string {
+	normalized := strings.TrimRight(rawURL, "/")
+	name := normalized
+	if u, err := url.Parse(normalized); err == nil && u.Host != "" {
+		u.Scheme, u.Host = strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+		normalized = u.String()
+		name = u.Host + u.Path
+	}
+	name = strings.Trim(strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, name), "_")
+	sum := sha256.Sum256([]byte(normalized))
+	return name + "-" + hex.EncodeToString(sum[:4])
+}
+
+// Store holds the caches of one Paperless instance. If a cache cannot be
+// written to disk, the Store falls back to keeping all its caches in memory
+// for the rest of the process and says so on its logger.
+type Store struct {
+	instance string
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	memory  bool
+	entries map[string]interface{} // In-memory entries by file name
+}
+
+// Open returns the Store of the instance at baseURL, or the shared one if
+// baseURL is "". With memory set, caches are kept in memory only and
+// nothing is read from or written to disk.
+func Open(baseURL string, memory bool) *Store {
+	s := &Store{memory: memory, entries: map[string]interface{}{}}
+	if baseURL != "" {
+		s.instance = Namespace(baseURL)
+	}
+	return s
+}
+
+// SetLogger sets the logger s reports failures to, slog.Default() by
+// default
+func (s *Store) SetLogger(l *slog.Logger) {
+	s.logger = l
+}
+
+// log returns the logger of s
+func (s *Store) log() *slog.Logger {
+	if s.logger != nil {
+		return s.logger
+	}
+	return slog.Default()
+}
+
+// Dir returns the directory of the Store's cache files. It is resolved on
+// each call, so a change of XDG_CACHE_HOME takes effect.
+func (s *Store) Dir() (string, error) {
+	dir, err := BaseDir()
+	if err != nil {
+		return "", err
+	}
+	if s.instance != "" {
+		dir = filepath.Join(dir, "instances", s.instance)
+	}
+	return dir, nil
+}
+
+// Memory reports whether s keeps its caches in memory only, because it was
+// opened so or because writing to disk failed
+func (s *Store) Memory() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.memory
+}
+
+// fallBack switches s to memory after err, reporting it as what failed
+func (s *Store) fallBack(what string, err error) {
+	s.log().Warn("Could not "+what, "error", err)
+	s.log().Info("Using in-memory cache as fallback")
+	s.memory = true
+}
+
+// Entry is cached data and when it was fetched
+type Entry[T any] struct {
+	Data      T
+	FetchedAt time.Time
+}
+
+// Stale reports whether e is missing or older than ttl
+func (e *Entry[T]) Stale(ttl time.Duration) bool {
+	return e == nil || time.Since(e.FetchedAt) > ttl
+}
+
+// Cache is one cache file of a Store. The file is a JSON object with the
+// data under a key, e.g. {"tags": {...}, "fetched_at": "..."}.
+type Cache[T any] struct {
+	store *Store
+	file  string
+	key   string
+}
+
+// New returns the cache of store in the file named file, with the data
+// under key
+func New[T any](store *Store, file, key string) *Cache[T] {
+	return &Cache[T]{store: store, file: file, key: key}
+}
+
+// Path returns the path of the cache file
+func (c *Cache[T]) Path() (string, error) {
+	dir, err := c.store.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, c.file), nil
+}
+
+// Load returns the cached entry, or nil if there is none. An unreadable
+// file is treated as missing, so a corrupt cache is simply fetched again.
+func (c *Cache[T]) Load() (*Entry[T], error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	if c.store.memory {
+		entry, _ := c.store.entries[c.file].(*Entry[T])
+		return entry, nil
+	}
+
+	path, err := c.Path()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read cache file: %w", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields[c.key] == nil {
+		return nil, nil
+	}
+	var entry Entry[T]
+	if json.Unmarshal(fields[c.key], &entry.Data) != nil || json.Unmarshal(fields["fetched_at"], &entry.FetchedAt) != nil {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// Save stores data as fetched now. Errors are not fatal: they are reported
+// and the Store falls back to memory.
+func (c *Cache[T]) Save(data T) {
+	entry := &Entry[T]{Data: data, FetchedAt: time.Now()}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	c.store.entries[c.file] = entry
+	if c.store.memory {
+		return
+	}
+
+	path, err := c.Path()
+	if err != nil {
+		c.store.fallBack("determine cache path", err)
+		return
+	}
+	encoded, err := json.MarshalIndent(map[string]interface{}{c.key: data, "fetched_at": entry.FetchedAt}, "", "  ")
+	if err != nil {
+		c.store.log().Warn("Could not marshal cache data", "error", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		c.store.fallBack("create cache directory", err)
+		return
+	}
+	unlock, err := LockFile(path + ".lock")
+	if err != nil {
+		c.store.fallBack("lock cache file", err)
+		return
+	}
+	defer unlock()
+	if err := WriteFileAtomic(path, encoded); err != nil {
+		c.store.fallBack("write cache file", err)
+	}
+}
+
+// Get returns the cached data unless it is older than ttl or refresh is
+// set, and otherwise the data fetch returns, which it saves
+func (c *Cache[T]) Get(ttl time.Duration, refresh bool, fetch func() (T, error)) (T, error) {
+	if !refresh {
+		entry, err := c.Load()
+		if err != nil {
+			// Fetch instead
+			c.store.log().Warn("Could not load cache", "error", err)
+		} else if !entry.Stale(ttl) {
+			return entry.Data, nil
+		}
+	}
+
+	data, err := fetch()
+	if err != nil {
+		return data, err
+	}
+	c.Save(data)
+	return data, nil
+}
+
+// Remove deletes the cache file, its lock and the temporary files left by
+// interrupted writes, and returns the paths it removed
+func (c *Cache[T]) Remove() ([]string, error) {
+	path, err := c.Path()
+	if err != nil {
+		return nil, err
+	}
+	temps, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".*"))
+	var removed []string
+	for _, p := range append([]string{path, path + ".lock"}, temps...) {
+		if err := os.Remove(p); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return removed, fmt.Errorf("failed to remove %s: %w", p, err)
+		}
+		removed = append(removed, p)
+	}
+	return removed, nil
+}
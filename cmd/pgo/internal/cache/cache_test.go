@@ -0,0 +1,345 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// unwritableCacheHome sets XDG_CACHE_HOME below a regular file, where no
+// cache directory can be created, even by root
+func unwritableCacheHome(t *testing.T) {
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(blocker, "cache"))
+}
+
+func TestBaseDir(t *testing.T) {
+	t.Run("uses XDG_CACHE_HOME when set", func(t *testing.T) {
+		t.Setenv("XDG_CACHE_HOME", "/tmp/test-cache")
+		dir, err := BaseDir()
+		if err != nil {
+			t.Fatalf("BaseDir failed: %v", err)
+		}
+		if want := filepath.Join("/tmp/test-cache", "paperless-go"); dir != want {
+			t.Errorf("dir = %v, want %v", dir, want)
+		}
+	})
+
+	t.Run("falls back to ~/.cache when XDG_CACHE_HOME not set", func(t *testing.T) {
+		t.Setenv("XDG_CACHE_HOME", "")
+		dir, err := BaseDir()
+		if err != nil {
+			t.Fatalf("BaseDir failed: %v", err)
+		}
+		home, _ := os.UserHomeDir()
+		if want := filepath.Join(home, ".cache", "paperless-go"); dir != want {
+			t.Errorf("dir = %v, want %v", dir, want)
+		}
+	})
+}
+
+func TestNamespace(t *testing.T) {
+	a := Namespace("https://paperless.example.com")
+	if a != Namespace("https://Paperless.example.com/") {
+		t.Errorf("equivalent URLs got different namespaces")
+	}
+	if !strings.HasPrefix(a, "paperless.example.com-") {
+		t.Errorf("namespace %q does not start with the host", a)
+	}
+	for _, other := range []string{"http://paperless.example.com", "https://paperless.example.com/archive", "https://paperless.example.com:8443"} {
+		if Namespace(other) == a {
+			t.Errorf("%s shares the namespace of https://paperless.example.com", other)
+		}
+	}
+	if ns := Namespace("http://10.0.0.5:8000/paperless"); strings.ContainsAny(ns, ":/") {
+		t.Errorf("namespace %q is not a safe directory name", ns)
+	}
+}
+
+func TestStoreDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", home)
+
+	if dir, _ := Open("", false).Dir(); dir != filepath.Join(home, "paperless-go") {
+		t.Errorf("shared dir = %s", dir)
+	}
+	want := filepath.Join(home, "paperless-go", "instances", Namespace("https://paperless.example.com"))
+	if dir, _ := Open("https://paperless.example.com", false).Dir(); dir != want {
+		t.Errorf("instance dir = %s, want %s", dir, want)
+	}
+	path, err := New[int](Open("https://paperless.example.com", false), "tags.json", "tags").Path()
+	if err != nil || path != filepath.Join(want, "tags.json") {
+		t.Errorf("path = %s, %v", path, err)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	c := New[map[int]string](Open("https://paperless.example.com", false), "tags.json", "tags")
+
+	tags := map[int]string{1: "Important", 2: "Work", 3: "Personal"}
+	c.Save(tags)
+
+	entry, err := c.Load()
+	if err != nil || entry == nil {
+		t.Fatalf("Load = %+v, %v", entry, err)
+	}
+	if len(entry.Data) != len(tags) || entry.Data[2] != "Work" {
+		t.Errorf("Data = %v, want %v", entry.Data, tags)
+	}
+	if time.Since(entry.FetchedAt) > 5*time.Second {
+		t.Errorf("FetchedAt is too old: %v", entry.FetchedAt)
+	}
+
+	// The file keeps the format of earlier versions
+	path, _ := c.Path()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file struct {
+		Tags      map[int]string `json:"tags"`
+		FetchedAt time.Time      `json:"fetched_at"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil || file.Tags[1] != "Important" || file.FetchedAt.IsZero() {
+		t.Errorf("file = %s, %v", data, err)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", home)
+	c := New[map[int]string](Open("", false), "tags.json", "tags")
+
+	if entry, err := c.Load(); err != nil || entry != nil {
+		t.Errorf("Load of missing cache = %+v, %v; want nil", entry, err)
+	}
+
+	// Invalid files are treated as missing
+	dir := filepath.Join(home, "paperless-go")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"invalid json", `{"docs": {}}`, `{"tags": "x", "fetched_at": "2024-01-01T00:00:00Z"}`} {
+		if err := os.WriteFile(filepath.Join(dir, "tags.json"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if entry, err := c.Load(); err != nil || entry != nil {
+			t.Errorf("Load of %s = %+v, %v; want nil", content, entry, err)
+		}
+	}
+}
+
+func TestEntryStale(t *testing.T) {
+	var missing *Entry[int]
+	if !missing.Stale(time.Hour) {
+		t.Error("nil entry should be stale")
+	}
+	if (&Entry[int]{FetchedAt: time.Now()}).Stale(time.Hour) {
+		t.Error("fresh entry should not be stale")
+	}
+	if !(&Entry[int]{FetchedAt: time.Now().Add(-2 * time.Hour)}).Stale(time.Hour) {
+		t.Error("old entry should be stale")
+	}
+	if !(&Entry[int]{FetchedAt: time.Now().Add(-time.Hour - time.Second)}).Stale(time.Hour) {
+		t.Error("entry past TTL should be stale")
+	}
+}
+
+func TestInstancesAreIsolated(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	home := New[map[int]string](Open("https://home.example", false), "tags.json", "tags")
+	work := New[map[int]string](Open("https://work.example", false), "tags.json", "tags")
+
+	home.Save(map[int]string{1: "tax"})
+	if entry, err := work.Load(); err != nil || entry != nil {
+		t.Errorf("work cache = %+v, %v; want none", entry, err)
+	}
+	work.Save(map[int]string{1: "payroll"})
+	if entry, _ := home.Load(); entry == nil || entry.Data[1] != "tax" {
+		t.Errorf("home cache = %+v, want tax", entry)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	t.Run("explicit -memory flag", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("XDG_CACHE_HOME", home)
+		store := Open("", true)
+		tags := New[map[int]string](store, "tags.json", "tags")
+		docs := New[map[int]string](store, "docs.json", "docs")
+
+		tags.Save(map[int]string{1: "Tag 1"})
+		entry, err := tags.Load()
+		if err != nil || entry == nil || entry.Data[1] != "Tag 1" {
+			t.Fatalf("Load = %+v, %v", entry, err)
+		}
+		if entry, _ := docs.Load(); entry != nil {
+			t.Errorf("docs cache = %+v, want none", entry)
+		}
+		if files, _ := os.ReadDir(home); len(files) != 0 {
+			t.Errorf("files written with -memory: %v", files)
+		}
+
+		// A second save replaces the first
+		tags.Save(map[int]string{2: "Tag 2"})
+		if entry, _ := tags.Load(); entry == nil || entry.Data[2] != "Tag 2" || entry.Data[1] != "" {
+			t.Errorf("Load after second save = %+v", entry)
+		}
+	})
+
+	t.Run("automatic fallback on write error", func(t *testing.T) {
+		unwritableCacheHome(t)
+		var warnings bytes.Buffer
+		store := Open("", false)
+		store.SetLogger(slog.New(slog.NewTextHandler(&warnings, nil)))
+		tags := New[map[int]string](store, "tags.json", "tags")
+		docs := New[map[int]string](store, "docs.json", "docs")
+
+		tags.Save(map[int]string{1: "Important", 2: "Work"})
+		if !store.Memory() {
+			t.Error("store should use memory after a write error")
+		}
+		if !strings.Contains(warnings.String(), "Using in-memory cache as fallback") {
+			t.Errorf("warnings = %q", warnings.String())
+		}
+		if entry, err := tags.Load(); err != nil || entry == nil || entry.Data[2] != "Work" {
+			t.Fatalf("Load = %+v, %v", entry, err)
+		}
+
+		// Other caches of the store use memory too, without more warnings
+		warnings.Reset()
+		docs.Save(map[int]string{3: "Letter"})
+		if entry, _ := docs.Load(); entry == nil || entry.Data[3] != "Letter" || warnings.Len() != 0 {
+			t.Errorf("docs Load = %+v, warnings %q", entry, warnings.String())
+		}
+	})
+}
+
+func TestGet(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	c := New[map[int]string](Open("", false), "tags.json", "tags")
+	fetches := 0
+	fetch := func() (map[int]string, error) {
+		fetches++
+		return map[int]string{1: "Fetched"}, nil
+	}
+
+	for _, tt := range []struct {
+		name    string
+		ttl     time.Duration
+		refresh bool
+		fetches int
+	}{
+		{"missing cache is fetched", time.Hour, false, 1},
+		{"fresh cache is used", time.Hour, false, 1},
+		{"refresh fetches", time.Hour, true, 2},
+		{"stale cache is fetched", -time.Second, false, 3},
+	} {
+		data, err := c.Get(tt.ttl, tt.refresh, fetch)
+		if err != nil || data[1] != "Fetched" || fetches != tt.fetches {
+			t.Errorf("%s: Get = %v, %v after %d fetches, want %d", tt.name, data, err, fetches, tt.fetches)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	c := New[map[int]string](Open("", false), "tags.json", "tags")
+	c.Save(map[int]string{1: "tax"})
+	path, _ := c.Path()
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), ".tags.json.123"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := c.Remove()
+	if err != nil || len(removed) != 2 {
+		t.Errorf("Remove = %v, %v; want the cache and the temporary file", removed, err)
+	}
+	if entry, _ := c.Load(); entry != nil {
+		t.Errorf("cache still loads after Remove: %+v", entry)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tags.json")
+
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFileAtomic(path, []byte(content)); err != nil {
+			t.Fatalf("WriteFileAtomic failed: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != content {
+			t.Errorf("file = %q, %v; want %q", data, err, content)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("mode = %v, %v; want 0644", info.Mode().Perm(), err)
+	}
+}
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tags.json.lock")
+
+	// Writers take turns
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		holders int
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := LockFile(path)
+			if err != nil {
+				t.Errorf("LockFile failed: %v", err)
+				return
+			}
+			mu.Lock()
+			holders++
+			if holders > 1 {
+				t.Error("lock held twice")
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			holders--
+			mu.Unlock()
+			unlock()
+		}()
+	}
+	wg.Wait()
+
+	// A lock left by a process that died is taken over
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * lockStale)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := LockFile(path)
+	if err != nil {
+		t.Fatalf("LockFile with a stale lock failed: %v", err)
+	}
+	unlock()
+}
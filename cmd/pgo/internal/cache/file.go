@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// A cache file is locked only while it is written, so a lock older than
+// lockStale was left by a process that died holding it, and one held longer
+// than lockTimeout is given up on
+var (
+	lockPoll    = 10 * time.Millisecond
+	lockStale   = 10 * time.Second
+	lockTimeout = 5 * time.Second
+)
+
+// WriteFileAtomic writes data to a temporary file and renames it to path, so
+// a command reading the file while it is written never sees a partial file
+func WriteFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LockFile takes the lock file at path, waiting while another process holds
+// it, and returns the function that releases it. Writers of a cache file
+// take its lock so that concurrent refreshes, e.g. by cache warm and a
+// command, write one after the other; other state files, such as ASN
+// reservations, use it the same way for read-modify-write updates.
+func LockFile(path string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is held by another process", path)
+		}
+		time.Sleep(lockPoll)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringService is the service pgo stores tokens under in the OS keyring.
+// The account is the Paperless URL, so each instance has its own token.
+const keyringService = "pgo"
+
+// keyring stores tokens in the keyring of the OS. Tools that ship with the
+// OS or its desktop do the work, so pgo needs no cgo or D-Bus code: security
+// for the macOS keychain, and secret-tool (libsecret) for the Secret Service
+// of GNOME Keyring or KWallet.
+type keyring struct {
+	name string // Shown to the user, e.g. "macOS keychain"
+	// get returns the token of account, or "" if there is none
+	get    func(account string) (string, error)
+	set    func(account, token string) error
+	remove func(account string) error
+}
+
+// osKeyring returns the keyring of the OS pgo runs on
+func osKeyring() (*keyring, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return macKeychain, nil
+	case "windows":
+		return nil, errors.New("the OS keyring is not supported on Windows; use a config profile or PAPERLESS_TOKEN")
+	default:
+		return secretService, nil
+	}
+}
+
+// keyringAccount is the keyring account of the instance at baseURL
+func keyringAccount(baseURL string) string {
+	return strings.TrimRight(baseURL, "/")
+}
+
+// keyringToken returns the token stored for the instance at baseURL, or ""
+// if there is none. A missing keyring tool means there is no token; other
+// failures are reported as warnings, since a token may not be needed.
+func keyringToken(baseURL string) string {
+	kr, err := osKeyring()
+	if err != nil {
+		return ""
+	}
+	token, err := kr.get(keyringAccount(baseURL))
+	if err != nil {
+		if !errors.Is(err, exec.ErrNotFound) {
+			slog.Warn("Could not read the token from the "+kr.name, "error", err)
+		}
+		return ""
+	}
+	return token
+}
+
+// keyringToolError is the failure of a keyring tool
+type keyringToolError struct {
+	tool    string
+	code    int    // Exit code
+	message string // What the tool wrote to stderr
+}
+
+func (e *keyringToolError) Error() string {
+	if e.message == "" {
+		return fmt.Sprintf("%s failed with exit code %d", e.tool, e.code)
+	}
+	return e.tool + ": " + e.message
+}
+
+// toolExitCode returns the exit code of a keyring tool that failed with err
+// and what it wrote to stderr, or -1 if err is not such a failure
+func toolExitCode(err error) (int, string) {
+	var toolErr *keyringToolError
+	if errors.As(err, &toolErr) {
+		return toolErr.code, toolErr.message
+	}
+	return -1, ""
+}
+
+// runKeyringTool runs a keyring tool with stdin as input and returns its
+// output
+func runKeyringTool(stdin string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", &keyringToolError{tool: name, code: exitErr.ExitCode(), message: strings.TrimSpace(stderr.String())}
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// macNotFound is the exit code of security for a missing item
+const macNotFound = 44
+
+var macKeychain = &keyring{
+	name: "macOS keychain",
+	get: func(account string) (string, error) {
+		token, err := runKeyringTool("", "security", "find-generic-password", "-s", keyringService, "-a", account, "-w")
+		if code, _ := toolExitCode(err); code == macNotFound {
+			return "", nil
+		}
+		return token, err
+	},
+	set: func(account, token string) error {
+		// The token is passed to security's interactive mode on stdin, so
+		// it does not show up in the process list
+		command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+			shellQuote(keyringService), shellQuote(account), shellQuote(token))
+		_, err := runKeyringTool(command, "security", "-i")
+		return err
+	},
+	remove: func(account string) error {
+		_, err := runKeyringTool("", "security", "delete-generic-password", "-s", keyringService, "-a", account)
+		if code, _ := toolExitCode(err); code == macNotFound {
+			return nil
+		}
+		return err
+	},
+}
+
+var secretService = &keyring{
+	name: "Secret Service keyring",
+	get: func(account string) (string, error) {
+		token, err := runKeyringTool("", "secret-tool", "lookup", "service", keyringService, "account", account)
+		// lookup fails without a message if there is no such secret
+		if code, message := toolExitCode(err); code == 1 && message == "" {
+			return "", nil
+		}
+		return token, err
+	},
+	set: func(account, token string) error {
+		// secret-tool reads the secret from stdin
+		_, err := runKeyringTool(token, "secret-tool", "store", "--label", "pgo token for "+account,
+			"service", keyringService, "account", account)
+		return err
+	},
+	remove: func(account string) error {
+		_, err := runKeyringTool("", "secret-tool", "clear", "service", keyringService, "account", account)
+		return err
+	},
+}
+
+// shellQuote quotes s for the command line of security -i
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
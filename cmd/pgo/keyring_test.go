@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeSecretTool installs a secret-tool on PATH that keeps secrets in files
+// in a temporary directory, and returns the PATH to run pgo with and the
+// directory
+func fakeSecretTool(t *testing.T) (path, store string) {
+	t.Helper()
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("the Secret Service keyring is used on Linux and BSD only")
+	}
+	bin, store := t.TempDir(), t.TempDir()
+	script := `#!/bin/sh
+# Arguments: store --label <label> service <s> account <a> | lookup|clear service <s> account <a>
+cmd=$1; shift
+[ "$cmd" = store ] && shift 2
+file="` + store + `/$(echo "$2-$4" | tr '/:' '__')"
+case $cmd in
+store) cat > "$file" ;;
+lookup) [ -f "$file" ] || exit 1; cat "$file" ;;
+clear) rm -f "$file" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "secret-tool"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return bin + string(os.PathListSeparator) + os.Getenv("PATH"), store
+}
+
+func TestShellQuote(t *testing.T) {
+	if got := shellQuote(`it's`); got != `'it'"'"'s'` {
+		t.Errorf("shellQuote = %s", got)
+	}
+}
+
+func TestCLI_LoginLogout(t *testing.T) {
+	path, store := fakeSecretTool(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Token stored-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"detail": "Invalid token."}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/api/tags/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 1, "name": "tax"}]}`))
+		case "/api/":
+			w.Header().Set("X-Version", "2.3.3")
+			w.Write([]byte(`{"documents": "x"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(stdin string, args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory"}, args...)...)
+		cmd.Env = append(os.Environ(), "PATH="+path, "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=", "PAPERLESS_PROFILE=",
+			"XDG_CONFIG_HOME="+t.TempDir(), "XDG_CACHE_HOME="+t.TempDir())
+		cmd.Stdin = strings.NewReader(stdin)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	// A wrong token is not stored
+	if _, stderr, err := run("wrong\n", "login"); err == nil || !strings.Contains(stderr, "failed to connect") {
+		t.Errorf("expected connection error, got %v, stderr: %s", err, stderr)
+	}
+	if files, _ := os.ReadDir(store); len(files) != 0 {
+		t.Errorf("wrong token stored: %v", files)
+	}
+
+	stdout, stderr, err := run("stored-token\n", "login")
+	if err != nil {
+		t.Fatalf("login failed: %v\nStderr: %s", err, stderr)
+	}
+	var login LoginOutput
+	if err := json.Unmarshal([]byte(stdout), &login); err != nil || login.URL != server.URL || login.Version != "2.3.3" || login.Keyring == "" {
+		t.Errorf("login output = %s, %v", stdout, err)
+	}
+
+	// Commands use the stored token
+	if stdout, stderr, err := run("", "get", "tags"); err != nil || !strings.Contains(stdout, "tax") {
+		t.Errorf("get tags with the stored token = %s, %v, stderr: %s", stdout, err, stderr)
+	}
+
+	stdout, stderr, err = run("", "logout")
+	var logout LogoutOutput
+	if err != nil || json.Unmarshal([]byte(stdout), &logout) != nil || !logout.Removed {
+		t.Errorf("logout = %s, %v, stderr: %s", stdout, err, stderr)
+	}
+	if _, stderr, err := run("", "get", "tags"); err == nil || !strings.Contains(stderr, "API token is required") {
+		t.Errorf("expected missing token after logout, got %v, stderr: %s", err, stderr)
+	}
+	if stdout, _, err := run("", "logout"); err != nil || json.Unmarshal([]byte(stdout), &logout) != nil || logout.Removed {
+		t.Errorf("second logout = %s, %v", stdout, err)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// redactedValue replaces redacted values in log messages
+const redactedValue = "[REDACTED]"
+
+// secretLogKeys are the attributes that are always redacted
+var secretLogKeys = map[string]bool{"token": true, "password": true, "authorization": true}
+
+// valueLogKeys are the attributes redacted with -log-redact: the tag names
+// and document titles that may reveal what a library holds
+var valueLogKeys = map[string]bool{"tag": true, "tags": true, "title": true}
+
+// logRedactor redacts secrets and, optionally, tag names and titles
+type logRedactor struct {
+	mu      sync.Mutex
+	secrets []string // Values redacted wherever they appear, e.g. the token
+	values  bool     // Set by -log-redact
+}
+
+// logRedaction is the redactor of the default logger
+var logRedaction = &logRedactor{}
+
+// addSecret redacts s from all log output, e.g. the API token in an error
+// message that quotes a request
+func (r *logRedactor) addSecret(s string) {
+	if s == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.secrets = append(r.secrets, s)
+}
+
+// text redacts the secrets in s
+func (r *logRedactor) text(s string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redactedValue)
+	}
+	return s
+}
+
+// value returns the text of the attribute key=v, redacted
+func (r *logRedactor) value(key string, v slog.Value) string {
+	key = strings.ToLower(key)
+	if secretLogKeys[key] || (r.values && valueLogKeys[key]) {
+		return redactedValue
+	}
+	return r.text(v.Resolve().String())
+}
+
+// configureLogging sets the default logger from -log-level, -verbose and
+// -quiet. -verbose means debug, which includes each request the client
+// makes; -quiet means errors only. Records are written for people, as
+// "Warning: message: error key=value".
+func configureLogging(level string, verbose, quiet, redactValues bool) error {
+	level = strings.TrimSpace(strings.ToLower(level))
+	if level == "" {
+		level = "info"
+	}
+
+	var slogLevel slog.Level
+	switch level {
+	case "debug":
+		slogLevel = slog.LevelDebug
+	case "info":
+		slogLevel = slog.LevelInfo
+	case "warn", "warning":
+		slogLevel = slog.LevelWarn
+	case "error":
+		slogLevel = slog.LevelError
+	default:
+		return usagef("invalid log level: %s (want debug, info, warn or error)", level)
+	}
+	switch {
+	case verbose:
+		slogLevel = slog.LevelDebug
+	case quiet:
+		slogLevel = slog.LevelError
+	}
+
+	logRedaction.values = redactValues
+	slog.SetDefault(slog.New(&logHandler{w: os.Stderr, mu: &sync.Mutex{}, level: slogLevel, redact: logRedaction}))
+	return nil
+}
+
+// logHandler writes log records as lines like
+// "Warning: Could not fetch tags: connection refused tag=tax". An "error"
+// attribute follows the message after a colon.
+type logHandler struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	level  slog.Level
+	redact *logRedactor
+	attrs  []slog.Attr
+	group  string // Prefix of the keys of attributes added later
+}
+
+// logLevelPrefixes start the lines of each level
+var logLevelPrefixes = map[slog.Level]string{
+	slog.LevelDebug: "Debug: ",
+	slog.LevelInfo:  "Info: ",
+	slog.LevelWarn:  "Warning: ",
+	slog.LevelError: "Error: ",
+}
+
+func (h *logHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *logHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(logLevelPrefixes[r.Level])
+	b.WriteString(h.redact.text(r.Message))
+
+	var rest strings.Builder
+	write := func(a slog.Attr) {
+		if a.Equal(slog.Attr{}) {
+			return
+		}
+		if a.Key == "error" {
+			b.WriteString(": " + h.redact.value(a.Key, a.Value))
+			return
+		}
+		value := h.redact.value(a.Key, a.Value)
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&rest, " %s=%s", a.Key, value)
+	}
+	for _, a := range h.attrs {
+		write(a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		if h.group != "" {
+			a.Key = h.group + a.Key
+		}
+		write(a)
+		return true
+	})
+	b.WriteString(rest.String())
+	b.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append([]slog.Attr{}, h.attrs...)
+	for _, a := range attrs {
+		if h.group != "" {
+			a.Key = h.group + a.Key
+		}
+		h2.attrs = append(h2.attrs, a)
+	}
+	return &h2
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.group = h.group + name + "."
+	return &h2
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	redact := &logRedactor{}
+	redact.addSecret("secret-token")
+	logger := slog.New(&logHandler{w: &buf, mu: &sync.Mutex{}, level: slog.LevelInfo, redact: redact})
+
+	logger.Debug("hidden")
+	logger.Warn("Could not fetch tags", "error", errors.New("GET /api/tags/?token=secret-token: refused"), "tag", "tax 2024")
+	logger.With("password", "hunter2").WithGroup("doc").Info("Loaded", "title", "Invoice", "id", 3)
+	want := `Warning: Could not fetch tags: GET /api/tags/?token=[REDACTED]: refused tag="tax 2024"` + "\n" +
+		`Info: Loaded password=[REDACTED] doc.title=Invoice doc.id=3` + "\n"
+	if buf.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	redact.values = true
+	logger.Warn("No such tag", "tag", "medical", "title", "Lab results", "id", 3)
+	if got := buf.String(); got != "Warning: No such tag tag=[REDACTED] title=[REDACTED] id=3\n" {
+		t.Errorf("redacted output = %q", got)
+	}
+}
+
+func TestConfigureLogging(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	defer func(values bool) { logRedaction.values = values }(logRedaction.values)
+
+	tests := []struct {
+		level          string
+		verbose, quiet bool
+		want           slog.Level
+	}{
+		{"", false, false, slog.LevelInfo},
+		{"WARN", false, false, slog.LevelWarn},
+		{"error", true, false, slog.LevelDebug},
+		{"debug", false, true, slog.LevelError},
+	}
+	for _, tt := range tests {
+		if err := configureLogging(tt.level, tt.verbose, tt.quiet, false); err != nil {
+			t.Fatalf("configureLogging(%q) error = %v", tt.level, err)
+		}
+		handler := slog.Default().Handler().(*logHandler)
+		if handler.level != tt.want {
+			t.Errorf("configureLogging(%q, %v, %v) level = %v, want %v", tt.level, tt.verbose, tt.quiet, handler.level, tt.want)
+		}
+	}
+	if err := configureLogging("trace", false, false, false); err == nil {
+		t.Error("expected error for an invalid level")
+	}
+}
+
+func TestCLI_Logging(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"count": 1, "results": [{"id": 1, "name": "tax"}]}`))
+	}))
+	defer server.Close()
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=secret-token",
+			"LOG_LEVEL=", "XDG_CACHE_HOME="+t.TempDir())
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		return stderr.String(), err
+	}
+
+	stderr, err := run("-verbose", "get", "tags")
+	if err != nil {
+		t.Fatalf("get tags failed: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "Debug: paperless request") || !strings.Contains(stderr, "path=/api/tags/") {
+		t.Errorf("expected requests to be logged, got: %s", stderr)
+	}
+	if strings.Contains(stderr, "secret-token") {
+		t.Errorf("token logged: %s", stderr)
+	}
+
+	if stderr, err := run("get", "tags"); err != nil || stderr != "" {
+		t.Errorf("expected no log output by default, got %v, stderr: %s", err, stderr)
+	}
+	if stderr, err := run("-log-level", "loud", "get", "tags"); err == nil || !strings.Contains(stderr, "invalid log level") {
+		t.Errorf("expected invalid log level error, got %v, stderr: %s", err, stderr)
+	}
+}
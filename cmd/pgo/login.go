@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// LoginOutput is the result of login
+type LoginOutput struct {
+	URL     string `json:"url"`
+	Keyring string `json:"keyring"`
+	Version string `json:"version,omitempty"` // Paperless version, if the server sends it
+}
+
+// LogoutOutput is the result of logout
+type LogoutOutput struct {
+	URL     string `json:"url"`
+	Keyring string `json:"keyring"`
+	Removed bool   `json:"removed"` // False if no token was stored
+}
+
+// runLogin asks for a token, or logs in to get one, checks it and stores it
+// in the OS keyring for the instance at conn.URL. The token is always read
+// from the prompt, never from flags or the environment, so that it stays out
+// of the shell history.
+func runLogin(conn settings, args []string) error {
+	if len(args) != 0 {
+		return commandUsage("login")
+	}
+	if conn.URL == "" {
+		return errNoURL
+	}
+	kr, err := osKeyring()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	p := newPrompter()
+	fmt.Fprintf(p.out, "Logging in to %s\n", conn.URL)
+	token, err := askToken(ctx, p, conn.URL)
+	if err != nil {
+		return err
+	}
+	caps, err := checkToken(ctx, p, conn.URL, token)
+	if err != nil {
+		return err
+	}
+	if err := kr.set(keyringAccount(conn.URL), token); err != nil {
+		return fmt.Errorf("failed to store the token in the %s: %w", kr.name, err)
+	}
+	fmt.Fprintf(p.out, "Stored the token in the %s\n", kr.name)
+
+	if err := writeOutput(LoginOutput{URL: conn.URL, Keyring: kr.name, Version: caps.Version}); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// runLogout removes the token of the instance at conn.URL from the OS
+// keyring
+func runLogout(conn settings, args []string) error {
+	if len(args) != 0 {
+		return commandUsage("logout")
+	}
+	if conn.URL == "" {
+		return errNoURL
+	}
+	kr, err := osKeyring()
+	if err != nil {
+		return err
+	}
+
+	account := keyringAccount(conn.URL)
+	token, err := kr.get(account)
+	if err != nil {
+		return fmt.Errorf("failed to read the %s: %w", kr.name, err)
+	}
+	output := LogoutOutput{URL: conn.URL, Keyring: kr.name, Removed: token != ""}
+	if output.Removed {
+		if err := kr.remove(account); err != nil {
+			return fmt.Errorf("failed to remove the token from the %s: %w", kr.name, err)
+		}
+	}
+	if err := writeOutput(output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
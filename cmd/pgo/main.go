@@ -1,10 +1,13 @@
 package main
 
 import (
+	"bufio"
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/exec"
 	"strconv"
@@ -12,6 +15,7 @@ import (
 	"time"
 
 	"github.com/jason-riddle/paperless-go"
+	"github.com/jason-riddle/paperless-go/cmd/pgo/internal/cache"
 )
 
 // DocumentWithTagNames represents a document with tag names resolved
@@ -22,7 +26,7 @@ type DocumentWithTagNames struct {
 	Created             string   `json:"created"`
 	Modified            string   `json:"modified"`
 	Added               string   `json:"added"`
-	ArchiveSerialNumber *int     `json:"archive_serial_number"`
+	ArchiveSerialNumber *int64   `json:"archive_serial_number"`
 	OriginalFileName    string   `json:"original_file_name"`
 	Tags                []int    `json:"tags"`
 	TagNames            []string `json:"tag_names"`
@@ -30,7 +34,10 @@ type DocumentWithTagNames struct {
 
 // DocumentListOutput represents the output for list documents command
 type DocumentListOutput struct {
+	// Count is the number of results fetched; Total is the number of
+	// matches reported by Paperless
 	Count   int                    `json:"count"`
+	Total   int                    `json:"total"`
 	Results []DocumentWithTagNames `json:"results"`
 }
 
@@ -67,46 +74,271 @@ func convertDocToOutput(doc *paperless.Document, tagNames map[int]string) Docume
 	}
 }
 
-// outputJSON outputs data as JSON to stdout
-func outputJSON(v interface{}) error {
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(v)
+// DeleteOutput is the result of the delete command
+type DeleteOutput struct {
+	Deleted []int           `json:"deleted"`
+	Failed  []DeleteFailure `json:"failed,omitempty"`
 }
 
+// DeleteFailure is an ID the delete command could not delete
+type DeleteFailure struct {
+	ID    int    `json:"id"`
+	Error string `json:"error"`
+}
+
+// confirm writes prompt to w and reports whether the answer read from r is yes
+func confirm(r io.Reader, w io.Writer, prompt string) (bool, error) {
+	fmt.Fprint(w, prompt)
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+func addDeleteFlags(fs *flag.FlagSet) (yes *bool) {
+	return fs.Bool("yes", false, "Delete without asking for confirmation")
+}
+
+// parseInterspersed parses args with fs, allowing flags after positional
+// arguments, and returns the positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// joinIDs formats IDs as a comma-separated list
+func joinIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// jsonErrors is set by -json-errors
+var jsonErrors bool
+
 func main() {
 	if err := run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(reportError(os.Stderr, err, jsonErrors))
+	}
+}
+
+// globalFlags are the flags that come before the command
+type globalFlags struct {
+	url          *string
+	token        *string
+	profile      *string
+	remote       *string
+	forceRefresh *bool
+	memory       *bool
+	outputFormat *string
+	template     *string
+	plain        *bool
+	quiet        *bool
+	logLevel     *string
+	verbose      *bool
+	logRedact    *bool
+	withMeta     *bool
+	concurrency  *int
+	rate         *float64
+	nice         *bool
+	jsonErrors   *bool
+	dryRun       *bool
+	yesIMeanIt   *bool
+	query        *string
+}
+
+func addGlobalFlags(fs *flag.FlagSet) *globalFlags {
+	return &globalFlags{
+		url:          fs.String("url", "", "Paperless instance URL (default: $PAPERLESS_URL or the profile's url)"),
+		token:        fs.String("token", "", "API authentication token (default: $PAPERLESS_TOKEN or the profile's token)"),
+		profile:      fs.String("profile", os.Getenv("PAPERLESS_PROFILE"), "Config file profile to use (default: $PAPERLESS_PROFILE or default_profile)"),
+		remote:       fs.String("remote", "", "Remote to use: a config file profile, see pgo remotes (overrides -profile)"),
+		forceRefresh: fs.Bool("force-refresh", false, "Force refresh caches, bypassing any cached data"),
+		memory:       fs.Bool("memory", false, "Use in-memory cache only for tags and docs, do not write to disk"),
+		outputFormat: fs.String("output-format", "", "Output format: pretty, json, table, csv or yaml (default: pretty on a terminal, json otherwise)"),
+		template:     fs.String("template", "", "Go text/template executed with the JSON fields of the result, e.g. '{{.count}}'"),
+		plain:        fs.Bool("plain", false, "Deterministic output: sorted keys, results sorted by ID, no color or timing fields"),
+		quiet:        fs.Bool("quiet", false, "Don't show progress bars, per-document progress or messages other than errors on stderr"),
+		logLevel:     fs.String("log-level", os.Getenv("LOG_LEVEL"), "Log level: debug, info, warn or error (default: $LOG_LEVEL or info)"),
+		verbose:      fs.Bool("verbose", false, "Log at debug level, including each request to the server"),
+		logRedact:    fs.Bool("log-redact", false, "Redact tag names and document titles in log messages"),
+		withMeta:     fs.Bool("with-meta", false, "Add a meta object with counts, page and elapsed time to list output"),
+		concurrency:  fs.Int("concurrency", 1, "Documents processed at once by apply, delete, export and download, or bulk edits by autoassign"),
+		rate:         fs.Float64("rate", 0, "Maximum documents started per second by apply, delete, export and download (0: no limit)"),
+		nice:         fs.Bool("nice", false, "Courtesy mode for busy servers: one request at a time, spaced out, with patient retries"),
+		jsonErrors:   fs.Bool("json-errors", false, "Write errors to stderr as JSON objects with a type and exit code"),
+		dryRun:       fs.Bool("dry-run", false, "Print the requests apply, add, delete, perms, correspondents, watch, check-ocr and autoassign would make, without making them"),
+		yesIMeanIt:   fs.Bool("yes-i-mean-it", false, "Allow changes to the instance of a profile labeled readonly or production"),
+		query:        fs.String("query", "", "Print only the part of the output selected by a JSONPath or gjson path, e.g. 'results[*].id'; may also follow the command"),
 	}
 }
 
-func run() error {
+func run() (err error) {
 	// Parse command line flags
-	baseURL := flag.String("url", os.Getenv("PAPERLESS_URL"), "Paperless instance URL (default: $PAPERLESS_URL)")
-	token := flag.String("token", os.Getenv("PAPERLESS_TOKEN"), "API authentication token (default: $PAPERLESS_TOKEN)")
-	forceRefresh := flag.Bool("force-refresh", false, "Force refresh caches, bypassing any cached data")
-	inMemoryCacheFlag := flag.Bool("memory", false, "Use in-memory cache only for tags and docs, do not write to disk")
-	outputFormat := flag.String("output-format", "json", "Output format (only 'json' is supported)")
+	globals := addGlobalFlags(flag.CommandLine)
+	flag.Usage = func() {
+		writeOverview(os.Stderr)
+		fmt.Fprintln(os.Stderr, "\nGlobal flags:")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
-
-	// Set the global in-memory cache flags for both tag and doc caches
-	useInMemoryCache = *inMemoryCacheFlag
-	useInMemoryDocCache = *inMemoryCacheFlag
+	jsonErrors = *globals.jsonErrors
+	withMeta = *globals.withMeta
+	quiet = *globals.quiet
+	allowProtectedWrites = *globals.yesIMeanIt
+	if err := configureLogging(*globals.logLevel, *globals.verbose, *globals.quiet, *globals.logRedact); err != nil {
+		return err
+	}
 
 	// Validate output format
-	if *outputFormat != "json" {
-		return fmt.Errorf("unsupported output format: %s (only 'json' is supported)", *outputFormat)
+	if err := configureOutput(*globals.outputFormat, *globals.template, *globals.plain); err != nil {
+		return err
+	}
+	pool, err := newWorkerPool(*globals.concurrency, *globals.rate)
+	if err != nil {
+		return err
+	}
+	if *globals.nice {
+		niceMode = true
+		if pool.concurrency > 1 {
+			slog.Warn("-nice limits -concurrency to 1")
+			pool.concurrency = 1
+		}
 	}
 
 	// Parse command
 	args := flag.Args()
 	if len(args) == 0 {
-		return fmt.Errorf("usage: pgo <command> [args]\nAvailable commands:\n  get docs - List documents\n  get docs <id> - Get specific document\n  get tags - List tags\n  get tags <id> - Get specific tag\n  search docs <query> - Search documents (use -title-only to search titles only)\n  search tags <query> - Search tags\n  apply docs <id> --tags=<id1>,<id2>... - Update tags for a document\n  add tag \"<name>\" - Create a new tag\n  rag <args> - Run pgo-rag (RAG indexing/search)\n  tagcache [path|build] - Print or build the tag cache\n  doccache [path|build] - Print or build the doc cache")
+		var overview strings.Builder
+		writeOverview(&overview)
+		return usagef("%s", strings.TrimSuffix(overview.String(), "\n"))
 	}
 
 	command := args[0]
 
+	switch {
+	case command == "help":
+		return runHelp(os.Stdout, args[1:])
+	case command == "completion":
+		return runCompletion(os.Stdout, args[1:])
+	case command != "rag" && helpRequested(args[1:]):
+		return writeCommandHelp(os.Stdout, args)
+	}
+
+	// -query may follow the command, unless the command has its own
+	queryExpr := *globals.query
+	if command != "rag" && !commandHasFlag(args, "query") {
+		expr, rest, err := extractQueryFlag(args[1:])
+		if err != nil {
+			return err
+		}
+		if expr != "" {
+			queryExpr = expr
+		}
+		args = append([]string{command}, rest...)
+	}
+	if outputQuery, err = parseQuery(queryExpr); err != nil {
+		return usagef("%v", err)
+	}
+
+	if *globals.dryRun {
+		if !dryRunCommands[command] {
+			return usagef("-dry-run is not supported by pgo %s", command)
+		}
+		dryRun = &dryRunTransport{next: http.DefaultTransport}
+		defer func() {
+			if err == nil {
+				err = writeDryRunOutput()
+			}
+		}()
+	}
+
+	configPath, err := getConfigFilePath()
+	if err != nil {
+		return fmt.Errorf("failed to get config file path: %w", err)
+	}
+
+	// Handle config command
+	if command == "config" {
+		if len(args) > 2 || (len(args) == 2 && args[1] != "path") {
+			return commandUsage("config")
+		}
+		fmt.Println(configPath)
+		return nil
+	}
+
+	if command == "remotes" {
+		return runRemotes(configPath, args[1:])
+	}
+
+	profileName := *globals.profile
+	if *globals.remote != "" {
+		profileName = *globals.remote
+	}
+
+	if command == "init" {
+		// The config may not exist yet, so only flags and environment
+		// give defaults
+		defaults, _ := resolveSettings(&Config{}, *globals.url, *globals.token, "", os.Getenv)
+		defaults.Profile.Name = profileName
+		return runInit(configPath, args[1:], defaults)
+	}
+
+	// Resolve the instance from flags, environment and config file
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	conn, err := resolveSettings(cfg, *globals.url, *globals.token, profileName, os.Getenv)
+	if err != nil {
+		return fmt.Errorf("%w (%s)", err, configPath)
+	}
+	caches = cache.Open(conn.URL, *globals.memory)
+	if writeCommands[command] && dryRun == nil {
+		if err := checkProtectedProfile(conn.Profile); err != nil {
+			return err
+		}
+	}
+
+	if command == "login" {
+		return runLogin(conn, args[1:])
+	}
+	if command == "logout" {
+		return runLogout(conn, args[1:])
+	}
+
+	// A token stored by pgo login is used if no flag, environment variable
+	// or profile gives one
+	if conn.Token == "" && conn.URL != "" {
+		conn.Token = keyringToken(conn.URL)
+	}
+	logRedaction.addSecret(conn.Token)
+
+	if command == "cache" {
+		return runCache(conn, args[1:])
+	}
+
+	// tagcache and doccache are kept for scripts written before pgo cache
+	if command == "tagcache" || command == "doccache" {
+		slog.Warn(fmt.Sprintf("pgo %s is deprecated; use pgo cache path|status|clear|warm", command))
+	}
+
 	// Handle tagcache command
 	if command == "tagcache" {
 		subcommand := ""
@@ -117,9 +349,9 @@ func run() error {
 		switch subcommand {
 		case "", "path":
 			if len(args) > 2 {
-				return fmt.Errorf("usage: pgo tagcache [path|build]")
+				return usagef("usage: pgo tagcache [path|build]")
 			}
-			cachePath, err := getCacheFilePath()
+			cachePath, err := tagCache().Path()
 			if err != nil {
 				return fmt.Errorf("failed to get cache file path: %w", err)
 			}
@@ -127,16 +359,16 @@ func run() error {
 			return nil
 		case "build":
 			if len(args) > 2 {
-				return fmt.Errorf("usage: pgo tagcache [path|build]")
+				return usagef("usage: pgo tagcache [path|build]")
 			}
-			if *baseURL == "" {
-				return fmt.Errorf("paperless URL is required (use -url flag or PAPERLESS_URL env var)")
+			if conn.URL == "" {
+				return errNoURL
 			}
-			if *token == "" {
-				return fmt.Errorf("API token is required (use -token flag or PAPERLESS_TOKEN env var)")
+			if conn.Token == "" {
+				return errNoToken
 			}
 
-			client := paperless.NewClient(*baseURL, *token)
+			client := newClient(conn)
 			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 			defer cancel()
 
@@ -145,28 +377,28 @@ func run() error {
 				return fmt.Errorf("failed to build tag cache: %w", err)
 			}
 
-			cachePath, err := getCacheFilePath()
+			cachePath, err := tagCache().Path()
 			if err != nil {
 				return fmt.Errorf("failed to get cache file path: %w", err)
 			}
 
 			fetchedAt := time.Now()
-			if cache, err := loadTagCache(); err == nil && cache != nil {
-				fetchedAt = cache.FetchedAt
+			if entry, err := tagCache().Load(); err == nil && entry != nil {
+				fetchedAt = entry.FetchedAt
 			}
 
 			output := CacheBuildOutput{
 				Path:      cachePath,
 				Entries:   len(tagNames),
 				FetchedAt: fetchedAt.Format(time.RFC3339),
-				InMemory:  useInMemoryCache,
+				InMemory:  caches.Memory(),
 			}
-			if err := outputJSON(output); err != nil {
-				return fmt.Errorf("failed to output JSON: %w", err)
+			if err := writeOutput(output); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
 			}
 			return nil
 		default:
-			return fmt.Errorf("usage: pgo tagcache [path|build]")
+			return usagef("usage: pgo tagcache [path|build]")
 		}
 	}
 
@@ -180,9 +412,9 @@ func run() error {
 		switch subcommand {
 		case "", "path":
 			if len(args) > 2 {
-				return fmt.Errorf("usage: pgo doccache [path|build]")
+				return usagef("usage: pgo doccache [path|build]")
 			}
-			cachePath, err := getDocCacheFilePath()
+			cachePath, err := docCache().Path()
 			if err != nil {
 				return fmt.Errorf("failed to get doc cache file path: %w", err)
 			}
@@ -190,16 +422,16 @@ func run() error {
 			return nil
 		case "build":
 			if len(args) > 2 {
-				return fmt.Errorf("usage: pgo doccache [path|build]")
+				return usagef("usage: pgo doccache [path|build]")
 			}
-			if *baseURL == "" {
-				return fmt.Errorf("paperless URL is required (use -url flag or PAPERLESS_URL env var)")
+			if conn.URL == "" {
+				return errNoURL
 			}
-			if *token == "" {
-				return fmt.Errorf("API token is required (use -token flag or PAPERLESS_TOKEN env var)")
+			if conn.Token == "" {
+				return errNoToken
 			}
 
-			client := paperless.NewClient(*baseURL, *token)
+			client := newClient(conn)
 			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 			defer cancel()
 
@@ -208,28 +440,28 @@ func run() error {
 				return fmt.Errorf("failed to build doc cache: %w", err)
 			}
 
-			cachePath, err := getDocCacheFilePath()
+			cachePath, err := docCache().Path()
 			if err != nil {
 				return fmt.Errorf("failed to get doc cache file path: %w", err)
 			}
 
 			fetchedAt := time.Now()
-			if cache, err := loadDocCache(); err == nil && cache != nil {
-				fetchedAt = cache.FetchedAt
+			if entry, err := docCache().Load(); err == nil && entry != nil {
+				fetchedAt = entry.FetchedAt
 			}
 
 			output := CacheBuildOutput{
 				Path:      cachePath,
 				Entries:   len(docNames),
 				FetchedAt: fetchedAt.Format(time.RFC3339),
-				InMemory:  useInMemoryDocCache,
+				InMemory:  caches.Memory(),
 			}
-			if err := outputJSON(output); err != nil {
-				return fmt.Errorf("failed to output JSON: %w", err)
+			if err := writeOutput(output); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
 			}
 			return nil
 		default:
-			return fmt.Errorf("usage: pgo doccache [path|build]")
+			return usagef("usage: pgo doccache [path|build]")
 		}
 	}
 
@@ -238,41 +470,116 @@ func run() error {
 	}
 
 	// Check for required arguments for API commands
-	if *baseURL == "" {
-		return fmt.Errorf("paperless URL is required (use -url flag or PAPERLESS_URL env var)")
+	if conn.URL == "" {
+		return errNoURL
 	}
-	if *token == "" {
-		return fmt.Errorf("API token is required (use -token flag or PAPERLESS_TOKEN env var)")
+	if conn.Token == "" {
+		return errNoToken
+	}
+
+	if command == "preview" {
+		return runPreview(newClient(conn), args[1:], *globals.forceRefresh)
+	}
+
+	if command == "browse" {
+		return runBrowse(newClient(conn), args[1:], *globals.forceRefresh)
+	}
+
+	if command == "watch" {
+		return runWatch(newClient(conn), args[1:], conn.Profile)
+	}
+
+	if command == "export" {
+		return runExport(newClient(conn), args[1:], conn.URL, pool)
+	}
+
+	if command == "autoassign" {
+		return runAutoassign(newClient(conn), args[1:], *globals.forceRefresh, pool)
+	}
+
+	if command == "check-ocr" {
+		return runCheckOCR(newClient(conn), args[1:], *globals.forceRefresh)
+	}
+
+	if command == "download" {
+		return runDownload(newClient(conn), args[1:], conn.URL, *globals.forceRefresh, pool)
+	}
+
+	if command == "perms" {
+		return runPerms(newClient(conn), args[1:])
+	}
+
+	if command == "report" {
+		return runReport(newClient(conn), args[1:], *globals.forceRefresh)
+	}
+
+	if command == "audit" {
+		return runAudit(newClient(conn), args[1:], *globals.forceRefresh)
+	}
+
+	if command == "view" {
+		return runView(newClient(conn), args[1:], *globals.forceRefresh)
+	}
+
+	if command == "diff" {
+		return runDiff(newClient(conn), args[1:], *globals.forceRefresh)
+	}
+
+	if command == "asn" {
+		return runASN(newClient(conn), args[1:], *globals.forceRefresh)
+	}
+
+	if command == "tag" {
+		return runTag(newClient(conn), args[1:], pool)
+	}
+
+	if command == "correspondents" {
+		if len(args) < 2 || args[1] != "normalize" {
+			return commandUsage("correspondents normalize")
+		}
+		return runNormalize(newClient(conn), args[2:])
 	}
 
 	if command == "apply" {
 		if len(args) < 3 {
-			return fmt.Errorf("usage: pgo apply docs <id> --tags=<id1>,<id2>")
+			return commandUsage("apply docs")
 		}
 
 		resource := args[1]
 		if resource != "docs" {
-			return fmt.Errorf("unknown resource for apply: %s", resource)
+			return usagef("unknown resource for apply: %s", resource)
 		}
 
-		// Parse ID and flags
-		var id int
-		var tagsStr string
+		// Without an ID, add tags to the documents listed in a file or stdin
+		if strings.HasPrefix(args[2], "-") {
+			return runBulkApply(newClient(conn), args[2:], nil, *globals.forceRefresh, pool)
+		}
 
 		// First argument after resource MUST be ID
+		var id int
 		if _, err := fmt.Sscanf(args[2], "%d", &id); err != nil {
-			return fmt.Errorf("invalid ID format: %s", args[2])
+			return usagef("invalid ID format: %s", args[2])
+		}
+		applyFlags := flag.NewFlagSet("apply docs", flag.ContinueOnError)
+		apply := addApplyDocFlags(applyFlags)
+		if err := applyFlags.Parse(args[3:]); err != nil {
+			return usagef("parse apply flags: %w", err)
 		}
+		if applyFlags.NArg() != 0 {
+			return commandUsage("apply docs")
+		}
+		tagsStr, tagNamesStr, createMissing := *apply.tags, *apply.tagNames, *apply.createMissing
 
-		// Loop through remaining args to find flags
-		for _, arg := range args[3:] {
-			if strings.HasPrefix(arg, "--tags=") {
-				tagsStr = strings.TrimPrefix(arg, "--tags=")
+		// Removing tags is a bulk edit of this one document
+		if *apply.removeTags != "" {
+			if tagsStr != "" || tagNamesStr != "" {
+				return usagef("--remove-tags cannot be combined with --tags or --tag-names for a single document")
 			}
+			return runBulkApply(newClient(conn), []string{"--remove-tags", *apply.removeTags}, []int{id}, *globals.forceRefresh, pool)
 		}
 
-		if tagsStr == "" {
-			return fmt.Errorf("missing required flag: --tags")
+		if tagsStr == "" && tagNamesStr == "" {
+			return usagef("missing required flag: --tags or --tag-names")
 		}
 
 		// Parse tags
@@ -289,10 +596,25 @@ func run() error {
 		}
 
 		// Create client
-		client := paperless.NewClient(*baseURL, *token)
+		client := newClient(conn)
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
+		// Resolve tag names, creating missing tags if asked to
+		var tagNames map[int]string
+		if names := splitList(tagNamesStr); len(names) > 0 {
+			ids, names, err := resolveTagNames(ctx, client, names, *globals.forceRefresh, createMissing)
+			if err != nil {
+				return err
+			}
+			for _, tid := range ids {
+				if !containsInt(tagIDs, tid) {
+					tagIDs = append(tagIDs, tid)
+				}
+			}
+			tagNames = names
+		}
+
 		// Call update
 		update := &paperless.DocumentUpdate{
 			Tags: &tagIDs,
@@ -303,106 +625,192 @@ func run() error {
 			return fmt.Errorf("failed to update document: %w", err)
 		}
 
-		tagNames, err := getTagNamesWithCache(ctx, client, *forceRefresh, DefaultCacheTTL)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Could not fetch tags for name resolution: %v\n", err)
-			tagNames = make(map[int]string)
+		if tagNames == nil {
+			tagNames, err = getTagNamesWithCache(ctx, client, *globals.forceRefresh, DefaultCacheTTL)
+			if err != nil {
+				slog.Warn("Could not fetch tags for name resolution", "error", err)
+				tagNames = make(map[int]string)
+			}
 		}
 
 		output := convertDocToOutput(doc, tagNames)
-		if err := outputJSON(output); err != nil {
-			return fmt.Errorf("failed to output JSON: %w", err)
+		if err := writeOutput(output); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
 		}
 		return nil
 	}
 
 	if command == "add" {
-		if len(args) < 2 {
-			return fmt.Errorf("usage: pgo add <resource> [args]\nAvailable resources:\n  tag \"<name>\" - Create a new tag")
+		return runAdd(newClient(conn), args[1:])
+	}
+
+	if command == "delete" {
+		if len(args) < 3 {
+			return commandUsage("delete")
 		}
 
 		resource := args[1]
-		if resource != "tag" {
-			return fmt.Errorf("unknown resource for add: %s", resource)
+		if resource != "docs" && resource != "tags" {
+			return usagef("unknown resource for delete: %s", resource)
 		}
 
-		if len(args) < 3 {
-			return fmt.Errorf("usage: pgo add tag \"<name>\"")
+		// IDs and flags may be mixed
+		deleteFlags := flag.NewFlagSet("delete "+resource, flag.ContinueOnError)
+		yesFlag := addDeleteFlags(deleteFlags)
+		idArgs, err := parseInterspersed(deleteFlags, args[2:])
+		if err != nil {
+			return usagef("parse delete flags: %w", err)
+		}
+		var ids []int
+		if len(idArgs) == 1 && idArgs[0] == stdinIDArg && resource == "docs" {
+			// stdin holds the IDs, so it can't answer the confirmation
+			if !*yesFlag && dryRun == nil {
+				return usagef("reading IDs from stdin needs --yes")
+			}
+			if ids, err = readStdinDocumentIDs(); err != nil {
+				return err
+			}
+			idArgs = nil
+		}
+		for _, arg := range idArgs {
+			id, err := strconv.Atoi(arg)
+			if err != nil || id <= 0 {
+				return usagef("invalid ID format: %s", arg)
+			}
+			ids = append(ids, id)
+		}
+		if len(ids) == 0 {
+			return commandUsage("delete " + resource)
+		}
+		yes := *yesFlag
+
+		if !yes && dryRun == nil {
+			prompt := fmt.Sprintf("Delete %s %s? [y/N]: ", resource, joinIDs(ids))
+			ok, err := confirm(os.Stdin, os.Stderr, prompt)
+			if err != nil {
+				return fmt.Errorf("failed to read confirmation: %w", err)
+			}
+			if !ok {
+				return fmt.Errorf("aborted; pass --yes to delete without confirmation")
+			}
 		}
-		tagName := args[2]
 
 		// Create client
-		client := paperless.NewClient(*baseURL, *token)
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		client := newClient(conn)
+		// Bulk deletes can run long; each request has its own timeout
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 		defer cancel()
 
-		// Create tag
-		tagCreate := &paperless.TagCreate{
-			Name: tagName,
-		}
-
-		tag, err := client.CreateTag(ctx, tagCreate)
-		if err != nil {
-			return fmt.Errorf("failed to create tag: %w", err)
+		// A failed ID does not stop the others; all failures are reported
+		errs := pool.run(ctx, len(ids), func(ctx context.Context, i int) error {
+			if resource == "docs" {
+				return client.DeleteDocument(ctx, ids[i])
+			}
+			return client.DeleteTag(ctx, ids[i])
+		})
+		output := DeleteOutput{Deleted: []int{}}
+		for i, err := range errs {
+			if err != nil {
+				output.Failed = append(output.Failed, DeleteFailure{ID: ids[i], Error: err.Error()})
+			} else {
+				output.Deleted = append(output.Deleted, ids[i])
+			}
 		}
 
-		if err := outputJSON(tag); err != nil {
-			return fmt.Errorf("failed to output JSON: %w", err)
+		if err := writeOutput(output); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
 		}
-		return nil
+		return batchError("delete "+resource, errs, func(i int) string {
+			return fmt.Sprintf("%s %d", strings.TrimSuffix(resource, "s"), ids[i])
+		})
 	}
 
 	if command != "get" && command != "search" {
-		return fmt.Errorf("unknown command: %s", command)
+		return usagef("unknown command: %s", command)
 	}
 
 	if len(args) < 2 {
-		return fmt.Errorf("usage: pgo %s <resource> [args]\nAvailable resources:\n  docs - Documents\n  tags - Tags", command)
+		return commandUsage(command)
 	}
 
 	resource := args[1]
-	if resource != "docs" && resource != "tags" {
-		return fmt.Errorf("unknown resource: %s", resource)
+	switch resource {
+	case "docs", "tags":
+	case "correspondents", "doctypes", "storagepaths":
+		if command != "get" {
+			return usagef("unknown resource for %s: %s", command, resource)
+		}
+	default:
+		return usagef("unknown resource: %s", resource)
 	}
 
-	// Check if an ID was provided
+	// Check if an ID was provided; get docs also takes filter flags instead
 	var id int
 	var hasID bool
-	if command == "get" && len(args) > 2 {
+	if command == "get" && len(args) > 2 && !(resource == "docs" && strings.HasPrefix(args[2], "-")) {
 		// Parse the ID argument
 		if _, err := fmt.Sscanf(args[2], "%d", &id); err != nil {
-			return fmt.Errorf("invalid ID format: %s", args[2])
+			return usagef("invalid ID format: %s", args[2])
 		}
 		hasID = true
 	}
 
+	var filters *docFilters
+	var paging *docPaging
+	var fields *docFields
+	var stdinIDs []int
+	if command == "get" && resource == "docs" && !hasID {
+		getFlags := flag.NewFlagSet("get docs", flag.ContinueOnError)
+		list := addDocListFlags(getFlags)
+		positional, err := parseInterspersed(getFlags, args[2:])
+		if err != nil {
+			return usagef("parse get docs flags: %w", err)
+		}
+		switch {
+		case len(positional) == 1 && positional[0] == stdinIDArg:
+			if stdinIDs, err = readStdinDocumentIDs(); err != nil {
+				return err
+			}
+			// Every listed document is fetched unless paging is asked for
+			if *list.paging.limit == 0 && *list.paging.page == 0 {
+				*list.paging.all = true
+			}
+		case len(positional) != 0:
+			return commandUsage("get docs")
+		}
+		filters, paging, fields = list.filters, list.paging, list.fields
+	}
+
 	var searchQuery string
 	var titleOnly bool
 	if command == "search" {
 		switch resource {
 		case "docs":
 			searchFlags := flag.NewFlagSet("search docs", flag.ContinueOnError)
-			titleOnlyFlag := searchFlags.Bool("title-only", false, "Search only document titles")
+			search := addSearchDocsFlags(searchFlags)
 			if err := searchFlags.Parse(args[2:]); err != nil {
-				return fmt.Errorf("parse search docs flags: %w", err)
+				return usagef("parse search docs flags: %w", err)
 			}
 			remaining := searchFlags.Args()
 			if len(remaining) == 0 {
-				return fmt.Errorf("usage: pgo search docs [-title-only] <query>")
+				return commandUsage("search docs")
 			}
 			searchQuery = strings.Join(remaining, " ")
-			titleOnly = *titleOnlyFlag
+			titleOnly = *search.titleOnly
+			filters, paging, fields = search.filters, search.paging, search.fields
 		case "tags":
 			if len(args) < 3 {
-				return fmt.Errorf("usage: pgo search tags <query>")
+				return commandUsage("search tags")
 			}
 			searchQuery = strings.Join(args[2:], " ")
 		}
 	}
 
 	// Create client
-	client := paperless.NewClient(*baseURL, *token)
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	client := newClient(conn)
+	// --all walks every page, which takes a while on large libraries or with
+	// -nice; each request has its own timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
 	switch resource {
@@ -415,53 +823,61 @@ func run() error {
 			}
 
 			// Fetch tag names for resolution (with caching)
-			tagNames, err := getTagNamesWithCache(ctx, client, *forceRefresh, DefaultCacheTTL)
+			tagNames, err := getTagNamesWithCache(ctx, client, *globals.forceRefresh, DefaultCacheTTL)
 			if err != nil {
 				// If tag fetching fails, continue but warn
-				fmt.Fprintf(os.Stderr, "Warning: Could not fetch tags for name resolution: %v\n", err)
+				slog.Warn("Could not fetch tags for name resolution", "error", err)
 				tagNames = make(map[int]string) // Empty map as fallback
 			}
 
 			// Convert to output format and display as JSON
 			output := convertDocToOutput(doc, tagNames)
-			if err := outputJSON(output); err != nil {
-				return fmt.Errorf("failed to output JSON: %w", err)
+			if err := writeOutput(output); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
 			}
 		} else {
+			selected, err := fields.selected()
+			if err != nil {
+				return err
+			}
+
 			// Fetch tag names for resolution (with caching)
-			tagNames, err := getTagNamesWithCache(ctx, client, *forceRefresh, DefaultCacheTTL)
+			tagNames, err := getTagNamesWithCache(ctx, client, *globals.forceRefresh, DefaultCacheTTL)
 			if err != nil {
 				// If tag fetching fails, continue but warn
-				fmt.Fprintf(os.Stderr, "Warning: Could not fetch tags for name resolution: %v\n", err)
+				slog.Warn("Could not fetch tags for name resolution", "error", err)
 				tagNames = make(map[int]string) // Empty map as fallback
 			}
 
 			// Fetch documents
-			var opts *paperless.ListOptions
-			if command == "search" {
-				opts = &paperless.ListOptions{
-					Query:     searchQuery,
-					TitleOnly: titleOnly,
-				}
+			opts := &paperless.ListOptions{Query: searchQuery, TitleOnly: titleOnly, IDs: stdinIDs}
+			if err := filters.apply(ctx, client, *globals.forceRefresh, opts); err != nil {
+				return err
 			}
-			docs, err := client.ListDocuments(ctx, opts)
+			docs, total, err := paging.fetch(ctx, client, opts)
 			if err != nil {
 				return fmt.Errorf("failed to %s documents: %w", command, err)
 			}
+			if stdinIDs != nil && *paging.all {
+				warnMissingDocuments(stdinIDs, docs)
+			}
 
 			// Convert documents to output format
-			results := make([]DocumentWithTagNames, len(docs.Results))
-			for i, doc := range docs.Results {
-				results[i] = convertDocToOutput(&doc, tagNames)
-			}
+			results := paperless.Map(docs, func(doc paperless.Document) DocumentWithTagNames {
+				return convertDocToOutput(&doc, tagNames)
+			})
 
 			// Output as JSON
-			output := DocumentListOutput{
-				Count:   docs.Count,
+			output, err := selectDocFields(DocumentListOutput{
+				Count:   len(results),
+				Total:   total,
 				Results: results,
+			}, selected)
+			if err != nil {
+				return fmt.Errorf("failed to select fields: %w", err)
 			}
-			if err := outputJSON(output); err != nil {
-				return fmt.Errorf("failed to output JSON: %w", err)
+			if err := writeListOutput(output, paging.meta(len(results), total)); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
 			}
 		}
 	case "tags":
@@ -473,8 +889,8 @@ func run() error {
 			}
 
 			// Output as JSON
-			if err := outputJSON(tag); err != nil {
-				return fmt.Errorf("failed to output JSON: %w", err)
+			if err := writeOutput(tag); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
 			}
 		} else {
 			// Fetch tags
@@ -490,9 +906,63 @@ func run() error {
 			}
 
 			// Output as JSON
-			if err := outputJSON(tags); err != nil {
-				return fmt.Errorf("failed to output JSON: %w", err)
+			if err := writeListOutput(tags, newListMeta(len(tags.Results), tags.Count, 1, defaultServerPageSize)); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+		}
+	case "correspondents":
+		if hasID {
+			result, err := client.GetCorrespondent(ctx, id)
+			if err != nil {
+				return fmt.Errorf("failed to get correspondent %d: %w", id, err)
 			}
+			if err := writeOutput(result); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			return nil
+		}
+		list, err := client.ListCorrespondents(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get correspondents: %w", err)
+		}
+		if err := writeListOutput(list, newListMeta(len(list.Results), list.Count, 1, defaultServerPageSize)); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	case "doctypes":
+		if hasID {
+			result, err := client.GetDocumentType(ctx, id)
+			if err != nil {
+				return fmt.Errorf("failed to get document type %d: %w", id, err)
+			}
+			if err := writeOutput(result); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			return nil
+		}
+		list, err := client.ListDocumentTypes(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get document types: %w", err)
+		}
+		if err := writeListOutput(list, newListMeta(len(list.Results), list.Count, 1, defaultServerPageSize)); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	case "storagepaths":
+		if hasID {
+			result, err := client.GetStoragePath(ctx, id)
+			if err != nil {
+				return fmt.Errorf("failed to get storage path %d: %w", id, err)
+			}
+			if err := writeOutput(result); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+			return nil
+		}
+		list, err := client.ListStoragePaths(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get storage paths: %w", err)
+		}
+		if err := writeListOutput(list, newListMeta(len(list.Results), list.Count, 1, defaultServerPageSize)); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
 		}
 	}
 
@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -818,3 +822,225 @@ func TestCLI_AddTag_Integration(t *testing.T) {
 		t.Errorf("Expected non-zero tag ID")
 	}
 }
+
+// newDeleteServer returns a server that accepts DELETE requests and records
+// the paths it received.
+func newDeleteServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("method = %s, want DELETE", r.Method)
+		}
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path == "/api/documents/404/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return server, &paths
+}
+
+func runDelete(t *testing.T, serverURL, stdin string, args ...string) (DeleteOutput, string, error) {
+	t.Helper()
+	cmd := exec.Command("./pgo", append([]string{"delete"}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"PAPERLESS_URL="+serverURL,
+		"PAPERLESS_TOKEN=test-token",
+	)
+	cmd.Stdin = strings.NewReader(stdin)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	var output DeleteOutput
+	if stdout.Len() > 0 {
+		if jsonErr := json.Unmarshal(stdout.Bytes(), &output); jsonErr != nil {
+			t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", jsonErr, stdout.String())
+		}
+	}
+	return output, stderr.String(), err
+}
+
+func TestCLI_DeleteDocs_Yes(t *testing.T) {
+	server, paths := newDeleteServer(t)
+
+	output, stderr, err := runDelete(t, server.URL, "", "docs", "1", "2", "--yes")
+	if err != nil {
+		t.Fatalf("Command failed: %v\nStderr: %s", err, stderr)
+	}
+	if !reflect.DeepEqual(output.Deleted, []int{1, 2}) {
+		t.Errorf("deleted = %v, want [1 2]", output.Deleted)
+	}
+	if want := []string{"/api/documents/1/", "/api/documents/2/"}; !reflect.DeepEqual(*paths, want) {
+		t.Errorf("paths = %v, want %v", *paths, want)
+	}
+}
+
+func TestCLI_DeleteTags_Confirmed(t *testing.T) {
+	server, paths := newDeleteServer(t)
+
+	output, stderr, err := runDelete(t, server.URL, "y\n", "tags", "5")
+	if err != nil {
+		t.Fatalf("Command failed: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "Delete tags 5? [y/N]") {
+		t.Errorf("Expected confirmation prompt, got: %s", stderr)
+	}
+	if !reflect.DeepEqual(output.Deleted, []int{5}) {
+		t.Errorf("deleted = %v, want [5]", output.Deleted)
+	}
+	if want := []string{"/api/tags/5/"}; !reflect.DeepEqual(*paths, want) {
+		t.Errorf("paths = %v, want %v", *paths, want)
+	}
+}
+
+func TestCLI_DeleteDocs_Aborted(t *testing.T) {
+	server, paths := newDeleteServer(t)
+
+	for _, answer := range []string{"n\n", "\n", ""} {
+		_, stderr, err := runDelete(t, server.URL, answer, "docs", "1")
+		if err == nil {
+			t.Errorf("answer %q: expected command to fail", answer)
+		}
+		if !strings.Contains(stderr, "aborted") {
+			t.Errorf("answer %q: expected 'aborted' in error output, got: %s", answer, stderr)
+		}
+	}
+	if len(*paths) != 0 {
+		t.Errorf("server received requests despite abort: %v", *paths)
+	}
+}
+
+func TestCLI_DeleteDocs_PartialFailure(t *testing.T) {
+	server, _ := newDeleteServer(t)
+
+	output, stderr, err := runDelete(t, server.URL, "", "docs", "1", "404", "2", "--yes")
+	if err == nil {
+		t.Fatal("Expected command to fail")
+	}
+	if !strings.Contains(stderr, "failed to delete docs (1 of 3 failed)") || !strings.Contains(stderr, "doc 404:") {
+		t.Errorf("Expected failure for 404 in error output, got: %s", stderr)
+	}
+	// The IDs after the failed one are still deleted
+	if !reflect.DeepEqual(output.Deleted, []int{1, 2}) {
+		t.Errorf("deleted = %v, want [1 2]", output.Deleted)
+	}
+	if len(output.Failed) != 1 || output.Failed[0].ID != 404 {
+		t.Errorf("failed = %+v, want ID 404", output.Failed)
+	}
+}
+
+func TestCLI_DeleteDocs_Concurrency(t *testing.T) {
+	server, paths := newDeleteServer(t)
+
+	cmd := exec.Command("./pgo", "-concurrency", "3", "delete", "docs", "1", "2", "3", "4", "404", "--yes")
+	cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err == nil {
+		t.Fatal("Expected command to fail")
+	}
+	var output DeleteOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, stdout.String())
+	}
+	// Output keeps the order of the arguments
+	if !reflect.DeepEqual(output.Deleted, []int{1, 2, 3, 4}) {
+		t.Errorf("deleted = %v, want [1 2 3 4]", output.Deleted)
+	}
+	if len(*paths) != 5 {
+		t.Errorf("server received %d requests, want 5", len(*paths))
+	}
+
+	cmd = exec.Command("./pgo", "-concurrency", "0", "delete", "docs", "1", "--yes")
+	cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token")
+	if out, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(out), "invalid -concurrency 0") {
+		t.Errorf("expected invalid -concurrency error, got %v: %s", err, out)
+	}
+}
+
+func TestCLI_DeleteDocs_InvalidID(t *testing.T) {
+	_, stderr, err := runDelete(t, "dummy", "", "docs", "abc", "--yes")
+	if err == nil {
+		t.Errorf("Expected command to fail with invalid ID")
+	}
+	if !strings.Contains(stderr, "invalid ID format") {
+		t.Errorf("Expected 'invalid ID format' in error output, got: %s", stderr)
+	}
+}
+
+func TestCLI_GetMetadataResources(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/correspondents/":
+			_, _ = w.Write([]byte(`{"count": 1, "results": [{"id": 1, "name": "ACME Corp"}]}`))
+		case "/api/document_types/2/":
+			_, _ = w.Write([]byte(`{"id": 2, "name": "Invoice"}`))
+		case "/api/storage_paths/":
+			_, _ = w.Write([]byte(`{"count": 1, "results": [{"id": 3, "name": "By year", "path": "{created_year}"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"get", "correspondents"}, `"name": "ACME Corp"`},
+		{[]string{"get", "doctypes", "2"}, `"name": "Invoice"`},
+		{[]string{"get", "storagepaths"}, `"path": "{created_year}"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			cmd := exec.Command("./pgo", tt.args...)
+			cmd.Env = append(os.Environ(),
+				"PAPERLESS_URL="+server.URL,
+				"PAPERLESS_TOKEN=test-token",
+			)
+
+			var stdout, stderr bytes.Buffer
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+			if err := cmd.Run(); err != nil {
+				t.Fatalf("Command failed: %v\nStderr: %s", err, stderr.String())
+			}
+			if !json.Valid(stdout.Bytes()) {
+				t.Fatalf("Output is not valid JSON: %s", stdout.String())
+			}
+			if !strings.Contains(stdout.String(), tt.want) {
+				t.Errorf("Expected %s in output, got: %s", tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestCLI_SearchMetadataResource(t *testing.T) {
+	cmd := exec.Command("./pgo", "search", "correspondents", "acme")
+	cmd.Env = append(os.Environ(),
+		"PAPERLESS_URL=dummy",
+		"PAPERLESS_TOKEN=dummy",
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err == nil {
+		t.Fatal("Expected command to fail")
+	}
+	if !strings.Contains(stderr.String(), "unknown resource for search: correspondents") {
+		t.Errorf("Expected unknown resource error, got: %s", stderr.String())
+	}
+}
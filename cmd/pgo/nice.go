@@ -0,0 +1,52 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// Settings of -nice, for running long jobs such as exports against a
+// server that people are using at the same time
+const (
+	niceRequestInterval   = 500 * time.Millisecond // Between the starts of requests
+	niceRetries           = 5                      // For 429 and 5xx responses
+	niceBackoffMultiplier = 4                      // Retries wait 0.8s, 1.6s, ... up to 20s
+)
+
+// niceMode is set by -nice
+var niceMode bool
+
+// newClient returns a client for conn that honors -nice, -dry-run, the
+// shared request rate limit and the readonly and production labels of its
+// profile
+func newClient(conn settings) *paperless.Client {
+	// Requests are logged at debug level, so -verbose shows them
+	opts := []paperless.Option{paperless.WithLogger(slog.Default())}
+	if niceMode {
+		opts = append(opts,
+			paperless.WithRequestInterval(niceRequestInterval),
+			paperless.WithRetries(niceRetries),
+			paperless.WithRetryBackoff(niceBackoffMultiplier),
+		)
+	}
+	if conn.MaxRate > 0 && dryRun == nil {
+		if path, err := paperless.DefaultRateLimitPath(conn.URL); err != nil {
+			slog.Warn("Not limiting the request rate", "error", err)
+		} else {
+			opts = append(opts, paperless.WithSharedRateLimit(path, conn.MaxRate))
+		}
+	}
+	if guard := protectedWriteGuard(conn.Profile); guard != nil && dryRun == nil {
+		opts = append(opts, paperless.WithRequestHook(guard))
+	}
+	if dryRun != nil {
+		opts = append(opts, paperless.WithHTTPClient(&http.Client{
+			Timeout:   30 * time.Second,
+			Transport: dryRun,
+		}))
+	}
+	return paperless.NewClient(conn.URL, conn.Token, opts...)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCLI_Nice(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		starts []time.Time
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cmd := exec.Command("./pgo", "-nice", "-concurrency", "4", "delete", "docs", "1", "2", "--yes")
+	cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Command failed: %v\nOutput: %s", err, out)
+	}
+	if !strings.Contains(string(out), "-nice limits -concurrency to 1") {
+		t.Errorf("expected concurrency warning, got: %s", out)
+	}
+	if len(starts) != 2 {
+		t.Fatalf("server received %d requests, want 2", len(starts))
+	}
+	if gap := starts[1].Sub(starts[0]); gap < niceRequestInterval-50*time.Millisecond {
+		t.Errorf("requests %v apart, want about %v", gap, niceRequestInterval)
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// NormalizeMerge is a duplicate correspondent merged into a canonical one
+type NormalizeMerge struct {
+	Canonical   string `json:"canonical"`
+	CanonicalID int    `json:"canonical_id"`
+	Duplicate   string `json:"duplicate"`
+	DuplicateID int    `json:"duplicate_id"`
+	Documents   []int  `json:"documents"`
+	Deleted     bool   `json:"deleted"`
+}
+
+// NormalizeOutput is the result of correspondents normalize
+type NormalizeOutput struct {
+	DryRun  bool             `json:"dry_run"`
+	Merges  []NormalizeMerge `json:"merges"`
+	Missing []string         `json:"missing"` // Aliases that matched no correspondent
+}
+
+// normalizeRule maps aliases (names or IDs) to a canonical correspondent
+type normalizeRule struct {
+	canonical string
+	aliases   []string
+}
+
+// parseNormalizeMap parses a map file of canonical names to their aliases:
+//
+//	Amazon:
+//	  - Amazon.com Inc
+//	  - AMAZON EU S.a.r.l.
+//	Deutsche Telekom: [Telekom, "T-Mobile", 17]
+//
+// Names match case-insensitively; a number matches a correspondent ID.
+func parseNormalizeMap(data []byte) ([]normalizeRule, error) {
+	value, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	obj, ok := value.(object)
+	if !ok {
+		return nil, fmt.Errorf("expected a mapping of canonical names to aliases")
+	}
+
+	var rules []normalizeRule
+	for _, f := range obj {
+		rule := normalizeRule{canonical: f.key}
+		switch v := f.value.(type) {
+		case string:
+			rule.aliases = []string{v}
+		case []interface{}:
+			for _, item := range v {
+				alias, ok := item.(string)
+				if !ok || alias == "" {
+					return nil, fmt.Errorf("%s: aliases must be names or IDs", f.key)
+				}
+				rule.aliases = append(rule.aliases, alias)
+			}
+		default:
+			return nil, fmt.Errorf("%s: expected a list of aliases", f.key)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// correspondentIndex looks up correspondents by ID or by name
+type correspondentIndex struct {
+	byID   map[int]paperless.Correspondent
+	byName map[string][]paperless.Correspondent
+}
+
+func newCorrespondentIndex(correspondents []paperless.Correspondent) *correspondentIndex {
+	idx := &correspondentIndex{
+		byID:   make(map[int]paperless.Correspondent),
+		byName: make(map[string][]paperless.Correspondent),
+	}
+	for _, c := range correspondents {
+		idx.byID[c.ID] = c
+		key := strings.ToLower(strings.TrimSpace(c.Name))
+		idx.byName[key] = append(idx.byName[key], c)
+	}
+	return idx
+}
+
+// lookup returns the correspondents matching a name or ID. A name can match
+// several correspondents that differ only in case.
+func (idx *correspondentIndex) lookup(ref string) []paperless.Correspondent {
+	if id, err := strconv.Atoi(ref); err == nil {
+		if c, ok := idx.byID[id]; ok {
+			return []paperless.Correspondent{c}
+		}
+	}
+	return idx.byName[strings.ToLower(strings.TrimSpace(ref))]
+}
+
+// planNormalize resolves the rules into merges. Documents are filled in
+// later.
+func planNormalize(rules []normalizeRule, idx *correspondentIndex) ([]NormalizeMerge, []string, error) {
+	merges := []NormalizeMerge{}
+	missing := []string{}
+	merged := make(map[int]string) // duplicate ID -> canonical name
+	canonicals := make(map[int]bool)
+
+	for _, rule := range rules {
+		targets := idx.lookup(rule.canonical)
+		if len(targets) == 0 {
+			return nil, nil, fmt.Errorf("canonical correspondent %q not found", rule.canonical)
+		}
+		// If names differ only in case, the exact match is canonical
+		canonical := targets[0]
+		for _, t := range targets {
+			if t.Name == rule.canonical {
+				canonical = t
+			}
+		}
+
+		// Correspondents matching the canonical name in another case are
+		// duplicates too
+		refs := rule.aliases
+		if len(targets) > 1 {
+			refs = append([]string{rule.canonical}, refs...)
+		}
+		for _, alias := range refs {
+			dups := idx.lookup(alias)
+			if len(dups) == 0 {
+				missing = append(missing, alias)
+				continue
+			}
+			for _, dup := range dups {
+				if dup.ID == canonical.ID {
+					continue
+				}
+				if other, ok := merged[dup.ID]; ok {
+					if other == canonical.Name {
+						continue
+					}
+					return nil, nil, fmt.Errorf("%q is an alias of both %q and %q", dup.Name, other, canonical.Name)
+				}
+				merged[dup.ID] = canonical.Name
+				merges = append(merges, NormalizeMerge{
+					Canonical:   canonical.Name,
+					CanonicalID: canonical.ID,
+					Duplicate:   dup.Name,
+					DuplicateID: dup.ID,
+					Documents:   []int{},
+				})
+			}
+		}
+		canonicals[canonical.ID] = true
+	}
+
+	for _, m := range merges {
+		if canonicals[m.DuplicateID] {
+			return nil, nil, fmt.Errorf("%q is both a canonical correspondent and an alias", m.Duplicate)
+		}
+	}
+	return merges, missing, nil
+}
+
+// normalizeFlags are the flags of correspondents normalize
+type normalizeFlags struct {
+	mapPath  *string
+	planOnly *bool
+	yes      *bool
+}
+
+func addNormalizeFlags(fs *flag.FlagSet) *normalizeFlags {
+	return &normalizeFlags{
+		mapPath:  fs.String("map", "", "YAML file mapping canonical correspondent names to their aliases"),
+		planOnly: fs.Bool("dry-run", false, "Show the merges without changing anything"),
+		yes:      fs.Bool("yes", false, "Merge without asking for confirmation"),
+	}
+}
+
+func runNormalize(client *paperless.Client, args []string) error {
+	fs := flag.NewFlagSet("correspondents normalize", flag.ContinueOnError)
+	flags := addNormalizeFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return usagef("parse normalize flags: %w", err)
+	}
+	mapPath, planOnly, yes := flags.mapPath, flags.planOnly, flags.yes
+	if *mapPath == "" || fs.NArg() != 0 {
+		return commandUsage("correspondents normalize")
+	}
+
+	data, err := os.ReadFile(*mapPath)
+	if err != nil {
+		return fmt.Errorf("failed to read map: %w", err)
+	}
+	rules, err := parseNormalizeMap(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *mapPath, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	correspondents, err := listAllCorrespondents(ctx, client)
+	if err != nil {
+		return err
+	}
+	merges, missing, err := planNormalize(rules, newCorrespondentIndex(correspondents))
+	if err != nil {
+		return err
+	}
+	for _, alias := range missing {
+		slog.Warn(fmt.Sprintf("No correspondent matches %q", alias))
+	}
+
+	documents := 0
+	for i := range merges {
+		ids, err := correspondentDocumentIDs(ctx, client, merges[i].DuplicateID)
+		if err != nil {
+			return err
+		}
+		merges[i].Documents = ids
+		documents += len(ids)
+	}
+
+	output := NormalizeOutput{DryRun: *planOnly, Merges: merges, Missing: missing}
+	if *planOnly || len(merges) == 0 {
+		if err := writeOutput(output); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		return nil
+	}
+
+	if !*yes && dryRun == nil {
+		prompt := fmt.Sprintf("Merge %d correspondents (%d documents) and delete the duplicates? [y/N]: ", len(merges), documents)
+		ok, err := confirm(os.Stdin, os.Stderr, prompt)
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("aborted; pass -yes to merge without confirmation")
+		}
+	}
+
+	// Merge in order, reporting what was done even if a later merge fails
+	for i := range output.Merges {
+		if err := mergeCorrespondent(ctx, client, output.Merges[i]); err != nil {
+			if outErr := writeOutput(output); outErr != nil {
+				return fmt.Errorf("failed to write output: %w", outErr)
+			}
+			return err
+		}
+		output.Merges[i].Deleted = true
+	}
+
+	if err := writeOutput(output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// mergeCorrespondent moves the documents of a duplicate to the canonical
+// correspondent and deletes the duplicate. Deleting a correspondent clears
+// it on its documents, so the duplicate is only deleted once none are left.
+func mergeCorrespondent(ctx context.Context, client *paperless.Client, m NormalizeMerge) error {
+	if len(m.Documents) > 0 {
+		params := map[string]interface{}{"correspondent": m.CanonicalID}
+		if err := client.BulkEditDocuments(ctx, m.Documents, paperless.BulkSetCorrespondent, params); err != nil {
+			return fmt.Errorf("failed to reassign documents of %q: %w", m.Duplicate, err)
+		}
+	}
+
+	remaining, err := correspondentDocumentIDs(ctx, client, m.DuplicateID)
+	if err != nil {
+		return err
+	}
+	if len(remaining) > 0 {
+		return fmt.Errorf("not deleting %q: documents %s still use it", m.Duplicate, joinIDs(remaining))
+	}
+
+	if err := client.DeleteCorrespondent(ctx, m.DuplicateID); err != nil {
+		return fmt.Errorf("failed to delete correspondent %d: %w", m.DuplicateID, err)
+	}
+	return nil
+}
+
+// listAllCorrespondents fetches every page of correspondents
+func listAllCorrespondents(ctx context.Context, client *paperless.Client) ([]paperless.Correspondent, error) {
+	var all []paperless.Correspondent
+	opts := &paperless.ListOptions{PageSize: 100, Page: 1}
+	for {
+		list, err := client.ListCorrespondents(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch correspondents: %w", err)
+		}
+		all = append(all, list.Results...)
+		if list.Next == nil || *list.Next == "" {
+			return all, nil
+		}
+		opts.Page++
+	}
+}
+
+// correspondentDocumentIDs returns the IDs of all documents of a
+// correspondent
+func correspondentDocumentIDs(ctx context.Context, client *paperless.Client, correspondentID int) ([]int, error) {
+	ids, err := client.ListDocumentIDs(ctx, &paperless.ListOptions{CorrespondentIDs: []int{correspondentID}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents of correspondent %d: %w", correspondentID, err)
+	}
+	if ids == nil {
+		ids = []int{}
+	}
+	return ids, nil
+}
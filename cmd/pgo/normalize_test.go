@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+func TestParseNormalizeMap(t *testing.T) {
+	rules, err := parseNormalizeMap([]byte("Amazon:\n  - Amazon.com Inc\n  - 7\nTelekom: T-Mobile\n"))
+	if err != nil {
+		t.Fatalf("parseNormalizeMap failed: %v", err)
+	}
+	want := []normalizeRule{
+		{canonical: "Amazon", aliases: []string{"Amazon.com Inc", "7"}},
+		{canonical: "Telekom", aliases: []string{"T-Mobile"}},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("rules = %#v, want %#v", rules, want)
+	}
+
+	for _, input := range []string{"- Amazon\n", "Amazon:\n  x: y\n", "Amazon:\n  - - nested\n"} {
+		if _, err := parseNormalizeMap([]byte(input)); err == nil {
+			t.Errorf("parseNormalizeMap(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestPlanNormalize(t *testing.T) {
+	idx := newCorrespondentIndex([]paperless.Correspondent{
+		{ID: 1, Name: "Amazon"},
+		{ID: 2, Name: "Amazon.com Inc"},
+		{ID: 3, Name: "AMAZON"},
+		{ID: 4, Name: "Amazon EU"},
+		{ID: 5, Name: "Telekom"},
+	})
+
+	merges, missing, err := planNormalize([]normalizeRule{
+		{canonical: "Amazon", aliases: []string{"amazon.com inc", "4", "Amazon.com Inc", "Nope"}},
+	}, idx)
+	if err != nil {
+		t.Fatalf("planNormalize failed: %v", err)
+	}
+	var dups []int
+	for _, m := range merges {
+		if m.CanonicalID != 1 {
+			t.Errorf("merge %+v: canonical ID = %d, want 1", m, m.CanonicalID)
+		}
+		dups = append(dups, m.DuplicateID)
+	}
+	if !reflect.DeepEqual(dups, []int{3, 2, 4}) {
+		t.Errorf("duplicates = %v, want [3 2 4]", dups)
+	}
+	if !reflect.DeepEqual(missing, []string{"Nope"}) {
+		t.Errorf("missing = %v, want [Nope]", missing)
+	}
+
+	tests := []struct {
+		name  string
+		rules []normalizeRule
+		want  string
+	}{
+		{"missing canonical", []normalizeRule{{canonical: "Apple", aliases: []string{"Amazon"}}}, `"Apple" not found`},
+		{"two canonicals", []normalizeRule{
+			{canonical: "Amazon", aliases: []string{"Amazon EU"}},
+			{canonical: "Telekom", aliases: []string{"Amazon EU"}},
+		}, "alias of both"},
+		{"canonical is alias", []normalizeRule{
+			{canonical: "Amazon", aliases: []string{"Telekom"}},
+			{canonical: "Telekom", aliases: []string{"Amazon EU"}},
+		}, "both a canonical correspondent and an alias"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := planNormalize(tt.rules, idx)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("planNormalize error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// newNormalizeServer serves correspondents and documents and applies
+// set_correspondent bulk edits and correspondent deletes
+func newNormalizeServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var calls []string
+	correspondents := map[int]string{1: "Amazon", 2: "Amazon.com Inc", 3: "AMAZON EU"}
+	docs := map[int]int{10: 2, 11: 2, 12: 3, 13: 1} // document -> correspondent
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/correspondents/":
+			results := []paperless.Correspondent{}
+			for id := 1; id <= 3; id++ {
+				if name, ok := correspondents[id]; ok {
+					results = append(results, paperless.Correspondent{ID: id, Name: name})
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"count": len(results), "results": results})
+		case r.Method == "GET" && r.URL.Path == "/api/documents/":
+			results := []paperless.Document{}
+			for id := 10; id <= 13; id++ {
+				if c, ok := docs[id]; ok && r.URL.Query().Get("correspondent__id__in") == strconv.Itoa(c) {
+					results = append(results, paperless.Document{ID: id})
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"count": len(results), "results": results})
+		case r.Method == "POST" && r.URL.Path == "/api/documents/bulk_edit/":
+			var req struct {
+				Documents  []int
+				Method     string
+				Parameters map[string]int
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			calls = append(calls, "bulk_edit "+req.Method+" "+joinIDs(req.Documents)+" -> "+strconv.Itoa(req.Parameters["correspondent"]))
+			for _, id := range req.Documents {
+				docs[id] = req.Parameters["correspondent"]
+			}
+			w.Write([]byte(`{"result": "OK"}`))
+		case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/api/correspondents/"):
+			calls = append(calls, "DELETE "+r.URL.Path)
+			id, _ := strconv.Atoi(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/correspondents/"), "/"))
+			delete(correspondents, id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func runNormalizeCLI(t *testing.T, serverURL, mapFile, stdin string, args ...string) (NormalizeOutput, string, error) {
+	t.Helper()
+	mapPath := filepath.Join(t.TempDir(), "map.yaml")
+	if err := os.WriteFile(mapPath, []byte(mapFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("./pgo", append([]string{"correspondents", "normalize", "-map", mapPath}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"PAPERLESS_URL="+serverURL,
+		"PAPERLESS_TOKEN=test-token",
+	)
+	cmd.Stdin = strings.NewReader(stdin)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	var output NormalizeOutput
+	if stdout.Len() > 0 {
+		if jsonErr := json.Unmarshal(stdout.Bytes(), &output); jsonErr != nil {
+			t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", jsonErr, stdout.String())
+		}
+	}
+	return output, stderr.String(), err
+}
+
+const testNormalizeMap = `Amazon:
+  - Amazon.com Inc
+  - amazon eu
+  - Amazon Prime
+`
+
+func TestCLI_Normalize_DryRun(t *testing.T) {
+	server, calls := newNormalizeServer(t)
+
+	output, stderr, err := runNormalizeCLI(t, server.URL, testNormalizeMap, "", "-dry-run")
+	if err != nil {
+		t.Fatalf("Command failed: %v\nStderr: %s", err, stderr)
+	}
+	if !output.DryRun || len(output.Merges) != 2 {
+		t.Fatalf("output = %+v, want a dry run with 2 merges", output)
+	}
+	if m := output.Merges[0]; m.DuplicateID != 2 || m.CanonicalID != 1 || !reflect.DeepEqual(m.Documents, []int{10, 11}) || m.Deleted {
+		t.Errorf("first merge = %+v", m)
+	}
+	if !reflect.DeepEqual(output.Missing, []string{"Amazon Prime"}) {
+		t.Errorf("missing = %v, want [Amazon Prime]", output.Missing)
+	}
+	if !strings.Contains(stderr, `Warning: No correspondent matches "Amazon Prime"`) {
+		t.Errorf("expected missing alias warning, got stderr: %s", stderr)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("dry run changed data: %v", *calls)
+	}
+}
+
+func TestCLI_Normalize_Yes(t *testing.T) {
+	server, calls := newNormalizeServer(t)
+
+	output, stderr, err := runNormalizeCLI(t, server.URL, testNormalizeMap, "", "-yes")
+	if err != nil {
+		t.Fatalf("Command failed: %v\nStderr: %s", err, stderr)
+	}
+	for _, m := range output.Merges {
+		if !m.Deleted {
+			t.Errorf("merge %+v not deleted", m)
+		}
+	}
+
+	want := []string{
+		"bulk_edit set_correspondent 10, 11 -> 1",
+		"DELETE /api/correspondents/2/",
+		"bulk_edit set_correspondent 12 -> 1",
+		"DELETE /api/correspondents/3/",
+	}
+	if !reflect.DeepEqual(*calls, want) {
+		t.Errorf("calls = %v, want %v", *calls, want)
+	}
+}
+
+func TestCLI_Normalize_Declined(t *testing.T) {
+	server, calls := newNormalizeServer(t)
+
+	_, stderr, err := runNormalizeCLI(t, server.URL, testNormalizeMap, "n\n")
+	if err == nil {
+		t.Fatal("Expected command to fail when confirmation is declined")
+	}
+	if !strings.Contains(stderr, "Merge 2 correspondents (3 documents)") || !strings.Contains(stderr, "aborted") {
+		t.Errorf("unexpected stderr: %s", stderr)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("declined merge changed data: %v", *calls)
+	}
+}
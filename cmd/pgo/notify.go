@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// notifier delivers a notification to the user
+type notifier interface {
+	Notify(ctx context.Context, title, message string) error
+}
+
+// newNotifier returns the notifier for a -notify target:
+//
+//	desktop                 desktop notification (notify-send or osascript)
+//	ntfy://ntfy.sh/<topic>  ntfy topic, sent over HTTPS
+//	https://example/hook    webhook receiving {"title": ..., "message": ...}
+func newNotifier(target string) (notifier, error) {
+	if target == "desktop" {
+		return desktopNotifier{}, nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid notify target %q (use desktop, ntfy://<host>/<topic> or an http(s) webhook URL)", target)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	switch u.Scheme {
+	case "ntfy":
+		u.Scheme = "https"
+		return ntfyNotifier{client: client, url: u.String()}, nil
+	case "http", "https":
+		return webhookNotifier{client: client, url: u.String()}, nil
+	}
+	return nil, fmt.Errorf("unsupported notify scheme: %s", u.Scheme)
+}
+
+// desktopNotifier shows a desktop notification
+type desktopNotifier struct{}
+
+func (desktopNotifier) Notify(ctx context.Context, title, message string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	} else {
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=pgo", title, message)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", cmd.Path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// ntfyNotifier publishes to an ntfy topic
+type ntfyNotifier struct {
+	client *http.Client
+	url    string
+}
+
+func (n ntfyNotifier) Notify(ctx context.Context, title, message string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", n.url, strings.NewReader(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", title)
+	return sendNotification(n.client, req)
+}
+
+// webhookNotifier posts a JSON payload to a URL
+type webhookNotifier struct {
+	client *http.Client
+	url    string
+}
+
+func (n webhookNotifier) Notify(ctx context.Context, title, message string) error {
+	body, err := json.Marshal(map[string]string{"title": title, "message": message})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return sendNotification(n.client, req)
+}
+
+func sendNotification(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewNotifier(t *testing.T) {
+	tests := []struct {
+		target string
+		want   notifier
+	}{
+		{"desktop", desktopNotifier{}},
+		{"ntfy://ntfy.sh/scans", ntfyNotifier{url: "https://ntfy.sh/scans"}},
+		{"https://hooks.example.com/pgo", webhookNotifier{url: "https://hooks.example.com/pgo"}},
+	}
+	for _, tt := range tests {
+		n, err := newNotifier(tt.target)
+		if err != nil {
+			t.Fatalf("newNotifier(%q) failed: %v", tt.target, err)
+		}
+		// Compare without the HTTP client
+		switch v := n.(type) {
+		case ntfyNotifier:
+			v.client = nil
+			n = v
+		case webhookNotifier:
+			v.client = nil
+			n = v
+		}
+		if n != tt.want {
+			t.Errorf("newNotifier(%q) = %#v, want %#v", tt.target, n, tt.want)
+		}
+	}
+
+	for _, target := range []string{"slack", "ftp://example.com/x", "https://"} {
+		if _, err := newNotifier(target); err == nil {
+			t.Errorf("newNotifier(%q) succeeded, want error", target)
+		}
+	}
+}
+
+func TestNotifiers_HTTP(t *testing.T) {
+	var gotTitle, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotTitle, gotBody = r.Header.Get("Title"), string(body)
+	}))
+	defer server.Close()
+
+	ntfy := ntfyNotifier{client: server.Client(), url: server.URL}
+	if err := ntfy.Notify(context.Background(), "Done", "scan.pdf is document 42"); err != nil {
+		t.Fatalf("ntfy Notify failed: %v", err)
+	}
+	if gotTitle != "Done" || gotBody != "scan.pdf is document 42" {
+		t.Errorf("ntfy request = %q %q", gotTitle, gotBody)
+	}
+
+	hook := webhookNotifier{client: server.Client(), url: server.URL}
+	if err := hook.Notify(context.Background(), "Done", "scan.pdf"); err != nil {
+		t.Fatalf("webhook Notify failed: %v", err)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal([]byte(gotBody), &payload); err != nil || payload["title"] != "Done" || payload["message"] != "scan.pdf" {
+		t.Errorf("webhook payload = %q", gotBody)
+	}
+}
+
+func TestNotifiers_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	hook := webhookNotifier{client: server.Client(), url: server.URL}
+	if err := hook.Notify(context.Background(), "t", "m"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected 403 error, got %v", err)
+	}
+}
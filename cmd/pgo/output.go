@@ -0,0 +1,696 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// Output formats accepted by -output-format
+const (
+	formatJSON   = "json"
+	formatTable  = "table"
+	formatCSV    = "csv"
+	formatYAML   = "yaml"
+	formatPretty = "pretty"
+)
+
+// maxTableCell is the width at which table cells are truncated
+const maxTableCell = 60
+
+// maxPrettyContent is the width at which document content is truncated in
+// pretty output, so that the other columns still fit on a line
+const maxPrettyContent = 40
+
+// outputFormat is the format used by writeOutput
+var outputFormat = formatJSON
+
+// outputColor colors pretty output. It is set when stdout is a terminal,
+// unless NO_COLOR is set or -plain is given.
+var outputColor bool
+
+// outputTemplate, if set, replaces outputFormat with a Go template
+var outputTemplate *template.Template
+
+// plainOutput makes output deterministic, see normalizePlain
+var plainOutput bool
+
+// volatileFields are fields that change between runs with the same data.
+// They are dropped in plain mode.
+var volatileFields = map[string]bool{
+	"fetched_at": true,
+	"elapsed_ms": true,
+}
+
+// withMeta adds a "meta" object to list output, set by -with-meta
+var withMeta bool
+
+// commandStart is when pgo started, for the elapsed time of list output
+var commandStart = time.Now()
+
+// defaultServerPageSize is the page size Paperless uses when none is given
+const defaultServerPageSize = 25
+
+// ListMeta summarizes a list result. It is printed as a footer under table
+// output and added to other formats as "meta" with -with-meta.
+type ListMeta struct {
+	Count     int   `json:"count"`           // Results in the output
+	Total     int   `json:"total"`           // Matches reported by Paperless
+	Page      int   `json:"page,omitempty"`  // Page shown, if a single page was fetched
+	Pages     int   `json:"pages,omitempty"` // Pages at the page size used
+	ElapsedMS int64 `json:"elapsed_ms"`
+}
+
+// newListMeta returns the meta of count results out of total. page and
+// pageSize describe the page fetched, or are 0 if several were.
+func newListMeta(count, total, page, pageSize int) ListMeta {
+	meta := ListMeta{Count: count, Total: total, Page: page, ElapsedMS: time.Since(commandStart).Milliseconds()}
+	if page > 0 && pageSize > 0 {
+		meta.Pages = max((total+pageSize-1)/pageSize, 1)
+	}
+	return meta
+}
+
+// footer is the summary line printed under tables. Plain output has no
+// elapsed time.
+func (m ListMeta) footer(plain bool) string {
+	parts := []string{fmt.Sprintf("%d of %d results", m.Count, m.Total)}
+	if m.Pages > 1 {
+		parts = append(parts, fmt.Sprintf("page %d of %d", m.Page, m.Pages))
+	}
+	if !plain {
+		parts = append(parts, (time.Duration(m.ElapsedMS) * time.Millisecond).String())
+	}
+	return strings.Join(parts, " · ")
+}
+
+// writeListOutput writes a list result like writeOutput, followed by a
+// footer for table output. With -with-meta, meta is added to v as "meta".
+func writeListOutput(v interface{}, meta ListMeta) error {
+	if withMeta {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		metaData, err := json.Marshal(meta)
+		if err != nil {
+			return err
+		}
+		sep := ","
+		if bytes.Equal(bytes.TrimSpace(data), []byte("{}")) {
+			sep = ""
+		}
+		data = bytes.TrimRight(bytes.TrimSpace(data), "}")
+		v = json.RawMessage(string(data) + sep + `"meta":` + string(metaData) + "}")
+	}
+	if err := writeOutput(v); err != nil {
+		return err
+	}
+	if (outputFormat == formatTable || outputFormat == formatPretty) && outputTemplate == nil && outputQuery == nil && dryRun == nil {
+		footer := meta.footer(plainOutput)
+		if outputFormat == formatPretty && outputColor {
+			footer = ansiDim + footer + ansiReset
+		}
+		_, err := fmt.Fprintln(os.Stdout, footer)
+		return err
+	}
+	return nil
+}
+
+// configureOutput validates the -output-format, -template and -plain flags.
+// Without a format, output is pretty on a terminal and JSON otherwise, so
+// that scripts reading pgo's output through a pipe always get JSON.
+func configureOutput(format, tmpl string, plain bool) error {
+	plainOutput = plain
+
+	terminal := isTerminal(os.Stdout)
+	switch format {
+	case "":
+		format = formatJSON
+		if terminal {
+			format = formatPretty
+		}
+	case formatJSON, formatTable, formatCSV, formatYAML, formatPretty:
+	default:
+		return fmt.Errorf("unsupported output format: %s (supported: pretty, json, table, csv, yaml)", format)
+	}
+	outputFormat = format
+	outputColor = terminal && !plain && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+
+	if tmpl != "" {
+		t, err := template.New("output").Option("missingkey=zero").Parse(tmpl)
+		if err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+		outputTemplate = t
+	}
+	return nil
+}
+
+// writeOutput writes v to stdout in the configured format
+func writeOutput(v interface{}) error {
+	// With -dry-run the planned requests are printed instead
+	if dryRun != nil {
+		return nil
+	}
+	return render(os.Stdout, v, outputFormat, outputTemplate, plainOutput)
+}
+
+// render writes v to w. All formats are derived from the JSON encoding of v,
+// so field names and order match the json output.
+func render(w io.Writer, v interface{}, format string, tmpl *template.Template, plain bool) error {
+	if tmpl == nil && format == formatJSON && !plain && outputQuery == nil {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	value, err := decodeOrdered(data)
+	if err != nil {
+		return err
+	}
+	if outputQuery != nil {
+		value = outputQuery.apply(value)
+		data = []byte(compactJSON(value))
+	}
+	if plain {
+		value = normalizePlain(value)
+		data = []byte(compactJSON(value))
+	}
+
+	if tmpl != nil {
+		var generic interface{}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&generic); err != nil {
+			return err
+		}
+		if err := tmpl.Execute(w, generic); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+
+	switch format {
+	case formatJSON:
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, data, "", "  "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err := buf.WriteTo(w)
+		return err
+	case formatYAML:
+		_, err := io.WriteString(w, strings.Join(yamlLines(value), "\n")+"\n")
+		return err
+	case formatCSV:
+		return writeCSV(w, value)
+	case formatPretty:
+		return writePretty(w, value, outputColor)
+	default:
+		return writeTable(w, value)
+	}
+}
+
+// field is a key/value pair of a JSON object
+type field struct {
+	key   string
+	value interface{}
+}
+
+// object is a JSON object with its key order preserved
+type object []field
+
+// decodeOrdered decodes JSON into objects, []interface{} and scalars,
+// keeping the key order of objects
+func decodeOrdered(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeValue(dec)
+}
+
+func decodeValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		obj := object{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, field{key: keyTok.(string), value: value})
+		}
+		_, err := dec.Token()
+		return obj, err
+	default:
+		arr := []interface{}{}
+		for dec.More() {
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		_, err := dec.Token()
+		return arr, err
+	}
+}
+
+// normalizePlain makes value independent of server and run order: object
+// keys are sorted, arrays of objects with an "id" are sorted by it, and
+// volatile fields are removed. Arrays of scalars keep their order, so
+// parallel fields such as tags and tag_names stay aligned.
+func normalizePlain(value interface{}) interface{} {
+	switch v := value.(type) {
+	case object:
+		out := make(object, 0, len(v))
+		for _, f := range v {
+			if volatileFields[f.key] {
+				continue
+			}
+			out = append(out, field{key: f.key, value: normalizePlain(f.value)})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].key < out[j].key })
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		ids := make([]float64, len(v))
+		sortable := true
+		for i, item := range v {
+			out[i] = normalizePlain(item)
+			ids[i], sortable = objectID(out[i], sortable)
+		}
+		if sortable {
+			sort.Stable(byID{items: out, ids: ids})
+		}
+		return out
+	}
+	return value
+}
+
+// objectID returns the numeric "id" of an object; ok is false if it has none
+func objectID(value interface{}, ok bool) (float64, bool) {
+	obj, isObj := value.(object)
+	if !ok || !isObj {
+		return 0, false
+	}
+	n, isNum := obj.lookup("id").(json.Number)
+	if !isNum {
+		return 0, false
+	}
+	id, err := n.Float64()
+	return id, err == nil
+}
+
+// byID sorts items by their parallel ids
+type byID struct {
+	items []interface{}
+	ids   []float64
+}
+
+func (s byID) Len() int           { return len(s.items) }
+func (s byID) Less(i, j int) bool { return s.ids[i] < s.ids[j] }
+func (s byID) Swap(i, j int) {
+	s.items[i], s.items[j] = s.items[j], s.items[i]
+	s.ids[i], s.ids[j] = s.ids[j], s.ids[i]
+}
+
+// yamlLines renders value as YAML lines without a trailing newline
+func yamlLines(value interface{}) []string {
+	switch v := value.(type) {
+	case object:
+		if len(v) == 0 {
+			return []string{"{}"}
+		}
+		var lines []string
+		for _, f := range v {
+			nested := yamlLines(f.value)
+			if isYAMLScalar(f.value) {
+				lines = append(lines, yamlString(f.key)+": "+nested[0])
+				continue
+			}
+			lines = append(lines, yamlString(f.key)+":")
+			for _, line := range nested {
+				lines = append(lines, "  "+line)
+			}
+		}
+		return lines
+	case []interface{}:
+		if len(v) == 0 {
+			return []string{"[]"}
+		}
+		var lines []string
+		for _, item := range v {
+			for i, line := range yamlLines(item) {
+				if i == 0 {
+					lines = append(lines, "- "+line)
+				} else {
+					lines = append(lines, "  "+line)
+				}
+			}
+		}
+		return lines
+	case string:
+		return []string{yamlString(v)}
+	case nil:
+		return []string{"null"}
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// isYAMLScalar reports whether value renders on a single line
+func isYAMLScalar(value interface{}) bool {
+	switch v := value.(type) {
+	case object:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return true
+}
+
+var (
+	yamlPlain    = regexp.MustCompile(`^[A-Za-z_./][A-Za-z0-9_./()@+-]*( [A-Za-z0-9_./()@+-]+)*$`)
+	yamlReserved = map[string]bool{"true": true, "false": true, "null": true, "yes": true, "no": true, "on": true, "off": true, "y": true, "n": true, "~": true}
+)
+
+// yamlString returns s as a plain scalar when that is unambiguous and as a
+// double-quoted scalar otherwise
+func yamlString(s string) string {
+	if yamlPlain.MatchString(s) && !yamlReserved[strings.ToLower(s)] {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// tabular extracts rows from value: the results of a list, the elements of
+// an array, or a single object as one row
+func tabular(value interface{}) (headers []string, rows []object) {
+	items := []interface{}{value}
+	switch v := value.(type) {
+	case []interface{}:
+		items = v
+	case object:
+		for _, f := range v {
+			if results, ok := f.value.([]interface{}); ok && f.key == "results" {
+				items = results
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, item := range items {
+		obj, ok := item.(object)
+		if !ok {
+			obj = object{{key: "value", value: item}}
+		}
+		for _, f := range obj {
+			if !seen[f.key] {
+				seen[f.key] = true
+				headers = append(headers, f.key)
+			}
+		}
+		rows = append(rows, obj)
+	}
+	return headers, rows
+}
+
+// cell formats a value for a table or CSV cell
+func cell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			if !isYAMLScalar(item) {
+				return compactJSON(v)
+			}
+			parts[i] = cell(item)
+		}
+		return strings.Join(parts, ",")
+	case object:
+		return compactJSON(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// compactJSON re-encodes an ordered value as single-line JSON
+func compactJSON(value interface{}) string {
+	var b strings.Builder
+	writeCompactJSON(&b, value)
+	return b.String()
+}
+
+func writeCompactJSON(b *strings.Builder, value interface{}) {
+	switch v := value.(type) {
+	case object:
+		b.WriteByte('{')
+		for i, f := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, _ := json.Marshal(f.key)
+			b.Write(key)
+			b.WriteByte(':')
+			writeCompactJSON(b, f.value)
+		}
+		b.WriteByte('}')
+	case []interface{}:
+		b.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeCompactJSON(b, item)
+		}
+		b.WriteByte(']')
+	default:
+		data, _ := json.Marshal(v)
+		b.Write(data)
+	}
+}
+
+// lookup returns the value of key in obj
+func (obj object) lookup(key string) interface{} {
+	for _, f := range obj {
+		if f.key == key {
+			return f.value
+		}
+	}
+	return nil
+}
+
+// writeCSV writes rows as CSV with a header row
+func writeCSV(w io.Writer, value interface{}) error {
+	headers, rows := tabular(value)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(headers); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := make([]string, len(headers))
+		for i, h := range headers {
+			record[i] = cell(row.lookup(h))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeTable writes rows as aligned columns. A single object is shown as
+// field/value pairs. Long cells are truncated and newlines flattened.
+func writeTable(w io.Writer, value interface{}) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if obj, ok := value.(object); ok && obj.lookup("results") == nil {
+		fmt.Fprintln(tw, "FIELD\tVALUE")
+		for _, f := range obj {
+			fmt.Fprintf(tw, "%s\t%s\n", f.key, tableCell(f.value))
+		}
+		return tw.Flush()
+	}
+
+	headers, rows := tabular(value)
+	upper := make([]string, len(headers))
+	for i, h := range headers {
+		upper[i] = strings.ToUpper(h)
+	}
+	fmt.Fprintln(tw, strings.Join(upper, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(headers))
+		for i, h := range headers {
+			cells[i] = tableCell(row.lookup(h))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func tableCell(value interface{}) string {
+	s := strings.Join(strings.Fields(cell(value)), " ")
+	if r := []rune(s); len(r) > maxTableCell {
+		return string(r[:maxTableCell-1]) + "…"
+	}
+	return s
+}
+
+// ANSI escape sequences of pretty output
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// statusColors color the values of status fields such as the state of a
+// task or the result of applying tags, by lowercased value
+var statusColors = map[string]string{
+	"success": ansiGreen, "ok": ansiGreen, "done": ansiGreen, "completed": ansiGreen, "applied": ansiGreen,
+	"failure": ansiRed, "failed": ansiRed, "error": ansiRed, "revoked": ansiRed,
+	"pending": ansiYellow, "started": ansiYellow, "running": ansiYellow, "queued": ansiYellow, "retry": ansiYellow, "skipped": ansiYellow,
+}
+
+// statusFields are the fields colored by statusColors
+var statusFields = map[string]bool{"status": true, "state": true, "result": true}
+
+// writePretty writes rows as aligned columns like writeTable, for people at
+// a terminal. With color, headers are bold, IDs cyan, statuses and errors
+// colored and empty cells dimmed. Content is cut shorter than other cells.
+func writePretty(w io.Writer, value interface{}, color bool) error {
+	var rows [][]string
+	if obj, ok := value.(object); ok && obj.lookup("results") == nil {
+		// A single object is shown as field/value pairs
+		for _, f := range obj {
+			rows = append(rows, []string{f.key, prettyCell(f.key, f.value)})
+		}
+		return writeColumns(w, []string{"FIELD", "VALUE"}, rows, color, func(row, col int) string {
+			if col == 0 {
+				return ansiBold
+			}
+			return prettyColor(obj[row].key, obj[row].value, rows[row][col])
+		})
+	}
+
+	headers, objs := tabular(value)
+	upper := make([]string, len(headers))
+	for i, h := range headers {
+		upper[i] = strings.ToUpper(h)
+	}
+	for _, obj := range objs {
+		cells := make([]string, len(headers))
+		for i, h := range headers {
+			cells[i] = prettyCell(h, obj.lookup(h))
+		}
+		rows = append(rows, cells)
+	}
+	return writeColumns(w, upper, rows, color, func(row, col int) string {
+		return prettyColor(headers[col], objs[row].lookup(headers[col]), rows[row][col])
+	})
+}
+
+// writeColumns writes headers and rows aligned by their width on screen.
+// colorOf returns the color of a cell; padding is added outside the
+// escape sequences so that they do not count toward the width.
+func writeColumns(w io.Writer, headers []string, rows [][]string, color bool, colorOf func(row, col int) string) error {
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i, c := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(c))
+		}
+	}
+
+	var b strings.Builder
+	line := func(cells []string, colorOf func(col int) string) {
+		for i, c := range cells {
+			if i > 0 {
+				b.WriteString("  ")
+			}
+			if code := colorOf(i); color && code != "" {
+				b.WriteString(code + c + ansiReset)
+			} else {
+				b.WriteString(c)
+			}
+			if i < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(c)))
+			}
+		}
+		b.WriteByte('\n')
+	}
+	line(headers, func(int) string { return ansiBold })
+	for r, row := range rows {
+		line(row, func(col int) string { return colorOf(r, col) })
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// prettyCell formats a value for pretty output: like a table cell, with
+// content truncated to maxPrettyContent and empty values shown as "-"
+func prettyCell(key string, value interface{}) string {
+	s := tableCell(value)
+	if s == "" {
+		return "-"
+	}
+	if r := []rune(s); key == "content" && len(r) > maxPrettyContent {
+		return string(r[:maxPrettyContent-1]) + "…"
+	}
+	return s
+}
+
+// prettyColor returns the color of the cell text of the field key
+func prettyColor(key string, value interface{}, text string) string {
+	switch {
+	case text == "-" && (value == nil || tableCell(value) == ""):
+		return ansiDim
+	case key == "id":
+		return ansiCyan
+	case key == "error":
+		return ansiRed
+	case statusFields[key]:
+		return statusColors[strings.ToLower(text)]
+	}
+	return ""
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+type outputDoc struct {
+	ID       int      `json:"id"`
+	Title    string   `json:"title"`
+	ASN      *int     `json:"archive_serial_number"`
+	TagNames []string `json:"tag_names"`
+}
+
+type outputList struct {
+	Count   int         `json:"count"`
+	Results []outputDoc `json:"results"`
+}
+
+var testList = outputList{
+	Count: 2,
+	Results: []outputDoc{
+		{ID: 1, Title: "Invoice 2024", TagNames: []string{"Finance", "Tax"}},
+		{ID: 22, Title: "Lease: flat, 2nd floor", TagNames: []string{}},
+	},
+}
+
+func renderString(t *testing.T, v interface{}, format, tmpl string) string {
+	t.Helper()
+	var parsed *template.Template
+	if tmpl != "" {
+		parsed = template.Must(template.New("test").Parse(tmpl))
+	}
+	var buf bytes.Buffer
+	if err := render(&buf, v, format, parsed, false); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestRender_Table(t *testing.T) {
+	got := renderString(t, testList, formatTable, "")
+	want := "" +
+		"ID  TITLE                   ARCHIVE_SERIAL_NUMBER  TAG_NAMES\n" +
+		"1   Invoice 2024                                   Finance,Tax\n" +
+		"22  Lease: flat, 2nd floor                         \n"
+	if got != want {
+		t.Errorf("table output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRender_TableSingleObject(t *testing.T) {
+	got := renderString(t, testList.Results[0], formatTable, "")
+	for _, want := range []string{"FIELD", "VALUE", "title", "Invoice 2024", "tag_names", "Finance,Tax"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("table output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestRender_TableTruncatesCells(t *testing.T) {
+	doc := outputDoc{ID: 1, Title: strings.Repeat("word ", 30) + "\nmore"}
+	got := renderString(t, []outputDoc{doc}, formatTable, "")
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected header and one row, got %d lines:\n%s", len(lines), got)
+	}
+	if !strings.Contains(lines[1], "…") {
+		t.Errorf("expected truncated cell, got: %s", lines[1])
+	}
+}
+
+func TestRender_Pretty(t *testing.T) {
+	got := renderString(t, testList, formatPretty, "")
+	want := "" +
+		"ID  TITLE                   ARCHIVE_SERIAL_NUMBER  TAG_NAMES\n" +
+		"1   Invoice 2024            -                      Finance,Tax\n" +
+		"22  Lease: flat, 2nd floor  -                      -\n"
+	if got != want {
+		t.Errorf("pretty output:\n%s\nwant:\n%s", got, want)
+	}
+
+	var buf bytes.Buffer
+	value, _ := decodeOrdered([]byte(`[{"id": 7, "status": "FAILURE", "content": "` + strings.Repeat("x", 50) + `"}]`))
+	if err := writePretty(&buf, value, true); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if lines[0] != ansiBold+"ID"+ansiReset+"  "+ansiBold+"STATUS"+ansiReset+"   "+ansiBold+"CONTENT"+ansiReset {
+		t.Errorf("header = %q", lines[0])
+	}
+	want = ansiCyan + "7" + ansiReset + "   " + ansiRed + "FAILURE" + ansiReset + "  " + strings.Repeat("x", maxPrettyContent-1) + "…"
+	if lines[1] != want {
+		t.Errorf("row = %q, want %q", lines[1], want)
+	}
+}
+
+func TestRender_CSV(t *testing.T) {
+	got := renderString(t, testList, formatCSV, "")
+	want := "" +
+		"id,title,archive_serial_number,tag_names\n" +
+		"1,Invoice 2024,,\"Finance,Tax\"\n" +
+		"22,\"Lease: flat, 2nd floor\",,\n"
+	if got != want {
+		t.Errorf("csv output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRender_YAML(t *testing.T) {
+	got := renderString(t, testList, formatYAML, "")
+	want := `count: 2
+results:
+  - id: 1
+    title: Invoice 2024
+    archive_serial_number: null
+    tag_names:
+      - Finance
+      - Tax
+  - id: 22
+    title: "Lease: flat, 2nd floor"
+    archive_serial_number: null
+    tag_names: []
+`
+	if got != want {
+		t.Errorf("yaml output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Finance", "Finance"},
+		{"Invoice 2024", "Invoice 2024"},
+		{"", `""`},
+		{"true", `"true"`},
+		{"No", `"No"`},
+		{"2024", `"2024"`},
+		{"a: b", `"a: b"`},
+		{"#comment", `"#comment"`},
+		{"line\nbreak", `"line\nbreak"`},
+		{"trailing ", `"trailing "`},
+	}
+
+	for _, tt := range tests {
+		if got := yamlString(tt.in); got != tt.want {
+			t.Errorf("yamlString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRender_Template(t *testing.T) {
+	got := renderString(t, testList, formatJSON, `{{range .results}}{{.id}}={{.title}};{{end}}`)
+	if got != "1=Invoice 2024;22=Lease: flat, 2nd floor;\n" {
+		t.Errorf("template output = %q", got)
+	}
+}
+
+func TestConfigureOutput(t *testing.T) {
+	defer func() { outputFormat, outputTemplate, plainOutput, outputColor = formatJSON, nil, false, false }()
+
+	// Tests do not run on a terminal, so the default is JSON
+	if err := configureOutput("", "", false); err != nil || outputFormat != formatJSON || outputColor {
+		t.Errorf("configureOutput(\"\") = %v, format %q, color %v", err, outputFormat, outputColor)
+	}
+
+	if err := configureOutput("xml", "", false); err == nil || !strings.Contains(err.Error(), "unsupported output format") {
+		t.Errorf("expected unsupported format error, got %v", err)
+	}
+	if err := configureOutput(formatJSON, "{{.count", false); err == nil || !strings.Contains(err.Error(), "invalid template") {
+		t.Errorf("expected invalid template error, got %v", err)
+	}
+	if err := configureOutput(formatYAML, "", false); err != nil || outputFormat != formatYAML {
+		t.Errorf("configureOutput(yaml) = %v, format %q", err, outputFormat)
+	}
+}
+
+func TestRender_Plain(t *testing.T) {
+	type cacheInfo struct {
+		Path      string `json:"path"`
+		FetchedAt string `json:"fetched_at"`
+	}
+	type doc struct {
+		Title    string   `json:"title"`
+		ID       int      `json:"id"`
+		Tags     []int    `json:"tags"`
+		TagNames []string `json:"tag_names"`
+	}
+	v := struct {
+		Results []doc     `json:"results"`
+		Count   int       `json:"count"`
+		Cache   cacheInfo `json:"cache"`
+	}{
+		Results: []doc{
+			{Title: "b", ID: 10, Tags: []int{3, 1}, TagNames: []string{"Tax", "Finance"}},
+			{Title: "a", ID: 2, Tags: []int{}, TagNames: []string{}},
+		},
+		Count: 2,
+		Cache: cacheInfo{Path: "/tmp/tags.json", FetchedAt: "2024-01-01T00:00:00Z"},
+	}
+
+	var buf bytes.Buffer
+	if err := render(&buf, v, formatJSON, nil, true); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	want := `{
+  "cache": {
+    "path": "/tmp/tags.json"
+  },
+  "count": 2,
+  "results": [
+    {
+      "id": 2,
+      "tag_names": [],
+      "tags": [],
+      "title": "a"
+    },
+    {
+      "id": 10,
+      "tag_names": [
+        "Tax",
+        "Finance"
+      ],
+      "tags": [
+        3,
+        1
+      ],
+      "title": "b"
+    }
+  ]
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("plain output:\n%s\nwant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	if err := render(&buf, v, formatCSV, nil, true); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if got := buf.String(); !strings.HasPrefix(got, "id,tag_names,tags,title\n2,") {
+		t.Errorf("plain csv output = %q", got)
+	}
+}
+
+func TestListMeta(t *testing.T) {
+	meta := newListMeta(25, 130, 2, 25)
+	if meta.Pages != 6 {
+		t.Errorf("pages = %d, want 6", meta.Pages)
+	}
+	if got := meta.footer(true); got != "25 of 130 results · page 2 of 6" {
+		t.Errorf("plain footer = %q", got)
+	}
+	meta.ElapsedMS = 1500
+	if got := meta.footer(false); got != "25 of 130 results · page 2 of 6 · 1.5s" {
+		t.Errorf("footer = %q", got)
+	}
+
+	// A single page or several fetched pages show no page numbers
+	if got := newListMeta(3, 3, 1, 25).footer(true); got != "3 of 3 results" {
+		t.Errorf("single page footer = %q", got)
+	}
+	if meta := newListMeta(200, 250, 0, 0); meta.Page != 0 || meta.Pages != 0 {
+		t.Errorf("meta of several pages = %+v", meta)
+	}
+}
+
+func TestCLI_ListFooterAndMeta(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"count": 30, "next": "http://example/api/tags/?page=2", "results": [{"id": 1, "name": "Finance"}, {"id": 2, "name": "Tax"}]}`))
+	}))
+	defer server.Close()
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("./pgo", args...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("pgo %v failed: %v", args, err)
+		}
+		return string(out)
+	}
+
+	out := run("-output-format", "table", "-plain", "get", "tags")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if last := lines[len(lines)-1]; last != "2 of 30 results · page 1 of 2" {
+		t.Errorf("footer = %q, output:\n%s", last, out)
+	}
+
+	var withMeta struct {
+		Count int      `json:"count"`
+		Meta  ListMeta `json:"meta"`
+	}
+	if err := json.Unmarshal([]byte(run("-with-meta", "get", "tags")), &withMeta); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if withMeta.Count != 30 || withMeta.Meta.Count != 2 || withMeta.Meta.Total != 30 || withMeta.Meta.Pages != 2 {
+		t.Errorf("output = %+v", withMeta)
+	}
+
+	if out := run("get", "tags"); strings.Contains(out, "meta") || strings.Contains(out, "results ·") {
+		t.Errorf("JSON output without -with-meta has meta or a footer:\n%s", out)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// docPaging holds the pagination flags of get docs and search docs
+type docPaging struct {
+	all      *bool
+	limit    *int
+	page     *int
+	pageSize *int
+}
+
+func addDocPagingFlags(fs *flag.FlagSet) *docPaging {
+	return &docPaging{
+		all:      fs.Bool("all", false, "Fetch every page of results"),
+		limit:    fs.Int("limit", 0, "Fetch pages until this many results, then stop"),
+		page:     fs.Int("page", 0, "Page to fetch, or to start from with -all or -limit"),
+		pageSize: fs.Int("page-size", 0, "Results per page requested from Paperless"),
+	}
+}
+
+// meta describes count fetched documents of total for list output
+func (p *docPaging) meta(count, total int) ListMeta {
+	if *p.all || *p.limit > 0 {
+		return newListMeta(count, total, 0, 0)
+	}
+	pageSize := *p.pageSize
+	if pageSize == 0 {
+		pageSize = defaultServerPageSize
+	}
+	return newListMeta(count, total, max(*p.page, 1), pageSize)
+}
+
+// fetch lists the documents matching opts. Without -all or -limit it fetches
+// a single page, like the API. It returns the documents and the total number
+// of matches reported by Paperless. lister is usually the client.
+func (p *docPaging) fetch(ctx context.Context, lister paperless.DocumentLister, opts *paperless.ListOptions) ([]paperless.Document, int, error) {
+	if *p.limit < 0 || *p.page < 0 || *p.pageSize < 0 {
+		return nil, 0, fmt.Errorf("-limit, -page and -page-size must not be negative")
+	}
+	opts.Page = *p.page
+	opts.PageSize = *p.pageSize
+
+	if !*p.all && *p.limit == 0 {
+		docs, err := lister.ListDocuments(ctx, opts)
+		if err != nil {
+			return nil, 0, err
+		}
+		return docs.Results, docs.Count, nil
+	}
+
+	// Don't fetch a large page for a small limit
+	if *p.limit > 0 && opts.PageSize == 0 {
+		opts.PageSize = min(*p.limit, 100)
+	}
+	docs := []paperless.Document{}
+	prog := newProgress("Fetching documents", 0)
+	it := paperless.NewDocumentIterator(lister, opts)
+	for (*p.limit == 0 || len(docs) < *p.limit) && it.Next(ctx) {
+		if *p.limit > 0 {
+			prog.setTotal(min(*p.limit, it.Count()))
+		} else {
+			prog.setTotal(it.Count())
+		}
+		docs = append(docs, it.Document())
+		prog.add(1, 0)
+	}
+	prog.finish()
+	if err := it.Err(); err != nil {
+		return nil, 0, err
+	}
+	return docs, it.Count(), nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCLI_DocPaging(t *testing.T) {
+	const total = 5
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	// takeRequests returns the document requests made so far and resets them
+	takeRequests := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		taken := requests
+		requests = nil
+		return taken
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags/":
+			w.Write([]byte(`{"count": 0, "results": []}`))
+		case "/api/documents/":
+			mu.Lock()
+			requests = append(requests, r.URL.RawQuery)
+			mu.Unlock()
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if page == 0 {
+				page = 1
+			}
+			size, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+			if size == 0 {
+				size = 2
+			}
+			var results []string
+			for id := (page-1)*size + 1; id <= page*size && id <= total; id++ {
+				results = append(results, fmt.Sprintf(`{"id": %d}`, id))
+			}
+			next := "null"
+			if page*size < total {
+				next = fmt.Sprintf(`"http://example/api/documents/?page=%d"`, page+1)
+			}
+			fmt.Fprintf(w, `{"count": %d, "next": %s, "results": [%s]}`, total, next, strings.Join(results, ", "))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantIDs  string
+		requests int
+	}{
+		{"first page", []string{"get", "docs"}, "[1 2]", 1},
+		{"page passthrough", []string{"get", "docs", "-page", "2", "-page-size", "1"}, "[2]", 1},
+		{"all", []string{"get", "docs", "-all"}, "[1 2 3 4 5]", 3},
+		{"limit", []string{"get", "docs", "-limit", "3", "-page-size", "2"}, "[1 2 3]", 2},
+		{"search with limit", []string{"search", "docs", "-limit", "1", "lease"}, "[1]", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			takeRequests()
+			cmd := exec.Command("./pgo", tt.args...)
+			cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+			var stdout, stderr bytes.Buffer
+			cmd.Stdout, cmd.Stderr = &stdout, &stderr
+			if err := cmd.Run(); err != nil {
+				t.Fatalf("Command failed: %v\nStderr: %s", err, stderr.String())
+			}
+			var out DocumentListOutput
+			if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, stdout.String())
+			}
+			ids := fmt.Sprint(paperlessIDs(out.Results))
+			if ids != tt.wantIDs || out.Count != len(out.Results) || out.Total != total {
+				t.Errorf("got ids %s, count %d, total %d; want %s, %d, %d", ids, out.Count, out.Total, tt.wantIDs, len(out.Results), total)
+			}
+			if requests := takeRequests(); len(requests) != tt.requests {
+				t.Errorf("made %d document requests %v, want %d", len(requests), requests, tt.requests)
+			}
+		})
+	}
+}
+
+func paperlessIDs(docs []DocumentWithTagNames) []int {
+	ids := make([]int, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	return ids
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// Principal is a user or group in perms output
+type Principal struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// PrincipalSet lists the users and groups granted a permission
+type PrincipalSet struct {
+	Users  []Principal `json:"users"`
+	Groups []Principal `json:"groups"`
+}
+
+// PermsOutput is the result of perms show and perms set
+type PermsOutput struct {
+	Document int          `json:"document"`
+	Owner    *Principal   `json:"owner"` // null if the document has no owner
+	View     PrincipalSet `json:"view"`
+	Change   PrincipalSet `json:"change"`
+}
+
+// principals holds the names of users and groups
+type principals struct {
+	users  map[int]string
+	groups map[int]string
+}
+
+// loadPrincipals fetches all users and groups. Both need admin permissions;
+// if they cannot be listed, names are unknown and only IDs can be used.
+func loadPrincipals(ctx context.Context, client *paperless.Client) *principals {
+	p := &principals{users: map[int]string{}, groups: map[int]string{}}
+
+	opts := &paperless.ListOptions{Page: 1, PageSize: 100}
+	for {
+		list, err := client.ListUsers(ctx, opts)
+		if err != nil {
+			slog.Warn("Could not fetch users for name resolution", "error", err)
+			break
+		}
+		for _, u := range list.Results {
+			p.users[u.ID] = u.Username
+		}
+		if list.Next == nil || *list.Next == "" {
+			break
+		}
+		opts.Page++
+	}
+
+	opts = &paperless.ListOptions{Page: 1, PageSize: 100}
+	for {
+		list, err := client.ListGroups(ctx, opts)
+		if err != nil {
+			slog.Warn("Could not fetch groups for name resolution", "error", err)
+			break
+		}
+		for _, g := range list.Results {
+			p.groups[g.ID] = g.Name
+		}
+		if list.Next == nil || *list.Next == "" {
+			break
+		}
+		opts.Page++
+	}
+	return p
+}
+
+// resolve looks up "user:<name>", "group:<name>" or a bare name, which may
+// be either. Names match case-insensitively; a number is an ID.
+func (p *principals) resolve(ref string) (paperless.Principal, error) {
+	kind, name, ok := strings.Cut(strings.TrimSpace(ref), ":")
+	if !ok {
+		kind, name = "", kind
+	}
+	if name == "" {
+		return paperless.Principal{}, fmt.Errorf("empty user or group name in %q", ref)
+	}
+
+	var matches []paperless.Principal
+	if kind == "" || kind == "user" {
+		if id, ok := lookupName(p.users, name); ok {
+			matches = append(matches, paperless.UserPrincipal(id))
+		}
+	}
+	if kind == "" || kind == "group" {
+		if id, ok := lookupName(p.groups, name); ok {
+			matches = append(matches, paperless.GroupPrincipal(id))
+		}
+	}
+	if kind != "" && kind != "user" && kind != "group" {
+		return paperless.Principal{}, fmt.Errorf("invalid principal %q (use user:<name> or group:<name>)", ref)
+	}
+
+	switch len(matches) {
+	case 0:
+		return paperless.Principal{}, fmt.Errorf("no user or group named %q", ref)
+	case 1:
+		return matches[0], nil
+	}
+	return paperless.Principal{}, fmt.Errorf("%q is both a user and a group (prefix it with user: or group:)", ref)
+}
+
+// lookupName finds name in names case-insensitively. A number is taken as
+// an ID, so IDs work even if names could not be fetched.
+func lookupName(names map[int]string, name string) (int, bool) {
+	for id, n := range names {
+		if strings.EqualFold(n, name) {
+			return id, true
+		}
+	}
+	if id, err := strconv.Atoi(name); err == nil && id > 0 {
+		if _, known := names[id]; known || len(names) == 0 {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func (p *principals) user(id int) Principal {
+	return Principal{ID: id, Name: nameOrUnknown(p.users, id)}
+}
+
+func (p *principals) group(id int) Principal {
+	return Principal{ID: id, Name: nameOrUnknown(p.groups, id)}
+}
+
+func nameOrUnknown(names map[int]string, id int) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", id)
+}
+
+// permsOutput converts permissions to output with names
+func (p *principals) permsOutput(docID int, perms *paperless.DocumentPermissions) PermsOutput {
+	out := PermsOutput{Document: docID}
+	if perms.Owner != nil {
+		owner := p.user(*perms.Owner)
+		out.Owner = &owner
+	}
+	convert := func(set paperless.PermissionSet) PrincipalSet {
+		ps := PrincipalSet{Users: []Principal{}, Groups: []Principal{}}
+		for _, id := range set.Users {
+			ps.Users = append(ps.Users, p.user(id))
+		}
+		for _, id := range set.Groups {
+			ps.Groups = append(ps.Groups, p.group(id))
+		}
+		return ps
+	}
+	out.View = convert(perms.Permissions.View)
+	out.Change = convert(perms.Permissions.Change)
+	return out
+}
+
+// permsChange is what perms set changes
+type permsChange struct {
+	owner       string // user, "none" to remove the owner, "" to keep it
+	shareView   []string
+	shareChange []string
+	unshare     []string
+	replace     bool // start from no grants instead of the current ones
+}
+
+// apply modifies perms. Granting change also grants view, see
+// paperless.Permissions.Grant.
+func (c permsChange) apply(perms *paperless.DocumentPermissions, p *principals) error {
+	switch c.owner {
+	case "":
+	case "none":
+		perms.Owner = nil
+	default:
+		owner, err := p.resolve("user:" + strings.TrimPrefix(c.owner, "user:"))
+		if err != nil {
+			return err
+		}
+		perms.Owner = &owner.ID
+	}
+
+	if c.replace {
+		perms.Permissions = paperless.Permissions{}
+	}
+
+	for _, ref := range c.unshare {
+		who, err := p.resolve(ref)
+		if err != nil {
+			return err
+		}
+		perms.Permissions.Revoke(who)
+	}
+	grants := []struct {
+		permission string
+		refs       []string
+	}{
+		{paperless.PermissionView, c.shareView},
+		{paperless.PermissionChange, c.shareChange},
+	}
+	for _, g := range grants {
+		for _, ref := range g.refs {
+			who, err := p.resolve(ref)
+			if err != nil {
+				return err
+			}
+			if err := perms.Permissions.Grant(g.permission, who); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// splitList splits a comma-separated flag value
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// permsSetFlags are the flags of perms set
+type permsSetFlags struct {
+	owner       *string
+	shareView   *string
+	shareChange *string
+	unshare     *string
+	replace     *bool
+}
+
+func addPermsSetFlags(fs *flag.FlagSet) *permsSetFlags {
+	return &permsSetFlags{
+		owner:       fs.String("owner", "", "New owner (user name or ID), or none to remove the owner"),
+		shareView:   fs.String("share-view", "", "Comma-separated users and groups to grant view permission"),
+		shareChange: fs.String("share-change", "", "Comma-separated users and groups to grant change (and view) permission"),
+		unshare:     fs.String("unshare", "", "Comma-separated users and groups to revoke all permissions from"),
+		replace:     fs.Bool("replace", false, "Replace the current grants instead of adding to them"),
+	}
+}
+
+func runPerms(client *paperless.Client, args []string) error {
+	if len(args) == 0 || (args[0] != "show" && args[0] != "set") {
+		return commandUsage("perms")
+	}
+	subcommand := args[0]
+
+	permsFlags := flag.NewFlagSet("perms "+subcommand, flag.ContinueOnError)
+	var set *permsSetFlags
+	if subcommand == "set" {
+		set = addPermsSetFlags(permsFlags)
+	}
+
+	// Flags may come before or after the document ID
+	if err := permsFlags.Parse(args[1:]); err != nil {
+		return usagef("parse perms flags: %w", err)
+	}
+	if permsFlags.NArg() == 0 {
+		return commandUsage("perms " + subcommand)
+	}
+	idArg := permsFlags.Arg(0)
+	if err := permsFlags.Parse(permsFlags.Args()[1:]); err != nil {
+		return usagef("parse perms flags: %w", err)
+	}
+	if permsFlags.NArg() != 0 {
+		return commandUsage("perms " + subcommand)
+	}
+	id, err := strconv.Atoi(idArg)
+	if err != nil || id <= 0 {
+		return usagef("invalid ID format: %s", idArg)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	perms, err := client.GetDocumentPermissions(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get permissions of document %d: %w", id, err)
+	}
+	names := loadPrincipals(ctx, client)
+
+	if subcommand == "set" {
+		change := permsChange{
+			owner:       *set.owner,
+			shareView:   splitList(*set.shareView),
+			shareChange: splitList(*set.shareChange),
+			unshare:     splitList(*set.unshare),
+			replace:     *set.replace,
+		}
+		if change.owner == "" && len(change.shareView)+len(change.shareChange)+len(change.unshare) == 0 && !change.replace {
+			return fmt.Errorf("nothing to change\n%v", commandUsage("perms set"))
+		}
+		if err := change.apply(perms, names); err != nil {
+			return err
+		}
+		if err := client.SetDocumentPermissions(ctx, id, perms); err != nil {
+			return fmt.Errorf("failed to set permissions of document %d: %w", id, err)
+		}
+		if perms, err = client.GetDocumentPermissions(ctx, id); err != nil {
+			return fmt.Errorf("failed to get permissions of document %d: %w", id, err)
+		}
+	}
+
+	if err := writeOutput(names.permsOutput(id, perms)); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
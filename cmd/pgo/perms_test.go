@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+func testPrincipals() *principals {
+	return &principals{
+		users:  map[int]string{1: "admin", 2: "alice", 3: "bob", 4: "family"},
+		groups: map[int]string{1: "Family", 2: "accounting"},
+	}
+}
+
+func TestPrincipals_Resolve(t *testing.T) {
+	p := testPrincipals()
+	tests := []struct {
+		ref     string
+		want    paperless.Principal
+		wantErr string
+	}{
+		{ref: "alice", want: paperless.UserPrincipal(2)},
+		{ref: "ACCOUNTING", want: paperless.GroupPrincipal(2)},
+		{ref: "user:family", want: paperless.UserPrincipal(4)},
+		{ref: "group:family", want: paperless.GroupPrincipal(1)},
+		{ref: "user:3", want: paperless.UserPrincipal(3)},
+		{ref: "family", wantErr: "both a user and a group"},
+		{ref: "carol", wantErr: `no user or group named "carol"`},
+		{ref: "user:9", wantErr: "no user or group"},
+		{ref: "team:x", wantErr: "invalid principal"},
+		{ref: "group:", wantErr: "empty"},
+	}
+	for _, tt := range tests {
+		got, err := p.resolve(tt.ref)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("resolve(%q) error = %v, want %q", tt.ref, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("resolve(%q) = %+v, %v; want %+v", tt.ref, got, err, tt.want)
+		}
+	}
+
+	// Without names, IDs still work
+	empty := &principals{users: map[int]string{}, groups: map[int]string{}}
+	if got, err := empty.resolve("group:5"); err != nil || got != paperless.GroupPrincipal(5) {
+		t.Errorf("resolve(group:5) without names = %+v, %v", got, err)
+	}
+}
+
+func TestPermsChange_Apply(t *testing.T) {
+	owner := 1
+	perms := &paperless.DocumentPermissions{
+		Owner: &owner,
+		Permissions: paperless.Permissions{
+			View:   paperless.PermissionSet{Users: []int{3}, Groups: []int{}},
+			Change: paperless.PermissionSet{Users: []int{3}, Groups: []int{}},
+		},
+	}
+	change := permsChange{
+		owner:       "alice",
+		shareView:   []string{"group:family"},
+		shareChange: []string{"accounting"},
+		unshare:     []string{"bob"},
+	}
+	if err := change.apply(perms, testPrincipals()); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+
+	want := paperless.Permissions{
+		View:   paperless.PermissionSet{Users: []int{}, Groups: []int{1, 2}},
+		Change: paperless.PermissionSet{Users: []int{}, Groups: []int{2}},
+	}
+	if *perms.Owner != 2 || !reflect.DeepEqual(perms.Permissions, want) {
+		t.Errorf("permissions = owner %d %+v, want owner 2 %+v", *perms.Owner, perms.Permissions, want)
+	}
+
+	if err := (permsChange{owner: "none", replace: true}).apply(perms, testPrincipals()); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if perms.Owner != nil || len(perms.Permissions.View.Groups) != 0 {
+		t.Errorf("owner none and replace should clear everything, got %+v", perms)
+	}
+
+	if err := (permsChange{owner: "accounting"}).apply(perms, testPrincipals()); err == nil {
+		t.Error("a group should not be accepted as owner")
+	}
+}
+
+func TestCLI_Perms(t *testing.T) {
+	var patched map[string]interface{}
+	current := `{"id": 7, "owner": 1, "permissions": {"view": {"users": [], "groups": [1]}, "change": {"users": [], "groups": []}}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/users/":
+			w.Write([]byte(`{"count": 2, "results": [{"id": 1, "username": "admin"}, {"id": 2, "username": "alice"}]}`))
+		case r.URL.Path == "/api/groups/":
+			w.Write([]byte(`{"count": 2, "results": [{"id": 1, "name": "family"}, {"id": 2, "name": "kids"}]}`))
+		case r.URL.Path == "/api/documents/7/" && r.Method == "GET":
+			if r.URL.Query().Get("full_perms") != "true" {
+				t.Errorf("permissions requested without full_perms: %s", r.URL)
+			}
+			w.Write([]byte(current))
+		case r.URL.Path == "/api/documents/7/" && r.Method == "PATCH":
+			json.NewDecoder(r.Body).Decode(&patched)
+			current = `{"id": 7, "owner": 2, "permissions": {"view": {"users": [], "groups": [1, 2]}, "change": {"users": [], "groups": []}}}`
+			w.Write([]byte(`{"id": 7}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(args ...string) (PermsOutput, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"perms"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token")
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		var out PermsOutput
+		if stdout.Len() > 0 {
+			if jsonErr := json.Unmarshal(stdout.Bytes(), &out); jsonErr != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", jsonErr, stdout.String())
+			}
+		}
+		return out, stderr.String(), err
+	}
+
+	out, stderr, err := run("show", "7")
+	if err != nil {
+		t.Fatalf("perms show failed: %v\nStderr: %s", err, stderr)
+	}
+	want := PermsOutput{
+		Document: 7,
+		Owner:    &Principal{ID: 1, Name: "admin"},
+		View:     PrincipalSet{Users: []Principal{}, Groups: []Principal{{ID: 1, Name: "family"}}},
+		Change:   PrincipalSet{Users: []Principal{}, Groups: []Principal{}},
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("perms show = %+v, want %+v", out, want)
+	}
+
+	out, stderr, err = run("set", "7", "--owner", "alice", "--share-view", "kids")
+	if err != nil {
+		t.Fatalf("perms set failed: %v\nStderr: %s", err, stderr)
+	}
+	if patched["owner"] != float64(2) {
+		t.Errorf("owner sent = %v, want 2", patched["owner"])
+	}
+	view := patched["set_permissions"].(map[string]interface{})["view"].(map[string]interface{})
+	if !reflect.DeepEqual(view["groups"], []interface{}{float64(1), float64(2)}) {
+		t.Errorf("view groups sent = %v, want [1 2]", view["groups"])
+	}
+	if out.Owner == nil || out.Owner.Name != "alice" || len(out.View.Groups) != 2 {
+		t.Errorf("perms set output = %+v", out)
+	}
+
+	if _, stderr, err = run("set", "7"); err == nil || !strings.Contains(stderr, "nothing to change") {
+		t.Errorf("expected nothing to change error, got %v, stderr: %s", err, stderr)
+	}
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register decoders for thumbnails
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// Graphics protocols accepted by -graphics
+const (
+	graphicsAuto  = "auto"
+	graphicsKitty = "kitty"
+	graphicsITerm = "iterm"
+	graphicsSixel = "sixel"
+	graphicsASCII = "ascii"
+	graphicsNone  = "none"
+)
+
+// previewWidth is the maximum width in pixels of a rendered thumbnail
+const previewWidth = 400
+
+// asciiWidth is the width in characters of an ASCII thumbnail
+const asciiWidth = 60
+
+// asciiRamp are the characters of ASCII thumbnails from light to dark, so
+// that the ink of a document shows and its paper stays blank
+const asciiRamp = " .:-=+*#%@"
+
+// detectGraphics picks a graphics protocol from the environment. Terminals
+// are not queried, so sixel is only detected for terminals that announce it
+// in $TERM or are known to support it.
+func detectGraphics(getenv func(string) string) string {
+	term, program := getenv("TERM"), getenv("TERM_PROGRAM")
+	switch {
+	case term == "xterm-kitty" || getenv("KITTY_WINDOW_ID") != "" || program == "ghostty":
+		return graphicsKitty
+	case program == "iTerm.app" || program == "WezTerm":
+		return graphicsITerm
+	case strings.Contains(term, "sixel") || strings.HasPrefix(term, "foot") || strings.HasPrefix(term, "mlterm"):
+		return graphicsSixel
+	}
+	return graphicsNone
+}
+
+// isTerminal reports whether f is a character device such as a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// previewFlags are the flags of preview
+type previewFlags struct {
+	graphics *string
+	excerpt  *int
+}
+
+func addPreviewFlags(fs *flag.FlagSet) *previewFlags {
+	return &previewFlags{
+		graphics: fs.String("graphics", graphicsAuto, "Image protocol: auto, kitty, iterm, sixel, ascii or none"),
+		excerpt:  fs.Int("excerpt", 400, "Number of content characters to show"),
+	}
+}
+
+// runPreview runs preview docs. The older form without "docs" is still
+// accepted.
+func runPreview(client *paperless.Client, args []string, forceRefresh bool) error {
+	fs := flag.NewFlagSet("preview docs", flag.ContinueOnError)
+	flags := addPreviewFlags(fs)
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return usagef("parse preview flags: %w", err)
+	}
+	graphics, excerpt := flags.graphics, flags.excerpt
+	if len(positional) == 2 && positional[0] == "docs" {
+		positional = positional[1:]
+	}
+	if len(positional) != 1 {
+		return commandUsage("preview docs")
+	}
+	id, err := strconv.Atoi(positional[0])
+	if err != nil || id <= 0 {
+		return usagef("invalid ID format: %s", positional[0])
+	}
+
+	protocol := *graphics
+	switch protocol {
+	case graphicsAuto:
+		// Terminals without a graphics protocol get ASCII art
+		protocol = graphicsNone
+		if isTerminal(os.Stdout) {
+			if protocol = detectGraphics(os.Getenv); protocol == graphicsNone {
+				protocol = graphicsASCII
+			}
+		}
+	case graphicsKitty, graphicsITerm, graphicsSixel, graphicsASCII, graphicsNone:
+	default:
+		return fmt.Errorf("unsupported graphics protocol: %s (supported: auto, kitty, iterm, sixel, ascii, none)", protocol)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	doc, err := client.GetDocument(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get document %d: %w", id, err)
+	}
+
+	tagNames, err := getTagNamesWithCache(ctx, client, forceRefresh, DefaultCacheTTL)
+	if err != nil {
+		slog.Warn("Could not fetch tags for name resolution", "error", err)
+		tagNames = make(map[int]string)
+	}
+
+	if protocol != graphicsNone {
+		thumb, err := client.DownloadThumbnail(ctx, id)
+		if err == nil {
+			err = writeImage(os.Stdout, protocol, thumb.Data)
+		}
+		if err != nil {
+			slog.Warn("Could not display thumbnail", "error", err)
+		}
+	}
+
+	return writePreviewText(os.Stdout, convertDocToOutput(doc, tagNames), doc.PageCount, *excerpt)
+}
+
+// writePreviewText writes the document metadata and a content excerpt
+func writePreviewText(w io.Writer, doc DocumentWithTagNames, pages *int, excerpt int) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#%d  %s\n", doc.ID, doc.Title)
+	fmt.Fprintf(&b, "Created   %s\n", strings.SplitN(doc.Created, "T", 2)[0])
+	if len(doc.TagNames) > 0 {
+		fmt.Fprintf(&b, "Tags      %s\n", strings.Join(doc.TagNames, ", "))
+	}
+	if doc.OriginalFileName != "" {
+		fmt.Fprintf(&b, "File      %s\n", doc.OriginalFileName)
+	}
+	if pages != nil {
+		fmt.Fprintf(&b, "Pages     %d\n", *pages)
+	}
+	if doc.ArchiveSerialNumber != nil {
+		fmt.Fprintf(&b, "ASN       %d\n", *doc.ArchiveSerialNumber)
+	}
+
+	if text := contentExcerpt(doc.Content, excerpt); text != "" {
+		b.WriteString("\n")
+		for _, line := range wrapText(text, 80) {
+			b.WriteString(line + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// contentExcerpt returns up to n characters of content with whitespace
+// collapsed
+func contentExcerpt(content string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	text := strings.Join(strings.Fields(content), " ")
+	r := []rune(text)
+	if len(r) <= n {
+		return text
+	}
+	cut := string(r[:n])
+	if i := strings.LastIndex(cut, " "); i > 0 && r[n] != ' ' {
+		cut = cut[:i] // don't split a word
+	}
+	return strings.TrimSpace(cut) + "…"
+}
+
+// wrapText breaks text into lines of at most width characters at spaces
+func wrapText(text string, width int) []string {
+	var lines []string
+	var line strings.Builder
+	for _, word := range strings.Fields(text) {
+		if line.Len() > 0 && len([]rune(line.String()))+1+len([]rune(word)) > width {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+		if line.Len() > 0 {
+			line.WriteByte(' ')
+		}
+		line.WriteString(word)
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// writeImage renders an image with a terminal graphics protocol
+func writeImage(w io.Writer, protocol string, data []byte) error {
+	if protocol == graphicsITerm {
+		// iTerm2 and WezTerm decode the file themselves, including WebP
+		_, err := fmt.Fprintf(w, "\x1b]1337;File=inline=1;size=%d;width=%dpx;preserveAspectRatio=1:%s\a\n",
+			len(data), previewWidth, base64.StdEncoding.EncodeToString(data))
+		return err
+	}
+
+	img, err := decodeImage(data)
+	if err != nil {
+		return err
+	}
+	switch protocol {
+	case graphicsKitty:
+		return writeKitty(w, scaleImage(img, previewWidth))
+	case graphicsASCII:
+		return writeASCII(w, img, asciiWidth)
+	}
+	return writeSixel(w, scaleImage(img, previewWidth))
+}
+
+// decodeImage decodes PNG, JPEG and GIF images. Other formats, notably the
+// WebP thumbnails of current Paperless versions, are converted with
+// ImageMagick if it is installed.
+func decodeImage(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err == nil {
+		return img, nil
+	}
+
+	for _, name := range []string{"magick", "convert"} {
+		path, lookErr := exec.LookPath(name)
+		if lookErr != nil {
+			continue
+		}
+		cmd := exec.Command(path, "-", "png:-")
+		cmd.Stdin = bytes.NewReader(data)
+		out, runErr := cmd.Output()
+		if runErr != nil {
+			return nil, fmt.Errorf("convert thumbnail with %s: %w", name, runErr)
+		}
+		return png.Decode(bytes.NewReader(out))
+	}
+	return nil, fmt.Errorf("decode thumbnail: %w (install ImageMagick to display WebP thumbnails)", err)
+}
+
+// scaleImage shrinks img to at most width pixels wide, keeping its aspect
+// ratio. Nearest-neighbour sampling is enough for a preview.
+func scaleImage(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= width {
+		return img
+	}
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		sy := bounds.Min.Y + y*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			sx := bounds.Min.X + x*bounds.Dx()/width
+			scaled.Set(x, y, img.At(sx, sy))
+		}
+	}
+	return scaled
+}
+
+// writeKitty transmits img as PNG with the kitty graphics protocol
+func writeKitty(w io.Writer, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	payload := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	const chunkSize = 4096
+	for i := 0; i < len(payload); i += chunkSize {
+		end := i + chunkSize
+		more := 1
+		if end >= len(payload) {
+			end, more = len(payload), 0
+		}
+		control := fmt.Sprintf("m=%d", more)
+		if i == 0 {
+			control = "a=T,f=100," + control
+		}
+		if _, err := fmt.Fprintf(w, "\x1b_G%s;%s\x1b\\", control, payload[i:end]); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeASCII draws img as characters of asciiRamp, width columns wide.
+// Terminal cells are about twice as high as wide, so each row of characters
+// covers two columns' worth of pixels.
+func writeASCII(w io.Writer, img image.Image, width int) error {
+	bounds := img.Bounds()
+	if bounds.Dx() < width {
+		width = bounds.Dx()
+	}
+	height := max(bounds.Dy()*width/bounds.Dx()/2, 1)
+
+	var b strings.Builder
+	for y := 0; y < height; y++ {
+		sy := bounds.Min.Y + (2*y+1)*bounds.Dy()/(2*height)
+		line := make([]byte, width)
+		for x := 0; x < width; x++ {
+			sx := bounds.Min.X + (2*x+1)*bounds.Dx()/(2*width)
+			c := color.NRGBAModel.Convert(img.At(sx, sy)).(color.NRGBA)
+			// Luminance, blending transparency onto white paper
+			blend := func(v uint8) int { return (int(v)*int(c.A) + 255*(255-int(c.A))) / 255 }
+			lum := (299*blend(c.R) + 587*blend(c.G) + 114*blend(c.B)) / 1000
+			line[x] = asciiRamp[(255-lum)*len(asciiRamp)/256]
+		}
+		b.WriteString(strings.TrimRight(string(line), " ") + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeSixel encodes img as sixel graphics using a 6x6x6 color cube
+func writeSixel(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// Map every pixel to its palette index, blending transparency onto white
+	pixels := make([]uint8, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+			level := func(v uint8) int {
+				blended := (int(v)*int(c.A) + 255*(255-int(c.A))) / 255
+				return (blended*5 + 127) / 255
+			}
+			pixels[y*width+x] = uint8(level(c.R)*36 + level(c.G)*6 + level(c.B))
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\x1bPq\"1;1;%d;%d", width, height)
+	for i := 0; i < 216; i++ {
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, i/36*20, i/6%6*20, i%6*20)
+	}
+
+	row := make([]byte, width)
+	for top := 0; top < height; top += 6 {
+		var used [216]bool
+		for y := top; y < top+6 && y < height; y++ {
+			for x := 0; x < width; x++ {
+				used[pixels[y*width+x]] = true
+			}
+		}
+		first := true
+		for c := range used {
+			if !used[c] {
+				continue
+			}
+			for x := 0; x < width; x++ {
+				bits := byte(0)
+				for dy := 0; dy < 6 && top+dy < height; dy++ {
+					if int(pixels[(top+dy)*width+x]) == c {
+						bits |= 1 << dy
+					}
+				}
+				row[x] = '?' + bits
+			}
+			if !first {
+				b.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(&b, "#%d", c)
+			writeSixelRun(&b, row)
+		}
+		b.WriteByte('-')
+	}
+	b.WriteString("\x1b\\\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeSixelRun writes sixel characters with run-length encoding
+func writeSixelRun(b *strings.Builder, row []byte) {
+	for i := 0; i < len(row); {
+		j := i
+		for j < len(row) && row[j] == row[i] {
+			j++
+		}
+		if n := j - i; n > 3 {
+			fmt.Fprintf(b, "!%d%c", n, row[i])
+		} else {
+			b.WriteString(strings.Repeat(string(row[i]), n))
+		}
+		i = j
+	}
+}
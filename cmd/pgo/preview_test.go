@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestDetectGraphics(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"TERM": "xterm-kitty"}, graphicsKitty},
+		{map[string]string{"TERM": "xterm-256color", "KITTY_WINDOW_ID": "1"}, graphicsKitty},
+		{map[string]string{"TERM_PROGRAM": "iTerm.app"}, graphicsITerm},
+		{map[string]string{"TERM_PROGRAM": "WezTerm"}, graphicsITerm},
+		{map[string]string{"TERM": "foot"}, graphicsSixel},
+		{map[string]string{"TERM": "xterm-sixel"}, graphicsSixel},
+		{map[string]string{"TERM": "xterm-256color"}, graphicsNone},
+	}
+	for _, tt := range tests {
+		if got := detectGraphics(func(k string) string { return tt.env[k] }); got != tt.want {
+			t.Errorf("detectGraphics(%v) = %s, want %s", tt.env, got, tt.want)
+		}
+	}
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriteImage_Sixel(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeImage(&buf, graphicsSixel, testPNG(t, 10, 7)); err != nil {
+		t.Fatalf("writeImage failed: %v", err)
+	}
+	got := buf.String()
+	if !strings.HasPrefix(got, "\x1bPq\"1;1;10;7") || !strings.HasSuffix(got, "\x1b\\\n") {
+		t.Errorf("sixel output not framed: %q", got)
+	}
+	// Solid red is palette color 5*36=180; the first band is six full rows,
+	// the second a single row
+	if !strings.Contains(got, "#180!10~-#180!10@-") {
+		t.Errorf("sixel output missing red bands: %q", got[len(got)-40:])
+	}
+}
+
+func TestWriteKitty_Chunks(t *testing.T) {
+	// Noise compresses badly, so the PNG needs several chunks
+	img := image.NewGray(image.Rect(0, 0, 100, 100))
+	rng := rand.New(rand.NewSource(1))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(rng.Intn(256))
+	}
+
+	var buf bytes.Buffer
+	if err := writeKitty(&buf, img); err != nil {
+		t.Fatalf("writeKitty failed: %v", err)
+	}
+	chunks := strings.Split(strings.TrimSuffix(buf.String(), "\x1b\\\n"), "\x1b\\")
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want several", len(chunks))
+	}
+	if !strings.HasPrefix(chunks[0], "\x1b_Ga=T,f=100,m=1;") {
+		t.Errorf("first chunk = %q...", chunks[0][:20])
+	}
+	for _, c := range chunks[1 : len(chunks)-1] {
+		if !strings.HasPrefix(c, "\x1b_Gm=1;") {
+			t.Errorf("middle chunk = %q...", c[:10])
+		}
+	}
+	if last := chunks[len(chunks)-1]; !strings.HasPrefix(last, "\x1b_Gm=0;") {
+		t.Errorf("last chunk = %q...", last[:10])
+	}
+}
+
+func TestWriteImage_ITermPassesFileThrough(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeImage(&buf, graphicsITerm, []byte("RIFF....WEBP")); err != nil {
+		t.Fatalf("writeImage failed: %v", err)
+	}
+	if want := "\x1b]1337;File=inline=1;size=12;width=400px;preserveAspectRatio=1:UklGRi4uLi5XRUJQ\a\n"; buf.String() != want {
+		t.Errorf("iterm output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWriteASCII(t *testing.T) {
+	// Black ink on the left half of white paper, a grey bar at the bottom
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			c := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+			if x < 4 {
+				c = color.NRGBA{A: 255}
+			}
+			if y >= 6 {
+				c = color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := writeASCII(&buf, img, asciiWidth); err != nil {
+		t.Fatalf("writeASCII failed: %v", err)
+	}
+	want := "@@@@\n@@@@\n@@@@\n========\n"
+	if buf.String() != want {
+		t.Errorf("ascii output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestScaleImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 1000))
+	if got := scaleImage(img, 400).Bounds(); got.Dx() != 400 || got.Dy() != 500 {
+		t.Errorf("scaled size = %v, want 400x500", got)
+	}
+	if got := scaleImage(img, 1000); got != image.Image(img) {
+		t.Error("small image was scaled")
+	}
+}
+
+func TestWritePreviewText(t *testing.T) {
+	asn := int64(17)
+	pages := 2
+	doc := DocumentWithTagNames{
+		ID:                  12,
+		Title:               "Invoice 2024",
+		Created:             "2024-01-15T00:00:00Z",
+		ArchiveSerialNumber: &asn,
+		OriginalFileName:    "invoice.pdf",
+		TagNames:            []string{"Finance", "Tax"},
+		Content:             "ACME   Corp\n\nInvoice  number 42 " + strings.Repeat("total ", 30),
+	}
+
+	var buf bytes.Buffer
+	if err := writePreviewText(&buf, doc, &pages, 60); err != nil {
+		t.Fatalf("writePreviewText failed: %v", err)
+	}
+	want := "#12  Invoice 2024\n" +
+		"Created   2024-01-15\n" +
+		"Tags      Finance, Tax\n" +
+		"File      invoice.pdf\n" +
+		"Pages     2\n" +
+		"ASN       17\n" +
+		"\n" +
+		"ACME Corp Invoice number 42 total total total total total…\n"
+	if buf.String() != want {
+		t.Errorf("preview text:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	got := wrapText("aaa bbb ccc dddd", 7)
+	want := []string{"aaa bbb", "ccc", "dddd"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("wrapText = %q, want %q", got, want)
+	}
+}
+
+func TestCLI_PreviewDocs(t *testing.T) {
+	thumb := testPNG(t, 4, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/documents/12/":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id": 12, "title": "Invoice 2024", "created": "2024-01-15", "tags": [], "content": "ACME Corp"}`))
+		case "/api/documents/12/thumb/":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(thumb)
+		case "/api/tags/":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"count": 0, "results": []}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cmd := exec.Command("./pgo", "-memory", "preview", "docs", "12", "-graphics", "ascii")
+	cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("preview docs failed: %v\nStderr: %s", err, stderr.String())
+	}
+	// The solid red thumbnail is drawn in mid-dark characters above the text
+	if want := "****\n****\n#12  Invoice 2024\n"; !strings.HasPrefix(stdout.String(), want) {
+		t.Errorf("output = %q, want prefix %q", stdout.String(), want)
+	}
+}
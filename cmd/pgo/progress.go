@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// quiet is set by -quiet and turns off progress bars
+var quiet bool
+
+// progressWidth is the width of the bar in characters
+const progressWidth = 30
+
+// progressInterval limits how often the bar is redrawn
+const progressInterval = 100 * time.Millisecond
+
+// progress shows how far a long operation is with a bar redrawn in place
+// on stderr. It is drawn only when stderr is a terminal and -quiet is not
+// set; otherwise, or on a nil progress, its methods do nothing, so callers
+// need no checks.
+type progress struct {
+	w      io.Writer
+	label  string
+	active bool
+
+	mu    sync.Mutex
+	total int // 0 if unknown
+	done  int
+	bytes int64
+	drawn time.Time
+}
+
+func newProgress(label string, total int) *progress {
+	return &progress{
+		w:      os.Stderr,
+		label:  label,
+		total:  total,
+		active: !quiet && isTerminal(os.Stderr),
+	}
+}
+
+// add records n more items done and size more bytes transferred
+func (p *progress) add(n int, size int64) {
+	if p == nil || !p.active {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += n
+	p.bytes += size
+	if time.Since(p.drawn) >= progressInterval {
+		p.draw()
+	}
+}
+
+// setTotal sets the number of items once it is known
+func (p *progress) setTotal(total int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total = total
+}
+
+// step prints a line about one item to stderr, unless a bar shows the
+// progress instead or -quiet is set
+func (p *progress) step(format string, args ...interface{}) {
+	if quiet || (p != nil && p.active) {
+		return
+	}
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
+
+// logf prints a line above the bar, for messages such as errors that are
+// shown even with -quiet. Without a bar it prints to stderr.
+func (p *progress) logf(format string, args ...interface{}) {
+	if p == nil || !p.active {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "\r\033[K"+format+"\n", args...)
+	p.draw()
+}
+
+// finish draws the final state and ends the line
+func (p *progress) finish() {
+	if p == nil || !p.active {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.draw()
+	fmt.Fprintln(p.w)
+}
+
+// draw redraws the bar; p.mu must be held
+func (p *progress) draw() {
+	p.drawn = time.Now()
+	fmt.Fprintf(p.w, "\r\033[K%s", p.line())
+}
+
+// line is the text of the bar, e.g.
+// "Exporting [#########---------] 120/240  50%  48.2 MB"
+func (p *progress) line() string {
+	var b strings.Builder
+	b.WriteString(p.label)
+	if p.total > 0 {
+		filled := min(p.done*progressWidth/p.total, progressWidth)
+		fmt.Fprintf(&b, " [%s%s] %d/%d %3d%%",
+			strings.Repeat("#", filled), strings.Repeat("-", progressWidth-filled),
+			p.done, p.total, min(p.done*100/p.total, 100))
+	} else {
+		fmt.Fprintf(&b, " %d", p.done)
+	}
+	if p.bytes > 0 {
+		b.WriteString("  " + formatBytes(p.bytes))
+	}
+	return b.String()
+}
+
+// formatBytes formats a size with a binary unit, e.g. "48.2 MB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProgressLine(t *testing.T) {
+	p := &progress{label: "Exporting", total: 240, done: 120, bytes: 48 << 20}
+	want := "Exporting [###############---------------] 120/240  50%  48.0 MB"
+	if got := p.line(); got != want {
+		t.Errorf("line = %q, want %q", got, want)
+	}
+
+	p = &progress{label: "Uploading", done: 3}
+	if got := p.line(); got != "Uploading 3" {
+		t.Errorf("line without total = %q", got)
+	}
+}
+
+func TestProgressDraw(t *testing.T) {
+	var buf bytes.Buffer
+	p := &progress{w: &buf, label: "Exporting", total: 2, active: true}
+	p.add(1, 0)
+	p.logf("#%d: %s", 7, "failed")
+	p.add(1, 0)
+	p.finish()
+
+	out := buf.String()
+	if !strings.Contains(out, "\r\033[K#7: failed\n") {
+		t.Errorf("log line not written above the bar: %q", out)
+	}
+	if !strings.HasSuffix(out, "2/2 100%\n") {
+		t.Errorf("final state not drawn: %q", out)
+	}
+
+	// A nil or inactive progress does nothing
+	var none *progress
+	none.add(1, 10)
+	none.setTotal(3)
+	none.finish()
+	inactive := &progress{w: &buf, total: 1}
+	buf.Reset()
+	inactive.add(1, 0)
+	inactive.finish()
+	if buf.Len() != 0 {
+		t.Errorf("inactive progress wrote %q", buf.String())
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1536:            "1.5 KB",
+		48 << 20:        "48.0 MB",
+		3<<30 + 512<<20: "3.5 GB",
+	}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
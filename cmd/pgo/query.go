@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// outputQuery selects part of the output, set by -query
+var outputQuery query
+
+// query is a parsed -query path. It accepts the common subset of JSONPath
+// and gjson paths: keys separated by dots, [n] or .n for an array element
+// (negative n counts from the end), [*], * or # for every element, and a
+// trailing # for the length. A leading $ is ignored.
+type query []querySegment
+
+// querySegment is one step of a query
+type querySegment struct {
+	kind  int    // One of the segment kinds below
+	key   string // Object key for segmentKey
+	index int    // Array index for segmentIndex, and for segmentKey if isIndex
+	// isIndex is set for a key made of digits, which selects an array
+	// element like gjson's "results.0"
+	isIndex bool
+}
+
+const (
+	segmentKey = iota
+	segmentIndex
+	segmentAll
+	segmentLength
+)
+
+// parseQuery parses a -query path
+func parseQuery(expr string) (query, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(expr), "$")
+	var q query
+	for rest != "" {
+		switch {
+		case rest[0] == '.':
+			rest = rest[1:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid query %q: missing ]", expr)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			if inner == "*" {
+				q = append(q, querySegment{kind: segmentAll})
+				continue
+			}
+			if n := len(inner); n >= 2 && (inner[0] == '"' || inner[0] == '\'') && inner[n-1] == inner[0] {
+				q = append(q, querySegment{kind: segmentKey, key: inner[1 : n-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid query %q: [%s] is not an index, * or a quoted key", expr, inner)
+			}
+			q = append(q, querySegment{kind: segmentIndex, index: index})
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			rest = rest[end:]
+			switch key {
+			case "*":
+				q = append(q, querySegment{kind: segmentAll})
+			case "#":
+				// # is the length at the end of a gjson path and every
+				// element elsewhere
+				if rest == "" {
+					q = append(q, querySegment{kind: segmentLength})
+				} else {
+					q = append(q, querySegment{kind: segmentAll})
+				}
+			default:
+				seg := querySegment{kind: segmentKey, key: key}
+				if index, err := strconv.Atoi(key); err == nil {
+					seg.index, seg.isIndex = index, true
+				}
+				q = append(q, seg)
+			}
+		}
+	}
+	return q, nil
+}
+
+// apply returns the part of value, as decoded by decodeOrdered, that q
+// selects. A query with a wildcard returns an array of the matches,
+// flattened like JSONPath; one without returns the match, or nil if there
+// is none.
+func (q query) apply(value interface{}) interface{} {
+	matches := []interface{}{value}
+	all := false
+	for _, seg := range q {
+		var next []interface{}
+		for _, m := range matches {
+			switch seg.kind {
+			case segmentKey:
+				if obj, ok := m.(object); ok {
+					for _, f := range obj {
+						if f.key == seg.key {
+							next = append(next, f.value)
+							break
+						}
+					}
+				} else if arr, ok := m.([]interface{}); ok && seg.isIndex {
+					next = appendElement(next, arr, seg.index)
+				}
+			case segmentIndex:
+				if arr, ok := m.([]interface{}); ok {
+					next = appendElement(next, arr, seg.index)
+				}
+			case segmentAll:
+				switch v := m.(type) {
+				case []interface{}:
+					next = append(next, v...)
+				case object:
+					for _, f := range v {
+						next = append(next, f.value)
+					}
+				}
+			case segmentLength:
+				switch v := m.(type) {
+				case []interface{}:
+					next = append(next, len(v))
+				case object:
+					next = append(next, len(v))
+				}
+			}
+		}
+		if seg.kind == segmentAll {
+			all = true
+		}
+		matches = next
+	}
+	if all {
+		if matches == nil {
+			return []interface{}{}
+		}
+		return matches
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	return matches[0]
+}
+
+// appendElement appends element index of arr to matches, if it exists
+func appendElement(matches, arr []interface{}, index int) []interface{} {
+	if index < 0 {
+		index += len(arr)
+	}
+	if index < 0 || index >= len(arr) {
+		return matches
+	}
+	return append(matches, arr[index])
+}
+
+// extractQueryFlag removes -query or --query from the arguments of a
+// command, before any "--", so that it may follow the command like in
+// "pgo get docs --all --query 'results[*].id'". expr is "" if there is none.
+func extractQueryFlag(args []string) (expr string, rest []string, err error) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return expr, append(rest, args[i:]...), nil
+		}
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "query" {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return "", nil, usagef("flag needs an argument: -query")
+			}
+			i++
+			value = args[i]
+		}
+		expr = value
+	}
+	return expr, rest, nil
+}
+
+// commandHasFlag reports whether the command args start with defines flag
+// name itself
+func commandHasFlag(args []string, name string) bool {
+	for _, s := range findSpecs(args) {
+		if s.flagSet().Lookup(name) != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQueryApply(t *testing.T) {
+	value, err := decodeOrdered([]byte(`{"count": 2, "results": [
+		{"id": 1, "title": "a", "tags": [1, 2], "owner": null},
+		{"id": 2, "title": "b", "tags": [3]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"count":              `2`,
+		"$.count":            `2`,
+		"results[*].id":      `[1,2]`,
+		"results.#.id":       `[1,2]`,
+		"results[1].title":   `"b"`,
+		"results.0.title":    `"a"`,
+		"results[-1].id":     `2`,
+		`results[0]["id"]`:   `1`,
+		"results.#":          `2`,
+		"results[*].tags[*]": `[1,2,3]`,
+		"results[*].owner":   `[null]`,
+		"results[0].owner":   `null`,
+		"missing":            `null`,
+		"results[5].id":      `null`,
+		"missing[*]":         `[]`,
+		"":                   `{"count":2,"results":[{"id":1,"title":"a","tags":[1,2],"owner":null},{"id":2,"title":"b","tags":[3]}]}`,
+	}
+	for expr, want := range tests {
+		q, err := parseQuery(expr)
+		if err != nil {
+			t.Errorf("parseQuery(%q) failed: %v", expr, err)
+			continue
+		}
+		if got := compactJSON(q.apply(value)); got != want {
+			t.Errorf("query %q = %s, want %s", expr, got, want)
+		}
+	}
+
+	for _, expr := range []string{"results[", "results[x]"} {
+		if _, err := parseQuery(expr); err == nil {
+			t.Errorf("parseQuery(%q) succeeded", expr)
+		}
+	}
+}
+
+func TestExtractQueryFlag(t *testing.T) {
+	expr, rest, err := extractQueryFlag([]string{"docs", "--all", "--query", "results[*].id", "--", "-query"})
+	if err != nil || expr != "results[*].id" || !reflect.DeepEqual(rest, []string{"docs", "--all", "--", "-query"}) {
+		t.Errorf("extractQueryFlag = %q, %q, %v", expr, rest, err)
+	}
+	if expr, rest, _ := extractQueryFlag([]string{"-query=count", "docs"}); expr != "count" || !reflect.DeepEqual(rest, []string{"docs"}) {
+		t.Errorf("extractQueryFlag = %q, %q", expr, rest)
+	}
+	if _, _, err := extractQueryFlag([]string{"docs", "-query"}); err == nil {
+		t.Error("expected error for -query without a value")
+	}
+}
+
+func TestCLI_Query(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 1, "name": "tax"}]}`))
+		case "/api/documents/":
+			w.Write([]byte(`{"count": 2, "next": null, "results": [{"id": 10, "title": "Invoice", "tags": [1]}, {"id": 11, "title": "Receipt", "tags": []}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"get", "docs", "--all", "--query", "results[*].id"}, "[\n  10,\n  11\n]\n"},
+		{[]string{"-query", "results.0.title", "get", "docs"}, "\"Invoice\"\n"},
+		{[]string{"-output-format", "csv", "get", "docs", "-query=results.#.title"}, "value\nInvoice\nReceipt\n"},
+	}
+	for _, tt := range tests {
+		stdout, stderr, err := run(tt.args...)
+		if err != nil {
+			t.Fatalf("%v failed: %v\nStderr: %s", tt.args, err, stderr)
+		}
+		if stdout != tt.want {
+			t.Errorf("%v output = %q, want %q", tt.args, stdout, tt.want)
+		}
+	}
+
+	if _, stderr, err := run("get", "docs", "--query", "results[x]"); err == nil || !strings.Contains(stderr, "invalid query") {
+		t.Errorf("expected invalid query error, got %v, stderr: %s", err, stderr)
+	}
+}
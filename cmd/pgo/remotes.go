@@ -0,0 +1,168 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RemoteInfo describes a remote. Remotes are the profiles of the config
+// file, named after git remotes: -remote work selects the profile work like
+// -profile work, and pgo remotes adds and removes profiles without editing
+// the file by hand. The token itself is never shown.
+type RemoteInfo struct {
+	Name       string `json:"name"`
+	URL        string `json:"url"`
+	Default    bool   `json:"default"`
+	Token      bool   `json:"token"` // Whether the config holds a token
+	ReadOnly   bool   `json:"readonly,omitempty"`
+	Production bool   `json:"production,omitempty"`
+}
+
+// RemotesOutput is the result of remotes list
+type RemotesOutput struct {
+	Config  string       `json:"config"`
+	Remotes []RemoteInfo `json:"remotes"`
+}
+
+// RemoteAddOutput is the result of remotes add
+type RemoteAddOutput struct {
+	Config  string `json:"config"`
+	Remote  string `json:"remote"`
+	URL     string `json:"url"`
+	Default bool   `json:"default"`
+}
+
+// RemoteRemoveOutput is the result of remotes rm
+type RemoteRemoveOutput struct {
+	Config  string `json:"config"`
+	Remote  string `json:"remote"`
+	Default bool   `json:"default"` // Whether it was the default, which is now unset
+}
+
+// remoteAddFlags are the flags of remotes add
+type remoteAddFlags struct {
+	token       *string
+	makeDefault *bool
+}
+
+func addRemoteAddFlags(fs *flag.FlagSet) *remoteAddFlags {
+	return &remoteAddFlags{
+		token:       fs.String("token", "", "API token to store in the config file (default: none; use pgo -remote <name> login)"),
+		makeDefault: fs.Bool("default", false, "Make it the default remote"),
+	}
+}
+
+// runRemotes runs the remotes subcommands, which only read and edit the
+// config file at configPath
+func runRemotes(configPath string, args []string) error {
+	if len(args) == 0 {
+		return commandUsage("remotes")
+	}
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	var output interface{}
+	switch args[0] {
+	case "list":
+		if len(args) != 1 {
+			return commandUsage("remotes list")
+		}
+		remotes := RemotesOutput{Config: configPath, Remotes: []RemoteInfo{}}
+		for _, p := range cfg.Profiles {
+			remotes.Remotes = append(remotes.Remotes, RemoteInfo{
+				Name: p.Name, URL: p.URL, Default: p.Name == cfg.DefaultProfile, Token: p.Token != "",
+				ReadOnly: p.ReadOnly, Production: p.Production,
+			})
+		}
+		sort.Slice(remotes.Remotes, func(i, j int) bool { return remotes.Remotes[i].Name < remotes.Remotes[j].Name })
+		output = remotes
+	case "add":
+		fs := flag.NewFlagSet("remotes add", flag.ContinueOnError)
+		flags := addRemoteAddFlags(fs)
+		positional, err := parseInterspersed(fs, args[1:])
+		if err != nil {
+			return usagef("parse remotes add flags: %w", err)
+		}
+		if len(positional) != 2 {
+			return commandUsage("remotes add")
+		}
+		name := positional[0]
+		if !bareKey.MatchString(name) {
+			return usagef("invalid remote name %s (use letters, digits, _ and -)", name)
+		}
+		if cfg.Profiles[name] != nil {
+			return usagef("remote %q already exists; remove it first or edit %s", name, configPath)
+		}
+		baseURL, err := normalizeURL(positional[1])
+		if err != nil {
+			return usagef("%w", err)
+		}
+		// The first remote becomes the default, like with pgo init
+		makeDefault := *flags.makeDefault || len(cfg.Profiles) == 0
+		if err := writeProfile(configPath, Profile{Name: name, URL: baseURL, Token: *flags.token}, makeDefault); err != nil {
+			return err
+		}
+		output = RemoteAddOutput{Config: configPath, Remote: name, URL: baseURL, Default: makeDefault}
+	case "rm":
+		if len(args) != 2 {
+			return commandUsage("remotes rm")
+		}
+		name := args[1]
+		if cfg.Profiles[name] == nil {
+			return fmt.Errorf("remote %q not found in config (%s)", name, configPath)
+		}
+		if err := removeProfile(configPath, name); err != nil {
+			return err
+		}
+		output = RemoteRemoveOutput{Config: configPath, Remote: name, Default: cfg.DefaultProfile == name}
+	default:
+		return commandUsage("remotes")
+	}
+
+	if err := writeOutput(output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// removeProfile removes the table of profile name from the config file at
+// path, and default_profile if it names it. Like writeProfile, it edits the
+// file as text, so comments elsewhere are kept.
+func removeProfile(path, name string) error {
+	lines, err := readConfigLines(path)
+	if err != nil {
+		return err
+	}
+
+	var kept []string
+	inRoot, removing := true, false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			inRoot = false
+			header, err := parseTableHeader(trimmed)
+			removing = err == nil && header == name
+		}
+		if removing {
+			continue
+		}
+		if inRoot {
+			key, value, ok := strings.Cut(trimmed, "=")
+			if ok && strings.TrimSpace(key) == "default_profile" {
+				if v, _, err := parseValue(strings.TrimSpace(value)); err == nil && v == name {
+					continue
+				}
+			}
+		}
+		kept = append(kept, line)
+	}
+	// Drop blank lines left at the end by the removed table
+	for len(kept) > 0 && strings.TrimSpace(kept[len(kept)-1]) == "" {
+		kept = kept[:len(kept)-1]
+	}
+	return writeConfigLines(path, kept)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRemoveProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	config := `# pgo config
+default_profile = "work"
+
+[profiles.home]
+url = "https://home.example" # NAS
+token = "a"
+
+[profiles.work]
+url = "https://work.example"
+token = "b"
+`
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := removeProfile(path, "work"); err != nil {
+		t.Fatalf("removeProfile failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	want := `# pgo config
+
+[profiles.home]
+url = "https://home.example" # NAS
+token = "a"
+`
+	if string(data) != want {
+		t.Errorf("config =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestCLI_Remotes(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		auth []string
+	)
+	// takeAuth returns the Authorization headers received so far and resets
+	// them
+	takeAuth := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		taken := auth
+		auth = nil
+		return taken
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auth = append(auth, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"count": 0, "results": []}`))
+	}))
+	defer server.Close()
+
+	configHome := t.TempDir()
+	run := func(args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL=", "PAPERLESS_TOKEN=", "PAPERLESS_PROFILE=",
+			"XDG_CONFIG_HOME="+configHome, "XDG_CACHE_HOME="+t.TempDir())
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	if _, stderr, err := run("remotes", "add", "home", server.URL, "-token", "home-token"); err != nil {
+		t.Fatalf("remotes add home failed: %v\nStderr: %s", err, stderr)
+	}
+	stdout, stderr, err := run("remotes", "add", "work", server.URL+"/", "-token", "work-token")
+	var added RemoteAddOutput
+	if err != nil || json.Unmarshal([]byte(stdout), &added) != nil || added.URL != server.URL || added.Default {
+		t.Fatalf("remotes add work = %s, %v, stderr: %s", stdout, err, stderr)
+	}
+	if _, stderr, err := run("remotes", "add", "work", server.URL); err == nil || !strings.Contains(stderr, "already exists") {
+		t.Errorf("expected duplicate remote error, got %v, stderr: %s", err, stderr)
+	}
+
+	stdout, _, err = run("remotes", "list")
+	var list RemotesOutput
+	if err != nil || json.Unmarshal([]byte(stdout), &list) != nil || len(list.Remotes) != 2 {
+		t.Fatalf("remotes list = %s, %v", stdout, err)
+	}
+	if home := list.Remotes[0]; home.Name != "home" || !home.Default || !home.Token {
+		t.Errorf("home = %+v, want the default with a token", home)
+	}
+	if strings.Contains(stdout, "work-token") {
+		t.Errorf("remotes list shows a token: %s", stdout)
+	}
+
+	// The default remote, then the one selected with -remote
+	takeAuth()
+	if _, stderr, err := run("get", "tags"); err != nil {
+		t.Fatalf("get tags failed: %v\nStderr: %s", err, stderr)
+	}
+	if _, stderr, err := run("-remote", "work", "get", "tags"); err != nil {
+		t.Fatalf("-remote work get tags failed: %v\nStderr: %s", err, stderr)
+	}
+	if auth := takeAuth(); len(auth) != 2 || auth[0] != "Token home-token" || auth[1] != "Token work-token" {
+		t.Errorf("Authorization headers = %v", auth)
+	}
+
+	stdout, _, err = run("remotes", "rm", "home")
+	var removed RemoteRemoveOutput
+	if err != nil || json.Unmarshal([]byte(stdout), &removed) != nil || !removed.Default {
+		t.Errorf("remotes rm home = %s, %v", stdout, err)
+	}
+	if _, stderr, err := run("-remote", "home", "get", "tags"); err == nil || !strings.Contains(stderr, `profile "home" not found`) {
+		t.Errorf("expected removed remote error, got %v, stderr: %s", err, stderr)
+	}
+	if _, stderr, err := run("remotes", "rm", "home"); err == nil {
+		t.Errorf("expected error removing a missing remote, stderr: %s", stderr)
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// reportNone labels documents without a value for a dimension, e.g. without
+// a correspondent
+const reportNone = "(none)"
+
+// reportTotal labels the totals row and column of a matrix
+const reportTotal = "total"
+
+// reportDimensions are the values documents can be grouped by
+var reportDimensions = []string{"correspondent", "doctype", "storagepath", "tag", "year", "month"}
+
+// ReportMatrix is the result of report matrix. Each result is an object with
+// the row label under the Rows key, a count per column and a total, so the
+// csv and table formats show the matrix as is.
+type ReportMatrix struct {
+	Rows      string            `json:"rows"`
+	Cols      string            `json:"cols"`
+	Documents int               `json:"documents"`
+	Columns   []string          `json:"columns"`
+	Results   []json.RawMessage `json:"results"`
+}
+
+// reportLabeler returns the labels of a document for a dimension. A document
+// has several labels for tag, so the counts of a row or column can add up to
+// more than the number of documents.
+type reportLabeler func(doc *paperless.Document) []string
+
+// newReportLabeler returns the labeler for dimension, fetching the names of
+// the objects it refers to
+func newReportLabeler(ctx context.Context, client *paperless.Client, dimension string, forceRefresh bool) (reportLabeler, error) {
+	switch dimension {
+	case "year", "month":
+		layout := "2006"
+		if dimension == "month" {
+			layout = "2006-01"
+		}
+		return func(doc *paperless.Document) []string {
+			created := doc.CreatedDay().Time()
+			if created.IsZero() {
+				return []string{reportNone}
+			}
+			return []string{created.Format(layout)}
+		}, nil
+	case "tag":
+		names, err := getTagNamesWithCache(ctx, client, forceRefresh, DefaultCacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tags: %w", err)
+		}
+		return func(doc *paperless.Document) []string {
+			if len(doc.Tags) == 0 {
+				return []string{reportNone}
+			}
+			labels := make([]string, len(doc.Tags))
+			for i, id := range doc.Tags {
+				labels[i] = reportName(names, &id)
+			}
+			return labels
+		}, nil
+	}
+
+	var names map[int]string
+	var ref func(doc *paperless.Document) *int
+	var err error
+	switch dimension {
+	case "correspondent":
+		ref = func(doc *paperless.Document) *int { return doc.Correspondent }
+		if names, err = getCorrespondentNamesWithCache(ctx, client, forceRefresh, DefaultCacheTTL); err != nil {
+			return nil, fmt.Errorf("failed to fetch correspondents: %w", err)
+		}
+	case "doctype":
+		ref = func(doc *paperless.Document) *int { return doc.DocumentType }
+		doctypes, err := listAll(ctx, func(ctx context.Context, opts *paperless.ListOptions) (*paperless.List[paperless.DocumentType], error) {
+			list, err := client.ListDocumentTypes(ctx, opts)
+			return (*paperless.List[paperless.DocumentType])(list), err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch document types: %w", err)
+		}
+		names = make(map[int]string, len(doctypes))
+		for _, dt := range doctypes {
+			names[dt.ID] = dt.Name
+		}
+	case "storagepath":
+		ref = func(doc *paperless.Document) *int { return doc.StoragePath }
+		paths, err := listAll(ctx, func(ctx context.Context, opts *paperless.ListOptions) (*paperless.List[paperless.StoragePath], error) {
+			list, err := client.ListStoragePaths(ctx, opts)
+			return (*paperless.List[paperless.StoragePath])(list), err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch storage paths: %w", err)
+		}
+		names = make(map[int]string, len(paths))
+		for _, sp := range paths {
+			names[sp.ID] = sp.Name
+		}
+	default:
+		return nil, usagef("unknown report dimension: %s (want %s)", dimension, strings.Join(reportDimensions, ", "))
+	}
+	return func(doc *paperless.Document) []string {
+		return []string{reportName(names, ref(doc))}
+	}, nil
+}
+
+// reportName is the name of the object id refers to, or its ID if the name
+// is unknown
+func reportName(names map[int]string, id *int) string {
+	if id == nil {
+		return reportNone
+	}
+	if name, ok := names[*id]; ok {
+		return name
+	}
+	return strconv.Itoa(*id)
+}
+
+// sortReportLabels sorts labels case-insensitively with reportNone last
+func sortReportLabels(labels []string) {
+	sort.Slice(labels, func(i, j int) bool {
+		if (labels[i] == reportNone) != (labels[j] == reportNone) {
+			return labels[j] == reportNone
+		}
+		return strings.ToLower(labels[i]) < strings.ToLower(labels[j])
+	})
+}
+
+// reportCounts counts documents by row and column label
+type reportCounts struct {
+	documents int
+	cells     map[string]map[string]int
+	cols      map[string]bool
+}
+
+func newReportCounts() *reportCounts {
+	return &reportCounts{cells: map[string]map[string]int{}, cols: map[string]bool{}}
+}
+
+func (c *reportCounts) add(doc *paperless.Document, rowLabels, colLabels reportLabeler) {
+	c.documents++
+	for _, row := range rowLabels(doc) {
+		if c.cells[row] == nil {
+			c.cells[row] = map[string]int{}
+		}
+		for _, col := range colLabels(doc) {
+			c.cells[row][col]++
+			c.cols[col] = true
+		}
+	}
+}
+
+// matrix lays out the counts with sorted rows and columns, a total column
+// and a totals row
+func (c *reportCounts) matrix(rows, cols string) ReportMatrix {
+	columns := make([]string, 0, len(c.cols))
+	for col := range c.cols {
+		columns = append(columns, col)
+	}
+	sortReportLabels(columns)
+	rowLabels := make([]string, 0, len(c.cells))
+	for row := range c.cells {
+		rowLabels = append(rowLabels, row)
+	}
+	sortReportLabels(rowLabels)
+
+	m := ReportMatrix{Rows: rows, Cols: cols, Documents: c.documents, Columns: columns, Results: []json.RawMessage{}}
+	colTotals := map[string]int{}
+	for _, row := range rowLabels {
+		m.Results = append(m.Results, reportRow(rows, row, columns, c.cells[row]))
+		for col, n := range c.cells[row] {
+			colTotals[col] += n
+		}
+	}
+	m.Results = append(m.Results, reportRow(rows, reportTotal, columns, colTotals))
+	return m
+}
+
+// reportRow encodes a row as a JSON object with its keys in column order
+func reportRow(key, label string, columns []string, counts map[string]int) json.RawMessage {
+	var b bytes.Buffer
+	name, _ := json.Marshal(key)
+	value, _ := json.Marshal(label)
+	fmt.Fprintf(&b, "{%s:%s", name, value)
+	total := 0
+	for _, col := range columns {
+		colName, _ := json.Marshal(col)
+		fmt.Fprintf(&b, ",%s:%d", colName, counts[col])
+		total += counts[col]
+	}
+	fmt.Fprintf(&b, `,%q:%d}`, reportTotal, total)
+	return b.Bytes()
+}
+
+// reportMatrixFlags are the flags of report matrix
+type reportMatrixFlags struct {
+	rows    *string
+	cols    *string
+	format  *string
+	filters *docFilters
+}
+
+func addReportMatrixFlags(fs *flag.FlagSet) *reportMatrixFlags {
+	return &reportMatrixFlags{
+		rows:    fs.String("rows", "correspondent", "Dimension of the rows: "+strings.Join(reportDimensions, ", ")),
+		cols:    fs.String("cols", "year", "Dimension of the columns: "+strings.Join(reportDimensions, ", ")),
+		format:  fs.String("format", "", "Output format: pretty, json, table, csv or yaml (default: -output-format)"),
+		filters: addDocFilterFlags(fs),
+	}
+}
+
+func runReport(client *paperless.Client, args []string, forceRefresh bool) error {
+	if len(args) == 0 || args[0] != "matrix" {
+		return commandUsage("report matrix")
+	}
+	matrixFlags := flag.NewFlagSet("report matrix", flag.ContinueOnError)
+	matrix := addReportMatrixFlags(matrixFlags)
+	if err := matrixFlags.Parse(args[1:]); err != nil {
+		return usagef("parse report matrix flags: %w", err)
+	}
+	rows, cols, format, filters := matrix.rows, matrix.cols, matrix.format, matrix.filters
+	if matrixFlags.NArg() != 0 {
+		return commandUsage("report matrix")
+	}
+	if *format != "" {
+		if err := configureOutput(*format, "", plainOutput); err != nil {
+			return usagef("%w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	rowLabels, err := newReportLabeler(ctx, client, *rows, forceRefresh)
+	if err != nil {
+		return err
+	}
+	colLabels, err := newReportLabeler(ctx, client, *cols, forceRefresh)
+	if err != nil {
+		return err
+	}
+
+	opts := &paperless.ListOptions{PageSize: 100}
+	if err := filters.apply(ctx, client, forceRefresh, opts); err != nil {
+		return err
+	}
+
+	counts := newReportCounts()
+	prog := newProgress("Fetching documents", 0)
+	it := client.IterDocuments(opts, paperless.WithIDCursor())
+	for it.Next(ctx) {
+		prog.setTotal(it.Count())
+		doc := it.Document()
+		counts.add(&doc, rowLabels, colLabels)
+		prog.add(1, 0)
+	}
+	prog.finish()
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	if err := writeOutput(counts.matrix(*rows, *cols)); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+func TestReportMatrix(t *testing.T) {
+	byTag := func(doc *paperless.Document) []string {
+		labels := []string{}
+		for _, id := range doc.Tags {
+			labels = append(labels, map[int]string{1: "tax", 2: "Bank"}[id])
+		}
+		if len(labels) == 0 {
+			return []string{reportNone}
+		}
+		return labels
+	}
+	byTitle := func(doc *paperless.Document) []string { return []string{doc.Title} }
+
+	counts := newReportCounts()
+	for _, doc := range []paperless.Document{
+		{Title: "2024", Tags: []int{1, 2}},
+		{Title: "2023", Tags: []int{1}},
+		{Title: "2023"},
+	} {
+		counts.add(&doc, byTag, byTitle)
+	}
+	m := counts.matrix("tag", "year")
+	if m.Documents != 3 || strings.Join(m.Columns, ",") != "2023,2024" {
+		t.Errorf("matrix = %+v", m)
+	}
+	var rows []string
+	for _, r := range m.Results {
+		rows = append(rows, string(r))
+	}
+	want := []string{
+		`{"tag":"Bank","2023":0,"2024":1,"total":1}`,
+		`{"tag":"tax","2023":1,"2024":1,"total":2}`,
+		`{"tag":"(none)","2023":1,"2024":0,"total":1}`,
+		`{"tag":"total","2023":2,"2024":2,"total":4}`,
+	}
+	if strings.Join(rows, "\n") != strings.Join(want, "\n") {
+		t.Errorf("rows =\n%s\nwant\n%s", strings.Join(rows, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCLI_ReportMatrix(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/correspondents/":
+			w.Write([]byte(`{"count": 2, "results": [{"id": 1, "name": "ACME Corp"}, {"id": 2, "name": "City, Inc"}]}`))
+		case "/api/documents/":
+			if r.URL.Query().Get("id__gt") != "" {
+				w.Write([]byte(`{"count": 4, "results": []}`))
+				return
+			}
+			if got := r.URL.Query().Get("created__date__gt"); got != "2021-12-31" {
+				t.Errorf("created__date__gt = %q, want the -created-after filter", got)
+			}
+			w.Write([]byte(`{"count": 4, "results": [
+				{"id": 1, "correspondent": 1, "created": "2022-03-01"},
+				{"id": 2, "correspondent": 1, "created": "2023-01-10"},
+				{"id": 3, "correspondent": 2, "created": "2023-05-05"},
+				{"id": 4, "correspondent": null, "created": "2023-07-07"}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory", "report", "matrix"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	stdout, stderr, err := run("--rows", "correspondent", "--cols", "year", "--format", "csv", "-created-after", "2021-12-31")
+	if err != nil {
+		t.Fatalf("report matrix failed: %v\nStderr: %s", err, stderr)
+	}
+	want := "correspondent,2022,2023,total\n" +
+		"ACME Corp,1,1,2\n" +
+		"\"City, Inc\",0,1,1\n" +
+		"(none),0,1,1\n" +
+		"total,1,3,4\n"
+	if stdout != want {
+		t.Errorf("csv output =\n%s\nwant\n%s", stdout, want)
+	}
+
+	stdout, stderr, err = run("-created-after", "2021-12-31")
+	if err != nil {
+		t.Fatalf("report matrix failed: %v\nStderr: %s", err, stderr)
+	}
+	var m struct {
+		Rows      string                   `json:"rows"`
+		Documents int                      `json:"documents"`
+		Results   []map[string]interface{} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &m); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, stdout)
+	}
+	if m.Rows != "correspondent" || m.Documents != 4 || len(m.Results) != 4 || m.Results[0]["2023"] != float64(1) {
+		t.Errorf("json output = %+v", m)
+	}
+
+	if _, stderr, err := run("--rows", "owner"); err == nil || !strings.Contains(stderr, "unknown report dimension: owner") {
+		t.Errorf("expected unknown dimension error, got %v, stderr: %s", err, stderr)
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// commandSpec describes one form of a command. The usage messages, pgo help,
+// the man page and the shell completions are generated from commandSpecs,
+// and each command builds its flag set with the same flags function as its
+// spec, so the documentation cannot drift from what is parsed.
+type commandSpec struct {
+	name    string                 // Command words, e.g. "get docs"
+	args    string                 // Positional arguments, e.g. "<id>..."
+	summary string                 // One line, starting with a verb
+	flags   func(fs *flag.FlagSet) // Registers the flags, nil if there are none
+}
+
+// commandSpecs lists the commands in the order they are documented
+var commandSpecs = []commandSpec{
+	{name: "get docs", args: "[-]", summary: "List documents, or those whose IDs are read from stdin with -", flags: func(fs *flag.FlagSet) { addDocListFlags(fs) }},
+	{name: "get docs", args: "<id>", summary: "Get a document"},
+	{name: "get tags", args: "[<id>]", summary: "List tags or get one"},
+	{name: "get correspondents", args: "[<id>]", summary: "List correspondents or get one"},
+	{name: "get doctypes", args: "[<id>]", summary: "List document types or get one"},
+	{name: "get storagepaths", args: "[<id>]", summary: "List storage paths or get one"},
+	{name: "search docs", args: "<query>", summary: "Search documents", flags: func(fs *flag.FlagSet) { addSearchDocsFlags(fs) }},
+	{name: "search tags", args: "<query>", summary: "Search tags"},
+	{name: "diff docs", args: "<id1> <id2>", summary: "Compare the metadata and files of two documents, e.g. duplicates"},
+	{name: "view", args: "<name|id>", summary: "List the documents of a saved view", flags: func(fs *flag.FlagSet) { addViewFlags(fs) }},
+	{name: "apply docs", args: "<id>", summary: "Set, add or remove the tags of a document", flags: func(fs *flag.FlagSet) { addApplyDocFlags(fs) }},
+	{name: "apply docs", args: "[-]", summary: "Add or remove tags of the documents listed in a file or stdin", flags: func(fs *flag.FlagSet) { addBulkApplyFlags(fs) }},
+	{name: "add tag", args: "<name>", summary: "Create a tag"},
+	{name: "add correspondent", args: "<name>", summary: "Create a correspondent", flags: func(fs *flag.FlagSet) { addMatchFlags(fs) }},
+	{name: "add doctype", args: "<name>", summary: "Create a document type", flags: func(fs *flag.FlagSet) { addMatchFlags(fs) }},
+	{name: "tag tree", summary: "Show tags as a hierarchy by name, or add parent tags to documents", flags: func(fs *flag.FlagSet) { addTagTreeFlags(fs) }},
+	{name: "delete docs", args: "<id>... | -", summary: "Delete documents after confirmation, or with --yes those whose IDs are read from stdin with -", flags: func(fs *flag.FlagSet) { addDeleteFlags(fs) }},
+	{name: "delete tags", args: "<id>...", summary: "Delete tags after confirmation", flags: func(fs *flag.FlagSet) { addDeleteFlags(fs) }},
+	{name: "preview docs", args: "<id>", summary: "Show a document's thumbnail and a content excerpt", flags: func(fs *flag.FlagSet) { addPreviewFlags(fs) }},
+	{name: "browse", summary: "Browse documents interactively", flags: func(fs *flag.FlagSet) { addBrowseFlags(fs) }},
+	{name: "watch", args: "<dir>", summary: "Upload new files in a directory", flags: func(fs *flag.FlagSet) { addWatchFlags(fs) }},
+	{name: "correspondents normalize", summary: "Merge duplicate correspondents", flags: func(fs *flag.FlagSet) { addNormalizeFlags(fs) }},
+	{name: "report matrix", summary: "Count documents by two of correspondent, doctype, storagepath, tag, year and month", flags: func(fs *flag.FlagSet) { addReportMatrixFlags(fs) }},
+	{name: "audit", summary: "List documents without tags, correspondent, document type or content, by problem", flags: func(fs *flag.FlagSet) { addAuditFlags(fs) }},
+	{name: "autoassign", summary: "Assign correspondents, document types and tags by the title, content and filename patterns of a rules file", flags: func(fs *flag.FlagSet) { addAutoassignFlags(fs) }},
+	{name: "check-ocr", summary: "List documents with empty or very short content, which OCR likely missed, and optionally reprocess them", flags: func(fs *flag.FlagSet) { addCheckOCRFlags(fs) }},
+	{name: "export", summary: "Download all documents and their metadata, resuming an earlier export", flags: func(fs *flag.FlagSet) { addExportFlags(fs) }},
+	{name: "download docs", summary: "Download the archived PDFs of the documents matching the filters, with a manifest", flags: func(fs *flag.FlagSet) { addDownloadFlags(fs) }},
+	{name: "asn next", summary: "Reserve the next archive serial number, e.g. for a label", flags: func(fs *flag.FlagSet) { addASNNextFlags(fs) }},
+	{name: "asn assign", args: "<id>", summary: "Assign the next or a reserved archive serial number to a document", flags: func(fs *flag.FlagSet) { addASNAssignFlags(fs) }},
+	{name: "perms show", args: "<id>", summary: "Show a document's owner and permissions"},
+	{name: "perms set", args: "<id>", summary: "Change a document's permissions", flags: func(fs *flag.FlagSet) { addPermsSetFlags(fs) }},
+	{name: "cache status", summary: "Show cache age, entries and TTL", flags: func(fs *flag.FlagSet) { addCacheFlags(fs) }},
+	{name: "cache clear", summary: "Remove cached data", flags: func(fs *flag.FlagSet) { addCacheFlags(fs) }},
+	{name: "cache path", summary: "Print the cache directory or file paths", flags: func(fs *flag.FlagSet) { addCacheFlags(fs) }},
+	{name: "cache warm", summary: "Refresh the tag, doc and correspondent caches", flags: func(fs *flag.FlagSet) { addCacheWarmFlags(fs) }},
+	{name: "init", summary: "Set up a profile interactively: URL, token, connection check and shell completion"},
+	{name: "login", summary: "Store an API token for the instance in the OS keyring"},
+	{name: "logout", summary: "Remove the instance's API token from the OS keyring"},
+	{name: "config", args: "[path]", summary: "Print the config file path"},
+	{name: "remotes list", summary: "List the remotes (config file profiles) and which is the default"},
+	{name: "remotes add", args: "<name> <url>", summary: "Add a remote to the config file", flags: func(fs *flag.FlagSet) { addRemoteAddFlags(fs) }},
+	{name: "remotes rm", args: "<name>", summary: "Remove a remote from the config file"},
+	{name: "rag", args: "<args>", summary: "Run pgo-rag (RAG indexing and search)"},
+	{name: "help", args: "[--man] [<command>]", summary: "Show the help of a command, or print the man page"},
+	{name: "completion", args: "bash|zsh", summary: "Print a shell completion script"},
+}
+
+// flagSet returns the flags of s
+func (s commandSpec) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(s.name, flag.ContinueOnError)
+	if s.flags != nil {
+		s.flags(fs)
+	}
+	return fs
+}
+
+// synopsis returns s as a command line with every flag, e.g.
+// "pgo browse [-limit <int>] [-query <string>]"
+func (s commandSpec) synopsis() string {
+	parts := []string{"pgo", s.name}
+	s.flagSet().VisitAll(func(f *flag.Flag) {
+		if name, _ := flag.UnquoteUsage(f); name != "" {
+			parts = append(parts, fmt.Sprintf("[-%s <%s>]", f.Name, name))
+		} else {
+			parts = append(parts, fmt.Sprintf("[-%s]", f.Name))
+		}
+	})
+	if s.args != "" {
+		parts = append(parts, s.args)
+	}
+	return strings.Join(parts, " ")
+}
+
+// findSpecs returns the specs of the command named by the leading words of
+// args, or of every command starting with them, e.g. all get commands for
+// "get". Flags in args are skipped.
+func findSpecs(args []string) []commandSpec {
+	var words []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			words = append(words, arg)
+		}
+	}
+	// The longest command name that args start with wins
+	var found []commandSpec
+	longest := 0
+	for _, s := range commandSpecs {
+		name := strings.Fields(s.name)
+		if len(name) < longest || len(name) > len(words) || strings.Join(words[:len(name)], " ") != s.name {
+			continue
+		}
+		if len(name) > longest {
+			found, longest = nil, len(name)
+		}
+		found = append(found, s)
+	}
+	if found != nil {
+		return found
+	}
+	prefix := strings.Join(words, " ") + " "
+	for _, s := range commandSpecs {
+		if strings.HasPrefix(s.name+" ", prefix) {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+// commandUsage returns a usage error listing the forms of command name
+func commandUsage(name string) error {
+	specs := findSpecs(strings.Fields(name))
+	lines := make([]string, len(specs))
+	for i, s := range specs {
+		lines[i] = s.synopsis()
+	}
+	return usagef("usage: %s", strings.Join(lines, "\n       "))
+}
+
+// helpRequested reports whether args ask for help with -h, -help or --help
+// before any "--"
+func helpRequested(args []string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "--":
+			return false
+		case "-h", "-help", "--help":
+			return true
+		}
+	}
+	return false
+}
+
+// writeOverview writes the list of commands and how to get more help
+func writeOverview(w io.Writer) {
+	fmt.Fprintln(w, "usage: pgo [global flags] <command> [args]")
+	fmt.Fprintln(w, "Available commands:")
+	for _, s := range commandSpecs {
+		line := s.name
+		if s.flags != nil {
+			line += " [flags]"
+		}
+		if s.args != "" {
+			line += " " + s.args
+		}
+		fmt.Fprintf(w, "  %s - %s\n", line, s.summary)
+	}
+	fmt.Fprintln(w, "Run 'pgo help <command>' for the flags of a command and 'pgo -h' for the global flags.")
+}
+
+// writeCommandHelp writes the synopsis, summary and flags of every form of
+// the command named by args
+func writeCommandHelp(w io.Writer, args []string) error {
+	specs := findSpecs(args)
+	if len(specs) == 0 {
+		return usagef("unknown command: %s", strings.Join(args, " "))
+	}
+	for i, s := range specs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "usage: %s\n\n%s\n", s.synopsis(), s.summary)
+		fs := s.flagSet()
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(w, "\nFlags:")
+			fs.SetOutput(w)
+			fs.PrintDefaults()
+		}
+	}
+	return nil
+}
+
+// runHelp implements pgo help [--man] [<command>]
+func runHelp(w io.Writer, args []string) error {
+	if len(args) > 0 && (args[0] == "--man" || args[0] == "-man") {
+		if len(args) > 1 {
+			return commandUsage("help")
+		}
+		writeManPage(w)
+		return nil
+	}
+	if len(args) == 0 {
+		writeOverview(w)
+		return nil
+	}
+	return writeCommandHelp(w, args)
+}
+
+// roffEscape escapes text for a roff man page
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// writeManFlags writes the flags of fs as a roff tagged paragraph list
+func writeManFlags(w io.Writer, fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		if name != "" {
+			fmt.Fprintf(w, ".TP\n.BI \\-%s \" %s\"\n", roffEscape(f.Name), roffEscape(name))
+		} else {
+			fmt.Fprintf(w, ".TP\n.B \\-%s\n", roffEscape(f.Name))
+		}
+		if f.DefValue != "" && f.DefValue != "0" && f.DefValue != "false" {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		fmt.Fprintln(w, roffEscape(usage))
+	})
+}
+
+// writeManPage writes the pgo(1) man page in roff format
+func writeManPage(w io.Writer) {
+	fmt.Fprintln(w, `.TH PGO 1 "" "pgo" "User Commands"`)
+	fmt.Fprintln(w, ".SH NAME")
+	fmt.Fprintln(w, `pgo \- command-line client for Paperless\-ngx`)
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintln(w, `.B pgo`)
+	fmt.Fprintln(w, `[\fIglobal flags\fR] \fIcommand\fR [\fIargs\fR]`)
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	fmt.Fprintln(w, "pgo lists, searches and changes the documents, tags and other objects of a Paperless\\-ngx instance. Results are written to stdout as JSON, or in the format chosen with \\-output\\-format.")
+	fmt.Fprintln(w, ".SH GLOBAL FLAGS")
+	fmt.Fprintln(w, "Global flags come before the command.")
+	global := flag.NewFlagSet("pgo", flag.ContinueOnError)
+	addGlobalFlags(global)
+	writeManFlags(w, global)
+	fmt.Fprintln(w, ".SH COMMANDS")
+	for _, s := range commandSpecs {
+		fmt.Fprintf(w, ".SS \"%s\"\n", roffEscape(s.synopsis()))
+		fmt.Fprintln(w, roffEscape(s.summary)+".")
+		writeManFlags(w, s.flagSet())
+	}
+	fmt.Fprintln(w, ".SH ENVIRONMENT")
+	for _, env := range [][2]string{
+		{"PAPERLESS_URL", "Paperless instance URL, unless \\-url is given"},
+		{"PAPERLESS_TOKEN", "API authentication token, unless \\-token is given"},
+		{"PAPERLESS_PROFILE", "Config file profile to use, unless \\-profile is given"},
+		{"PGO_NOTIFY", "Notification target of watch, unless \\-notify is given"},
+	} {
+		fmt.Fprintf(w, ".TP\n.B %s\n%s\n", env[0], env[1])
+	}
+	fmt.Fprintln(w, ".SH EXIT STATUS")
+	codes := make([]int, 0, len(errorTypes))
+	for code := range errorTypes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	fmt.Fprintf(w, ".TP\n.B 0\nSuccess\n")
+	for _, code := range codes {
+		fmt.Fprintf(w, ".TP\n.B %d\n%s\n", code, exitCodeDescriptions[code])
+	}
+}
+
+// completionWords returns, for each command prefix (e.g. "" or "get"), the
+// words that can follow it: further command words, or the flags of the
+// command once it is complete
+func completionWords() map[string][]string {
+	words := map[string][]string{}
+	add := func(prefix, word string) {
+		if !containsString(words[prefix], word) {
+			words[prefix] = append(words[prefix], word)
+		}
+	}
+	global := flag.NewFlagSet("pgo", flag.ContinueOnError)
+	addGlobalFlags(global)
+	global.VisitAll(func(f *flag.Flag) { add("", "-"+f.Name) })
+	for _, s := range commandSpecs {
+		name := strings.Fields(s.name)
+		for i, word := range name {
+			add(strings.Join(name[:i], " "), word)
+		}
+		s.flagSet().VisitAll(func(f *flag.Flag) { add(s.name, "-"+f.Name) })
+	}
+	return words
+}
+
+// writeBashCompletion writes a bash completion script. zsh uses it through
+// bashcompinit.
+func writeBashCompletion(w io.Writer) {
+	words := completionWords()
+	prefixes := make([]string, 0, len(words))
+	for prefix := range words {
+		prefixes = append(prefixes, prefix)
+	}
+	// Longer prefixes first, so that "get docs" is matched before "get"
+	sort.Slice(prefixes, func(i, j int) bool {
+		if len(prefixes[i]) != len(prefixes[j]) {
+			return len(prefixes[i]) > len(prefixes[j])
+		}
+		return prefixes[i] < prefixes[j]
+	})
+
+	var valueFlags []string
+	global := flag.NewFlagSet("pgo", flag.ContinueOnError)
+	addGlobalFlags(global)
+	global.VisitAll(func(f *flag.Flag) {
+		if name, _ := flag.UnquoteUsage(f); name != "" {
+			valueFlags = append(valueFlags, "-"+f.Name)
+		}
+	})
+
+	fmt.Fprintln(w, "# bash completion for pgo; generated by pgo completion bash")
+	fmt.Fprintln(w, "_pgo() {")
+	fmt.Fprintln(w, `	local cur="${COMP_WORDS[COMP_CWORD]}" path="" word words i`)
+	fmt.Fprintf(w, "\tlocal value_flags=\" %s \"\n", strings.Join(valueFlags, " "))
+	fmt.Fprintln(w, "	for ((i = 1; i < COMP_CWORD; i++)); do")
+	fmt.Fprintln(w, `		word="${COMP_WORDS[i]}"`)
+	fmt.Fprintln(w, `		if [[ $word == -* ]]; then`)
+	fmt.Fprintln(w, `			[[ -z $path && $word != *=* && $value_flags == *" $word "* ]] && ((i++))`)
+	fmt.Fprintln(w, "			continue")
+	fmt.Fprintln(w, "		fi")
+	fmt.Fprintln(w, `		path="${path:+$path }$word"`)
+	fmt.Fprintln(w, "	done")
+	fmt.Fprintln(w, `	case "$path" in`)
+	for _, prefix := range prefixes {
+		if prefix == "" {
+			continue
+		}
+		fmt.Fprintf(w, "\t\"%s\"|\"%s \"*) words=\"%s\" ;;\n", prefix, prefix, strings.Join(words[prefix], " "))
+	}
+	fmt.Fprintf(w, "\t\"\") words=\"%s\" ;;\n", strings.Join(words[""], " "))
+	fmt.Fprintln(w, "	*) return ;;")
+	fmt.Fprintln(w, "	esac")
+	fmt.Fprintln(w, `	COMPREPLY=($(compgen -W "$words" -- "$cur"))`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -o default -F _pgo pgo")
+}
+
+// runCompletion implements pgo completion bash|zsh
+func runCompletion(w io.Writer, args []string) error {
+	if len(args) != 1 {
+		return commandUsage("completion")
+	}
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(w)
+	case "zsh":
+		fmt.Fprintln(w, "autoload -U +X bashcompinit && bashcompinit")
+		writeBashCompletion(w)
+	default:
+		return usagef("unsupported shell: %s (want bash or zsh)", args[0])
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommandSpecs(t *testing.T) {
+	seen := map[string]bool{}
+	for _, s := range commandSpecs {
+		key := s.name + " " + s.args
+		if seen[key] {
+			t.Errorf("duplicate spec %q", key)
+		}
+		seen[key] = true
+		if s.summary == "" {
+			t.Errorf("spec %q has no summary", key)
+		}
+		// Registering a flag twice panics
+		s.flagSet()
+	}
+}
+
+func TestFindSpecs(t *testing.T) {
+	names := func(args ...string) []string {
+		var found []string
+		for _, s := range findSpecs(args) {
+			found = append(found, s.name+" "+s.args)
+		}
+		return found
+	}
+	if got, want := names("get", "docs", "5", "-all"), []string{"get docs [-]", "get docs <id>"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("get docs 5 = %q, want %q", got, want)
+	}
+	if got := names("cache"); len(got) != 4 {
+		t.Errorf("cache = %q, want the four cache commands", got)
+	}
+	if got := names("nosuch"); got != nil {
+		t.Errorf("nosuch = %q, want none", got)
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	fs := flag.NewFlagSet("delete docs", flag.ContinueOnError)
+	yes := addDeleteFlags(fs)
+	args, err := parseInterspersed(fs, []string{"1", "--yes", "2"})
+	if err != nil {
+		t.Fatalf("parseInterspersed failed: %v", err)
+	}
+	if !*yes || !reflect.DeepEqual(args, []string{"1", "2"}) {
+		t.Errorf("yes = %v, args = %q", *yes, args)
+	}
+}
+
+func TestCLI_Help(t *testing.T) {
+	run := func(args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", args...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL=", "PAPERLESS_TOKEN=", "XDG_CONFIG_HOME="+t.TempDir())
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	help, stderr, err := run("help", "view")
+	if err != nil {
+		t.Fatalf("help view failed: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.HasPrefix(help, "usage: pgo view ") || !strings.Contains(help, "-page-size int") {
+		t.Errorf("help view = %s", help)
+	}
+
+	// -h works without a URL and prints the same help
+	stdout, stderr, err := run("view", "-h")
+	if err != nil {
+		t.Fatalf("view -h failed: %v\nStderr: %s", err, stderr)
+	}
+	if stdout != help {
+		t.Errorf("view -h = %s, want %s", stdout, help)
+	}
+
+	if _, stderr, err := run("help", "nosuch"); err == nil || !strings.Contains(stderr, "unknown command: nosuch") {
+		t.Errorf("expected unknown command error, got %v, stderr: %s", err, stderr)
+	}
+
+	man, stderr, err := run("help", "--man")
+	if err != nil {
+		t.Fatalf("help --man failed: %v\nStderr: %s", err, stderr)
+	}
+	for _, want := range []string{".TH PGO 1", `.SS "pgo view `, `.BI \-page\-size " int"`, `.BI \-output\-format " string"`} {
+		if !strings.Contains(man, want) {
+			t.Errorf("man page is missing %q", want)
+		}
+	}
+
+	completion, stderr, err := run("completion", "bash")
+	if err != nil {
+		t.Fatalf("completion bash failed: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(completion, `"get docs"|"get docs "*) words="-all `) || !strings.HasSuffix(completion, "complete -o default -F _pgo pgo\n") {
+		t.Errorf("completion = %s", completion)
+	}
+	if bash, err := exec.LookPath("bash"); err == nil {
+		cmd := exec.Command(bash, "-n")
+		cmd.Stdin = strings.NewReader(completion)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("completion script has syntax errors: %v\n%s", err, out)
+		}
+	}
+}
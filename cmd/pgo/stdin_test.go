@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCLI_StdinIDs(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/tags/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 1, "name": "tax"}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/documents/":
+			if got := r.URL.Query().Get("id__in"); got != "5,6,7" {
+				t.Errorf("id__in = %q, want 5,6,7", got)
+			}
+			w.Write([]byte(`{"count": 2, "results": [{"id": 5, "title": "Receipt", "tags": [1]}, {"id": 6, "title": "Bill"}]}`))
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(stdin string, args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		cmd.Stdin = strings.NewReader(stdin)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	// jq -r '.results[].id' output, with flags after the -
+	stdout, stderr, err := run("5\n6\n7\n", "-output-format", "csv", "get", "docs", "-", "-fields", "id,title")
+	if err != nil {
+		t.Fatalf("get docs - failed: %v\nStderr: %s", err, stderr)
+	}
+	if want := "id,title\n5,Receipt\n6,Bill\n"; stdout != want {
+		t.Errorf("csv output = %q, want %q", stdout, want)
+	}
+	if !strings.Contains(stderr, "Warning: documents not found or not matching the filters: 7") {
+		t.Errorf("expected a warning about document 7, stderr: %s", stderr)
+	}
+
+	if _, stderr, err := run("", "get", "docs", "-"); err == nil || !strings.Contains(stderr, "no document IDs given") {
+		t.Errorf("expected no IDs error, got %v, stderr: %s", err, stderr)
+	}
+
+	if _, stderr, err := run("5\n", "delete", "docs", "-"); err == nil || !strings.Contains(stderr, "reading IDs from stdin needs --yes") {
+		t.Errorf("expected --yes error, got %v, stderr: %s", err, stderr)
+	}
+	if _, stderr, err := run("5\n6\n", "delete", "docs", "-", "--yes"); err != nil {
+		t.Fatalf("delete docs - failed: %v\nStderr: %s", err, stderr)
+	}
+	if want := "/api/documents/5/ /api/documents/6/"; strings.Join(deleted, " ") != want {
+		t.Errorf("deleted %q, want %q", deleted, want)
+	}
+}
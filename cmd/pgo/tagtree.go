@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// TagTreeNode is a tag in the hierarchy given by tag names such as
+// "finance/taxes/2023". Levels that are not tags themselves have ID 0.
+type TagTreeNode struct {
+	Name          string        `json:"name"`  // Full tag name
+	Label         string        `json:"label"` // Last level of the name
+	ID            int           `json:"id,omitempty"`
+	DocumentCount int           `json:"document_count"`
+	Children      []TagTreeNode `json:"children,omitempty"`
+}
+
+// TagTreeOutput is the result of tag tree
+type TagTreeOutput struct {
+	Separator string        `json:"separator"`
+	Tags      []TagTreeNode `json:"tags"`
+}
+
+// ParentTagsApplied lists the documents given an ancestor tag
+type ParentTagsApplied struct {
+	Tag       string `json:"tag"`
+	ID        int    `json:"id"`
+	Documents []int  `json:"documents"`
+}
+
+// ParentTagsOutput is the result of tag tree -apply-parent-tags
+type ParentTagsOutput struct {
+	Applied []ParentTagsApplied `json:"applied"`
+	// Missing are ancestor names that are not tags, so they were skipped
+	Missing []string `json:"missing,omitempty"`
+	Failed  int      `json:"failed"`
+}
+
+// buildTagTree arranges tags by the levels of their names, sorted by label
+func buildTagTree(tags []paperless.Tag, sep string) []TagTreeNode {
+	root := &TagTreeNode{}
+	for _, tag := range tags {
+		levels := splitTagName(tag.Name, sep)
+		if len(levels) == 0 {
+			continue
+		}
+		node := root
+		for i, level := range levels {
+			node = node.child(level, strings.Join(levels[:i+1], sep))
+		}
+		// A level added for an earlier child takes the tag's spelling
+		node.Name, node.Label = strings.Join(levels, sep), levels[len(levels)-1]
+		node.ID = tag.ID
+		node.DocumentCount = tag.DocumentCount
+	}
+	root.sort()
+	return root.Children
+}
+
+// splitTagName splits a tag name into its levels, ignoring empty levels
+// and spaces around the separator
+func splitTagName(name, sep string) []string {
+	var levels []string
+	for _, level := range strings.Split(name, sep) {
+		if level = strings.TrimSpace(level); level != "" {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// child returns the child with label, adding it if needed
+func (n *TagTreeNode) child(label, name string) *TagTreeNode {
+	for i := range n.Children {
+		if strings.EqualFold(n.Children[i].Label, label) {
+			return &n.Children[i]
+		}
+	}
+	n.Children = append(n.Children, TagTreeNode{Name: name, Label: label})
+	return &n.Children[len(n.Children)-1]
+}
+
+func (n *TagTreeNode) sort() {
+	sort.Slice(n.Children, func(i, j int) bool {
+		return strings.ToLower(n.Children[i].Label) < strings.ToLower(n.Children[j].Label)
+	})
+	for i := range n.Children {
+		n.Children[i].sort()
+	}
+}
+
+// writeTagTree writes nodes as an indented list for table output
+func writeTagTree(w io.Writer, nodes []TagTreeNode, depth int) {
+	for _, n := range nodes {
+		count := ""
+		if n.ID != 0 {
+			count = fmt.Sprintf(" (%d)", n.DocumentCount)
+		}
+		fmt.Fprintf(w, "%s%s%s\n", strings.Repeat("  ", depth), n.Label, count)
+		writeTagTree(w, n.Children, depth+1)
+	}
+}
+
+// ancestorTags maps each tag ID to the IDs of the existing tags named like
+// its ancestors. Ancestor names that are not tags are returned as missing.
+func ancestorTags(tags []paperless.Tag, sep string) (map[int][]int, []string) {
+	byName := make(map[string]int, len(tags))
+	for _, tag := range tags {
+		byName[strings.ToLower(strings.Join(splitTagName(tag.Name, sep), sep))] = tag.ID
+	}
+
+	ancestors := map[int][]int{}
+	var missing []string
+	for _, tag := range tags {
+		levels := splitTagName(tag.Name, sep)
+		for i := 1; i < len(levels); i++ {
+			name := strings.Join(levels[:i], sep)
+			if id, ok := byName[strings.ToLower(name)]; ok {
+				ancestors[tag.ID] = append(ancestors[tag.ID], id)
+			} else if !containsString(missing, name) {
+				missing = append(missing, name)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return ancestors, missing
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// tagTreeFlags are the flags of tag tree
+type tagTreeFlags struct {
+	separator    *string
+	applyParents *bool
+}
+
+func addTagTreeFlags(fs *flag.FlagSet) *tagTreeFlags {
+	return &tagTreeFlags{
+		separator:    fs.String("separator", "/", "Separator between the levels of tag names"),
+		applyParents: fs.Bool("apply-parent-tags", false, "Add the ancestor tags to every document with a child tag"),
+	}
+}
+
+func runTag(client *paperless.Client, args []string, pool workerPool) error {
+	if len(args) == 0 || args[0] != "tree" {
+		return commandUsage("tag tree")
+	}
+	treeFlags := flag.NewFlagSet("tag tree", flag.ContinueOnError)
+	tree := addTagTreeFlags(treeFlags)
+	if err := treeFlags.Parse(args[1:]); err != nil {
+		return usagef("parse tag tree flags: %w", err)
+	}
+	sep, applyParents := tree.separator, tree.applyParents
+	if treeFlags.NArg() != 0 || *sep == "" {
+		return commandUsage("tag tree")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	tags, err := listAll(ctx, func(ctx context.Context, opts *paperless.ListOptions) (*paperless.List[paperless.Tag], error) {
+		list, err := client.ListTags(ctx, opts)
+		return (*paperless.List[paperless.Tag])(list), err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch tags: %w", err)
+	}
+
+	if *applyParents {
+		return applyParentTags(ctx, client, tags, *sep, pool)
+	}
+
+	output := TagTreeOutput{Separator: *sep, Tags: buildTagTree(tags, *sep)}
+	if (outputFormat == formatTable || outputFormat == formatPretty) && outputTemplate == nil {
+		writeTagTree(os.Stdout, output.Tags, 0)
+		return nil
+	}
+	if err := writeOutput(output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// applyParentTags adds the ancestor tags to the documents of each child tag
+// that lack them
+func applyParentTags(ctx context.Context, client *paperless.Client, tags []paperless.Tag, sep string, pool workerPool) error {
+	ancestors, missing := ancestorTags(tags, sep)
+	for _, name := range missing {
+		slog.Warn("No such tag; documents below it are not tagged with it", "tag", name)
+	}
+
+	// Documents to add, by ancestor tag
+	pending := map[int][]int{}
+	for _, tag := range tags {
+		if len(ancestors[tag.ID]) == 0 || tag.DocumentCount == 0 {
+			continue
+		}
+		it := client.IterDocuments(&paperless.ListOptions{TagIDs: []int{tag.ID}, PageSize: 100}, paperless.WithIDCursor())
+		for it.Next(ctx) {
+			doc := it.Document()
+			for _, ancestor := range ancestors[tag.ID] {
+				if !containsInt(doc.Tags, ancestor) && !containsInt(pending[ancestor], doc.ID) {
+					pending[ancestor] = append(pending[ancestor], doc.ID)
+				}
+			}
+		}
+		if err := it.Err(); err != nil {
+			return fmt.Errorf("failed to list documents tagged %s: %w", tag.Name, err)
+		}
+	}
+
+	names := make(map[int]string, len(tags))
+	for _, tag := range tags {
+		names[tag.ID] = tag.Name
+	}
+	output := ParentTagsOutput{Applied: []ParentTagsApplied{}, Missing: missing}
+	for _, tag := range tags {
+		ids := pending[tag.ID]
+		if len(ids) == 0 {
+			continue
+		}
+		sort.Ints(ids)
+		applied := ParentTagsApplied{Tag: names[tag.ID], ID: tag.ID, Documents: []int{}}
+		params := map[string]interface{}{"tag": tag.ID}
+		for _, r := range bulkApply(ctx, pool, ids, 100, func(ctx context.Context, docIDs []int) error {
+			return client.BulkEditDocuments(ctx, docIDs, paperless.BulkAddTag, params)
+		}) {
+			if r.OK {
+				applied.Documents = append(applied.Documents, r.ID)
+			} else {
+				output.Failed++
+				slog.Warn(fmt.Sprintf("Could not tag document %d", r.ID), "error", r.Error, "tag", tag.Name)
+			}
+		}
+		output.Applied = append(output.Applied, applied)
+	}
+
+	if err := writeOutput(output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	if output.Failed > 0 {
+		return fmt.Errorf("failed to add parent tags to %d documents", output.Failed)
+	}
+	return nil
+}
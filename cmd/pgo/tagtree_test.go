@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+func TestBuildTagTree(t *testing.T) {
+	tags := []paperless.Tag{
+		{ID: 3, Name: "finance/taxes/2023", DocumentCount: 2},
+		{ID: 1, Name: "Finance", DocumentCount: 12},
+		{ID: 4, Name: "finance / bank", DocumentCount: 5},
+		{ID: 5, Name: "inbox", DocumentCount: 1},
+	}
+	got := buildTagTree(tags, "/")
+	want := []TagTreeNode{
+		{Name: "Finance", Label: "Finance", ID: 1, DocumentCount: 12, Children: []TagTreeNode{
+			{Name: "finance/bank", Label: "bank", ID: 4, DocumentCount: 5},
+			{Name: "finance/taxes", Label: "taxes", Children: []TagTreeNode{
+				{Name: "finance/taxes/2023", Label: "2023", ID: 3, DocumentCount: 2},
+			}},
+		}},
+		{Name: "inbox", Label: "inbox", ID: 5, DocumentCount: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildTagTree = %+v, want %+v", got, want)
+	}
+
+	var b strings.Builder
+	writeTagTree(&b, got, 0)
+	wantText := "Finance (12)\n  bank (5)\n  taxes\n    2023 (2)\ninbox (1)\n"
+	if b.String() != wantText {
+		t.Errorf("writeTagTree = %q, want %q", b.String(), wantText)
+	}
+
+	ancestors, missing := ancestorTags(tags, "/")
+	if !reflect.DeepEqual(ancestors, map[int][]int{3: {1}, 4: {1}}) {
+		t.Errorf("ancestors = %v", ancestors)
+	}
+	if !reflect.DeepEqual(missing, []string{"finance/taxes"}) {
+		t.Errorf("missing = %v, want [finance/taxes]", missing)
+	}
+}
+
+func TestCLI_TagTreeApplyParentTags(t *testing.T) {
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags/":
+			w.Write([]byte(`{"count": 3, "results": [
+				{"id": 1, "name": "finance", "document_count": 1},
+				{"id": 2, "name": "finance/taxes", "document_count": 2},
+				{"id": 3, "name": "finance/taxes/2023", "document_count": 1}]}`))
+		case "/api/documents/":
+			if r.URL.Query().Get("id__gt") != "" {
+				w.Write([]byte(`{"count": 0, "results": []}`))
+				return
+			}
+			switch r.URL.Query().Get("tags__id__all") {
+			case "2":
+				w.Write([]byte(`{"count": 2, "results": [{"id": 10, "tags": [1, 2]}, {"id": 11, "tags": [2]}]}`))
+			case "3":
+				w.Write([]byte(`{"count": 1, "results": [{"id": 12, "tags": [3]}]}`))
+			default:
+				t.Errorf("unexpected document query %s", r.URL.RawQuery)
+			}
+		case "/api/documents/bulk_edit/":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			requests = append(requests, body)
+			w.Write([]byte(`{"result": "OK"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	stdout, stderr, err := run("-output-format", "table", "tag", "tree")
+	if err != nil {
+		t.Fatalf("tag tree failed: %v\nStderr: %s", err, stderr)
+	}
+	if want := "finance (1)\n  taxes (2)\n    2023 (1)\n"; stdout != want {
+		t.Errorf("tag tree output = %q, want %q", stdout, want)
+	}
+
+	stdout, stderr, err = run("tag", "tree", "-apply-parent-tags")
+	if err != nil {
+		t.Fatalf("tag tree -apply-parent-tags failed: %v\nStderr: %s", err, stderr)
+	}
+	var out ParentTagsOutput
+	if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, stdout)
+	}
+	want := []ParentTagsApplied{
+		{Tag: "finance", ID: 1, Documents: []int{11, 12}},
+		{Tag: "finance/taxes", ID: 2, Documents: []int{12}},
+	}
+	if !reflect.DeepEqual(out.Applied, want) || out.Failed != 0 {
+		t.Errorf("output = %+v, want applied %+v", out, want)
+	}
+
+	var got []string
+	for _, req := range requests {
+		params := req["parameters"].(map[string]interface{})
+		got = append(got, fmt.Sprintf("%v %v %v", req["method"], params["tag"], req["documents"]))
+	}
+	wantRequests := []string{"add_tag 1 [11 12]", "add_tag 2 [12]"}
+	if !reflect.DeepEqual(got, wantRequests) {
+		t.Errorf("bulk edit requests = %v, want %v", got, wantRequests)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// viewQueryLister lists the documents of a saved view. The view's filter
+// rules are sent as a raw query, since ListOptions covers only some of them.
+type viewQueryLister struct {
+	client *paperless.Client
+	query  url.Values
+}
+
+// ListDocuments lists a page of the view's documents. Only the page and
+// page size of opts are used.
+func (l viewQueryLister) ListDocuments(ctx context.Context, opts *paperless.ListOptions) (*paperless.DocumentList, error) {
+	query := url.Values{}
+	for param, values := range l.query {
+		query[param] = values
+	}
+	if opts.Page > 0 {
+		query.Set(paperless.ParamPage, strconv.Itoa(opts.Page))
+	}
+	if opts.PageSize > 0 {
+		query.Set(paperless.ParamPageSize, strconv.Itoa(opts.PageSize))
+	}
+	var docs paperless.DocumentList
+	if err := l.client.Do(ctx, "GET", "/api/documents/", query, nil, &docs); err != nil {
+		return nil, err
+	}
+	return &docs, nil
+}
+
+// findSavedView returns the saved view with ID or name ref. Names are
+// matched case-insensitively.
+func findSavedView(ctx context.Context, client *paperless.Client, ref string) (*paperless.SavedView, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		view, err := client.GetSavedView(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get saved view %d: %w", id, err)
+		}
+		return view, nil
+	}
+	views, err := listAll(ctx, func(ctx context.Context, opts *paperless.ListOptions) (*paperless.List[paperless.SavedView], error) {
+		list, err := client.ListSavedViews(ctx, opts)
+		return (*paperless.List[paperless.SavedView])(list), err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch saved views: %w", err)
+	}
+	for i := range views {
+		if strings.EqualFold(views[i].Name, ref) {
+			return &views[i], nil
+		}
+	}
+	return nil, fmt.Errorf("saved view not found: %s", ref)
+}
+
+// viewFlags are the flags of view
+type viewFlags struct {
+	paging *docPaging
+	fields *docFields
+}
+
+func addViewFlags(fs *flag.FlagSet) *viewFlags {
+	return &viewFlags{paging: addDocPagingFlags(fs), fields: addDocFieldFlags(fs)}
+}
+
+// runView lists the documents of a saved view like get docs
+func runView(client *paperless.Client, args []string, forceRefresh bool) error {
+	fs := flag.NewFlagSet("view", flag.ContinueOnError)
+	flags := addViewFlags(fs)
+	paging, fields := flags.paging, flags.fields
+
+	// The view may come before or after the flags
+	var ref string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		ref, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return usagef("parse view flags: %w", err)
+	}
+	if ref == "" {
+		ref = strings.Join(fs.Args(), " ")
+	} else if fs.NArg() != 0 {
+		return commandUsage("view")
+	}
+	if ref == "" {
+		return commandUsage("view")
+	}
+	selected, err := fields.selected()
+	if err != nil {
+		return err
+	}
+
+	// A view's documents can span many pages; each request has its own
+	// timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	view, err := findSavedView(ctx, client, ref)
+	if err != nil {
+		return err
+	}
+	query, err := view.DocumentQuery()
+	if err != nil {
+		return err
+	}
+
+	// Fetch tag names for resolution (with caching)
+	tagNames, err := getTagNamesWithCache(ctx, client, forceRefresh, DefaultCacheTTL)
+	if err != nil {
+		slog.Warn("Could not fetch tags for name resolution", "error", err)
+		tagNames = make(map[int]string)
+	}
+
+	docs, total, err := paging.fetch(ctx, viewQueryLister{client: client, query: query}, &paperless.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list documents of saved view %q: %w", view.Name, err)
+	}
+	results := paperless.Map(docs, func(doc paperless.Document) DocumentWithTagNames {
+		return convertDocToOutput(&doc, tagNames)
+	})
+	output, err := selectDocFields(DocumentListOutput{
+		Count:   len(results),
+		Total:   total,
+		Results: results,
+	}, selected)
+	if err != nil {
+		return fmt.Errorf("failed to select fields: %w", err)
+	}
+	if err := writeListOutput(output, paging.meta(len(results), total)); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCLI_View(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 1, "name": "tax"}]}`))
+		case "/api/saved_views/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 3, "name": "Tax Inbox", "sort_field": "created", "sort_reverse": true,
+				"filter_rules": [{"rule_type": 6, "value": "1"}, {"rule_type": 5, "value": "1"}]}]}`))
+		case "/api/saved_views/3/":
+			w.Write([]byte(`{"id": 3, "name": "Tax Inbox", "filter_rules": [{"rule_type": 6, "value": "1"}]}`))
+		case "/api/documents/":
+			q := r.URL.Query()
+			if q.Get("tags__id__all") != "1" {
+				t.Errorf("documents requested without the view's filters: %s", r.URL.RawQuery)
+			}
+			if q.Get("page") == "2" {
+				w.Write([]byte(`{"count": 2, "next": null, "results": [{"id": 6, "title": "Bill", "tags": [1]}]}`))
+				return
+			}
+			w.Write([]byte(`{"count": 2, "next": "more", "results": [{"id": 5, "title": "Receipt", "tags": [1]}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(args ...string) (string, string, error) {
+		cmd := exec.Command("./pgo", append([]string{"-memory"}, args...)...)
+		cmd.Env = append(os.Environ(), "PAPERLESS_URL="+server.URL, "PAPERLESS_TOKEN=test-token", "XDG_CACHE_HOME="+t.TempDir())
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	stdout, stderr, err := run("-output-format", "csv", "view", "tax inbox", "-all", "-fields", "id,title,tag_names")
+	if err != nil {
+		t.Fatalf("view by name failed: %v\nStderr: %s", err, stderr)
+	}
+	if want := "id,title,tag_names\n5,Receipt,tax\n6,Bill,tax\n"; stdout != want {
+		t.Errorf("csv output = %q, want %q", stdout, want)
+	}
+
+	stdout, stderr, err = run("view", "3")
+	if err != nil {
+		t.Fatalf("view by ID failed: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"title": "Receipt"`) || strings.Contains(stdout, "Bill") {
+		t.Errorf("expected the first page of the view, got %s", stdout)
+	}
+
+	if _, stderr, err := run("view", "Missing"); err == nil || !strings.Contains(stderr, "saved view not found: Missing") {
+		t.Errorf("expected not found error, got %v, stderr: %s", err, stderr)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// CacheWarmOutput is the result of one cache refresh
+type CacheWarmOutput struct {
+	Tags           int      `json:"tags"`
+	Docs           int      `json:"docs"`
+	Correspondents int      `json:"correspondents"`
+	FetchedAt      string   `json:"fetched_at"`
+	Errors         []string `json:"errors,omitempty"`
+}
+
+// warmCaches refetches the tag, doc and correspondent caches. A cache that
+// fails to refresh keeps its previous contents.
+func warmCaches(ctx context.Context, client *paperless.Client) CacheWarmOutput {
+	output := CacheWarmOutput{FetchedAt: time.Now().Format(time.RFC3339)}
+
+	if tags, err := getTagNamesWithCache(ctx, client, true, DefaultCacheTTL); err != nil {
+		output.Errors = append(output.Errors, err.Error())
+	} else {
+		output.Tags = len(tags)
+	}
+	if docs, err := getDocNamesWithCache(ctx, client, true, DefaultCacheTTL); err != nil {
+		output.Errors = append(output.Errors, err.Error())
+	} else {
+		output.Docs = len(docs)
+	}
+	if correspondents, err := getCorrespondentNamesWithCache(ctx, client, true, DefaultCacheTTL); err != nil {
+		output.Errors = append(output.Errors, err.Error())
+	} else {
+		output.Correspondents = len(correspondents)
+	}
+	return output
+}
+
+// cacheWarmFlags are the flags of cache warm
+type cacheWarmFlags struct {
+	interval *time.Duration
+	daemon   *bool
+	once     *bool
+}
+
+func addCacheWarmFlags(fs *flag.FlagSet) *cacheWarmFlags {
+	return &cacheWarmFlags{
+		interval: fs.Duration("interval", 6*time.Hour, "Time between refreshes with -daemon"),
+		daemon:   fs.Bool("daemon", false, "Keep running and refresh the caches every -interval"),
+		once:     fs.Bool("once", false, "Refresh the caches once and exit (the default), e.g. from a systemd timer"),
+	}
+}
+
+func runCacheWarm(client *paperless.Client, args []string) error {
+	warmFlags := flag.NewFlagSet("cache warm", flag.ContinueOnError)
+	warm := addCacheWarmFlags(warmFlags)
+	if err := warmFlags.Parse(args); err != nil {
+		return usagef("parse cache warm flags: %w", err)
+	}
+	interval, daemon, once := warm.interval, warm.daemon, warm.once
+	if warmFlags.NArg() != 0 || *interval <= 0 {
+		return commandUsage("cache warm")
+	}
+	if *once && *daemon {
+		return fmt.Errorf("-once and -daemon cannot be combined")
+	}
+	if caches.Memory() {
+		return fmt.Errorf("cache warm refreshes the disk caches and cannot be used with -memory")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !*daemon {
+		output := warmCaches(ctx, client)
+		if err := writeOutput(output); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		if len(output.Errors) > 0 {
+			return fmt.Errorf("failed to refresh %d of 3 caches", len(output.Errors))
+		}
+		return nil
+	}
+
+	if *interval >= DefaultCacheTTL {
+		slog.Warn(fmt.Sprintf("-interval %s is not shorter than the cache TTL (%s); commands may still find stale caches", *interval, DefaultCacheTTL))
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		// Errors are reported and retried at the next interval
+		output := warmCaches(ctx, client)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err := writeOutput(output); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
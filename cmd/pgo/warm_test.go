@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go/cmd/pgo/internal/cache"
+)
+
+func runPgoCacheWarm(t *testing.T, serverURL, cacheHome string, args ...string) (CacheWarmOutput, string, error) {
+	t.Helper()
+	cmd := exec.Command("./pgo", args...)
+	cmd.Env = append(os.Environ(),
+		"PAPERLESS_URL="+serverURL,
+		"PAPERLESS_TOKEN=test-token",
+		"XDG_CACHE_HOME="+cacheHome,
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+
+	var output CacheWarmOutput
+	if stdout.Len() > 0 {
+		if jsonErr := json.Unmarshal(stdout.Bytes(), &output); jsonErr != nil {
+			t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", jsonErr, stdout.String())
+		}
+	}
+	return output, stderr.String(), err
+}
+
+func TestCLI_CacheWarm(t *testing.T) {
+	var failCorrespondents atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags/":
+			w.Write([]byte(`{"count": 2, "results": [{"id": 1, "name": "invoice"}, {"id": 2, "name": "receipt"}]}`))
+		case "/api/documents/":
+			w.Write([]byte(`{"count": 1, "results": [{"id": 10, "title": "Lease"}]}`))
+		case "/api/correspondents/":
+			if failCorrespondents.Load() {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(`{"count": 1, "results": [{"id": 5, "name": "Amazon"}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	cacheHome := t.TempDir()
+
+	output, stderr, err := runPgoCacheWarm(t, server.URL, cacheHome, "cache", "warm", "-once")
+	if err != nil {
+		t.Fatalf("Command failed: %v\nStderr: %s", err, stderr)
+	}
+	if output.Tags != 2 || output.Docs != 1 || output.Correspondents != 1 || len(output.Errors) != 0 {
+		t.Errorf("output = %+v", output)
+	}
+	for _, name := range []string{"tags.json", "docs.json", "correspondents.json"} {
+		if _, err := os.Stat(filepath.Join(cacheHome, "paperless-go", "instances", cache.Namespace(server.URL), name)); err != nil {
+			t.Errorf("cache file %s not written: %v", name, err)
+		}
+	}
+
+	failCorrespondents.Store(true)
+	output, stderr, err = runPgoCacheWarm(t, server.URL, cacheHome, "cache", "warm")
+	if err == nil || !strings.Contains(stderr, "failed to refresh 1 of 3 caches") {
+		t.Errorf("expected a failed refresh, got %v, stderr: %s", err, stderr)
+	}
+	if output.Tags != 2 || len(output.Errors) != 1 {
+		t.Errorf("output = %+v, want tags refreshed and one error", output)
+	}
+
+	if _, stderr, err = runPgoCacheWarm(t, server.URL, cacheHome, "-memory", "cache", "warm"); err == nil || !strings.Contains(stderr, "-memory") {
+		t.Errorf("expected -memory to be rejected, got %v, stderr: %s", err, stderr)
+	}
+	if _, stderr, err = runPgoCacheWarm(t, server.URL, cacheHome, "cache", "warm", "-once", "-daemon"); err == nil || !strings.Contains(stderr, "cannot be combined") {
+		t.Errorf("expected -once -daemon to be rejected, got %v, stderr: %s", err, stderr)
+	}
+}
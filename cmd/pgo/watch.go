@@ -0,0 +1,432 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// defaultJournalName is the journal file created in the watched directory
+const defaultJournalName = ".pgo-watch.jsonl"
+
+// WatchEntry records the upload of one file. Entries are appended to the
+// journal and written to stdout as JSON lines.
+type WatchEntry struct {
+	File     string `json:"file"`
+	Size     int64  `json:"size"`
+	ModTime  string `json:"mod_time"`
+	TaskID   string `json:"task_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Attempts int    `json:"attempts"`
+	Time     string `json:"time"`
+}
+
+// key identifies a file version: a file is uploaded again if it is
+// replaced or modified
+func (e WatchEntry) key() string {
+	return e.File + "\x00" + strconv.FormatInt(e.Size, 10) + "\x00" + e.ModTime
+}
+
+// uploadFunc uploads the file at path and returns the consumption task ID
+type uploadFunc func(ctx context.Context, path string) (string, error)
+
+// taskFunc returns the status of a consumption task
+type taskFunc func(ctx context.Context, taskID string) (*paperless.Task, error)
+
+// watcher polls a directory and uploads files that appear in it
+type watcher struct {
+	dir         string
+	journalPath string
+	upload      uploadFunc
+	retries     int
+	retryDelay  time.Duration
+	out         io.Writer
+
+	// done holds the keys of journaled files
+	done map[string]bool
+	// pending holds the key of each file seen in the previous scan; a file
+	// is uploaded once its key is unchanged between two scans
+	pending map[string]string
+
+	// notifier, if set, is told about failed uploads and finished
+	// consumption tasks, whose status is polled with task
+	notifier notifier
+	task     taskFunc
+	// tasks maps the IDs of unfinished consumption tasks to file names
+	tasks map[string]string
+
+	// progress, if set, counts uploaded files and bytes
+	progress *progress
+}
+
+func newWatcher(dir, journalPath string, upload uploadFunc, retries int, out io.Writer) (*watcher, error) {
+	w := &watcher{
+		dir:         dir,
+		journalPath: journalPath,
+		upload:      upload,
+		retries:     retries,
+		retryDelay:  time.Second,
+		out:         out,
+		done:        map[string]bool{},
+		pending:     map[string]string{},
+		tasks:       map[string]string{},
+	}
+	if err := w.loadJournal(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// loadJournal reads the keys of previously processed files
+func (w *watcher) loadJournal() error {
+	f, err := os.Open(w.journalPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open journal: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry WatchEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // skip a line truncated by a crash
+		}
+		w.done[entry.key()] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read journal: %w", err)
+	}
+	return nil
+}
+
+// scan uploads new files in the directory. With settle set, a file is only
+// uploaded once its size and modification time are unchanged since the
+// previous scan, so files still being written are left alone.
+func (w *watcher) scan(ctx context.Context, settle bool) error {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return fmt.Errorf("read directory: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if !e.Type().IsRegular() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue // removed since ReadDir
+		}
+		entry := WatchEntry{
+			File:    e.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime().UTC().Format(time.RFC3339Nano),
+		}
+		key := entry.key()
+		seen[entry.File] = true
+		if w.done[key] {
+			continue
+		}
+		if settle && w.pending[entry.File] != key {
+			w.pending[entry.File] = key
+			continue
+		}
+		if err := w.process(ctx, entry); err != nil {
+			return err
+		}
+	}
+
+	for name := range w.pending {
+		if !seen[name] {
+			delete(w.pending, name)
+		}
+	}
+	return nil
+}
+
+// process uploads a file, retrying failures, and journals the result.
+// Upload failures are journaled rather than returned; the file is not
+// tried again unless it changes.
+func (w *watcher) process(ctx context.Context, entry WatchEntry) error {
+	path := filepath.Join(w.dir, entry.File)
+	for {
+		entry.Attempts++
+		taskID, err := w.upload(ctx, path)
+		if err == nil {
+			entry.TaskID = taskID
+			entry.Error = ""
+			break
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		entry.Error = err.Error()
+		if entry.Attempts > w.retries || !retryableUpload(err) {
+			break
+		}
+		delay := w.retryDelay << (entry.Attempts - 1)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+	}
+	entry.Time = time.Now().UTC().Format(time.RFC3339)
+	if entry.Error == "" {
+		w.progress.add(1, entry.Size)
+	}
+
+	if err := w.appendJournal(entry); err != nil {
+		return err
+	}
+	w.done[entry.key()] = true
+	delete(w.pending, entry.File)
+	if entry.Error != "" {
+		w.notify(ctx, "Upload failed", entry.File+": "+entry.Error)
+	} else if w.notifier != nil {
+		w.tasks[entry.TaskID] = entry.File
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w.out, "%s\n", line)
+	return err
+}
+
+func (w *watcher) appendJournal(entry WatchEntry) error {
+	if w.journalPath == "" {
+		return nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.journalPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open journal: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("write journal: %w", err)
+	}
+	return f.Close()
+}
+
+// checkTasks notifies about consumption tasks that finished since the last
+// check. Tasks whose status cannot be fetched are tried again next time.
+func (w *watcher) checkTasks(ctx context.Context) {
+	for taskID, file := range w.tasks {
+		task, err := w.task(ctx, taskID)
+		if err != nil || !task.Finished() {
+			continue
+		}
+		delete(w.tasks, taskID)
+		switch {
+		case task.Status == paperless.TaskFailure:
+			w.notify(ctx, "Consumption failed", file+": "+task.Result)
+		case task.RelatedDocument != nil:
+			w.notify(ctx, "Document consumed", fmt.Sprintf("%s is document %d", file, *task.RelatedDocument))
+		default:
+			w.notify(ctx, "Document consumed", file)
+		}
+	}
+}
+
+// notify sends a notification if enabled. Failures are reported on stderr
+// and do not stop the watcher.
+func (w *watcher) notify(ctx context.Context, title, message string) {
+	if w.notifier == nil {
+		return
+	}
+	if err := w.notifier.Notify(ctx, "pgo watch: "+title, message); err != nil {
+		slog.Warn("Could not send notification", "error", err)
+	}
+}
+
+// retryableUpload reports whether an upload error may succeed on retry.
+// Client errors such as an unsupported file type are final.
+func retryableUpload(err error) bool {
+	var apiErr *paperless.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == 429
+	}
+	return true
+}
+
+// run scans the directory every interval until ctx is done
+func (w *watcher) run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := w.scan(ctx, true); err != nil {
+			return err
+		}
+		w.checkTasks(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// parseIDList parses a comma-separated list of positive IDs
+func parseIDList(s string) ([]int, error) {
+	var ids []int
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		id, err := strconv.Atoi(p)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid ID: %s", p)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// drain waits for the consumption tasks of uploaded files to finish,
+// checking every interval
+func (w *watcher) drain(ctx context.Context, interval time.Duration) {
+	for len(w.tasks) > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		w.checkTasks(ctx)
+	}
+}
+
+// watchFlags are the flags of watch
+type watchFlags struct {
+	tags     *string
+	interval *time.Duration
+	retries  *int
+	journal  *string
+	notify   *string
+	once     *bool
+}
+
+func addWatchFlags(fs *flag.FlagSet) *watchFlags {
+	return &watchFlags{
+		tags:     fs.String("tags", "", "Comma-separated tag IDs applied to every upload (default: the profile's tags)"),
+		interval: fs.Duration("interval", 5*time.Second, "How often to scan the directory"),
+		retries:  fs.Int("retries", 3, "Retries for a failed upload"),
+		journal:  fs.String("journal", "", "Journal of processed files (default: <dir>/"+defaultJournalName+")"),
+		notify:   fs.String("notify", os.Getenv("PGO_NOTIFY"), "Notify on failures and finished consumption: desktop, ntfy://<host>/<topic> or a webhook URL (default: $PGO_NOTIFY or the profile's notify)"),
+		once:     fs.Bool("once", false, "Upload the files present now and exit"),
+	}
+}
+
+func runWatch(client *paperless.Client, args []string, profile Profile) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	flags := addWatchFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return usagef("parse watch flags: %w", err)
+	}
+	tagsFlag, interval, retries, journalFlag, notifyFlag, once := flags.tags, flags.interval, flags.retries, flags.journal, flags.notify, flags.once
+	if fs.NArg() != 1 {
+		return commandUsage("watch")
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if dryRun != nil && !*once {
+		return fmt.Errorf("-dry-run needs -once; the watcher would run forever")
+	}
+
+	dir := fs.Arg(0)
+	if info, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("watch directory: %w", err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", dir)
+	}
+
+	tags, err := parseIDList(*tagsFlag)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		tags = profile.Tags
+	}
+	notify := *notifyFlag
+	if notify == "" {
+		notify = profile.Notify
+	}
+
+	journalPath := *journalFlag
+	if journalPath == "" {
+		journalPath = filepath.Join(dir, defaultJournalName)
+	}
+
+	upload := func(ctx context.Context, path string) (string, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		defer cancel()
+		return client.UploadDocument(ctx, filepath.Base(path), f, &paperless.DocumentUpload{Tags: tags})
+	}
+
+	w, err := newWatcher(dir, journalPath, upload, *retries, os.Stdout)
+	if err != nil {
+		return err
+	}
+	// A dry run skips journaled files but journals nothing itself, or the
+	// files would never be uploaded for real
+	if dryRun != nil {
+		w.journalPath = ""
+		w.out = io.Discard
+		notify = ""
+	}
+
+	if notify != "" {
+		n, err := newNotifier(notify)
+		if err != nil {
+			return err
+		}
+		w.notifier = n
+		w.task = client.GetTask
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *once {
+		// The bar would be mixed up with the JSON lines on a terminal
+		if !isTerminal(os.Stdout) {
+			w.progress = newProgress("Uploading", 0)
+			defer w.progress.finish()
+		}
+		if err := w.scan(ctx, false); err != nil {
+			return err
+		}
+		w.drain(ctx, *interval)
+		return nil
+	}
+	return w.run(ctx, *interval)
+}
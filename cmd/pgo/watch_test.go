@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jason-riddle/paperless-go"
+)
+
+// fakeUploader records uploads and fails while failures is positive
+type fakeUploader struct {
+	uploaded []string
+	failures int
+	err      error
+}
+
+func (u *fakeUploader) upload(ctx context.Context, path string) (string, error) {
+	u.uploaded = append(u.uploaded, filepath.Base(path))
+	if u.failures > 0 {
+		u.failures--
+		return "", u.err
+	}
+	return "task-" + filepath.Base(path), nil
+}
+
+func newTestWatcher(t *testing.T, dir string, u *fakeUploader, out *bytes.Buffer) *watcher {
+	t.Helper()
+	w, err := newWatcher(dir, filepath.Join(dir, defaultJournalName), u.upload, 2, out)
+	if err != nil {
+		t.Fatalf("newWatcher failed: %v", err)
+	}
+	w.retryDelay = 0
+	return w
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestWatcher_UploadsSettledFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "scan.pdf"), "pdf")
+	writeFile(t, filepath.Join(dir, ".hidden"), "skip")
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	u := &fakeUploader{}
+	var out bytes.Buffer
+	w := newTestWatcher(t, dir, u, &out)
+	ctx := context.Background()
+
+	if err := w.scan(ctx, true); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if len(u.uploaded) != 0 {
+		t.Fatalf("uploaded %v on first sight, want to wait for the file to settle", u.uploaded)
+	}
+
+	if err := w.scan(ctx, true); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if len(u.uploaded) != 1 || u.uploaded[0] != "scan.pdf" {
+		t.Fatalf("uploaded = %v, want [scan.pdf]", u.uploaded)
+	}
+	if !strings.Contains(out.String(), `"task_id":"task-scan.pdf"`) {
+		t.Errorf("output = %q, want task ID", out.String())
+	}
+
+	// Processed files are not uploaded again, even by a new watcher
+	w = newTestWatcher(t, dir, u, &out)
+	for i := 0; i < 2; i++ {
+		if err := w.scan(ctx, true); err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+	}
+	if len(u.uploaded) != 1 {
+		t.Errorf("uploaded = %v, want journaled file skipped", u.uploaded)
+	}
+
+	// A modified file is a new version
+	writeFile(t, filepath.Join(dir, "scan.pdf"), "pdf v2")
+	if err := w.scan(ctx, false); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if len(u.uploaded) != 2 {
+		t.Errorf("uploaded = %v, want modified file uploaded again", u.uploaded)
+	}
+}
+
+func TestWatcher_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		wantAttempts int
+		wantError    bool
+	}{
+		{"transient failure", 1, errors.New("connection reset"), 2, false},
+		{"gives up", 5, &paperless.Error{StatusCode: 503}, 3, true},
+		{"client error not retried", 5, &paperless.Error{StatusCode: 400, Message: "unsupported"}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "a.pdf"), "pdf")
+
+			u := &fakeUploader{failures: tt.failures, err: tt.err}
+			var out bytes.Buffer
+			w := newTestWatcher(t, dir, u, &out)
+			if err := w.scan(context.Background(), false); err != nil {
+				t.Fatalf("scan failed: %v", err)
+			}
+			if len(u.uploaded) != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", len(u.uploaded), tt.wantAttempts)
+			}
+			if got := strings.Contains(out.String(), `"error"`); got != tt.wantError {
+				t.Errorf("output = %q, want error %v", out.String(), tt.wantError)
+			}
+
+			journal, err := os.ReadFile(filepath.Join(dir, defaultJournalName))
+			if err != nil {
+				t.Fatalf("read journal: %v", err)
+			}
+			if string(journal) != out.String() {
+				t.Errorf("journal = %q, want %q", journal, out.String())
+			}
+		})
+	}
+}
+
+// recordingNotifier records notifications
+type recordingNotifier struct {
+	sent []string
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, title, message string) error {
+	n.sent = append(n.sent, title+": "+message)
+	return nil
+}
+
+func TestWatcher_Notify(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.pdf"), "pdf")
+	writeFile(t, filepath.Join(dir, "b.exe"), "exe")
+
+	u := &fakeUploader{}
+	var out bytes.Buffer
+	w := newTestWatcher(t, dir, u, &out)
+	n := &recordingNotifier{}
+	w.notifier = n
+
+	docID := 42
+	status := paperless.TaskStarted
+	w.task = func(ctx context.Context, taskID string) (*paperless.Task, error) {
+		if taskID != "task-a.pdf" {
+			t.Errorf("task ID = %q, want task-a.pdf", taskID)
+		}
+		return &paperless.Task{TaskID: taskID, Status: status, RelatedDocument: &docID}, nil
+	}
+
+	// b.exe sorts after a.pdf, so the failure hits the second upload
+	w.upload = func(ctx context.Context, path string) (string, error) {
+		if filepath.Ext(path) == ".exe" {
+			return "", &paperless.Error{StatusCode: 400, Message: "unsupported"}
+		}
+		return u.upload(ctx, path)
+	}
+	if err := w.scan(context.Background(), false); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if len(n.sent) != 1 || !strings.Contains(n.sent[0], "Upload failed: b.exe") {
+		t.Fatalf("notifications = %q, want upload failure for b.exe", n.sent)
+	}
+
+	w.checkTasks(context.Background())
+	if len(n.sent) != 1 {
+		t.Fatalf("notified about unfinished task: %q", n.sent)
+	}
+
+	status = paperless.TaskSuccess
+	w.checkTasks(context.Background())
+	w.checkTasks(context.Background())
+	if len(n.sent) != 2 || n.sent[1] != "pgo watch: Document consumed: a.pdf is document 42" {
+		t.Errorf("notifications = %q, want one consumed notification", n.sent)
+	}
+}
+
+func TestParseIDList(t *testing.T) {
+	ids, err := parseIDList("3, 1,,7")
+	if err != nil || len(ids) != 3 || ids[0] != 3 || ids[2] != 7 {
+		t.Errorf("parseIDList = %v, %v", ids, err)
+	}
+	if _, err := parseIDList("1,x"); err == nil {
+		t.Error("expected error for invalid ID")
+	}
+}
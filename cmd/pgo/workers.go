@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// workerPool runs the items of a batch operation (apply, delete, export)
+// concurrently
+type workerPool struct {
+	concurrency int     // Items processed at once; values below 1 mean 1
+	rate        float64 // Items started per second; 0 means no limit
+}
+
+// newWorkerPool validates the -concurrency and -rate flags
+func newWorkerPool(concurrency int, rate float64) (workerPool, error) {
+	if concurrency < 1 {
+		return workerPool{}, usagef("invalid -concurrency %d, want 1 or more", concurrency)
+	}
+	if rate < 0 {
+		return workerPool{}, usagef("invalid -rate %g, want 0 (no limit) or more", rate)
+	}
+	return workerPool{concurrency: concurrency, rate: rate}, nil
+}
+
+// run calls task for each index in [0, n) and returns the errors by index.
+// A failed task does not stop the others; items not started when ctx is
+// done get its error.
+func (p workerPool) run(ctx context.Context, n int, task func(ctx context.Context, i int) error) []error {
+	errs := make([]error, n)
+	workers := p.concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	var tick <-chan time.Time
+	if p.rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / p.rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = task(ctx, i)
+			}
+		}()
+	}
+
+	i := 0
+feed:
+	for ; i < n; i++ {
+		if tick != nil && i > 0 {
+			select {
+			case <-tick:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	for ; i < n; i++ {
+		errs[i] = ctx.Err()
+	}
+	return errs
+}
+
+// batchError combines the errors of a batch operation into one error that
+// lists every failed item, or returns nil if none failed. what describes
+// the operation, e.g. "delete docs", and name describes item i in messages.
+func batchError(what string, errs []error, name func(i int) string) error {
+	var failed []error
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", name(i), err))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("failed to %s (%d of %d failed):\n%w", what, len(failed), len(errs), errors.Join(failed...))
+}
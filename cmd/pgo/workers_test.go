@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool(t *testing.T) {
+	t.Run("bounds concurrency and keeps errors by index", func(t *testing.T) {
+		var running, peak int32
+		errs := workerPool{concurrency: 3}.run(context.Background(), 10, func(ctx context.Context, i int) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			if i%4 == 0 {
+				return fmt.Errorf("item %d", i)
+			}
+			return nil
+		})
+		if peak > 3 || peak < 2 {
+			t.Errorf("peak concurrency = %d, want 2-3", peak)
+		}
+		for i, err := range errs {
+			if (i%4 == 0) != (err != nil) || (err != nil && err.Error() != fmt.Sprintf("item %d", i)) {
+				t.Errorf("errs[%d] = %v", i, err)
+			}
+		}
+	})
+
+	t.Run("limits the rate", func(t *testing.T) {
+		start := time.Now()
+		workerPool{concurrency: 4, rate: 50}.run(context.Background(), 4, func(ctx context.Context, i int) error {
+			return nil
+		})
+		// 4 starts at 50/s take at least 3 intervals of 20ms
+		if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+			t.Errorf("4 items at 50/s took %v, want at least 60ms", elapsed)
+		}
+	})
+
+	t.Run("stops starting items when cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var mu sync.Mutex
+		var started []int
+		errs := workerPool{concurrency: 1}.run(ctx, 5, func(ctx context.Context, i int) error {
+			mu.Lock()
+			started = append(started, i)
+			mu.Unlock()
+			if i == 1 {
+				cancel()
+			}
+			return nil
+		})
+		if len(started) > 3 {
+			t.Errorf("started %v after cancel", started)
+		}
+		if !errors.Is(errs[4], context.Canceled) {
+			t.Errorf("errs[4] = %v, want context.Canceled", errs[4])
+		}
+	})
+}
+
+func TestBatchError(t *testing.T) {
+	name := func(i int) string { return fmt.Sprintf("doc %d", i+1) }
+	if err := batchError("delete docs", []error{nil, nil}, name); err != nil {
+		t.Errorf("batchError without failures = %v, want nil", err)
+	}
+
+	notFound := errors.New("not found")
+	err := batchError("delete docs", []error{nil, notFound, nil, errors.New("forbidden")}, name)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	want := "failed to delete docs (2 of 4 failed):\ndoc 2: not found\ndoc 4: forbidden"
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+	if !errors.Is(err, notFound) {
+		t.Error("batchError does not wrap the item errors")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	input := `# duplicates
+---
+Amazon:
+  - Amazon.com Inc   # trailing comment
+  - 'AMAZON EU S.a.r.l.'
+"Deutsche Telekom": [Telekom, "T-Mobile, GmbH", 17]
+rules:
+- name: invoices
+  tags: [1, 2]
+- plain
+Empty:
+Nested:
+  key: "value # not a comment"
+  list:
+  - a
+`
+	got, err := parseYAML([]byte(input))
+	if err != nil {
+		t.Fatalf("parseYAML failed: %v", err)
+	}
+
+	want := object{
+		{key: "Amazon", value: []interface{}{"Amazon.com Inc", "AMAZON EU S.a.r.l."}},
+		{key: "Deutsche Telekom", value: []interface{}{"Telekom", "T-Mobile, GmbH", "17"}},
+		{key: "rules", value: []interface{}{
+			object{
+				{key: "name", value: "invoices"},
+				{key: "tags", value: []interface{}{"1", "2"}},
+			},
+			"plain",
+		}},
+		{key: "Empty", value: nil},
+		{key: "Nested", value: object{
+			{key: "key", value: "value # not a comment"},
+			{key: "list", value: []interface{}{"a"}},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseYAML =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestParseYAML_Empty(t *testing.T) {
+	got, err := parseYAML([]byte("# nothing here\n\n"))
+	if err != nil || got != nil {
+		t.Errorf("parseYAML = %#v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestParseYAML_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"tab indent", "a:\n\t- b\n", "line 2: tabs"},
+		{"duplicate key", "a: 1\na: 2\n", `line 2: duplicate key "a"`},
+		{"bad indent", "a: 1\n  b: 2\n", "line 2: unexpected indentation"},
+		{"bad item indent", "- a\n  - b\n", "line 2: unexpected indentation"},
+		{"not a mapping", "a: 1\njust text\n", "line 2: expected key: value"},
+		{"multi-line flow", "a: [1,\n  2]\n", "line 1: flow sequences must be on one line"},
+		{"flow mapping", "a: {b: 1}\n", "line 1: flow mappings are not supported"},
+		{"unterminated", "a: \"b\n", "line 1: unterminated string"},
+		{"mixed sequence", "- a\nb: c\n", `line 2: unexpected "b: c"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseYAML([]byte(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseYAML error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
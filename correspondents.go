@@ -0,0 +1,59 @@
+package paperless
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListCorrespondents retrieves correspondents.
+func (c *Client) ListCorrespondents(ctx context.Context, opts *ListOptions) (*CorrespondentList, error) {
+	ctx = withOperation(ctx, "ListCorrespondents", ResourceCorrespondents)
+	fullURL, err := c.buildURL(correspondentsAPIPath, opts)
+	if err != nil {
+		return nil, fmt.Errorf("build URL: %w", err)
+	}
+
+	var result CorrespondentList
+	if err := c.doRequestWithURL(ctx, "GET", fullURL, nil, &result); err != nil {
+		return nil, wrapError(err, "ListCorrespondents")
+	}
+
+	return &result, nil
+}
+
+// GetCorrespondent retrieves a single correspondent by ID.
+func (c *Client) GetCorrespondent(ctx context.Context, id int) (*Correspondent, error) {
+	ctx = withOperation(ctx, "GetCorrespondent", ResourceCorrespondents)
+	path := fmt.Sprintf("%s%d/", correspondentsAPIPath, id)
+
+	var result Correspondent
+	if err := c.doRequest(ctx, "GET", path, nil, &result); err != nil {
+		return nil, wrapError(err, "GetCorrespondent")
+	}
+
+	return &result, nil
+}
+
+// CreateCorrespondent creates a new correspondent.
+func (c *Client) CreateCorrespondent(ctx context.Context, corr *CorrespondentCreate) (*Correspondent, error) {
+	ctx = withOperation(ctx, "CreateCorrespondent", ResourceCorrespondents)
+	var result Correspondent
+	if err := c.doRequest(ctx, "POST", correspondentsAPIPath, corr, &result); err != nil {
+		return nil, wrapError(err, "CreateCorrespondent")
+	}
+
+	return &result, nil
+}
+
+// DeleteCorrespondent deletes a correspondent. Documents assigned to it are
+// left without a correspondent.
+func (c *Client) DeleteCorrespondent(ctx context.Context, id int) error {
+	ctx = withOperation(ctx, "DeleteCorrespondent", ResourceCorrespondents)
+	path := fmt.Sprintf("%s%d/", correspondentsAPIPath, id)
+
+	if err := c.doRequest(ctx, "DELETE", path, nil, nil); err != nil {
+		return wrapError(err, "DeleteCorrespondent")
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package paperless
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_ListCorrespondents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/correspondents/" {
+			t.Errorf("path = %v, want /api/correspondents/", r.URL.Path)
+		}
+		if r.URL.Query().Get("page_size") != "50" {
+			t.Errorf("page_size = %v, want 50", r.URL.Query().Get("page_size"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(CorrespondentList{
+			Count:   1,
+			Results: []Correspondent{{ID: 1, Name: "ACME Corp", Slug: "acme-corp", DocumentCount: 3}},
+		})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	list, err := c.ListCorrespondents(context.Background(), &ListOptions{PageSize: 50})
+	if err != nil {
+		t.Fatalf("ListCorrespondents failed: %v", err)
+	}
+	if list.Count != 1 || len(list.Results) != 1 || list.Results[0].Name != "ACME Corp" {
+		t.Errorf("list = %+v, want ACME Corp", list)
+	}
+}
+
+func TestClient_CreateCorrespondent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/correspondents/" {
+			t.Errorf("request = %s %s, want POST /api/correspondents/", r.Method, r.URL.Path)
+		}
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		// MatchNone is 0 but must still be sent
+		if body["name"] != "ACME Corp" || body["match"] != "acme" || body["matching_algorithm"] != float64(0) || body["is_insensitive"] != false {
+			t.Errorf("body = %v", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(Correspondent{ID: 4, Name: "ACME Corp", Slug: "acme-corp"})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	corr, err := c.CreateCorrespondent(context.Background(), &CorrespondentCreate{
+		Name:              "ACME Corp",
+		Match:             "acme",
+		MatchingAlgorithm: Ptr(MatchNone),
+		IsInsensitive:     Ptr(false),
+	})
+	if err != nil {
+		t.Fatalf("CreateCorrespondent failed: %v", err)
+	}
+	if corr.ID != 4 || corr.Slug != "acme-corp" {
+		t.Errorf("correspondent = %+v, want ID 4", corr)
+	}
+}
+
+func TestClient_GetCorrespondent(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/correspondents/1/" {
+				t.Errorf("path = %v, want /api/correspondents/1/", r.URL.Path)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(Correspondent{ID: 1, Name: "ACME Corp"})
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		corr, err := c.GetCorrespondent(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("GetCorrespondent failed: %v", err)
+		}
+		if corr.Name != "ACME Corp" {
+			t.Errorf("name = %v, want ACME Corp", corr.Name)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		_, err := c.GetCorrespondent(context.Background(), 999)
+		if !IsNotFound(err) {
+			t.Fatalf("expected 404 error, got %v", err)
+		}
+		if apiErr, ok := err.(*Error); !ok || apiErr.Op != "GetCorrespondent" {
+			t.Errorf("err = %v, want *Error with op GetCorrespondent", err)
+		}
+	})
+}
+
+func TestClient_DeleteCorrespondent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" {
+			t.Errorf("method = %v, want DELETE", r.Method)
+		}
+		if r.URL.Path != "/api/correspondents/4/" {
+			t.Errorf("path = %v, want /api/correspondents/4/", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	if err := c.DeleteCorrespondent(context.Background(), 4); err != nil {
+		t.Fatalf("DeleteCorrespondent failed: %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+package paperless
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListCustomFields retrieves custom field definitions.
+func (c *Client) ListCustomFields(ctx context.Context, opts *ListOptions) (*CustomFieldList, error) {
+	ctx = withOperation(ctx, "ListCustomFields", ResourceCustomFields)
+	fullURL, err := c.buildURL(customFieldsAPIPath, opts)
+	if err != nil {
+		return nil, fmt.Errorf("build URL: %w", err)
+	}
+
+	var result CustomFieldList
+	if err := c.doRequestWithURL(ctx, "GET", fullURL, nil, &result); err != nil {
+		return nil, wrapError(err, "ListCustomFields")
+	}
+
+	return &result, nil
+}
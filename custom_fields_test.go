@@ -0,0 +1,29 @@
+package paperless
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_ListCustomFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/custom_fields/" {
+			t.Errorf("path = %v, want /api/custom_fields/", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"count": 1, "results": [{"id": 2, "name": "Amount", "data_type": "monetary", "extra_data": {}}]}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	list, err := c.ListCustomFields(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListCustomFields failed: %v", err)
+	}
+	want := CustomField{ID: 2, Name: "Amount", DataType: "monetary"}
+	if len(list.Results) != 1 || list.Results[0] != want {
+		t.Errorf("results = %+v, want %+v", list.Results, want)
+	}
+}
@@ -0,0 +1,138 @@
+package paperless
+
+import (
+	"reflect"
+	"sort"
+)
+
+// FieldChange is a field that differs between two versions of a document.
+// Old and New hold the field's values as in Document, e.g. a string for
+// "title" or []int for "tags".
+type FieldChange struct {
+	Field string      `json:"field"` // JSON name of the field, e.g. "title"
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+	// Added and Removed are the IDs added to and removed from "tags" and
+	// "notes"; for notes they are note IDs.
+	Added   []int `json:"added,omitempty"`
+	Removed []int `json:"removed,omitempty"`
+}
+
+// DiffDocuments returns the fields that differ from a to b, in the order of
+// the Document fields. Tags, notes and custom fields are compared regardless
+// of order, and "created" is compared by CreatedDay, so responses of servers
+// with different API versions don't differ. Search hits are not compared.
+// The result is empty if the documents are the same.
+func DiffDocuments(a, b Document) []FieldChange {
+	var changes []FieldChange
+	add := func(field string, old, new interface{}) {
+		changes = append(changes, FieldChange{Field: field, Old: old, New: new})
+	}
+
+	if a.ID != b.ID {
+		add("id", a.ID, b.ID)
+	}
+	if a.Title != b.Title {
+		add("title", a.Title, b.Title)
+	}
+	if a.Content != b.Content {
+		add("content", a.Content, b.Content)
+	}
+	if created, other := a.CreatedDay(), b.CreatedDay(); !created.Time().Equal(other.Time()) {
+		add("created", created, other)
+	}
+	if !a.Modified.Time().Equal(b.Modified.Time()) {
+		add("modified", a.Modified, b.Modified)
+	}
+	if !a.Added.Time().Equal(b.Added.Time()) {
+		add("added", a.Added, b.Added)
+	}
+	if !equalPtr(a.ArchiveSerialNumber, b.ArchiveSerialNumber) {
+		add("archive_serial_number", a.ArchiveSerialNumber, b.ArchiveSerialNumber)
+	}
+	if a.OriginalFileName != b.OriginalFileName {
+		add("original_file_name", a.OriginalFileName, b.OriginalFileName)
+	}
+	if added, removed := diffIDs(a.Tags, b.Tags); len(added)+len(removed) > 0 {
+		changes = append(changes, FieldChange{Field: "tags", Old: a.Tags, New: b.Tags, Added: added, Removed: removed})
+	}
+	if !equalPtr(a.Correspondent, b.Correspondent) {
+		add("correspondent", a.Correspondent, b.Correspondent)
+	}
+	if !equalPtr(a.DocumentType, b.DocumentType) {
+		add("document_type", a.DocumentType, b.DocumentType)
+	}
+	if !equalPtr(a.StoragePath, b.StoragePath) {
+		add("storage_path", a.StoragePath, b.StoragePath)
+	}
+	if !equalPtr(a.Owner, b.Owner) {
+		add("owner", a.Owner, b.Owner)
+	}
+	noteIDs := func(notes []Note) []int {
+		return Map(notes, func(n Note) int { return n.ID })
+	}
+	added, removed := diffIDs(noteIDs(a.Notes), noteIDs(b.Notes))
+	if len(added)+len(removed) > 0 || !reflect.DeepEqual(notesByID(a.Notes), notesByID(b.Notes)) {
+		changes = append(changes, FieldChange{Field: "notes", Old: a.Notes, New: b.Notes, Added: added, Removed: removed})
+	}
+	if !equalPtr(a.PageCount, b.PageCount) {
+		add("page_count", a.PageCount, b.PageCount)
+	}
+	if a.ArchivedFileName != b.ArchivedFileName {
+		add("archived_file_name", a.ArchivedFileName, b.ArchivedFileName)
+	}
+	if !reflect.DeepEqual(sortedCustomFields(a.CustomFields), sortedCustomFields(b.CustomFields)) {
+		add("custom_fields", a.CustomFields, b.CustomFields)
+	}
+	return changes
+}
+
+// equalPtr reports whether a and b are both nil or point to equal values.
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// diffIDs returns the IDs in b but not in a, and those in a but not in b.
+func diffIDs(a, b []int) (added, removed []int) {
+	for _, id := range b {
+		if !containsID(a, id) {
+			added = append(added, id)
+		}
+	}
+	for _, id := range a {
+		if !containsID(b, id) {
+			removed = append(removed, id)
+		}
+	}
+	return added, removed
+}
+
+func containsID(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// notesByID indexes notes for an order-insensitive comparison. Created is
+// compared as an instant.
+func notesByID(notes []Note) map[int]Note {
+	byID := make(map[int]Note, len(notes))
+	for _, n := range notes {
+		n.Created = Date(n.Created.Time().UTC())
+		byID[n.ID] = n
+	}
+	return byID
+}
+
+// sortedCustomFields returns a copy of fields sorted by field ID.
+func sortedCustomFields(fields []CustomFieldInstance) []CustomFieldInstance {
+	sorted := append([]CustomFieldInstance{}, fields...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Field < sorted[j].Field })
+	return sorted
+}
@@ -0,0 +1,64 @@
+package paperless
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffDocuments(t *testing.T) {
+	created := Date(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	a := Document{
+		ID:            1,
+		Title:         "Invoice",
+		Created:       created,
+		Tags:          []int{1, 2, 3},
+		Correspondent: Ptr(4),
+		Notes:         []Note{{ID: 1, Note: "paid"}, {ID: 2, Note: "filed"}},
+		CustomFields:  []CustomFieldInstance{{Field: 1, Value: "a"}, {Field: 2, Value: 3.0}},
+	}
+
+	t.Run("equal", func(t *testing.T) {
+		b := a
+		b.Tags = []int{3, 1, 2}
+		b.Correspondent = Ptr(4)
+		b.Notes = []Note{a.Notes[1], a.Notes[0]}
+		b.CustomFields = []CustomFieldInstance{a.CustomFields[1], a.CustomFields[0]}
+		// Created as a timestamp by API version 9 and newer
+		b.Created = Date(time.Date(2024, 3, 1, 0, 0, 0, 0, time.FixedZone("CET", 3600)))
+		if changes := DiffDocuments(a, b); len(changes) != 0 {
+			t.Errorf("changes = %+v, want none", changes)
+		}
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		b := a
+		b.Title = "Invoice 2024"
+		b.Tags = []int{2, 3, 5}
+		b.Correspondent = nil
+		b.Notes = []Note{{ID: 1, Note: "paid late"}, a.Notes[1], {ID: 3, Note: "new"}}
+		b.CustomFields = []CustomFieldInstance{{Field: 1, Value: "b"}}
+
+		changes := DiffDocuments(a, b)
+		var fields []string
+		for _, c := range changes {
+			fields = append(fields, c.Field)
+		}
+		want := []string{"title", "tags", "correspondent", "notes", "custom_fields"}
+		if !reflect.DeepEqual(fields, want) {
+			t.Fatalf("fields = %v, want %v", fields, want)
+		}
+		if c := changes[0]; c.Old != "Invoice" || c.New != "Invoice 2024" {
+			t.Errorf("title change = %+v", c)
+		}
+		if c := changes[1]; !reflect.DeepEqual(c.Added, []int{5}) || !reflect.DeepEqual(c.Removed, []int{1}) {
+			t.Errorf("tags change = %+v, want added [5], removed [1]", c)
+		}
+		if c := changes[2]; c.New.(*int) != nil {
+			t.Errorf("correspondent change = %+v, want new nil", c)
+		}
+		if c := changes[3]; !reflect.DeepEqual(c.Added, []int{3}) || c.Removed != nil {
+			t.Errorf("notes change = %+v, want added [3]", c)
+		}
+	})
+}
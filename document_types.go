@@ -0,0 +1,46 @@
+package paperless
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListDocumentTypes retrieves document types.
+func (c *Client) ListDocumentTypes(ctx context.Context, opts *ListOptions) (*DocumentTypeList, error) {
+	ctx = withOperation(ctx, "ListDocumentTypes", ResourceDocumentTypes)
+	fullURL, err := c.buildURL(documentTypesAPIPath, opts)
+	if err != nil {
+		return nil, fmt.Errorf("build URL: %w", err)
+	}
+
+	var result DocumentTypeList
+	if err := c.doRequestWithURL(ctx, "GET", fullURL, nil, &result); err != nil {
+		return nil, wrapError(err, "ListDocumentTypes")
+	}
+
+	return &result, nil
+}
+
+// GetDocumentType retrieves a single document type by ID.
+func (c *Client) GetDocumentType(ctx context.Context, id int) (*DocumentType, error) {
+	ctx = withOperation(ctx, "GetDocumentType", ResourceDocumentTypes)
+	path := fmt.Sprintf("%s%d/", documentTypesAPIPath, id)
+
+	var result DocumentType
+	if err := c.doRequest(ctx, "GET", path, nil, &result); err != nil {
+		return nil, wrapError(err, "GetDocumentType")
+	}
+
+	return &result, nil
+}
+
+// CreateDocumentType creates a new document type.
+func (c *Client) CreateDocumentType(ctx context.Context, docType *DocumentTypeCreate) (*DocumentType, error) {
+	ctx = withOperation(ctx, "CreateDocumentType", ResourceDocumentTypes)
+	var result DocumentType
+	if err := c.doRequest(ctx, "POST", documentTypesAPIPath, docType, &result); err != nil {
+		return nil, wrapError(err, "CreateDocumentType")
+	}
+
+	return &result, nil
+}
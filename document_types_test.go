@@ -0,0 +1,78 @@
+package paperless
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_ListDocumentTypes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/document_types/" {
+			t.Errorf("path = %v, want /api/document_types/", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(DocumentTypeList{
+			Count:   2,
+			Results: []DocumentType{{ID: 1, Name: "Invoice"}, {ID: 2, Name: "Receipt"}},
+		})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	list, err := c.ListDocumentTypes(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListDocumentTypes failed: %v", err)
+	}
+	if list.Count != 2 || len(list.Results) != 2 || list.Results[1].Name != "Receipt" {
+		t.Errorf("list = %+v, want Invoice and Receipt", list)
+	}
+}
+
+func TestClient_GetDocumentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/document_types/2/" {
+			t.Errorf("path = %v, want /api/document_types/2/", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(DocumentType{ID: 2, Name: "Receipt", DocumentCount: 7})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	docType, err := c.GetDocumentType(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("GetDocumentType failed: %v", err)
+	}
+	if docType.Name != "Receipt" || docType.DocumentCount != 7 {
+		t.Errorf("document type = %+v, want Receipt with 7 documents", docType)
+	}
+}
+
+func TestClient_CreateDocumentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/document_types/" {
+			t.Errorf("request = %s %s, want POST /api/document_types/", r.Method, r.URL.Path)
+		}
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if len(body) != 1 || body["name"] != "Receipt" {
+			t.Errorf("body = %v, want only the name", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(DocumentType{ID: 3, Name: "Receipt"})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	docType, err := c.CreateDocumentType(context.Background(), &DocumentTypeCreate{Name: "Receipt"})
+	if err != nil {
+		t.Fatalf("CreateDocumentType failed: %v", err)
+	}
+	if docType.ID != 3 {
+		t.Errorf("ID = %d, want 3", docType.ID)
+	}
+}
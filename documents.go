@@ -3,10 +3,13 @@ package paperless
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"time"
 )
 
 // ListDocuments retrieves documents with optional filtering.
 func (c *Client) ListDocuments(ctx context.Context, opts *ListOptions) (*DocumentList, error) {
+	ctx = withOperation(ctx, "ListDocuments", ResourceDocuments)
 	fullURL, err := c.buildURL(documentsAPIPath, opts)
 	if err != nil {
 		return nil, fmt.Errorf("build URL: %w", err)
@@ -20,9 +23,65 @@ func (c *Client) ListDocuments(ctx context.Context, opts *ListOptions) (*Documen
 	return &result, nil
 }
 
+// idPageSize is the page size of ListDocumentIDs when opts sets none. Pages
+// of IDs are small, so few requests are needed even for large libraries.
+const idPageSize = 1000
+
+// ListDocumentIDs returns the IDs of all documents matching opts, in
+// ascending order, without fetching their content: only the id field is
+// requested, a page of opts.PageSize (default 1000) at a time. Pages follow
+// the ID cursor of WithIDCursor, so documents added or deleted meanwhile
+// don't cause skips or duplicates. opts.Page and opts.Ordering are ignored.
+func (c *Client) ListDocumentIDs(ctx context.Context, opts *ListOptions) ([]int, error) {
+	ctx = withOperation(ctx, "ListDocumentIDs", ResourceDocuments)
+	var o ListOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.PageSize <= 0 {
+		o.PageSize = idPageSize
+	}
+
+	var ids []int
+	it := NewDocumentIterator(idLister{c}, &o, WithIDCursor())
+	for it.Next(ctx) {
+		ids = append(ids, it.Document().ID)
+	}
+	if err := it.Err(); err != nil {
+		return nil, wrapError(err, "ListDocumentIDs")
+	}
+	return ids, nil
+}
+
+// idLister lists documents with only their id field.
+type idLister struct {
+	c *Client
+}
+
+func (l idLister) ListDocuments(ctx context.Context, opts *ListOptions) (*DocumentList, error) {
+	fullURL, err := l.c.buildURL(documentsAPIPath, opts)
+	if err != nil {
+		return nil, fmt.Errorf("build URL: %w", err)
+	}
+	u, err := url.Parse(fullURL)
+	if err != nil {
+		return nil, fmt.Errorf("build URL: %w", err)
+	}
+	q := u.Query()
+	q.Set(ParamFields, "id")
+	u.RawQuery = q.Encode()
+
+	var result DocumentList
+	if err := l.c.doRequestWithURL(ctx, "GET", u.String(), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 // GetDocument retrieves a single document by ID.
 func (c *Client) GetDocument(ctx context.Context, id int) (*Document, error) {
-	path := fmt.Sprintf("/api/documents/%d/", id)
+	ctx = withOperation(ctx, "GetDocument", ResourceDocuments)
+	path := documentPath(id)
 
 	var result Document
 	if err := c.doRequest(ctx, "GET", path, nil, &result); err != nil {
@@ -32,12 +91,59 @@ func (c *Client) GetDocument(ctx context.Context, id int) (*Document, error) {
 	return &result, nil
 }
 
+// GetDocumentMetadata retrieves the checksums, sizes and file names of a
+// document's stored files.
+func (c *Client) GetDocumentMetadata(ctx context.Context, id int) (*DocumentMetadata, error) {
+	ctx = withOperation(ctx, "GetDocumentMetadata", ResourceDocuments)
+	path := documentPath(id) + "metadata/"
+
+	var result DocumentMetadata
+	if err := c.doRequest(ctx, "GET", path, nil, &result); err != nil {
+		return nil, wrapError(err, "GetDocumentMetadata")
+	}
+
+	return &result, nil
+}
+
+// NextArchiveSerialNumber returns the archive serial number the server
+// suggests for the next document: one more than the highest in use. It is
+// not reserved, so two callers can get the same number until one of them
+// assigns it with UpdateDocument.
+func (c *Client) NextArchiveSerialNumber(ctx context.Context) (int64, error) {
+	ctx = withOperation(ctx, "NextArchiveSerialNumber", ResourceDocuments)
+
+	var next int64
+	if err := c.doRequest(ctx, "GET", nextASNAPIPath, nil, &next); err != nil {
+		return 0, wrapError(err, "NextArchiveSerialNumber")
+	}
+
+	return next, nil
+}
+
 // UpdateDocument updates a document.
+//
+// A Created date is sent in the form the server expects. Servers with API
+// version 9 or later store created as a date, so the time of day is dropped.
+// Older servers store a timestamp: a date-only Created (midnight UTC) is sent
+// as created_date, which keeps the document's time of day, and any other
+// value is sent as a full timestamp. Setting Created therefore makes one
+// extra request, the first time, to learn the server's API version.
 func (c *Client) UpdateDocument(ctx context.Context, id int, update *DocumentUpdate) (*Document, error) {
-	path := fmt.Sprintf("/api/documents/%d/", id)
+	ctx = withOperation(ctx, "UpdateDocument", ResourceDocuments)
+	path := documentPath(id)
+
+	var body interface{} = update
+	if update != nil && update.Created != nil {
+		apiVersion := 0
+		// A failed probe falls back to the pre-version 9 behavior
+		if caps, err := c.Capabilities(ctx); err == nil {
+			apiVersion = caps.APIVersion
+		}
+		body = newDocumentUpdateBody(update, apiVersion)
+	}
 
 	var result Document
-	if err := c.doRequest(ctx, "PATCH", path, update, &result); err != nil {
+	if err := c.doRequest(ctx, "PATCH", path, body, &result); err != nil {
 		return nil, wrapError(err, "UpdateDocument")
 	}
 
@@ -48,6 +154,7 @@ func (c *Client) UpdateDocument(ctx context.Context, id int, update *DocumentUpd
 // This is a convenience wrapper around UpdateDocument that only updates the title field.
 // Returns an error if the new title is empty or if the document ID is invalid.
 func (c *Client) RenameDocument(ctx context.Context, id int, newTitle string) (*Document, error) {
+	ctx = withOperation(ctx, "RenameDocument", ResourceDocuments)
 	if id <= 0 {
 		return nil, fmt.Errorf("RenameDocument: invalid document ID: %d", id)
 	}
@@ -72,6 +179,7 @@ func (c *Client) RenameDocument(ctx context.Context, id int, newTitle string) (*
 // Pass an empty slice to remove all tags from the document.
 // Returns an error if the document ID is invalid or if any tag IDs are invalid.
 func (c *Client) UpdateDocumentTags(ctx context.Context, id int, tagIDs []int) (*Document, error) {
+	ctx = withOperation(ctx, "UpdateDocumentTags", ResourceDocuments)
 	if id <= 0 {
 		return nil, fmt.Errorf("UpdateDocumentTags: invalid document ID: %d", id)
 	}
@@ -99,3 +207,39 @@ func (c *Client) UpdateDocumentTags(ctx context.Context, id int, tagIDs []int) (
 
 	return doc, nil
 }
+
+// DeleteDocument deletes a document. Paperless versions with a trash move
+// the document there; older versions delete it permanently.
+func (c *Client) DeleteDocument(ctx context.Context, id int) error {
+	ctx = withOperation(ctx, "DeleteDocument", ResourceDocuments)
+	if err := c.doRequest(ctx, "DELETE", documentPath(id), nil, nil); err != nil {
+		return wrapError(err, "DeleteDocument")
+	}
+	return nil
+}
+
+// createdDateAPIVersion is the API version from which created is a date.
+const createdDateAPIVersion = 9
+
+// documentUpdateBody is the PATCH body of UpdateDocument when Created is set.
+// Its Created field shadows the one of the embedded update.
+type documentUpdateBody struct {
+	*DocumentUpdate
+	Created     *Date `json:"created,omitempty"`
+	CreatedDate *Date `json:"created_date,omitempty"`
+}
+
+func newDocumentUpdateBody(update *DocumentUpdate, apiVersion int) documentUpdateBody {
+	body := documentUpdateBody{DocumentUpdate: update}
+	created := *update.Created
+	switch {
+	case apiVersion >= createdDateAPIVersion:
+		y, m, d := created.Time().Date()
+		body.Created = Ptr(Date(time.Date(y, m, d, 0, 0, 0, 0, time.UTC)))
+	case created.IsDateOnly():
+		body.CreatedDate = &created
+	default:
+		body.Created = &created
+	}
+	return body
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -123,6 +125,51 @@ func TestClient_ListDocuments(t *testing.T) {
 	})
 }
 
+func TestClient_ListDocumentIDs(t *testing.T) {
+	ids := []int{3, 5, 8, 13, 21}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		query := r.URL.Query()
+		if got := query.Get("fields"); got != "id" {
+			t.Errorf("fields = %q, want id", got)
+		}
+		if got := query.Get("ordering"); got != "id" {
+			t.Errorf("ordering = %q, want id", got)
+		}
+		if got := query.Get("tags__id__all"); got != "4" {
+			t.Errorf("tags__id__all = %q, want 4", got)
+		}
+		after, _ := strconv.Atoi(query.Get("id__gt"))
+		size, _ := strconv.Atoi(query.Get("page_size"))
+		var page []Document
+		for _, id := range ids {
+			if id > after && len(page) < size {
+				page = append(page, Document{ID: id})
+			}
+		}
+		list := DocumentList{Count: len(ids), Results: page}
+		if len(page) > 0 && page[len(page)-1].ID != ids[len(ids)-1] {
+			list.Next = Ptr("next")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(list)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	got, err := c.ListDocumentIDs(context.Background(), &ListOptions{TagIDs: []int{4}, PageSize: 2, Ordering: "-created"})
+	if err != nil {
+		t.Fatalf("ListDocumentIDs failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, ids) {
+		t.Errorf("ids = %v, want %v", got, ids)
+	}
+	if requests != 3 {
+		t.Errorf("requests = %d, want 3", requests)
+	}
+}
+
 func TestClient_GetDocument(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		expectedDoc := Document{
@@ -202,6 +249,50 @@ func TestClient_GetDocument(t *testing.T) {
 	})
 }
 
+func TestClient_GetDocumentMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/documents/3/metadata/" {
+			t.Errorf("path = %v, want /api/documents/3/metadata/", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"original_checksum": "abc", "original_size": 1024, "original_mime_type": "image/png",
+			"original_filename": "scan.png", "media_filename": "0000003.png", "has_archive_version": false,
+			"archive_checksum": null, "archive_size": null, "archive_media_filename": null, "original_metadata": [], "lang": "de"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	meta, err := c.GetDocumentMetadata(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("GetDocumentMetadata failed: %v", err)
+	}
+	want := DocumentMetadata{OriginalChecksum: "abc", OriginalSize: 1024, OriginalMimeType: "image/png",
+		OriginalFilename: "scan.png", MediaFilename: "0000003.png", Lang: "de"}
+	if *meta != want {
+		t.Errorf("metadata = %+v, want %+v", *meta, want)
+	}
+}
+
+func TestClient_NextArchiveSerialNumber(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/documents/next_asn/" {
+			t.Errorf("path = %v, want /api/documents/next_asn/", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("42"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	next, err := c.NextArchiveSerialNumber(context.Background())
+	if err != nil {
+		t.Fatalf("NextArchiveSerialNumber failed: %v", err)
+	}
+	if next != 42 {
+		t.Errorf("next = %d, want 42", next)
+	}
+}
+
 func TestClient_UpdateDocument(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		tags := []int{1, 2}
@@ -252,6 +343,126 @@ func TestClient_UpdateDocument(t *testing.T) {
 	})
 }
 
+func TestClient_UpdateDocument_OmitsUnsetFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func(c *Client) error
+		wantKeys []string
+	}{
+		{
+			name: "UpdateDocumentTags sends only tags",
+			call: func(c *Client) error {
+				_, err := c.UpdateDocumentTags(context.Background(), 1, []int{3})
+				return err
+			},
+			wantKeys: []string{"tags"},
+		},
+		{
+			name: "RenameDocument sends only title",
+			call: func(c *Client) error {
+				_, err := c.RenameDocument(context.Background(), 1, "Renamed")
+				return err
+			},
+			wantKeys: []string{"title"},
+		},
+		{
+			name: "UpdateDocument sends only set fields",
+			call: func(c *Client) error {
+				_, err := c.UpdateDocument(context.Background(), 1, &DocumentUpdate{ArchiveSerialNumber: Ptr[int64](42)})
+				return err
+			},
+			wantKeys: []string{"archive_serial_number"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body map[string]json.RawMessage
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode request body: %v", err)
+				}
+				if len(body) != len(tt.wantKeys) {
+					t.Errorf("body has keys %v, want %v", body, tt.wantKeys)
+				}
+				for _, key := range tt.wantKeys {
+					if _, ok := body[key]; !ok {
+						t.Errorf("body missing %q", key)
+					}
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(Document{ID: 1})
+			}))
+			defer server.Close()
+
+			if err := tt.call(NewClient(server.URL, "test-token")); err != nil {
+				t.Fatalf("update failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_UpdateDocument_Created(t *testing.T) {
+	dateOnly := Date(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	withTime := Date(time.Date(2024, 1, 15, 23, 30, 0, 0, time.FixedZone("CET", 3600)))
+
+	tests := []struct {
+		name       string
+		apiVersion string // "" fails the capabilities probe
+		created    Date
+		want       map[string]string
+	}{
+		{"date on API 9", "9", dateOnly, map[string]string{"created": `"2024-01-15"`}},
+		{"time dropped on API 9", "9", withTime, map[string]string{"created": `"2024-01-15"`}},
+		{"date keeps time of day before API 9", "7", dateOnly, map[string]string{"created_date": `"2024-01-15"`}},
+		{"timestamp before API 9", "7", withTime, map[string]string{"created": `"2024-01-15T23:30:00+01:00"`}},
+		{"date with unknown version", "", dateOnly, map[string]string{"created_date": `"2024-01-15"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Path == "/api/" {
+					if tt.apiVersion == "" {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					w.Header().Set("X-Api-Version", tt.apiVersion)
+					w.Write([]byte(`{}`))
+					return
+				}
+				var body map[string]json.RawMessage
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode request body: %v", err)
+				}
+				want := map[string]string{"title": `"Lease"`}
+				for k, v := range tt.want {
+					want[k] = v
+				}
+				if len(body) != len(want) {
+					t.Errorf("body = %v, want %v", body, want)
+				}
+				for key, v := range want {
+					if string(body[key]) != v {
+						t.Errorf("%s = %s, want %s", key, body[key], v)
+					}
+				}
+				_ = json.NewEncoder(w).Encode(Document{ID: 1})
+			}))
+			defer server.Close()
+
+			update := &DocumentUpdate{Title: Ptr("Lease"), Created: &tt.created}
+			if _, err := NewClient(server.URL, "test-token").UpdateDocument(context.Background(), 1, update); err != nil {
+				t.Fatalf("update failed: %v", err)
+			}
+			if *update.Created != tt.created {
+				t.Error("UpdateDocument modified the caller's update")
+			}
+		})
+	}
+}
+
 func TestClient_RenameDocument(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		newTitle := "New Document Title"
@@ -547,3 +758,79 @@ func TestClient_UpdateDocumentTags(t *testing.T) {
 		}
 	})
 }
+
+func TestClient_DocumentURLs(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		want    DocumentURLs
+	}{
+		{
+			name:    "root",
+			baseURL: "http://localhost:8000",
+			want: DocumentURLs{
+				Web:       "http://localhost:8000/documents/42/details",
+				API:       "http://localhost:8000/api/documents/42/",
+				Download:  "http://localhost:8000/api/documents/42/download/",
+				Preview:   "http://localhost:8000/api/documents/42/preview/",
+				Thumbnail: "http://localhost:8000/api/documents/42/thumb/",
+			},
+		},
+		{
+			name:    "path prefix with trailing slash",
+			baseURL: "https://example.com/paperless/",
+			want: DocumentURLs{
+				Web:       "https://example.com/paperless/documents/42/details",
+				API:       "https://example.com/paperless/api/documents/42/",
+				Download:  "https://example.com/paperless/api/documents/42/download/",
+				Preview:   "https://example.com/paperless/api/documents/42/preview/",
+				Thumbnail: "https://example.com/paperless/api/documents/42/thumb/",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(tt.baseURL, "test-token")
+			if got := c.DocumentURLs(42); got != tt.want {
+				t.Errorf("DocumentURLs(42) = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_DeleteDocument(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "DELETE" {
+				t.Errorf("method = %v, want DELETE", r.Method)
+			}
+			if r.URL.Path != "/api/documents/7/" {
+				t.Errorf("path = %v, want /api/documents/7/", r.URL.Path)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		if err := c.DeleteDocument(context.Background(), 7); err != nil {
+			t.Fatalf("DeleteDocument failed: %v", err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		err := c.DeleteDocument(context.Background(), 999)
+		if !IsNotFound(err) {
+			t.Fatalf("expected 404 error, got %v", err)
+		}
+		if apiErr, ok := err.(*Error); !ok || apiErr.Op != "DeleteDocument" {
+			t.Errorf("err = %v, want *Error with op DeleteDocument", err)
+		}
+	})
+}
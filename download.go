@@ -0,0 +1,68 @@
+package paperless
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// File is a file downloaded from Paperless. The whole file is held in
+// memory, so downloads are subject to WithMaxResponseSize.
+type File struct {
+	Name        string // File name from Content-Disposition, if sent
+	ContentType string
+	Data        []byte
+}
+
+// DownloadDocument downloads a document. By default Paperless returns the
+// archived (OCRed PDF) version when there is one; set original to get the
+// file as it was uploaded.
+func (c *Client) DownloadDocument(ctx context.Context, id int, original bool) (*File, error) {
+	ctx = withOperation(ctx, "DownloadDocument", ResourceDocuments)
+	fullURL := c.DocumentURLs(id).Download
+	if original {
+		fullURL += "?original=true"
+	}
+
+	var result File
+	if err := c.doRequestWithURL(ctx, "GET", fullURL, nil, &result); err != nil {
+		return nil, wrapError(err, "DownloadDocument")
+	}
+
+	return &result, nil
+}
+
+// DownloadThumbnail downloads the thumbnail of a document, an image of its
+// first page. Current Paperless versions serve WebP; older ones PNG.
+func (c *Client) DownloadThumbnail(ctx context.Context, id int) (*File, error) {
+	ctx = withOperation(ctx, "DownloadThumbnail", ResourceDocuments)
+
+	var result File
+	if err := c.doRequestWithURL(ctx, "GET", c.DocumentURLs(id).Thumbnail, nil, &result); err != nil {
+		return nil, wrapError(err, "DownloadThumbnail")
+	}
+
+	return &result, nil
+}
+
+// isFile reports whether result receives a raw file rather than JSON.
+func isFile(result interface{}) bool {
+	_, ok := result.(*File)
+	return ok
+}
+
+// readFile reads a non-JSON response body into f.
+func readFile(resp *http.Response, r io.Reader, f *File) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	f.Data = data
+	f.ContentType = resp.Header.Get("Content-Type")
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		f.Name = params["filename"]
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package paperless
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_DownloadDocument(t *testing.T) {
+	tests := []struct {
+		name      string
+		original  bool
+		wantQuery string
+	}{
+		{"archived", false, ""},
+		{"original", true, "original=true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/documents/5/download/" {
+					t.Errorf("path = %v, want /api/documents/5/download/", r.URL.Path)
+				}
+				if r.URL.RawQuery != tt.wantQuery {
+					t.Errorf("query = %q, want %q", r.URL.RawQuery, tt.wantQuery)
+				}
+				if accept := r.Header.Get("Accept"); accept != "*/*" {
+					t.Errorf("Accept = %q, want */*", accept)
+				}
+				w.Header().Set("Content-Type", "application/pdf")
+				w.Header().Set("Content-Disposition", `attachment; filename="invoice.pdf"`)
+				_, _ = w.Write([]byte("%PDF-1.7"))
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, "test-token")
+			f, err := c.DownloadDocument(context.Background(), 5, tt.original)
+			if err != nil {
+				t.Fatalf("DownloadDocument failed: %v", err)
+			}
+			if f.Name != "invoice.pdf" || f.ContentType != "application/pdf" || string(f.Data) != "%PDF-1.7" {
+				t.Errorf("file = %q %q %q", f.Name, f.ContentType, f.Data)
+			}
+		})
+	}
+}
+
+func TestClient_DownloadThumbnail(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		image := []byte("RIFF\x00\x00\x00\x00WEBPVP8 ")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/documents/5/thumb/" {
+				t.Errorf("path = %v, want /api/documents/5/thumb/", r.URL.Path)
+			}
+			w.Header().Set("Content-Type", "image/webp")
+			_, _ = w.Write(image)
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		f, err := c.DownloadThumbnail(context.Background(), 5)
+		if err != nil {
+			t.Fatalf("DownloadThumbnail failed: %v", err)
+		}
+		if f.ContentType != "image/webp" || !bytes.Equal(f.Data, image) {
+			t.Errorf("file = %q %q", f.ContentType, f.Data)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		_, err := c.DownloadThumbnail(context.Background(), 5)
+		apiErr, ok := err.(*Error)
+		if !ok || !IsNotFound(err) || apiErr.Op != "DownloadThumbnail" {
+			t.Errorf("expected DownloadThumbnail 404, got %v", err)
+		}
+	})
+
+	t.Run("size limit", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(make([]byte, 100))
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token", WithMaxResponseSize(10))
+		if _, err := c.DownloadThumbnail(context.Background(), 5); !errors.Is(err, ErrResponseTooLarge) {
+			t.Errorf("expected ErrResponseTooLarge, got %v", err)
+		}
+	})
+}
@@ -3,8 +3,24 @@ package paperless
 import (
 	"errors"
 	"fmt"
+	"net/http"
 )
 
+// Sentinel errors for common failure modes. API errors returned by the
+// Client match these with errors.Is based on their HTTP status code.
+var (
+	ErrNotFound     = errors.New("paperless: not found")
+	ErrUnauthorized = errors.New("paperless: unauthorized")
+	ErrForbidden    = errors.New("paperless: forbidden")
+	ErrRateLimited  = errors.New("paperless: rate limited")
+	ErrConflict     = errors.New("paperless: conflict")
+	ErrValidation   = errors.New("paperless: validation failed")
+)
+
+// ErrResponseTooLarge is returned when a response body exceeds the limit set
+// with WithMaxResponseSize.
+var ErrResponseTooLarge = errors.New("paperless: response too large")
+
 // Error represents an API error.
 type Error struct {
 	StatusCode int
@@ -20,11 +36,54 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("%d %s", e.StatusCode, e.Message)
 }
 
-// IsNotFound reports whether err indicates a 404 response.
-func IsNotFound(err error) bool {
-	var apiErr *Error
-	if errors.As(err, &apiErr) {
-		return apiErr.StatusCode == 404
+// Is reports whether the error matches one of the sentinel errors.
+// This allows callers to use errors.Is(err, paperless.ErrNotFound).
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrValidation:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
 	}
 	return false
 }
+
+// IsNotFound reports whether err indicates a 404 response.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsUnauthorized reports whether err indicates a 401 response,
+// typically caused by a missing or invalid API token.
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsForbidden reports whether err indicates a 403 response.
+func IsForbidden(err error) bool {
+	return errors.Is(err, ErrForbidden)
+}
+
+// IsRateLimited reports whether err indicates a 429 response.
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// IsConflict reports whether err indicates a 409 response.
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// IsValidation reports whether err indicates the server rejected the
+// request payload (400 or 422 response).
+func IsValidation(err error) bool {
+	return errors.Is(err, ErrValidation)
+}
@@ -2,6 +2,7 @@ package paperless
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -86,3 +87,63 @@ func TestIsNotFound(t *testing.T) {
 		})
 	}
 }
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		check      func(error) bool
+		sentinel   error
+	}{
+		{name: "unauthorized", statusCode: 401, check: IsUnauthorized, sentinel: ErrUnauthorized},
+		{name: "forbidden", statusCode: 403, check: IsForbidden, sentinel: ErrForbidden},
+		{name: "not found", statusCode: 404, check: IsNotFound, sentinel: ErrNotFound},
+		{name: "conflict", statusCode: 409, check: IsConflict, sentinel: ErrConflict},
+		{name: "rate limited", statusCode: 429, check: IsRateLimited, sentinel: ErrRateLimited},
+		{name: "validation 400", statusCode: 400, check: IsValidation, sentinel: ErrValidation},
+		{name: "validation 422", statusCode: 422, check: IsValidation, sentinel: ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &Error{StatusCode: tt.statusCode, Message: "msg", Op: "GetDocument"}
+			if !tt.check(err) {
+				t.Errorf("helper returned false for status %d", tt.statusCode)
+			}
+			if !errors.Is(err, tt.sentinel) {
+				t.Errorf("errors.Is(%d, %v) = false, want true", tt.statusCode, tt.sentinel)
+			}
+
+			wrapped := fmt.Errorf("context: %w", err)
+			if !tt.check(wrapped) {
+				t.Errorf("helper returned false for wrapped status %d", tt.statusCode)
+			}
+
+			other := &Error{StatusCode: 500}
+			if tt.check(other) {
+				t.Errorf("helper returned true for status 500")
+			}
+			if errors.Is(other, tt.sentinel) {
+				t.Errorf("errors.Is(500, %v) = true, want false", tt.sentinel)
+			}
+		})
+	}
+}
+
+func TestErrorClassification_NonAPIError(t *testing.T) {
+	err := errors.New("network down")
+	for name, check := range map[string]func(error) bool{
+		"IsUnauthorized": IsUnauthorized,
+		"IsForbidden":    IsForbidden,
+		"IsRateLimited":  IsRateLimited,
+		"IsConflict":     IsConflict,
+		"IsValidation":   IsValidation,
+	} {
+		if check(err) {
+			t.Errorf("%s() = true for non-API error", name)
+		}
+		if check(nil) {
+			t.Errorf("%s() = true for nil error", name)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package paperless
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RequestHook is called before each request is sent, after the client has
+// set its own headers. It may modify the request, e.g. to add a correlation
+// ID or a signature header. Returning an error aborts the request.
+type RequestHook func(req *http.Request) error
+
+// ResponseHook is called after each response is received and before its
+// body is read or the status code is checked. A hook that consumes the body
+// (for example to record test fixtures) must replace resp.Body with an
+// equivalent reader. Returning an error aborts the request.
+type ResponseHook func(resp *http.Response) error
+
+// WithRequestHook adds a hook that runs before every request.
+// Hooks run in the order they were added.
+func WithRequestHook(h RequestHook) Option {
+	return func(client *Client) {
+		client.requestHooks = append(client.requestHooks, h)
+	}
+}
+
+// WithResponseHook adds a hook that runs after every response.
+// Hooks run in the order they were added.
+func WithResponseHook(h ResponseHook) Option {
+	return func(client *Client) {
+		client.responseHooks = append(client.responseHooks, h)
+	}
+}
+
+// runRequestHooks applies the configured request hooks in order.
+func (c *Client) runRequestHooks(req *http.Request) error {
+	for _, h := range c.requestHooks {
+		if err := h(req); err != nil {
+			return fmt.Errorf("request hook: %w", err)
+		}
+	}
+	return nil
+}
+
+// runResponseHooks applies the configured response hooks in order.
+func (c *Client) runResponseHooks(resp *http.Response) error {
+	for _, h := range c.responseHooks {
+		if err := h(resp); err != nil {
+			return fmt.Errorf("response hook: %w", err)
+		}
+	}
+	return nil
+}
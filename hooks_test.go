@@ -0,0 +1,107 @@
+package paperless
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithRequestHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Correlation-ID"); got != "abc-123" {
+			t.Errorf("X-Correlation-ID = %q, want abc-123", got)
+		}
+		if got := r.Header.Get("X-Order"); got != "first,second" {
+			t.Errorf("X-Order = %q, want first,second", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Tag{ID: 1})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token",
+		WithRequestHook(func(req *http.Request) error {
+			req.Header.Set("X-Correlation-ID", "abc-123")
+			req.Header.Set("X-Order", "first")
+			return nil
+		}),
+		WithRequestHook(func(req *http.Request) error {
+			req.Header.Set("X-Order", req.Header.Get("X-Order")+",second")
+			return nil
+		}),
+	)
+	if _, err := c.GetTag(context.Background(), 1); err != nil {
+		t.Fatalf("GetTag failed: %v", err)
+	}
+}
+
+func TestWithRequestHook_Error(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	hookErr := errors.New("refused")
+	c := NewClient(server.URL, "test-token", WithRequestHook(func(req *http.Request) error {
+		return hookErr
+	}))
+	_, err := c.GetTag(context.Background(), 1)
+	if !errors.Is(err, hookErr) {
+		t.Fatalf("expected hook error, got %v", err)
+	}
+	if called {
+		t.Error("request was sent despite hook error")
+	}
+}
+
+func TestWithResponseHook_RecordsBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Tag{ID: 7, Name: "fixture"})
+	}))
+	defer server.Close()
+
+	var recorded []byte
+	c := NewClient(server.URL, "test-token", WithResponseHook(func(resp *http.Response) error {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		recorded = body
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return nil
+	}))
+
+	tag, err := c.GetTag(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("GetTag failed: %v", err)
+	}
+	if tag.Name != "fixture" {
+		t.Errorf("tag name = %q, want fixture", tag.Name)
+	}
+	if !bytes.Contains(recorded, []byte(`"fixture"`)) {
+		t.Errorf("recorded body = %s, want fixture JSON", recorded)
+	}
+}
+
+func TestWithResponseHook_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Tag{ID: 1})
+	}))
+	defer server.Close()
+
+	hookErr := errors.New("unexpected header")
+	c := NewClient(server.URL, "test-token", WithResponseHook(func(resp *http.Response) error {
+		return hookErr
+	}))
+	if _, err := c.GetTag(context.Background(), 1); !errors.Is(err, hookErr) {
+		t.Fatalf("expected hook error, got %v", err)
+	}
+}
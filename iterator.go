@@ -0,0 +1,157 @@
+package paperless
+
+import "context"
+
+// DocumentLister lists a page of documents. It is implemented by Client and
+// can be faked in tests of code that iterates over documents.
+type DocumentLister interface {
+	ListDocuments(ctx context.Context, opts *ListOptions) (*DocumentList, error)
+}
+
+// DocumentIterator walks the documents matching a query across pages,
+// fetching each page when the previous one is used up:
+//
+//	it := client.IterDocuments(opts)
+//	for it.Next(ctx) {
+//		doc := it.Document()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type DocumentIterator struct {
+	lister DocumentLister
+	opts   ListOptions
+	page   []Document
+	pos    int
+	count  int
+	pages  int
+	done   bool
+	err    error
+
+	// With WithIDCursor
+	cursor bool
+	paged  bool // the server ignored the cursor; pages are used instead
+	lastID int
+}
+
+// IterOption configures a DocumentIterator.
+type IterOption func(*DocumentIterator)
+
+// WithIDCursor makes the iterator consistent under concurrent modification:
+// documents are listed by ascending ID and each page asks for the IDs after
+// the last one seen (AfterID), instead of a page number. Documents added or
+// deleted during the iteration then cannot shift later pages, which would
+// skip or repeat documents. Documents added after the iteration started are
+// included if their ID comes after the cursor.
+//
+// opts.Ordering and opts.Page are ignored; opts.AfterID sets the starting
+// point. If the server ignores the cursor filter, the iterator falls back to
+// page numbers with ascending IDs and drops documents already seen, so there
+// are still no duplicates, but a deletion may skip a document.
+func WithIDCursor() IterOption {
+	return func(it *DocumentIterator) {
+		it.cursor = true
+	}
+}
+
+// IterDocuments returns an iterator over the documents matching opts,
+// starting at opts.Page (default 1). opts is copied, so it may be reused.
+func (c *Client) IterDocuments(opts *ListOptions, iterOpts ...IterOption) *DocumentIterator {
+	return NewDocumentIterator(c, opts, iterOpts...)
+}
+
+// NewDocumentIterator returns an iterator over the documents that lister
+// lists for opts. Client.IterDocuments is the usual way to get one.
+func NewDocumentIterator(lister DocumentLister, opts *ListOptions, iterOpts ...IterOption) *DocumentIterator {
+	it := &DocumentIterator{lister: lister}
+	if opts != nil {
+		it.opts = *opts
+	}
+	for _, opt := range iterOpts {
+		opt(it)
+	}
+	if it.cursor {
+		it.opts.Ordering = "id"
+		it.opts.Page = 0
+		it.lastID = it.opts.AfterID
+	} else if it.opts.Page <= 0 {
+		it.opts.Page = 1
+	}
+	return it
+}
+
+// Next advances to the next document, fetching the next page if needed. It
+// returns false when there are no more documents or a request fails.
+func (it *DocumentIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	for it.pos >= len(it.page) {
+		if it.done {
+			return false
+		}
+		if it.cursor && !it.paged {
+			it.opts.AfterID = it.lastID
+		}
+		list, err := it.lister.ListDocuments(ctx, &it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		results := list.Results
+		if it.cursor {
+			if !it.paged && it.pages > 0 && len(results) > 0 && results[0].ID <= it.lastID {
+				// The cursor filter was ignored and the first page came
+				// back; continue after the pages already read
+				it.paged = true
+				it.opts.AfterID = 0
+				it.opts.Page = it.pages + 1
+				continue
+			}
+			results = it.unseen(results)
+		}
+		if it.pages == 0 {
+			it.count = list.Count
+		}
+		it.pages++
+		it.page, it.pos = results, 0
+		it.done = list.Next == nil || *list.Next == ""
+		if it.paged || !it.cursor {
+			it.opts.Page++
+		}
+	}
+	it.pos++
+	if doc := it.page[it.pos-1]; doc.ID > it.lastID {
+		it.lastID = doc.ID
+	}
+	return true
+}
+
+// unseen returns the documents after the last ID seen.
+func (it *DocumentIterator) unseen(docs []Document) []Document {
+	kept := docs[:0:0]
+	for _, doc := range docs {
+		if doc.ID > it.lastID {
+			kept = append(kept, doc)
+		}
+	}
+	return kept
+}
+
+// Document returns the current document. It is only valid after Next
+// returned true.
+func (it *DocumentIterator) Document() Document {
+	return it.page[it.pos-1]
+}
+
+// Count returns the total number of matching documents reported by the
+// server with the first page, or 0 before it is fetched.
+func (it *DocumentIterator) Count() int {
+	return it.count
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *DocumentIterator) Err() error {
+	return it.err
+}
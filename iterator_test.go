@@ -0,0 +1,204 @@
+package paperless
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDocumentIterator(t *testing.T) {
+	t.Run("walks every page", func(t *testing.T) {
+		var pages []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			page := r.URL.Query().Get("page")
+			pages = append(pages, page)
+			if r.URL.Query().Get("tags__id__all") != "3" {
+				t.Errorf("filters not sent on page %s: %s", page, r.URL.RawQuery)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			switch page {
+			case "1":
+				fmt.Fprint(w, `{"count": 3, "next": "http://example/api/documents/?page=2", "results": [{"id": 1}, {"id": 2}]}`)
+			case "2":
+				fmt.Fprint(w, `{"count": 3, "next": null, "results": [{"id": 3}]}`)
+			default:
+				t.Errorf("unexpected page %q", page)
+			}
+		}))
+		defer server.Close()
+
+		opts := &ListOptions{TagIDs: []int{3}, PageSize: 2}
+		it := NewClient(server.URL, "test-token").IterDocuments(opts)
+		var ids []int
+		for it.Next(context.Background()) {
+			ids = append(ids, it.Document().ID)
+		}
+		if err := it.Err(); err != nil {
+			t.Fatalf("iteration failed: %v", err)
+		}
+		if fmt.Sprint(ids) != "[1 2 3]" || it.Count() != 3 {
+			t.Errorf("ids = %v, count = %d; want [1 2 3], 3", ids, it.Count())
+		}
+		if fmt.Sprint(pages) != "[1 2]" {
+			t.Errorf("requested pages %v, want [1 2]", pages)
+		}
+		if opts.Page != 0 {
+			t.Errorf("iterator modified the caller's options: page = %d", opts.Page)
+		}
+		if it.Next(context.Background()) {
+			t.Error("Next returned true after the last page")
+		}
+	})
+
+	t.Run("stops on error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		it := NewClient(server.URL, "test-token").IterDocuments(nil)
+		if it.Next(context.Background()) {
+			t.Error("Next returned true for a failed request")
+		}
+		if it.Err() == nil {
+			t.Error("expected Err to report the failed request")
+		}
+	})
+}
+
+// changingLister serves documents ordered by ID from a list that is
+// modified by change before each page after the first, like a server with
+// concurrent uploads and deletions.
+type changingLister struct {
+	ids          []int
+	ignoreCursor bool
+	change       func(l *changingLister, page int)
+	requests     []ListOptions
+}
+
+func (l *changingLister) ListDocuments(ctx context.Context, opts *ListOptions) (*DocumentList, error) {
+	l.requests = append(l.requests, *opts)
+	if n := len(l.requests); n > 1 && l.change != nil {
+		l.change(l, n)
+	}
+	var matching []int
+	for _, id := range l.ids {
+		if l.ignoreCursor || id > opts.AfterID {
+			matching = append(matching, id)
+		}
+	}
+	start := 0
+	if opts.Page > 1 {
+		start = (opts.Page - 1) * opts.PageSize
+	}
+	if start > len(matching) {
+		start = len(matching)
+	}
+	end := start + opts.PageSize
+	if end > len(matching) {
+		end = len(matching)
+	}
+	list := &DocumentList{Count: len(matching)}
+	for _, id := range matching[start:end] {
+		list.Results = append(list.Results, Document{ID: id})
+	}
+	if end < len(matching) {
+		next := "more"
+		list.Next = &next
+	}
+	return list, nil
+}
+
+func (l *changingLister) remove(id int) {
+	for i, v := range l.ids {
+		if v == id {
+			l.ids = append(l.ids[:i], l.ids[i+1:]...)
+			return
+		}
+	}
+}
+
+func collectIDs(t *testing.T, it *DocumentIterator) []int {
+	t.Helper()
+	var ids []int
+	for it.Next(context.Background()) {
+		ids = append(ids, it.Document().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	return ids
+}
+
+func TestDocumentIterator_IDCursor(t *testing.T) {
+	t.Run("no skips when documents are deleted", func(t *testing.T) {
+		lister := &changingLister{
+			ids: []int{1, 2, 3, 4, 5, 6},
+			change: func(l *changingLister, page int) {
+				if page == 2 {
+					l.remove(1) // already seen; page numbers would skip 3
+					l.ids = append(l.ids, 7)
+				}
+			},
+		}
+		it := NewDocumentIterator(lister, &ListOptions{PageSize: 2, Page: 5, Ordering: "-created"}, WithIDCursor())
+		ids := collectIDs(t, it)
+		if fmt.Sprint(ids) != "[1 2 3 4 5 6 7]" {
+			t.Errorf("ids = %v, want [1 2 3 4 5 6 7]", ids)
+		}
+		if it.Count() != 6 {
+			t.Errorf("Count() = %d, want 6 from the first page", it.Count())
+		}
+		for i, req := range lister.requests {
+			if req.Ordering != "id" || req.Page != 0 {
+				t.Errorf("request %d: ordering %q, page %d; want id, 0", i, req.Ordering, req.Page)
+			}
+		}
+		if got := lister.requests[len(lister.requests)-1].AfterID; got != 6 {
+			t.Errorf("last request AfterID = %d, want 6", got)
+		}
+	})
+
+	t.Run("falls back to pages without duplicates", func(t *testing.T) {
+		lister := &changingLister{
+			ids:          []int{1, 2, 3, 4, 5},
+			ignoreCursor: true,
+			change: func(l *changingLister, page int) {
+				if page == 2 {
+					l.ids = append([]int{0}, l.ids...) // shifts 2 onto page 2
+				}
+			},
+		}
+		it := NewDocumentIterator(lister, &ListOptions{PageSize: 2}, WithIDCursor())
+		ids := collectIDs(t, it)
+		if fmt.Sprint(ids) != "[1 2 3 4 5]" {
+			t.Errorf("ids = %v, want [1 2 3 4 5]", ids)
+		}
+	})
+
+	t.Run("sends id__gt", func(t *testing.T) {
+		var cursors []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			cursors = append(cursors, q.Get("id__gt"))
+			if q.Get("ordering") != "id" || q.Has("page") {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if q.Get("id__gt") == "" {
+				fmt.Fprint(w, `{"count": 3, "next": "http://example/api/documents/?page=2", "results": [{"id": 4}, {"id": 9}]}`)
+			} else {
+				fmt.Fprint(w, `{"count": 1, "next": null, "results": [{"id": 12}]}`)
+			}
+		}))
+		defer server.Close()
+
+		it := NewClient(server.URL, "test-token").IterDocuments(&ListOptions{PageSize: 2}, WithIDCursor())
+		ids := collectIDs(t, it)
+		if fmt.Sprint(ids) != "[4 9 12]" || fmt.Sprint(cursors) != "[ 9]" {
+			t.Errorf("ids = %v, cursors = %q; want [4 9 12], [\"\" \"9\"]", ids, cursors)
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package paperless
+
+// The helpers below are functions rather than methods: DocumentList and
+// TagList are aliases of List[Document] and List[Tag], so the two spellings
+// stay interchangeable, and an instantiated generic type can't have methods.
+
+// DocumentIDs returns the IDs of the documents in l, in order.
+func DocumentIDs(l *DocumentList) []int {
+	return Map(l.Results, func(doc Document) int { return doc.ID })
+}
+
+// TagIDs returns the IDs of the tags in l, in order.
+func TagIDs(l *TagList) []int {
+	return Map(l.Results, func(tag Tag) int { return tag.ID })
+}
+
+// TagsByID indexes the tags in l by ID.
+func TagsByID(l *TagList) map[int]Tag {
+	byID := make(map[int]Tag, len(l.Results))
+	for _, tag := range l.Results {
+		byID[tag.ID] = tag
+	}
+	return byID
+}
+
+// TagsByName indexes the tags in l by name. Names are unique per owner; if
+// tags of different owners share a name, the last one wins.
+func TagsByName(l *TagList) map[string]Tag {
+	byName := make(map[string]Tag, len(l.Results))
+	for _, tag := range l.Results {
+		byName[tag.Name] = tag
+	}
+	return byName
+}
+
+// Map applies fn to each item and returns the results in order. It is
+// typically used on the Results of a list, e.g. to convert documents to an
+// output type:
+//
+//	titles := paperless.Map(docs.Results, func(d paperless.Document) string { return d.Title })
+func Map[T, R any](items []T, fn func(T) R) []R {
+	out := make([]R, len(items))
+	for i, item := range items {
+		out[i] = fn(item)
+	}
+	return out
+}
@@ -0,0 +1,43 @@
+package paperless
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDocumentIDs(t *testing.T) {
+	list := &DocumentList{Results: []Document{{ID: 3}, {ID: 1}, {ID: 2}}}
+	if got := DocumentIDs(list); !reflect.DeepEqual(got, []int{3, 1, 2}) {
+		t.Errorf("DocumentIDs() = %v, want [3 1 2]", got)
+	}
+
+	empty := &DocumentList{}
+	if got := DocumentIDs(empty); len(got) != 0 {
+		t.Errorf("DocumentIDs() on empty list = %v, want empty", got)
+	}
+}
+
+func TestTagsIndex(t *testing.T) {
+	list := &TagList{Results: []Tag{{ID: 1, Name: "tax"}, {ID: 2, Name: "inbox"}}}
+
+	if got := TagIDs(list); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("TagIDs() = %v, want [1 2]", got)
+	}
+	byName := TagsByName(list)
+	if len(byName) != 2 || byName["inbox"].ID != 2 {
+		t.Errorf("TagsByName() = %v, want inbox -> 2", byName)
+	}
+	byID := TagsByID(list)
+	if len(byID) != 2 || byID[1].Name != "tax" {
+		t.Errorf("TagsByID() = %v, want 1 -> tax", byID)
+	}
+}
+
+func TestMap(t *testing.T) {
+	docs := []Document{{Title: "a"}, {Title: "b"}}
+	got := Map(docs, func(d Document) string { return strings.ToUpper(d.Title) })
+	if !reflect.DeepEqual(got, []string{"A", "B"}) {
+		t.Errorf("Map() = %v, want [A B]", got)
+	}
+}
@@ -0,0 +1,95 @@
+package paperless
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrorClass categorizes a failed request for metrics and logging.
+type ErrorClass string
+
+// Error classes reported to a Recorder. A successful request has an empty class.
+const (
+	ErrorClassNone         ErrorClass = ""
+	ErrorClassNotFound     ErrorClass = "not_found"
+	ErrorClassUnauthorized ErrorClass = "unauthorized"
+	ErrorClassForbidden    ErrorClass = "forbidden"
+	ErrorClassRateLimited  ErrorClass = "rate_limited"
+	ErrorClassConflict     ErrorClass = "conflict"
+	ErrorClassValidation   ErrorClass = "validation"
+	ErrorClassClient       ErrorClass = "client_error"
+	ErrorClassServer       ErrorClass = "server_error"
+	ErrorClassCanceled     ErrorClass = "canceled"
+	ErrorClassTransport    ErrorClass = "transport"
+)
+
+// ClassifyError returns the ErrorClass for err.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassNone
+	}
+	switch {
+	case IsNotFound(err):
+		return ErrorClassNotFound
+	case IsUnauthorized(err):
+		return ErrorClassUnauthorized
+	case IsForbidden(err):
+		return ErrorClassForbidden
+	case IsRateLimited(err):
+		return ErrorClassRateLimited
+	case IsConflict(err):
+		return ErrorClassConflict
+	case IsValidation(err):
+		return ErrorClassValidation
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassCanceled
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		if apiErr.StatusCode >= 500 {
+			return ErrorClassServer
+		}
+		return ErrorClassClient
+	}
+	return ErrorClassTransport
+}
+
+// RequestMetrics describes a single completed API request.
+type RequestMetrics struct {
+	Operation  string        // Client method, e.g. "ListDocuments"
+	Resource   string        // API resource, e.g. "documents"
+	Method     string        // HTTP method
+	StatusCode int           // HTTP status, 0 if no response was received
+	Latency    time.Duration // Time from sending the request to reading the body
+	ErrorClass ErrorClass    // Empty on success
+}
+
+// Recorder receives metrics for every request made by a Client.
+// Implementations must be safe for concurrent use.
+type Recorder interface {
+	RecordRequest(ctx context.Context, m RequestMetrics)
+}
+
+// WithMetrics reports every request to r.
+func WithMetrics(r Recorder) Option {
+	return func(client *Client) {
+		client.metrics = r
+	}
+}
+
+// recordMetrics reports a completed request if a Recorder is configured.
+func (c *Client) recordMetrics(ctx context.Context, method string, status int, latency time.Duration, err error) {
+	if c.metrics == nil {
+		return
+	}
+	info, _ := RequestInfoFromContext(ctx)
+	c.metrics.RecordRequest(ctx, RequestMetrics{
+		Operation:  info.Operation,
+		Resource:   info.Resource,
+		Method:     method,
+		StatusCode: status,
+		Latency:    latency,
+		ErrorClass: ClassifyError(err),
+	})
+}
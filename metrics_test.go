@@ -0,0 +1,95 @@
+package paperless
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type fakeRecorder struct {
+	mu      sync.Mutex
+	metrics []RequestMetrics
+}
+
+func (f *fakeRecorder) RecordRequest(_ context.Context, m RequestMetrics) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.metrics = append(f.metrics, m)
+}
+
+func TestWithMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/documents/404/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Document{ID: 1, Title: "Renamed"})
+	}))
+	defer server.Close()
+
+	rec := &fakeRecorder{}
+	c := NewClient(server.URL, "test-token", WithMetrics(rec))
+
+	if _, err := c.GetDocument(context.Background(), 1); err != nil {
+		t.Fatalf("GetDocument failed: %v", err)
+	}
+	if _, err := c.GetDocument(context.Background(), 404); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if _, err := c.RenameDocument(context.Background(), 1, "Renamed"); err != nil {
+		t.Fatalf("RenameDocument failed: %v", err)
+	}
+
+	if len(rec.metrics) != 3 {
+		t.Fatalf("recorded %d requests, want 3", len(rec.metrics))
+	}
+
+	want := []RequestMetrics{
+		{Operation: "GetDocument", Resource: ResourceDocuments, Method: "GET", StatusCode: 200, ErrorClass: ErrorClassNone},
+		{Operation: "GetDocument", Resource: ResourceDocuments, Method: "GET", StatusCode: 404, ErrorClass: ErrorClassNotFound},
+		{Operation: "RenameDocument", Resource: ResourceDocuments, Method: "PATCH", StatusCode: 200, ErrorClass: ErrorClassNone},
+	}
+	for i, w := range want {
+		got := rec.metrics[i]
+		if got.Operation != w.Operation || got.Resource != w.Resource || got.Method != w.Method || got.StatusCode != w.StatusCode || got.ErrorClass != w.ErrorClass {
+			t.Errorf("metrics[%d] = %+v, want %+v", i, got, w)
+		}
+		if got.Latency <= 0 {
+			t.Errorf("metrics[%d] latency = %v, want > 0", i, got.Latency)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{name: "nil", err: nil, want: ErrorClassNone},
+		{name: "not found", err: &Error{StatusCode: 404}, want: ErrorClassNotFound},
+		{name: "unauthorized", err: &Error{StatusCode: 401}, want: ErrorClassUnauthorized},
+		{name: "forbidden", err: &Error{StatusCode: 403}, want: ErrorClassForbidden},
+		{name: "rate limited", err: &Error{StatusCode: 429}, want: ErrorClassRateLimited},
+		{name: "conflict", err: &Error{StatusCode: 409}, want: ErrorClassConflict},
+		{name: "validation", err: &Error{StatusCode: 400}, want: ErrorClassValidation},
+		{name: "other client error", err: &Error{StatusCode: 418}, want: ErrorClassClient},
+		{name: "server error", err: &Error{StatusCode: 502}, want: ErrorClassServer},
+		{name: "canceled", err: fmt.Errorf("do request: %w", context.Canceled), want: ErrorClassCanceled},
+		{name: "transport", err: errors.New("connection refused"), want: ErrorClassTransport},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package paperless
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Query parameter names of the Paperless list endpoints, as sent for
+// ListOptions. They can be used to build raw queries for Do, e.g. with
+// filters ListOptions does not cover yet.
+const (
+	ParamPage     = "page"
+	ParamPageSize = "page_size"
+	ParamQuery    = "query"
+	ParamOrdering = "ordering"
+	ParamFields   = "fields" // Fields to include in each result, e.g. "id"
+
+	// Document filters
+	ParamTitleContains       = "title__icontains"
+	ParamTagNameIs           = "tags__name__iexact"
+	ParamTagIDsAll           = "tags__id__all"
+	ParamCorrespondentIDsIn  = "correspondent__id__in"
+	ParamDocumentTypeIDsIn   = "document_type__id__in"
+	ParamCreatedAfter        = "created__date__gt"
+	ParamCreatedBefore       = "created__date__lt"
+	ParamCreatedSince        = "created__date__gte"
+	ParamModifiedSince       = "modified__gte"
+	ParamArchiveSerialNumber = "archive_serial_number"
+	ParamIDGreaterThan       = "id__gt"
+	ParamIDsIn               = "id__in"
+)
+
+// ListOptionParams maps the ListOptions fields to the query parameter they
+// are sent as. Query is sent as ParamTitleContains instead when TitleOnly is
+// set for documents.
+var ListOptionParams = map[string]string{
+	"Page":                ParamPage,
+	"PageSize":            ParamPageSize,
+	"Query":               ParamQuery,
+	"Ordering":            ParamOrdering,
+	"TagName":             ParamTagNameIs,
+	"TagIDs":              ParamTagIDsAll,
+	"CorrespondentIDs":    ParamCorrespondentIDsIn,
+	"DocumentTypeIDs":     ParamDocumentTypeIDsIn,
+	"CreatedAfter":        ParamCreatedAfter,
+	"CreatedBefore":       ParamCreatedBefore,
+	"CreatedSince":        ParamCreatedSince,
+	"ModifiedSince":       ParamModifiedSince,
+	"ArchiveSerialNumber": ParamArchiveSerialNumber,
+	"AfterID":             ParamIDGreaterThan,
+	"IDs":                 ParamIDsIn,
+}
+
+// Do sends a request to an API path not covered by the client, such as
+// "/api/documents/" with a raw query, and decodes the JSON response into
+// result. body, if not nil, is sent as JSON. The request gets the client's
+// authentication, retries and hooks.
+func (c *Client) Do(ctx context.Context, method, path string, query url.Values, body, result interface{}) error {
+	ctx = withOperation(ctx, "Do", "")
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid base URL: %w", err)
+	}
+	u.Path = path
+	u.RawQuery = query.Encode()
+
+	if err := c.doRequestWithURL(ctx, method, u.String(), body, result); err != nil {
+		return wrapError(err, "Do")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package paperless
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListOptionParams(t *testing.T) {
+	// Every field but TitleOnly is sent as a parameter
+	typ := reflect.TypeOf(ListOptions{})
+	for i := 0; i < typ.NumField(); i++ {
+		name := typ.Field(i).Name
+		if _, ok := ListOptionParams[name]; !ok && name != "TitleOnly" {
+			t.Errorf("ListOptionParams is missing %s", name)
+		}
+	}
+
+	opts := &ListOptions{
+		Page: 2, PageSize: 10, Query: "q", Ordering: "id",
+		TagName: "tax", TagIDs: []int{1}, CorrespondentIDs: []int{2}, DocumentTypeIDs: []int{3},
+		CreatedAfter:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		CreatedBefore:       time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		CreatedSince:        time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		ModifiedSince:       time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+		ArchiveSerialNumber: 7, AfterID: 5, IDs: []int{8, 9},
+	}
+	raw, err := NewClient("http://example.com", "token").buildURL(documentsAPIPath, opts)
+	if err != nil {
+		t.Fatalf("buildURL failed: %v", err)
+	}
+	u, _ := url.Parse(raw)
+	for field, param := range ListOptionParams {
+		if !u.Query().Has(param) {
+			t.Errorf("%s is not sent as %s: %s", field, param, u.RawQuery)
+		}
+	}
+	if len(u.Query()) != len(ListOptionParams) {
+		t.Errorf("sent %d parameters, ListOptionParams has %d", len(u.Query()), len(ListOptionParams))
+	}
+}
+
+func TestClient_Do(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/documents/" || r.URL.Query().Get(ParamTagIDsAll) != "1,2" || r.URL.Query().Get("is_in_inbox") != "true" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.Header.Get("Authorization") != "Token test-token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"count": 1, "results": [{"id": 5}]}`))
+	}))
+	defer server.Close()
+
+	query := url.Values{ParamTagIDsAll: {"1,2"}, "is_in_inbox": {"true"}}
+	var docs DocumentList
+	if err := NewClient(server.URL, "test-token").Do(context.Background(), "GET", "/api/documents/", query, nil, &docs); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if docs.Count != 1 || docs.Results[0].ID != 5 {
+		t.Errorf("docs = %+v", docs)
+	}
+}
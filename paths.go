@@ -1,6 +1,50 @@
 package paperless
 
+import (
+	"fmt"
+	"strings"
+)
+
 const (
-	documentsAPIPath = "/api/documents/"
-	tagsAPIPath      = "/api/tags/"
+	documentsAPIPath      = "/api/documents/"
+	tagsAPIPath           = "/api/tags/"
+	correspondentsAPIPath = "/api/correspondents/"
+	documentTypesAPIPath  = "/api/document_types/"
+	storagePathsAPIPath   = "/api/storage_paths/"
+	tasksAPIPath          = "/api/tasks/"
+	customFieldsAPIPath   = "/api/custom_fields/"
+	usersAPIPath          = "/api/users/"
+	groupsAPIPath         = "/api/groups/"
+	savedViewsAPIPath     = "/api/saved_views/"
+	nextASNAPIPath        = "/api/documents/next_asn/"
+	tokenAPIPath          = "/api/token/"
 )
+
+// documentPath returns the API path of a single document.
+func documentPath(id int) string {
+	return fmt.Sprintf("%s%d/", documentsAPIPath, id)
+}
+
+// DocumentURLs holds the absolute URLs of a document and its sub-resources.
+type DocumentURLs struct {
+	Web       string // Document details page in the web UI
+	API       string // Document API endpoint
+	Download  string // Original file download
+	Preview   string // Inline preview of the archived file
+	Thumbnail string // Thumbnail image
+}
+
+// DocumentURLs returns the URLs of document id, built from the client's base
+// URL. A path prefix in the base URL (e.g. "https://host/paperless") is kept.
+// No request is made and the document is not checked to exist.
+func (c *Client) DocumentURLs(id int) DocumentURLs {
+	base := strings.TrimRight(c.baseURL, "/")
+	api := base + documentPath(id)
+	return DocumentURLs{
+		Web:       fmt.Sprintf("%s/documents/%d/details", base, id),
+		API:       api,
+		Download:  api + "download/",
+		Preview:   api + "preview/",
+		Thumbnail: api + "thumb/",
+	}
+}
@@ -0,0 +1,242 @@
+package paperless
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// setPermissionsRequest is the body that replaces a document's owner and
+// permissions
+type setPermissionsRequest struct {
+	Owner          *int        `json:"owner"`
+	SetPermissions Permissions `json:"set_permissions"`
+}
+
+// GetDocumentPermissions retrieves the owner and permissions of a document.
+func (c *Client) GetDocumentPermissions(ctx context.Context, id int) (*DocumentPermissions, error) {
+	ctx = withOperation(ctx, "GetDocumentPermissions", ResourceDocuments)
+	fullURL := c.DocumentURLs(id).API + "?full_perms=true"
+
+	var result DocumentPermissions
+	if err := c.doRequestWithURL(ctx, "GET", fullURL, nil, &result); err != nil {
+		return nil, wrapError(err, "GetDocumentPermissions")
+	}
+	normalizePermissions(&result.Permissions)
+
+	return &result, nil
+}
+
+// SetDocumentPermissions replaces the owner and permissions of a document.
+// To change a single grant, get the current permissions with
+// GetDocumentPermissions, modify them and set them again. A nil Owner
+// removes the owner. Only the owner and superusers can change permissions.
+// Invalid permissions are reported without making a request.
+func (c *Client) SetDocumentPermissions(ctx context.Context, id int, perms *DocumentPermissions) error {
+	ctx = withOperation(ctx, "SetDocumentPermissions", ResourceDocuments)
+	if err := perms.Permissions.Validate(); err != nil {
+		return fmt.Errorf("SetDocumentPermissions: %w", err)
+	}
+	if perms.Owner != nil && *perms.Owner <= 0 {
+		return fmt.Errorf("SetDocumentPermissions: invalid owner ID: %d", *perms.Owner)
+	}
+
+	req := &setPermissionsRequest{Owner: perms.Owner, SetPermissions: perms.Permissions}
+	normalizePermissions(&req.SetPermissions)
+	if err := c.doRequest(ctx, "PATCH", documentPath(id), req, nil); err != nil {
+		return wrapError(err, "SetDocumentPermissions")
+	}
+
+	return nil
+}
+
+// normalizePermissions replaces nil lists with empty ones, which Paperless
+// requires when setting permissions
+func normalizePermissions(p *Permissions) {
+	for _, set := range []*PermissionSet{&p.View, &p.Change} {
+		if set.Users == nil {
+			set.Users = []int{}
+		}
+		if set.Groups == nil {
+			set.Groups = []int{}
+		}
+	}
+}
+
+// UserPrincipal returns the principal of the user with the given ID.
+func UserPrincipal(id int) Principal {
+	return Principal{Kind: PrincipalUser, ID: id}
+}
+
+// GroupPrincipal returns the principal of the group with the given ID.
+func GroupPrincipal(id int) Principal {
+	return Principal{Kind: PrincipalGroup, ID: id}
+}
+
+// ParsePrincipal parses a principal written as "user:<id>" or
+// "group:<id>", the form String returns.
+func ParsePrincipal(s string) (Principal, error) {
+	kind, id, ok := strings.Cut(strings.TrimSpace(s), ":")
+	p := Principal{Kind: PrincipalKind(kind)}
+	n, err := strconv.Atoi(id)
+	if !ok || err != nil {
+		return Principal{}, fmt.Errorf("invalid principal %q (use user:<id> or group:<id>)", s)
+	}
+	p.ID = n
+	if err := p.Validate(); err != nil {
+		return Principal{}, err
+	}
+	return p, nil
+}
+
+// String returns p as "user:<id>" or "group:<id>".
+func (p Principal) String() string {
+	return fmt.Sprintf("%s:%d", p.Kind, p.ID)
+}
+
+// Validate reports an error if p has an unknown kind or an ID that is not
+// positive.
+func (p Principal) Validate() error {
+	if p.Kind != PrincipalUser && p.Kind != PrincipalGroup {
+		return fmt.Errorf("invalid principal kind %q (use user or group)", p.Kind)
+	}
+	if p.ID <= 0 {
+		return fmt.Errorf("invalid %s ID: %d", p.Kind, p.ID)
+	}
+	return nil
+}
+
+// ids returns the list of s that holds principals of p's kind.
+func (s *PermissionSet) ids(p Principal) *[]int {
+	if p.Kind == PrincipalGroup {
+		return &s.Groups
+	}
+	return &s.Users
+}
+
+// Has reports whether s grants the permission to p directly. Membership of
+// a granted group is not considered.
+func (s PermissionSet) Has(p Principal) bool {
+	for _, id := range *s.ids(p) {
+		if id == p.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// Add grants the permission to p, keeping the IDs sorted.
+func (s *PermissionSet) Add(p Principal) {
+	if s.Has(p) {
+		return
+	}
+	ids := s.ids(p)
+	*ids = append(*ids, p.ID)
+	sort.Ints(*ids)
+}
+
+// Remove revokes the permission from p.
+func (s *PermissionSet) Remove(p Principal) {
+	ids := s.ids(p)
+	kept := []int{}
+	for _, id := range *ids {
+		if id != p.ID {
+			kept = append(kept, id)
+		}
+	}
+	*ids = kept
+}
+
+// Merge returns the users and groups of s and other, sorted and without
+// duplicates.
+func (s PermissionSet) Merge(other PermissionSet) PermissionSet {
+	merged := PermissionSet{Users: []int{}, Groups: []int{}}
+	for _, set := range []PermissionSet{s, other} {
+		for _, id := range set.Users {
+			merged.Add(UserPrincipal(id))
+		}
+		for _, id := range set.Groups {
+			merged.Add(GroupPrincipal(id))
+		}
+	}
+	return merged
+}
+
+// Validate reports an error if s holds an ID that is not positive or holds
+// an ID twice.
+func (s PermissionSet) Validate() error {
+	for _, kind := range []PrincipalKind{PrincipalUser, PrincipalGroup} {
+		seen := make(map[int]bool)
+		for _, id := range *s.ids(Principal{Kind: kind}) {
+			if err := (Principal{Kind: kind, ID: id}).Validate(); err != nil {
+				return err
+			}
+			if seen[id] {
+				return fmt.Errorf("%s %d is listed twice", kind, id)
+			}
+			seen[id] = true
+		}
+	}
+	return nil
+}
+
+// set returns the set of permission, which is PermissionView or
+// PermissionChange.
+func (p *Permissions) set(permission string) (*PermissionSet, error) {
+	switch permission {
+	case PermissionView:
+		return &p.View, nil
+	case PermissionChange:
+		return &p.Change, nil
+	}
+	return nil, fmt.Errorf("invalid permission %q (use %s or %s)", permission, PermissionView, PermissionChange)
+}
+
+// Grant gives who the permission, PermissionView or PermissionChange.
+// Granting change also grants view, since Paperless only shows documents a
+// user can view.
+func (p *Permissions) Grant(permission string, who Principal) error {
+	if err := who.Validate(); err != nil {
+		return err
+	}
+	set, err := p.set(permission)
+	if err != nil {
+		return err
+	}
+	set.Add(who)
+	if permission == PermissionChange {
+		p.View.Add(who)
+	}
+	return nil
+}
+
+// Revoke removes all permissions of who.
+func (p *Permissions) Revoke(who Principal) {
+	p.View.Remove(who)
+	p.Change.Remove(who)
+}
+
+// Has reports whether p grants the permission, PermissionView or
+// PermissionChange, to who directly.
+func (p Permissions) Has(permission string, who Principal) bool {
+	set, err := p.set(permission)
+	return err == nil && set.Has(who)
+}
+
+// Merge returns the grants of both p and other.
+func (p Permissions) Merge(other Permissions) Permissions {
+	return Permissions{View: p.View.Merge(other.View), Change: p.Change.Merge(other.Change)}
+}
+
+// Validate reports an error if a set of p holds an invalid or duplicate ID.
+func (p Permissions) Validate() error {
+	for _, permission := range []string{PermissionView, PermissionChange} {
+		set, _ := p.set(permission)
+		if err := set.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", permission, err)
+		}
+	}
+	return nil
+}
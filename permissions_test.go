@@ -0,0 +1,149 @@
+package paperless
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestClient_GetDocumentPermissions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/documents/7/" || r.URL.Query().Get("full_perms") != "true" {
+			t.Errorf("request = %s, want /api/documents/7/?full_perms=true", r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 7, "title": "Lease", "owner": 2, "permissions": {"view": {"users": [3], "groups": [1]}, "change": {"users": [], "groups": []}}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	perms, err := c.GetDocumentPermissions(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("GetDocumentPermissions failed: %v", err)
+	}
+	owner := 2
+	want := &DocumentPermissions{
+		Owner: &owner,
+		Permissions: Permissions{
+			View:   PermissionSet{Users: []int{3}, Groups: []int{1}},
+			Change: PermissionSet{Users: []int{}, Groups: []int{}},
+		},
+	}
+	if !reflect.DeepEqual(perms, want) {
+		t.Errorf("permissions = %+v, want %+v", perms, want)
+	}
+}
+
+func TestClient_SetDocumentPermissions(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" || r.URL.Path != "/api/documents/7/" {
+			t.Errorf("request = %s %s, want PATCH /api/documents/7/", r.Method, r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 7}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	owner := 2
+	err := c.SetDocumentPermissions(context.Background(), 7, &DocumentPermissions{
+		Owner:       &owner,
+		Permissions: Permissions{View: PermissionSet{Groups: []int{1}}},
+	})
+	if err != nil {
+		t.Fatalf("SetDocumentPermissions failed: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"owner": float64(2),
+		"set_permissions": map[string]interface{}{
+			"view":   map[string]interface{}{"users": []interface{}{}, "groups": []interface{}{float64(1)}},
+			"change": map[string]interface{}{"users": []interface{}{}, "groups": []interface{}{}},
+		},
+	}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("body = %v, want %v", body, want)
+	}
+
+	// A nil owner is sent as null to remove the owner
+	if err := c.SetDocumentPermissions(context.Background(), 7, &DocumentPermissions{}); err != nil {
+		t.Fatalf("SetDocumentPermissions failed: %v", err)
+	}
+	if v, ok := body["owner"]; !ok || v != nil {
+		t.Errorf("owner = %v (present %v), want null", v, ok)
+	}
+
+	// Invalid permissions are not sent
+	body = nil
+	err = c.SetDocumentPermissions(context.Background(), 7, &DocumentPermissions{
+		Permissions: Permissions{View: PermissionSet{Users: []int{0}}},
+	})
+	if err == nil || body != nil {
+		t.Errorf("invalid permissions: err %v, body %v", err, body)
+	}
+}
+
+func TestPermissions_Helpers(t *testing.T) {
+	var p Permissions
+	if err := p.Grant(PermissionChange, GroupPrincipal(4)); err != nil {
+		t.Fatalf("Grant failed: %v", err)
+	}
+	for _, who := range []Principal{UserPrincipal(9), UserPrincipal(3), UserPrincipal(3)} {
+		if err := p.Grant(PermissionView, who); err != nil {
+			t.Fatalf("Grant failed: %v", err)
+		}
+	}
+	want := Permissions{
+		View:   PermissionSet{Users: []int{3, 9}, Groups: []int{4}},
+		Change: PermissionSet{Groups: []int{4}},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("permissions = %+v, want %+v", p, want)
+	}
+	if !p.Has(PermissionView, GroupPrincipal(4)) || p.Has(PermissionChange, UserPrincipal(3)) || p.Has("delete", UserPrincipal(3)) {
+		t.Errorf("Has is wrong for %+v", p)
+	}
+
+	p.Revoke(GroupPrincipal(4))
+	if p.Has(PermissionView, GroupPrincipal(4)) || p.Has(PermissionChange, GroupPrincipal(4)) {
+		t.Errorf("Revoke kept grants: %+v", p)
+	}
+
+	merged := p.Merge(Permissions{View: PermissionSet{Users: []int{1, 9}}, Change: PermissionSet{Users: []int{1}}})
+	wantMerged := Permissions{
+		View:   PermissionSet{Users: []int{1, 3, 9}, Groups: []int{}},
+		Change: PermissionSet{Users: []int{1}, Groups: []int{}},
+	}
+	if !reflect.DeepEqual(merged, wantMerged) {
+		t.Errorf("Merge = %+v, want %+v", merged, wantMerged)
+	}
+
+	if err := p.Grant("delete", UserPrincipal(1)); err == nil {
+		t.Error("Grant accepted an unknown permission")
+	}
+	if err := p.Grant(PermissionView, UserPrincipal(0)); err == nil {
+		t.Error("Grant accepted user 0")
+	}
+	if err := (Permissions{Change: PermissionSet{Users: []int{2, 2}}}).Validate(); err == nil {
+		t.Error("Validate accepted a duplicate user")
+	}
+}
+
+func TestParsePrincipal(t *testing.T) {
+	for _, s := range []string{"user:3", "group:12"} {
+		p, err := ParsePrincipal(s)
+		if err != nil || p.String() != s {
+			t.Errorf("ParsePrincipal(%q) = %v, %v", s, p, err)
+		}
+	}
+	for _, s := range []string{"3", "team:3", "user:x", "group:-1"} {
+		if _, err := ParsePrincipal(s); err == nil {
+			t.Errorf("ParsePrincipal(%q) succeeded", s)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package paperless
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrCertificateNotPinned is returned when the server's TLS certificate
+// chain contains none of the certificates pinned with WithPinnedCertificate.
+var ErrCertificateNotPinned = errors.New("server certificate does not match a pinned certificate")
+
+// WithPinnedCertificate pins the server's TLS certificate: a connection is
+// only used if its verified certificate chain includes a certificate with
+// the given SHA-256 fingerprint, so a certificate issued by another, even
+// valid, CA is rejected and the token is not sent. The usual verification
+// against the system roots still applies. The fingerprint is hex encoded
+// with optional colons, as printed by
+//
+//	openssl x509 -noout -fingerprint -sha256 -in cert.pem
+//
+// Pinning the leaf certificate means updating the pin when it is renewed;
+// pinning an intermediate or root of the chain does not. Use the option
+// several times to accept any of several certificates, e.g. the current
+// and the next one during a renewal.
+//
+// With a pin, requests to a plain http:// base URL fail instead of sending
+// the token unencrypted, and a malformed fingerprint fails every request
+// instead of silently disabling the pin. The pin is checked by the default
+// transport, so combined with WithHTTPClient every request fails rather
+// than going out unpinned.
+func WithPinnedCertificate(sha256Fingerprint string) Option {
+	return func(client *Client) {
+		pin, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(sha256Fingerprint), ":", ""))
+		if err != nil || len(pin) != sha256.Size {
+			client.pinErr = fmt.Errorf("invalid pinned certificate fingerprint %q: want %d hex-encoded bytes", sha256Fingerprint, sha256.Size)
+			return
+		}
+		client.pins = append(client.pins, pin)
+
+		if client.transport.TLSClientConfig == nil {
+			client.transport.TLSClientConfig = &tls.Config{}
+		}
+		client.transport.TLSClientConfig.VerifyConnection = client.verifyPinnedCertificate
+	}
+}
+
+// verifyPinnedCertificate checks a TLS connection against the pins. It runs
+// after the chain was verified.
+func (c *Client) verifyPinnedCertificate(state tls.ConnectionState) error {
+	for _, chain := range state.VerifiedChains {
+		for _, cert := range chain {
+			sum := sha256.Sum256(cert.Raw)
+			for _, pin := range c.pins {
+				if bytes.Equal(sum[:], pin) {
+					return nil
+				}
+			}
+		}
+	}
+	return ErrCertificateNotPinned
+}
+
+// checkPinning fails requests that the pins can't protect: those to a URL
+// that isn't https, any request if a pin was malformed, and any request
+// made with an HTTP client that doesn't use the pinned transport.
+func (c *Client) checkPinning(u *url.URL) error {
+	if c.pinErr != nil {
+		return c.pinErr
+	}
+	if len(c.pins) > 0 && c.httpClient.Transport != http.RoundTripper(c.transport) {
+		return errors.New("refusing request: a certificate is pinned, which a custom HTTP client from WithHTTPClient can't enforce")
+	}
+	if len(c.pins) > 0 && u.Scheme != "https" {
+		return fmt.Errorf("refusing %s request to %s: a certificate is pinned, so https is required", u.Scheme, u.Host)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package paperless
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithPinnedCertificate(t *testing.T) {
+	var requests int
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"count": 0, "results": []}`))
+	}))
+	defer server.Close()
+
+	sum := sha256.Sum256(server.Certificate().Raw)
+	var colons []string
+	for _, b := range sum {
+		colons = append(colons, fmt.Sprintf("%02X", b))
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	newClient := func(baseURL string, pins ...string) *Client {
+		var opts []Option
+		for _, pin := range pins {
+			opts = append(opts, WithPinnedCertificate(pin))
+		}
+		c := NewClient(baseURL, "test-token", append(opts, WithRetries(2))...)
+		if c.transport.TLSClientConfig != nil {
+			c.transport.TLSClientConfig.RootCAs = roots
+		}
+		return c
+	}
+	other := strings.Repeat("ab", sha256.Size)
+
+	// Any of several pins, in openssl's colon format
+	if _, err := newClient(server.URL, other, strings.Join(colons, ":")).ListTags(context.Background(), nil); err != nil {
+		t.Fatalf("ListTags with the server's pin failed: %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("requests = %d, want 1", requests)
+	}
+
+	// A valid chain with another certificate is rejected without retries
+	// and before the token is sent
+	_, err := newClient(server.URL, other).ListTags(context.Background(), nil)
+	if !errors.Is(err, ErrCertificateNotPinned) {
+		t.Errorf("err = %v, want ErrCertificateNotPinned", err)
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want no more", requests)
+	}
+
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected plain http request %s", r.URL)
+	}))
+	defer plain.Close()
+	if _, err := newClient(plain.URL, other).ListTags(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "https is required") {
+		t.Errorf("err = %v, want https required", err)
+	}
+
+	if _, err := newClient(server.URL, "not-hex").ListTags(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "invalid pinned certificate fingerprint") {
+		t.Errorf("err = %v, want invalid fingerprint", err)
+	}
+}
+
+func TestWithPinnedCertificate_CustomHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected unpinned request %s", r.URL)
+	}))
+	defer server.Close()
+
+	// The custom client trusts the server, but can't check the pin
+	pin := sha256.Sum256(server.Certificate().Raw)
+	for _, opts := range [][]Option{
+		{WithHTTPClient(server.Client()), WithPinnedCertificate(fmt.Sprintf("%x", pin))},
+		{WithPinnedCertificate(fmt.Sprintf("%x", pin)), WithHTTPClient(server.Client())},
+	} {
+		c := NewClient(server.URL, "test-token", opts...)
+		if _, err := c.ListTags(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "WithHTTPClient") {
+			t.Errorf("err = %v, want refusal of the custom HTTP client", err)
+		}
+	}
+}
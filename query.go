@@ -0,0 +1,148 @@
+package paperless
+
+import "time"
+
+// Ordering fields accepted by Query.OrderBy for documents.
+const (
+	OrderByCreated             = "created"
+	OrderByAdded               = "added"
+	OrderByModified            = "modified"
+	OrderByTitle               = "title"
+	OrderByArchiveSerialNumber = "archive_serial_number"
+)
+
+// Query builds ListOptions fluently:
+//
+//	opts := paperless.NewQuery().
+//		Tag("tax").
+//		CreatedAfter(start).
+//		OrderByDesc(paperless.OrderByCreated).
+//		Options()
+//
+// Every method returns a new Query and leaves the receiver unchanged, so a
+// base query can be shared and extended without affecting other users.
+type Query struct {
+	opts ListOptions
+}
+
+// NewQuery returns an empty Query.
+func NewQuery() Query {
+	return Query{}
+}
+
+// Search sets the full-text search query.
+func (q Query) Search(text string) Query {
+	q.opts.Query = text
+	return q
+}
+
+// TitleOnly restricts the search to document titles.
+func (q Query) TitleOnly() Query {
+	q.opts.TitleOnly = true
+	return q
+}
+
+// Tag filters documents to those with a tag named name (case-insensitive).
+func (q Query) Tag(name string) Query {
+	q.opts.TagName = name
+	return q
+}
+
+// TagIDs filters documents to those having all of the given tags. IDs are
+// added to any set by earlier calls.
+func (q Query) TagIDs(ids ...int) Query {
+	q.opts.TagIDs = append(append([]int(nil), q.opts.TagIDs...), ids...)
+	return q
+}
+
+// CorrespondentIDs filters documents to those with any of the given
+// correspondents. IDs are added to any set by earlier calls.
+func (q Query) CorrespondentIDs(ids ...int) Query {
+	q.opts.CorrespondentIDs = append(append([]int(nil), q.opts.CorrespondentIDs...), ids...)
+	return q
+}
+
+// DocumentTypeIDs filters documents to those with any of the given
+// document types. IDs are added to any set by earlier calls.
+func (q Query) DocumentTypeIDs(ids ...int) Query {
+	q.opts.DocumentTypeIDs = append(append([]int(nil), q.opts.DocumentTypeIDs...), ids...)
+	return q
+}
+
+// ArchiveSerialNumber filters documents to the one with the given ASN.
+func (q Query) ArchiveSerialNumber(asn int64) Query {
+	q.opts.ArchiveSerialNumber = asn
+	return q
+}
+
+// AfterID filters documents to those with an ID greater than id.
+func (q Query) AfterID(id int) Query {
+	q.opts.AfterID = id
+	return q
+}
+
+// IDs filters documents to those with any of the given IDs. IDs are added
+// to any set by earlier calls.
+func (q Query) IDs(ids ...int) Query {
+	q.opts.IDs = append(append([]int(nil), q.opts.IDs...), ids...)
+	return q
+}
+
+// CreatedAfter filters documents to those created after the date of t.
+func (q Query) CreatedAfter(t time.Time) Query {
+	q.opts.CreatedAfter = t
+	return q
+}
+
+// CreatedBefore filters documents to those created before the date of t.
+func (q Query) CreatedBefore(t time.Time) Query {
+	q.opts.CreatedBefore = t
+	return q
+}
+
+// CreatedSince filters documents to those created on or after the date of t.
+func (q Query) CreatedSince(t time.Time) Query {
+	q.opts.CreatedSince = t
+	return q
+}
+
+// ModifiedSince filters documents to those modified at or after t.
+func (q Query) ModifiedSince(t time.Time) Query {
+	q.opts.ModifiedSince = t
+	return q
+}
+
+// OrderBy sorts results by field in ascending order.
+func (q Query) OrderBy(field string) Query {
+	q.opts.Ordering = field
+	return q
+}
+
+// OrderByDesc sorts results by field in descending order.
+func (q Query) OrderByDesc(field string) Query {
+	q.opts.Ordering = "-" + field
+	return q
+}
+
+// Page selects the result page (1-indexed).
+func (q Query) Page(n int) Query {
+	q.opts.Page = n
+	return q
+}
+
+// PageSize sets the number of results per page.
+func (q Query) PageSize(n int) Query {
+	q.opts.PageSize = n
+	return q
+}
+
+// Options returns the ListOptions built by q. The result does not share
+// memory with q and may be modified freely.
+func (q Query) Options() *ListOptions {
+	opts := q.opts
+	opts.TagIDs = append([]int(nil), q.opts.TagIDs...)
+	opts.CorrespondentIDs = append([]int(nil), q.opts.CorrespondentIDs...)
+	opts.DocumentTypeIDs = append([]int(nil), q.opts.DocumentTypeIDs...)
+	opts.IDs = append([]int(nil), q.opts.IDs...)
+	return &opts
+}
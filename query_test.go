@@ -0,0 +1,75 @@
+package paperless
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQuery_Options(t *testing.T) {
+	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	got := NewQuery().
+		Search("invoice").
+		TitleOnly().
+		Tag("tax").
+		TagIDs(1, 2).
+		TagIDs(3).
+		CorrespondentIDs(7).
+		DocumentTypeIDs(4, 5).
+		ArchiveSerialNumber(1001).
+		IDs(10, 11).
+		CreatedAfter(after).
+		CreatedBefore(before).
+		CreatedSince(after).
+		ModifiedSince(before).
+		OrderByDesc(OrderByCreated).
+		Page(2).
+		PageSize(50).
+		Options()
+
+	want := &ListOptions{
+		Page:                2,
+		PageSize:            50,
+		Query:               "invoice",
+		Ordering:            "-created",
+		TitleOnly:           true,
+		TagName:             "tax",
+		TagIDs:              []int{1, 2, 3},
+		CorrespondentIDs:    []int{7},
+		DocumentTypeIDs:     []int{4, 5},
+		CreatedAfter:        after,
+		CreatedBefore:       before,
+		CreatedSince:        after,
+		ModifiedSince:       before,
+		ArchiveSerialNumber: 1001,
+		IDs:                 []int{10, 11},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Options() = %+v, want %+v", got, want)
+	}
+}
+
+func TestQuery_CopyOnWrite(t *testing.T) {
+	base := NewQuery().TagIDs(1).OrderBy(OrderByTitle)
+
+	a := base.TagIDs(2)
+	b := base.TagIDs(3).OrderBy(OrderByAdded)
+
+	if got := base.Options(); !reflect.DeepEqual(got.TagIDs, []int{1}) || got.Ordering != "title" {
+		t.Errorf("base changed: %+v", got)
+	}
+	if got := a.Options().TagIDs; !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("a.TagIDs = %v, want [1 2]", got)
+	}
+	if got := b.Options().TagIDs; !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Errorf("b.TagIDs = %v, want [1 3]", got)
+	}
+
+	opts := a.Options()
+	opts.TagIDs[0] = 99
+	if got := a.Options().TagIDs[0]; got != 1 {
+		t.Errorf("modifying Options() result changed query: TagIDs[0] = %d", got)
+	}
+}
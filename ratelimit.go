@@ -0,0 +1,126 @@
+package paperless
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Shared rate limit state files are locked only to read and update the time
+// of the next request, so a lock older than sharedLockStale was left by a
+// process that died holding it. A next request time further ahead than
+// sharedMaxWait is treated as stale too, e.g. after a clock change.
+var (
+	sharedLockPoll  = 5 * time.Millisecond
+	sharedLockStale = 2 * time.Second
+	sharedMaxWait   = time.Minute
+)
+
+// WithSharedRateLimit limits the requests of all clients that use the state
+// file at path, in this and other processes, to perSecond together. pgo and
+// pgo-rag use it so that, e.g., a scheduled index build and interactive use
+// stay under one ceiling. The limit is soft: if the state file can't be used
+// the request is sent anyway and the problem is logged. DefaultRateLimitPath
+// returns a path per server.
+func WithSharedRateLimit(path string, perSecond float64) Option {
+	return func(client *Client) {
+		if perSecond > 0 {
+			client.sharedRatePath = path
+			client.sharedInterval = time.Duration(float64(time.Second) / perSecond)
+		}
+	}
+}
+
+// DefaultRateLimitPath returns the state file WithSharedRateLimit uses for
+// the server at baseURL: a file in the user's cache directory named after a
+// hash of the URL.
+func DefaultRateLimitPath(baseURL string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(strings.TrimRight(baseURL, "/")))
+	return filepath.Join(dir, "paperless-go", "ratelimit", hex.EncodeToString(sum[:8])), nil
+}
+
+// paceShared waits until the next request may start under
+// WithSharedRateLimit.
+func (c *Client) paceShared(ctx context.Context) error {
+	if c.sharedRatePath == "" {
+		return nil
+	}
+	start, err := reserveSharedSlot(ctx, c.sharedRatePath, c.sharedInterval)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if c.logger != nil {
+			c.logger.LogAttrs(ctx, slog.LevelWarn, "paperless shared rate limit unavailable",
+				slog.String("path", c.sharedRatePath),
+				slog.String("error", err.Error()),
+			)
+		}
+		return nil
+	}
+	return sleepContext(ctx, time.Until(start))
+}
+
+// reserveSharedSlot returns when the next request may start and records
+// that the one after it may start interval later.
+func reserveSharedSlot(ctx context.Context, path string, interval time.Duration) (time.Time, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return time.Time{}, err
+	}
+	unlock, err := lockFile(ctx, path+".lock")
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer unlock()
+
+	now := time.Now()
+	start := now
+	if data, err := os.ReadFile(path); err == nil {
+		if ns, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); err == nil {
+			if next := time.Unix(0, ns); next.After(now) && next.Sub(now) <= sharedMaxWait {
+				start = next
+			}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, err
+	}
+	next := strconv.FormatInt(start.Add(interval).UnixNano(), 10)
+	if err := os.WriteFile(path, []byte(next+"\n"), 0600); err != nil {
+		return time.Time{}, err
+	}
+	return start, nil
+}
+
+// lockFile takes the lock file at path, waiting while another process holds
+// it, and returns the function that releases it.
+func lockFile(ctx context.Context, path string) (func(), error) {
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > sharedLockStale {
+			os.Remove(path)
+			continue
+		}
+		if err := sleepContext(ctx, sharedLockPoll); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package paperless
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithSharedRateLimit(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		starts []time.Time
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	// Two clients stand in for two processes sharing the state file
+	path := filepath.Join(t.TempDir(), "rate", "state")
+	clients := []*Client{
+		NewClient(server.URL, "test-token", WithSharedRateLimit(path, 50)),
+		NewClient(server.URL, "test-token", WithSharedRateLimit(path, 50)),
+	}
+	var wg sync.WaitGroup
+	for i := 1; i <= 4; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			if _, err := clients[id%2].GetDocument(context.Background(), id); err != nil {
+				t.Errorf("GetDocument failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(starts) != 4 {
+		t.Fatalf("server received %d requests, want 4", len(starts))
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	if spread := starts[3].Sub(starts[0]); spread < 55*time.Millisecond {
+		t.Errorf("4 requests spread over %v, want at least 60ms", spread)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+}
+
+func TestWithSharedRateLimitIsSoft(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	// The state file can't be created below a regular file
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	c := NewClient(server.URL, "test-token", WithLogger(logger), WithSharedRateLimit(filepath.Join(blocker, "state"), 1))
+	if _, err := c.GetDocument(context.Background(), 1); err != nil {
+		t.Fatalf("GetDocument failed: %v", err)
+	}
+	if !strings.Contains(logs.String(), "shared rate limit unavailable") {
+		t.Errorf("expected a warning, logs: %s", logs.String())
+	}
+}
+
+func TestDefaultRateLimitPath(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	a, err := DefaultRateLimitPath("https://paperless.example.com/")
+	if err != nil {
+		t.Fatalf("DefaultRateLimitPath failed: %v", err)
+	}
+	b, _ := DefaultRateLimitPath("https://paperless.example.com")
+	c, _ := DefaultRateLimitPath("https://other.example.com")
+	if a != b || a == c || !strings.Contains(a, filepath.Join("paperless-go", "ratelimit")) {
+		t.Errorf("paths = %s, %s, %s", a, b, c)
+	}
+}
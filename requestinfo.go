@@ -0,0 +1,52 @@
+package paperless
+
+import "context"
+
+// Resource names reported in RequestInfo.
+const (
+	ResourceDocuments      = "documents"
+	ResourceTags           = "tags"
+	ResourceCorrespondents = "correspondents"
+	ResourceDocumentTypes  = "document_types"
+	ResourceStoragePaths   = "storage_paths"
+	ResourceTasks          = "tasks"
+	ResourceCustomFields   = "custom_fields"
+	ResourceUsers          = "users"
+	ResourceGroups         = "groups"
+	ResourceSavedViews     = "saved_views"
+	ResourceToken          = "token"
+)
+
+// RequestInfo describes the client call that issued a request. It is
+// attached to the request context, so hooks can read it with
+// RequestInfoFromContext(req.Context()) to label logs, metrics or traces
+// without parsing URLs.
+type RequestInfo struct {
+	Operation string // Client method, e.g. "ListDocuments"
+	Resource  string // API resource, e.g. "documents"
+}
+
+type requestInfoKey struct{}
+
+// withOperation records the client operation on the request context.
+// An operation that is already set is kept, so convenience wrappers such as
+// RenameDocument are reported under their own name.
+func withOperation(ctx context.Context, op, resource string) context.Context {
+	if _, ok := RequestInfoFromContext(ctx); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, requestInfoKey{}, RequestInfo{Operation: op, Resource: resource})
+}
+
+// RequestInfoFromContext returns the RequestInfo of a request made by a
+// Client. It reports false for contexts not created by the client.
+func RequestInfoFromContext(ctx context.Context) (RequestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info, ok
+}
+
+// operationFromContext returns the operation name set by withOperation.
+func operationFromContext(ctx context.Context) string {
+	info, _ := RequestInfoFromContext(ctx)
+	return info.Operation
+}
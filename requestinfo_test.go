@@ -0,0 +1,53 @@
+package paperless
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestInfo_InHooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Document{ID: 1})
+	}))
+	defer server.Close()
+
+	var got []RequestInfo
+	c := NewClient(server.URL, "test-token", WithRequestHook(func(req *http.Request) error {
+		info, ok := RequestInfoFromContext(req.Context())
+		if !ok {
+			t.Error("request context has no RequestInfo")
+		}
+		got = append(got, info)
+		return nil
+	}))
+
+	if _, err := c.GetTag(context.Background(), 1); err != nil {
+		t.Fatalf("GetTag failed: %v", err)
+	}
+	if _, err := c.RenameDocument(context.Background(), 1, "New title"); err != nil {
+		t.Fatalf("RenameDocument failed: %v", err)
+	}
+
+	want := []RequestInfo{
+		{Operation: "GetTag", Resource: ResourceTags},
+		{Operation: "RenameDocument", Resource: ResourceDocuments},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d requests, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("request %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRequestInfoFromContext_Missing(t *testing.T) {
+	if info, ok := RequestInfoFromContext(context.Background()); ok {
+		t.Errorf("RequestInfoFromContext = %+v, true; want false", info)
+	}
+}
@@ -0,0 +1,193 @@
+package paperless
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// IdempotencyKeyHeader is the header set on write requests by
+// WithIdempotencyKeys.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// retryBaseDelay is the wait before the first retry; it doubles on each
+// further attempt up to retryMaxDelay.
+var (
+	retryBaseDelay = 200 * time.Millisecond
+	retryMaxDelay  = 5 * time.Second
+)
+
+// WithRetries retries failed requests up to n times with exponential
+// backoff. Reads (GET, HEAD, OPTIONS) are retried on transport errors and on
+// 429, 502, 503 and 504 responses. Writes are retried only when it is safe:
+// on 429, or when the connection could not be established so the server
+// never saw the request. With WithIdempotencyKeys, writes are retried like
+// reads.
+func WithRetries(n int) Option {
+	return func(client *Client) {
+		client.maxRetries = n
+	}
+}
+
+// WithIdempotencyKeys sends a random Idempotency-Key header with every write
+// request, reused across its retries. Enable it only when the server or a
+// proxy in front of it deduplicates requests by this header; it lets
+// WithRetries retry writes after the request may have been received.
+func WithIdempotencyKeys() Option {
+	return func(client *Client) {
+		client.idempotencyKeys = true
+	}
+}
+
+// WithRetryBackoff multiplies the delays between retries, 200ms doubling
+// up to 5s by default, by multiplier. A multiplier above 1 gives an
+// overloaded server more time to recover.
+func WithRetryBackoff(multiplier float64) Option {
+	return func(client *Client) {
+		client.backoffMultiplier = multiplier
+	}
+}
+
+// WithRequestInterval spaces the requests of the client, including retries,
+// at least d apart, however many goroutines share it. It keeps bulk work
+// such as exports from crowding out other users of the server.
+func WithRequestInterval(d time.Duration) Option {
+	return func(client *Client) {
+		client.requestInterval = d
+	}
+}
+
+// pace waits until the next request may start under WithRequestInterval
+// and WithSharedRateLimit.
+func (c *Client) pace(ctx context.Context) error {
+	if c.requestInterval <= 0 {
+		return c.paceShared(ctx)
+	}
+	c.paceMu.Lock()
+	start := time.Now()
+	if c.nextRequest.After(start) {
+		start = c.nextRequest
+	}
+	c.nextRequest = start.Add(c.requestInterval)
+	c.paceMu.Unlock()
+	if err := sleepContext(ctx, time.Until(start)); err != nil {
+		return err
+	}
+	return c.paceShared(ctx)
+}
+
+// setIdempotencyKey adds an idempotency key to write requests if enabled.
+func (c *Client) setIdempotencyKey(req *http.Request) error {
+	if !c.idempotencyKeys || isSafeMethod(req.Method) {
+		return nil
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return err
+	}
+	req.Header.Set(IdempotencyKeyHeader, hex.EncodeToString(b[:]))
+	return nil
+}
+
+// send performs req, retrying as configured by WithRetries.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if err := c.checkPinning(req.URL); err != nil {
+		return nil, err
+	}
+	for attempt := 0; ; attempt++ {
+		if err := c.pace(req.Context()); err != nil {
+			return nil, err
+		}
+		resp, err := c.httpClient.Do(req)
+		if attempt >= c.maxRetries || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+		}
+		if c.logger != nil {
+			c.logger.LogAttrs(req.Context(), slog.LevelDebug, "paperless retry",
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.Int("attempt", attempt+1),
+			)
+		}
+		delay := retryDelay(attempt)
+		if c.backoffMultiplier > 0 {
+			delay = time.Duration(float64(delay) * c.backoffMultiplier)
+		}
+		if err := sleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// shouldRetry reports whether a request that got resp or err may be sent
+// again without risking a duplicate write.
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	repeatable := isSafeMethod(req.Method) || req.Header.Get(IdempotencyKeyHeader) != ""
+	if err != nil {
+		if errors.Is(err, ErrCertificateNotPinned) {
+			return false
+		}
+		return repeatable || isDialError(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return repeatable
+	}
+	return false
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// isDialError reports whether err occurred while connecting, before any part
+// of the request was sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func retryDelay(attempt int) time.Duration {
+	d := retryBaseDelay << attempt
+	if d > retryMaxDelay || d <= 0 {
+		return retryMaxDelay
+	}
+	return d
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
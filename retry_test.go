@@ -0,0 +1,268 @@
+package paperless
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func fastRetries(t *testing.T) {
+	t.Helper()
+	base, max := retryBaseDelay, retryMaxDelay
+	retryBaseDelay, retryMaxDelay = time.Millisecond, time.Millisecond
+	t.Cleanup(func() { retryBaseDelay, retryMaxDelay = base, max })
+}
+
+// flakyServer fails the first failures requests with status, then returns
+// an empty JSON object. It records the request bodies and idempotency keys.
+type flakyServer struct {
+	mu       sync.Mutex
+	failures int
+	status   int
+	bodies   []string
+	keys     []string
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bodies = append(s.bodies, string(body))
+	s.keys = append(s.keys, r.Header.Get(IdempotencyKeyHeader))
+	if len(s.bodies) <= s.failures {
+		w.WriteHeader(s.status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{}`))
+}
+
+func TestWithRetries_GetRetriedOnUnavailable(t *testing.T) {
+	fastRetries(t)
+	fs := &flakyServer{failures: 2, status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(fs)
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token", WithRetries(3))
+	if _, err := c.GetDocument(context.Background(), 1); err != nil {
+		t.Fatalf("GetDocument failed: %v", err)
+	}
+	if len(fs.bodies) != 3 {
+		t.Errorf("server received %d requests, want 3", len(fs.bodies))
+	}
+}
+
+func TestWithRetries_GivesUp(t *testing.T) {
+	fastRetries(t)
+	fs := &flakyServer{failures: 10, status: http.StatusBadGateway}
+	server := httptest.NewServer(fs)
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token", WithRetries(2))
+	_, err := c.GetDocument(context.Background(), 1)
+	if apiErr, ok := err.(*Error); !ok || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected 502 error, got %v", err)
+	}
+	if len(fs.bodies) != 3 {
+		t.Errorf("server received %d requests, want 3", len(fs.bodies))
+	}
+}
+
+func TestWithRetries_WriteNotRetriedAfterSend(t *testing.T) {
+	fastRetries(t)
+	fs := &flakyServer{failures: 1, status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(fs)
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token", WithRetries(3))
+	if _, err := c.RenameDocument(context.Background(), 1, "New"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if len(fs.bodies) != 1 {
+		t.Errorf("server received %d requests, want 1", len(fs.bodies))
+	}
+}
+
+func TestWithRetries_WriteRetriedOnRateLimit(t *testing.T) {
+	fastRetries(t)
+	fs := &flakyServer{failures: 1, status: http.StatusTooManyRequests}
+	server := httptest.NewServer(fs)
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token", WithRetries(3))
+	if err := c.DeleteDocument(context.Background(), 1); err != nil {
+		t.Fatalf("DeleteDocument failed: %v", err)
+	}
+	if len(fs.bodies) != 2 {
+		t.Errorf("server received %d requests, want 2", len(fs.bodies))
+	}
+}
+
+func TestWithIdempotencyKeys(t *testing.T) {
+	fastRetries(t)
+	fs := &flakyServer{failures: 1, status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(fs)
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token", WithRetries(3), WithIdempotencyKeys())
+	if _, err := c.RenameDocument(context.Background(), 1, "New"); err != nil {
+		t.Fatalf("RenameDocument failed: %v", err)
+	}
+	if _, err := c.RenameDocument(context.Background(), 1, "Newer"); err != nil {
+		t.Fatalf("RenameDocument failed: %v", err)
+	}
+
+	if len(fs.bodies) != 3 {
+		t.Fatalf("server received %d requests, want 3", len(fs.bodies))
+	}
+	if fs.bodies[0] != fs.bodies[1] || !strings.Contains(fs.bodies[1], `"New"`) {
+		t.Errorf("retried body = %q, want %q", fs.bodies[1], fs.bodies[0])
+	}
+	if fs.keys[0] == "" || fs.keys[0] != fs.keys[1] {
+		t.Errorf("keys = %q, want the same non-empty key for both attempts", fs.keys[:2])
+	}
+	if fs.keys[2] == fs.keys[0] {
+		t.Error("a new call reused the previous idempotency key")
+	}
+
+	if _, err := c.GetDocument(context.Background(), 1); err != nil {
+		t.Fatalf("GetDocument failed: %v", err)
+	}
+	if key := fs.keys[len(fs.keys)-1]; key != "" {
+		t.Errorf("GET sent idempotency key %q", key)
+	}
+}
+
+func TestWithRetries_TransportErrors(t *testing.T) {
+	fastRetries(t)
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	readErr := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+
+	tests := []struct {
+		name      string
+		err       error
+		opts      []Option
+		wantCalls int
+	}{
+		{"dial error", dialErr, nil, 2},
+		{"error after send", readErr, nil, 1},
+		{"error after send with key", readErr, []Option{WithIdempotencyKeys()}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				calls++
+				if calls == 1 {
+					return nil, tt.err
+				}
+				return &http.Response{
+					StatusCode: http.StatusNoContent,
+					Body:       io.NopCloser(strings.NewReader("")),
+					Header:     http.Header{},
+					Request:    req,
+				}, nil
+			})
+			opts := append([]Option{WithHTTPClient(&http.Client{Transport: transport}), WithRetries(2)}, tt.opts...)
+			c := NewClient("http://paperless.invalid", "test-token", opts...)
+
+			_ = c.DeleteDocument(context.Background(), 1)
+			if calls != tt.wantCalls {
+				t.Errorf("transport called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWithRetries_Disabled(t *testing.T) {
+	fs := &flakyServer{failures: 1, status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(fs)
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	if _, err := c.GetDocument(context.Background(), 1); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if len(fs.bodies) != 1 {
+		t.Errorf("server received %d requests, want 1", len(fs.bodies))
+	}
+}
+
+func TestWithRetryBackoff(t *testing.T) {
+	base, max := retryBaseDelay, retryMaxDelay
+	retryBaseDelay, retryMaxDelay = 10*time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() { retryBaseDelay, retryMaxDelay = base, max })
+
+	fs := &flakyServer{failures: 1, status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(fs)
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token", WithRetries(1), WithRetryBackoff(5))
+	start := time.Now()
+	if _, err := c.GetDocument(context.Background(), 1); err != nil {
+		t.Fatalf("GetDocument failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("retry after %v, want at least 50ms (10ms x 5)", elapsed)
+	}
+}
+
+func TestWithRequestInterval(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		starts []time.Time
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token", WithRequestInterval(20*time.Millisecond))
+	var wg sync.WaitGroup
+	for i := 1; i <= 4; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			if _, err := c.GetDocument(context.Background(), id); err != nil {
+				t.Errorf("GetDocument failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(starts) != 4 {
+		t.Fatalf("server received %d requests, want 4", len(starts))
+	}
+	// Concurrent requests are spread over at least 3 intervals
+	first, last := starts[0], starts[0]
+	for _, s := range starts {
+		if s.Before(first) {
+			first = s
+		}
+		if s.After(last) {
+			last = s
+		}
+	}
+	if spread := last.Sub(first); spread < 55*time.Millisecond {
+		t.Errorf("4 requests spread over %v, want at least 60ms", spread)
+	}
+}
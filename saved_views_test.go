@@ -0,0 +1,102 @@
+package paperless
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_ListSavedViews(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/saved_views/" {
+			t.Errorf("path = %v, want /api/saved_views/", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"count": 1, "next": null, "previous": null, "results": [
+			{"id": 1, "name": "Inbox", "show_on_dashboard": true, "show_in_sidebar": false,
+			 "sort_field": "created", "sort_reverse": true,
+			 "filter_rules": [{"rule_type": 6, "value": "3"}, {"rule_type": 3, "value": null}]}
+		]}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	list, err := c.ListSavedViews(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListSavedViews failed: %v", err)
+	}
+	if len(list.Results) != 1 {
+		t.Fatalf("len(results) = %d, want 1", len(list.Results))
+	}
+	view := list.Results[0]
+	if view.Name != "Inbox" || !view.ShowOnDashboard || !view.SortReverse || len(view.FilterRules) != 2 {
+		t.Errorf("view = %+v", view)
+	}
+	if rule := view.FilterRules[0]; rule.RuleType != 6 || rule.Value == nil || *rule.Value != "3" {
+		t.Errorf("rule 0 = %+v", rule)
+	}
+	if view.FilterRules[1].Value != nil {
+		t.Errorf("rule 1 value = %v, want nil", *view.FilterRules[1].Value)
+	}
+}
+
+func TestClient_GetSavedView(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/saved_views/2/" {
+			t.Errorf("path = %v, want /api/saved_views/2/", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(SavedView{ID: 2, Name: "Taxes"})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	view, err := c.GetSavedView(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("GetSavedView failed: %v", err)
+	}
+	if view.Name != "Taxes" {
+		t.Errorf("name = %v, want Taxes", view.Name)
+	}
+}
+
+func TestSavedView_DocumentQuery(t *testing.T) {
+	view := SavedView{
+		Name:        "Taxes",
+		SortField:   "created",
+		SortReverse: true,
+		FilterRules: []SavedViewFilterRule{
+			{RuleType: 6, Value: Ptr("1")},
+			{RuleType: 6, Value: Ptr("2")},
+			{RuleType: 0, Value: Ptr("invoice")},
+			{RuleType: 9, Value: Ptr("2024-01-01")},
+			{RuleType: 3, Value: nil},
+		},
+	}
+	q, err := view.DocumentQuery()
+	if err != nil {
+		t.Fatalf("DocumentQuery failed: %v", err)
+	}
+	want := map[string]string{
+		ParamTagIDsAll:          "1,2",
+		ParamTitleContains:      "invoice",
+		ParamCreatedAfter:       "2024-01-01",
+		"correspondent__isnull": "1",
+		ParamOrdering:           "-created",
+	}
+	for param, value := range want {
+		if got := q.Get(param); got != value {
+			t.Errorf("%s = %q, want %q", param, got, value)
+		}
+	}
+	if len(q) != len(want) {
+		t.Errorf("query = %v, want %d parameters", q, len(want))
+	}
+
+	view.FilterRules = append(view.FilterRules, SavedViewFilterRule{RuleType: 999, Value: Ptr("x")})
+	if _, err := view.DocumentQuery(); err == nil {
+		t.Error("DocumentQuery with an unknown rule type succeeded")
+	}
+}
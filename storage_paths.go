@@ -0,0 +1,35 @@
+package paperless
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListStoragePaths retrieves storage paths.
+func (c *Client) ListStoragePaths(ctx context.Context, opts *ListOptions) (*StoragePathList, error) {
+	ctx = withOperation(ctx, "ListStoragePaths", ResourceStoragePaths)
+	fullURL, err := c.buildURL(storagePathsAPIPath, opts)
+	if err != nil {
+		return nil, fmt.Errorf("build URL: %w", err)
+	}
+
+	var result StoragePathList
+	if err := c.doRequestWithURL(ctx, "GET", fullURL, nil, &result); err != nil {
+		return nil, wrapError(err, "ListStoragePaths")
+	}
+
+	return &result, nil
+}
+
+// GetStoragePath retrieves a single storage path by ID.
+func (c *Client) GetStoragePath(ctx context.Context, id int) (*StoragePath, error) {
+	ctx = withOperation(ctx, "GetStoragePath", ResourceStoragePaths)
+	path := fmt.Sprintf("%s%d/", storagePathsAPIPath, id)
+
+	var result StoragePath
+	if err := c.doRequest(ctx, "GET", path, nil, &result); err != nil {
+		return nil, wrapError(err, "GetStoragePath")
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,54 @@
+package paperless
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_ListStoragePaths(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/storage_paths/" {
+			t.Errorf("path = %v, want /api/storage_paths/", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"count": 1, "next": null, "previous": null, "results": [
+			{"id": 1, "name": "By year", "slug": "by-year", "path": "{created_year}/{title}", "document_count": 4}
+		]}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	list, err := c.ListStoragePaths(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListStoragePaths failed: %v", err)
+	}
+	if len(list.Results) != 1 {
+		t.Fatalf("len(results) = %d, want 1", len(list.Results))
+	}
+	if got := list.Results[0]; got.Path != "{created_year}/{title}" || got.DocumentCount != 4 {
+		t.Errorf("storage path = %+v", got)
+	}
+}
+
+func TestClient_GetStoragePath(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/storage_paths/1/" {
+			t.Errorf("path = %v, want /api/storage_paths/1/", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(StoragePath{ID: 1, Name: "By year"})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	sp, err := c.GetStoragePath(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetStoragePath failed: %v", err)
+	}
+	if sp.Name != "By year" {
+		t.Errorf("name = %v, want By year", sp.Name)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 // ListTags retrieves all tags.
 func (c *Client) ListTags(ctx context.Context, opts *ListOptions) (*TagList, error) {
+	ctx = withOperation(ctx, "ListTags", ResourceTags)
 	fullURL, err := c.buildURL(tagsAPIPath, opts)
 	if err != nil {
 		return nil, fmt.Errorf("build URL: %w", err)
@@ -22,7 +23,8 @@ func (c *Client) ListTags(ctx context.Context, opts *ListOptions) (*TagList, err
 
 // GetTag retrieves a single tag by ID.
 func (c *Client) GetTag(ctx context.Context, id int) (*Tag, error) {
-	path := fmt.Sprintf("/api/tags/%d/", id)
+	ctx = withOperation(ctx, "GetTag", ResourceTags)
+	path := fmt.Sprintf("%s%d/", tagsAPIPath, id)
 
 	var result Tag
 	if err := c.doRequest(ctx, "GET", path, nil, &result); err != nil {
@@ -34,6 +36,7 @@ func (c *Client) GetTag(ctx context.Context, id int) (*Tag, error) {
 
 // CreateTag creates a new tag.
 func (c *Client) CreateTag(ctx context.Context, tag *TagCreate) (*Tag, error) {
+	ctx = withOperation(ctx, "CreateTag", ResourceTags)
 	var result Tag
 	if err := c.doRequest(ctx, "POST", "/api/tags/", tag, &result); err != nil {
 		return nil, wrapError(err, "CreateTag")
@@ -41,3 +44,13 @@ func (c *Client) CreateTag(ctx context.Context, tag *TagCreate) (*Tag, error) {
 
 	return &result, nil
 }
+
+// DeleteTag deletes a tag. Documents keep existing and lose the tag.
+func (c *Client) DeleteTag(ctx context.Context, id int) error {
+	ctx = withOperation(ctx, "DeleteTag", ResourceTags)
+	path := fmt.Sprintf("%s%d/", tagsAPIPath, id)
+	if err := c.doRequest(ctx, "DELETE", path, nil, nil); err != nil {
+		return wrapError(err, "DeleteTag")
+	}
+	return nil
+}
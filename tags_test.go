@@ -185,3 +185,21 @@ func TestClient_GetTag(t *testing.T) {
 		}
 	})
 }
+
+func TestClient_DeleteTag(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" {
+			t.Errorf("method = %v, want DELETE", r.Method)
+		}
+		if r.URL.Path != "/api/tags/3/" {
+			t.Errorf("path = %v, want /api/tags/3/", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	if err := c.DeleteTag(context.Background(), 3); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+}
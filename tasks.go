@@ -0,0 +1,34 @@
+package paperless
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// GetTask retrieves a task by its task ID, such as the one returned by
+// UploadDocument. It returns an error matching ErrNotFound if Paperless has
+// no such task.
+func (c *Client) GetTask(ctx context.Context, taskID string) (*Task, error) {
+	ctx = withOperation(ctx, "GetTask", ResourceTasks)
+	if taskID == "" {
+		return nil, fmt.Errorf("GetTask: task ID cannot be empty")
+	}
+
+	fullURL, err := c.buildURL(tasksAPIPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build URL: %w", err)
+	}
+	fullURL += "?" + url.Values{"task_id": {taskID}}.Encode()
+
+	var result []Task
+	if err := c.doRequestWithURL(ctx, "GET", fullURL, nil, &result); err != nil {
+		return nil, wrapError(err, "GetTask")
+	}
+	if len(result) == 0 {
+		return nil, &Error{StatusCode: http.StatusNotFound, Message: "task not found", Op: "GetTask"}
+	}
+
+	return &result[0], nil
+}
@@ -0,0 +1,81 @@
+package paperless
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_GetTask(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/tasks/" {
+				t.Errorf("path = %v, want /api/tasks/", r.URL.Path)
+			}
+			if got := r.URL.Query().Get("task_id"); got != "abc-123" {
+				t.Errorf("task_id = %v, want abc-123", got)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[{
+				"id": 7,
+				"task_id": "abc-123",
+				"task_file_name": "scan.pdf",
+				"date_created": "2024-03-01T09:12:50Z",
+				"date_done": "2024-03-01T09:13:04Z",
+				"type": "file",
+				"status": "SUCCESS",
+				"result": "Success. New document id 42 created",
+				"acknowledged": false,
+				"related_document": "42"
+			}]`))
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		task, err := c.GetTask(context.Background(), "abc-123")
+		if err != nil {
+			t.Fatalf("GetTask failed: %v", err)
+		}
+		if !task.Finished() || task.Status != TaskSuccess {
+			t.Errorf("status = %v, want finished %v", task.Status, TaskSuccess)
+		}
+		if task.RelatedDocument == nil || *task.RelatedDocument != 42 {
+			t.Errorf("related document = %v, want 42", task.RelatedDocument)
+		}
+		if task.TaskFileName != "scan.pdf" || task.Done == nil {
+			t.Errorf("task = %+v", task)
+		}
+	})
+
+	t.Run("pending with numeric fields", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[{"id": 8, "task_id": "def", "date_created": "2024-03-01T09:12:50Z", "date_done": null, "status": "STARTED", "result": null, "related_document": null}]`))
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		task, err := c.GetTask(context.Background(), "def")
+		if err != nil {
+			t.Fatalf("GetTask failed: %v", err)
+		}
+		if task.Finished() || task.RelatedDocument != nil || task.Done != nil {
+			t.Errorf("task = %+v, want unfinished without document", task)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[]`))
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		_, err := c.GetTask(context.Background(), "missing")
+		if !IsNotFound(err) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+}
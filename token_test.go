@@ -0,0 +1,46 @@
+package paperless
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_RequestToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/token/" {
+			t.Errorf("request = %s %s, want POST /api/token/", r.Method, r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("Authorization = %q, want none", auth)
+		}
+		var body tokenRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		if body != (tokenRequest{Username: "alice", Password: "secret"}) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"non_field_errors": ["Unable to log in with provided credentials."]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"token": "abc123"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "")
+	token, err := c.RequestToken(context.Background(), "alice", "secret")
+	if err != nil {
+		t.Fatalf("RequestToken failed: %v", err)
+	}
+	if token != "abc123" {
+		t.Errorf("token = %q, want abc123", token)
+	}
+
+	if _, err := c.RequestToken(context.Background(), "alice", "wrong"); !IsValidation(err) {
+		t.Errorf("error = %v, want a validation error", err)
+	}
+	if _, err := c.RequestToken(context.Background(), "alice", ""); err == nil {
+		t.Error("RequestToken without a password succeeded")
+	}
+}
@@ -0,0 +1,67 @@
+package paperless
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// Default connection pool settings for the client's transport. Bulk callers
+// such as indexers issue many requests to a single host, so more idle
+// connections are kept per host than net/http's default of two.
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 16
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
+// newTransport returns the transport used when no HTTP client is supplied.
+// It pools connections, sends TCP keep-alives and negotiates HTTP/2 when the
+// server supports it.
+func newTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          DefaultMaxIdleConns,
+		MaxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:       DefaultIdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// WithMaxIdleConnsPerHost sets how many idle connections to the Paperless
+// host are kept for reuse. It applies to the default transport and has no
+// effect on a client supplied with WithHTTPClient.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(client *Client) {
+		client.transport.MaxIdleConnsPerHost = n
+		if n > client.transport.MaxIdleConns && client.transport.MaxIdleConns != 0 {
+			client.transport.MaxIdleConns = n
+		}
+	}
+}
+
+// WithMaxConnsPerHost limits the total number of connections to the
+// Paperless host, including those in use; 0 means no limit. It applies to
+// the default transport and has no effect on a client supplied with
+// WithHTTPClient.
+func WithMaxConnsPerHost(n int) Option {
+	return func(client *Client) {
+		client.transport.MaxConnsPerHost = n
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle connection is kept before it is
+// closed; 0 means no limit. It applies to the default transport and has no
+// effect on a client supplied with WithHTTPClient.
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(client *Client) {
+		client.transport.IdleConnTimeout = d
+	}
+}
@@ -0,0 +1,91 @@
+package paperless
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewClient_DefaultTransport(t *testing.T) {
+	c := NewClient("http://localhost:8000", "test-token")
+
+	transport, ok := c.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Transport = %T, want *http.Transport", c.httpClient.Transport)
+	}
+	if !transport.ForceAttemptHTTP2 {
+		t.Error("ForceAttemptHTTP2 = false, want true")
+	}
+	if transport.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost {
+		t.Errorf("MaxIdleConnsPerHost = %d, want %d", transport.MaxIdleConnsPerHost, DefaultMaxIdleConnsPerHost)
+	}
+	if transport.IdleConnTimeout != DefaultIdleConnTimeout {
+		t.Errorf("IdleConnTimeout = %v, want %v", transport.IdleConnTimeout, DefaultIdleConnTimeout)
+	}
+}
+
+func TestTransportOptions(t *testing.T) {
+	c := NewClient("http://localhost:8000", "test-token",
+		WithMaxIdleConnsPerHost(200),
+		WithMaxConnsPerHost(8),
+		WithIdleConnTimeout(time.Minute),
+	)
+
+	transport := c.httpClient.Transport.(*http.Transport)
+	if transport.MaxIdleConnsPerHost != 200 {
+		t.Errorf("MaxIdleConnsPerHost = %d, want 200", transport.MaxIdleConnsPerHost)
+	}
+	if transport.MaxIdleConns != 200 {
+		t.Errorf("MaxIdleConns = %d, want 200", transport.MaxIdleConns)
+	}
+	if transport.MaxConnsPerHost != 8 {
+		t.Errorf("MaxConnsPerHost = %d, want 8", transport.MaxConnsPerHost)
+	}
+	if transport.IdleConnTimeout != time.Minute {
+		t.Errorf("IdleConnTimeout = %v, want 1m", transport.IdleConnTimeout)
+	}
+}
+
+func TestTransportOptions_CustomHTTPClient(t *testing.T) {
+	custom := &http.Transport{MaxIdleConnsPerHost: 1}
+	c := NewClient("http://localhost:8000", "test-token",
+		WithMaxIdleConnsPerHost(50),
+		WithHTTPClient(&http.Client{Transport: custom}),
+	)
+
+	if c.httpClient.Transport != custom {
+		t.Fatal("custom transport was replaced")
+	}
+	if custom.MaxIdleConnsPerHost != 1 {
+		t.Errorf("custom MaxIdleConnsPerHost = %d, want 1", custom.MaxIdleConnsPerHost)
+	}
+}
+
+func TestDefaultTransport_ReusesConnections(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		addrs = map[string]bool{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		addrs[r.RemoteAddr] = true
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Tag{ID: 1})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	for i := 0; i < 5; i++ {
+		if _, err := c.GetTag(context.Background(), 1); err != nil {
+			t.Fatalf("GetTag failed: %v", err)
+		}
+	}
+	if len(addrs) != 1 {
+		t.Errorf("requests used %d connections, want 1", len(addrs))
+	}
+}
@@ -1,7 +1,10 @@
 package paperless
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -40,9 +43,25 @@ func (d *Date) UnmarshalJSON(data []byte) error {
 	return fmt.Errorf("unable to parse date: %s", str)
 }
 
-// MarshalJSON implements json.Marshaler
+// MarshalJSON implements json.Marshaler. Dates without a time of day (UTC
+// midnight, as produced by parsing a date-only value) are written as
+// yyyy-mm-dd; anything else is written as RFC3339 with its full precision
+// and offset, so timestamps such as Modified survive a round trip.
 func (d Date) MarshalJSON() ([]byte, error) {
-	return []byte(`"` + time.Time(d).Format("2006-01-02") + `"`), nil
+	if d.IsDateOnly() {
+		return []byte(`"` + time.Time(d).Format("2006-01-02") + `"`), nil
+	}
+	return []byte(`"` + time.Time(d).Format(time.RFC3339Nano) + `"`), nil
+}
+
+// IsDateOnly reports whether d carries no time of day, that is, whether it is
+// midnight UTC.
+func (d Date) IsDateOnly() bool {
+	t := time.Time(d)
+	if t.Location() != time.UTC {
+		return false
+	}
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
 }
 
 // Time returns the underlying time.Time
@@ -57,15 +76,109 @@ func (d Date) String() string {
 
 // Document represents a Paperless-ngx document.
 type Document struct {
-	ID                  int    `json:"id"`
-	Title               string `json:"title"`
-	Content             string `json:"content"`
-	Created             Date   `json:"created"`
-	Modified            Date   `json:"modified"`
-	Added               Date   `json:"added"`
-	ArchiveSerialNumber *int   `json:"archive_serial_number"`
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	// Created is a timestamp before API version 9 and a date since.
+	// CreatedDay returns the date for either.
+	Created Date `json:"created"`
+	// CreatedDate is the date-only twin of Created sent by servers before
+	// API version 9. It is deprecated since.
+	CreatedDate *Date `json:"created_date,omitempty"`
+	Modified    Date  `json:"modified"`
+	Added       Date  `json:"added"`
+	// ArchiveSerialNumber is int64 because ASNs go up to 2^32-1, which
+	// overflows int on 32-bit platforms.
+	ArchiveSerialNumber *int64 `json:"archive_serial_number"`
 	OriginalFileName    string `json:"original_file_name"`
 	Tags                []int  `json:"tags"`
+	Correspondent       *int   `json:"correspondent"`
+	DocumentType        *int   `json:"document_type"`
+	StoragePath         *int   `json:"storage_path"`
+	Owner               *int   `json:"owner"`
+	Notes               []Note `json:"notes"`
+	PageCount           *int   `json:"page_count"`
+	// ArchivedFileName is empty if Paperless did not create an archive
+	// version (OCRed PDF) of the document.
+	ArchivedFileName string                `json:"archived_file_name"`
+	CustomFields     []CustomFieldInstance `json:"custom_fields"`
+	// SearchHit is set only on results of a full-text search (ListOptions.Query).
+	SearchHit *SearchHit `json:"__search_hit__,omitempty"`
+}
+
+// CreatedDay returns the date the document was created, on servers of any
+// version: from Created, in the time zone the server sent it in, or from
+// CreatedDate if the server sent only that. The result is midnight UTC.
+func (d *Document) CreatedDay() Date {
+	t := d.Created.Time()
+	if t.IsZero() && d.CreatedDate != nil {
+		t = d.CreatedDate.Time()
+	}
+	if t.IsZero() {
+		return Date{}
+	}
+	y, m, day := t.Date()
+	return Date(time.Date(y, m, day, 0, 0, 0, 0, time.UTC))
+}
+
+// SearchHit describes how a document matched a full-text search. Highlights
+// and NoteHighlights are HTML snippets with matched terms wrapped in
+// <span class="match"> elements.
+type SearchHit struct {
+	Score          float64 `json:"score"`
+	Highlights     string  `json:"highlights"`
+	NoteHighlights string  `json:"note_highlights"`
+	Rank           int     `json:"rank"`
+}
+
+// DocumentMetadata describes the stored files of a document.
+type DocumentMetadata struct {
+	OriginalChecksum string `json:"original_checksum"` // MD5 of the original file
+	OriginalSize     int64  `json:"original_size"`
+	OriginalMimeType string `json:"original_mime_type"`
+	OriginalFilename string `json:"original_filename"`
+	MediaFilename    string `json:"media_filename"`
+	// HasArchiveVersion reports whether there is an archived (OCRed PDF)
+	// file; the Archive fields are empty if not.
+	HasArchiveVersion    bool   `json:"has_archive_version"`
+	ArchiveChecksum      string `json:"archive_checksum"`
+	ArchiveSize          int64  `json:"archive_size"`
+	ArchiveMediaFilename string `json:"archive_media_filename"`
+	Lang                 string `json:"lang"` // Detected language of the content
+}
+
+// Note represents a note attached to a document.
+type Note struct {
+	ID      int       `json:"id"`
+	Note    string    `json:"note"`
+	Created Date      `json:"created"`
+	User    *NoteUser `json:"user"`
+}
+
+// NoteUser identifies the author of a note. Older Paperless versions return
+// only the user ID; newer versions include the username and name.
+type NoteUser struct {
+	ID        int    `json:"id"`
+	Username  string `json:"username,omitempty"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler for both a bare user ID and a
+// user object.
+func (u *NoteUser) UnmarshalJSON(data []byte) error {
+	var id int
+	if err := json.Unmarshal(data, &id); err == nil {
+		*u = NoteUser{ID: id}
+		return nil
+	}
+	type noteUser NoteUser
+	var full noteUser
+	if err := json.Unmarshal(data, &full); err != nil {
+		return fmt.Errorf("unable to parse note user: %w", err)
+	}
+	*u = NoteUser(full)
+	return nil
 }
 
 // Tag represents a Paperless-ngx tag.
@@ -77,6 +190,198 @@ type Tag struct {
 	DocumentCount int    `json:"document_count"`
 }
 
+// Correspondent represents a Paperless-ngx correspondent.
+type Correspondent struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Slug          string `json:"slug"`
+	DocumentCount int    `json:"document_count"`
+}
+
+// DocumentType represents a Paperless-ngx document type.
+type DocumentType struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Slug          string `json:"slug"`
+	DocumentCount int    `json:"document_count"`
+}
+
+// StoragePath represents a Paperless-ngx storage path. Path is the template
+// used to place files on disk, e.g. "{created_year}/{correspondent}/{title}".
+type StoragePath struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Slug          string `json:"slug"`
+	Path          string `json:"path"`
+	DocumentCount int    `json:"document_count"`
+}
+
+// CustomField is a custom field definition. DataType is e.g. "string",
+// "date", "monetary" or "documentlink".
+type CustomField struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	DataType string `json:"data_type"`
+}
+
+// CustomFieldInstance is the value of a custom field on a document. The
+// type of Value depends on the field's data type, as decoded by
+// encoding/json.
+type CustomFieldInstance struct {
+	Field int         `json:"field"`
+	Value interface{} `json:"value"`
+}
+
+// User represents a Paperless-ngx user.
+type User struct {
+	ID          int    `json:"id"`
+	Username    string `json:"username"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	IsSuperuser bool   `json:"is_superuser"`
+}
+
+// Group represents a Paperless-ngx user group.
+type Group struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// SavedView is a document view saved in the Paperless web UI. Its filter
+// rules are the view's query; SavedView.DocumentQuery turns them into list
+// parameters.
+type SavedView struct {
+	ID              int                   `json:"id"`
+	Name            string                `json:"name"`
+	ShowOnDashboard bool                  `json:"show_on_dashboard"`
+	ShowInSidebar   bool                  `json:"show_in_sidebar"`
+	SortField       string                `json:"sort_field"`
+	SortReverse     bool                  `json:"sort_reverse"`
+	FilterRules     []SavedViewFilterRule `json:"filter_rules"`
+	PageSize        int                   `json:"page_size,omitempty"`
+}
+
+// SavedViewFilterRule is one rule of a saved view. RuleType is the filter
+// rule type number of the Paperless web UI; a nil Value means "none", e.g.
+// documents without a correspondent.
+type SavedViewFilterRule struct {
+	RuleType int     `json:"rule_type"`
+	Value    *string `json:"value"`
+}
+
+// PrincipalKind is whether a Principal is a user or a group.
+type PrincipalKind string
+
+// Kinds of principals.
+const (
+	PrincipalUser  PrincipalKind = "user"
+	PrincipalGroup PrincipalKind = "group"
+)
+
+// Principal is a user or group that permissions are granted to.
+type Principal struct {
+	Kind PrincipalKind
+	ID   int
+}
+
+// Permissions of the permissions object, as in "view" and "change".
+const (
+	PermissionView   = "view"
+	PermissionChange = "change"
+)
+
+// PermissionSet lists the users and groups granted a permission.
+type PermissionSet struct {
+	Users  []int `json:"users"`
+	Groups []int `json:"groups"`
+}
+
+// Permissions are the object-level permissions of a document. The owner
+// and superusers always have full access.
+type Permissions struct {
+	View   PermissionSet `json:"view"`
+	Change PermissionSet `json:"change"`
+}
+
+// DocumentPermissions is the owner and permissions of a document. A nil
+// Owner means the document has no owner and is visible to all users.
+type DocumentPermissions struct {
+	Owner       *int        `json:"owner"`
+	Permissions Permissions `json:"permissions"`
+}
+
+// SelectionCount is how many documents of a selection an object, such as a
+// tag, is applied to.
+type SelectionCount struct {
+	ID            int `json:"id"`
+	DocumentCount int `json:"document_count"`
+}
+
+// SelectionData summarizes the objects applied to a set of documents, as
+// returned by GetSelectionData. Objects applied to none of the documents may
+// be listed with a DocumentCount of 0.
+type SelectionData struct {
+	Correspondents []SelectionCount `json:"selected_correspondents"`
+	Tags           []SelectionCount `json:"selected_tags"`
+	DocumentTypes  []SelectionCount `json:"selected_document_types"`
+	StoragePaths   []SelectionCount `json:"selected_storage_paths"`
+	CustomFields   []SelectionCount `json:"selected_custom_fields"`
+}
+
+// Task statuses reported by Paperless-ngx.
+const (
+	TaskPending = "PENDING"
+	TaskStarted = "STARTED"
+	TaskSuccess = "SUCCESS"
+	TaskFailure = "FAILURE"
+)
+
+// Task represents a Paperless-ngx background task, such as the consumption
+// of an uploaded document. RelatedDocument is set once consumption created
+// a document.
+type Task struct {
+	ID              int    `json:"id"`
+	TaskID          string `json:"task_id"`
+	TaskFileName    string `json:"task_file_name"`
+	Created         Date   `json:"date_created"`
+	Done            *Date  `json:"date_done"`
+	Type            string `json:"type"`
+	Status          string `json:"status"`
+	Result          string `json:"result"`
+	Acknowledged    bool   `json:"acknowledged"`
+	RelatedDocument *int   `json:"related_document"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Paperless returns
+// related_document as a string in some versions and a number in others.
+func (t *Task) UnmarshalJSON(data []byte) error {
+	type task Task
+	var raw struct {
+		task
+		RelatedDocument json.RawMessage `json:"related_document"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*t = Task(raw.task)
+	t.RelatedDocument = nil
+
+	doc := strings.Trim(string(raw.RelatedDocument), `"`)
+	if doc != "" && doc != "null" {
+		id, err := strconv.Atoi(doc)
+		if err != nil {
+			return fmt.Errorf("unable to parse related document: %w", err)
+		}
+		t.RelatedDocument = &id
+	}
+	return nil
+}
+
+// Finished reports whether the task succeeded or failed.
+func (t *Task) Finished() bool {
+	return t.Status == TaskSuccess || t.Status == TaskFailure
+}
+
 // List is a paginated response.
 type List[T any] struct {
 	Count    int     `json:"count"`
@@ -91,6 +396,27 @@ type DocumentList = List[Document]
 // TagList is a paginated list of tags.
 type TagList = List[Tag]
 
+// CorrespondentList is a paginated list of correspondents.
+type CorrespondentList = List[Correspondent]
+
+// DocumentTypeList is a paginated list of document types.
+type DocumentTypeList = List[DocumentType]
+
+// StoragePathList is a paginated list of storage paths.
+type StoragePathList = List[StoragePath]
+
+// CustomFieldList is a paginated list of custom field definitions.
+type CustomFieldList = List[CustomField]
+
+// UserList is a paginated list of users.
+type UserList = List[User]
+
+// GroupList is a paginated list of groups.
+type GroupList = List[Group]
+
+// SavedViewList is a paginated list of saved views.
+type SavedViewList = List[SavedView]
+
 // ListOptions configures list operations.
 type ListOptions struct {
 	Page     int    // Page number (1-indexed), 0 means default
@@ -100,12 +426,47 @@ type ListOptions struct {
 	// TitleOnly searches only document titles when used with document listing/search.
 	// For other resources this option is ignored.
 	TitleOnly bool
+
+	// Document filters. They apply to document listing/search only and are
+	// ignored for other resources. Zero values mean no filter.
+	TagName          string    // Documents with a tag of this name (case-insensitive)
+	TagIDs           []int     // Documents having all of these tags
+	CorrespondentIDs []int     // Documents with any of these correspondents
+	DocumentTypeIDs  []int     // Documents with any of these document types
+	CreatedAfter     time.Time // Documents created after this date
+	CreatedBefore    time.Time // Documents created before this date
+	CreatedSince     time.Time // Documents created on or after this date
+	// ModifiedSince filters to documents modified at or after this time,
+	// for syncing only what changed since the last run.
+	ModifiedSince time.Time
+	// ArchiveSerialNumber filters to the document with this ASN.
+	ArchiveSerialNumber int64
+	// AfterID filters to documents with an ID greater than this one. With
+	// Ordering "id" it pages by cursor; see WithIDCursor.
+	AfterID int
+	// IDs filters to the documents with these IDs. IDs that don't exist
+	// are left out of the results.
+	IDs []int
 }
 
 // DocumentUpdate represents fields to update on a document.
+//
+// Every field is a pointer: nil fields are omitted from the PATCH body and
+// left unchanged on the server, while a non-nil pointer sends its value even
+// if it is the zero value. For example, Tags set to a pointer to an empty
+// slice removes all tags without touching the title. Use Ptr to take the
+// address of a literal.
 type DocumentUpdate struct {
-	Title *string `json:"title,omitempty"`
-	Tags  *[]int  `json:"tags,omitempty"`
+	Title               *string `json:"title,omitempty"`
+	Tags                *[]int  `json:"tags,omitempty"`
+	Created             *Date   `json:"created,omitempty"`
+	ArchiveSerialNumber *int64  `json:"archive_serial_number,omitempty"`
+}
+
+// Ptr returns a pointer to v. It is a convenience for populating optional
+// fields such as those of DocumentUpdate.
+func Ptr[T any](v T) *T {
+	return &v
 }
 
 // TagCreate represents fields to create a new tag.
@@ -114,3 +475,36 @@ type TagCreate struct {
 	Color string `json:"color,omitempty"`
 	Slug  string `json:"slug,omitempty"`
 }
+
+// MatchingAlgorithm is how Paperless-ngx matches the Match text of a
+// correspondent or document type against new documents.
+type MatchingAlgorithm int
+
+// Matching algorithms, as numbered by Paperless-ngx.
+const (
+	MatchNone    MatchingAlgorithm = 0 // Never assign automatically
+	MatchAny     MatchingAlgorithm = 1 // Any of the words
+	MatchAll     MatchingAlgorithm = 2 // All of the words
+	MatchLiteral MatchingAlgorithm = 3 // The exact text
+	MatchRegex   MatchingAlgorithm = 4 // A regular expression
+	MatchFuzzy   MatchingAlgorithm = 5 // Approximately the text
+	MatchAuto    MatchingAlgorithm = 6 // Learned from assigned documents
+)
+
+// CorrespondentCreate represents fields to create a new correspondent.
+// Nil fields use the server's defaults.
+type CorrespondentCreate struct {
+	Name              string             `json:"name"`
+	Match             string             `json:"match,omitempty"`
+	MatchingAlgorithm *MatchingAlgorithm `json:"matching_algorithm,omitempty"`
+	IsInsensitive     *bool              `json:"is_insensitive,omitempty"`
+}
+
+// DocumentTypeCreate represents fields to create a new document type.
+// Nil fields use the server's defaults.
+type DocumentTypeCreate struct {
+	Name              string             `json:"name"`
+	Match             string             `json:"match,omitempty"`
+	MatchingAlgorithm *MatchingAlgorithm `json:"matching_algorithm,omitempty"`
+	IsInsensitive     *bool              `json:"is_insensitive,omitempty"`
+}
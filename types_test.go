@@ -85,16 +85,71 @@ func TestDate_UnmarshalJSON(t *testing.T) {
 }
 
 func TestDate_MarshalJSON(t *testing.T) {
-	d := Date(time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC))
+	tests := []struct {
+		name     string
+		date     Date
+		expected string
+	}{
+		{
+			name:     "date only",
+			date:     Date(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)),
+			expected: `"2024-01-15"`,
+		},
+		{
+			name:     "timestamp",
+			date:     Date(time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)),
+			expected: `"2024-01-15T10:30:45Z"`,
+		},
+		{
+			name:     "fractional seconds",
+			date:     Date(time.Date(2024, 1, 15, 10, 30, 45, 123456000, time.UTC)),
+			expected: `"2024-01-15T10:30:45.123456Z"`,
+		},
+		{
+			name:     "midnight with offset",
+			date:     Date(time.Date(2024, 1, 15, 0, 0, 0, 0, time.FixedZone("", 2*60*60))),
+			expected: `"2024-01-15T00:00:00+02:00"`,
+		},
+	}
 
-	data, err := json.Marshal(d)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.date)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, string(data))
+			}
+		})
 	}
+}
 
-	expected := `"2024-01-15"`
-	if string(data) != expected {
-		t.Errorf("expected %s, got %s", expected, string(data))
+func TestDate_RoundTrip(t *testing.T) {
+	inputs := []string{
+		`"2024-01-15"`,
+		`"2024-01-15T10:30:45Z"`,
+		`"2024-01-15T10:30:45.123456+01:00"`,
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			var first Date
+			if err := json.Unmarshal([]byte(input), &first); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			data, err := json.Marshal(first)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var second Date
+			if err := json.Unmarshal(data, &second); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !first.Time().Equal(second.Time()) {
+				t.Errorf("round trip changed %v to %v (via %s)", first.Time(), second.Time(), data)
+			}
+		})
 	}
 }
 
@@ -118,3 +173,215 @@ func TestDate_String(t *testing.T) {
 		t.Errorf("expected %s, got %s", expected, actual)
 	}
 }
+
+func TestDocumentUpdate_MarshalJSON(t *testing.T) {
+	created := Date(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name   string
+		update DocumentUpdate
+		want   map[string]string
+	}{
+		{
+			name:   "empty update sends nothing",
+			update: DocumentUpdate{},
+			want:   map[string]string{},
+		},
+		{
+			name:   "tags only leaves title untouched",
+			update: DocumentUpdate{Tags: Ptr([]int{1, 2})},
+			want:   map[string]string{"tags": `[1,2]`},
+		},
+		{
+			name:   "empty tags are sent to clear tags",
+			update: DocumentUpdate{Tags: Ptr([]int{})},
+			want:   map[string]string{"tags": `[]`},
+		},
+		{
+			name:   "zero values are sent when set",
+			update: DocumentUpdate{Title: Ptr(""), ArchiveSerialNumber: Ptr[int64](0)},
+			want:   map[string]string{"title": `""`, "archive_serial_number": `0`},
+		},
+		{
+			name:   "created",
+			update: DocumentUpdate{Created: &created},
+			want:   map[string]string{"created": `"2024-01-15"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.update)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got map[string]json.RawMessage
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("body = %s, want keys %v", data, tt.want)
+			}
+			for key, want := range tt.want {
+				if string(got[key]) != want {
+					t.Errorf("%s = %s, want %s", key, got[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPtr(t *testing.T) {
+	p := Ptr("title")
+	if p == nil || *p != "title" {
+		t.Errorf("Ptr(\"title\") = %v, want pointer to \"title\"", p)
+	}
+}
+
+func TestDocument_UnmarshalMetadata(t *testing.T) {
+	data := []byte(`{
+		"id": 1,
+		"title": "Invoice",
+		"correspondent": 4,
+		"document_type": 2,
+		"storage_path": null,
+		"owner": 3,
+		"page_count": 5,
+		"archived_file_name": "2024-01-15 Invoice.pdf",
+		"custom_fields": [{"field": 1, "value": "12.50"}, {"field": 2, "value": null}],
+		"notes": [
+			{"id": 10, "note": "paid", "created": "2024-01-15T10:30:45Z", "user": {"id": 3, "username": "alex"}},
+			{"id": 11, "note": "legacy", "created": "2023-12-01T08:00:00Z", "user": 7}
+		]
+	}`)
+
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if doc.Correspondent == nil || *doc.Correspondent != 4 {
+		t.Errorf("Correspondent = %v, want 4", doc.Correspondent)
+	}
+	if doc.DocumentType == nil || *doc.DocumentType != 2 {
+		t.Errorf("DocumentType = %v, want 2", doc.DocumentType)
+	}
+	if doc.StoragePath != nil {
+		t.Errorf("StoragePath = %v, want nil", *doc.StoragePath)
+	}
+	if doc.Owner == nil || *doc.Owner != 3 {
+		t.Errorf("Owner = %v, want 3", doc.Owner)
+	}
+	if doc.PageCount == nil || *doc.PageCount != 5 {
+		t.Errorf("PageCount = %v, want 5", doc.PageCount)
+	}
+	if doc.ArchivedFileName != "2024-01-15 Invoice.pdf" {
+		t.Errorf("ArchivedFileName = %q", doc.ArchivedFileName)
+	}
+	if len(doc.CustomFields) != 2 || doc.CustomFields[0] != (CustomFieldInstance{Field: 1, Value: "12.50"}) || doc.CustomFields[1].Value != nil {
+		t.Errorf("CustomFields = %+v", doc.CustomFields)
+	}
+
+	if len(doc.Notes) != 2 {
+		t.Fatalf("len(Notes) = %d, want 2", len(doc.Notes))
+	}
+	if doc.Notes[0].Note != "paid" || doc.Notes[0].User == nil || doc.Notes[0].User.Username != "alex" {
+		t.Errorf("Notes[0] = %+v, want note from alex", doc.Notes[0])
+	}
+	if doc.Notes[1].User == nil || doc.Notes[1].User.ID != 7 {
+		t.Errorf("Notes[1].User = %+v, want ID 7", doc.Notes[1].User)
+	}
+}
+
+func TestNoteUser_UnmarshalJSON_Invalid(t *testing.T) {
+	var u NoteUser
+	if err := json.Unmarshal([]byte(`"alex"`), &u); err == nil {
+		t.Error("expected error for string user")
+	}
+}
+
+func TestDocument_UnmarshalSearchHit(t *testing.T) {
+	data := []byte(`{
+		"id": 1,
+		"title": "Invoice",
+		"__search_hit__": {
+			"score": 0.75,
+			"highlights": "overdue <span class=\"match\">invoice</span>",
+			"note_highlights": "",
+			"rank": 2
+		}
+	}`)
+
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if doc.SearchHit == nil {
+		t.Fatal("SearchHit = nil, want hit")
+	}
+	if doc.SearchHit.Score != 0.75 {
+		t.Errorf("Score = %v, want 0.75", doc.SearchHit.Score)
+	}
+	if doc.SearchHit.Rank != 2 {
+		t.Errorf("Rank = %d, want 2", doc.SearchHit.Rank)
+	}
+	if doc.SearchHit.Highlights != `overdue <span class="match">invoice</span>` {
+		t.Errorf("Highlights = %q", doc.SearchHit.Highlights)
+	}
+
+	var plain Document
+	if err := json.Unmarshal([]byte(`{"id": 2, "title": "Receipt"}`), &plain); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plain.SearchHit != nil {
+		t.Errorf("SearchHit = %+v, want nil without search", plain.SearchHit)
+	}
+}
+
+func TestDocument_LargeArchiveSerialNumber(t *testing.T) {
+	data := []byte(`{"id": 1, "archive_serial_number": 4294967295}`)
+
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.ArchiveSerialNumber == nil || *doc.ArchiveSerialNumber != 4294967295 {
+		t.Errorf("ArchiveSerialNumber = %v, want 4294967295", doc.ArchiveSerialNumber)
+	}
+
+	out, err := json.Marshal(DocumentUpdate{ArchiveSerialNumber: doc.ArchiveSerialNumber})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != `{"archive_serial_number":4294967295}` {
+		t.Errorf("Marshal = %s", out)
+	}
+}
+
+func TestDocument_CreatedDay(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"date", `{"created": "2024-01-15"}`, "2024-01-15"},
+		// The date in the server's time zone, not in UTC
+		{"timestamp", `{"created": "2024-01-15T00:30:00+01:00", "created_date": "2024-01-15"}`, "2024-01-15"},
+		{"created_date only", `{"created_date": "2024-01-15"}`, "2024-01-15"},
+		{"neither", `{}`, "0001-01-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc Document
+			if err := json.Unmarshal([]byte(tt.json), &doc); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			day := doc.CreatedDay()
+			if day.String() != tt.want || !day.IsDateOnly() {
+				t.Errorf("CreatedDay() = %v, want %s at midnight UTC", day.Time(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package paperless
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const postDocumentAPIPath = documentsAPIPath + "post_document/"
+
+// DocumentUpload holds optional metadata for UploadDocument. Zero values are
+// not sent, so Paperless applies its own defaults and matching rules.
+type DocumentUpload struct {
+	Title         string
+	Created       time.Time
+	Correspondent int
+	DocumentType  int
+	StoragePath   int
+	Tags          []int
+	ASN           int64
+}
+
+// UploadDocument uploads a file for consumption and returns the ID of the
+// consumption task. Paperless processes the file asynchronously, so the
+// document does not exist yet when UploadDocument returns.
+//
+// The file is read into memory so the request can be retried.
+func (c *Client) UploadDocument(ctx context.Context, filename string, r io.Reader, opts *DocumentUpload) (string, error) {
+	ctx = withOperation(ctx, "UploadDocument", ResourceDocuments)
+	if filename == "" {
+		return "", fmt.Errorf("UploadDocument: filename cannot be empty")
+	}
+
+	body, err := uploadBody(filename, r, opts)
+	if err != nil {
+		return "", fmt.Errorf("UploadDocument: %w", err)
+	}
+
+	var taskID string
+	if err := c.doRequest(ctx, "POST", postDocumentAPIPath, body, &taskID); err != nil {
+		return "", wrapError(err, "UploadDocument")
+	}
+
+	return taskID, nil
+}
+
+// uploadBody encodes a post_document multipart form.
+func uploadBody(filename string, r io.Reader, opts *DocumentUpload) (*rawBody, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	part, err := mw.CreateFormFile("document", filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	for name, values := range uploadFields(opts) {
+		for _, value := range values {
+			if err := mw.WriteField(name, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return &rawBody{contentType: mw.FormDataContentType(), data: buf.Bytes()}, nil
+}
+
+// uploadFields returns the form fields for the set options in opts.
+func uploadFields(opts *DocumentUpload) url.Values {
+	fields := url.Values{}
+	if opts == nil {
+		return fields
+	}
+	if opts.Title != "" {
+		fields.Set("title", opts.Title)
+	}
+	if !opts.Created.IsZero() {
+		fields.Set("created", opts.Created.Format(time.RFC3339))
+	}
+	if opts.Correspondent > 0 {
+		fields.Set("correspondent", strconv.Itoa(opts.Correspondent))
+	}
+	if opts.DocumentType > 0 {
+		fields.Set("document_type", strconv.Itoa(opts.DocumentType))
+	}
+	if opts.StoragePath > 0 {
+		fields.Set("storage_path", strconv.Itoa(opts.StoragePath))
+	}
+	if opts.ASN > 0 {
+		fields.Set("archive_serial_number", strconv.FormatInt(opts.ASN, 10))
+	}
+	for _, id := range opts.Tags {
+		fields.Add("tags", strconv.Itoa(id))
+	}
+	return fields
+}
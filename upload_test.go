@@ -0,0 +1,112 @@
+package paperless
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_UploadDocument(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "POST" {
+				t.Errorf("method = %v, want POST", r.Method)
+			}
+			if r.URL.Path != "/api/documents/post_document/" {
+				t.Errorf("path = %v, want /api/documents/post_document/", r.URL.Path)
+			}
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Fatalf("ParseMultipartForm failed: %v", err)
+			}
+			file, header, err := r.FormFile("document")
+			if err != nil {
+				t.Fatalf("FormFile failed: %v", err)
+			}
+			content, _ := io.ReadAll(file)
+			if header.Filename != "scan.pdf" || string(content) != "%PDF-1.4" {
+				t.Errorf("file = %q %q, want scan.pdf %%PDF-1.4", header.Filename, content)
+			}
+			if got := r.FormValue("title"); got != "Scan" {
+				t.Errorf("title = %q, want Scan", got)
+			}
+			if got := r.FormValue("created"); got != "2024-03-01T00:00:00Z" {
+				t.Errorf("created = %q, want 2024-03-01T00:00:00Z", got)
+			}
+			if got := r.MultipartForm.Value["tags"]; !reflect.DeepEqual(got, []string{"1", "7"}) {
+				t.Errorf("tags = %v, want [1 7]", got)
+			}
+			if _, ok := r.MultipartForm.Value["correspondent"]; ok {
+				t.Error("unset correspondent was sent")
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`"a8b4c0de-1111-2222-3333-444455556666"`))
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		taskID, err := c.UploadDocument(context.Background(), "scan.pdf", strings.NewReader("%PDF-1.4"), &DocumentUpload{
+			Title:   "Scan",
+			Created: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			Tags:    []int{1, 7},
+		})
+		if err != nil {
+			t.Fatalf("UploadDocument failed: %v", err)
+		}
+		if taskID != "a8b4c0de-1111-2222-3333-444455556666" {
+			t.Errorf("task ID = %q", taskID)
+		}
+	})
+
+	t.Run("retried with the same body", func(t *testing.T) {
+		fastRetries(t)
+		var bodies []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			if len(bodies) == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			_, _ = w.Write([]byte(`"task"`))
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token", WithRetries(2))
+		if _, err := c.UploadDocument(context.Background(), "a.txt", strings.NewReader("hello"), nil); err != nil {
+			t.Fatalf("UploadDocument failed: %v", err)
+		}
+		if len(bodies) != 2 || bodies[0] != bodies[1] || !strings.Contains(bodies[1], "hello") {
+			t.Errorf("bodies = %q, want two identical uploads", bodies)
+		}
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"document":["File type not supported"]}`))
+		}))
+		defer server.Close()
+
+		c := NewClient(server.URL, "test-token")
+		_, err := c.UploadDocument(context.Background(), "a.exe", strings.NewReader("MZ"), nil)
+		apiErr, ok := err.(*Error)
+		if !ok {
+			t.Fatalf("expected *Error, got %T", err)
+		}
+		if apiErr.Op != "UploadDocument" || apiErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("error = %+v, want UploadDocument 400", apiErr)
+		}
+	})
+
+	t.Run("empty filename", func(t *testing.T) {
+		c := NewClient("http://paperless.invalid", "test-token")
+		if _, err := c.UploadDocument(context.Background(), "", strings.NewReader("x"), nil); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+}
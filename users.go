@@ -0,0 +1,40 @@
+package paperless
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListUsers retrieves users. Listing users requires the view_user
+// permission.
+func (c *Client) ListUsers(ctx context.Context, opts *ListOptions) (*UserList, error) {
+	ctx = withOperation(ctx, "ListUsers", ResourceUsers)
+	fullURL, err := c.buildURL(usersAPIPath, opts)
+	if err != nil {
+		return nil, fmt.Errorf("build URL: %w", err)
+	}
+
+	var result UserList
+	if err := c.doRequestWithURL(ctx, "GET", fullURL, nil, &result); err != nil {
+		return nil, wrapError(err, "ListUsers")
+	}
+
+	return &result, nil
+}
+
+// ListGroups retrieves user groups. Listing groups requires the view_group
+// permission.
+func (c *Client) ListGroups(ctx context.Context, opts *ListOptions) (*GroupList, error) {
+	ctx = withOperation(ctx, "ListGroups", ResourceGroups)
+	fullURL, err := c.buildURL(groupsAPIPath, opts)
+	if err != nil {
+		return nil, fmt.Errorf("build URL: %w", err)
+	}
+
+	var result GroupList
+	if err := c.doRequestWithURL(ctx, "GET", fullURL, nil, &result); err != nil {
+		return nil, wrapError(err, "ListGroups")
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,61 @@
+package paperless
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_ListUsers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/users/" {
+			t.Errorf("path = %v, want /api/users/", r.URL.Path)
+		}
+		if r.URL.Query().Get("page") != "2" {
+			t.Errorf("page = %v, want 2", r.URL.Query().Get("page"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"count": 1, "results": [{"id": 3, "username": "alice", "first_name": "Alice", "is_superuser": true}]}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	list, err := c.ListUsers(context.Background(), &ListOptions{Page: 2})
+	if err != nil {
+		t.Fatalf("ListUsers failed: %v", err)
+	}
+	want := User{ID: 3, Username: "alice", FirstName: "Alice", IsSuperuser: true}
+	if len(list.Results) != 1 || list.Results[0] != want {
+		t.Errorf("results = %+v, want %+v", list.Results, want)
+	}
+}
+
+func TestClient_ListGroups(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/groups/" {
+			t.Errorf("path = %v, want /api/groups/", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(GroupList{Count: 1, Results: []Group{{ID: 1, Name: "family"}}})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "test-token")
+	list, err := c.ListGroups(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListGroups failed: %v", err)
+	}
+	if len(list.Results) != 1 || list.Results[0].Name != "family" {
+		t.Errorf("results = %+v, want family", list.Results)
+	}
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	_, err = c.ListGroups(context.Background(), nil)
+	if !IsForbidden(err) {
+		t.Errorf("expected forbidden error, got %v", err)
+	}
+}